@@ -22,6 +22,23 @@ type Config struct {
 	SupportEmail                 string `mapstructure:"support_email"`
 	ArchiveLink                  string `mapstructure:"archive_link"`
 
+	// code of country pack (phone numbers, holidays, default subjects, sms), local phone numbers are of this country
+	DefaultCountry string `mapstructure:"default_country"`
+	// json file of country pack which is not built in, see models.CountryPackLoad
+	CountryPackFile string `mapstructure:"country_pack_file"`
+	// timezone of deployment, used by schedules and schools without own timezone
+	Timezone string `mapstructure:"timezone"`
+
+	// roles which must login with two factor (totp), comma separated
+	TwoFactorRoles      []string `mapstructure:"two_factor_roles"`
+	LoginMaxAttempts    int      `mapstructure:"login_max_attempts"`
+	LoginLockoutMinutes int      `mapstructure:"login_lockout_minutes"`
+
+	// api urls of archive servers of past years, comma separated, diary and journal of their periods are proxied
+	ArchiveServers []string `mapstructure:"archive_servers"`
+	// shared between live and archive servers to read snapshot manifests
+	ArchiveToken string `mapstructure:"archive_token"`
+
 	DbConnection string `mapstructure:"db_connection"`
 	DbHost       string `mapstructure:"db_host"`
 	DbPort       string `mapstructure:"db_port"`
@@ -43,6 +60,34 @@ type Config struct {
 
 	SentryDsn string `mapstructure:"sentry_dsn"`
 
+	StorageDriver      string `mapstructure:"storage_driver"`
+	StorageLocalPath   string `mapstructure:"storage_local_path"`
+	StorageSignKey     string `mapstructure:"storage_sign_key"`
+	StorageS3Endpoint  string `mapstructure:"storage_s3_endpoint"`
+	StorageS3Region    string `mapstructure:"storage_s3_region"`
+	StorageS3Bucket    string `mapstructure:"storage_s3_bucket"`
+	StorageS3AccessKey string `mapstructure:"storage_s3_access_key"`
+	StorageS3SecretKey string `mapstructure:"storage_s3_secret_key"`
+	StorageS3UseSSL    bool   `mapstructure:"storage_s3_use_ssl"`
+	UploadMaxSizeMb    int64  `mapstructure:"upload_max_size_mb"`
+	ClamdAddress       string `mapstructure:"clamd_address"`
+
+	PushDriver         string `mapstructure:"push_driver"`
+	FcmCredentialsFile string `mapstructure:"fcm_credentials_file"`
+
+	MailDriver      string `mapstructure:"mail_driver"`
+	MailHost        string `mapstructure:"mail_host"`
+	MailPort        string `mapstructure:"mail_port"`
+	MailUsername    string `mapstructure:"mail_username"`
+	MailPassword    string `mapstructure:"mail_password"`
+	MailEncryption  string `mapstructure:"mail_encryption"`
+	MailFromAddress string `mapstructure:"mail_from_address"`
+	MailFromName    string `mapstructure:"mail_from_name"`
+	MailLang        string `mapstructure:"mail_lang"`
+
+	MetricsEnabled bool   `mapstructure:"metrics_enabled"`
+	MetricsToken   string `mapstructure:"metrics_token"`
+
 	SettingLoginAlert *string `mapstructure:"setting_login_alert"`
 	AppEnvIsProd      bool
 	DevPhones         []string `mapstructure:"phones"`
@@ -51,6 +96,18 @@ type Config struct {
 const APP_ENV_PROD = "prod"
 const APP_ENV_DEV = "dev"
 
+const STORAGE_DRIVER_LOCAL = "local"
+const STORAGE_DRIVER_S3 = "s3"
+
+const PUSH_DRIVER_FCM = "fcm"
+const PUSH_DRIVER_FAKE = "fake"
+
+const MAIL_DRIVER_SMTP = "smtp"
+const MAIL_DRIVER_FAKE = "fake"
+
+const MAIL_ENCRYPTION_TLS = "tls"
+const MAIL_ENCRYPTION_SSL = "ssl"
+
 var Conf Config
 var RequestLocation *time.Location
 
@@ -78,13 +135,74 @@ func LoadConfig() {
 		Conf.SettingLoginAlert = nil
 	}
 
+	if Conf.StorageDriver == "" {
+		Conf.StorageDriver = STORAGE_DRIVER_LOCAL
+	}
+	if Conf.StorageLocalPath == "" {
+		Conf.StorageLocalPath = "web/uploads"
+	}
+	if Conf.UploadMaxSizeMb == 0 {
+		Conf.UploadMaxSizeMb = 20
+	}
+	if Conf.PushDriver == "" {
+		Conf.PushDriver = PUSH_DRIVER_FCM
+	}
+	if Conf.FcmCredentialsFile == "" {
+		Conf.FcmCredentialsFile = "service_account.json"
+	}
+	if Conf.MailDriver == "" {
+		Conf.MailDriver = MAIL_DRIVER_SMTP
+	}
+	if Conf.MailPort == "" {
+		Conf.MailPort = "25"
+	}
+	if Conf.MailFromAddress == "" {
+		Conf.MailFromAddress = Conf.SupportEmail
+	}
+	if Conf.MailFromName == "" {
+		Conf.MailFromName = "eMekdep"
+	}
+	if Conf.MailLang == "" {
+		Conf.MailLang = "tm"
+	}
+
 	phones := viper.GetString("phones")
 	if phones != "" {
 		Conf.DevPhones = strings.Split(phones, ",")
 	}
+	twoFactorRoles := viper.GetString("two_factor_roles")
+	Conf.TwoFactorRoles = nil
+	if twoFactorRoles != "" {
+		Conf.TwoFactorRoles = strings.Split(twoFactorRoles, ",")
+	}
+	if Conf.LoginMaxAttempts == 0 {
+		Conf.LoginMaxAttempts = 10
+	}
+	if Conf.LoginLockoutMinutes == 0 {
+		Conf.LoginLockoutMinutes = 15
+	}
+	archiveServers := viper.GetString("archive_servers")
+	Conf.ArchiveServers = nil
+	if archiveServers != "" {
+		for _, v := range strings.Split(archiveServers, ",") {
+			Conf.ArchiveServers = append(Conf.ArchiveServers, strings.TrimRight(strings.TrimSpace(v), "/"))
+		}
+	}
+
+	if Conf.DefaultCountry == "" {
+		Conf.DefaultCountry = "TM"
+	}
+	Conf.DefaultCountry = strings.ToUpper(Conf.DefaultCountry)
+	if Conf.Timezone == "" {
+		Conf.Timezone = "Asia/Ashgabat"
+	}
 
 	// // init the loc
-	RequestLocation, _ = time.LoadLocation("Asia/Ashgabat")
+	var err error
+	RequestLocation, err = time.LoadLocation(Conf.Timezone)
+	if err != nil {
+		log.Fatalln(err)
+	}
 	UTCLoc, _ := time.LoadLocation("UTC")
 	time.Local = UTCLoc
 	// // set timezone,
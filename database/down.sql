@@ -1,3 +1,24 @@
+\i database/migrations/0055_user_merges_skipped.down.sql
+\i database/migrations/0054_countries.down.sql
+\i database/migrations/0053_user_data_requests.down.sql
+\i database/migrations/0052_user_merges.down.sql
+\i database/migrations/0051_user_imports.down.sql
+\i database/migrations/0050_archive_snapshots.down.sql
+\i database/migrations/0049_school_rollovers.down.sql
+\i database/migrations/0048_user_security.down.sql
+\i database/migrations/0047_roles_permissions.down.sql
+\i database/migrations/0046_assignment_submissions.down.sql
+\i database/migrations/0045_school_transfers_workflow.down.sql
+\i database/migrations/0044_contact_items_payload.down.sql
+\i database/migrations/0043_contact_helpdesk.down.sql
+\i database/migrations/0042_report_items_status.down.sql
+\i database/migrations/0041_reports_formula.down.sql
+\i database/migrations/0040_email.down.sql
+\i database/migrations/0039_notifications_schedule.down.sql
+\i database/migrations/0038_notification_preferences.down.sql
+\i database/migrations/0037_push_deliveries.down.sql
+\i database/migrations/0036_curriculum_plans.down.sql
+\i database/migrations/0035_book_pages.down.sql
 \i database/migrations/0006_messages.down.sql
 \i database/migrations/0005_payments.down.sql
 \i database/migrations/0004_lessons.down.sql
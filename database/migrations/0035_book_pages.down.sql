@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS book_pages;
+ALTER TABLE books DROP COLUMN IF EXISTS process_status;
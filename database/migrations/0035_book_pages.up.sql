@@ -0,0 +1,16 @@
+ALTER TABLE books ADD COLUMN process_status varchar(32) DEFAULT NULL;
+
+CREATE TABLE book_pages (
+   uid uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+   book_uid uuid NOT NULL REFERENCES books ON DELETE CASCADE,
+   page int NOT NULL,
+   thumbnail varchar(255) DEFAULT NULL,
+   content text DEFAULT NULL,
+   content_tsv tsvector GENERATED ALWAYS AS (to_tsvector('simple', coalesce(content, ''))) STORED,
+   created_at timestamp DEFAULT CURRENT_TIMESTAMP,
+   UNIQUE (book_uid, page)
+);
+CREATE INDEX book_pages_content_tsv_idx ON book_pages USING GIN (content_tsv);
+
+-- existing books are processed by background worker
+UPDATE books SET process_status = 'pending' WHERE file ILIKE '%.pdf';
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS lesson_topics;
+DROP TABLE IF EXISTS curriculum_plans;
@@ -0,0 +1,17 @@
+CREATE TABLE curriculum_plans (
+   uid uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+   base_subject_uid uuid NOT NULL REFERENCES base_subjects(uid) ON DELETE CASCADE,
+   classyear varchar(255) NOT NULL,
+   topic_uids uuid[] NOT NULL DEFAULT '{}',
+   updated_at timestamp DEFAULT CURRENT_TIMESTAMP,
+   created_at timestamp DEFAULT CURRENT_TIMESTAMP,
+   UNIQUE (base_subject_uid, classyear)
+);
+
+-- topics filled into lessons by plan, title is kept to not overwrite teacher edits
+CREATE TABLE lesson_topics (
+   lesson_uid uuid PRIMARY KEY REFERENCES lessons(uid) ON DELETE CASCADE,
+   topic_uid uuid NOT NULL REFERENCES topics(uid) ON DELETE CASCADE,
+   title varchar(255) DEFAULT NULL
+);
+CREATE INDEX lesson_topics_topic_uid_idx ON lesson_topics (topic_uid);
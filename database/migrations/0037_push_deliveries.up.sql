@@ -0,0 +1,19 @@
+CREATE TABLE push_deliveries (
+   uid uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+   user_notification_uid uuid DEFAULT NULL REFERENCES user_notifications(uid) ON DELETE CASCADE,
+   user_uid uuid NOT NULL REFERENCES users(uid) ON DELETE CASCADE,
+   device_token varchar(500) NOT NULL,
+   title varchar(255) NOT NULL,
+   body text DEFAULT NULL,
+   data jsonb DEFAULT NULL,
+   status varchar(32) NOT NULL DEFAULT 'pending',
+   attempts int NOT NULL DEFAULT 0,
+   error text DEFAULT NULL,
+   message_id varchar(255) DEFAULT NULL,
+   next_retry_at timestamp DEFAULT NULL,
+   sent_at timestamp DEFAULT NULL,
+   updated_at timestamp DEFAULT CURRENT_TIMESTAMP,
+   created_at timestamp DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX push_deliveries_user_notification_uid_idx ON push_deliveries (user_notification_uid);
+CREATE INDEX push_deliveries_retry_idx ON push_deliveries (next_retry_at) WHERE status = 'retry';
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS sms_sender_send_at_idx;
+ALTER TABLE sms_sender DROP COLUMN IF EXISTS send_at;
+DROP TABLE IF EXISTS notification_preferences;
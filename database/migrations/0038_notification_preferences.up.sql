@@ -0,0 +1,16 @@
+CREATE TABLE notification_preferences (
+   uid uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+   user_uid uuid DEFAULT NULL REFERENCES users(uid) ON DELETE CASCADE,
+   role varchar(32) DEFAULT NULL,
+   category varchar(32) NOT NULL,
+   channel varchar(16) NOT NULL,
+   quiet_start varchar(5) DEFAULT NULL,
+   quiet_end varchar(5) DEFAULT NULL,
+   updated_at timestamp DEFAULT CURRENT_TIMESTAMP,
+   created_at timestamp DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX notification_preferences_user_category_idx ON notification_preferences (user_uid, category) WHERE user_uid IS NOT NULL;
+CREATE UNIQUE INDEX notification_preferences_role_category_idx ON notification_preferences (role, category) WHERE user_uid IS NULL;
+
+ALTER TABLE sms_sender ADD COLUMN send_at timestamp DEFAULT NULL;
+CREATE INDEX sms_sender_send_at_idx ON sms_sender (send_at) WHERE is_completed = false;
@@ -0,0 +1,12 @@
+DROP INDEX IF EXISTS user_notifications_notification_uid_idx;
+ALTER TABLE user_notifications DROP COLUMN IF EXISTS opened_at;
+ALTER TABLE user_notifications DROP COLUMN IF EXISTS school_uid;
+
+DROP INDEX IF EXISTS notifications_scheduled_idx;
+ALTER TABLE notifications DROP COLUMN IF EXISTS tariff_types;
+ALTER TABLE notifications DROP COLUMN IF EXISTS regions;
+ALTER TABLE notifications DROP COLUMN IF EXISTS classyears;
+ALTER TABLE notifications DROP COLUMN IF EXISTS classroom_uids;
+ALTER TABLE notifications DROP COLUMN IF EXISTS sent_at;
+ALTER TABLE notifications DROP COLUMN IF EXISTS expires_at;
+ALTER TABLE notifications DROP COLUMN IF EXISTS send_at;
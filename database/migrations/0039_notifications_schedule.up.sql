@@ -0,0 +1,13 @@
+ALTER TABLE notifications ADD COLUMN send_at timestamp DEFAULT NULL;
+ALTER TABLE notifications ADD COLUMN expires_at timestamp DEFAULT NULL;
+ALTER TABLE notifications ADD COLUMN sent_at timestamp DEFAULT NULL;
+ALTER TABLE notifications ADD COLUMN classroom_uids uuid[] NOT NULL DEFAULT '{}';
+ALTER TABLE notifications ADD COLUMN classyears varchar(8)[] NOT NULL DEFAULT '{}';
+ALTER TABLE notifications ADD COLUMN regions varchar(32)[] NOT NULL DEFAULT '{}';
+ALTER TABLE notifications ADD COLUMN tariff_types varchar(255)[] NOT NULL DEFAULT '{}';
+UPDATE notifications SET sent_at = created_at;
+CREATE INDEX notifications_scheduled_idx ON notifications (send_at) WHERE sent_at IS NULL;
+
+ALTER TABLE user_notifications ADD COLUMN school_uid uuid DEFAULT NULL REFERENCES schools(uid) ON DELETE SET NULL;
+ALTER TABLE user_notifications ADD COLUMN opened_at timestamp DEFAULT NULL;
+CREATE INDEX user_notifications_notification_uid_idx ON user_notifications (notification_uid);
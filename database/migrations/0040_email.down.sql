@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS reports_deadline_at_idx;
+ALTER TABLE reports DROP COLUMN IF EXISTS deadline_at;
+
+DROP TABLE IF EXISTS email_tokens;
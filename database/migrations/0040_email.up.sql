@@ -0,0 +1,15 @@
+CREATE TABLE email_tokens (
+   uid uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+   user_uid uuid NOT NULL REFERENCES users(uid) ON DELETE CASCADE,
+   type varchar(32) NOT NULL,
+   email varchar(255) NOT NULL,
+   token_hash varchar(64) NOT NULL,
+   expires_at timestamp NOT NULL,
+   used_at timestamp DEFAULT NULL,
+   created_at timestamp DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX email_tokens_token_hash_idx ON email_tokens (token_hash);
+CREATE INDEX email_tokens_user_uid_idx ON email_tokens (user_uid, type);
+
+ALTER TABLE reports ADD COLUMN deadline_at timestamp DEFAULT NULL;
+CREATE INDEX reports_deadline_at_idx ON reports (deadline_at) WHERE deadline_at IS NOT NULL;
@@ -0,0 +1,2 @@
+ALTER TABLE reports DROP COLUMN IF EXISTS end_date;
+ALTER TABLE reports DROP COLUMN IF EXISTS start_date;
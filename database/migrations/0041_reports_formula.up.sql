@@ -0,0 +1,2 @@
+ALTER TABLE reports ADD COLUMN start_date date DEFAULT NULL;
+ALTER TABLE reports ADD COLUMN end_date date DEFAULT NULL;
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS report_items_report_status_idx;
+ALTER TABLE report_items DROP COLUMN IF EXISTS review_comment;
+ALTER TABLE report_items DROP COLUMN IF EXISTS reviewed_at;
+ALTER TABLE report_items DROP COLUMN IF EXISTS reviewed_by;
+ALTER TABLE report_items DROP COLUMN IF EXISTS submitted_at;
+ALTER TABLE report_items DROP COLUMN IF EXISTS status;
@@ -0,0 +1,8 @@
+ALTER TABLE report_items ADD COLUMN status varchar(20) NOT NULL DEFAULT 'draft';
+ALTER TABLE report_items ADD COLUMN submitted_at timestamp DEFAULT NULL;
+ALTER TABLE report_items ADD COLUMN reviewed_by uuid DEFAULT NULL REFERENCES users(uid) ON DELETE SET NULL;
+ALTER TABLE report_items ADD COLUMN reviewed_at timestamp DEFAULT NULL;
+ALTER TABLE report_items ADD COLUMN review_comment text DEFAULT NULL;
+-- forms filled before statuses are counted as submitted
+UPDATE report_items SET status='submitted', submitted_at=updated_at WHERE cardinality(values) > 0;
+CREATE INDEX report_items_report_status_idx ON report_items (report_uid, status);
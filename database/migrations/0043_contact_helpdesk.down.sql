@@ -0,0 +1,10 @@
+DROP TABLE IF EXISTS contact_replies;
+
+DROP INDEX IF EXISTS contact_items_due_at_idx;
+DROP INDEX IF EXISTS contact_items_assignee_uid_idx;
+ALTER TABLE contact_items DROP COLUMN IF EXISTS escalated_at;
+ALTER TABLE contact_items DROP COLUMN IF EXISTS due_at;
+ALTER TABLE contact_items DROP COLUMN IF EXISTS resolved_at;
+ALTER TABLE contact_items DROP COLUMN IF EXISTS first_response_at;
+ALTER TABLE contact_items DROP COLUMN IF EXISTS assigned_at;
+ALTER TABLE contact_items DROP COLUMN IF EXISTS assignee_uid;
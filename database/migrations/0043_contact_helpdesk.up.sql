@@ -0,0 +1,21 @@
+ALTER TABLE contact_items ADD COLUMN assignee_uid uuid DEFAULT NULL REFERENCES users(uid) ON DELETE SET NULL;
+ALTER TABLE contact_items ADD COLUMN assigned_at timestamp DEFAULT NULL;
+ALTER TABLE contact_items ADD COLUMN first_response_at timestamp DEFAULT NULL;
+ALTER TABLE contact_items ADD COLUMN resolved_at timestamp DEFAULT NULL;
+ALTER TABLE contact_items ADD COLUMN due_at timestamp DEFAULT NULL;
+ALTER TABLE contact_items ADD COLUMN escalated_at timestamp DEFAULT NULL;
+CREATE INDEX contact_items_assignee_uid_idx ON contact_items (assignee_uid);
+CREATE INDEX contact_items_due_at_idx ON contact_items (due_at) WHERE resolved_at IS NULL AND escalated_at IS NULL;
+
+-- thread of ticket: replies of operators and submitter, status changes (status not null)
+CREATE TABLE contact_replies (
+   uid uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+   contact_item_uid uuid NOT NULL REFERENCES contact_items(uid) ON DELETE CASCADE,
+   user_uid uuid DEFAULT NULL REFERENCES users(uid) ON DELETE SET NULL,
+   message text DEFAULT NULL,
+   status varchar(20) DEFAULT NULL,
+   files varchar[] DEFAULT NULL,
+   is_internal boolean NOT NULL DEFAULT false,
+   created_at timestamp DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX contact_replies_contact_item_uid_idx ON contact_replies (contact_item_uid, created_at);
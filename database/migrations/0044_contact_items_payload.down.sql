@@ -0,0 +1 @@
+ALTER TABLE contact_items DROP COLUMN IF EXISTS payload;
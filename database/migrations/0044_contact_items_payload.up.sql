@@ -0,0 +1,2 @@
+-- structured payload of data_complaint, free text message is kept for old clients
+ALTER TABLE contact_items ADD COLUMN payload jsonb DEFAULT NULL;
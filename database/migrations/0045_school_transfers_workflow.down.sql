@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS school_transfers_status_expires_at_idx;
+DROP INDEX IF EXISTS school_transfers_student_uid_idx;
+ALTER TABLE school_transfers DROP COLUMN IF EXISTS expires_at;
@@ -0,0 +1,5 @@
+-- waiting transfers are expired by job after expires_at
+ALTER TABLE school_transfers ADD COLUMN expires_at timestamp DEFAULT (CURRENT_TIMESTAMP + interval '14 days');
+UPDATE school_transfers SET expires_at = created_at + interval '14 days';
+CREATE INDEX school_transfers_student_uid_idx ON school_transfers (student_uid);
+CREATE INDEX school_transfers_status_expires_at_idx ON school_transfers (status, expires_at);
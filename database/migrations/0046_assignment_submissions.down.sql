@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS assignment_submissions;
+ALTER TABLE lessons DROP COLUMN IF EXISTS assignment_due_at;
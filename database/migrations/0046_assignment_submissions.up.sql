@@ -0,0 +1,23 @@
+ALTER TABLE lessons ADD COLUMN assignment_due_at timestamp DEFAULT NULL;
+
+-- homework handed in by student (or parent), one row per lesson and student, resubmission updates it
+CREATE TABLE assignment_submissions (
+   uid uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+   lesson_uid uuid NOT NULL REFERENCES lessons(uid) ON DELETE CASCADE,
+   student_uid uuid NOT NULL REFERENCES users(uid) ON DELETE CASCADE,
+   submitted_by_uid uuid DEFAULT NULL REFERENCES users(uid) ON DELETE SET NULL,
+   content text DEFAULT NULL,
+   files varchar[] DEFAULT NULL,
+   status varchar(20) NOT NULL DEFAULT 'submitted',
+   is_late boolean NOT NULL DEFAULT false,
+   attempt int NOT NULL DEFAULT 1,
+   grade_value int DEFAULT NULL,
+   feedback text DEFAULT NULL,
+   graded_by_uid uuid DEFAULT NULL REFERENCES users(uid) ON DELETE SET NULL,
+   graded_at timestamp DEFAULT NULL,
+   submitted_at timestamp DEFAULT CURRENT_TIMESTAMP,
+   updated_at timestamp DEFAULT CURRENT_TIMESTAMP,
+   created_at timestamp DEFAULT CURRENT_TIMESTAMP,
+   UNIQUE (lesson_uid, student_uid)
+);
+CREATE INDEX assignment_submissions_student_uid_idx ON assignment_submissions (student_uid);
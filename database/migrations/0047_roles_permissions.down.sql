@@ -0,0 +1,3 @@
+ALTER TABLE user_schools DROP COLUMN IF EXISTS custom_role_code;
+DROP TABLE IF EXISTS role_permissions;
+DROP TABLE IF EXISTS roles;
@@ -0,0 +1,23 @@
+-- roles with permission grants, system roles and their grants are seeded by app from compiled defaults
+CREATE TABLE roles (
+   code varchar(50) PRIMARY KEY,
+   name varchar(255) NOT NULL,
+   base_role varchar(20) NOT NULL,
+   is_system boolean NOT NULL DEFAULT false,
+   updated_at timestamp DEFAULT CURRENT_TIMESTAMP,
+   created_at timestamp DEFAULT CURRENT_TIMESTAMP
+);
+
+-- grant of role: read, write or none (removed), rows with school_uid override global rows for school
+CREATE TABLE role_permissions (
+   uid uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+   role_code varchar(50) NOT NULL REFERENCES roles(code) ON DELETE CASCADE,
+   school_uid uuid DEFAULT NULL REFERENCES schools(uid) ON DELETE CASCADE,
+   permission varchar(50) NOT NULL,
+   access varchar(10) NOT NULL,
+   updated_at timestamp DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX role_permissions_role_school_permission_idx ON role_permissions (role_code, (COALESCE(school_uid, '00000000-0000-0000-0000-000000000000'::uuid)), permission);
+
+-- custom role of user in school, role_code stays base role of custom role
+ALTER TABLE user_schools ADD COLUMN custom_role_code varchar(50) DEFAULT NULL REFERENCES roles(code) ON DELETE SET NULL;
@@ -0,0 +1,26 @@
+-- two factor (totp) and lockout state of user, recovery codes are bcrypt hashes
+CREATE TABLE user_security (
+   user_uid uuid PRIMARY KEY REFERENCES users(uid) ON DELETE CASCADE,
+   totp_secret varchar(64) DEFAULT NULL,
+   totp_enabled_at timestamp DEFAULT NULL,
+   totp_last_counter bigint NOT NULL DEFAULT 0,
+   recovery_codes varchar[] DEFAULT NULL,
+   failed_count int NOT NULL DEFAULT 0,
+   locked_until timestamp DEFAULT NULL,
+   updated_at timestamp DEFAULT CURRENT_TIMESTAMP
+);
+
+-- every login attempt, user_uid is null when login is not found
+CREATE TABLE login_attempts (
+   uid uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+   user_uid uuid DEFAULT NULL REFERENCES users(uid) ON DELETE CASCADE,
+   login varchar(255) NOT NULL,
+   ip varchar(64) DEFAULT NULL,
+   agent text DEFAULT NULL,
+   device varchar(255) DEFAULT NULL,
+   is_success boolean NOT NULL DEFAULT false,
+   reason varchar(50) DEFAULT NULL,
+   created_at timestamp DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX login_attempts_user_uid_idx ON login_attempts (user_uid, created_at);
+CREATE INDEX login_attempts_login_idx ON login_attempts (login, created_at);
@@ -0,0 +1,11 @@
+-- year-end rollover of school: next period and successor of each classroom (students, teacher, sub groups, subjects)
+CREATE TABLE school_rollovers (
+   uid uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+   school_uid uuid NOT NULL REFERENCES schools(uid) ON DELETE CASCADE,
+   from_period_uid uuid DEFAULT NULL REFERENCES periods(uid) ON DELETE SET NULL,
+   to_period_uid uuid DEFAULT NULL REFERENCES periods(uid) ON DELETE SET NULL,
+   classrooms jsonb NOT NULL DEFAULT '[]',
+   created_by uuid DEFAULT NULL REFERENCES users(uid) ON DELETE SET NULL,
+   created_at timestamp DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX school_rollovers_school_uid_idx ON school_rollovers (school_uid, created_at);
@@ -0,0 +1,11 @@
+-- snapshots of academic years loaded into archive server, live server reads them to proxy past year queries
+CREATE TABLE archive_snapshots (
+   uid uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+   title varchar(50) NOT NULL,
+   period_uids uuid[] NOT NULL DEFAULT '{}',
+   school_uids uuid[] NOT NULL DEFAULT '{}',
+   tables jsonb NOT NULL DEFAULT '{}',
+   api_version varchar(50) DEFAULT NULL,
+   exported_at timestamp DEFAULT NULL,
+   loaded_at timestamp DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,34 @@
+-- bulk import of students with parents or teachers from xlsx/csv: raw rows, column mapping and dry run report
+CREATE TABLE user_imports (
+   uid uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+   school_uid uuid NOT NULL REFERENCES schools(uid) ON DELETE CASCADE,
+   type varchar(30) NOT NULL,
+   file_name varchar(255) DEFAULT NULL,
+   columns jsonb NOT NULL DEFAULT '[]',
+   rows jsonb NOT NULL DEFAULT '[]',
+   mapping jsonb NOT NULL DEFAULT '{}',
+   report jsonb NOT NULL DEFAULT '[]',
+   status varchar(30) NOT NULL DEFAULT 'draft',
+   error text DEFAULT NULL,
+   created_by uuid DEFAULT NULL REFERENCES users(uid) ON DELETE SET NULL,
+   created_at timestamp DEFAULT CURRENT_TIMESTAMP,
+   updated_at timestamp DEFAULT CURRENT_TIMESTAMP,
+   committed_at timestamp DEFAULT NULL,
+   rolled_back_at timestamp DEFAULT NULL
+);
+CREATE INDEX user_imports_school_uid_idx ON user_imports (school_uid, created_at);
+CREATE INDEX user_imports_status_idx ON user_imports (status);
+
+-- what commit of batch added, rollback removes exactly these
+CREATE TABLE user_import_items (
+   uid uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+   import_uid uuid NOT NULL REFERENCES user_imports(uid) ON DELETE CASCADE,
+   kind varchar(30) NOT NULL,
+   user_uid uuid NOT NULL,
+   school_uid uuid DEFAULT NULL,
+   classroom_uid uuid DEFAULT NULL,
+   parent_uid uuid DEFAULT NULL,
+   role_code varchar(30) DEFAULT NULL,
+   created_at timestamp DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX user_import_items_import_uid_idx ON user_import_items (import_uid);
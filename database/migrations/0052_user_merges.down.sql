@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS user_merges;
+DROP TABLE IF EXISTS user_duplicates;
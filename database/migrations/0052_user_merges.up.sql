@@ -0,0 +1,35 @@
+-- pairs of students or parents which look like same person, operators review them
+CREATE TABLE user_duplicates (
+   uid uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+   school_uid uuid NOT NULL REFERENCES schools(uid) ON DELETE CASCADE,
+   user_uid uuid NOT NULL REFERENCES users(uid) ON DELETE CASCADE,
+   other_uid uuid NOT NULL REFERENCES users(uid) ON DELETE CASCADE,
+   role_code varchar(30) NOT NULL,
+   score integer NOT NULL DEFAULT 0,
+   reasons jsonb NOT NULL DEFAULT '[]',
+   status varchar(30) NOT NULL DEFAULT 'pending',
+   reviewed_by uuid DEFAULT NULL REFERENCES users(uid) ON DELETE SET NULL,
+   reviewed_at timestamp DEFAULT NULL,
+   created_at timestamp DEFAULT CURRENT_TIMESTAMP,
+   updated_at timestamp DEFAULT CURRENT_TIMESTAMP
+);
+-- pair is kept ordered: user_uid < other_uid
+ALTER TABLE user_duplicates ADD CONSTRAINT user_duplicates_unique UNIQUE (user_uid, other_uid);
+CREATE INDEX user_duplicates_school_uid_idx ON user_duplicates (school_uid, status);
+
+-- merge of duplicate into survivor, moved keys of rows per table.column are kept for undo
+CREATE TABLE user_merges (
+   uid uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+   school_uid uuid DEFAULT NULL REFERENCES schools(uid) ON DELETE SET NULL,
+   duplicate_pair_uid uuid DEFAULT NULL REFERENCES user_duplicates(uid) ON DELETE SET NULL,
+   survivor_uid uuid NOT NULL REFERENCES users(uid) ON DELETE CASCADE,
+   duplicate_uid uuid NOT NULL REFERENCES users(uid) ON DELETE CASCADE,
+   duplicate_status varchar(255) NOT NULL,
+   moved jsonb NOT NULL DEFAULT '{}',
+   created_by uuid DEFAULT NULL REFERENCES users(uid) ON DELETE SET NULL,
+   created_at timestamp DEFAULT CURRENT_TIMESTAMP,
+   undone_by uuid DEFAULT NULL REFERENCES users(uid) ON DELETE SET NULL,
+   undone_at timestamp DEFAULT NULL
+);
+CREATE INDEX user_merges_school_uid_idx ON user_merges (school_uid, created_at);
+CREATE INDEX user_merges_duplicate_uid_idx ON user_merges (duplicate_uid);
@@ -0,0 +1,19 @@
+-- data subject requests: export of personal data into zip or erasure (anonymisation) approved by admin
+CREATE TABLE user_data_requests (
+   uid uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+   user_uid uuid NOT NULL REFERENCES users(uid) ON DELETE CASCADE,
+   type varchar(30) NOT NULL,
+   status varchar(30) NOT NULL,
+   file varchar(255) DEFAULT NULL,
+   reason text DEFAULT NULL,
+   error text DEFAULT NULL,
+   requested_by uuid DEFAULT NULL REFERENCES users(uid) ON DELETE SET NULL,
+   reviewed_by uuid DEFAULT NULL REFERENCES users(uid) ON DELETE SET NULL,
+   reviewed_at timestamp DEFAULT NULL,
+   expires_at timestamp DEFAULT NULL,
+   created_at timestamp DEFAULT CURRENT_TIMESTAMP,
+   updated_at timestamp DEFAULT CURRENT_TIMESTAMP,
+   done_at timestamp DEFAULT NULL
+);
+CREATE INDEX user_data_requests_user_uid_idx ON user_data_requests (user_uid, created_at);
+CREATE INDEX user_data_requests_status_idx ON user_data_requests (status);
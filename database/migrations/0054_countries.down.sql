@@ -0,0 +1,10 @@
+-- local numbers keep only phones of Turkmenistan, rollback is refused while users have phones of other countries
+DO $$
+BEGIN
+   IF EXISTS (SELECT 1 FROM users WHERE phone LIKE '+%' AND phone !~ '^\+993[0-9]{8}$') THEN
+      RAISE EXCEPTION 'users have phones of other countries than +993, they can not be stored as local numbers';
+   END IF;
+END $$;
+UPDATE users SET phone = substr(phone, 5) WHERE phone ~ '^\+993[0-9]{8}$';
+
+ALTER TABLE schools DROP COLUMN IF EXISTS timezone;
@@ -0,0 +1,4 @@
+ALTER TABLE schools ADD COLUMN timezone varchar(64) DEFAULT NULL;
+
+-- phones are stored as E.164, existing ones are local numbers of Turkmenistan
+UPDATE users SET phone = '+993' || phone WHERE phone ~ '^[0-9]{8}$';
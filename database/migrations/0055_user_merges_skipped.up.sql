@@ -0,0 +1,2 @@
+-- rows of duplicate not moved as survivor already has same ones (e.g. grade of same lesson), per table.column
+ALTER TABLE user_merges ADD COLUMN skipped jsonb NOT NULL DEFAULT '{}';
@@ -30,5 +30,26 @@
 \i database/migrations/0031_alter_period_grades.sql
 \i database/migrations/0032_alter_user_parents_changes.sql
 \i database/migrations/0034_school_transfers.sql
+\i database/migrations/0035_book_pages.up.sql
+\i database/migrations/0036_curriculum_plans.up.sql
+\i database/migrations/0037_push_deliveries.up.sql
+\i database/migrations/0038_notification_preferences.up.sql
+\i database/migrations/0039_notifications_schedule.up.sql
+\i database/migrations/0040_email.up.sql
+\i database/migrations/0041_reports_formula.up.sql
+\i database/migrations/0042_report_items_status.up.sql
+\i database/migrations/0043_contact_helpdesk.up.sql
+\i database/migrations/0044_contact_items_payload.up.sql
+\i database/migrations/0045_school_transfers_workflow.up.sql
+\i database/migrations/0046_assignment_submissions.up.sql
+\i database/migrations/0047_roles_permissions.up.sql
+\i database/migrations/0048_user_security.up.sql
+\i database/migrations/0049_school_rollovers.up.sql
+\i database/migrations/0050_archive_snapshots.up.sql
+\i database/migrations/0051_user_imports.up.sql
+\i database/migrations/0052_user_merges.up.sql
+\i database/migrations/0053_user_data_requests.up.sql
+\i database/migrations/0054_countries.up.sql
+\i database/migrations/0055_user_merges_skipped.up.sql
 -- \i database/migrations/0027_new_alters.sql
 -- \i database/migrations/indexes.sql
@@ -3,25 +3,22 @@ package api
 import (
 	"context"
 	"encoding/json"
-	"errors"
-	"image/jpeg"
 	"math/rand"
 	"mime/multipart"
 	"net/http"
-	"os"
-	"strings"
 	"time"
 
-	"github.com/gen2brain/go-fitz"
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
 	"github.com/jackc/pgx/v4"
 	"github.com/mekdep/server/config"
+	apiutils "github.com/mekdep/server/internal/api/utils"
 	"github.com/mekdep/server/internal/app"
 	"github.com/mekdep/server/internal/app/app_validation"
 	"github.com/mekdep/server/internal/models"
 	"github.com/mekdep/server/internal/store"
 	"github.com/mekdep/server/internal/utils"
+	"github.com/mekdep/server/internal/utils/storage"
 )
 
 func Success(c *gin.Context, data gin.H) {
@@ -87,7 +84,7 @@ func handleError(c *gin.Context, err error) {
 func userLog(data models.UserLog) error {
 	go func() {
 		// delete security keys
-		secKeys := []string{"password", "otp", "device_token", "token"}
+		secKeys := []string{"password", "otp", "device_token", "token", "totp_code", "recovery_code"}
 		prStr, _ := json.Marshal(data.SubjectProperties)
 		pr := map[string]interface{}{}
 		_ = json.Unmarshal(prStr, &pr)
@@ -106,8 +103,9 @@ func userLog(data models.UserLog) error {
 	return nil
 }
 
-func ParseDate(s string) (time.Time, error) {
-	tt, err := time.ParseInLocation(time.DateOnly, s, config.RequestLocation)
+// ParseDate parses date of request in timezone of school of session
+func ParseDate(ses *apiutils.Session, s string) (time.Time, error) {
+	tt, err := time.ParseInLocation(time.DateOnly, s, ses.GetLocation())
 	if err != nil {
 		return time.Time{}, err
 	}
@@ -236,7 +234,25 @@ func HasRole(c *gin.Context, role models.Role) bool {
 	return false
 }
 
+// uploadLimit caps body of multipart request by size limit of its folders before form is parsed (binding parses it too),
+// so too large upload is refused while reading instead of being written to temp files first
+func uploadLimit(c *gin.Context, folders ...string) {
+	if c.ContentType() != "multipart/form-data" {
+		return
+	}
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, storage.RequestMaxSize(folders...))
+}
+
 func handleFilesUpload(c *gin.Context, key string, folder string) ([]string, error) {
+	return handleFilesUploadWith(c, key, folder, handleFile)
+}
+
+// handleImagesUpload is for galleries, images are processed (see storage.UploadImage)
+func handleImagesUpload(c *gin.Context, key string, folder string) ([]string, error) {
+	return handleFilesUploadWith(c, key, folder, handleImage)
+}
+
+func handleFilesUploadWith(c *gin.Context, key string, folder string, handle func(*gin.Context, *multipart.FileHeader, string) (string, int64, error)) ([]string, error) {
 	c.Request.ParseMultipartForm(100)
 	form, err := c.MultipartForm()
 	if err != nil {
@@ -246,7 +262,7 @@ func handleFilesUpload(c *gin.Context, key string, folder string) ([]string, err
 
 	paths := []string{}
 	for _, f := range files {
-		path, _, err := handleFile(c, f, folder)
+		path, _, err := handle(c, f, folder)
 		if err != nil {
 			return nil, err
 		}
@@ -256,114 +272,33 @@ func handleFilesUpload(c *gin.Context, key string, folder string) ([]string, err
 }
 
 func deleteFile(c *gin.Context, path string, folder string) error {
-
-	return nil
+	err := storage.Delete(c, path, folder)
+	if err != nil {
+		utils.LoggerDesc("File delete error").Error(err)
+	}
+	return err
 }
 
 func handleFile(c *gin.Context, handler *multipart.FileHeader, subFolder string) (string, int64, error) {
-	var err error
-	uploadPath := "web/uploads/" + subFolder + "/"
-	fParts := strings.Split(handler.Filename, ".")
-	ext := "." + strings.ToLower(fParts[len(fParts)-1])
-	validExts := map[string]bool{
-		".png": true, ".jpg": true, ".jpeg": true, ".zip": true, ".rar": true,
-		".mp3": true, ".mp4": true, ".pdf": true, ".docx": true, ".doc": true, ".xlsx": true,
-	}
-	if !validExts[ext] {
-		return "", 0, errors.New("invalid extension: " + ext)
-	}
-	folderHash := RandStringBytes(16)
-	uploadPath = uploadPath + folderHash + "/"
-	fileName := handler.Filename
-	fileSize := handler.Size / 1024
-	err = c.SaveUploadedFile(handler, uploadPath+fileName)
+	filePath, fileSize, err := storage.Upload(c, handler, subFolder, storage.DefaultExts)
 	if err != nil {
 		utils.LoggerDesc("HTTP error").Error(err)
 		return "", 0, err
 	}
-
-	err = os.Chmod(uploadPath, 0777)
-	if err != nil {
-		return "", 0, err
-	}
-	err = os.Chmod(uploadPath+fileName, 0777)
-	if err != nil {
-		return "", 0, err
-	}
-
-	return subFolder + "/" + folderHash + "/" + fileName, fileSize, nil
-}
-
-func storeBook(book *models.Book) error {
-	_, err := store.Store().BookUpdate(context.Background(), book)
-	return err
+	return filePath, fileSize, nil
 }
 
-func handleFileForBook(c *gin.Context, handler *multipart.FileHeader, subFolder string, m *models.Book, saver func(model *models.Book) error) (string, int64, error) {
-	var err error
-	uploadDir := "web/uploads/" + subFolder + "/"
-	fParts := strings.Split(handler.Filename, ".")
-	ext := "." + strings.ToLower(fParts[len(fParts)-1])
-	validExts := map[string]bool{
-		".png": true, ".jpg": true, ".jpeg": true, ".pdf": true,
-	}
-	if !validExts[ext] {
-		return "", 0, errors.New("invalid extension: " + ext)
-	}
-	folderHash := RandStringBytes(16)
-	uploadDir = uploadDir + folderHash + "/"
-	fileName := handler.Filename
-	fileSize := handler.Size / 1024
-	err = c.SaveUploadedFile(handler, uploadDir+fileName)
-	os.Chmod(uploadDir, 0755)
-	os.Chmod(uploadDir+fileName, 0755)
+func handleImage(c *gin.Context, handler *multipart.FileHeader, subFolder string) (string, int64, error) {
+	filePath, fileSize, err := storage.UploadImage(c, handler, subFolder)
 	if err != nil {
 		utils.LoggerDesc("HTTP error").Error(err)
 		return "", 0, err
 	}
-	var previewImage string
-	var pageCount int
-	filePath := strings.Replace(uploadDir, "web/uploads/", "", 1) + fileName
-	if ext == ".pdf" {
-		func() {
-			previewImage, pageCount, err = convertPdfToCoverImage(uploadDir, fileName)
-			if err != nil {
-				utils.LoggerDesc("PDF convert error").Error(err)
-				return
-			}
-			m.Pages = &pageCount
-			m.FilePreview = &previewImage
-			m.File = &filePath
-			err := saver(m)
-			if err != nil {
-				utils.LoggerDesc("PDF convert error").Error(err)
-				return
-			}
-		}()
-	}
 	return filePath, fileSize, nil
 }
 
-func convertPdfToCoverImage(uploadPath, fileName string) (string, int, error) {
-	doc, err := fitz.New(uploadPath + fileName)
-	if err != nil {
-		return "", 0, err
-	}
-	defer doc.Close()
-	pageCount := doc.NumPage()
-	img, err := doc.Image(0)
-	previewImage := uploadPath + "hover.jpg"
-	f, err := os.Create(previewImage)
-	os.Chmod(previewImage, 0755)
-	if err != nil {
-		return "", 0, err
-	}
-	err = jpeg.Encode(f, img, &jpeg.Options{jpeg.DefaultQuality})
-	if err != nil {
-		return "", 0, err
-	}
-	f.Close()
-	return strings.Replace(previewImage, "web/uploads/", "", 1), pageCount, nil
+var bookExts = map[string]bool{
+	".png": true, ".jpg": true, ".jpeg": true, ".pdf": true,
 }
 
 func handleFileUpload(c *gin.Context, key string, folder string, public bool) (string, int64, error) {
@@ -375,13 +310,29 @@ func handleFileUpload(c *gin.Context, key string, folder string, public bool) (s
 	return handleFile(c, handler, folder)
 }
 
-func handleFileUploadForBook(c *gin.Context, key string, folder string, public bool, m *models.Book) (string, int64, error) {
+// handleImageUpload is for avatars, images are processed (see storage.UploadImage)
+func handleImageUpload(c *gin.Context, key string, folder string) (string, int64, error) {
 	c.Request.ParseMultipartForm(10 << 20)
 	_, handler, err := c.Request.FormFile(key)
 	if err != nil {
 		return "", 0, nil
 	}
-	return handleFileForBook(c, handler, folder, m, storeBook)
+	return handleImage(c, handler, folder)
+}
+
+// handleBookFileUpload only saves file, pdf is processed in background (app.BookProcessWorker)
+func handleBookFileUpload(c *gin.Context, key string) (string, int64, error) {
+	c.Request.ParseMultipartForm(10 << 20)
+	_, handler, err := c.Request.FormFile(key)
+	if err != nil {
+		return "", 0, nil
+	}
+	filePath, fileSize, err := storage.Upload(c, handler, "books", bookExts)
+	if err != nil {
+		utils.LoggerDesc("HTTP error").Error(err)
+		return "", 0, err
+	}
+	return filePath, fileSize, nil
 }
 
 // make utils
@@ -107,6 +107,8 @@ func SettingReport(c *gin.Context) {
 			"description": v.Description,
 			"key":         v.Key,
 			"type":        v.Type,
+			"formula":     v.Formula,
+			"scoring":     v.Scoring,
 			"value":       v.Value,
 		})
 	}
@@ -147,6 +149,7 @@ func Permissions(c *gin.Context) {
 	Success(c, gin.H{
 		"permissions_write": psw,
 		"permissions":       ps,
+		"impersonated_by":   ses.GetImpersonatorId(),
 	})
 }
 
@@ -357,7 +360,7 @@ func AppSettingsV2(c *gin.Context) {
 			},
 			"payment_map":        paymentMap,
 			"bank_types":         models.DefaultPaymentBank,
-			"holidays":           models.DefaultHolidays,
+			"holidays":           models.DefaultCountry().Holidays,
 			"grade_reasons":      gradeReasonNames,
 			"lesson_types":       lessonTypeNames,
 			"menu_apps":          menuApps,
@@ -0,0 +1,35 @@
+package api
+
+import (
+	"crypto/subtle"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mekdep/server/config"
+	"github.com/mekdep/server/internal/api/utils"
+	"github.com/mekdep/server/internal/app"
+)
+
+func ArchiveRoutes(api *gin.RouterGroup) {
+	r := api.Group("/archive")
+	{
+		r.GET("snapshots", ArchiveSnapshotList)
+	}
+}
+
+// ArchiveSnapshotList is read by live server to know periods of this archive server
+func ArchiveSnapshotList(c *gin.Context) {
+	ses := utils.InitSession(c)
+	token := c.GetHeader("X-Archive-Token")
+	if config.Conf.ArchiveToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(config.Conf.ArchiveToken)) != 1 {
+		handleError(c, app.ErrForbidden)
+		return
+	}
+	l, err := app.ArchiveSnapshots(&ses)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+	Success(c, gin.H{
+		"snapshots": l,
+	})
+}
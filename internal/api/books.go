@@ -17,6 +17,8 @@ func BookRoutes(api *gin.RouterGroup) {
 		bookRoutes.DELETE("", BookDelete)
 		bookRoutes.GET(":id", BookDetail)
 		bookRoutes.GET("/authors", BookGetAuthors)
+		bookRoutes.GET("/search", BookSearch)
+		bookRoutes.GET(":id/pages", BookPages)
 	}
 }
 
@@ -96,7 +98,80 @@ func BookGetAuthors(c *gin.Context) {
 	}
 }
 
+// bookFilesUpload saves file and preview of book, pdf cover is made by background processing
+func bookFilesUpload(c *gin.Context, ses *utils.Session, bookModel *models.Book, book *models.BookResponse) (*models.BookResponse, error) {
+	file, fileSize, err := handleBookFileUpload(c, "file")
+	if err != nil {
+		return nil, app.NewAppError(err.Error(), "file", "")
+	}
+	// if user upload file_preview
+	previewImageByHand, _, err := handleImageUpload(c, "file_preview", "books")
+	if err != nil {
+		return nil, app.NewAppError(err.Error(), "file_preview", "")
+	}
+	if file == "" && previewImageByHand == "" {
+		return book, nil
+	}
+	if previewImageByHand != "" {
+		bookModel.FilePreview = &previewImageByHand
+	}
+	if file != "" {
+		bookModel.File = &file
+		fileSizeInt := int(fileSize)
+		bookModel.FileSize = &fileSizeInt
+	}
+	return app.BookFilesUpdate(ses, bookModel, file != "")
+}
+
+func BookSearch(c *gin.Context) {
+	ses := utils.InitSession(c)
+	err := app.Ap().UserActionCheckRead(&ses, app.PermUser, func(u *models.User) error {
+		r := models.BookPageSearchRequest{}
+		if errMsg, errKey := BindAndValidate(c, &r); errMsg != "" || errKey != "" {
+			return app.NewAppError(errMsg, errKey, "")
+		}
+		pages, total, err := app.BookPagesSearch(&ses, r)
+		if err != nil {
+			return err
+		}
+		Success(c, gin.H{
+			"pages": pages,
+			"total": total,
+		})
+		return nil
+	})
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+}
+
+func BookPages(c *gin.Context) {
+	ses := utils.InitSession(c)
+	err := app.Ap().UserActionCheckRead(&ses, app.PermUser, func(u *models.User) error {
+		id := c.Param("id")
+		if ok, err := booksAvailableCheck(&ses, models.BookFilterRequest{ID: id}); err != nil {
+			return err
+		} else if !ok {
+			return app.ErrNotfound
+		}
+		pages, err := app.BookPages(&ses, id)
+		if err != nil {
+			return err
+		}
+		Success(c, gin.H{
+			"pages": pages,
+		})
+		return nil
+	})
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+}
+
 func BookUpdate(c *gin.Context) {
+	uploadLimit(c, "books")
 	ses := utils.InitSession(c)
 	err := app.Ap().UserActionCheckWrite(&ses, app.PermAdminBooks, func(user *models.User) (err error) {
 		r := models.BookRequest{}
@@ -122,25 +197,9 @@ func BookUpdate(c *gin.Context) {
 		if err != nil {
 			return err
 		}
-		// upload file
-		file, fileSize, err := handleFileUploadForBook(c, "file", "books", true, bookModel)
-		if err != nil {
-			return app.NewAppError(err.Error(), "file", "")
-		}
-
-		// if user upload file_preview
-		previewImageByHand, _, err := handleFileUploadForBook(c, "file_preview", "books", true, bookModel)
+		book, err = bookFilesUpload(c, &ses, bookModel, book)
 		if err != nil {
-			return app.NewAppError(err.Error(), "file_preview", "")
-		}
-		if previewImageByHand != "" {
-			bookModel.FilePreview = &previewImageByHand
-		}
-
-		if file != "" {
-			bookModel.File = &file
-			fileSizeInt := int(fileSize)
-			bookModel.FileSize = &fileSizeInt
+			return err
 		}
 
 		userLog(models.UserLog{
@@ -166,6 +225,7 @@ func BookUpdate(c *gin.Context) {
 }
 
 func BookCreate(c *gin.Context) {
+	uploadLimit(c, "books")
 	ses := utils.InitSession(c)
 	err := app.Ap().UserActionCheckWrite(&ses, app.PermAdminBooks, func(user *models.User) (err error) {
 		r := models.BookRequest{}
@@ -180,23 +240,9 @@ func BookCreate(c *gin.Context) {
 		if err != nil {
 			return err
 		}
-		// upload file
-		file, fileSize, err := handleFileUploadForBook(c, "file", "books", true, bookModel)
-		if err != nil {
-			return app.NewAppError(err.Error(), "file", "")
-		}
-		// if user upload file_preview
-		previewImageByHand, _, err := handleFileUploadForBook(c, "file_preview", "books", true, bookModel)
+		book, err = bookFilesUpload(c, &ses, bookModel, book)
 		if err != nil {
-			return app.NewAppError(err.Error(), "file_preview", "")
-		}
-		if previewImageByHand != "" {
-			bookModel.FilePreview = &previewImageByHand
-		}
-		if file != "" {
-			bookModel.File = &file
-			fileSizeInt := int(fileSize)
-			bookModel.FileSize = &fileSizeInt
+			return err
 		}
 		userLog(models.UserLog{
 			SchoolId:          ses.GetSchoolId(),
@@ -16,6 +16,13 @@ func ContactItemsRoutes(api *gin.RouterGroup) {
 		contactItemsRoutes.POST("", ContactItemsCreate)
 		contactItemsRoutes.DELETE("", ContactItemsDelete)
 		contactItemsRoutes.GET(":id", ContactItemsDetail)
+		contactItemsRoutes.POST(":id/assign", ContactItemsAssign)
+		contactItemsRoutes.POST(":id/replies", ContactRepliesCreate)
+		contactItemsRoutes.GET(":id/data", ContactItemsDataProposal)
+		contactItemsRoutes.POST(":id/data/apply", ContactItemsDataApply)
+		contactItemsRoutes.GET("my", ContactItemsMyList)
+		contactItemsRoutes.GET("my/:id", ContactItemsMyDetail)
+		contactItemsRoutes.POST("my/:id/replies", ContactRepliesMyCreate)
 	}
 }
 
@@ -128,6 +135,7 @@ func ContactItemsUpdate(c *gin.Context) {
 }
 
 func ContactItemsCreate(c *gin.Context) {
+	uploadLimit(c, "contact_files")
 	ses := utils.InitSession(c)
 	if _, err := c.MultipartForm(); err == nil {
 		ContactItemsCreateHandleFiles(c)
@@ -229,3 +237,210 @@ func ContactItemsDelete(c *gin.Context) {
 		return
 	}
 }
+
+func ContactItemsAssign(c *gin.Context) {
+	ses := utils.InitSession(c)
+	err := app.Ap().UserActionCheckWrite(&ses, app.PermAdminContactItems, func(user *models.User) error {
+		r := models.ContactItemsAssignRequest{}
+		if errMsg, errKey := BindAndValidate(c, &r); errMsg != "" || errKey != "" {
+			return app.NewAppError(errMsg, errKey, "")
+		}
+		id := c.Param("id")
+		r.ID = &id
+		if ok, err := contactItemsAvailableCheck(&ses, models.ContactItemsFilterRequest{ID: r.ID}); err != nil {
+			return err
+		} else if !ok {
+			return app.ErrForbidden
+		}
+		contactItem, err := app.ContactItemsAssign(&ses, r)
+		if err != nil {
+			return err
+		}
+		userLog(models.UserLog{
+			SchoolId:          ses.GetSchoolId(),
+			SessionId:         ses.GetSessionId(),
+			UserId:            user.ID,
+			SubjectId:         r.ID,
+			Subject:           models.LogSubjectContactItems,
+			SubjectAction:     models.LogActionUpdate,
+			SubjectProperties: r,
+		})
+		Success(c, gin.H{
+			"contact_item": contactItem,
+		})
+		return nil
+	})
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+}
+
+func ContactRepliesCreate(c *gin.Context) {
+	ses := utils.InitSession(c)
+	err := app.Ap().UserActionCheckWrite(&ses, app.PermAdminContactItems, func(user *models.User) error {
+		r := models.ContactReplyRequest{}
+		if errMsg, errKey := BindAndValidate(c, &r); errMsg != "" || errKey != "" {
+			return app.NewAppError(errMsg, errKey, "")
+		}
+		id := c.Param("id")
+		r.ContactItemId = &id
+		if ok, err := contactItemsAvailableCheck(&ses, models.ContactItemsFilterRequest{ID: r.ContactItemId}); err != nil {
+			return err
+		} else if !ok {
+			return app.ErrForbidden
+		}
+		reply, err := app.ContactRepliesCreate(&ses, r)
+		if err != nil {
+			return err
+		}
+		userLog(models.UserLog{
+			SchoolId:          ses.GetSchoolId(),
+			SessionId:         ses.GetSessionId(),
+			UserId:            user.ID,
+			SubjectId:         r.ContactItemId,
+			Subject:           models.LogSubjectContactItems,
+			SubjectAction:     models.LogActionCreate,
+			SubjectProperties: r,
+		})
+		Success(c, gin.H{
+			"reply": reply,
+		})
+		return nil
+	})
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+}
+
+func ContactItemsMyList(c *gin.Context) {
+	ses := utils.InitSession(c)
+	err := app.Ap().UserActionCheckRead(&ses, app.PermUser, func(user *models.User) error {
+		r := models.ContactItemsFilterRequest{}
+		if errMsg, errKey := BindAndValidate(c, &r); errMsg != "" || errKey != "" {
+			return app.NewAppError(errMsg, errKey, "")
+		}
+		if r.Limit == nil {
+			r.Limit = new(int)
+			*r.Limit = 12
+		}
+		if r.Offset == nil {
+			r.Offset = new(int)
+			*r.Offset = 0
+		}
+		contactItems, total, err := app.ContactItemsMyList(&ses, r)
+		if err != nil {
+			return err
+		}
+		Success(c, gin.H{
+			"contact_items": contactItems,
+			"total":         total,
+		})
+		return nil
+	})
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+}
+
+func ContactItemsMyDetail(c *gin.Context) {
+	ses := utils.InitSession(c)
+	err := app.Ap().UserActionCheckRead(&ses, app.PermUser, func(user *models.User) error {
+		m, err := app.ContactItemsMyDetail(&ses, c.Param("id"))
+		if err != nil {
+			return err
+		}
+		Success(c, gin.H{
+			"contact_item": m,
+		})
+		return nil
+	})
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+}
+
+func ContactRepliesMyCreate(c *gin.Context) {
+	ses := utils.InitSession(c)
+	err := app.Ap().UserActionCheckWrite(&ses, app.PermUser, func(user *models.User) error {
+		r := models.ContactReplyRequest{}
+		if errMsg, errKey := BindAndValidate(c, &r); errMsg != "" || errKey != "" {
+			return app.NewAppError(errMsg, errKey, "")
+		}
+		id := c.Param("id")
+		r.ContactItemId = &id
+		r.IsInternal = nil
+		reply, err := app.ContactRepliesMyCreate(&ses, r)
+		if err != nil {
+			return err
+		}
+		Success(c, gin.H{
+			"reply": reply,
+		})
+		return nil
+	})
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+}
+
+func ContactItemsDataProposal(c *gin.Context) {
+	ses := utils.InitSession(c)
+	err := app.Ap().UserActionCheckRead(&ses, app.PermAdminContactItems, func(user *models.User) error {
+		id := c.Param("id")
+		if ok, err := contactItemsAvailableCheck(&ses, models.ContactItemsFilterRequest{ID: &id}); err != nil {
+			return err
+		} else if !ok {
+			return app.ErrNotfound
+		}
+		proposal, err := app.ContactItemsDataProposal(&ses, id)
+		if err != nil {
+			return err
+		}
+		Success(c, gin.H{
+			"proposal": proposal,
+		})
+		return nil
+	})
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+}
+
+func ContactItemsDataApply(c *gin.Context) {
+	ses := utils.InitSession(c)
+	err := app.Ap().UserActionCheckWrite(&ses, app.PermAdminContactItems, func(user *models.User) error {
+		id := c.Param("id")
+		if ok, err := contactItemsAvailableCheck(&ses, models.ContactItemsFilterRequest{ID: &id}); err != nil {
+			return err
+		} else if !ok {
+			return app.ErrForbidden
+		}
+		proposal, err := app.ContactItemsDataApply(&ses, id)
+		if err != nil {
+			return err
+		}
+		userLog(models.UserLog{
+			SchoolId:          ses.GetSchoolId(),
+			SessionId:         ses.GetSessionId(),
+			UserId:            user.ID,
+			SubjectId:         &id,
+			Subject:           models.LogSubjectContactItems,
+			SubjectAction:     models.LogActionUpdate,
+			SubjectProperties: proposal,
+		})
+		Success(c, gin.H{
+			"proposal": proposal,
+		})
+		return nil
+	})
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+}
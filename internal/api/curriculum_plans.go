@@ -0,0 +1,206 @@
+package api
+
+import (
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mekdep/server/internal/api/utils"
+	"github.com/mekdep/server/internal/app"
+	"github.com/mekdep/server/internal/models"
+)
+
+func CurriculumPlanRoutes(api *gin.RouterGroup) {
+	r := api.Group("/curriculum-plans")
+	{
+		r.GET("", CurriculumPlansList)
+		r.GET("/progress", CurriculumPlansProgress)
+		r.GET(":id", CurriculumPlansDetail)
+		r.POST("", CurriculumPlansCreate)
+		r.PUT(":id", CurriculumPlansUpdate)
+		r.DELETE("", CurriculumPlansDelete)
+		r.POST(":id/apply", CurriculumPlansApply)
+	}
+}
+
+func CurriculumPlansList(c *gin.Context) {
+	ses := utils.InitSession(c)
+	err := app.Ap().UserActionCheckRead(&ses, app.PermAdminTopics, func(user *models.User) error {
+		r := models.CurriculumPlanFilterRequest{}
+		if errMsg, errKey := BindAndValidate(c, &r); errMsg != "" || errKey != "" {
+			return app.NewAppError(errMsg, errKey, "")
+		}
+		items, total, err := app.CurriculumPlansList(&ses, r)
+		if err != nil {
+			return err
+		}
+		Success(c, gin.H{
+			"curriculum_plans": items,
+			"total":            total,
+		})
+		return nil
+	})
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+}
+
+func CurriculumPlansDetail(c *gin.Context) {
+	ses := utils.InitSession(c)
+	err := app.Ap().UserActionCheckRead(&ses, app.PermAdminTopics, func(user *models.User) error {
+		m, err := app.CurriculumPlansDetail(&ses, c.Param("id"))
+		if err != nil {
+			return err
+		}
+		Success(c, gin.H{
+			"curriculum_plan": m,
+		})
+		return nil
+	})
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+}
+
+func CurriculumPlansCreate(c *gin.Context) {
+	ses := utils.InitSession(c)
+	err := app.Ap().UserActionCheckWrite(&ses, app.PermAdminTopics, func(user *models.User) error {
+		r := models.CurriculumPlanRequest{}
+		if errMsg, errKey := BindAndValidate(c, &r); errMsg != "" || errKey != "" {
+			return app.NewAppError(errMsg, errKey, "")
+		}
+		m, err := app.CurriculumPlansCreate(&ses, r)
+		if err != nil {
+			return err
+		}
+		userLog(models.UserLog{
+			SchoolId:          ses.GetSchoolId(),
+			SessionId:         ses.GetSessionId(),
+			UserId:            user.ID,
+			SubjectId:         &m.ID,
+			Subject:           models.LogSubjectCurriculumPlans,
+			SubjectAction:     models.LogActionCreate,
+			SubjectProperties: r,
+		})
+		Success(c, gin.H{
+			"curriculum_plan": m,
+		})
+		return nil
+	})
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+}
+
+func CurriculumPlansUpdate(c *gin.Context) {
+	ses := utils.InitSession(c)
+	err := app.Ap().UserActionCheckWrite(&ses, app.PermAdminTopics, func(user *models.User) error {
+		r := models.CurriculumPlanRequest{}
+		if errMsg, errKey := BindAndValidate(c, &r); errMsg != "" || errKey != "" {
+			return app.NewAppError(errMsg, errKey, "")
+		}
+		id := c.Param("id")
+		if id == "" {
+			return app.ErrRequired.SetKey("id")
+		}
+		r.ID = &id
+		m, err := app.CurriculumPlansUpdate(&ses, r)
+		if err != nil {
+			return err
+		}
+		userLog(models.UserLog{
+			SchoolId:          ses.GetSchoolId(),
+			SessionId:         ses.GetSessionId(),
+			UserId:            user.ID,
+			SubjectId:         &m.ID,
+			Subject:           models.LogSubjectCurriculumPlans,
+			SubjectAction:     models.LogActionUpdate,
+			SubjectProperties: r,
+		})
+		Success(c, gin.H{
+			"curriculum_plan": m,
+		})
+		return nil
+	})
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+}
+
+func CurriculumPlansDelete(c *gin.Context) {
+	ses := utils.InitSession(c)
+	err := app.Ap().UserActionCheckWrite(&ses, app.PermAdminTopics, func(user *models.User) error {
+		ids := c.QueryArray("ids")
+		if len(ids) == 0 {
+			return app.ErrRequired.SetKey("ids")
+		}
+		items, err := app.CurriculumPlansDelete(&ses, ids)
+		if err != nil {
+			return err
+		}
+		userLog(models.UserLog{
+			SchoolId:          ses.GetSchoolId(),
+			SessionId:         ses.GetSessionId(),
+			UserId:            user.ID,
+			Subject:           models.LogSubjectCurriculumPlans,
+			SubjectAction:     models.LogActionDelete,
+			SubjectProperties: items,
+		})
+		Success(c, gin.H{
+			"curriculum_plans": items,
+		})
+		return nil
+	})
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+}
+
+// CurriculumPlansApply refills lessons by plan, in case lessons were changed by hand
+func CurriculumPlansApply(c *gin.Context) {
+	ses := utils.InitSession(c)
+	err := app.Ap().UserActionCheckWrite(&ses, app.PermAdminTopics, func(user *models.User) error {
+		err := app.CurriculumPlanApply(&ses, c.Param("id"))
+		if err != nil {
+			return err
+		}
+		Success(c, gin.H{})
+		return nil
+	})
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+}
+
+func CurriculumPlansProgress(c *gin.Context) {
+	ses := utils.InitSession(c)
+	err := app.Ap().UserActionCheckRead(&ses, app.PermAdminSubjects, func(user *models.User) error {
+		r := models.CurriculumProgressRequest{}
+		if errMsg, errKey := BindAndValidate(c, &r); errMsg != "" || errKey != "" {
+			return app.NewAppError(errMsg, errKey, "")
+		}
+		if r.SchoolId == nil || !slices.Contains(ses.GetSchoolIds(), *r.SchoolId) {
+			r.SchoolId = ses.GetSchoolId()
+		}
+		if r.SchoolId == nil {
+			return app.ErrRequired.SetKey("school_id")
+		}
+		items, err := app.CurriculumProgress(&ses, r)
+		if err != nil {
+			return err
+		}
+		Success(c, gin.H{
+			"progress": items,
+		})
+		return nil
+	})
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+}
@@ -0,0 +1,80 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mekdep/server/internal/api/utils"
+	"github.com/mekdep/server/internal/app"
+	"github.com/mekdep/server/internal/models"
+)
+
+func EventRoutes(api *gin.RouterGroup) {
+	api.GET("/events", EventsStream)
+}
+
+// keeps proxies from closing idle stream
+const eventsHeartbeat = time.Second * 25
+
+// EventsStream is server-sent events stream of user, resumed by Last-Event-ID header or last_event_id query
+func EventsStream(c *gin.Context) {
+	ses := utils.InitSession(c)
+	err := app.Ap().UserActionCheckRead(&ses, app.PermUser, func(user *models.User) error {
+		lastId := c.GetHeader("Last-Event-ID")
+		if lastId == "" {
+			lastId = c.Query("last_event_id")
+		}
+		sub, missed, resync := app.EventsSubscribe(user.ID, lastId)
+		defer app.EventsUnsubscribe(sub)
+
+		c.Header("Content-Type", "text/event-stream")
+		c.Header("Cache-Control", "no-cache")
+		c.Header("Connection", "keep-alive")
+		c.Header("X-Accel-Buffering", "no")
+		c.Status(200)
+
+		if resync {
+			writeEvent(c, app.Event{Type: app.EventResync, CreatedAt: time.Now()})
+		}
+		for _, e := range missed {
+			writeEvent(c, e)
+		}
+		c.Writer.Flush()
+
+		ticker := time.NewTicker(eventsHeartbeat)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-c.Request.Context().Done():
+				return nil
+			case e, ok := <-sub.C:
+				if !ok {
+					// too slow, client reconnects and resumes from last id
+					return nil
+				}
+				writeEvent(c, e)
+				c.Writer.Flush()
+			case <-ticker.C:
+				fmt.Fprint(c.Writer, ": ping\n\n")
+				c.Writer.Flush()
+			}
+		}
+	})
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+}
+
+func writeEvent(c *gin.Context, e app.Event) {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	if e.ID > 0 {
+		fmt.Fprintf(c.Writer, "id: %d\n", e.ID)
+	}
+	fmt.Fprintf(c.Writer, "event: %s\ndata: %s\n\n", e.Type, data)
+}
@@ -0,0 +1,56 @@
+package api
+
+import (
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	apiutils "github.com/mekdep/server/internal/api/utils"
+	"github.com/mekdep/server/internal/app"
+	"github.com/mekdep/server/internal/utils"
+	"github.com/mekdep/server/internal/utils/storage"
+)
+
+func FileRoutes(routes *gin.Engine) {
+	routes.GET("/uploads/*path", FileServe)
+	routes.HEAD("/uploads/*path", FileServe)
+}
+
+// FileServe serves uploaded files, private files require signature given by storage.FileUrl
+// and token of its owner or of staff of its school (header or token query), so leaked url alone is not enough
+func FileServe(c *gin.Context) {
+	p, err := storage.CleanPath(c.Param("path"))
+	if err != nil {
+		c.AbortWithStatus(http.StatusNotFound)
+		return
+	}
+	if storage.IsPrivate(p) {
+		if !storage.VerifySignature(p, c.Query("expires"), c.Query("signature")) {
+			c.AbortWithStatus(http.StatusForbidden)
+			return
+		}
+		ses := apiutils.InitSession(c)
+		err = app.FileCheckRead(&ses, p)
+		if err != nil {
+			c.AbortWithStatus(http.StatusForbidden)
+			return
+		}
+	}
+	if local, ok := storage.Disk().(*storage.LocalStorage); ok {
+		fp := local.FullPath(p)
+		if st, err := os.Stat(fp); err != nil || st.IsDir() {
+			c.AbortWithStatus(http.StatusNotFound)
+			return
+		}
+		c.File(fp)
+		return
+	}
+	u, err := storage.Disk().PresignedUrl(c, p, time.Minute*5)
+	if err != nil {
+		utils.LoggerDesc("File presign error").Error(err)
+		c.AbortWithStatus(http.StatusNotFound)
+		return
+	}
+	c.Redirect(http.StatusFound, u)
+}
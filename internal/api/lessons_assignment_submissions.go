@@ -0,0 +1,122 @@
+package api
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mekdep/server/internal/api/utils"
+	"github.com/mekdep/server/internal/app"
+	"github.com/mekdep/server/internal/models"
+)
+
+func AssignmentSubmissionRoutes(api *gin.RouterGroup) {
+	rs := api.Group("/parent/assignments")
+	{
+		rs.POST(":lesson_id/submission", AssignmentSubmissionSubmit)
+	}
+	rt := api.Group("/lessons/submissions")
+	{
+		rt.GET("", AssignmentSubmissionMatrix)
+		rt.POST(":id/grade", AssignmentSubmissionGrade)
+	}
+}
+
+func AssignmentSubmissionSubmit(c *gin.Context) {
+	uploadLimit(c, "submissions")
+	ses := utils.InitSession(c)
+	err := app.Ap().UserActionCheckWrite(&ses, app.PermDiary, func(user *models.User) (err error) {
+		r := models.AssignmentSubmissionRequest{}
+		if err := BindAny(c, &r); err != nil {
+			return err
+		}
+		student, err := getParentChild(c, &ses, user)
+		if err != nil {
+			return err
+		}
+		lessonId := c.Param("lesson_id")
+		r.LessonId = &lessonId
+		r.StudentId = &student.ID
+		if c.ContentType() == "multipart/form-data" {
+			files, err := handleFilesUpload(c, "files", "submissions")
+			if err != nil {
+				return app.NewAppError(err.Error(), "files", "")
+			}
+			r.Files = &files
+		}
+		res, err := app.AssignmentSubmissionsSubmit(&ses, student, r)
+		if err != nil {
+			return err
+		}
+		Success(c, gin.H{
+			"submission": res,
+		})
+		return nil
+	})
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+}
+
+func AssignmentSubmissionMatrix(c *gin.Context) {
+	ses := utils.InitSession(c)
+	err := app.Ap().UserActionCheckRead(&ses, app.PermJournal, func(user *models.User) error {
+		subjectId := c.Query("subject_id")
+		if subjectId == "" {
+			return app.ErrRequired.SetKey("subject_id")
+		}
+		var periodNumber *int
+		if v := c.Query("period_number"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return app.ErrInvalid.SetKey("period_number")
+			}
+			periodNumber = &n
+		}
+		res, err := app.AssignmentSubmissionsMatrix(&ses, subjectId, periodNumber)
+		if err != nil {
+			return err
+		}
+		Success(c, gin.H{
+			"data": res,
+		})
+		return nil
+	})
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+}
+
+func AssignmentSubmissionGrade(c *gin.Context) {
+	ses := utils.InitSession(c)
+	err := app.Ap().UserActionCheckWrite(&ses, app.PermJournal, func(user *models.User) error {
+		r := models.AssignmentSubmissionGradeRequest{}
+		if errMsg, errKey := BindAndValidate(c, &r); errMsg != "" || errKey != "" {
+			return app.NewAppError(errMsg, errKey, "")
+		}
+		id := c.Param("id")
+		r.ID = &id
+		res, err := app.AssignmentSubmissionsGrade(&ses, r)
+		if err != nil {
+			return err
+		}
+		userLog(models.UserLog{
+			SchoolId:          ses.GetSchoolId(),
+			SessionId:         ses.GetSessionId(),
+			UserId:            user.ID,
+			SubjectId:         &res.ID,
+			Subject:           models.LogSubjectAssignmentSubmissions,
+			SubjectAction:     models.LogActionUpdate,
+			SubjectProperties: r,
+		})
+		Success(c, gin.H{
+			"submission": res,
+		})
+		return nil
+	})
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+}
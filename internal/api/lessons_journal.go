@@ -4,6 +4,7 @@ import (
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/mekdep/server/internal/api/middleware"
 	"github.com/mekdep/server/internal/api/utils"
 	"github.com/mekdep/server/internal/app"
 	"github.com/mekdep/server/internal/models"
@@ -13,11 +14,11 @@ func JournalRoutes(api *gin.RouterGroup) {
 	rs := api.Group("lessons")
 	{
 		// rs.Use(middleware.JwtTokenCheck)
-		rs.GET("journal", LessonJournal)
-		rs.GET("final/:subject_id", LessonFinal)
-		rs.GET("final/v2", LessonFinalV2)
-		rs.GET("final/subjects", LessonFinalBySubject)
-		rs.GET("final", LessonFinalOld)
+		rs.GET("journal", middleware.ArchiveProxy, LessonJournal)
+		rs.GET("final/:subject_id", middleware.ArchiveProxy, LessonFinal)
+		rs.GET("final/v2", middleware.ArchiveProxy, LessonFinalV2)
+		rs.GET("final/subjects", middleware.ArchiveProxy, LessonFinalBySubject)
+		rs.GET("final", middleware.ArchiveProxy, LessonFinalOld)
 		rs.POST("final/:subject_id", LessonFinalMake)
 		rs.POST("final/v2", LessonFinalMake)
 		rs.POST("", LessonUpdate)
@@ -137,6 +138,7 @@ func LessonUpdateJSON(c *gin.Context) {
 }
 
 func LessonUpdateFormData(c *gin.Context) {
+	uploadLimit(c, "lesson_pro", "assignments")
 	ses := utils.InitSession(c)
 	err := app.Ap().UserActionCheckWrite(&ses, app.PermJournal, func(user *models.User) error {
 		r := models.JournalFormRequest{}
@@ -171,6 +173,7 @@ func LessonUpdateFormData(c *gin.Context) {
 }
 
 func LessonUpdateHandleFiles(c *gin.Context) {
+	uploadLimit(c, "assignments")
 	ses := utils.InitSession(c)
 	err := app.Ap().UserActionCheckWrite(&ses, app.PermJournal, func(user *models.User) error {
 		r := models.AssignmentRequest{}
@@ -187,6 +190,7 @@ func LessonUpdateHandleFiles(c *gin.Context) {
 		}
 		res := models.AssignmentResponse{}
 		res.FromModel(ma.Assignment)
+		res.DueAt = ma.AssignmentDueAt
 		Success(c, gin.H{
 			"assignment": res,
 		})
@@ -76,6 +76,10 @@ var upgrader = websocket.Upgrader{
 func ConnectAndHandleMessages(c *gin.Context) {
 	ses := utils.InitSession(c)
 	err := app.Ap().UserActionCheckRead(&ses, app.PermUser, func(user *models.User) error {
+		// socket posts messages, impersonated session is read only
+		if ses.IsImpersonated() {
+			return app.ErrForbidden.SetComment("impersonation is read only")
+		}
 		dto := models.MessageRequest{}
 		if errMsg, errKey := BindAndValidate(c, &dto); errMsg != "" || errKey != "" {
 			return app.NewAppError(errMsg, errKey, "")
@@ -0,0 +1,34 @@
+package api
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mekdep/server/config"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+func MetricsRoutes(routes *gin.Engine) {
+	if !config.Conf.MetricsEnabled {
+		return
+	}
+	h := promhttp.Handler()
+	routes.GET("/metrics", func(c *gin.Context) {
+		if !metricsAuthorized(c) {
+			c.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+		h.ServeHTTP(c.Writer, c.Request)
+	})
+}
+
+// metrics_token is checked as bearer token, empty token leaves endpoint open only out of prod
+func metricsAuthorized(c *gin.Context) bool {
+	if config.Conf.MetricsToken == "" {
+		return !config.Conf.AppEnvIsProd
+	}
+	token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+	return subtle.ConstantTimeCompare([]byte(token), []byte(config.Conf.MetricsToken)) == 1
+}
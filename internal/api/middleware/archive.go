@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	apiutils "github.com/mekdep/server/internal/api/utils"
+	"github.com/mekdep/server/internal/app"
+	"github.com/mekdep/server/internal/utils"
+)
+
+// ArchiveProxy sends get requests of past year (period of query or of token) to archive server of that year,
+// token of user is signed as sessions are not in snapshot, archive server loads user of token (see utils.SessionArchiveLoad)
+func ArchiveProxy(c *gin.Context) {
+	if c.Request.Method != http.MethodGet {
+		return
+	}
+	periodId := c.Query("period_id")
+	if periodId == "" {
+		periodId = c.GetString("period_id")
+	}
+	server := app.ArchiveServerByPeriod(periodId)
+	if server == "" {
+		return
+	}
+	// only tokens of live sessions are signed, tokens of logged out or erased users must not open archives
+	token := c.GetString("token")
+	if _, err := apiutils.SessionByToken(token); token == "" || err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"message": "session not found",
+		})
+		return
+	}
+	target, err := url.Parse(server)
+	if err != nil {
+		utils.LoggerDesc("In ArchiveProxy " + server).Error(err)
+		return
+	}
+	proxy := &httputil.ReverseProxy{
+		Director: func(req *http.Request) {
+			req.URL.Scheme = target.Scheme
+			req.URL.Host = target.Host
+			req.URL.Path = target.Path + strings.TrimPrefix(req.URL.Path, "/api")
+			req.Host = target.Host
+			ts := time.Now().Unix()
+			req.Header.Set("X-Archive-Time", strconv.FormatInt(ts, 10))
+			req.Header.Set("X-Archive-Signature", apiutils.SessionArchiveSign(token, ts))
+		},
+	}
+	proxy.ServeHTTP(c.Writer, c.Request)
+	c.Abort()
+}
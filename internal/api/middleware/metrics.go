@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mekdep/server/internal/utils"
+)
+
+func SetMetricsRequest(c *gin.Context) {
+	start := time.Now()
+	c.Next()
+	route := c.FullPath()
+	if route == "" {
+		// unmatched routes would blow up label cardinality
+		route = "unknown"
+	}
+	utils.MetricsHttpDuration.
+		WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).
+		Observe(time.Since(start).Seconds())
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/mekdep/server/internal/app"
 	"github.com/mekdep/server/internal/app/app_validation"
 	"github.com/mekdep/server/internal/models"
+	"github.com/mekdep/server/internal/utils/storage"
 )
 
 func NotificationsRoutes(api *gin.RouterGroup) {
@@ -15,6 +16,7 @@ func NotificationsRoutes(api *gin.RouterGroup) {
 	{
 		notificationRoutes.GET("", NotificationsList)
 		notificationRoutes.GET(":id", NotificationsDetail)
+		notificationRoutes.GET(":id/analytics", NotificationsAnalytics)
 		notificationRoutes.PUT(":id", NotificationsUpdate)
 		notificationRoutes.POST("", NotificationsCreate)
 		notificationRoutes.DELETE(":id", NotificationDelete)
@@ -111,7 +113,43 @@ func NotificationsDetail(c *gin.Context) {
 	}
 }
 
+func NotificationsAnalytics(c *gin.Context) {
+	ses := utils.InitSession(c)
+	err := app.Ap().UserActionCheckRead(&ses, app.PermToolNotifier, func(user *models.User) error {
+		id := c.Param("id")
+		if id == "" {
+			return app.ErrRequired.SetKey("id")
+		}
+		if ok, err := notificationsAvailableCheck(&ses, models.NotificationsFilterRequest{ID: &id}); err != nil {
+			return err
+		} else if !ok {
+			return app.ErrNotfound
+		}
+		items, err := app.NotificationAnalytics(&ses, id)
+		if err != nil {
+			return err
+		}
+		total := models.NotificationAnalytics{}
+		for _, v := range items {
+			total.Total += v.Total
+			total.Delivered += v.Delivered
+			total.Opened += v.Opened
+			total.Read += v.Read
+		}
+		Success(c, gin.H{
+			"items": items,
+			"total": total,
+		})
+		return nil
+	})
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+}
+
 func NotificationsUpdate(c *gin.Context) {
+	uploadLimit(c, "notifications")
 	ses := utils.InitSession(c)
 	err := app.Ap().UserActionCheckWrite(&ses, app.PermToolNotifier, func(user *models.User) (err error) {
 		r := models.NotificationsRequest{}
@@ -139,9 +177,10 @@ func NotificationsUpdate(c *gin.Context) {
 				*r.FilesDelete = paths
 			}
 			for _, v := range *r.FilesDelete {
-				deleteFile(c, v, "notifications")
+				v = storage.PathFromUrl(v)
 				k := slices.Index(paths, v)
 				if k >= 0 {
+					deleteFile(c, v, "notifications")
 					paths = slices.Delete(paths, k, k+1)
 				}
 			}
@@ -175,6 +214,7 @@ func NotificationsUpdate(c *gin.Context) {
 }
 
 func NotificationsCreate(c *gin.Context) {
+	uploadLimit(c, "notifications")
 	ses := utils.InitSession(c)
 	err := app.Ap().UserActionCheckWrite(&ses, app.PermToolNotifier, func(user *models.User) (err error) {
 		r := models.NotificationsRequest{}
@@ -8,12 +8,17 @@ import (
 	"github.com/mekdep/server/internal/app"
 	"github.com/mekdep/server/internal/app/app_validation"
 	"github.com/mekdep/server/internal/models"
+	"github.com/mekdep/server/internal/utils/storage"
 )
 
 func UserNotificationsRoutes(api *gin.RouterGroup) {
 	userNotificationRoutes := api.Group("/users/notifications")
 	{
 		userNotificationRoutes.GET("", UserNotificationsList)
+		userNotificationRoutes.GET("preferences", NotificationPreferencesList)
+		userNotificationRoutes.PUT("preferences", NotificationPreferencesUpdate)
+		userNotificationRoutes.GET("preferences/roles", NotificationPreferencesRoleList)
+		userNotificationRoutes.PUT("preferences/roles", NotificationPreferencesRoleUpdate)
 		userNotificationRoutes.PUT(":id", UserNotificationsUpdate)
 	}
 }
@@ -35,6 +40,8 @@ func UserNotificationsList(c *gin.Context) {
 		}
 		r.UserId = &user.ID
 		r.Role = (*string)(ses.GetRole())
+		r.NotExpired = new(bool)
+		*r.NotExpired = true
 
 		userNotifications, total, totalUnread, err := app.UserNotificationsList(&ses, r)
 
@@ -56,6 +63,7 @@ func UserNotificationsList(c *gin.Context) {
 }
 
 func UserNotificationsUpdate(c *gin.Context) {
+	uploadLimit(c, "notifications")
 	ses := utils.InitSession(c)
 	err := app.Ap().UserActionCheckWrite(&ses, app.PermUser, func(user *models.User) (err error) {
 		r := models.UserNotificationRequest{}
@@ -85,9 +93,10 @@ func UserNotificationsUpdate(c *gin.Context) {
 				*r.CommentFilesDelete = paths
 			}
 			for _, v := range *r.CommentFilesDelete {
-				deleteFile(c, v, "notifications")
+				v = storage.PathFromUrl(v)
 				k := slices.Index(paths, v)
 				if k >= 0 {
+					deleteFile(c, v, "notifications")
 					paths = slices.Delete(paths, k, k+1)
 				}
 			}
@@ -120,3 +129,108 @@ func UserNotificationsUpdate(c *gin.Context) {
 		return
 	}
 }
+
+func NotificationPreferencesList(c *gin.Context) {
+	ses := utils.InitSession(c)
+	err := app.Ap().UserActionCheckRead(&ses, app.PermUser, func(user *models.User) error {
+		items, err := app.NotificationPreferencesList(&ses, &user.ID, *ses.GetRole())
+		if err != nil {
+			return err
+		}
+		Success(c, gin.H{
+			"items": items,
+		})
+		return nil
+	})
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+}
+
+func NotificationPreferencesUpdate(c *gin.Context) {
+	ses := utils.InitSession(c)
+	err := app.Ap().UserActionCheckWrite(&ses, app.PermUser, func(user *models.User) error {
+		r := models.NotificationPreferencesRequest{}
+		if errMsg, errKey := BindAndValidate(c, &r); errMsg != "" || errKey != "" {
+			return app.NewAppError(errMsg, errKey, "")
+		}
+		items, err := app.NotificationPreferencesUpdate(&ses, &user.ID, *ses.GetRole(), r.Items)
+		if err != nil {
+			return err
+		}
+		userLog(models.UserLog{
+			SchoolId:          ses.GetSchoolId(),
+			SessionId:         ses.GetSessionId(),
+			UserId:            user.ID,
+			SubjectId:         &user.ID,
+			Subject:           models.LogSubjectNotificationPreferences,
+			SubjectAction:     models.LogActionUpdate,
+			SubjectProperties: r,
+		})
+		Success(c, gin.H{
+			"items": items,
+		})
+		return nil
+	})
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+}
+
+func NotificationPreferencesRoleList(c *gin.Context) {
+	ses := utils.InitSession(c)
+	err := app.Ap().UserActionCheckRead(&ses, app.PermAdminSettings, func(user *models.User) error {
+		role := c.Query("role")
+		if role == "" {
+			return app.ErrRequired.SetKey("role")
+		}
+		items, err := app.NotificationPreferencesList(&ses, nil, models.Role(role))
+		if err != nil {
+			return err
+		}
+		Success(c, gin.H{
+			"items": items,
+		})
+		return nil
+	})
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+}
+
+func NotificationPreferencesRoleUpdate(c *gin.Context) {
+	ses := utils.InitSession(c)
+	err := app.Ap().UserActionCheckWrite(&ses, app.PermAdminSettings, func(user *models.User) error {
+		r := models.NotificationPreferencesRequest{}
+		if errMsg, errKey := BindAndValidate(c, &r); errMsg != "" || errKey != "" {
+			return app.NewAppError(errMsg, errKey, "")
+		}
+		if r.Role == nil {
+			return app.ErrRequired.SetKey("role")
+		}
+		items, err := app.NotificationPreferencesUpdate(&ses, nil, models.Role(*r.Role), r.Items)
+		if err != nil {
+			return err
+		}
+		userLog(models.UserLog{
+			SchoolId:          ses.GetSchoolId(),
+			SessionId:         ses.GetSessionId(),
+			UserId:            user.ID,
+			SubjectId:         r.Role,
+			Subject:           models.LogSubjectNotificationPreferences,
+			SubjectAction:     models.LogActionUpdate,
+			SubjectProperties: r,
+		})
+		Success(c, gin.H{
+			"items": items,
+		})
+		return nil
+	})
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+}
@@ -24,7 +24,7 @@ func ParentAnalyticsWeekly(c *gin.Context) {
 	ses := utils.InitSession(c)
 	p := app.PermPlus
 	err := app.Ap().UserActionCheckRead(&ses, p, func(user *models.User) error {
-		date, err := getDate(c, false)
+		date, err := getDate(&ses, c, false)
 		if err != nil {
 			return err
 		}
@@ -59,7 +59,7 @@ func ParentAnalyticsSummary(c *gin.Context) {
 	ses := utils.InitSession(c)
 	p := app.PermPlus
 	err := app.Ap().UserActionCheckRead(&ses, p, func(user *models.User) error {
-		date, err := getDate(c, false)
+		date, err := getDate(&ses, c, false)
 		if err != nil {
 			return err
 		}
@@ -119,7 +119,7 @@ func ParentAnalyticsGrades(c *gin.Context) {
 
 		var endDate *time.Time
 		if endStr := c.Query("end_date"); endStr != "" {
-			parsedDate, err := ParseDate(endStr)
+			parsedDate, err := ParseDate(&ses, endStr)
 			if err == nil {
 				endDate = &parsedDate
 			}
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/mekdep/server/internal/api/middleware"
 	"github.com/mekdep/server/internal/api/utils"
 	"github.com/mekdep/server/internal/app"
 	"github.com/mekdep/server/internal/models"
@@ -13,6 +14,8 @@ import (
 
 func DiaryRoutes(api *gin.RouterGroup) {
 	rs := api.Group("/parent")
+	// past year diary is in archive server
+	rs.Use(middleware.ArchiveProxy)
 	{
 		rs.GET("/children", ParentChildren)
 		rs.GET("/diary", StudentDiary)
@@ -75,7 +78,7 @@ func StudentSubjectGrades(c *gin.Context) {
 func StudentDiary(c *gin.Context) {
 	ses := utils.InitSession(c)
 	err := app.Ap().UserActionCheckRead(&ses, app.PermDiary, func(user *models.User) error {
-		date, err := getDate(c, false)
+		date, err := getDate(&ses, c, false)
 		if err != nil {
 			return err
 		}
@@ -125,7 +128,7 @@ func ParentSubjects(c *gin.Context) {
 			return err
 		}
 
-		date, err := getDate(c, false)
+		date, err := getDate(&ses, c, false)
 		if err != nil {
 			return err
 		}
@@ -148,12 +151,12 @@ func ParentSubjects(c *gin.Context) {
 	}
 }
 
-func getDate(c *gin.Context, isRequired bool) (time.Time, error) {
+func getDate(ses *utils.Session, c *gin.Context, isRequired bool) (time.Time, error) {
 	var err error
 	date := time.Now()
 	dateStr := c.Query("date")
 	if dateStr != "" {
-		date, err = ParseDate(dateStr)
+		date, err = ParseDate(ses, dateStr)
 		if err != nil {
 			if isRequired {
 				return time.Time{}, app.ErrInvalid.SetKey("date")
@@ -12,6 +12,7 @@ func ReportItemsRoutes(api *gin.RouterGroup) {
 	reportItemsRoutes := api.Group("/report-forms/items")
 	{
 		reportItemsRoutes.POST(":id", ReportItemsCreate)
+		reportItemsRoutes.POST(":id/review", ReportItemsReview)
 	}
 }
 
@@ -55,3 +56,36 @@ func ReportItemsCreate(c *gin.Context) {
 		return
 	}
 }
+
+func ReportItemsReview(c *gin.Context) {
+	ses := utils.InitSession(c)
+	err := app.Ap().UserActionCheckWrite(&ses, app.PermToolReportForms, func(user *models.User) (err error) {
+		r := models.ReportItemsReviewRequest{}
+		if errMsg, errKey := BindAndValidate(c, &r); errMsg != "" || errKey != "" {
+			return app.NewAppError(errMsg, errKey, "")
+		}
+		id := c.Param("id")
+		r.ID = &id
+		reportItem, err := app.ReportItemsReview(&ses, r)
+		if err != nil {
+			return err
+		}
+		userLog(models.UserLog{
+			SchoolId:          ses.GetSchoolId(),
+			SessionId:         ses.GetSessionId(),
+			UserId:            user.ID,
+			SubjectId:         &reportItem.ID,
+			Subject:           models.LogSubjectReportItems,
+			SubjectAction:     models.LogActionUpdate,
+			SubjectProperties: r,
+		})
+		Success(c, gin.H{
+			"report_item": reportItem,
+		})
+		return nil
+	})
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/mekdep/server/internal/app"
 	"github.com/mekdep/server/internal/app/app_validation"
 	"github.com/mekdep/server/internal/models"
+	"github.com/mekdep/server/internal/store"
 )
 
 func ReportsRoutes(api *gin.RouterGroup) {
@@ -13,6 +14,7 @@ func ReportsRoutes(api *gin.RouterGroup) {
 	{
 		reportsRoutes.GET("", ReportsList)
 		reportsRoutes.GET("total", ReportsListTotal)
+		reportsRoutes.GET("inbox", ReportsInbox)
 		reportsRoutes.POST("", ReportsCreate)
 		reportsRoutes.PUT(":id", ReportsUpdate)
 		reportsRoutes.DELETE("", ReportsDelete)
@@ -21,7 +23,7 @@ func ReportsRoutes(api *gin.RouterGroup) {
 	}
 }
 
-func reportsListQuery(ses *utils.Session, req models.ReportsFilterRequest) ([]*models.ReportsResponse, int, int, error) {
+func reportsListFilter(ses *utils.Session, req models.ReportsFilterRequest) models.ReportsFilterRequest {
 	req.SchoolIds = &[]string{}
 	if *ses.GetRole() != models.RoleAdmin {
 		*req.SchoolIds = ses.GetSchoolIds()
@@ -42,7 +44,11 @@ func reportsListQuery(ses *utils.Session, req models.ReportsFilterRequest) ([]*m
 			req.ClassroomUids = &[]string{ses.GetUser().TeacherClassroom.ID}
 		}
 	}
+	return req
+}
 
+func reportsListQuery(ses *utils.Session, req models.ReportsFilterRequest) ([]*models.ReportsResponse, int, int, error) {
+	req = reportsListFilter(ses, req)
 	res, total, totalUnfilled, err := app.ReportsList(ses, req)
 	return res, total, totalUnfilled, err
 }
@@ -95,11 +101,54 @@ func ReportsListTotal(c *gin.Context) {
 		if err != nil {
 			return err
 		}
+		// completion of schools by region is shown to reviewers
+		var regions []*models.ReportRegionCompletion
+		if role := *ses.GetRole(); role == models.RoleAdmin || role == models.RoleOrganization {
+			f := reportsListFilter(&ses, r)
+			f.Limit = new(int)
+			*f.Limit = 1000
+			reports, _, err := store.Store().ReportsFindBy(ses.Context(), f)
+			if err != nil {
+				return err
+			}
+			reportIds := []string{}
+			for _, v := range reports {
+				reportIds = append(reportIds, v.ID)
+			}
+			var regionIds *[]string
+			if role == models.RoleOrganization {
+				regionIds = f.SchoolIds
+			}
+			regions, err = app.ReportsCompletionByRegion(&ses, reportIds, regionIds)
+			if err != nil {
+				return err
+			}
+		}
 
 		Success(c, gin.H{
 			"reports":        nil,
 			"total":          total,
 			"total_unfilled": totalUnfilled,
+			"regions":        regions,
+		})
+		return nil
+	})
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+}
+
+func ReportsInbox(c *gin.Context) {
+	ses := utils.InitSession(c)
+	err := app.Ap().UserActionCheckRead(&ses, app.PermToolReportForms, func(u *models.User) error {
+		items, err := app.ReportsInbox(&ses)
+		if err != nil {
+			return err
+		}
+		Success(c, gin.H{
+			"report_items": items,
+			"total":        len(items),
 		})
 		return nil
 	})
@@ -0,0 +1,211 @@
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/mekdep/server/internal/api/utils"
+	"github.com/mekdep/server/internal/app"
+	"github.com/mekdep/server/internal/models"
+)
+
+func RoleRoutes(api *gin.RouterGroup) {
+	r := api.Group("/roles")
+	{
+		r.GET("", RoleList)
+		r.GET("permissions", RolePermissionList)
+		r.POST("", RoleCreate)
+		r.PUT(":code", RoleUpdate)
+		r.DELETE(":code", RoleDelete)
+		r.PUT(":code/overrides", RoleOverridesUpdate)
+		r.POST(":code/assign", RoleAssign)
+	}
+}
+
+func RoleList(c *gin.Context) {
+	ses := utils.InitSession(c)
+	err := app.Ap().UserActionCheckRead(&ses, app.PermAdminRoles, func(user *models.User) error {
+		var schoolId *string
+		if v := c.Query("school_id"); v != "" {
+			schoolId = &v
+		}
+		l, err := app.RolesList(&ses, schoolId)
+		if err != nil {
+			return err
+		}
+		Success(c, gin.H{
+			"roles": l,
+		})
+		return nil
+	})
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+}
+
+func RolePermissionList(c *gin.Context) {
+	ses := utils.InitSession(c)
+	err := app.Ap().UserActionCheckRead(&ses, app.PermAdminRoles, func(user *models.User) error {
+		Success(c, gin.H{
+			"permissions": app.AllPermissions,
+			"roles":       models.DefaultRoles,
+		})
+		return nil
+	})
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+}
+
+func RoleCreate(c *gin.Context) {
+	ses := utils.InitSession(c)
+	err := app.Ap().UserActionCheckWrite(&ses, app.PermAdminRoles, func(user *models.User) error {
+		r := models.RoleRequest{}
+		if errMsg, errKey := BindAndValidate(c, &r); errMsg != "" || errKey != "" {
+			return app.NewAppError(errMsg, errKey, "")
+		}
+		m, err := app.RolesCreate(&ses, r)
+		if err != nil {
+			return err
+		}
+		userLog(models.UserLog{
+			SchoolId:          ses.GetSchoolId(),
+			SessionId:         ses.GetSessionId(),
+			UserId:            user.ID,
+			SubjectId:         &m.Code,
+			Subject:           models.LogSubjectRoles,
+			SubjectAction:     models.LogActionCreate,
+			SubjectProperties: r,
+		})
+		Success(c, gin.H{
+			"role": m,
+		})
+		return nil
+	})
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+}
+
+func RoleUpdate(c *gin.Context) {
+	ses := utils.InitSession(c)
+	err := app.Ap().UserActionCheckWrite(&ses, app.PermAdminRoles, func(user *models.User) error {
+		r := models.RoleRequest{}
+		if errMsg, errKey := BindAndValidate(c, &r); errMsg != "" || errKey != "" {
+			return app.NewAppError(errMsg, errKey, "")
+		}
+		code := c.Param("code")
+		r.Code = &code
+		m, err := app.RolesUpdate(&ses, r)
+		if err != nil {
+			return err
+		}
+		userLog(models.UserLog{
+			SchoolId:          ses.GetSchoolId(),
+			SessionId:         ses.GetSessionId(),
+			UserId:            user.ID,
+			SubjectId:         &m.Code,
+			Subject:           models.LogSubjectRoles,
+			SubjectAction:     models.LogActionUpdate,
+			SubjectProperties: r,
+		})
+		Success(c, gin.H{
+			"role": m,
+		})
+		return nil
+	})
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+}
+
+func RoleDelete(c *gin.Context) {
+	ses := utils.InitSession(c)
+	err := app.Ap().UserActionCheckWrite(&ses, app.PermAdminRoles, func(user *models.User) error {
+		code := c.Param("code")
+		err := app.RolesDelete(&ses, code)
+		if err != nil {
+			return err
+		}
+		userLog(models.UserLog{
+			SchoolId:      ses.GetSchoolId(),
+			SessionId:     ses.GetSessionId(),
+			UserId:        user.ID,
+			SubjectId:     &code,
+			Subject:       models.LogSubjectRoles,
+			SubjectAction: models.LogActionDelete,
+		})
+		Success(c, gin.H{})
+		return nil
+	})
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+}
+
+func RoleOverridesUpdate(c *gin.Context) {
+	ses := utils.InitSession(c)
+	err := app.Ap().UserActionCheckWrite(&ses, app.PermAdminRoles, func(user *models.User) error {
+		r := models.RoleOverridesRequest{}
+		if errMsg, errKey := BindAndValidate(c, &r); errMsg != "" || errKey != "" {
+			return app.NewAppError(errMsg, errKey, "")
+		}
+		code := c.Param("code")
+		r.Code = &code
+		m, err := app.RolesOverride(&ses, r)
+		if err != nil {
+			return err
+		}
+		userLog(models.UserLog{
+			SchoolId:          r.SchoolId,
+			SessionId:         ses.GetSessionId(),
+			UserId:            user.ID,
+			SubjectId:         &code,
+			Subject:           models.LogSubjectRoles,
+			SubjectAction:     models.LogActionUpdate,
+			SubjectProperties: r,
+		})
+		Success(c, gin.H{
+			"role": m,
+		})
+		return nil
+	})
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+}
+
+func RoleAssign(c *gin.Context) {
+	ses := utils.InitSession(c)
+	err := app.Ap().UserActionCheckWrite(&ses, app.PermAdminRoles, func(user *models.User) error {
+		r := models.RoleAssignRequest{}
+		if errMsg, errKey := BindAndValidate(c, &r); errMsg != "" || errKey != "" {
+			return app.NewAppError(errMsg, errKey, "")
+		}
+		code := c.Param("code")
+		r.Code = &code
+		err := app.RolesAssign(&ses, r)
+		if err != nil {
+			return err
+		}
+		userLog(models.UserLog{
+			SchoolId:          r.SchoolId,
+			SessionId:         ses.GetSessionId(),
+			UserId:            user.ID,
+			SubjectId:         r.UserId,
+			Subject:           models.LogSubjectUsers,
+			SubjectAction:     models.LogActionUpdate,
+			SubjectProperties: r,
+		})
+		Success(c, gin.H{})
+		return nil
+	})
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+}
@@ -22,6 +22,7 @@ func Routes(routes *gin.Engine) {
 		ShiftRoutes(api)
 		DiaryRoutes(api)
 		JournalRoutes(api)
+		AssignmentSubmissionRoutes(api)
 		TeacherRoutes(api)
 		UserNotificationsRoutes(api)
 		NotificationsRoutes(api)
@@ -43,8 +44,13 @@ func Routes(routes *gin.Engine) {
 		ReportItemsRoutes(api)
 		TeacherExcuseRoutes(api)
 		SchoolTransferRoutes(api)
+		RoleRoutes(api)
+		CurriculumPlanRoutes(api)
+		EventRoutes(api)
+		ArchiveRoutes(api)
 	}
-	routes.Static("/uploads", "./web/uploads")
+	MetricsRoutes(routes)
+	FileRoutes(routes)
 	if !config.Conf.AppEnvIsProd {
 		routes.StaticFile("/docs", "./api/docs.html")
 		routes.StaticFile("/openapi.yaml", "./api/openapi.yaml")
@@ -30,7 +30,7 @@ func ReportRoutes(api *gin.RouterGroup) {
 func StatisticsSchoolData(c *gin.Context) {
 	ses := utils.InitSession(c)
 	err := app.Ap().UserActionCheckRead(&ses, app.PermToolReports, func(u *models.User) error {
-		startDate, endDate := getDateBetween(c)
+		startDate, endDate := getDateBetween(&ses, c)
 
 		rep, err := app.Ap().StatisticsSchoolData(&ses, ses.GetSchoolsByAdminRoles(), startDate, endDate)
 		if err != nil {
@@ -84,7 +84,7 @@ func StatisticsSchoolExams(c *gin.Context) {
 func StatisticsOnline(c *gin.Context) {
 	ses := utils.InitSession(c)
 	err := app.Ap().UserActionCheckRead(&ses, app.PermToolReports, func(u *models.User) error {
-		startDate, endDate := getDateBetween(c)
+		startDate, endDate := getDateBetween(&ses, c)
 
 		rep, err := app.Ap().StatisticsOnline(&ses, ses.SchoolAllIds(), startDate, endDate)
 		if err != nil {
@@ -351,30 +351,30 @@ func StatisticsParents(c *gin.Context) {
 	}
 }
 
-func getDateBetween(c *gin.Context) (time.Time, time.Time) {
+func getDateBetween(ses *utils.Session, c *gin.Context) (time.Time, time.Time) {
 	startDate := time.Now()
 	endDate := time.Now()
 	if startStr := c.Query("start_date"); startStr != "" {
-		item, err := ParseDate(startStr)
+		item, err := ParseDate(ses, startStr)
 		if err == nil {
 			startDate = item
 		}
 	} else if len(c.QueryArray("date[]")) > 0 {
 		if startStr := c.QueryArray("date[]")[0]; startStr != "" {
-			item, err := ParseDate(startStr)
+			item, err := ParseDate(ses, startStr)
 			if err == nil {
 				startDate = item
 			}
 		}
 	}
 	if endStr := c.Query("end_date"); endStr != "" {
-		item, err := ParseDate(endStr)
+		item, err := ParseDate(ses, endStr)
 		if err == nil {
 			endDate = item
 		}
 	} else if len(c.QueryArray("date[]")) > 1 {
 		if endStr := c.QueryArray("date[]")[1]; endStr != "" {
-			item, err := ParseDate(endStr)
+			item, err := ParseDate(ses, endStr)
 			if err == nil {
 				endDate = item
 			}
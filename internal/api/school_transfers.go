@@ -18,6 +18,7 @@ func SchoolTransferRoutes(api *gin.RouterGroup) {
 		r.GET("/inbox", SchoolTransferInbox)
 		r.PUT("/inbox/:id/status", SchoolTransferInboxUpdate)
 		r.GET("/inbox/:id", SchoolTransferInboxDetail)
+		r.GET("/inbox/:id/preview", SchoolTransferInboxPreview)
 	}
 }
 
@@ -117,17 +118,27 @@ func SchoolTransferDetail(c *gin.Context) {
 }
 
 func SchoolTransferCreate(c *gin.Context) {
+	uploadLimit(c, "school_transfers")
 	ses := utils.InitSession(c)
 	err := app.Ap().UserActionCheckWrite(&ses, app.PermAdminSchoolTransfers, func(user *models.User) (err error) {
 		req := models.SchoolTransferCreateDto{}
 		if err := BindAny(c, &req); err != nil {
 			return err
 		}
+		var senderFiles []string
+		if c.ContentType() == "multipart/form-data" {
+			senderFiles, err = handleFilesUpload(c, "files", "school_transfers")
+			if err != nil {
+				return app.NewAppError(err.Error(), "files", "")
+			}
+		}
 		req = models.SchoolTransferCreateDto{
 			StudentId:         req.StudentId,
 			TargetSchoolId:    req.TargetSchoolId,
 			SourceClassroomId: req.SourceClassroomId,
+			SenderNote:        req.SenderNote,
 			SourceSchoolId:    ses.GetSchoolId(),
+			SenderFiles:       senderFiles,
 			SentBy:            &ses.GetUser().ID,
 		}
 		req.Status = new(string)
@@ -233,6 +244,7 @@ func SchoolTransferInboxUpdate(c *gin.Context) {
 		r = models.SchoolTransferCreateDto{
 			Status:            r.Status,
 			TargetClassroomId: r.TargetClassroomId,
+			ReceiverNote:      r.ReceiverNote,
 			ReceivedBy:        &ses.GetUser().ID,
 		}
 		id := c.Param("id")
@@ -303,3 +315,31 @@ func SchoolTransferInboxDetail(c *gin.Context) {
 		return
 	}
 }
+
+func SchoolTransferInboxPreview(c *gin.Context) {
+	ses := utils.InitSession(c)
+	err := app.Ap().UserActionCheckRead(&ses, app.PermAdminSchoolTransfers, func(u *models.User) (err error) {
+		id := c.Param("id")
+		targetClassroomId := c.Query("target_classroom_id")
+		if targetClassroomId == "" {
+			return app.ErrRequired.SetKey("target_classroom_id")
+		}
+		if ok, err := schoolTransfersInboxAvailableCheck(&ses, models.SchoolTransferQueryDto{ID: &id}); err != nil {
+			return err
+		} else if !ok {
+			return app.ErrNotfound
+		}
+		response, err := app.SchoolTransfersPreview(&ses, id, targetClassroomId)
+		if err != nil {
+			return err
+		}
+		Success(c, gin.H{
+			"preview": response,
+		})
+		return nil
+	})
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+}
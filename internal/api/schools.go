@@ -195,6 +195,7 @@ func SchoolDetail(c *gin.Context) {
 }
 
 func SchoolCreate(c *gin.Context) {
+	uploadLimit(c, "schools")
 	ses := utils.InitSession(c)
 	err := app.Ap().UserActionCheckWrite(&ses, app.PermAdminSchools, func(user *models.User) (err error) {
 		r := models.SchoolRequest{}
@@ -207,7 +208,7 @@ func SchoolCreate(c *gin.Context) {
 			return err
 		}
 
-		galleries, err := handleFilesUpload(c, "galleries", "schools")
+		galleries, err := handleImagesUpload(c, "galleries", "schools")
 		if err != nil {
 			return app.NewAppError(err.Error(), "galleries", "")
 		}
@@ -216,7 +217,7 @@ func SchoolCreate(c *gin.Context) {
 			r.Galleries = &galleries
 		}
 
-		avatar, _, err := handleFileUpload(c, "avatar", "schools", true)
+		avatar, _, err := handleImageUpload(c, "avatar", "schools")
 		if err != nil {
 			return app.NewAppError(err.Error(), "avatar", "")
 		}
@@ -249,6 +250,7 @@ func SchoolCreate(c *gin.Context) {
 }
 
 func SchoolUpdate(c *gin.Context) {
+	uploadLimit(c, "schools")
 	ses := utils.InitSession(c)
 	err := app.Ap().UserActionCheckWrite(&ses, app.PermAdminUsers, func(user *models.User) (err error) {
 		r := models.SchoolRequest{}
@@ -270,7 +272,7 @@ func SchoolUpdate(c *gin.Context) {
 			model.Galleries = new([]string)
 		}
 
-		galleries, err := handleFilesUpload(c, "galleries", "schools")
+		galleries, err := handleImagesUpload(c, "galleries", "schools")
 		if err != nil {
 			return app.NewAppError(err.Error(), "galleries", "")
 		}
@@ -280,7 +282,7 @@ func SchoolUpdate(c *gin.Context) {
 			r.GalleriesDelete = &v
 		}
 
-		avatar, _, err := handleFileUpload(c, "avatar", "schools", true)
+		avatar, _, err := handleImageUpload(c, "avatar", "schools")
 		if err != nil {
 			return app.NewAppError(err.Error(), "avatar", "")
 		}
@@ -18,6 +18,8 @@ func PeriodRoutes(api *gin.RouterGroup) {
 		periodRoutes.PUT(":id", PeriodUpdate)
 		periodRoutes.POST("", PeriodCreate)
 		periodRoutes.GET(":id", PeriodDetail)
+		periodRoutes.POST("rollover", PeriodRollover)
+		periodRoutes.GET("rollovers", PeriodRolloverList)
 	}
 }
 
@@ -198,3 +200,74 @@ func PeriodDelete(c *gin.Context) {
 		return
 	}
 }
+
+// PeriodRollover moves school to next year, with is_dry_run only shows what will be done
+func PeriodRollover(c *gin.Context) {
+	ses := utils.InitSession(c)
+	err := app.Ap().UserActionCheckWrite(&ses, app.PermAdminPeriods, func(user *models.User) error {
+		r := models.RolloverRequest{}
+		if errMsg, errKey := BindAndValidate(c, &r); errMsg != "" || errKey != "" {
+			return app.NewAppError(errMsg, errKey, "")
+		}
+		if r.SchoolId == nil {
+			r.SchoolId = ses.GetSchoolId()
+		}
+		if r.SchoolId == nil {
+			return app.ErrRequired.SetKey("school_id")
+		}
+		if !slices.Contains(ses.GetSchoolIds(), *r.SchoolId) {
+			return app.ErrForbidden.SetKey("school_id")
+		}
+		res, err := app.SchoolsRollover(&ses, r)
+		if err != nil {
+			return err
+		}
+		if !r.IsDryRun {
+			userLog(models.UserLog{
+				SchoolId:          r.SchoolId,
+				SessionId:         ses.GetSessionId(),
+				UserId:            user.ID,
+				SubjectId:         res.ID,
+				Subject:           models.LogSubjectPeriod,
+				SubjectAction:     models.LogActionRollover,
+				SubjectProperties: r,
+			})
+		}
+		Success(c, gin.H{
+			"rollover": res,
+		})
+		return nil
+	})
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+}
+
+func PeriodRolloverList(c *gin.Context) {
+	ses := utils.InitSession(c)
+	err := app.Ap().UserActionCheckRead(&ses, app.PermAdminPeriods, func(user *models.User) error {
+		r := models.RolloverFilterRequest{}
+		if errMsg, errKey := BindAndValidate(c, &r); errMsg != "" || errKey != "" {
+			return app.NewAppError(errMsg, errKey, "")
+		}
+		if r.SchoolId != nil && !slices.Contains(ses.GetSchoolIds(), *r.SchoolId) {
+			return app.ErrForbidden.SetKey("school_id")
+		}
+		sids := ses.GetSchoolIds()
+		r.SchoolIds = &sids
+		l, total, err := app.RolloversList(&ses, r)
+		if err != nil {
+			return err
+		}
+		Success(c, gin.H{
+			"rollovers": l,
+			"total":     total,
+		})
+		return nil
+	})
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+}
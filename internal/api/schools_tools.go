@@ -11,6 +11,7 @@ func ToolRoutes(api *gin.RouterGroup) {
 	r := api.Group("/tools")
 	{
 		r.GET("reset", ToolResetPassword)
+		UserImportRoutes(r)
 	}
 }
 
@@ -87,6 +87,7 @@ func TeacherExcuseDetail(c *gin.Context) {
 }
 
 func TeacherExcuseCreate(c *gin.Context) {
+	uploadLimit(c, "teacher_excuses")
 	ses := utils.InitSession(c)
 	err := app.Ap().UserActionCheckWrite(&ses, app.PermAdminTeacherExcuses, func(user *models.User) (err error) {
 		// bind request
@@ -127,6 +128,7 @@ func TeacherExcuseCreate(c *gin.Context) {
 }
 
 func TeacherExcuseUpdate(c *gin.Context) {
+	uploadLimit(c, "teacher_excuses")
 	ses := utils.InitSession(c)
 	err := app.Ap().UserActionCheckWrite(&ses, app.PermAdminTeacherExcuses, func(user *models.User) (err error) {
 		// bind request
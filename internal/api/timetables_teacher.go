@@ -22,7 +22,7 @@ func TeacherTimetable(c *gin.Context) {
 		dateStr := c.Query("date")
 		date := time.Now()
 		if dateStr != "" {
-			date, err = ParseDate(dateStr)
+			date, err = ParseDate(&ses, dateStr)
 			if err != nil {
 				err = nil
 				date = time.Now()
@@ -9,6 +9,7 @@ import (
 	"github.com/mekdep/server/internal/app"
 	"github.com/mekdep/server/internal/app/app_validation"
 	"github.com/mekdep/server/internal/models"
+	"github.com/mekdep/server/internal/utils/storage"
 )
 
 func TopicRoutes(api *gin.RouterGroup) {
@@ -105,6 +106,7 @@ func TopicsDetail(c *gin.Context) {
 }
 
 func TopicsUpdate(c *gin.Context) {
+	uploadLimit(c, "topics")
 	ses := utils.InitSession(c)
 	err := app.Ap().UserActionCheckWrite(&ses, app.PermAdminTopics, func(user *models.User) (err error) {
 		r := models.TopicsRequest{}
@@ -129,9 +131,10 @@ func TopicsUpdate(c *gin.Context) {
 				*r.FilesDelete = paths
 			}
 			for _, v := range *r.FilesDelete {
-				deleteFile(c, v, "topics")
+				v = storage.PathFromUrl(v)
 				k := slices.Index(paths, v)
 				if k >= 0 {
+					deleteFile(c, v, "topics")
 					paths = slices.Delete(paths, k, k+1)
 				}
 			}
@@ -181,6 +184,7 @@ func TopicsUpdate(c *gin.Context) {
 }
 
 func TopicsCreate(c *gin.Context) {
+	uploadLimit(c, "topics")
 	ses := utils.InitSession(c)
 	err := app.Ap().UserActionCheckWrite(&ses, app.PermAdminTopics, func(user *models.User) (err error) {
 		r := models.TopicsRequest{}
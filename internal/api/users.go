@@ -31,7 +31,21 @@ func UserRoutes(api *gin.RouterGroup) {
 		userRoutes.GET("sessions", UserSessions)
 		userRoutes.DELETE("sessions", UserSessionsDelete)
 		userRoutes.POST("security", UserPasswordUpdate)
+		userRoutes.POST("me/email/verify", UserEmailVerifySend)
+		userRoutes.POST("email/verify", UserEmailVerify)
+		userRoutes.POST("password/reset", UserPasswordResetSend)
+		userRoutes.POST("password/reset/confirm", UserPasswordReset)
 		userRoutes.POST(":id/promote", UserPromote)
+		userRoutes.POST(":id/impersonate", UserImpersonate)
+		userRoutes.GET("me/2fa", UserTwoFactorStatus)
+		userRoutes.POST("me/2fa/setup", UserTwoFactorSetup)
+		userRoutes.POST("me/2fa/enable", UserTwoFactorEnable)
+		userRoutes.POST("me/2fa/recovery-codes", UserTwoFactorRecoveryCodes)
+		userRoutes.DELETE("me/2fa", UserTwoFactorDisable)
+		userRoutes.POST(":id/2fa/reset", UserTwoFactorReset)
+		userRoutes.GET("login-attempts", UserLoginAttempts)
+		UserMergeRoutes(userRoutes)
+		UserDataRequestRoutes(userRoutes)
 	}
 }
 
@@ -191,6 +205,7 @@ func UserPromote(c *gin.Context) {
 }
 
 func UserUpdate(c *gin.Context) {
+	uploadLimit(c, "users")
 	ses := utils.InitSession(c)
 	err := app.Ap().UserActionCheckWrite(&ses, app.PermAdminUsers, func(user *models.User) (err error) {
 		if _, err := c.MultipartForm(); err == nil {
@@ -351,7 +366,7 @@ func UserUpdateHandleFiles(c *gin.Context) {
 		}
 		r.DocumentFiles = &documentFiles
 
-		avatar, _, err := handleFileUpload(c, "avatar", "users", true)
+		avatar, _, err := handleImageUpload(c, "avatar", "users")
 		if err != nil {
 			return app.NewAppError(err.Error(), "avatar", "")
 		}
@@ -427,6 +442,7 @@ func UserDetail(c *gin.Context) {
 }
 
 func UserCreate(c *gin.Context) {
+	uploadLimit(c, "users")
 	ses := utils.InitSession(c)
 	err := app.Ap().UserActionCheckWrite(&ses, app.PermAdminUsers, func(user *models.User) (err error) {
 		if _, err := c.MultipartForm(); err == nil {
@@ -548,7 +564,7 @@ func UserCreateHandleFiles(c *gin.Context) {
 			r.DocumentFiles = &documentFiles
 		}
 		// handle avatar
-		avatar, _, err := handleFileUpload(c, "avatar", "users", true)
+		avatar, _, err := handleImageUpload(c, "avatar", "users")
 		if err != nil {
 			return app.NewAppError(err.Error(), "avatar", "")
 		}
@@ -828,6 +844,7 @@ func UserMe(c *gin.Context) {
 
 // TODO: refactor this to separate logic, split into "changeRole"
 func UserMeUpdate(c *gin.Context) {
+	uploadLimit(c, "users")
 	ses := utils.InitSession(c)
 	err := app.Ap().UserActionCheckRead(&ses, app.PermUser, func(user *models.User) (err error) {
 		dto := models.UserProfileUpdateRequest{}
@@ -835,7 +852,7 @@ func UserMeUpdate(c *gin.Context) {
 			return app.NewAppError(errMsg, errKey, "")
 		}
 
-		avatar, _, err := handleFileUpload(c, "avatar", "users", true)
+		avatar, _, err := handleImageUpload(c, "avatar", "users")
 		if err != nil {
 			return app.NewAppError(err.Error(), "avatar", "")
 		}
@@ -32,12 +32,21 @@ func UserLogin(c *gin.Context) {
 	}
 
 	isMobile := r.SchoolID != nil && *r.SchoolID != ""
-	model, err := app.Login(&r, !isMobile)
+	r.Ip = c.ClientIP()
+	r.Agent = c.Request.UserAgent()
+	model, security, err := app.Login(&r, !isMobile)
 	if err != nil {
 		handleError(c, err)
 		return
 	}
 	if model == nil {
+		// otp is sent or two factor code is required
+		if security != nil && security.TwoFactor != nil {
+			Success(c, gin.H{
+				"two_factor": security.TwoFactor,
+			})
+			return
+		}
 		Success(c, gin.H{})
 		return
 	}
@@ -154,5 +163,91 @@ func UserLogin(c *gin.Context) {
 		"last_session":         resp.LastSession,
 		"token":                resp.Token,
 		"expires_at":           resp.ExpiresAt,
+		"login_alert":          security.Alert,
+		"recovery_codes":       security.RecoveryCodes,
+		"recovery_codes_left":  security.RecoveryCodesLeft,
+	})
+}
+
+func UserEmailVerifySend(c *gin.Context) {
+	ses := utils.InitSession(c)
+	err := app.Ap().UserActionCheckWrite(&ses, app.PermUser, func(user *models.User) error {
+		r := models.UserEmailVerifySendRequest{}
+		if errMsg, errKey := BindAndValidate(c, &r); errMsg != "" || errKey != "" {
+			return app.NewAppError(errMsg, errKey, "")
+		}
+		err := app.UserEmailVerifySend(&ses, r)
+		if err != nil {
+			return err
+		}
+		Success(c, gin.H{})
+		return nil
+	})
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+}
+
+// UserEmailVerify is opened from email link, so it does not require login
+func UserEmailVerify(c *gin.Context) {
+	ses := utils.InitSession(c)
+	r := models.UserEmailVerifyRequest{}
+	if errMsg, errKey := BindAndValidate(c, &r); errMsg != "" || errKey != "" {
+		handleError(c, app.NewAppError(errMsg, errKey, ""))
+		return
+	}
+	user, err := app.UserEmailVerify(&ses, r)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+	userLog(models.UserLog{
+		UserId:        user.ID,
+		Subject:       models.LogSubjectUsers,
+		SubjectAction: models.LogActionVerifyEmail,
+		SubjectProperties: gin.H{
+			"email": user.Email,
+		},
+	})
+	res := &models.UserResponse{}
+	res.FromModel(user)
+	Success(c, gin.H{
+		"user": res,
+	})
+}
+
+func UserPasswordResetSend(c *gin.Context) {
+	ses := utils.InitSession(c)
+	r := models.UserPasswordResetSendRequest{}
+	if errMsg, errKey := BindAndValidate(c, &r); errMsg != "" || errKey != "" {
+		handleError(c, app.NewAppError(errMsg, errKey, ""))
+		return
+	}
+	err := app.UserPasswordResetSend(&ses, r)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+	Success(c, gin.H{})
+}
+
+func UserPasswordReset(c *gin.Context) {
+	ses := utils.InitSession(c)
+	r := models.UserPasswordResetRequest{}
+	if errMsg, errKey := BindAndValidate(c, &r); errMsg != "" || errKey != "" {
+		handleError(c, app.NewAppError(errMsg, errKey, ""))
+		return
+	}
+	user, err := app.UserPasswordReset(&ses, r)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+	userLog(models.UserLog{
+		UserId:        user.ID,
+		Subject:       models.LogSubjectUsers,
+		SubjectAction: models.LogActionResetPassword,
 	})
+	Success(c, gin.H{})
 }
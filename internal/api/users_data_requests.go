@@ -0,0 +1,181 @@
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/mekdep/server/internal/api/utils"
+	"github.com/mekdep/server/internal/app"
+	"github.com/mekdep/server/internal/models"
+)
+
+func UserDataRequestRoutes(r *gin.RouterGroup) {
+	r.GET("me/data-requests", UserDataRequestListMe)
+	r.POST("me/data-requests", UserDataRequestCreate)
+	r.GET("data-requests", UserDataRequestList)
+	r.POST(":id/data-requests", UserDataRequestCreateFor)
+	r.POST("data-requests/:id/approve", UserDataRequestApprove)
+	r.POST("data-requests/:id/reject", UserDataRequestReject)
+}
+
+func userDataRequestLog(ses *utils.Session, user *models.User, res *models.UserDataRequestResponse, action models.LogAction) {
+	userLog(models.UserLog{
+		SchoolId:      ses.GetSchoolId(),
+		SessionId:     ses.GetSessionId(),
+		UserId:        user.ID,
+		SubjectId:     &res.ID,
+		Subject:       models.LogSubjectUserDataRequests,
+		SubjectAction: action,
+		SubjectProperties: gin.H{
+			"user_id": res.UserId,
+			"type":    res.Type,
+			"status":  res.Status,
+		},
+	})
+}
+
+// UserDataRequestListMe lists export and erasure requests of user and its children
+func UserDataRequestListMe(c *gin.Context) {
+	ses := utils.InitSession(c)
+	err := app.Ap().UserActionCheckRead(&ses, app.PermUser, func(user *models.User) error {
+		r := models.UserDataRequestFilterRequest{}
+		if errMsg, errKey := BindAndValidate(c, &r); errMsg != "" || errKey != "" {
+			return app.NewAppError(errMsg, errKey, "")
+		}
+		l, total, err := app.UserDataRequestListMe(&ses, r)
+		if err != nil {
+			return err
+		}
+		Success(c, gin.H{
+			"data_requests": l,
+			"total":         total,
+		})
+		return nil
+	})
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+}
+
+// UserDataRequestCreate starts export of own data (with children for parent) or asks erasure of own or child data
+func UserDataRequestCreate(c *gin.Context) {
+	ses := utils.InitSession(c)
+	err := app.Ap().UserActionCheckWrite(&ses, app.PermUser, func(user *models.User) error {
+		r := models.UserDataRequestRequest{}
+		if errMsg, errKey := BindAndValidate(c, &r); errMsg != "" || errKey != "" {
+			return app.NewAppError(errMsg, errKey, "")
+		}
+		res, err := app.UserDataRequestCreate(&ses, r)
+		if err != nil {
+			return err
+		}
+		userDataRequestLog(&ses, user, res, models.LogActionCreate)
+		Success(c, gin.H{
+			"data_request": res,
+		})
+		return nil
+	})
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+}
+
+// UserDataRequestList is queue of requests of users of schools, erasures with status wait are approved by admin
+func UserDataRequestList(c *gin.Context) {
+	ses := utils.InitSession(c)
+	err := app.Ap().UserActionCheckRead(&ses, app.PermAdminUsers, func(user *models.User) error {
+		r := models.UserDataRequestFilterRequest{}
+		if errMsg, errKey := BindAndValidate(c, &r); errMsg != "" || errKey != "" {
+			return app.NewAppError(errMsg, errKey, "")
+		}
+		r.SchoolIds = nil
+		if *ses.GetRole() != models.RoleAdmin {
+			sids := ses.GetSchoolIds()
+			r.SchoolIds = &sids
+		}
+		l, total, err := app.UserDataRequestList(&ses, r)
+		if err != nil {
+			return err
+		}
+		Success(c, gin.H{
+			"data_requests": l,
+			"total":         total,
+		})
+		return nil
+	})
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+}
+
+// UserDataRequestCreateFor is request of operator for user of its schools
+func UserDataRequestCreateFor(c *gin.Context) {
+	ses := utils.InitSession(c)
+	err := app.Ap().UserActionCheckWrite(&ses, app.PermAdminUsers, func(user *models.User) error {
+		r := models.UserDataRequestRequest{}
+		if errMsg, errKey := BindAndValidate(c, &r); errMsg != "" || errKey != "" {
+			return app.NewAppError(errMsg, errKey, "")
+		}
+		res, err := app.UserDataRequestCreateFor(&ses, c.Param("id"), r)
+		if err != nil {
+			return err
+		}
+		userDataRequestLog(&ses, user, res, models.LogActionCreate)
+		Success(c, gin.H{
+			"data_request": res,
+		})
+		return nil
+	})
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+}
+
+// UserDataRequestApprove lets erasure anonymise user
+func UserDataRequestApprove(c *gin.Context) {
+	ses := utils.InitSession(c)
+	err := app.Ap().UserActionCheckWrite(&ses, app.PermAdminUserErasures, func(user *models.User) error {
+		r := models.UserDataRequestReviewRequest{}
+		if errMsg, errKey := BindAndValidate(c, &r); errMsg != "" || errKey != "" {
+			return app.NewAppError(errMsg, errKey, "")
+		}
+		res, err := app.UserDataRequestApprove(&ses, c.Param("id"), r)
+		if err != nil {
+			return err
+		}
+		userDataRequestLog(&ses, user, res, models.LogActionApprove)
+		Success(c, gin.H{
+			"data_request": res,
+		})
+		return nil
+	})
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+}
+
+func UserDataRequestReject(c *gin.Context) {
+	ses := utils.InitSession(c)
+	err := app.Ap().UserActionCheckWrite(&ses, app.PermAdminUserErasures, func(user *models.User) error {
+		r := models.UserDataRequestReviewRequest{}
+		if errMsg, errKey := BindAndValidate(c, &r); errMsg != "" || errKey != "" {
+			return app.NewAppError(errMsg, errKey, "")
+		}
+		res, err := app.UserDataRequestReject(&ses, c.Param("id"), r)
+		if err != nil {
+			return err
+		}
+		userDataRequestLog(&ses, user, res, models.LogActionReject)
+		Success(c, gin.H{
+			"data_request": res,
+		})
+		return nil
+	})
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+}
@@ -0,0 +1,54 @@
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/mekdep/server/internal/api/utils"
+	"github.com/mekdep/server/internal/app"
+	"github.com/mekdep/server/internal/models"
+)
+
+// UserImpersonate returns read only token of user in role, requests by token are logged with real actor
+func UserImpersonate(c *gin.Context) {
+	ses := utils.InitSession(c)
+	err := app.Ap().UserActionCheckWrite(&ses, app.PermAdminImpersonate, func(user *models.User) error {
+		r := models.ImpersonationRequest{}
+		if errMsg, errKey := BindAndValidate(c, &r); errMsg != "" || errKey != "" {
+			return app.NewAppError(errMsg, errKey, "")
+		}
+		id := c.Param("id")
+		r.UserId = &id
+		target, userSchool, ttl, err := app.ImpersonationStart(&ses, r)
+		if err != nil {
+			return err
+		}
+		claims, sesId, err := utils.GenerateImpersonationToken(c, *target, &userSchool.RoleCode, userSchool.SchoolUid, r.PeriodId, user.ID, ttl)
+		if err != nil {
+			return err
+		}
+		userLog(models.UserLog{
+			SchoolId:          userSchool.SchoolUid,
+			SessionId:         ses.GetSessionId(),
+			UserId:            user.ID,
+			SubjectId:         &target.ID,
+			Subject:           models.LogSubjectUsers,
+			SubjectAction:     models.LogActionImpersonate,
+			SubjectProperties: r,
+		})
+		userRes := models.UserResponse{}
+		userRes.FromModel(target)
+		Success(c, gin.H{
+			"user":            userRes,
+			"current_role":    userSchool.RoleCode,
+			"current_school":  userSchool.SchoolUid,
+			"session_id":      sesId,
+			"new_token":       claims["token"],
+			"expires_at":      claims["exp"],
+			"impersonator_id": user.ID,
+		})
+		return nil
+	})
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+}
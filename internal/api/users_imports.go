@@ -0,0 +1,196 @@
+package api
+
+import (
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mekdep/server/internal/api/utils"
+	"github.com/mekdep/server/internal/app"
+	"github.com/mekdep/server/internal/app/app_validation"
+	"github.com/mekdep/server/internal/models"
+)
+
+func UserImportRoutes(r *gin.RouterGroup) {
+	r.GET("imports", UserImportList)
+	r.POST("imports", UserImportUpload)
+	r.GET("imports/:id", UserImportDetail)
+	r.POST("imports/:id/dry-run", UserImportDryRun)
+	r.POST("imports/:id/commit", UserImportCommit)
+	r.POST("imports/:id/rollback", UserImportRollback)
+}
+
+// userImportValidate checks rows as UserCreateBatch does, phone and username are unique
+func userImportValidate(ses *utils.Session) app.UserImportValidate {
+	return func(dto models.UserRequest) ([]models.UserImportError, error) {
+		errs := []models.UserImportError{}
+		err := app_validation.ValidateUser(ses, dto, true)
+		if errA, ok := err.(app_validation.AppErrorCollection); err != nil && ok {
+			for _, e := range errA.Errors {
+				errs = append(errs, models.UserImportError{Key: e.Key(), Code: e.Code(), Comment: e.Comment()})
+			}
+		} else if err != nil {
+			return nil, err
+		}
+		return errs, nil
+	}
+}
+
+func UserImportList(c *gin.Context) {
+	ses := utils.InitSession(c)
+	err := app.Ap().UserActionCheckRead(&ses, app.PermToolImport, func(user *models.User) error {
+		r := models.UserImportFilterRequest{}
+		if errMsg, errKey := BindAndValidate(c, &r); errMsg != "" || errKey != "" {
+			return app.NewAppError(errMsg, errKey, "")
+		}
+		if r.SchoolId != nil && !slices.Contains(ses.GetSchoolIds(), *r.SchoolId) {
+			return app.ErrForbidden.SetKey("school_id")
+		}
+		sids := ses.GetSchoolIds()
+		r.SchoolIds = &sids
+		l, total, err := app.UserImportList(&ses, r)
+		if err != nil {
+			return err
+		}
+		Success(c, gin.H{
+			"imports": l,
+			"total":   total,
+		})
+		return nil
+	})
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+}
+
+func UserImportDetail(c *gin.Context) {
+	ses := utils.InitSession(c)
+	err := app.Ap().UserActionCheckRead(&ses, app.PermToolImport, func(user *models.User) error {
+		res, err := app.UserImportDetail(&ses, c.Param("id"))
+		if err != nil {
+			return err
+		}
+		Success(c, gin.H{
+			"import": res,
+		})
+		return nil
+	})
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+}
+
+// UserImportUpload reads xlsx or csv file of students with parents or of teachers, columns are mapped by headers
+func UserImportUpload(c *gin.Context) {
+	ses := utils.InitSession(c)
+	err := app.Ap().UserActionCheckWrite(&ses, app.PermToolImport, func(user *models.User) error {
+		r := models.UserImportUploadRequest{}
+		if errMsg, errKey := BindAndValidate(c, &r); errMsg != "" || errKey != "" {
+			return app.NewAppError(errMsg, errKey, "")
+		}
+		c.Request.ParseMultipartForm(10 << 20)
+		file, handler, err := c.Request.FormFile("file")
+		if err != nil {
+			return app.ErrRequired.SetKey("file")
+		}
+		defer file.Close()
+		res, err := app.UserImportUpload(&ses, r.Type, handler.Filename, file)
+		if err != nil {
+			return err
+		}
+		Success(c, gin.H{
+			"import": res,
+		})
+		return nil
+	})
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+}
+
+// UserImportDryRun saves mapping of columns and returns report of rows: create, merge, conflict or error
+func UserImportDryRun(c *gin.Context) {
+	ses := utils.InitSession(c)
+	err := app.Ap().UserActionCheckWrite(&ses, app.PermToolImport, func(user *models.User) error {
+		r := models.UserImportMappingRequest{}
+		if errMsg, errKey := BindAndValidate(c, &r); errMsg != "" || errKey != "" {
+			return app.NewAppError(errMsg, errKey, "")
+		}
+		res, err := app.UserImportDryRun(&ses, c.Param("id"), r, userImportValidate(&ses))
+		if err != nil {
+			return err
+		}
+		Success(c, gin.H{
+			"import": res,
+		})
+		return nil
+	})
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+}
+
+// UserImportCommit starts background commit of checked rows, status of import shows progress
+func UserImportCommit(c *gin.Context) {
+	ses := utils.InitSession(c)
+	err := app.Ap().UserActionCheckWrite(&ses, app.PermToolImport, func(user *models.User) error {
+		res, err := app.UserImportCommit(&ses, c.Param("id"), userImportValidate(&ses))
+		if err != nil {
+			return err
+		}
+		userLog(models.UserLog{
+			SchoolId:      &res.SchoolId,
+			SessionId:     ses.GetSessionId(),
+			UserId:        user.ID,
+			SubjectId:     &res.ID,
+			Subject:       models.LogSubjectUserImports,
+			SubjectAction: models.LogActionImport,
+			SubjectProperties: gin.H{
+				"file_name":    res.FileName,
+				"type":         res.Type,
+				"create_count": res.CreateCount,
+				"merge_count":  res.MergeCount,
+			},
+		})
+		Success(c, gin.H{
+			"import": res,
+		})
+		return nil
+	})
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+}
+
+// UserImportRollback removes what commit of batch added
+func UserImportRollback(c *gin.Context) {
+	ses := utils.InitSession(c)
+	err := app.Ap().UserActionCheckWrite(&ses, app.PermToolImport, func(user *models.User) error {
+		res, deleted, err := app.UserImportRollback(&ses, c.Param("id"))
+		if err != nil {
+			return err
+		}
+		userLog(models.UserLog{
+			SchoolId:          &res.SchoolId,
+			SessionId:         ses.GetSessionId(),
+			UserId:            user.ID,
+			SubjectId:         &res.ID,
+			Subject:           models.LogSubjectUserImports,
+			SubjectAction:     models.LogActionRollback,
+			SubjectProperties: gin.H{"deleted_users": deleted},
+		})
+		Success(c, gin.H{
+			"import":        res,
+			"deleted_users": deleted,
+		})
+		return nil
+	})
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+}
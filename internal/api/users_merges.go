@@ -0,0 +1,190 @@
+package api
+
+import (
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mekdep/server/internal/api/utils"
+	"github.com/mekdep/server/internal/app"
+	"github.com/mekdep/server/internal/models"
+)
+
+func UserMergeRoutes(r *gin.RouterGroup) {
+	r.GET("duplicates", UserDuplicateList)
+	r.POST("duplicates/scan", UserDuplicateScan)
+	r.POST("duplicates/:id/dismiss", UserDuplicateDismiss)
+	r.GET("merges", UserMergeList)
+	r.POST("merges", UserMerge)
+	r.POST("merges/:id/undo", UserMergeUndo)
+}
+
+// UserDuplicateList is review queue of pairs which look like same person, pending pairs by default
+func UserDuplicateList(c *gin.Context) {
+	ses := utils.InitSession(c)
+	err := app.Ap().UserActionCheckRead(&ses, app.PermAdminUserMerges, func(user *models.User) error {
+		r := models.UserDuplicateFilterRequest{}
+		if errMsg, errKey := BindAndValidate(c, &r); errMsg != "" || errKey != "" {
+			return app.NewAppError(errMsg, errKey, "")
+		}
+		if r.SchoolId != nil && !slices.Contains(ses.GetSchoolIds(), *r.SchoolId) {
+			return app.ErrForbidden.SetKey("school_id")
+		}
+		sids := ses.GetSchoolIds()
+		r.SchoolIds = &sids
+		if r.Status == nil {
+			status := models.UserDuplicatePending
+			r.Status = &status
+		}
+		l, total, err := app.UserDuplicateList(&ses, r)
+		if err != nil {
+			return err
+		}
+		Success(c, gin.H{
+			"duplicates": l,
+			"total":      total,
+		})
+		return nil
+	})
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+}
+
+// UserDuplicateScan finds duplicates of school again, reviewed pairs are kept
+func UserDuplicateScan(c *gin.Context) {
+	ses := utils.InitSession(c)
+	err := app.Ap().UserActionCheckWrite(&ses, app.PermAdminUserMerges, func(user *models.User) error {
+		r := models.UserDuplicateScanRequest{}
+		if errMsg, errKey := BindAndValidate(c, &r); errMsg != "" || errKey != "" {
+			return app.NewAppError(errMsg, errKey, "")
+		}
+		found, err := app.UserDuplicateScan(&ses, r.SchoolId)
+		if err != nil {
+			return err
+		}
+		Success(c, gin.H{
+			"found": found,
+		})
+		return nil
+	})
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+}
+
+func UserDuplicateDismiss(c *gin.Context) {
+	ses := utils.InitSession(c)
+	err := app.Ap().UserActionCheckWrite(&ses, app.PermAdminUserMerges, func(user *models.User) error {
+		res, err := app.UserDuplicateDismiss(&ses, c.Param("id"))
+		if err != nil {
+			return err
+		}
+		Success(c, gin.H{
+			"duplicate": res,
+		})
+		return nil
+	})
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+}
+
+func UserMergeList(c *gin.Context) {
+	ses := utils.InitSession(c)
+	err := app.Ap().UserActionCheckRead(&ses, app.PermAdminUserMerges, func(user *models.User) error {
+		r := models.UserMergeFilterRequest{}
+		if errMsg, errKey := BindAndValidate(c, &r); errMsg != "" || errKey != "" {
+			return app.NewAppError(errMsg, errKey, "")
+		}
+		if r.SchoolId != nil && !slices.Contains(ses.GetSchoolIds(), *r.SchoolId) {
+			return app.ErrForbidden.SetKey("school_id")
+		}
+		if *ses.GetRole() != models.RoleAdmin {
+			sids := ses.GetSchoolIds()
+			r.SchoolIds = &sids
+		}
+		l, total, err := app.UserMergeList(&ses, r)
+		if err != nil {
+			return err
+		}
+		Success(c, gin.H{
+			"merges": l,
+			"total":  total,
+		})
+		return nil
+	})
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+}
+
+// UserMerge moves grades, absents, parents, classrooms, payments, sessions and messages of duplicate onto survivor
+func UserMerge(c *gin.Context) {
+	ses := utils.InitSession(c)
+	err := app.Ap().UserActionCheckWrite(&ses, app.PermAdminUserMerges, func(user *models.User) error {
+		r := models.UserMergeRequest{}
+		if errMsg, errKey := BindAndValidate(c, &r); errMsg != "" || errKey != "" {
+			return app.NewAppError(errMsg, errKey, "")
+		}
+		res, err := app.UserMerge(&ses, r)
+		if err != nil {
+			return err
+		}
+		userLog(models.UserLog{
+			SchoolId:      res.SchoolId,
+			SessionId:     ses.GetSessionId(),
+			UserId:        user.ID,
+			SubjectId:     &res.ID,
+			Subject:       models.LogSubjectUserMerges,
+			SubjectAction: models.LogActionMerge,
+			SubjectProperties: gin.H{
+				"survivor_id":  res.SurvivorId,
+				"duplicate_id": res.DuplicateId,
+				"moved_counts": res.MovedCounts,
+			},
+		})
+		Success(c, gin.H{
+			"merge": res,
+		})
+		return nil
+	})
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+}
+
+// UserMergeUndo moves rows of merge back to duplicate and restores its status
+func UserMergeUndo(c *gin.Context) {
+	ses := utils.InitSession(c)
+	err := app.Ap().UserActionCheckWrite(&ses, app.PermAdminUserMerges, func(user *models.User) error {
+		res, err := app.UserMergeUndo(&ses, c.Param("id"))
+		if err != nil {
+			return err
+		}
+		userLog(models.UserLog{
+			SchoolId:      res.SchoolId,
+			SessionId:     ses.GetSessionId(),
+			UserId:        user.ID,
+			SubjectId:     &res.ID,
+			Subject:       models.LogSubjectUserMerges,
+			SubjectAction: models.LogActionUndo,
+			SubjectProperties: gin.H{
+				"survivor_id":  res.SurvivorId,
+				"duplicate_id": res.DuplicateId,
+			},
+		})
+		Success(c, gin.H{
+			"merge": res,
+		})
+		return nil
+	})
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+}
@@ -0,0 +1,189 @@
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/mekdep/server/internal/api/utils"
+	"github.com/mekdep/server/internal/app"
+	"github.com/mekdep/server/internal/models"
+)
+
+func UserTwoFactorStatus(c *gin.Context) {
+	ses := utils.InitSession(c)
+	err := app.Ap().UserActionCheckRead(&ses, app.PermUser, func(user *models.User) error {
+		res, err := app.TwoFactorStatus(&ses)
+		if err != nil {
+			return err
+		}
+		Success(c, gin.H{
+			"two_factor": res,
+		})
+		return nil
+	})
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+}
+
+func UserTwoFactorSetup(c *gin.Context) {
+	ses := utils.InitSession(c)
+	err := app.Ap().UserActionCheckWrite(&ses, app.PermUser, func(user *models.User) error {
+		res, err := app.TwoFactorSetup(&ses)
+		if err != nil {
+			return err
+		}
+		Success(c, gin.H{
+			"two_factor": res,
+		})
+		return nil
+	})
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+}
+
+func UserTwoFactorEnable(c *gin.Context) {
+	ses := utils.InitSession(c)
+	err := app.Ap().UserActionCheckWrite(&ses, app.PermUser, func(user *models.User) error {
+		r := models.TwoFactorCodeRequest{}
+		if errMsg, errKey := BindAndValidate(c, &r); errMsg != "" || errKey != "" {
+			return app.NewAppError(errMsg, errKey, "")
+		}
+		codes, err := app.TwoFactorEnable(&ses, r)
+		if err != nil {
+			return err
+		}
+		userLog(models.UserLog{
+			SchoolId:      ses.GetSchoolId(),
+			SessionId:     ses.GetSessionId(),
+			UserId:        user.ID,
+			SubjectId:     &user.ID,
+			Subject:       models.LogSubjectUsers,
+			SubjectAction: models.LogActionTwoFactorEnable,
+		})
+		Success(c, gin.H{
+			"recovery_codes": codes,
+		})
+		return nil
+	})
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+}
+
+func UserTwoFactorRecoveryCodes(c *gin.Context) {
+	ses := utils.InitSession(c)
+	err := app.Ap().UserActionCheckWrite(&ses, app.PermUser, func(user *models.User) error {
+		r := models.TwoFactorCodeRequest{}
+		if errMsg, errKey := BindAndValidate(c, &r); errMsg != "" || errKey != "" {
+			return app.NewAppError(errMsg, errKey, "")
+		}
+		codes, err := app.TwoFactorRecoveryCodes(&ses, r)
+		if err != nil {
+			return err
+		}
+		Success(c, gin.H{
+			"recovery_codes": codes,
+		})
+		return nil
+	})
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+}
+
+func UserTwoFactorDisable(c *gin.Context) {
+	ses := utils.InitSession(c)
+	err := app.Ap().UserActionCheckWrite(&ses, app.PermUser, func(user *models.User) error {
+		r := models.TwoFactorDisableRequest{}
+		if errMsg, errKey := BindAndValidate(c, &r); errMsg != "" || errKey != "" {
+			return app.NewAppError(errMsg, errKey, "")
+		}
+		err := app.TwoFactorDisable(&ses, r)
+		if err != nil {
+			return err
+		}
+		userLog(models.UserLog{
+			SchoolId:      ses.GetSchoolId(),
+			SessionId:     ses.GetSessionId(),
+			UserId:        user.ID,
+			SubjectId:     &user.ID,
+			Subject:       models.LogSubjectUsers,
+			SubjectAction: models.LogActionTwoFactorDisable,
+		})
+		Success(c, gin.H{})
+		return nil
+	})
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+}
+
+// UserTwoFactorReset removes two factor and lockout of user who lost authenticator
+func UserTwoFactorReset(c *gin.Context) {
+	ses := utils.InitSession(c)
+	err := app.Ap().UserActionCheckWrite(&ses, app.PermAdminUsers, func(user *models.User) error {
+		id := c.Param("id")
+		if ok, err := userAvailableCheck(&ses, models.UserFilterRequest{ID: &id}); err != nil {
+			return err
+		} else if !ok {
+			return app.ErrNotfound
+		}
+		err := app.TwoFactorReset(&ses, id)
+		if err != nil {
+			return err
+		}
+		userLog(models.UserLog{
+			SchoolId:      ses.GetSchoolId(),
+			SessionId:     ses.GetSessionId(),
+			UserId:        user.ID,
+			SubjectId:     &id,
+			Subject:       models.LogSubjectUsers,
+			SubjectAction: models.LogActionTwoFactorReset,
+		})
+		Success(c, gin.H{})
+		return nil
+	})
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+}
+
+// UserLoginAttempts lists login attempts, admin sees all, others only of available user
+func UserLoginAttempts(c *gin.Context) {
+	ses := utils.InitSession(c)
+	err := app.Ap().UserActionCheckRead(&ses, app.PermToolLogs, func(user *models.User) error {
+		r := models.LoginAttemptFilterRequest{}
+		if errMsg, errKey := BindAndValidate(c, &r); errMsg != "" || errKey != "" {
+			return app.NewAppError(errMsg, errKey, "")
+		}
+		if *ses.GetRole() != models.RoleAdmin {
+			if r.UserId == nil {
+				return app.ErrRequired.SetKey("user_id")
+			}
+			if ok, err := userAvailableCheck(&ses, models.UserFilterRequest{ID: r.UserId}); err != nil {
+				return err
+			} else if !ok {
+				return app.ErrNotfound.SetKey("user_id")
+			}
+		}
+		l, total, err := app.LoginAttemptsList(&ses, r)
+		if err != nil {
+			return err
+		}
+		Success(c, gin.H{
+			"login_attempts": l,
+			"total":          total,
+		})
+		return nil
+	})
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+}
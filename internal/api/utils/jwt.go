@@ -60,10 +60,36 @@ func JwtTokenParse(c *gin.Context) {
 		return
 	}
 	PrepareSession(c, jwtToken, string(userId), string(role), string(schoolId), string(periodId))
+	// token of impersonation, read only session of user opened by admin or operator
+	if impersonatorId, ok := claims["impersonator_id"].(string); ok && impersonatorId != "" {
+		c.Set("impersonator_id", impersonatorId)
+	}
+	// request proxied by live server to archive server, see middleware.ArchiveProxy
+	if c.GetHeader("X-Archive-Signature") != "" {
+		if _, err := SessionByToken(jwtToken); err != nil {
+			exp, _ := claims["exp"].(float64)
+			err = SessionArchiveLoad(c, jwtToken, userId, time.Unix(int64(exp), 0))
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+					"message": err.Error(),
+				})
+				return
+			}
+		}
+	}
 	SessionActByToken(jwtToken, time.Now())
 }
 
 func GenerateToken(c *gin.Context, userModel models.User, role *models.Role, schoolId *string, periodId *string, deviceToken *string) (jwt.MapClaims, *string, error) {
+	return generateToken(c, userModel, role, schoolId, periodId, deviceToken, time.Now().Add(time.Hour*24*120), nil)
+}
+
+// GenerateImpersonationToken returns short token of user with impersonator_id claim, see Session.IsImpersonated
+func GenerateImpersonationToken(c *gin.Context, userModel models.User, role *models.Role, schoolId *string, periodId *string, impersonatorId string, ttl time.Duration) (jwt.MapClaims, *string, error) {
+	return generateToken(c, userModel, role, schoolId, periodId, nil, time.Now().Add(ttl), &impersonatorId)
+}
+
+func generateToken(c *gin.Context, userModel models.User, role *models.Role, schoolId *string, periodId *string, deviceToken *string, exp time.Time, impersonatorId *string) (jwt.MapClaims, *string, error) {
 	if schoolId == nil {
 		schoolId = new(string)
 	}
@@ -71,7 +97,7 @@ func GenerateToken(c *gin.Context, userModel models.User, role *models.Role, sch
 		role = new(models.Role)
 	}
 	claims := jwt.MapClaims{}
-	claims["exp"] = time.Now().Add(time.Hour * 24 * 120).Unix()
+	claims["exp"] = exp.Unix()
 	claims["user_id"] = userModel.ID
 	claims["school_id"] = *schoolId
 	if periodId == nil {
@@ -79,6 +105,9 @@ func GenerateToken(c *gin.Context, userModel models.User, role *models.Role, sch
 	}
 	claims["period_id"] = *periodId
 	claims["role_code"] = role
+	if impersonatorId != nil {
+		claims["impersonator_id"] = *impersonatorId
+	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	tokenStr, err := token.SignedString([]byte(jwtSecretKey))
 	if err != nil {
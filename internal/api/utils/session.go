@@ -3,6 +3,8 @@ package utils
 import (
 	"context"
 	"errors"
+	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/mekdep/server/config"
@@ -16,9 +18,13 @@ type TokenClaim struct {
 	periodId string
 	// regionId uint
 	roleCode models.Role
+	// user who opened session by impersonation
+	impersonatorId string
 }
 type Session struct {
 	token   string
+	method  string
+	path    string
 	claim   TokenClaim
 	ctx     context.Context
 	model   models.Session
@@ -39,14 +45,17 @@ func InitSession(c *gin.Context) Session {
 		}
 	}
 	return Session{
-		token: token,
-		model: model,
-		ctx:   c.Request.Context(),
+		token:  token,
+		method: c.Request.Method,
+		path:   c.Request.URL.Path,
+		model:  model,
+		ctx:    c.Request.Context(),
 		claim: TokenClaim{
-			userId:   string(c.GetString("user_id")),        // PrepareSession
-			roleCode: models.Role(c.GetString("role_code")), // PrepareSession
-			schoolId: string(c.GetString("school_id")),      // PrepareSession
-			periodId: string(c.GetString("period_id")),      // PrepareSession
+			userId:         string(c.GetString("user_id")),        // PrepareSession
+			roleCode:       models.Role(c.GetString("role_code")), // PrepareSession
+			schoolId:       string(c.GetString("school_id")),      // PrepareSession
+			periodId:       string(c.GetString("period_id")),      // PrepareSession
+			impersonatorId: c.GetString("impersonator_id"),        // JwtTokenParse
 			// regionId: uint(c.GetInt("region_id")),
 		},
 		school: models.School{ID: string(c.GetString("school_id"))},
@@ -118,6 +127,48 @@ func (ses *Session) GetAvailableRole(r models.Role, sId *string) *models.UserSch
 	return nil
 }
 
+// GetCustomRole returns custom role of user for selected role and school of token
+func (ses Session) GetCustomRole() *string {
+	role := ses.GetRole()
+	if role == nil {
+		return nil
+	}
+	for _, s := range ses.user.Schools {
+		if s.RoleCode == *role && s.CustomRoleCode != nil && s.SchoolUid != nil && *s.SchoolUid == ses.claim.schoolId {
+			return s.CustomRoleCode
+		}
+	}
+	return nil
+}
+
+// GetClaimSchoolId returns selected school of token, it is set before session is loaded
+func (ses Session) GetClaimSchoolId() string {
+	return ses.claim.schoolId
+}
+
+// IsImpersonated is true when session is opened by admin or operator as the user, it is read only
+func (ses Session) IsImpersonated() bool {
+	return ses.claim.impersonatorId != ""
+}
+
+// GetImpersonatorId returns real actor of impersonated session
+func (ses Session) GetImpersonatorId() *string {
+	if ses.claim.impersonatorId == "" {
+		return nil
+	}
+	return &ses.claim.impersonatorId
+}
+
+// GetRequest returns method and path of request of session
+func (ses Session) GetRequest() string {
+	return ses.method + " " + ses.path
+}
+
+// IsReadRequest is true for requests which do not change data (GET, HEAD)
+func (ses Session) IsReadRequest() bool {
+	return ses.method == http.MethodGet || ses.method == http.MethodHead
+}
+
 func (ses *Session) GetSessionId() *string {
 	if ses.model.ID == "" {
 		return nil
@@ -264,6 +315,11 @@ func (ses Session) GetSchool() *models.School {
 	return nil
 }
 
+// GetLocation is timezone of selected school, dates of request are in it
+func (ses Session) GetLocation() *time.Location {
+	return ses.school.Location()
+}
+
 func (ses Session) GetPeriod() *models.Period {
 	if ses.period.ID != "" {
 		return &ses.period
@@ -2,9 +2,14 @@ package utils
 
 import (
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"log"
 	"slices"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -14,19 +19,19 @@ import (
 	"github.com/mekdep/server/internal/store"
 )
 
+// st is loaded sessions, request handlers and background workers use it at once so stMu guards every access
 var st []models.Session
+var stMu sync.RWMutex
 
 func SessionStoreInit() error {
-	st = []models.Session{}
 	// load all sessions from DB
-	var err error
-	st, err = store.Store().SessionsSelect(context.Background(), models.SessionFilter{})
+	l, err := store.Store().SessionsSelect(context.Background(), models.SessionFilter{})
 	if err != nil {
 		return err
 	}
 	// load relation users
 	userIds := []string{}
-	for _, v := range st {
+	for _, v := range l {
 		userIds = append(userIds, v.UserId)
 	}
 	users, err := store.Store().UsersFindByIds(context.Background(), userIds)
@@ -37,14 +42,17 @@ func SessionStoreInit() error {
 	if err != nil {
 		return err
 	}
-	for k := range st {
+	for k := range l {
 		for _, u := range users {
-			if u.ID == st[k].UserId {
-				st[k].User = *u
+			if u.ID == l[k].UserId {
+				l[k].User = *u
 				break
 			}
 		}
 	}
+	stMu.Lock()
+	st = l
+	stMu.Unlock()
 
 	return err
 }
@@ -65,11 +73,25 @@ func AddSession(c *gin.Context, claims jwt.MapClaims, userModel models.User, dev
 	})
 	ses.User = userModel
 	config.Conf.AppIsReadonly = appIsReadonly
+	stMu.Lock()
 	st = append(st, ses)
+	stMu.Unlock()
 	return ses, err
 }
 
+// SessionUsersUpdate replaces user of loaded sessions, e.g. after roles of user changed
+func SessionUsersUpdate(user models.User) {
+	stMu.Lock()
+	defer stMu.Unlock()
+	for k, v := range st {
+		if v.UserId == user.ID {
+			st[k].User = user
+		}
+	}
+}
+
 func SessionDelete(ses models.Session) {
+	stMu.Lock()
 	rk := -1
 	for k, v := range st {
 		if v.ID == ses.ID {
@@ -79,12 +101,28 @@ func SessionDelete(ses models.Session) {
 	if rk >= 0 {
 		st = append(st[:rk], st[rk+1:]...)
 	}
+	stMu.Unlock()
 	_ = store.Store().SessionsDelete(context.Background(), models.SessionFilter{
 		ID: &ses.ID,
 	})
 }
 
 func SessionDeleteByUserId(userId string) error {
+	err := SessionForgetByUserId(userId)
+	if err != nil {
+		return err
+	}
+	_ = store.Store().SessionsDelete(context.Background(), models.SessionFilter{
+		UserId: &userId,
+	})
+
+	return nil
+}
+
+// SessionForgetByUserId removes loaded sessions of user, rows in DB are kept
+func SessionForgetByUserId(userId string) error {
+	stMu.Lock()
+	defer stMu.Unlock()
 	if st == nil {
 		return errors.New("session store not set")
 	}
@@ -95,15 +133,12 @@ func SessionDeleteByUserId(userId string) error {
 		}
 	}
 	st = newSt
-
-	_ = store.Store().SessionsDelete(context.Background(), models.SessionFilter{
-		UserId: &userId,
-	})
-
 	return nil
 }
 
 func GetLastSession(userId string) (*models.Session, error) {
+	stMu.RLock()
+	defer stMu.RUnlock()
 	if st == nil {
 		return nil, errors.New("session store not set")
 	}
@@ -118,7 +153,80 @@ func GetLastSession(userId string) (*models.Session, error) {
 	return lastSession, nil
 }
 
+// archiveSignTtl is how long signature of proxied request is valid, clocks of servers may differ a little
+const archiveSignTtl = time.Minute * 5
+
+// archiveSessionIdle is how long session of proxied token is kept on archive server after its last request
+const archiveSessionIdle = time.Hour * 24
+
+// SessionArchiveSign is signature of token which live server adds to requests proxied to archive server,
+// archive servers have no sessions so they trust token signed by ARCHIVE_TOKEN
+func SessionArchiveSign(token string, ts int64) string {
+	mac := hmac.New(sha256.New, []byte(config.Conf.ArchiveToken))
+	mac.Write([]byte(strconv.FormatInt(ts, 10) + "." + token))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// sessionArchiveUser loads user of proxied token from snapshot of archive server
+var sessionArchiveUser = func(ctx context.Context, userId string) (*models.User, error) {
+	user, err := store.Store().UsersFindById(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	err = store.Store().UsersLoadRelations(ctx, &[]*models.User{user}, false)
+	return user, err
+}
+
+// SessionArchiveLoad adds session of token proxied by live server, only readonly server accepts fresh valid signature
+func SessionArchiveLoad(c *gin.Context, token string, userId string, exp time.Time) error {
+	if config.Conf.AppIsReadonly == nil || !*config.Conf.AppIsReadonly || config.Conf.ArchiveToken == "" {
+		return errors.New("not an archive server")
+	}
+	ts, err := strconv.ParseInt(c.GetHeader("X-Archive-Time"), 10, 64)
+	if err != nil {
+		return errors.New("archive time is invalid")
+	}
+	if d := time.Since(time.Unix(ts, 0)); d > archiveSignTtl || d < -archiveSignTtl {
+		return errors.New("archive signature expired")
+	}
+	if !hmac.Equal([]byte(c.GetHeader("X-Archive-Signature")), []byte(SessionArchiveSign(token, ts))) {
+		return errors.New("archive signature is invalid")
+	}
+	if _, err := SessionByToken(token); err == nil {
+		return nil
+	}
+	user, err := sessionArchiveUser(c.Request.Context(), userId)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	ses := models.Session{
+		Token:  token,
+		UserId: userId,
+		Ip:     c.ClientIP(),
+		Agent:  c.Request.UserAgent(),
+		Exp:    exp,
+		Iat:    now,
+		Lat:    now,
+		User:   *user,
+	}
+	stMu.Lock()
+	defer stMu.Unlock()
+	// archive sessions have no row (empty id), expired and idle ones are dropped so store doesn't grow
+	l := []models.Session{}
+	for _, v := range st {
+		if v.Token == token || v.ID == "" && (v.Exp.Before(now) || v.Lat.Before(now.Add(-archiveSessionIdle))) {
+			continue
+		}
+		l = append(l, v)
+	}
+	st = append(l, ses)
+	return nil
+}
+
 func SessionByToken(token string) (ses models.Session, err error) {
+	stMu.RLock()
+	defer stMu.RUnlock()
 	if st == nil {
 		err = errors.New("session store not set")
 		return
@@ -135,6 +243,8 @@ func SessionByToken(token string) (ses models.Session, err error) {
 }
 
 func SessionActByToken(token string, lat time.Time) (err error) {
+	stMu.Lock()
+	defer stMu.Unlock()
 	if st == nil {
 		err = errors.New("session store not set")
 		return
@@ -153,6 +263,8 @@ func SessionOnlineCount(schoolId *uint, min int) int {
 	c := 0
 	now := time.Now().Add(time.Hour * (-5)).Add(time.Minute * time.Duration(-min))
 	log.Println(now)
+	stMu.RLock()
+	defer stMu.RUnlock()
 	for _, v := range st {
 		if v.Iat.After(now) {
 			c++
@@ -162,6 +274,8 @@ func SessionOnlineCount(schoolId *uint, min int) int {
 }
 
 func SessionByUserId(userId string) ([]models.Session, error) {
+	stMu.RLock()
+	defer stMu.RUnlock()
 	if st == nil {
 		return nil, errors.New("session store not set")
 	}
@@ -176,6 +290,8 @@ func SessionByUserId(userId string) ([]models.Session, error) {
 }
 
 func SessionByUserIds(userIds []string) ([]models.Session, error) {
+	stMu.RLock()
+	defer stMu.RUnlock()
 	if st == nil {
 		return nil, errors.New("session store not set")
 	}
@@ -188,3 +304,18 @@ func SessionByUserIds(userIds []string) ([]models.Session, error) {
 	}
 	return s, nil
 }
+
+// SessionClearDeviceTokens removes unregistered device tokens from sessions, users stay logged in
+func SessionClearDeviceTokens(tokens []string) error {
+	if len(tokens) < 1 {
+		return nil
+	}
+	stMu.Lock()
+	for k, v := range st {
+		if v.DeviceToken != nil && slices.Contains(tokens, *v.DeviceToken) {
+			st[k].DeviceToken = nil
+		}
+	}
+	stMu.Unlock()
+	return store.Store().SessionsClearDeviceTokens(context.Background(), tokens)
+}
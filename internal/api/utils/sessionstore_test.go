@@ -0,0 +1,126 @@
+package utils
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt"
+	"github.com/mekdep/server/config"
+	"github.com/mekdep/server/internal/models"
+)
+
+func TestSessionArchiveLoad(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	readonly := true
+	config.Conf.AppIsReadonly = &readonly
+	config.Conf.ArchiveToken = "archive-secret"
+	defer func() {
+		config.Conf.AppIsReadonly = nil
+		config.Conf.ArchiveToken = ""
+		st = nil
+	}()
+	sessionArchiveUser = func(ctx context.Context, userId string) (*models.User, error) {
+		return &models.User{ID: userId}, nil
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"exp":       time.Now().Add(time.Hour).Unix(),
+		"user_id":   "u1",
+		"school_id": "",
+		"period_id": "p1",
+		"role_code": "student",
+	}).SignedString([]byte(jwtSecretKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := gin.New()
+	r.Use(JwtTokenParse)
+	r.GET("/journal", func(c *gin.Context) {
+		ses := InitSession(c)
+		c.String(http.StatusOK, ses.GetUser().ID)
+	})
+	request := func(ts int64, sign string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/journal", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("X-Archive-Time", strconv.FormatInt(ts, 10))
+		req.Header.Set("X-Archive-Signature", sign)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	now := time.Now().Unix()
+	if w := request(now, SessionArchiveSign(token, now+1)); w.Code != http.StatusUnauthorized {
+		t.Errorf("wrong signature: got %d", w.Code)
+	}
+	old := time.Now().Add(-time.Hour).Unix()
+	if w := request(old, SessionArchiveSign(token, old)); w.Code != http.StatusUnauthorized {
+		t.Errorf("expired signature: got %d", w.Code)
+	}
+	readonly = false
+	if w := request(now, SessionArchiveSign(token, now)); w.Code != http.StatusUnauthorized {
+		t.Errorf("live server: got %d", w.Code)
+	}
+	readonly = true
+	w := request(now, SessionArchiveSign(token, now))
+	if w.Code != http.StatusOK || w.Body.String() != "u1" {
+		t.Errorf("proxied request: got %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestSessionImpersonated(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	defer func() {
+		st = nil
+	}()
+	sign := func(claims jwt.MapClaims) string {
+		claims["exp"] = time.Now().Add(time.Hour).Unix()
+		claims["user_id"] = "u1"
+		claims["role_code"] = "student"
+		claims["school_id"] = ""
+		claims["period_id"] = ""
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(jwtSecretKey))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	impersonated := sign(jwt.MapClaims{"impersonator_id": "admin1"})
+	own := sign(jwt.MapClaims{})
+	st = []models.Session{{ID: "s1", Token: impersonated, UserId: "u1"}, {ID: "s2", Token: own, UserId: "u1"}}
+
+	r := gin.New()
+	r.Use(JwtTokenParse)
+	r.Any("/me", func(c *gin.Context) {
+		ses := InitSession(c)
+		actor := ""
+		if ses.GetImpersonatorId() != nil {
+			actor = *ses.GetImpersonatorId()
+		}
+		c.String(http.StatusOK, "%v %v %s", ses.IsImpersonated(), ses.IsReadRequest(), actor)
+	})
+	cases := []struct {
+		method string
+		token  string
+		want   string
+	}{
+		{http.MethodGet, impersonated, "true true admin1"},
+		{http.MethodPut, impersonated, "true false admin1"},
+		{http.MethodPost, own, "false false "},
+	}
+	for _, c := range cases {
+		req := httptest.NewRequest(c.method, "/me", nil)
+		req.Header.Set("Authorization", "Bearer "+c.token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Body.String() != c.want {
+			t.Errorf("%s: got %q, want %q", c.method, w.Body.String(), c.want)
+		}
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/mekdep/server/config"
 	"github.com/mekdep/server/internal/api/utils"
 	"github.com/mekdep/server/internal/models"
 	"github.com/mekdep/server/internal/store"
@@ -28,6 +29,13 @@ func Init() *App {
 		log.Fatal(err)
 	}
 	app.cache = cache.New(1*time.Hour, 1*time.Hour)
+	err = models.CountryPackLoad(config.Conf.CountryPackFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if _, ok := models.Countries[config.Conf.DefaultCountry]; !ok {
+		log.Fatal("country pack is not found: " + config.Conf.DefaultCountry)
+	}
 
 	return app
 }
@@ -36,6 +44,13 @@ type App struct {
 	cache *cache.Cache
 }
 
+// cacheGet wraps cache lookups to count hit rate
+func (a App) cacheGet(k string) (interface{}, bool) {
+	v, found := a.cache.Get(k)
+	apputils.MetricsCache(found)
+	return v, found
+}
+
 type AppError struct {
 	code    string
 	key     string
@@ -96,7 +111,7 @@ func userLog(ses *utils.Session, data models.UserLog) error {
 	defer sp.End()
 	go func() {
 		// delete security keys
-		secKeys := []string{"password", "otp", "device_token", "token"}
+		secKeys := []string{"password", "otp", "device_token", "token", "totp_code", "recovery_code"}
 		prStr, _ := json.Marshal(data.SubjectProperties)
 		pr := map[string]interface{}{}
 		_ = json.Unmarshal(prStr, &pr)
@@ -37,7 +37,7 @@ func (a App) Dashboards(ses *utils.Session, startDate time.Time, endDate time.Ti
 		}
 		if *r == models.RolePrincipal {
 			cKey := sesId + "_dashboard_users_count" + startDate.Format(time.DateOnly) + endDate.Format(time.DateOnly)
-			if val, found := a.cache.Get(cKey); found {
+			if val, found := a.cacheGet(cKey); found {
 				d[*r] = val.(UserDashboard)
 			} else {
 				d[*r], err = dashboardPrincipal(ses, *ses.GetSchoolId(), startDate, endDate)
@@ -57,7 +57,7 @@ func (a App) Dashboards(ses *utils.Session, startDate time.Time, endDate time.Ti
 		}
 		if *r == models.RoleAdmin || *r == models.RoleOrganization {
 			cKey := sesId + "_dashboard_users_count" + startDate.Format(time.DateOnly) + endDate.Format(time.DateOnly)
-			if val, found := a.cache.Get(cKey); found {
+			if val, found := a.cacheGet(cKey); found {
 				d[*r] = val.(UserDashboard)
 			} else {
 				d[*r], err = dashboardAdmin(ses, ses.SchoolAllIds(), startDate, endDate)
@@ -97,7 +97,7 @@ func (a App) DashboardDetails(ses *utils.Session, startDate time.Time, endDate t
 			if detailId != nil {
 				cKey += *detailId
 			}
-			// if val, found := a.cache.Get(cKey); found {
+			// if val, found := a.cacheGet(cKey); found {
 			// 	d[*r] = val.(UserDashboard)
 			// } else {
 			d[*r], err = dashboardPrincipalDetails(ses, *ses.GetSchoolId(), startDate, endDate)
@@ -120,7 +120,7 @@ func (a App) DashboardDetails(ses *utils.Session, startDate time.Time, endDate t
 			if detailId != nil {
 				cKey += *detailId
 			}
-			if val, found := a.cache.Get(cKey); found {
+			if val, found := a.cacheGet(cKey); found {
 				d[*r] = val.(UserDashboard)
 			} else {
 				d[*r], err = dashboardAdminDetails(ses, ses.SchoolAllIds(), startDate, endDate, detailId)
@@ -7,7 +7,6 @@ import (
 	"sort"
 	"time"
 
-	"github.com/mekdep/server/config"
 	"github.com/mekdep/server/internal/api/utils"
 	"github.com/mekdep/server/internal/models"
 	"github.com/mekdep/server/internal/store"
@@ -57,7 +56,7 @@ func (a App) DashboardNumbersV2Cached(ses *utils.Session, req DashboardNumbersRe
 	cacheKey := *ses.GetSessionId() + "DashboardNumbersV2Cached" + string(reqStr)
 	res := DashboardNumbersResponse{}
 	var err error
-	if val, found := a.cache.Get(cacheKey); found {
+	if val, found := a.cacheGet(cacheKey); found {
 		res = val.(DashboardNumbersResponse)
 	} else {
 		resPtr, err := a.DashboardNumbersV2(ses, req)
@@ -75,7 +74,7 @@ func (a App) DashboardDetailsV2Cached(ses *utils.Session, req DashboardDetailsRe
 	cacheKey := *ses.GetSessionId() + "DashboardDetailsV2Cached" + string(reqStr)
 	res := DashboardDetailsResponse{}
 	var err error
-	if val, found := a.cache.Get(cacheKey); found {
+	if val, found := a.cacheGet(cacheKey); found {
 		resStr := val.(string)
 		err = json.Unmarshal([]byte(resStr), &res)
 	} else {
@@ -430,7 +429,7 @@ func dashboardDetailsCurrentLesson(ses *utils.Session, req DashboardDetailsReque
 	})
 	date := *req.StartDate
 	if req.StartDate.Format(time.DateOnly) == time.Now().Format(time.DateOnly) {
-		date = time.Now().In(config.RequestLocation)
+		date = time.Now().In(ses.GetLocation())
 	}
 	dateWeekday := int(date.Weekday()) - 1
 	if dateWeekday == -1 {
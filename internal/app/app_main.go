@@ -158,7 +158,7 @@ func AppSettings(ses *utils.Session, schoolId string) (map[string]interface{}, e
 
 	// Create SubjectSetting instances
 	subjectNames := []models.SubjectElement{}
-	for _, v := range models.DefaultSubjects {
+	for _, v := range models.DefaultCountry().Subjects {
 		subjectNames = append(subjectNames, models.SubjectElement{Name: v[0], FullName: v[1], Code: v[0], Area: v[2], Color: v[3]})
 	}
 
@@ -200,7 +200,7 @@ func AppSettings(ses *utils.Session, schoolId string) (map[string]interface{}, e
 			Contact:                    appSettingsContactMessages(role),
 			BankTypes:                  models.DefaultPaymentBank,
 			BookCategories:             models.BookCategories,
-			Holidays:                   models.DefaultHolidays,
+			Holidays:                   models.DefaultCountry().Holidays,
 			MenuApps:                   menuAdd,
 			DefaultPeriod:              appSettingsPeriods(),
 			GradeUpdateMinutes:         settings.GradeUpdateMinutes,
@@ -209,6 +209,7 @@ func AppSettings(ses *utils.Session, schoolId string) (map[string]interface{}, e
 			TimetableUpdateCurrentWeek: config.Conf.TimetableUpdateWeekAvailable,
 			IsArchive:                  settings.IsArchive,
 			IsForeignCountry:           config.Conf.IsForeignCountry,
+			Country:                    models.DefaultCountry().Setting(),
 			UserDocumentKeys:           models.UserDocumentKeys,
 			ContactPhones:              settings.ContactPhones,
 		},
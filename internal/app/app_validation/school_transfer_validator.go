@@ -44,14 +44,15 @@ func ValidateSchoolTransfersInboxCreate(ses *utils.Session, dto models.SchoolTra
 	if errsE != nil {
 		errs = errsE.(AppErrorCollection)
 	}
-	if dto.TargetClassroomId == nil || *dto.TargetClassroomId == "" {
-		errs.Append(*ErrRequired.SetKey("target_classroom_id"))
-		return errs
-	}
 	if dto.Status == nil || *dto.Status == "" {
 		errs.Append(*ErrRequired.SetKey("status"))
 		return errs
 	}
+	// rejected transfer has no classroom
+	if *dto.Status == string(models.StatusAccepted) && (dto.TargetClassroomId == nil || *dto.TargetClassroomId == "") {
+		errs.Append(*ErrRequired.SetKey("target_classroom_id"))
+		return errs
+	}
 	if dto.ReceivedBy == nil || *dto.ReceivedBy == "" {
 		errs.Append(*ErrRequired.SetKey("received_by"))
 		return errs
@@ -22,6 +22,9 @@ func ValidateSchoolsCreate(ses *utils.Session, dto models.SchoolRequest) error {
 	if dto.ParentUid == nil {
 		errs.Append(*ErrRequired.SetKey("parent_id"))
 	}
+	if dto.Timezone != nil && *dto.Timezone != "" && models.LoadLocation(*dto.Timezone) == nil {
+		errs.Append(*ErrInvalid.SetKey("timezone"))
+	}
 	if !errs.HasError() {
 		return nil
 	}
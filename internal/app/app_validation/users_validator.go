@@ -67,8 +67,13 @@ func ValidateUser(ses *utils.Session, dto models.UserRequest, checkUnique bool)
 		models.RoleTeacher}, func(i models.Role) bool {
 		return slices.Contains(roleCodes, i)
 	}) {
-		if dto.Phone == nil || len(*dto.Phone) != 8 {
-			errs.Append(*ErrRequired.SetKey("phone").SetComment("phone is required and length must be 8"))
+		if dto.Phone == nil || *dto.Phone == "" {
+			errs.Append(*ErrRequired.SetKey("phone").SetComment("phone is required"))
+		}
+	}
+	if dto.Phone != nil && *dto.Phone != "" {
+		if _, err := models.PhoneE164(*dto.Phone); err != nil {
+			errs.Append(*ErrInvalid.SetKey("phone").SetComment("phone must be local number of " + models.DefaultCountry().Code + " or in international format"))
 		}
 	}
 
@@ -0,0 +1,240 @@
+package app
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/mekdep/server/config"
+	"github.com/mekdep/server/internal/api/utils"
+	"github.com/mekdep/server/internal/models"
+	"github.com/mekdep/server/internal/store"
+	apputils "github.com/mekdep/server/internal/utils"
+)
+
+// ArchiveExport writes academic year of schools (by period title, like "2024/25") into zip snapshot:
+// manifest and one json lines file per table, see models.ArchiveTables
+func ArchiveExport(ses *utils.Session, periodTitle string, schoolCodes []string, file string) (*models.ArchiveSnapshot, error) {
+	sf := models.SchoolFilterRequest{}
+	if len(schoolCodes) > 0 {
+		sf.Codes = &schoolCodes
+	}
+	sf.Limit = new(int)
+	*sf.Limit = 1000
+	schools, _, err := store.Store().SchoolsFindBy(ses.Context(), sf)
+	if err != nil {
+		return nil, err
+	}
+	m := models.ArchiveSnapshot{
+		Title:      periodTitle,
+		PeriodIds:  []string{},
+		SchoolIds:  []string{},
+		Tables:     map[string]int{},
+		ApiVersion: &config.Conf.ApiVersion,
+	}
+	for _, s := range schools {
+		m.SchoolIds = append(m.SchoolIds, s.ID)
+	}
+	pf := models.PeriodFilterRequest{SchoolIds: &m.SchoolIds}
+	pf.Limit = new(int)
+	*pf.Limit = 10000
+	periods, _, err := store.Store().PeriodsListFilters(ses.Context(), pf)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range periods {
+		if p.Title == periodTitle {
+			m.PeriodIds = append(m.PeriodIds, p.ID)
+		}
+	}
+	if len(m.PeriodIds) < 1 {
+		return nil, ErrNotfound.SetKey("period").SetComment(periodTitle)
+	}
+	log.Println("# Schools:", len(m.SchoolIds), "periods:", len(m.PeriodIds))
+
+	f, err := os.Create(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	zw := zip.NewWriter(f)
+	for _, table := range models.ArchiveTables {
+		w, err := zw.Create(table + ".jsonl")
+		if err != nil {
+			return nil, err
+		}
+		count, err := store.Store().ArchiveExportRows(ses.Context(), table, m.SchoolIds, m.PeriodIds, func(row []byte) error {
+			_, err := w.Write(append(row, '\n'))
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		m.Tables[table] = count
+		log.Println(table, count)
+	}
+	now := time.Now()
+	m.ExportedAt = &now
+	w, err := zw.Create(models.ArchiveManifestFile)
+	if err != nil {
+		return nil, err
+	}
+	err = json.NewEncoder(w).Encode(m)
+	if err != nil {
+		return nil, err
+	}
+	err = zw.Close()
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// ArchiveLoad loads zip snapshot into database of archive server, which then runs with APP_IS_READONLY
+func ArchiveLoad(ses *utils.Session, file string) (*models.ArchiveSnapshot, error) {
+	zr, err := zip.OpenReader(file)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	m := models.ArchiveSnapshot{}
+	mf, err := zr.Open(models.ArchiveManifestFile)
+	if err != nil {
+		return nil, errors.New("snapshot has no manifest: " + err.Error())
+	}
+	err = json.NewDecoder(mf).Decode(&m)
+	mf.Close()
+	if err != nil {
+		return nil, err
+	}
+	l, err := store.Store().ArchiveSnapshotsFindAll(ses.Context())
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range l {
+		for _, id := range m.PeriodIds {
+			if slices.Contains(v.PeriodIds, id) {
+				return nil, ErrUnique.SetKey("period").SetComment("already loaded by snapshot " + v.Title)
+			}
+		}
+	}
+	err = store.Store().ArchiveImport(ses.Context(), &m, func(table string, insert func(row []byte) error) error {
+		tf, err := zr.Open(table + ".jsonl")
+		if err != nil {
+			// snapshot of older version
+			log.Println(table, "skipped")
+			return nil
+		}
+		defer tf.Close()
+		count := 0
+		r := bufio.NewReader(tf)
+		for {
+			row, err := r.ReadBytes('\n')
+			if len(row) > 1 {
+				if err := insert(row); err != nil {
+					return err
+				}
+				count++
+			}
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return err
+			}
+		}
+		log.Println(table, count)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// ArchiveSnapshots lists snapshots loaded in this server
+func ArchiveSnapshots(ses *utils.Session) ([]*models.ArchiveSnapshot, error) {
+	return store.Store().ArchiveSnapshotsFindAll(ses.Context())
+}
+
+const archiveServersTtl = time.Minute * 10
+
+// periods of archive servers by their manifests, reloaded after ttl
+var archiveServers = struct {
+	sync.Mutex
+	periods  map[string]string
+	loadedAt time.Time
+}{}
+
+// ArchiveServerByPeriod returns url of archive server which has period, empty when period is live
+func ArchiveServerByPeriod(periodId string) string {
+	if periodId == "" || len(config.Conf.ArchiveServers) < 1 {
+		return ""
+	}
+	archiveServers.Lock()
+	known := archiveServers.periods
+	if known != nil && time.Since(archiveServers.loadedAt) < archiveServersTtl {
+		archiveServers.Unlock()
+		return known[periodId]
+	}
+	// other requests use known periods while servers are asked
+	archiveServers.loadedAt = time.Now()
+	archiveServers.Unlock()
+
+	periods := map[string]string{}
+	for _, url := range config.Conf.ArchiveServers {
+		l, err := archiveServerSnapshots(url)
+		if err != nil {
+			apputils.LoggerDesc("In ArchiveServerByPeriod " + url).Error(err)
+			// keep known periods of server till it answers
+			for k, v := range known {
+				if v == url {
+					periods[k] = v
+				}
+			}
+			continue
+		}
+		for _, s := range l {
+			for _, id := range s.PeriodIds {
+				periods[id] = url
+			}
+		}
+	}
+	archiveServers.Lock()
+	archiveServers.periods = periods
+	archiveServers.Unlock()
+	return periods[periodId]
+}
+
+func archiveServerSnapshots(url string) ([]*models.ArchiveSnapshot, error) {
+	req, err := http.NewRequest(http.MethodGet, url+"/archive/snapshots", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Archive-Token", config.Conf.ArchiveToken)
+	client := &http.Client{Timeout: time.Second * 10}
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, errors.New("archive server answered " + res.Status)
+	}
+	body := struct {
+		Snapshots []*models.ArchiveSnapshot `json:"snapshots"`
+	}{}
+	err = json.NewDecoder(res.Body).Decode(&body)
+	if err != nil {
+		return nil, err
+	}
+	return body.Snapshots, nil
+}
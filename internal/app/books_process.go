@@ -0,0 +1,253 @@
+package app
+
+import (
+	"context"
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/gen2brain/go-fitz"
+	"github.com/mekdep/server/internal/api/utils"
+	"github.com/mekdep/server/internal/models"
+	"github.com/mekdep/server/internal/store"
+	"github.com/mekdep/server/internal/utils/storage"
+	"go.elastic.co/apm/v2"
+)
+
+const (
+	bookCoverDpi      = 100
+	bookPageDpi       = 50
+	bookPageThumbSize = 320
+)
+
+var bookProcessQueue = newJobQueue("BookProcess", func(ctx context.Context, id string) (bool, error) {
+	return store.Store().BookProcessClaim(ctx, id)
+}, bookProcess)
+
+// BookProcessEnqueue schedules pdf processing, if queue is full
+// book stays pending and is picked later by BookProcessPending
+func BookProcessEnqueue(id string) {
+	bookProcessQueue.Enqueue(id)
+}
+
+// BookProcessWorker processes books one by one, pdf rendering is heavy on cpu
+func BookProcessWorker() {
+	bookProcessQueue.Work()
+}
+
+// BookProcessPending enqueues books left pending after restart or stuck in processing
+func BookProcessPending() error {
+	ids, err := store.Store().BookProcessList(context.Background(), 50)
+	if err != nil {
+		return err
+	}
+	bookProcessQueue.EnqueueAll(ids)
+	return nil
+}
+
+// bookProcess renders claimed book, see bookProcessQueue
+func bookProcess(ctx context.Context, id string) error {
+	book, err := store.Store().BookFindById(ctx, id)
+	if err != nil {
+		return err
+	}
+	// only processed columns are saved, book could be edited meanwhile
+	res := &models.Book{ID: book.ID, IsDownloadable: book.IsDownloadable}
+	err = bookRender(ctx, book, res)
+	status := models.BookProcessDone
+	if err != nil {
+		status = models.BookProcessFailed
+	}
+	res.ProcessStatus = &status
+	if _, uErr := store.Store().BookUpdate(ctx, res); uErr != nil {
+		return uErr
+	}
+	return err
+}
+
+// bookRender extracts page count, cover (if not uploaded by hand), page thumbnails and text layer
+func bookRender(ctx context.Context, book *models.Book, res *models.Book) error {
+	if book.File == nil || storage.Ext(*book.File) != ".pdf" {
+		return store.Store().BookPagesReplace(ctx, book.ID, []models.BookPage{})
+	}
+	fp, err := bookLocalFile(ctx, *book.File)
+	if err != nil {
+		return err
+	}
+	defer fp.Remove()
+	doc, err := fitz.New(fp.Path)
+	if err != nil {
+		return err
+	}
+	defer doc.Close()
+
+	dir := path.Dir(*book.File)
+	pageCount := doc.NumPage()
+	res.Pages = &pageCount
+	if pageCount > 0 && (book.FilePreview == nil || path.Base(*book.FilePreview) == "hover.jpg") {
+		img, err := doc.ImageDPI(0, bookCoverDpi)
+		if err != nil {
+			return err
+		}
+		cover := dir + "/hover.jpg"
+		if err := storage.PutImage(ctx, cover, img, 0); err != nil {
+			return err
+		}
+		res.FilePreview = &cover
+	}
+
+	oldPages, err := store.Store().BookPagesFindByBook(ctx, book.ID)
+	if err != nil {
+		return err
+	}
+	pages := []models.BookPage{}
+	thumbs := map[string]bool{}
+	for i := 0; i < pageCount; i++ {
+		// pages are numbered from 1 as BookPage of topics and lessons
+		page := models.BookPage{BookId: book.ID, Page: i + 1}
+		if img, err := doc.ImageDPI(i, bookPageDpi); err == nil {
+			thumb := dir + "/pages/" + strconv.Itoa(i+1) + ".jpg"
+			if err := storage.PutImage(ctx, thumb, img, bookPageThumbSize); err == nil {
+				page.Thumbnail = &thumb
+				thumbs[thumb] = true
+			}
+		}
+		if text, err := doc.Text(i); err == nil {
+			// postgres text can't keep null bytes
+			text = strings.ReplaceAll(text, "\x00", "")
+			page.Content = &text
+		}
+		pages = append(pages, page)
+	}
+	err = store.Store().BookPagesReplace(ctx, book.ID, pages)
+	if err != nil {
+		return err
+	}
+	// thumbnails of previous file
+	for _, p := range oldPages {
+		if p.Thumbnail != nil && !thumbs[*p.Thumbnail] {
+			_ = storage.Delete(ctx, *p.Thumbnail, "books")
+		}
+	}
+	return nil
+}
+
+// BookFilesUpdate saves uploaded files of book, new pdf is sent to processing
+func BookFilesUpdate(ses *utils.Session, model *models.Book, fileChanged bool) (*models.BookResponse, error) {
+	sp, ctx := apm.StartSpan(ses.Context(), "BookFilesUpdate", "app")
+	ses.SetContext(ctx)
+	defer sp.End()
+	isPdf := fileChanged && model.File != nil && storage.Ext(*model.File) == ".pdf"
+	if isPdf {
+		status := models.BookProcessPending
+		model.ProcessStatus = &status
+	}
+	model, err := store.Store().BookUpdate(ses.Context(), model)
+	if err != nil {
+		return nil, err
+	}
+	if isPdf {
+		BookProcessEnqueue(model.ID)
+	}
+	res := &models.BookResponse{}
+	res.FromModel(model)
+	return res, nil
+}
+
+func BookPages(ses *utils.Session, id string) ([]*models.BookPageResponse, error) {
+	sp, ctx := apm.StartSpan(ses.Context(), "BookPages", "app")
+	ses.SetContext(ctx)
+	defer sp.End()
+	pages, err := store.Store().BookPagesFindByBook(ses.Context(), id)
+	if err != nil {
+		return nil, err
+	}
+	res := []*models.BookPageResponse{}
+	for _, p := range pages {
+		r := &models.BookPageResponse{}
+		r.FromModel(p)
+		res = append(res, r)
+	}
+	return res, nil
+}
+
+func BookPagesSearch(ses *utils.Session, f models.BookPageSearchRequest) ([]*models.BookPageSearchResponse, int, error) {
+	sp, ctx := apm.StartSpan(ses.Context(), "BookPagesSearch", "app")
+	ses.SetContext(ctx)
+	defer sp.End()
+	if f.Limit == nil {
+		f.Limit = new(int)
+		*f.Limit = 20
+	}
+	if f.Offset == nil {
+		f.Offset = new(int)
+	}
+	found, total, err := store.Store().BookPagesSearch(ses.Context(), f)
+	if err != nil {
+		return nil, 0, err
+	}
+	ids := []string{}
+	for _, v := range found {
+		ids = append(ids, v.BookId)
+	}
+	books, err := store.Store().BookFindByIds(ses.Context(), ids)
+	if err != nil {
+		return nil, 0, err
+	}
+	booksById := map[string]*models.BookResponse{}
+	for _, b := range books {
+		r := &models.BookResponse{}
+		r.FromModel(b)
+		booksById[b.ID] = r
+	}
+	res := []*models.BookPageSearchResponse{}
+	for _, v := range found {
+		res = append(res, &models.BookPageSearchResponse{
+			Book:     booksById[v.BookId],
+			BookId:   v.BookId,
+			BookPage: v.Page,
+			Headline: v.Headline,
+		})
+	}
+	return res, total, nil
+}
+
+// bookFile is pdf on local disk, Remove deletes it when it is temp copy of remote storage
+type bookFile struct {
+	Path   string
+	isTemp bool
+}
+
+func (f bookFile) Remove() {
+	if f.isTemp {
+		os.Remove(f.Path)
+	}
+}
+
+// bookLocalFile gives path of pdf for rendering, file of remote storage is streamed to temp file instead of memory
+func bookLocalFile(ctx context.Context, p string) (bookFile, error) {
+	if local, ok := storage.Disk().(*storage.LocalStorage); ok {
+		return bookFile{Path: local.FullPath(p)}, nil
+	}
+	r, err := storage.Disk().Open(ctx, p)
+	if err != nil {
+		return bookFile{}, err
+	}
+	defer r.Close()
+	f, err := os.CreateTemp("", "book-*.pdf")
+	if err != nil {
+		return bookFile{}, err
+	}
+	defer f.Close()
+	res := bookFile{Path: f.Name(), isTemp: true}
+	if _, err = io.Copy(f, r); err == nil {
+		err = f.Close()
+	}
+	if err != nil {
+		res.Remove()
+		return bookFile{}, err
+	}
+	return res, nil
+}
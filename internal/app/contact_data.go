@@ -0,0 +1,368 @@
+package app
+
+import (
+	"strings"
+	"time"
+
+	"github.com/mekdep/server/internal/api/utils"
+	"github.com/mekdep/server/internal/models"
+	"github.com/mekdep/server/internal/store"
+	"go.elastic.co/apm/v2"
+)
+
+// contactDataStep is proposed change of data_complaint with loaded models to be applied
+type contactDataStep struct {
+	Action          models.ContactDataAction
+	Child           *models.ChildrenObject
+	User            *models.User
+	Classroom       *models.Classroom
+	CandidatesCount int
+	Comment         string
+}
+
+func (s contactDataStep) toResponse() models.ContactDataChange {
+	r := models.ContactDataChange{
+		Action:          s.Action,
+		Child:           s.Child,
+		CandidatesCount: s.CandidatesCount,
+		Comment:         s.Comment,
+	}
+	if s.User != nil {
+		r.User = &models.UserResponse{}
+		r.User.FromModel(s.User)
+	}
+	if s.Classroom != nil {
+		r.Classroom = &models.ClassroomResponse{}
+		r.Classroom.FromModel(s.Classroom)
+	}
+	return r
+}
+
+func contactDataNameKey(firstName *string, lastName *string) string {
+	return strings.ToLower(LettersRemoveTurkmen(strings.TrimSpace(stringOrEmpty(firstName)) + " " + strings.TrimSpace(stringOrEmpty(lastName))))
+}
+
+// contactDataKey compares classrooms and documents written by parents: "9 A", "9-a" and "9A" are same
+func contactDataKey(name string) string {
+	return strings.NewReplacer(" ", "", "-", "", "\"", "").Replace(strings.ToLower(LettersRemoveTurkmen(name)))
+}
+
+func contactDataIsSet(v *string) bool {
+	return v != nil && strings.TrimSpace(*v) != ""
+}
+
+// contactDataPlan matches structured request with existing users like checkExistingAndMerge:
+// by names in classroom of school, then by birth certificate. Ambiguous matches are left to operator
+func contactDataPlan(ses *utils.Session, item *models.ContactItems) (*contactDataStep, []contactDataStep, error) {
+	if item.Type != models.ContactTypeDataComplaint || item.Payload == nil {
+		return nil, nil, ErrInvalid.SetKey("payload")
+	}
+	if item.SchoolId == nil {
+		return nil, nil, ErrRequired.SetKey("school_id")
+	}
+	payload := item.Payload
+
+	// parent is sender of request, anonymous request is matched by phone
+	var parent *models.User
+	var err error
+	if item.UserId != nil {
+		parent, err = store.Store().UsersFindById(ses.Context(), *item.UserId)
+		if err != nil {
+			return nil, nil, err
+		}
+	} else if contactDataIsSet(payload.ParentPhone) {
+		role := string(models.RoleParent)
+		l, _, err := store.Store().UsersFindBy(ses.Context(), models.UserFilterRequest{Phone: payload.ParentPhone, Role: &role})
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(l) == 1 {
+			parent = l[0]
+		}
+	}
+	parentData := models.UserRequest{
+		FirstName:      payload.ParentFirstName,
+		LastName:       payload.ParentLastName,
+		Phone:          payload.ParentPhone,
+		PassportNumber: payload.ParentPassportNumber,
+		Birthday:       payload.ParentBirthday,
+	}
+	parentModel := models.User{}
+	parentData.ToModel(&parentModel)
+	parentStep := &contactDataStep{Action: models.ContactDataActionNone}
+	if parent == nil {
+		parentStep.Comment = "parent_not_found"
+		if contactDataIsSet(payload.ParentFirstName) && contactDataIsSet(payload.ParentLastName) {
+			parentStep.Action = models.ContactDataActionCreate
+			parentStep.User = &parentModel
+		}
+	} else {
+		// only filled values are changed, names are kept when they are same ignoring letters
+		updated := *parent
+		isChanged := false
+		if contactDataIsSet(parentModel.FirstName) && contactDataNameKey(parentModel.FirstName, nil) != contactDataNameKey(parent.FirstName, nil) {
+			updated.FirstName = parentModel.FirstName
+			isChanged = true
+		}
+		if contactDataIsSet(parentModel.LastName) && contactDataNameKey(nil, parentModel.LastName) != contactDataNameKey(nil, parent.LastName) {
+			updated.LastName = parentModel.LastName
+			isChanged = true
+		}
+		if contactDataIsSet(parentModel.PassportNumber) && stringOrEmpty(parentModel.PassportNumber) != stringOrEmpty(parent.PassportNumber) {
+			updated.PassportNumber = parentModel.PassportNumber
+			isChanged = true
+		}
+		if parentModel.Birthday != nil && (parent.Birthday == nil || !parent.Birthday.Equal(*parentModel.Birthday)) {
+			updated.Birthday = parentModel.Birthday
+			isChanged = true
+		}
+		parentStep.User = &updated
+		if isChanged {
+			parentStep.Action = models.ContactDataActionUpdate
+		}
+		err = store.Store().UsersLoadRelationsChildren(ses.Context(), &[]*models.User{parent})
+		if err != nil {
+			return nil, nil, err
+		}
+		updated.Children = parent.Children
+	}
+
+	var classrooms []*models.Classroom
+	steps := []contactDataStep{}
+	for k := range payload.Children {
+		child := payload.Children[k]
+		step := contactDataStep{Action: models.ContactDataActionNone, Child: &child}
+		var linked *models.User
+		if parent != nil {
+			for _, v := range parent.Children {
+				if contactDataNameKey(v.FirstName, v.LastName) == contactDataNameKey(child.FirstName, child.LastName) ||
+					contactDataIsSet(child.BirthCertNumber) && contactDataKey(stringOrEmpty(v.BirthCertNumber)) == contactDataKey(*child.BirthCertNumber) {
+					linked = v
+				}
+			}
+		}
+		if child.IsDelete != nil && *child.IsDelete {
+			step.User = linked
+			step.Comment = "not_linked"
+			if linked != nil {
+				step.Action = models.ContactDataActionUnlink
+				step.Comment = ""
+			}
+			steps = append(steps, step)
+			continue
+		}
+		if linked != nil {
+			step.User = linked
+			step.Comment = "already_linked"
+			steps = append(steps, step)
+			continue
+		}
+		if contactDataIsSet(child.ClassroomName) {
+			if classrooms == nil {
+				f := models.ClassroomFilterRequest{SchoolId: item.SchoolId}
+				f.Limit = new(int)
+				*f.Limit = 500
+				classrooms, _, err = store.Store().ClassroomsFindBy(ses.Context(), f)
+				if err != nil {
+					return nil, nil, err
+				}
+			}
+			for _, c := range classrooms {
+				if c.Name != nil && contactDataKey(*c.Name) == contactDataKey(*child.ClassroomName) {
+					step.Classroom = c
+				}
+			}
+		}
+		role := string(models.RoleStudent)
+		if contactDataIsSet(child.FirstName) && contactDataIsSet(child.LastName) {
+			f := models.UserFilterRequest{
+				LowFirstName: child.FirstName,
+				LowLastName:  child.LastName,
+				SchoolId:     item.SchoolId,
+				Role:         &role,
+			}
+			if step.Classroom != nil {
+				f.ClassroomId = &step.Classroom.ID
+			}
+			sameUsers, _, err := store.Store().UsersFindBy(ses.Context(), f)
+			if err != nil {
+				return nil, nil, err
+			}
+			step.CandidatesCount = len(sameUsers)
+			if len(sameUsers) == 1 {
+				step.User = sameUsers[0]
+			}
+		}
+		if step.CandidatesCount == 0 && contactDataIsSet(child.BirthCertNumber) {
+			sameUsers, _, err := store.Store().UsersFindBy(ses.Context(), models.UserFilterRequest{
+				BirthCertNumber: child.BirthCertNumber,
+				SchoolId:        item.SchoolId,
+				Role:            &role,
+			})
+			if err != nil {
+				return nil, nil, err
+			}
+			step.CandidatesCount = len(sameUsers)
+			if len(sameUsers) == 1 {
+				step.User = sameUsers[0]
+			}
+		}
+		switch {
+		case step.User != nil:
+			step.Action = models.ContactDataActionLink
+		case step.CandidatesCount > 1:
+			step.Comment = "ambiguous"
+		case step.Classroom == nil:
+			step.Comment = "classroom_not_found"
+		case !contactDataIsSet(child.FirstName) || !contactDataIsSet(child.LastName):
+			step.Comment = "name_required"
+		default:
+			step.Action = models.ContactDataActionCreate
+			step.User = &models.User{
+				FirstName:       child.FirstName,
+				LastName:        child.LastName,
+				BirthCertNumber: child.BirthCertNumber,
+			}
+		}
+		steps = append(steps, step)
+	}
+	// children can not be linked without parent
+	if parentStep.User == nil {
+		for k := range steps {
+			if steps[k].Action != models.ContactDataActionNone {
+				steps[k].Action = models.ContactDataActionNone
+				steps[k].Comment = "parent_not_found"
+			}
+		}
+	}
+	return parentStep, steps, nil
+}
+
+func contactDataProposalResponse(item *models.ContactItems, parentStep *contactDataStep, steps []contactDataStep) *models.ContactDataProposal {
+	res := &models.ContactDataProposal{
+		ContactItemId: item.ID,
+		Changes:       []models.ContactDataChange{},
+	}
+	parentChange := parentStep.toResponse()
+	res.Parent = parentChange.User
+	res.Changes = append(res.Changes, parentChange)
+	for _, s := range steps {
+		res.Changes = append(res.Changes, s.toResponse())
+	}
+	for _, c := range res.Changes {
+		if c.Action != models.ContactDataActionNone {
+			res.IsApplicable = !models.ContactStatusIsResolved(item.Status)
+		}
+	}
+	return res
+}
+
+// ContactItemsDataProposal returns changes of users which data_complaint asks for
+func ContactItemsDataProposal(ses *utils.Session, id string) (*models.ContactDataProposal, error) {
+	sp, ctx := apm.StartSpan(ses.Context(), "ContactItemsDataProposal", "app")
+	ses.SetContext(ctx)
+	defer sp.End()
+	item, err := store.Store().ContactItemsFindById(ses.Context(), id)
+	if err != nil {
+		return nil, ErrNotfound.SetKey("id")
+	}
+	parentStep, steps, err := contactDataPlan(ses, item)
+	if err != nil {
+		return nil, err
+	}
+	return contactDataProposalResponse(item, parentStep, steps), nil
+}
+
+// ContactItemsDataApply applies proposal by operator: creates or updates parent and children,
+// links and unlinks them, then item is done. Proposal is matched again, not taken from client
+func ContactItemsDataApply(ses *utils.Session, id string) (*models.ContactDataProposal, error) {
+	sp, ctx := apm.StartSpan(ses.Context(), "ContactItemsDataApply", "app")
+	ses.SetContext(ctx)
+	defer sp.End()
+	item, err := store.Store().ContactItemsFindById(ses.Context(), id)
+	if err != nil {
+		return nil, ErrNotfound.SetKey("id")
+	}
+	if models.ContactStatusIsResolved(item.Status) {
+		return nil, ErrInvalid.SetKey("status").SetComment(item.Status)
+	}
+	parentStep, steps, err := contactDataPlan(ses, item)
+	if err != nil {
+		return nil, err
+	}
+	proposal := contactDataProposalResponse(item, parentStep, steps)
+	if !proposal.IsApplicable {
+		return nil, ErrInvalid.SetKey("payload").SetComment("nothing to apply")
+	}
+
+	parent := parentStep.User
+	switch parentStep.Action {
+	case models.ContactDataActionCreate:
+		birthday := stringOrEmpty(item.Payload.ParentBirthday)
+		role := models.RoleParent
+		created, _, err := UsersCreate(ses, &models.UserRequest{
+			FirstName:      parent.FirstName,
+			LastName:       parent.LastName,
+			Phone:          parent.Phone,
+			PassportNumber: parent.PassportNumber,
+			Birthday:       &birthday,
+			SchoolIds:      &[]models.UserSchoolRequest{{SchoolUid: item.SchoolId, RoleCode: &role}},
+		})
+		if err != nil {
+			return nil, err
+		}
+		parent.ID = created.ID
+	case models.ContactDataActionUpdate:
+		parent, err = store.Store().UserUpdate(ses.Context(), parent)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	addIds := []string{}
+	removeIds := []string{}
+	for _, s := range steps {
+		switch s.Action {
+		case models.ContactDataActionCreate:
+			role := models.RoleStudent
+			created, _, err := UsersCreate(ses, &models.UserRequest{
+				FirstName:       s.User.FirstName,
+				LastName:        s.User.LastName,
+				BirthCertNumber: s.User.BirthCertNumber,
+				SchoolIds:       &[]models.UserSchoolRequest{{SchoolUid: item.SchoolId, RoleCode: &role}},
+				ClassroomIds:    &[]models.UserClassroomRequest{{ClassroomId: &s.Classroom.ID}},
+			})
+			if err != nil {
+				return nil, err
+			}
+			addIds = append(addIds, created.ID)
+		case models.ContactDataActionLink:
+			addIds = append(addIds, s.User.ID)
+		case models.ContactDataActionUnlink:
+			removeIds = append(removeIds, s.User.ID)
+		}
+	}
+	err = store.Store().UserParentsChange(ses.Context(), parent.ID, item.SchoolId, addIds, removeIds)
+	if err != nil {
+		return nil, err
+	}
+	UserCacheClear(ses, parent.ID)
+
+	// item is closed with history for submitter
+	now := time.Now()
+	err = contactItemStatusSet(item, string(models.ContactStatusDone), now)
+	if err != nil {
+		return nil, err
+	}
+	item.UpdatedBy = &ses.GetUser().ID
+	item, err = store.Store().ContactItemUpdate(ses.Context(), item)
+	if err != nil {
+		return nil, err
+	}
+	_, err = contactReplyAdd(ses, item, nil, &item.Status, false)
+	if err != nil {
+		return nil, err
+	}
+	contactItemNotify(item.UserId, item, "Maglumatlaryňyz girizildi")
+	return proposal, nil
+}
@@ -0,0 +1,349 @@
+package app
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mekdep/server/internal/api/utils"
+	"github.com/mekdep/server/internal/models"
+	"github.com/mekdep/server/internal/store"
+	apputils "github.com/mekdep/server/internal/utils"
+	"go.elastic.co/apm/v2"
+)
+
+// roles which can be assigned to any contact item, principal only to items of own school
+var contactAssigneeRoles = []models.Role{models.RoleOperator, models.RoleAdmin}
+
+func contactSla(contactType string) models.ContactSla {
+	if sla, ok := models.DefaultContactSla[contactType]; ok {
+		return sla
+	}
+	return models.DefaultContactSla[models.ContactTypeSuggestion]
+}
+
+// contactItemResponded sets first response, after it item is due to be resolved
+func contactItemResponded(item *models.ContactItems, now time.Time) {
+	if item.FirstResponseAt != nil {
+		return
+	}
+	item.FirstResponseAt = &now
+	createdAt := now
+	if item.CreatedAt != nil {
+		createdAt = *item.CreatedAt
+	}
+	dueAt := createdAt.Add(contactSla(item.Type).Resolution)
+	item.DueAt = &dueAt
+}
+
+// contactItemStatusSet changes status by allowed transitions, resolved time is cleared on reopen
+func contactItemStatusSet(item *models.ContactItems, status string, now time.Time) error {
+	if !models.ContactStatusCanChange(item.Status, status) {
+		return ErrInvalid.SetKey("status").SetComment(item.Status + " -> " + status)
+	}
+	item.Status = status
+	if models.ContactStatusIsResolved(status) {
+		item.ResolvedAt = &now
+	} else {
+		item.ResolvedAt = nil
+	}
+	contactItemResponded(item, now)
+	return nil
+}
+
+func contactReplyAdd(ses *utils.Session, item *models.ContactItems, message *string, status *string, isInternal bool) (*models.ContactReply, error) {
+	m := &models.ContactReply{
+		ContactItemId: item.ID,
+		Message:       message,
+		Status:        status,
+		IsInternal:    isInternal,
+	}
+	if ses.GetUser() != nil {
+		m.UserId = &ses.GetUser().ID
+	}
+	m, err := store.Store().ContactReplyCreate(ses.Context(), m)
+	if err != nil {
+		return nil, err
+	}
+	m.User = ses.GetUser()
+	return m, nil
+}
+
+// contactItemsLoadReplies loads threads with authors
+func contactItemsLoadReplies(ses *utils.Session, items []*models.ContactItems, withInternal bool) error {
+	ids := []string{}
+	for _, v := range items {
+		ids = append(ids, v.ID)
+		v.Replies = []*models.ContactReply{}
+	}
+	if len(ids) < 1 {
+		return nil
+	}
+	replies, err := store.Store().ContactRepliesFindBy(ses.Context(), ids, withInternal)
+	if err != nil {
+		return err
+	}
+	userIds := []string{}
+	for _, r := range replies {
+		if r.UserId != nil {
+			userIds = append(userIds, *r.UserId)
+		}
+	}
+	users := []*models.User{}
+	if len(userIds) > 0 {
+		users, err = store.Store().UsersFindByIds(ses.Context(), userIds)
+		if err != nil {
+			return err
+		}
+	}
+	for _, r := range replies {
+		for _, u := range users {
+			if r.UserId != nil && *r.UserId == u.ID {
+				r.User = u
+			}
+		}
+		for _, v := range items {
+			if v.ID == r.ContactItemId {
+				v.Replies = append(v.Replies, r)
+			}
+		}
+	}
+	return nil
+}
+
+// contactItemNotify sends event of contact item to user (submitter or assignee)
+func contactItemNotify(userId *string, item *models.ContactItems, body string) {
+	if userId == nil {
+		return
+	}
+	go func() {
+		users, err := store.Store().UsersFindByIds((&utils.Session{}).Context(), []string{*userId})
+		if err == nil {
+			err = notifyDispatch(users, Notify{
+				Category: models.NotifyContactItem,
+				Title:    "Ýüzlenme",
+				Body:     body,
+				PushType: PushTypeNotification,
+				PushId:   item.ID,
+			})
+		}
+		if err != nil {
+			apputils.LoggerDesc("In contactItemNotify " + item.ID).Error(err)
+		}
+	}()
+}
+
+// ContactItemsAssign sets operator or school admin responsible for contact item
+func ContactItemsAssign(ses *utils.Session, data models.ContactItemsAssignRequest) (*models.ContactItemsResponse, error) {
+	sp, ctx := apm.StartSpan(ses.Context(), "ContactItemsAssign", "app")
+	ses.SetContext(ctx)
+	defer sp.End()
+	model, err := store.Store().ContactItemsFindById(ses.Context(), *data.ID)
+	if err != nil {
+		return nil, ErrNotfound.SetKey("id")
+	}
+	now := time.Now()
+	message := "Jogapkärçilikden aýryldy"
+	if data.AssigneeId != nil && *data.AssigneeId != "" {
+		assignee, err := store.Store().UsersFindById(ses.Context(), *data.AssigneeId)
+		if err != nil {
+			return nil, ErrNotfound.SetKey("assignee_id")
+		}
+		err = store.Store().UsersLoadRelations(ses.Context(), &[]*models.User{assignee}, false)
+		if err != nil {
+			return nil, err
+		}
+		canAssign := false
+		for _, us := range assignee.Schools {
+			if slices.Contains(contactAssigneeRoles, us.RoleCode) ||
+				us.RoleCode == models.RolePrincipal && us.SchoolUid != nil && model.SchoolId != nil && *us.SchoolUid == *model.SchoolId {
+				canAssign = true
+			}
+		}
+		if !canAssign {
+			return nil, ErrInvalid.SetKey("assignee_id")
+		}
+		model.AssigneeId = &assignee.ID
+		model.AssignedAt = &now
+		message = "Jogapkär bellenildi: " + assignee.FullName()
+	} else {
+		model.AssigneeId = nil
+		model.AssignedAt = nil
+	}
+	model.UpdatedBy = &ses.GetUser().ID
+	model, err = store.Store().ContactItemUpdate(ses.Context(), model)
+	if err != nil {
+		return nil, err
+	}
+	_, err = contactReplyAdd(ses, model, &message, nil, true)
+	if err != nil {
+		return nil, err
+	}
+	contactItemNotify(model.AssigneeId, model, "Size täze ýüzlenme berildi")
+	return ContactItemsDetail(ses, model.ID)
+}
+
+// ContactRepliesCreate adds reply of operator to thread, not internal reply is first response to submitter
+func ContactRepliesCreate(ses *utils.Session, data models.ContactReplyRequest) (*models.ContactReplyResponse, error) {
+	sp, ctx := apm.StartSpan(ses.Context(), "ContactRepliesCreate", "app")
+	ses.SetContext(ctx)
+	defer sp.End()
+	model, err := store.Store().ContactItemsFindById(ses.Context(), *data.ContactItemId)
+	if err != nil {
+		return nil, ErrNotfound.SetKey("id")
+	}
+	isInternal := data.IsInternal != nil && *data.IsInternal
+	reply, err := contactReplyAdd(ses, model, data.Message, nil, isInternal)
+	if err != nil {
+		return nil, err
+	}
+	if !isInternal {
+		contactItemResponded(model, time.Now())
+		model.UpdatedBy = &ses.GetUser().ID
+		model, err = store.Store().ContactItemUpdate(ses.Context(), model)
+		if err != nil {
+			return nil, err
+		}
+		contactItemNotify(model.UserId, model, "Ýüzlenmäňize jogap berildi")
+	}
+	res := &models.ContactReplyResponse{}
+	res.FromModel(reply)
+	return res, nil
+}
+
+// ContactItemsMyList returns contact items sent by current user
+func ContactItemsMyList(ses *utils.Session, f models.ContactItemsFilterRequest) ([]*models.ContactItemsResponse, int, error) {
+	sp, ctx := apm.StartSpan(ses.Context(), "ContactItemsMyList", "app")
+	ses.SetContext(ctx)
+	defer sp.End()
+	f.UserId = &ses.GetUser().ID
+	l, total, err := store.Store().ContactItemsFindBy(ses.Context(), f)
+	if err != nil {
+		return nil, 0, err
+	}
+	err = store.Store().ContactItemLoadRelations(ses.Context(), &l, false)
+	if err != nil {
+		return nil, 0, err
+	}
+	res := []*models.ContactItemsResponse{}
+	for _, v := range l {
+		item := models.ContactItemsResponse{}
+		item.FromModel(contactItemForSubmitter(v))
+		res = append(res, &item)
+	}
+	return res, total, nil
+}
+
+// ContactItemsMyDetail returns own contact item with thread without internal notes
+func ContactItemsMyDetail(ses *utils.Session, id string) (*models.ContactItemsResponse, error) {
+	sp, ctx := apm.StartSpan(ses.Context(), "ContactItemsMyDetail", "app")
+	ses.SetContext(ctx)
+	defer sp.End()
+	m, err := contactItemFindOwn(ses, id)
+	if err != nil {
+		return nil, err
+	}
+	err = store.Store().ContactItemLoadRelations(ses.Context(), &[]*models.ContactItems{m}, false)
+	if err != nil {
+		return nil, err
+	}
+	err = contactItemsLoadReplies(ses, []*models.ContactItems{m}, false)
+	if err != nil {
+		return nil, err
+	}
+	res := &models.ContactItemsResponse{}
+	res.FromModel(contactItemForSubmitter(m))
+	return res, nil
+}
+
+// ContactRepliesMyCreate adds reply of submitter to own contact item
+func ContactRepliesMyCreate(ses *utils.Session, data models.ContactReplyRequest) (*models.ContactReplyResponse, error) {
+	sp, ctx := apm.StartSpan(ses.Context(), "ContactRepliesMyCreate", "app")
+	ses.SetContext(ctx)
+	defer sp.End()
+	if ok := rateLimit("contact_reply"+ses.GetUser().ID, 20, 600); !ok {
+		return nil, ErrExceeded.SetComment("limit exceed (contact reply)")
+	}
+	m, err := contactItemFindOwn(ses, *data.ContactItemId)
+	if err != nil {
+		return nil, err
+	}
+	reply, err := contactReplyAdd(ses, m, data.Message, nil, false)
+	if err != nil {
+		return nil, err
+	}
+	contactItemNotify(m.AssigneeId, m, "Ýüzlenmä täze hat geldi")
+	res := &models.ContactReplyResponse{}
+	res.FromModel(reply)
+	return res, nil
+}
+
+func contactItemFindOwn(ses *utils.Session, id string) (*models.ContactItems, error) {
+	m, err := store.Store().ContactItemsFindById(ses.Context(), id)
+	if err != nil || m.UserId == nil || *m.UserId != ses.GetUser().ID {
+		return nil, ErrNotfound.SetKey("id")
+	}
+	return m, nil
+}
+
+// contactItemForSubmitter hides notes and operators of helpdesk from submitter
+func contactItemForSubmitter(m *models.ContactItems) *models.ContactItems {
+	m.Note = nil
+	m.UpdatedByUser = nil
+	m.Assignee = nil
+	return m
+}
+
+// ContactItemsEscalate notifies organization of region about items of its schools which passed SLA
+func ContactItemsEscalate() error {
+	ses := &utils.Session{}
+	items, err := store.Store().ContactItemsEscalateDue(ses.Context(), time.Now())
+	if err != nil {
+		return err
+	}
+	if len(items) < 1 {
+		return nil
+	}
+	err = store.Store().ContactItemLoadRelations(ses.Context(), &items, false)
+	if err != nil {
+		return err
+	}
+	byRegion := map[string][]*models.ContactItems{}
+	for _, item := range items {
+		message := "SLA möhleti geçdi"
+		_, err = contactReplyAdd(ses, item, &message, nil, true)
+		if err != nil {
+			return err
+		}
+		if item.School != nil && item.School.ParentUid != nil {
+			byRegion[*item.School.ParentUid] = append(byRegion[*item.School.ParentUid], item)
+		}
+	}
+	for regionId, l := range byRegion {
+		users, _, err := store.Store().UsersFindBy(ses.Context(), models.UserFilterRequest{
+			Roles:    &[]string{string(models.RoleOrganization)},
+			SchoolId: &regionId,
+		})
+		if err != nil {
+			return err
+		}
+		schools := []string{}
+		for _, item := range l {
+			if item.School.Name != nil && !slices.Contains(schools, *item.School.Name) {
+				schools = append(schools, *item.School.Name)
+			}
+		}
+		err = notifyDispatch(users, Notify{
+			Category: models.NotifyContactItem,
+			Role:     models.RoleOrganization,
+			Title:    "Möhleti geçen ýüzlenmeler: " + strconv.Itoa(len(l)),
+			Body:     strings.Join(schools, ", "),
+			PushType: PushTypeNotification,
+		})
+		if err != nil {
+			apputils.LoggerDesc("In ContactItemsEscalate " + regionId).Error(err)
+		}
+	}
+	return nil
+}
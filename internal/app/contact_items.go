@@ -3,6 +3,7 @@ package app
 import (
 	"errors"
 	"strings"
+	"time"
 
 	"github.com/mekdep/server/internal/api/utils"
 	"github.com/mekdep/server/internal/models"
@@ -49,6 +50,10 @@ func ContactItemsDetail(ses *utils.Session, id string) (*models.ContactItemsResp
 	if err != nil {
 		return nil, err
 	}
+	err = contactItemsLoadReplies(ses, []*models.ContactItems{m}, true)
+	if err != nil {
+		return nil, err
+	}
 	res := &models.ContactItemsResponse{}
 	res.FromModel(m)
 	return res, nil
@@ -58,27 +63,33 @@ func ContactItemsUpdate(ses *utils.Session, data models.ContactItemsRequest) (*m
 	sp, ctx := apm.StartSpan(ses.Context(), "ContactItemsUpdate", "app")
 	ses.SetContext(ctx)
 	defer sp.End()
-	var err error
+	model, err := store.Store().ContactItemsFindById(ses.Context(), *data.ID)
+	if err != nil {
+		return nil, ErrNotfound.SetKey("id")
+	}
 	if data.RelatedChildrenIds != nil && len(*data.RelatedChildrenIds) > 0 {
 		relatedChildren, err := store.Store().ContactItemsFindByIds(ses.Context(), *data.RelatedChildrenIds)
 		if err != nil {
 			return nil, err
 		}
-		if relatedChildren != nil && len(relatedChildren) > 0 {
-			for _, child := range relatedChildren {
-				child.RelatedId = data.ID
-				_, err = store.Store().ContactItemUpdate(ses.Context(), child)
-				if err != nil {
-					return nil, err
-				}
+		for _, child := range relatedChildren {
+			child.RelatedId = data.ID
+			_, err = store.Store().ContactItemUpdate(ses.Context(), child)
+			if err != nil {
+				return nil, err
 			}
 		}
-		return nil, nil
 	}
-	model, err := store.Store().ContactItemsFindById(ses.Context(), *data.ID)
-	if data.Status != "" {
-		model.Status = data.Status
+	now := time.Now()
+	var status *string
+	if data.Status != "" && data.Status != model.Status {
+		err = contactItemStatusSet(model, data.Status, now)
+		if err != nil {
+			return nil, err
+		}
+		status = &model.Status
 	}
+	isNoteChanged := data.Note != nil && (model.Note == nil || *model.Note != *data.Note)
 	if data.Note != nil {
 		model.Note = data.Note
 	}
@@ -91,14 +102,21 @@ func ContactItemsUpdate(ses *utils.Session, data models.ContactItemsRequest) (*m
 	if err != nil {
 		return nil, err
 	}
-	err = store.Store().ContactItemLoadRelations(ses.Context(), &[]*models.ContactItems{model}, true)
-	if err != nil {
-		return nil, err
+	// history of status is visible to submitter, notes are kept for operators
+	if status != nil {
+		_, err = contactReplyAdd(ses, model, nil, status, false)
+		if err != nil {
+			return nil, err
+		}
+		contactItemNotify(model.UserId, model, "Ýüzlenmäňiziň ýagdaýy üýtgedi: "+model.Status)
 	}
-
-	res := &models.ContactItemsResponse{}
-	res.FromModel(model)
-	return res, nil
+	if isNoteChanged {
+		_, err = contactReplyAdd(ses, model, model.Note, nil, true)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ContactItemsDetail(ses, model.ID)
 }
 
 func ContactItemsCreate(ses *utils.Session, data models.ContactItemsRequest) (*models.ContactItemsResponse, error) {
@@ -110,6 +128,8 @@ func ContactItemsCreate(ses *utils.Session, data models.ContactItemsRequest) (*m
 	}
 	model := &models.ContactItems{}
 	data.ToModel(model)
+	dueAt := time.Now().Add(contactSla(model.Type).Response)
+	model.DueAt = &dueAt
 	res := &models.ContactItemsResponse{}
 	var err error
 	model, err = store.Store().ContactItemCreate(ses.Context(), model)
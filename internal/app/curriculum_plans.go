@@ -0,0 +1,395 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mekdep/server/internal/api/utils"
+	"github.com/mekdep/server/internal/models"
+	"github.com/mekdep/server/internal/store"
+	apputils "github.com/mekdep/server/internal/utils"
+	"go.elastic.co/apm/v2"
+)
+
+// curriculumApplyQueue fills lessons of saved plans in background, plan can have thousands of lessons
+var curriculumApplyQueue = newJobQueue("CurriculumPlanApply", nil, func(ctx context.Context, id string) error {
+	ses := &utils.Session{}
+	ses.SetContext(ctx)
+	return CurriculumPlanApply(ses, id)
+})
+
+func CurriculumPlansList(ses *utils.Session, f models.CurriculumPlanFilterRequest) ([]*models.CurriculumPlanResponse, int, error) {
+	sp, ctx := apm.StartSpan(ses.Context(), "CurriculumPlansList", "app")
+	ses.SetContext(ctx)
+	defer sp.End()
+	plans, total, err := store.Store().CurriculumPlansFindBy(ses.Context(), f)
+	if err != nil {
+		return nil, 0, err
+	}
+	err = store.Store().CurriculumPlansLoadRelations(ses.Context(), &plans)
+	if err != nil {
+		return nil, 0, err
+	}
+	res := []*models.CurriculumPlanResponse{}
+	for _, m := range plans {
+		r := &models.CurriculumPlanResponse{}
+		r.FromModel(m)
+		res = append(res, r)
+	}
+	return res, total, nil
+}
+
+func CurriculumPlansDetail(ses *utils.Session, id string) (*models.CurriculumPlanResponse, error) {
+	sp, ctx := apm.StartSpan(ses.Context(), "CurriculumPlansDetail", "app")
+	ses.SetContext(ctx)
+	defer sp.End()
+	m, err := store.Store().CurriculumPlansFindById(ses.Context(), id)
+	if err != nil {
+		return nil, ErrNotfound
+	}
+	err = store.Store().CurriculumPlansLoadRelations(ses.Context(), &[]*models.CurriculumPlan{m})
+	if err != nil {
+		return nil, err
+	}
+	res := &models.CurriculumPlanResponse{}
+	res.FromModel(m)
+	return res, nil
+}
+
+func CurriculumPlansCreate(ses *utils.Session, data models.CurriculumPlanRequest) (*models.CurriculumPlanResponse, error) {
+	sp, ctx := apm.StartSpan(ses.Context(), "CurriculumPlansCreate", "app")
+	ses.SetContext(ctx)
+	defer sp.End()
+	model := &models.CurriculumPlan{}
+	data.ToModel(model)
+	model, err := store.Store().CurriculumPlansCreate(ses.Context(), model)
+	if err != nil {
+		return nil, err
+	}
+	return curriculumPlanSaved(ses, model)
+}
+
+func CurriculumPlansUpdate(ses *utils.Session, data models.CurriculumPlanRequest) (*models.CurriculumPlanResponse, error) {
+	sp, ctx := apm.StartSpan(ses.Context(), "CurriculumPlansUpdate", "app")
+	ses.SetContext(ctx)
+	defer sp.End()
+	model := &models.CurriculumPlan{}
+	data.ToModel(model)
+	model, err := store.Store().CurriculumPlansUpdate(ses.Context(), model)
+	if err != nil {
+		return nil, err
+	}
+	return curriculumPlanSaved(ses, model)
+}
+
+func curriculumPlanSaved(ses *utils.Session, model *models.CurriculumPlan) (*models.CurriculumPlanResponse, error) {
+	err := store.Store().CurriculumPlansLoadRelations(ses.Context(), &[]*models.CurriculumPlan{model})
+	if err != nil {
+		return nil, err
+	}
+	curriculumApplyEnqueue(model.ID)
+	res := &models.CurriculumPlanResponse{}
+	res.FromModel(model)
+	return res, nil
+}
+
+func CurriculumPlansDelete(ses *utils.Session, ids []string) ([]*models.CurriculumPlan, error) {
+	sp, ctx := apm.StartSpan(ses.Context(), "CurriculumPlansDelete", "app")
+	ses.SetContext(ctx)
+	defer sp.End()
+	plans, _, err := store.Store().CurriculumPlansFindBy(ses.Context(), models.CurriculumPlanFilterRequest{IDs: &ids})
+	if err != nil {
+		return nil, err
+	}
+	if len(plans) < 1 {
+		return nil, errors.New("model not found: " + strings.Join(ids, ","))
+	}
+	return store.Store().CurriculumPlansDelete(ses.Context(), plans)
+}
+
+// CurriculumPlanApply fills lessons of all subjects of plan's base subject and class year
+func CurriculumPlanApply(ses *utils.Session, id string) error {
+	sp, ctx := apm.StartSpan(ses.Context(), "CurriculumPlanApply", "app")
+	ses.SetContext(ctx)
+	defer sp.End()
+	plan, err := store.Store().CurriculumPlansFindById(ses.Context(), id)
+	if err != nil {
+		return ErrNotfound
+	}
+	f := models.SubjectFilterRequest{BaseSubjectId: &plan.BaseSubjectId}
+	f.Limit = new(int)
+	*f.Limit = 5000
+	subjects, _, err := store.Store().SubjectsListFilters(ses.Context(), &f)
+	if err != nil {
+		return err
+	}
+	return curriculumApplySubjects(ses, subjects)
+}
+
+// curriculumApplyEnqueue queues filling of plan's lessons, when queue is full it runs in own goroutine
+func curriculumApplyEnqueue(id string) {
+	if curriculumApplyQueue.Enqueue(id) {
+		return
+	}
+	go func() {
+		err := curriculumApplyQueue.run(context.Background(), id)
+		if err != nil {
+			apputils.LoggerDesc("In CurriculumPlanApply " + id).Error(err)
+		}
+	}()
+}
+
+// CurriculumPlanApplyWorker fills lessons of queued plans one by one
+func CurriculumPlanApplyWorker() {
+	curriculumApplyQueue.Work()
+}
+
+// curriculumApplyClassroom is called after lessons of classroom are regenerated by timetable
+func curriculumApplyClassroom(ses *utils.Session, classroomId string) error {
+	subjects, err := store.Store().SubjectsFindByClassroomId(ses.Context(), classroomId)
+	if err != nil {
+		return err
+	}
+	return curriculumApplySubjects(ses, subjects)
+}
+
+// curriculumPlansBySubjects finds plan of each subject by base subject and class year of classroom
+func curriculumPlansBySubjects(ses *utils.Session, subjects []*models.Subject) (map[string]*models.CurriculumPlan, error) {
+	res := map[string]*models.CurriculumPlan{}
+	baseSubjectIds := []string{}
+	for _, s := range subjects {
+		if s.BaseSubjectId != nil {
+			baseSubjectIds = append(baseSubjectIds, *s.BaseSubjectId)
+		}
+	}
+	if len(baseSubjectIds) < 1 {
+		return res, nil
+	}
+	f := models.CurriculumPlanFilterRequest{BaseSubjectIds: &baseSubjectIds}
+	f.Limit = new(int)
+	*f.Limit = 1000
+	plans, _, err := store.Store().CurriculumPlansFindBy(ses.Context(), f)
+	if err != nil {
+		return nil, err
+	}
+	if len(plans) < 1 {
+		return res, nil
+	}
+	err = store.Store().CurriculumPlansLoadRelations(ses.Context(), &plans)
+	if err != nil {
+		return nil, err
+	}
+	err = store.Store().SubjectsLoadRelations(ses.Context(), &subjects, false)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range subjects {
+		if s.BaseSubjectId == nil || s.Classroom == nil {
+			continue
+		}
+		for _, p := range plans {
+			if p.BaseSubjectId == *s.BaseSubjectId && p.Classyear == s.Classroom.Classyear() {
+				res[s.ID] = p
+			}
+		}
+	}
+	return res, nil
+}
+
+// curriculumSubjectLessons returns lessons of subject in current period ordered by date and hour
+func curriculumSubjectLessons(ses *utils.Session, subject *models.Subject) ([]*models.Lesson, *models.Period, error) {
+	period, _, err := periodsGetByDate(ses, time.Now(), subject.SchoolId)
+	if err != nil || period == nil {
+		return nil, nil, err
+	}
+	startDate, endDate, err := period.Dates()
+	if err != nil {
+		return nil, nil, err
+	}
+	f := models.LessonFilterRequest{
+		SubjectId: &subject.ID,
+		DateRange: &[]string{startDate.Format(time.DateOnly), endDate.Format(time.DateOnly)},
+	}
+	f.Limit = new(int)
+	*f.Limit = 2000
+	lessons, _, err := store.Store().LessonsFindBy(ses.Context(), f)
+	if err != nil {
+		return nil, nil, err
+	}
+	sort.SliceStable(lessons, func(i, j int) bool {
+		if !lessons[i].Date.Equal(lessons[j].Date) {
+			return lessons[i].Date.Before(lessons[j].Date)
+		}
+		if lessons[i].HourNumber == nil || lessons[j].HourNumber == nil {
+			return false
+		}
+		return *lessons[i].HourNumber < *lessons[j].HourNumber
+	})
+	return lessons, period, nil
+}
+
+// isLessonHeld is false for lessons cancelled by teacher excuse or landing on holiday or vacation
+func isLessonHeld(l *models.Lesson, period models.Period) bool {
+	if l.IsTeacherExcused != nil && *l.IsTeacherExcused {
+		return false
+	}
+	return !isDateHoliday(l.Date) && !isDateVacation(l.Date, period)
+}
+
+// curriculumApplySubjects maps topics of plan onto held lessons one by one, so cancelled lessons shift
+// remaining topics forward. Lessons with title written by teacher are kept as is.
+func curriculumApplySubjects(ses *utils.Session, subjects []*models.Subject) error {
+	sp, ctx := apm.StartSpan(ses.Context(), "curriculumApplySubjects", "app")
+	ses.SetContext(ctx)
+	defer sp.End()
+	plans, err := curriculumPlansBySubjects(ses, subjects)
+	if err != nil {
+		return err
+	}
+	for _, subject := range subjects {
+		plan, ok := plans[subject.ID]
+		if !ok {
+			continue
+		}
+		lessons, period, err := curriculumSubjectLessons(ses, subject)
+		if err != nil {
+			return err
+		}
+		if len(lessons) < 1 {
+			continue
+		}
+		lessonIds := []string{}
+		for _, l := range lessons {
+			lessonIds = append(lessonIds, l.ID)
+		}
+		filled, err := store.Store().LessonTopicsFindByLessons(ses.Context(), lessonIds)
+		if err != nil {
+			return err
+		}
+		filledByLesson := map[string]models.LessonTopic{}
+		for _, v := range filled {
+			filledByLesson[v.LessonId] = v
+		}
+
+		updateLessons := []models.Lesson{}
+		clearIds := []string{}
+		items := []models.LessonTopic{}
+		k := 0
+		for _, l := range lessons {
+			lt, isFilled := filledByLesson[l.ID]
+			isManaged := l.Title == nil || *l.Title == "" ||
+				(isFilled && lt.Title != nil && *lt.Title == *l.Title)
+			var topic *models.Topics
+			if isLessonHeld(l, *period) {
+				if k < len(plan.Topics) {
+					topic = plan.Topics[k]
+				}
+				k++
+			}
+			if !isManaged {
+				continue
+			}
+			if topic == nil || topic.Title == nil {
+				if isFilled {
+					clearIds = append(clearIds, l.ID)
+				}
+				continue
+			}
+			items = append(items, models.LessonTopic{LessonId: l.ID, TopicId: topic.ID, Title: topic.Title})
+			if isFilled && lt.TopicId == topic.ID && l.Title != nil && *l.Title != "" {
+				continue
+			}
+			updateLessons = append(updateLessons, models.Lesson{
+				ID:       l.ID,
+				Title:    topic.Title,
+				BookId:   topic.BookId,
+				BookPage: topic.BookPage,
+			})
+		}
+		for _, id := range clearIds {
+			_, err = store.Store().LessonsUpdateBy(ses.Context(), models.LessonFilterRequest{ID: &id}, map[string]interface{}{
+				"title":     nil,
+				"book_uid":  nil,
+				"book_page": nil,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		err = store.Store().LessonsUpdateBatch(ses.Context(), updateLessons)
+		if err != nil {
+			return err
+		}
+		err = store.Store().LessonTopicsReplace(ses.Context(), lessonIds, items)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CurriculumProgress compares held lessons of subjects until today with their plans
+func CurriculumProgress(ses *utils.Session, f models.CurriculumProgressRequest) ([]*models.CurriculumProgress, error) {
+	sp, ctx := apm.StartSpan(ses.Context(), "CurriculumProgress", "app")
+	ses.SetContext(ctx)
+	defer sp.End()
+	sf := models.SubjectFilterRequest{
+		ID:          f.SubjectId,
+		SchoolId:    f.SchoolId,
+		ClassroomId: f.ClassroomId,
+	}
+	sf.Limit = new(int)
+	*sf.Limit = 5000
+	subjects, _, err := store.Store().SubjectsListFilters(ses.Context(), &sf)
+	if err != nil {
+		return nil, err
+	}
+	plans, err := curriculumPlansBySubjects(ses, subjects)
+	if err != nil {
+		return nil, err
+	}
+	// lesson dates are days without timezone, today is day of school
+	now := time.Now().In(ses.GetLocation())
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	res := []*models.CurriculumProgress{}
+	for _, subject := range subjects {
+		plan, ok := plans[subject.ID]
+		if !ok {
+			continue
+		}
+		lessons, period, err := curriculumSubjectLessons(ses, subject)
+		if err != nil {
+			return nil, err
+		}
+		item := &models.CurriculumProgress{
+			Subject:     &models.SubjectResponse{},
+			PlanId:      plan.ID,
+			TopicsCount: len(plan.Topics),
+		}
+		item.Subject.FromModel(subject)
+		for _, l := range lessons {
+			if !isLessonHeld(l, *period) {
+				continue
+			}
+			if l.Date.After(today) {
+				item.LessonsLeft++
+			} else {
+				item.LessonsDone++
+			}
+		}
+		if n := item.TopicsCount; n > 0 {
+			done := min(item.LessonsDone, n)
+			if done > 0 {
+				item.CurrentTopic = &models.TopicsResponse{}
+				item.CurrentTopic.FromModel(plan.Topics[done-1])
+			}
+			item.TopicsBehind = max(0, n-item.LessonsDone-item.LessonsLeft)
+			item.Percent = done * 100 / n
+		}
+		res = append(res, item)
+	}
+	return res, nil
+}
@@ -0,0 +1,228 @@
+package app
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/mekdep/server/internal/models"
+	"github.com/mekdep/server/internal/store"
+	apputils "github.com/mekdep/server/internal/utils"
+)
+
+type EventType string
+
+const (
+	EventGrade        EventType = "grade"
+	EventGradeDelete  EventType = "grade_delete"
+	EventAbsent       EventType = "absent"
+	EventAbsentDelete EventType = "absent_delete"
+	EventNotification EventType = "notification"
+	EventUnreadCount  EventType = "unread_count"
+	EventPayment      EventType = "payment"
+	// client missed events (too old or server restarted), it should refetch everything
+	EventResync EventType = "resync"
+)
+
+// Event is sent to one user, ID grows over time, so client resumes from last received ID
+type Event struct {
+	ID        int64       `json:"id"`
+	Type      EventType   `json:"type"`
+	UserId    string      `json:"-"`
+	Data      interface{} `json:"data"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+// EventSubscriber is one open stream of user, C is closed when it is too slow to read
+type EventSubscriber struct {
+	UserId string
+	C      chan Event
+}
+
+// last events are kept in memory for resuming, older or lost by restart get resync
+const eventsBufferSize = 10000
+const eventsSubscriberBuffer = 64
+
+type eventHub struct {
+	mu sync.Mutex
+	// started from time, so ids after restart are bigger than before
+	lastId      int64
+	buffer      []Event
+	subscribers map[string]map[*EventSubscriber]bool
+}
+
+var events = &eventHub{
+	lastId:      time.Now().UnixMilli() * 1000,
+	subscribers: map[string]map[*EventSubscriber]bool{},
+}
+
+// EventsSubscribe opens stream of user, returns missed events after lastId,
+// resync is true when they can not be restored from buffer
+func EventsSubscribe(userId string, lastId string) (sub *EventSubscriber, missed []Event, resync bool) {
+	events.mu.Lock()
+	defer events.mu.Unlock()
+	sub = &EventSubscriber{UserId: userId, C: make(chan Event, eventsSubscriberBuffer)}
+	if events.subscribers[userId] == nil {
+		events.subscribers[userId] = map[*EventSubscriber]bool{}
+	}
+	events.subscribers[userId][sub] = true
+	apputils.MetricsEventConnections.Inc()
+
+	if lastId == "" {
+		return sub, nil, false
+	}
+	id, err := strconv.ParseInt(lastId, 10, 64)
+	if err != nil || id > events.lastId {
+		return sub, nil, true
+	}
+	if len(events.buffer) < 1 || events.buffer[0].ID > id+1 {
+		// buffer does not start right after last id, something could be missed
+		return sub, nil, id < events.lastId
+	}
+	for _, e := range events.buffer {
+		if e.ID > id && e.UserId == userId {
+			missed = append(missed, e)
+		}
+	}
+	return sub, missed, false
+}
+
+func EventsUnsubscribe(sub *EventSubscriber) {
+	events.mu.Lock()
+	defer events.mu.Unlock()
+	if _, ok := events.subscribers[sub.UserId][sub]; !ok {
+		return
+	}
+	delete(events.subscribers[sub.UserId], sub)
+	if len(events.subscribers[sub.UserId]) < 1 {
+		delete(events.subscribers, sub.UserId)
+	}
+	close(sub.C)
+	apputils.MetricsEventConnections.Dec()
+}
+
+// eventsHasSubscribers is used to skip loading data for events when nobody listens
+func eventsHasSubscribers() bool {
+	events.mu.Lock()
+	defer events.mu.Unlock()
+	return len(events.subscribers) > 0
+}
+
+// eventsEmit sends event to users, slow subscribers are dropped and resume after reconnect
+func eventsEmit(userIds []string, t EventType, data interface{}) {
+	events.mu.Lock()
+	defer events.mu.Unlock()
+	now := time.Now()
+	sent := map[string]bool{}
+	for _, userId := range userIds {
+		if sent[userId] {
+			continue
+		}
+		sent[userId] = true
+		events.lastId++
+		e := Event{ID: events.lastId, Type: t, UserId: userId, Data: data, CreatedAt: now}
+		events.buffer = append(events.buffer, e)
+		for sub := range events.subscribers[userId] {
+			select {
+			case sub.C <- e:
+			default:
+				delete(events.subscribers[userId], sub)
+				close(sub.C)
+				apputils.MetricsEventConnections.Dec()
+			}
+		}
+		if len(events.subscribers[userId]) < 1 {
+			delete(events.subscribers, userId)
+		}
+	}
+	if len(events.buffer) > eventsBufferSize {
+		events.buffer = append([]Event{}, events.buffer[len(events.buffer)-eventsBufferSize:]...)
+	}
+}
+
+// eventsEmitStudents sends event to students and their parents
+func eventsEmitStudents(studentIds []string, t EventType, data func(studentId string) interface{}) {
+	if len(studentIds) < 1 || !eventsHasSubscribers() {
+		return
+	}
+	students, err := store.Store().UsersFindByIds(context.Background(), studentIds)
+	if err != nil {
+		apputils.LoggerDesc("In eventsEmitStudents").Error(err)
+		return
+	}
+	err = store.Store().UsersLoadRelationsParents(context.Background(), &students)
+	if err != nil {
+		apputils.LoggerDesc("In eventsEmitStudents").Error(err)
+		return
+	}
+	for _, s := range students {
+		userIds := []string{s.ID}
+		for _, p := range s.Parents {
+			userIds = append(userIds, p.ID)
+		}
+		eventsEmit(userIds, t, data(s.ID))
+	}
+}
+
+// eventsEmitUnreadCount sends unread notifications count to listening users
+func eventsEmitUnreadCount(userIds []string, role string) {
+	if !eventsHasSubscribers() {
+		return
+	}
+	for _, userId := range userIds {
+		events.mu.Lock()
+		_, ok := events.subscribers[userId]
+		events.mu.Unlock()
+		if !ok {
+			continue
+		}
+		total, err := store.Store().UserNotificationsSelectTotalUnread(context.Background(), userId, role)
+		if err != nil {
+			apputils.LoggerDesc("In eventsEmitUnreadCount").Error(err)
+			continue
+		}
+		eventsEmit([]string{userId}, EventUnreadCount, map[string]interface{}{
+			"role":         role,
+			"total_unread": total,
+		})
+	}
+}
+
+func eventsEmitGrades(grades []*models.Grade) {
+	ids := []string{}
+	byStudent := map[string]*models.GradeResponse{}
+	for _, g := range grades {
+		r := &models.GradeResponse{}
+		r.FromModel(g)
+		byStudent[g.StudentId] = r
+		ids = append(ids, g.StudentId)
+	}
+	eventsEmitStudents(ids, EventGrade, func(studentId string) interface{} {
+		return byStudent[studentId]
+	})
+}
+
+func eventsEmitAbsents(absents []*models.Absent) {
+	ids := []string{}
+	byStudent := map[string]*models.AbsentResponse{}
+	for _, a := range absents {
+		r := &models.AbsentResponse{}
+		r.FromModel(a)
+		byStudent[a.StudentId] = r
+		ids = append(ids, a.StudentId)
+	}
+	eventsEmitStudents(ids, EventAbsent, func(studentId string) interface{} {
+		return byStudent[studentId]
+	})
+}
+
+// eventsEmitDeleted is sent when grade or absent of students in lesson is removed
+func eventsEmitDeleted(t EventType, lessonId string, studentIds []string) {
+	eventsEmitStudents(studentIds, t, func(studentId string) interface{} {
+		return map[string]string{
+			"lesson_id":  lessonId,
+			"student_id": studentId,
+		}
+	})
+}
@@ -0,0 +1,45 @@
+package app
+
+import (
+	"slices"
+
+	"github.com/mekdep/server/internal/api/utils"
+	"github.com/mekdep/server/internal/models"
+	"github.com/mekdep/server/internal/store"
+)
+
+// FileCheckRead lets private file be read by its owner, parent of owner and staff of its school,
+// shared files (avatars) by anyone of its school
+func FileCheckRead(ses *utils.Session, p string) error {
+	user := ses.GetUser()
+	if ses.GetRole() == nil || user == nil {
+		return ErrUnauthorized
+	}
+	if *ses.GetRole() == models.RoleAdmin {
+		return nil
+	}
+	owners, err := store.Store().FileOwnersFind(ses.Context(), p)
+	if err != nil {
+		return err
+	}
+	isStaff := *ses.GetRole() != models.RoleStudent && *ses.GetRole() != models.RoleParent
+	childIds := []string{}
+	if *ses.GetRole() == models.RoleParent {
+		children, err := UserChildrenGet(ses, user)
+		if err != nil {
+			return err
+		}
+		for _, v := range children {
+			childIds = append(childIds, v.ID)
+		}
+	}
+	for _, v := range owners {
+		if v.UserId != nil && (*v.UserId == user.ID || slices.Contains(childIds, *v.UserId)) {
+			return nil
+		}
+		if v.SchoolId != nil && (isStaff || v.Shared) && slices.Contains(ses.SchoolsWithCentersIds(), *v.SchoolId) {
+			return nil
+		}
+	}
+	return ErrForbidden.SetKey("path")
+}
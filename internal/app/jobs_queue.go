@@ -0,0 +1,67 @@
+package app
+
+import (
+	"context"
+
+	apputils "github.com/mekdep/server/internal/utils"
+)
+
+// jobQueue runs background jobs of one kind one by one. Jobs are kept in DB with status, so those
+// which don't fit in full queue or are lost by restart are enqueued again by pending job of schedule
+type jobQueue[T any] struct {
+	name  string
+	items chan T
+	// claim marks job as processing, false when job is taken or done already (optional)
+	claim   func(ctx context.Context, item T) (bool, error)
+	process func(ctx context.Context, item T) error
+}
+
+func newJobQueue[T any](name string, claim func(ctx context.Context, item T) (bool, error), process func(ctx context.Context, item T) error) *jobQueue[T] {
+	return &jobQueue[T]{
+		name:    name,
+		items:   make(chan T, 100),
+		claim:   claim,
+		process: process,
+	}
+}
+
+// Enqueue returns false when queue is full
+func (q *jobQueue[T]) Enqueue(item T) bool {
+	select {
+	case q.items <- item:
+		return true
+	default:
+		return false
+	}
+}
+
+func (q *jobQueue[T]) EnqueueAll(items []T) {
+	for _, item := range items {
+		q.Enqueue(item)
+	}
+}
+
+// Work processes queued jobs, it is run once in own goroutine
+func (q *jobQueue[T]) Work() {
+	for item := range q.items {
+		err := q.run(context.Background(), item)
+		if err != nil {
+			desc := "In " + q.name
+			if id, ok := any(item).(string); ok {
+				desc += " " + id
+			}
+			apputils.LoggerDesc(desc).Error(err)
+		}
+	}
+}
+
+func (q *jobQueue[T]) run(ctx context.Context, item T) error {
+	defer apputils.MetricsJobTimer(q.name).ObserveDuration()
+	if q.claim != nil {
+		claimed, err := q.claim(ctx, item)
+		if err != nil || !claimed {
+			return err
+		}
+	}
+	return q.process(ctx, item)
+}
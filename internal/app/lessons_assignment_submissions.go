@@ -0,0 +1,290 @@
+package app
+
+import (
+	"slices"
+	"time"
+
+	"github.com/mekdep/server/internal/api/utils"
+	"github.com/mekdep/server/internal/models"
+	"github.com/mekdep/server/internal/store"
+	"go.elastic.co/apm/v2"
+)
+
+// AssignmentSubmissionsSubmit hands in homework of student, submitted by student or by parent.
+// Submitting again before grade replaces content and files, after due date submission is late
+func AssignmentSubmissionsSubmit(ses *utils.Session, student *models.User, data models.AssignmentSubmissionRequest) (*models.AssignmentSubmissionResponse, error) {
+	sp, ctx := apm.StartSpan(ses.Context(), "AssignmentSubmissionsSubmit", "app")
+	ses.SetContext(ctx)
+	defer sp.End()
+	if ok := rateLimit("assignment_submission"+student.ID, 20, 600); !ok {
+		return nil, ErrExceeded.SetComment("limit exceed (assignment submission)")
+	}
+	if (data.Content == nil || *data.Content == "") && (data.Files == nil || len(*data.Files) < 1) {
+		return nil, ErrRequired.SetKey("content")
+	}
+	lesson, err := store.Store().LessonsFindById(ses.Context(), *data.LessonId)
+	if err != nil || lesson.ID == "" {
+		return nil, ErrNotfound.SetKey("lesson_id")
+	}
+	if !lesson.HasAssignment() {
+		return nil, ErrNotExists.SetKey("assignment")
+	}
+	subject, err := store.Store().SubjectsFindById(ses.Context(), lesson.SubjectId)
+	if err != nil {
+		return nil, ErrNotfound.SetKey("lesson_id")
+	}
+	if len(student.Classrooms) < 1 {
+		err = store.Store().UsersLoadRelationsClassrooms(ses.Context(), &[]*models.User{student})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !slices.ContainsFunc(student.Classrooms, func(uc *models.UserClassroom) bool { return uc.ClassroomId == subject.ClassroomId }) {
+		return nil, ErrForbidden.SetKey("lesson_id")
+	}
+
+	l, err := store.Store().AssignmentSubmissionsFindBy(ses.Context(), models.AssignmentSubmissionFilterRequest{
+		LessonIds: &[]string{lesson.ID},
+		StudentId: &student.ID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	m := &models.AssignmentSubmission{
+		LessonId:  lesson.ID,
+		StudentId: student.ID,
+	}
+	if len(l) > 0 {
+		m = l[0]
+		if !m.CanResubmit() {
+			return nil, ErrInvalid.SetKey("status").SetComment("submission is already graded")
+		}
+	}
+	m.SubmittedBy = &ses.GetUser().ID
+	m.Content = data.Content
+	m.Files = data.Files
+	m.Status = string(models.SubmissionStatusSubmitted)
+	m.IsLate = lesson.AssignmentDueAt != nil && now.After(*lesson.AssignmentDueAt)
+	m.SubmittedAt = &now
+	m.Attempt++
+	if m.ID == "" {
+		m, err = store.Store().AssignmentSubmissionCreate(ses.Context(), m)
+	} else {
+		m, err = store.Store().AssignmentSubmissionUpdate(ses.Context(), m)
+	}
+	if err != nil {
+		return nil, err
+	}
+	res := &models.AssignmentSubmissionResponse{}
+	res.FromModel(m)
+	return res, nil
+}
+
+// AssignmentSubmissionsMatrix returns students of subject classroom with their submissions
+// for lessons with assignment, missing counts are by due date
+func AssignmentSubmissionsMatrix(ses *utils.Session, subjectId string, periodNumber *int) (*models.AssignmentSubmissionMatrixResponse, error) {
+	sp, ctx := apm.StartSpan(ses.Context(), "AssignmentSubmissionsMatrix", "app")
+	ses.SetContext(ctx)
+	defer sp.End()
+	subject, err := journalSubjectFind(ses, &subjectId)
+	if err != nil {
+		return nil, err
+	}
+	students, err := fetchStudents(ses, subject)
+	if err != nil {
+		return nil, err
+	}
+	argL := models.LessonFilterRequest{
+		SubjectId:    &subject.ID,
+		PeriodNumber: periodNumber,
+	}
+	argL.Limit = new(int)
+	*argL.Limit = 500
+	lessons, _, err := store.Store().LessonsFindBy(ses.Context(), argL)
+	if err != nil {
+		return nil, err
+	}
+	lessons = lessonsSort(lessons)
+
+	res := &models.AssignmentSubmissionMatrixResponse{
+		Students:      students,
+		Lessons:       []models.LessonResponse{},
+		Submissions:   []models.AssignmentSubmissionResponse{},
+		MissingCounts: map[string]int{},
+	}
+	lessonIds := []string{}
+	for _, lesson := range lessons {
+		if !lesson.HasAssignment() {
+			continue
+		}
+		lessonIds = append(lessonIds, lesson.ID)
+		item := models.LessonResponse{}
+		item.FromModel(lesson)
+		res.Lessons = append(res.Lessons, item)
+	}
+	if len(lessonIds) < 1 {
+		return res, nil
+	}
+	studentIds := []string{}
+	for _, s := range students {
+		studentIds = append(studentIds, s.ID)
+	}
+	submissions, err := store.Store().AssignmentSubmissionsFindBy(ses.Context(), models.AssignmentSubmissionFilterRequest{
+		LessonIds:  &lessonIds,
+		StudentIds: &studentIds,
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range submissions {
+		item := models.AssignmentSubmissionResponse{}
+		item.FromModel(v)
+		res.Submissions = append(res.Submissions, item)
+	}
+	now := time.Now()
+	for _, lesson := range lessons {
+		if !slices.Contains(lessonIds, lesson.ID) {
+			continue
+		}
+		for _, s := range students {
+			var submission *models.AssignmentSubmission
+			for _, v := range submissions {
+				if v.LessonId == lesson.ID && v.StudentId == s.ID {
+					submission = v
+				}
+			}
+			if models.AssignmentIsMissing(lesson, submission, now) {
+				res.MissingCounts[lesson.ID]++
+			}
+		}
+	}
+	return res, nil
+}
+
+// AssignmentSubmissionsGrade gives feedback on submission: graded value is set to journal
+// as grade of lesson (see gradeMake), returned submission waits for resubmission
+func AssignmentSubmissionsGrade(ses *utils.Session, data models.AssignmentSubmissionGradeRequest) (*models.AssignmentSubmissionResponse, error) {
+	sp, ctx := apm.StartSpan(ses.Context(), "AssignmentSubmissionsGrade", "app")
+	ses.SetContext(ctx)
+	defer sp.End()
+	m, err := store.Store().AssignmentSubmissionsFindById(ses.Context(), *data.ID)
+	if err != nil {
+		return nil, ErrNotfound.SetKey("id")
+	}
+	lesson, err := store.Store().LessonsFindById(ses.Context(), m.LessonId)
+	if err != nil {
+		return nil, ErrNotfound.SetKey("lesson_id")
+	}
+	// teacher grades only submissions of own subject
+	if _, err := journalSubjectFind(ses, &lesson.SubjectId); err != nil {
+		return nil, ErrForbidden.SetKey("id")
+	}
+
+	now := time.Now()
+	m.Status = *data.Status
+	m.Feedback = data.Feedback
+	m.GradedBy = &ses.GetUser().ID
+	m.GradedAt = &now
+	if m.Status == string(models.SubmissionStatusGraded) {
+		if data.Value == nil {
+			return nil, ErrRequired.SetKey("value")
+		}
+		_, _, err = gradeMake(ses, &models.GradeRequest{
+			StudentId: m.StudentId,
+			Value:     data.Value,
+			Comment:   data.Feedback,
+			UpdatedBy: ses.GetUser().ID,
+		}, lesson)
+		if err != nil {
+			return nil, err
+		}
+		m.GradeValue = data.Value
+	} else {
+		m.GradeValue = nil
+	}
+	m, err = store.Store().AssignmentSubmissionUpdate(ses.Context(), m)
+	if err != nil {
+		return nil, err
+	}
+	err = store.Store().AssignmentSubmissionsLoadRelations(ses.Context(), []*models.AssignmentSubmission{m})
+	if err != nil {
+		return nil, err
+	}
+	res := &models.AssignmentSubmissionResponse{}
+	res.FromModel(m)
+	return res, nil
+}
+
+// assignmentSubmissionsByLesson returns submissions of student by lesson id
+func assignmentSubmissionsByLesson(ses *utils.Session, studentId string, lessonIds []string) (map[string]*models.AssignmentSubmission, error) {
+	res := map[string]*models.AssignmentSubmission{}
+	if len(lessonIds) < 1 {
+		return res, nil
+	}
+	l, err := store.Store().AssignmentSubmissionsFindBy(ses.Context(), models.AssignmentSubmissionFilterRequest{
+		LessonIds: &lessonIds,
+		StudentId: &studentId,
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range l {
+		res[v.LessonId] = v
+	}
+	return res, nil
+}
+
+// assignmentsMissing returns lessons of classroom in date range with assignment due and not submitted by student
+func assignmentsMissing(ses *utils.Session, student *models.User, classroomId string, startDate, endDate time.Time) ([]models.DiaryLessonResponse, error) {
+	argL := models.LessonFilterRequest{
+		ClassroomId: &classroomId,
+		DateRange:   &[]string{startDate.Format(time.DateOnly), endDate.Format(time.DateOnly)},
+	}
+	argL.Limit = new(int)
+	*argL.Limit = 1000
+	lessons, _, err := store.Store().LessonsFindBy(ses.Context(), argL)
+	if err != nil {
+		return nil, err
+	}
+	lessonIds := []string{}
+	for _, v := range lessons {
+		if v.HasAssignment() {
+			lessonIds = append(lessonIds, v.ID)
+		}
+	}
+	submissions, err := assignmentSubmissionsByLesson(ses, student.ID, lessonIds)
+	if err != nil {
+		return nil, err
+	}
+	missing := []*models.Lesson{}
+	now := time.Now()
+	for _, v := range lessonsSort(lessons) {
+		if models.AssignmentIsMissing(v, submissions[v.ID], now) {
+			missing = append(missing, v)
+		}
+	}
+	res := []models.DiaryLessonResponse{}
+	if len(missing) < 1 {
+		return res, nil
+	}
+	err = store.Store().LessonsLoadSubject(ses.Context(), &missing)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range missing {
+		item := models.DiaryLessonResponse{IsAssignmentMissing: true}
+		item.Lesson = &models.LessonResponse{}
+		item.Lesson.FromModel(v)
+		if v.Subject != nil {
+			item.Subject = &models.SubjectResponse{}
+			item.Subject.FromModel(v.Subject)
+		}
+		if s, ok := submissions[v.ID]; ok {
+			item.Submission = &models.AssignmentSubmissionResponse{}
+			item.Submission.FromModel(s)
+		}
+		res = append(res, item)
+	}
+	return res, nil
+}
@@ -25,30 +25,10 @@ func (a App) LessonJournal(ses *utils.Session, data LessonJournalRequest) (*mode
 	ses.SetContext(ctx)
 	defer sp.End()
 	// fetch subject
-	args := models.SubjectFilterRequest{
-		ID: data.SubjectId,
-	}
-
-	// check access
-	if data.SubjectId == nil {
-		return nil, ErrNotExists.SetKey("subject_id")
-	}
-	if *ses.GetRole() == models.RoleAdmin {
-		args.SchoolId = ses.GetSchoolId()
-	} else if *ses.GetRole() == models.RoleOrganization {
-		args.SchoolId = ses.GetSchoolId()
-	} else if *ses.GetRole() == models.RolePrincipal {
-		args.SchoolId = ses.GetSchoolId()
-	} else {
-		args.TeacherIds = []string{ses.GetUser().ID}
-	}
-
-	// subject find
-	ls, _, err := store.Store().SubjectsListFilters(ses.Context(), &args)
-	if err != nil || len(ls) < 1 {
-		return nil, ErrNotExists.SetKey("subject_id")
+	subject, err := journalSubjectFind(ses, data.SubjectId)
+	if err != nil {
+		return nil, err
 	}
-	subject := ls[0]
 	subjectId := subject.GetId()
 	res := models.JournalResponse{}
 	if data.OnlyLessons {
@@ -93,6 +73,34 @@ func (a App) LessonJournal(ses *utils.Session, data LessonJournalRequest) (*mode
 	return &res, nil
 }
 
+// journalSubjectFind returns subject of school for admins, for teacher only own subject
+func journalSubjectFind(ses *utils.Session, subjectId *string) (*models.Subject, error) {
+	args := models.SubjectFilterRequest{
+		ID: subjectId,
+	}
+
+	// check access
+	if subjectId == nil {
+		return nil, ErrNotExists.SetKey("subject_id")
+	}
+	if *ses.GetRole() == models.RoleAdmin {
+		args.SchoolId = ses.GetSchoolId()
+	} else if *ses.GetRole() == models.RoleOrganization {
+		args.SchoolId = ses.GetSchoolId()
+	} else if *ses.GetRole() == models.RolePrincipal {
+		args.SchoolId = ses.GetSchoolId()
+	} else {
+		args.TeacherIds = []string{ses.GetUser().ID}
+	}
+
+	// subject find
+	ls, _, err := store.Store().SubjectsListFilters(ses.Context(), &args)
+	if err != nil || len(ls) < 1 {
+		return nil, ErrNotExists.SetKey("subject_id")
+	}
+	return ls[0], nil
+}
+
 func fetchJournalItems(ses *utils.Session, subjectId string, schoolId string, periodNumber *int, lessonDate *time.Time, hourNumber *int) ([]models.JournalItemResponse, *int, error) {
 	sp, ctx := apm.StartSpan(ses.Context(), "fetchJournalItems", "app")
 	ses.SetContext(ctx)
@@ -299,9 +307,9 @@ func LessonUpdateFormLessonPro(c *gin.Context, data *models.JournalFormRequest)
 		}
 		for _, v := range *data.LessonProFilesDelete {
 			filePath := extractFullUrl(v)
-			deleteFile(c, filePath, "lesson_pro")
 			k := slices.Index(paths, filePath)
 			if k >= 0 {
+				deleteFile(c, filePath, "lesson_pro")
 				paths = slices.Delete(paths, k, k+1)
 			}
 		}
@@ -337,9 +345,9 @@ func LessonUpdateFormAssignment(c *gin.Context, data *models.JournalFormRequest)
 		}
 		for _, v := range *data.AssignmentFilesDelete {
 			prefix := extractFullUrl(v)
-			deleteFile(c, prefix, "assignments")
 			k := slices.Index(paths, prefix)
 			if k >= 0 {
+				deleteFile(c, prefix, "assignments")
 				paths = slices.Delete(paths, k, k+1)
 			}
 		}
@@ -28,6 +28,11 @@ func absentMake(ses *apiutils.Session, data *models.AbsentRequest, lesson models
 			return nil, nil, err
 		}
 
+		// new absents by student for parents notification
+		notifyValues := map[string]string{}
+		// saved and deleted absents for event stream
+		savedAbsents := []*models.Absent{}
+		deletedIds := []string{}
 		for _, studentId := range data.StudentIds {
 			newAbsent := models.Absent{}
 			newAbsent.FromRequest(data)
@@ -54,13 +59,16 @@ func absentMake(ses *apiutils.Session, data *models.AbsentRequest, lesson models
 					continue
 				}
 				absents = []*models.Absent{}
+				deletedIds = append(deletedIds, studentId)
 			} else {
 				newAbsent, err = store.Store().AbsentsCreateOrUpdate(ses.Context(), newAbsent)
 				if err != nil {
 					continue
 				}
+				savedAbsents = append(savedAbsents, &newAbsent)
 				if oldAbsent.ID != newAbsent.ID {
 					absents = append(absents, &newAbsent)
+					notifyValues[newAbsent.StudentId] = ""
 				}
 			}
 			// update period grade
@@ -79,6 +87,9 @@ func absentMake(ses *apiutils.Session, data *models.AbsentRequest, lesson models
 				// }
 			}
 		}
+		go notifyJournal(models.NotifyAbsence, lesson, notifyValues)
+		go eventsEmitAbsents(savedAbsents)
+		go eventsEmitDeleted(EventAbsentDelete, lesson.ID, deletedIds)
 		if err != nil {
 			return nil, nil, err
 		}
@@ -1,17 +1,15 @@
 package app
 
 import (
-	"errors"
 	"math/rand"
 	"mime/multipart"
-	"os"
 	"slices"
-	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/mekdep/server/internal/models"
 	"github.com/mekdep/server/internal/store"
 	"github.com/mekdep/server/internal/utils"
+	"github.com/mekdep/server/internal/utils/storage"
 )
 
 func LessonUpdateAssignment(c *gin.Context, data *models.AssignmentRequest) (*models.Lesson, error) {
@@ -31,9 +29,10 @@ func LessonUpdateAssignment(c *gin.Context, data *models.AssignmentRequest) (*mo
 			*data.FilesDelete = paths
 		}
 		for _, v := range *data.FilesDelete {
-			deleteFile(c, v, "assignments")
+			v = extractFullUrl(v)
 			k := slices.Index(paths, v)
 			if k >= 0 {
+				deleteFile(c, v, "assignments")
 				paths = slices.Delete(paths, k, k+1)
 			}
 		}
@@ -50,6 +49,9 @@ func LessonUpdateAssignment(c *gin.Context, data *models.AssignmentRequest) (*mo
 	ml.AssignmentTitle = data.Title
 	ml.AssignmentContent = data.Content
 	ml.AssignmentFiles = data.Files
+	if dueAt := data.DueAtTime(); dueAt != nil {
+		ml.AssignmentDueAt = dueAt
+	}
 	ml, err = store.Store().LessonsUpdate(c, ml)
 
 	// deprecated
@@ -82,46 +84,24 @@ func handleFilesUpload(c *gin.Context, key string, folder string) ([]string, err
 
 // TODO refactor: same function also has in api.go
 func deleteFile(c *gin.Context, path string, folder string) error {
-
-	return nil
+	err := storage.Delete(c, path, folder)
+	if err != nil {
+		utils.LoggerDesc("File delete error").Error(err)
+	}
+	return err
 }
 
 func extractFullUrl(url2 string) string {
-	prefix := "https://mekdep.edu.tm/uploads/"
-	if strings.HasPrefix(url2, prefix) {
-		filePath := strings.TrimPrefix(url2, prefix)
-		return filePath
-	}
-	return url2
+	return storage.PathFromUrl(url2)
 }
 
 func handleFile(c *gin.Context, handler *multipart.FileHeader, subFolder string) (string, error) {
-	var err error
-	uploadPath := "web/uploads/" + subFolder + "/"
-	fParts := strings.Split(handler.Filename, ".")
-	ext := "." + strings.ToLower(fParts[len(fParts)-1])
-	if ext != ".png" && ext != ".jpg" && ext != ".jpeg" && ext != ".zip" && ext != ".rar" && ext != ".mp3" && ext != ".mp4" && ext != ".pdf" && ext != ".docx" && ext != ".doc" && ext != ".xlsx" {
-		return "", errors.New("invalid extension: " + ext)
-	}
-	folderHash := RandStringBytes(16)
-	uploadPath = uploadPath + folderHash + "/"
-	fileName := handler.Filename
-
-	err = c.SaveUploadedFile(handler, uploadPath+fileName)
+	filePath, _, err := storage.Upload(c, handler, subFolder, storage.DefaultExts)
 	if err != nil {
 		utils.LoggerDesc("HTTP error").Error(err)
 		return "", err
 	}
-	err = os.Chmod(uploadPath, 0777)
-	if err != nil {
-		return "", err
-	}
-	err = os.Chmod(uploadPath+fileName, 0777)
-	if err != nil {
-		return "", err
-	}
-
-	return subFolder + "/" + folderHash + "/" + fileName, nil
+	return filePath, nil
 }
 
 // make utils
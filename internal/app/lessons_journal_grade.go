@@ -30,6 +30,11 @@ func gradeMake(ses *apiutils.Session, data *models.GradeRequest, lesson models.L
 		}
 
 		var listErr error
+		// new grades by student for parents notification
+		notifyValues := map[string]string{}
+		// saved and deleted grades for event stream
+		savedGrades := []*models.Grade{}
+		deletedIds := []string{}
 		for _, studentId := range data.StudentIds {
 			newGrade := models.Grade{}
 			newGrade.FromRequest(data)
@@ -60,14 +65,17 @@ func gradeMake(ses *apiutils.Session, data *models.GradeRequest, lesson models.L
 					continue
 				}
 				grades = []*models.Grade{}
+				deletedIds = append(deletedIds, studentId)
 			} else {
 				newGrade, err := store.Store().GradesCreateOrUpdate(ses.Context(), newGrade)
 				if err != nil {
 					listErr = err
 					continue
 				}
+				savedGrades = append(savedGrades, &newGrade)
 				if oldGrade.ID != newGrade.ID {
 					grades = append(grades, &newGrade)
+					notifyValues[newGrade.StudentId] = newGrade.ValueString()
 				}
 			}
 			// update period grade
@@ -88,6 +96,9 @@ func gradeMake(ses *apiutils.Session, data *models.GradeRequest, lesson models.L
 				// }
 			}
 		}
+		go notifyJournal(models.NotifyNewGrade, lesson, notifyValues)
+		go eventsEmitGrades(savedGrades)
+		go eventsEmitDeleted(EventGradeDelete, lesson.ID, deletedIds)
 		if listErr != nil {
 			return nil, nil, err
 		}
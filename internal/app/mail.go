@@ -0,0 +1,74 @@
+package app
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/mekdep/server/config"
+	"github.com/mekdep/server/internal/models"
+	"github.com/mekdep/server/internal/store"
+	apputils "github.com/mekdep/server/internal/utils"
+	"github.com/mekdep/server/internal/utils/mail"
+)
+
+const mailSendTimeout = time.Minute
+
+const (
+	emailVerifyExpire        = time.Hour * 24
+	emailPasswordResetExpire = time.Hour * 2
+)
+
+// links of web app opened from emails
+const (
+	mailLinkVerify        = "/email/verify?token="
+	mailLinkPasswordReset = "/password/reset?token="
+	mailLinkJournalStats  = "/statistics/journal"
+	mailLinkReport        = "/reports/"
+)
+
+func mailLink(path string) string {
+	return config.Conf.AppUrl + path
+}
+
+// mailSend renders template in given language and sends it to one address
+func mailSend(to string, template string, lang string, data map[string]interface{}) error {
+	msg, err := mail.Render(template, lang, data)
+	if err != nil {
+		return err
+	}
+	msg.To = []string{to}
+	ctx, cancel := context.WithTimeout(context.Background(), mailSendTimeout)
+	defer cancel()
+	err = mail.Provider().Send(ctx, msg)
+	apputils.MetricsMail(template, err)
+	return err
+}
+
+// mailTokenCreate saves hash of new random token, plain token is only sent in email
+func mailTokenCreate(ctx context.Context, user *models.User, tokenType string, expire time.Duration) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(b)
+	m := &models.EmailToken{
+		UserId:    user.ID,
+		Type:      tokenType,
+		Email:     *user.Email,
+		TokenHash: mailTokenHash(token),
+		ExpiresAt: time.Now().Add(expire),
+	}
+	err := store.Store().EmailTokensCreate(ctx, m)
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+func mailTokenHash(token string) string {
+	h := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(h[:])
+}
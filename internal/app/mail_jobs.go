@@ -0,0 +1,256 @@
+package app
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mekdep/server/internal/api/utils"
+	"github.com/mekdep/server/internal/models"
+	"github.com/mekdep/server/internal/store"
+	apputils "github.com/mekdep/server/internal/utils"
+	"github.com/mekdep/server/internal/utils/mail"
+)
+
+// StatisticsJournal loads up to 500 schools at once
+const mailDigestSchoolsChunk = 500
+const mailUsersPage = 500
+
+// reminders are sent when this many days left to deadline
+var reportReminderDays = []int{3, 1, 0}
+
+type mailDigestRow struct {
+	School   string
+	Students string
+	Lessons  string
+	Percent  int
+	IsLow    bool
+}
+
+// mailUsersVerified returns users of roles with verified email, with school relations
+func mailUsersVerified(ses *utils.Session, roles []string, schoolIds *[]string) ([]*models.User, error) {
+	verified := true
+	res := []*models.User{}
+	for offset := 0; ; offset += mailUsersPage {
+		f := models.UserFilterRequest{
+			Roles:         &roles,
+			SchoolIds:     schoolIds,
+			EmailVerified: &verified,
+		}
+		f.Limit = new(int)
+		*f.Limit = mailUsersPage
+		f.Offset = new(int)
+		*f.Offset = offset
+		users, _, err := store.Store().UsersFindBy(ses.Context(), f)
+		if err != nil {
+			return nil, err
+		}
+		err = store.Store().UsersLoadRelations(ses.Context(), &users, false)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, users...)
+		if len(users) < mailUsersPage {
+			break
+		}
+	}
+	return res, nil
+}
+
+// mailDigestSchoolIds returns schools which principal or organization user sees in digest
+func mailDigestSchoolIds(ses *utils.Session, user *models.User) ([]string, error) {
+	ids := []string{}
+	for _, us := range user.Schools {
+		if us.RoleCode == models.RolePrincipal && us.SchoolUid != nil {
+			ids = append(ids, *us.SchoolUid)
+		}
+		if us.RoleCode == models.RoleOrganization && us.School != nil && us.School.Code != nil {
+			regionIds, err := notificationRegionSchoolIds(ses, []string{*us.School.Code})
+			if err != nil {
+				return nil, err
+			}
+			ids = append(ids, regionIds...)
+		}
+	}
+	slices.Sort(ids)
+	return slices.Compact(ids), nil
+}
+
+// MailWeeklyDigest sends journal fill statistics of last week to principals and organizations
+func MailWeeklyDigest() error {
+	ses := &utils.Session{}
+	users, err := mailUsersVerified(ses, []string{string(models.RolePrincipal), string(models.RoleOrganization)}, nil)
+	if err != nil {
+		return err
+	}
+	// week ends today of user, schools are counted once per distinct week
+	userEndDates := map[string]time.Time{}
+	userSchoolIds := map[string][]string{}
+	weekIds := map[time.Time][]string{}
+	for _, u := range users {
+		ids, err := mailDigestSchoolIds(ses, u)
+		if err != nil {
+			return err
+		}
+		now := time.Now().In(u.Location())
+		endDate := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+		userEndDates[u.ID] = endDate
+		userSchoolIds[u.ID] = ids
+		weekIds[endDate] = append(weekIds[endDate], ids...)
+	}
+
+	rows := map[time.Time]map[string]StatisticsRow{}
+	for endDate, allIds := range weekIds {
+		slices.Sort(allIds)
+		allIds = slices.Compact(allIds)
+		startDate := endDate.AddDate(0, 0, -7)
+		rows[endDate] = map[string]StatisticsRow{}
+		for start := 0; start < len(allIds); start += mailDigestSchoolsChunk {
+			part := allIds[start:min(start+mailDigestSchoolsChunk, len(allIds))]
+			res, err := Ap().StatisticsJournal(ses, ReportsRequestDto{
+				StartDate: &startDate,
+				EndDate:   &endDate,
+				SchoolIds: &part,
+			})
+			if err != nil {
+				return err
+			}
+			for _, r := range res.Rows {
+				rows[endDate][r.SchoolId] = r
+			}
+		}
+	}
+
+	for _, u := range users {
+		endDate := userEndDates[u.ID]
+		startDate := endDate.AddDate(0, 0, -7)
+		digestRows := []mailDigestRow{}
+		full := 0
+		percentSum := 0
+		for _, id := range userSchoolIds[u.ID] {
+			r, ok := rows[endDate][id]
+			if !ok {
+				continue
+			}
+			row := mailDigestRow{School: r.School}
+			if r.Percent != nil {
+				row.Percent = int(*r.Percent)
+			}
+			if len(r.Values) > 2 {
+				row.Students = string(r.Values[1])
+				row.Lessons = string(r.Values[2])
+			}
+			row.IsLow = row.Percent < 50
+			if !row.IsLow {
+				full++
+			}
+			percentSum += row.Percent
+			digestRows = append(digestRows, row)
+		}
+		if len(digestRows) < 1 {
+			continue
+		}
+		// least filled first, they need attention
+		slices.SortStableFunc(digestRows, func(a, b mailDigestRow) int {
+			return a.Percent - b.Percent
+		})
+		err = mailSend(*u.Email, mail.TemplateWeeklyDigest, "", map[string]interface{}{
+			"Name":           u.FullName(),
+			"StartDate":      startDate.Format(time.DateOnly),
+			"EndDate":        endDate.AddDate(0, 0, -1).Format(time.DateOnly),
+			"Rows":           digestRows,
+			"SchoolsCount":   len(digestRows),
+			"FullCount":      full,
+			"AveragePercent": percentSum / len(digestRows),
+			"Link":           mailLink(mailLinkJournalStats),
+		})
+		if err != nil {
+			apputils.LoggerDesc("In MailWeeklyDigest " + u.ID).Error(err)
+		}
+	}
+	return nil
+}
+
+// MailReportDeadlineReminders reminds principals of schools which did not submit report (or did not correct returned one) before deadline
+func MailReportDeadlineReminders() error {
+	ses := &utils.Session{}
+	now := time.Now()
+	// a day more for schools which timezone is behind, days left are counted in timezone of school
+	deadlineTo := now.AddDate(0, 0, slices.Max(reportReminderDays)+2)
+	reports, _, err := store.Store().ReportsFindBy(ses.Context(), models.ReportsFilterRequest{
+		DeadlineFrom: &now,
+		DeadlineTo:   &deadlineTo,
+	})
+	if err != nil {
+		return err
+	}
+	for _, report := range reports {
+		err = mailReportDeadlineSend(ses, report, now)
+		if err != nil {
+			apputils.LoggerDesc("In MailReportDeadlineReminders " + report.ID).Error(err)
+		}
+	}
+	return nil
+}
+
+// mailReportDaysLeft counts days from today till day of deadline in timezone of school
+func mailReportDaysLeft(deadline time.Time, now time.Time, loc *time.Location) int {
+	now = now.In(loc)
+	deadline = deadline.In(loc)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	deadlineDay := time.Date(deadline.Year(), deadline.Month(), deadline.Day(), 0, 0, 0, 0, time.UTC)
+	return int(deadlineDay.Sub(today).Hours() / 24)
+}
+
+func mailReportDeadlineSend(ses *utils.Session, report *models.Reports, now time.Time) error {
+	items, _, err := store.Store().ReportItemsFindBy(ses.Context(), models.ReportItemsFilterRequest{
+		ReportId: &report.ID,
+	})
+	if err != nil {
+		return err
+	}
+	unfilled := []string{}
+	for _, item := range items {
+		// region items are filled by organizations, classroom items by teachers
+		if item.ClassroomId != nil || item.SchoolId == nil || !slices.Contains(report.SchoolIds, *item.SchoolId) {
+			continue
+		}
+		if !item.IsFilled() {
+			unfilled = append(unfilled, *item.SchoolId)
+		}
+	}
+	if len(unfilled) < 1 {
+		return nil
+	}
+	users, err := mailUsersVerified(ses, []string{string(models.RolePrincipal)}, &unfilled)
+	if err != nil {
+		return err
+	}
+	for _, u := range users {
+		for _, us := range u.Schools {
+			if us.RoleCode != models.RolePrincipal || us.SchoolUid == nil || !slices.Contains(unfilled, *us.SchoolUid) {
+				continue
+			}
+			daysLeft := mailReportDaysLeft(*report.DeadlineAt, now, us.School.Location())
+			if !slices.Contains(reportReminderDays, daysLeft) {
+				continue
+			}
+			schoolName := ""
+			if us.School != nil && us.School.Name != nil {
+				schoolName = strings.TrimSpace(*us.School.Name)
+			}
+			err = mailSend(*u.Email, mail.TemplateReportDeadline, "", map[string]interface{}{
+				"Name":        u.FullName(),
+				"ReportTitle": report.Title,
+				"SchoolName":  schoolName,
+				"Deadline":    report.DeadlineAt.In(us.School.Location()).Format("2006-01-02 15:04"),
+				"DaysLeft":    daysLeft,
+				"Link":        mailLink(mailLinkReport + report.ID),
+			})
+			if err != nil {
+				apputils.LoggerDesc("In mailReportDeadlineSend " + u.ID).Error(err)
+			}
+		}
+	}
+	return nil
+}
@@ -177,12 +177,15 @@ func ConnectAndHandleMessages(ses *utils.Session, dto *models.MessageRequest, co
 		}
 		groupsWithClients[*dto.GroupId] = clientMap
 	}
+	apputils.MetricsChatConnections.WithLabelValues(*dto.GroupId).Inc()
 
 	defer func() {
 		conn.Close()
+		apputils.MetricsChatConnections.WithLabelValues(*dto.GroupId).Dec()
 		delete(groupsWithClients[*dto.GroupId], client)
 		if len(groupsWithClients[*dto.GroupId]) == 0 {
 			delete(groupsWithClients, *dto.GroupId)
+			apputils.MetricsChatConnections.DeleteLabelValues(*dto.GroupId)
 		}
 	}()
 
@@ -0,0 +1,75 @@
+package app
+
+import (
+	"slices"
+
+	"github.com/mekdep/server/internal/api/utils"
+	"github.com/mekdep/server/internal/models"
+	"github.com/mekdep/server/internal/store"
+	"go.elastic.co/apm/v2"
+)
+
+// NotificationPreferencesList returns preference of every category, of user when userId is set, otherwise of role
+func NotificationPreferencesList(ses *utils.Session, userId *string, role models.Role) ([]*models.NotificationPreferenceResponse, error) {
+	sp, ctx := apm.StartSpan(ses.Context(), "NotificationPreferencesList", "app")
+	ses.SetContext(ctx)
+	defer sp.End()
+	res := []*models.NotificationPreferenceResponse{}
+	for _, category := range models.NotificationCategories {
+		var pref models.NotificationPreference
+		var err error
+		if userId != nil {
+			var prefs map[string]models.NotificationPreference
+			prefs, err = notifyPreferences([]string{*userId}, role, category)
+			pref = prefs[*userId]
+		} else {
+			pref, err = notifyRoleDefault(role, category)
+		}
+		if err != nil {
+			return nil, err
+		}
+		r := &models.NotificationPreferenceResponse{}
+		r.FromModel(&pref)
+		if userId != nil {
+			r.IsDefault = pref.UserId == nil
+		} else {
+			r.IsDefault = pref.Role == nil
+		}
+		res = append(res, r)
+	}
+	return res, nil
+}
+
+// NotificationPreferencesUpdate replaces preferences of user when userId is set, otherwise of role
+func NotificationPreferencesUpdate(ses *utils.Session, userId *string, role models.Role, data []models.NotificationPreferenceRequest) ([]*models.NotificationPreferenceResponse, error) {
+	sp, ctx := apm.StartSpan(ses.Context(), "NotificationPreferencesUpdate", "app")
+	ses.SetContext(ctx)
+	defer sp.End()
+	if userId == nil && !slices.Contains(models.DefaultRoles, role) {
+		return nil, ErrInvalid.SetKey("role")
+	}
+	items := []*models.NotificationPreference{}
+	categories := map[string]bool{}
+	for _, r := range data {
+		if categories[r.Category] {
+			return nil, ErrInvalid.SetKey("category").SetComment("duplicate: " + r.Category)
+		}
+		categories[r.Category] = true
+		if (r.QuietStart == nil) != (r.QuietEnd == nil) {
+			return nil, ErrRequired.SetKey("quiet_end")
+		}
+		m := &models.NotificationPreference{}
+		r.ToModel(m)
+		items = append(items, m)
+	}
+	var rolePtr *string
+	if userId == nil {
+		roleStr := string(role)
+		rolePtr = &roleStr
+	}
+	err := store.Store().NotificationPreferencesReplace(ses.Context(), userId, rolePtr, items)
+	if err != nil {
+		return nil, err
+	}
+	return NotificationPreferencesList(ses, userId, role)
+}
@@ -1,14 +1,10 @@
 package app
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
-	"net/http"
 	"slices"
+	"time"
 
-	"firebase.google.com/go/v4/messaging"
-	"github.com/appleboy/go-fcm"
 	"github.com/mekdep/server/internal/api/utils"
 	"github.com/mekdep/server/internal/models"
 	"github.com/mekdep/server/internal/store"
@@ -60,6 +56,10 @@ func NotificationDetail(f models.UserNotificationFilterRequest) (*models.Notific
 	if err != nil {
 		return nil, 0, err
 	}
+	err = store.Store().UserNotificationsLoadPushDeliveries(context.Background(), &nn)
+	if err != nil {
+		return nil, 0, err
+	}
 	for _, v := range nn {
 		v.Notifications = nil
 		nnr := models.UserNotificationResponse{}
@@ -103,6 +103,13 @@ func NotificationCreate(ses *utils.Session, data models.NotificationsRequest) (*
 	model := &models.Notifications{}
 	data.ToModel(model)
 
+	if len(model.Regions) > 0 {
+		regionSchoolIds, err := notificationRegionSchoolIds(ses, model.Regions)
+		if err != nil {
+			return nil, err
+		}
+		model.SchoolIds = append(model.SchoolIds, regionSchoolIds...)
+	}
 	if model.SchoolIds == nil || len(model.SchoolIds) < 1 {
 		model.SchoolIds = []string{}
 		if ses.GetSchoolId() != nil {
@@ -127,6 +134,18 @@ func NotificationCreate(ses *utils.Session, data models.NotificationsRequest) (*
 		return nil, ErrRequired.SetKey("roles")
 	}
 	model.AuthorID = &ses.GetUser().ID
+	now := time.Now()
+	if model.ExpiresAt != nil && !model.ExpiresAt.After(now) {
+		return nil, ErrInvalid.SetKey("expires_at")
+	}
+	if model.SendAt != nil && model.ExpiresAt != nil && !model.ExpiresAt.After(*model.SendAt) {
+		return nil, ErrInvalid.SetKey("expires_at")
+	}
+	// scheduled ones are sent by NotificationsSendScheduled
+	isScheduled := model.SendAt != nil && model.SendAt.After(now)
+	if !isScheduled {
+		model.SentAt = &now
+	}
 
 	var err error
 	model, err = store.Store().NotificationCreate(ses.Context(), model)
@@ -134,9 +153,11 @@ func NotificationCreate(ses *utils.Session, data models.NotificationsRequest) (*
 		return nil, err
 	}
 
-	err = createNotificationItems(*model)
-	if err != nil {
-		return nil, err
+	if !isScheduled {
+		err = createNotificationItems(*model)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	err = store.Store().NotificationsLoadRelations(ses.Context(), &[]*models.Notifications{model})
@@ -200,27 +221,144 @@ func createNotificationItems(model models.Notifications) error {
 	return nil
 }
 
+// NotificationsSendScheduled sends notifications whose send time came
+func NotificationsSendScheduled() error {
+	nn, err := store.Store().NotificationsClaimScheduled(context.Background(), time.Now())
+	if err != nil {
+		return err
+	}
+	for _, n := range nn {
+		err = createNotificationItems(*n)
+		if err != nil {
+			apputils.LoggerDesc("In NotificationsSendScheduled").Error(err)
+		}
+	}
+	return nil
+}
+
+// notificationRegionSchoolIds returns schools of regions, region is key of models.Regions or code of region itself
+func notificationRegionSchoolIds(ses *utils.Session, regions []string) ([]string, error) {
+	codes := []string{}
+	for _, r := range regions {
+		if v, ok := models.Regions[r]; ok {
+			codes = append(codes, v...)
+		} else {
+			codes = append(codes, r)
+		}
+	}
+	isParent := true
+	parents, _, err := store.Store().SchoolsFindBy(ses.Context(), models.SchoolFilterRequest{
+		Codes:    &codes,
+		IsParent: &isParent,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(parents) < 1 {
+		return []string{}, nil
+	}
+	parentIds := []string{}
+	for _, v := range parents {
+		parentIds = append(parentIds, v.ID)
+	}
+	schools, _, err := store.Store().SchoolsFindBy(ses.Context(), models.SchoolFilterRequest{
+		ParentUids: &parentIds,
+	})
+	if err != nil {
+		return nil, err
+	}
+	res := []string{}
+	for _, v := range schools {
+		res = append(res, v.ID)
+	}
+	return res, nil
+}
+
+// notificationClassroomIds returns targeted classrooms of school, nil when all school is targeted
+func notificationClassroomIds(n models.Notifications, sid string) (*[]string, error) {
+	if !n.HasClassroomTarget() {
+		return nil, nil
+	}
+	res := []string{}
+	res = append(res, n.ClassroomIds...)
+	if len(n.Classyears) > 0 && sid != "" {
+		cc, _, err := store.Store().ClassroomsFindBy(context.Background(), models.ClassroomFilterRequest{
+			SchoolId: &sid,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range cc {
+			if c.ParentId == nil && slices.Contains(n.Classyears, c.Classyear()) && !slices.Contains(res, c.ID) {
+				res = append(res, c.ID)
+			}
+		}
+	}
+	return &res, nil
+}
+
+// users are fanned out page by page, each page gets its user notifications and pushes
+const notificationFanoutPage = 1000
+
 func createNotificationItem(n models.Notifications, sid string, r models.Role, ids []string) error {
 	rStr := string(r)
-	sidP := &sid
-	if r == models.RoleAdmin || r == models.RoleOrganization {
-		sidP = nil
-	} else {
+	var sidP *string
+	if r != models.RoleAdmin && r != models.RoleOrganization {
 		sidP = &sid
 	}
 	f := models.UserFilterRequest{
 		SchoolId: sidP,
 		Role:     &rStr,
 	}
-	f.Limit = new(int)
-	*f.Limit = 10000
 	if ids != nil && len(ids) > 0 {
 		f.Ids = &ids
 	}
-	uu, _, err := store.Store().UsersFindBy(context.Background(), f)
+	// classrooms and tariffs narrow audience to students, their parents and class teachers
+	classroomIds, err := notificationClassroomIds(n, sid)
 	if err != nil {
 		return err
 	}
+	if classroomIds != nil {
+		if !slices.Contains([]models.Role{models.RoleStudent, models.RoleParent, models.RoleTeacher}, r) {
+			return nil
+		}
+		if len(*classroomIds) < 1 {
+			return nil
+		}
+		f.ClassroomIds = classroomIds
+	}
+	if len(n.TariffTypes) > 0 {
+		if r != models.RoleStudent && r != models.RoleParent {
+			return nil
+		}
+		f.TariffTypes = &n.TariffTypes
+	}
+	f.Limit = new(int)
+	*f.Limit = notificationFanoutPage
+	f.Offset = new(int)
+	f.Sort = new(string)
+	for {
+		*f.Sort = "u.uid~"
+		uu, _, err := store.Store().UsersFindBy(context.Background(), f)
+		if err != nil {
+			return err
+		}
+		if len(uu) < 1 {
+			return nil
+		}
+		err = createNotificationItemsPage(n, sidP, r, uu)
+		if err != nil {
+			return err
+		}
+		if len(uu) < *f.Limit {
+			return nil
+		}
+		*f.Offset += *f.Limit
+	}
+}
+
+func createNotificationItemsPage(n models.Notifications, sid *string, r models.Role, uu []*models.User) error {
+	rStr := string(r)
 	uids := []string{}
 	nn := []models.UserNotification{}
 	for _, u := range uu {
@@ -228,159 +366,89 @@ func createNotificationItem(n models.Notifications, sid string, r models.Role, i
 			NotificationId: n.ID,
 			Role:           &rStr,
 			UserId:         u.ID,
+			SchoolId:       sid,
 		}
 		nn = append(nn, n)
 		uids = append(uids, u.ID)
 	}
 
 	// batch insert user notification
-	err = store.Store().UserNotificationsCreateBatch(context.Background(), nn)
+	err := store.Store().UserNotificationsCreateBatch(context.Background(), nn)
 	if err != nil {
 		return err
 	}
 
-	// do push
-	err = sendNotificationPush(n, uids, PushTypeNotification, n.ID)
+	// link push deliveries to created user notifications
+	uf := models.UserNotificationFilterRequest{
+		NotificationId: &n.ID,
+		Role:           &rStr,
+		UserIds:        &uids,
+	}
+	uf.Limit = new(int)
+	*uf.Limit = len(uids) * 2
+	uf.Offset = new(int)
+	unn, _, err := store.Store().UserNotificationsFindBy(context.Background(), uf)
 	if err != nil {
 		return err
 	}
-	return nil
-}
-
-type PushType string
+	unIds := map[string]string{}
+	for _, un := range unn {
+		unIds[un.UserId] = un.ID
+	}
 
-const PushTypeNotification PushType = "NotificationPage"
-const PushTypeChat PushType = "ChatsPage"
+	// stream to open apps
+	nr := models.NotificationsResponse{}
+	nr.FromModel(&n)
+	eventsEmit(uids, EventNotification, nr)
+	eventsEmitUnreadCount(uids, rStr)
 
-func sendNotificationPush(n models.Notifications, userIds []string, t PushType, id string) error {
-	ss, err := utils.SessionByUserIds(userIds)
-	if err != nil {
-		return nil
+	// do push by preferences of users
+	title, content := "", ""
+	if n.Title != nil {
+		title = *n.Title
 	}
-	dts := []string{}
-	for _, s := range ss {
-		if s.DeviceToken != nil && *s.DeviceToken != "" {
-			if !slices.Contains(dts, *s.DeviceToken) {
-				dts = append(dts, *s.DeviceToken)
-			}
-		}
-	}
-	// log.Println("send push: "+*n.Title, len(userIds), len(dts))
-
-	desc := ""
 	if n.Content != nil {
-		desc = *n.Content
-		if len(desc) > 255 {
-			desc = desc[:255]
-		}
-	} else {
-		desc = ""
-	}
-	go func() {
-		err := SendPushByTokens(dts, *n.Title, desc, map[string]string{
-			"page": string(t),
-			"id":   id,
-		})
-		if err != nil {
-			apputils.LoggerDesc("SendPushByTokens").Error(err)
-		}
-	}()
-	if err != nil {
-		return nil
-	}
-	return nil
+		content = *n.Content
+	}
+	return notifyDispatch(uu, Notify{
+		Category:            models.NotifyAnnouncement,
+		Role:                r,
+		Title:               title,
+		Body:                content,
+		PushType:            PushTypeNotification,
+		PushId:              n.ID,
+		UserNotificationIds: unIds,
+	})
 }
 
-func SendPushByTokens(tokens []string, title string, desc string, data map[string]string) error {
-	ctx := context.Background()
-	client, err := fcm.NewClient(
-		ctx,
-		fcm.WithCredentialsFile("service_account.json"),
-		// initial with service account
-		// fcm.WithServiceAccount("my-client-id@my-project-id.iam.gserviceaccount.com"),
-	)
+// NotificationAnalytics returns delivered, opened and read counts per school and role
+func NotificationAnalytics(ses *utils.Session, id string) ([]*models.NotificationAnalytics, error) {
+	sp, ctx := apm.StartSpan(ses.Context(), "NotificationAnalytics", "app")
+	ses.SetContext(ctx)
+	defer sp.End()
+	l, err := store.Store().NotificationsAnalytics(ses.Context(), id)
 	if err != nil {
-		return err
+		return nil, err
 	}
-
-	// Send multicast message
-	// Create a list containing up to 500 registration tokens.
-	// This registration tokens come from the client FCM SDKs.
-	limit := 500
-	for page := 0; page < len(tokens)/limit+1; page++ {
-		max := page*limit + limit
-		if max > len(tokens) {
-			max = len(tokens)
+	schoolIds := []string{}
+	for _, v := range l {
+		if v.SchoolId != nil && !slices.Contains(schoolIds, *v.SchoolId) {
+			schoolIds = append(schoolIds, *v.SchoolId)
 		}
-		partTokens := tokens[page*limit : max]
-		msg := &messaging.MulticastMessage{
-			Notification: &messaging.Notification{
-				Title: title,
-				Body:  desc,
-			},
-			Data: data,
-			APNS: &messaging.APNSConfig{
-				Headers: map[string]string{
-					"apns-priority": "10",
-				},
-			},
-			Tokens: partTokens,
-		}
-		_, err = client.SendMulticast(
-			ctx,
-			msg,
-		)
+	}
+	if len(schoolIds) > 0 {
+		ss, err := store.Store().SchoolsFindByIds(ses.Context(), schoolIds)
 		if err != nil {
-			return err
+			return nil, err
 		}
-	}
-
-	return nil
-}
-
-func SendPushByTokensOld(dts []string, title string, desc string, data map[string]interface{}) error {
-	dtsStr, _ := json.Marshal(dts)
-	dataStr, _ := json.Marshal(data)
-	if string(dataStr) != "" {
-		dataStr = []byte(`"data":` + string(dataStr) + `,`)
-	}
-	body := []byte(`{
-		"notification":{
-			"title": "` + title + `",
-			"body": "` + desc + `"
-		},
-		` + string(dataStr) + `
-		"apns": {
-			"headers": {
-				"apns-priority": 10
-			},
-			"payload": {
-				"aps": "possible"
+		for _, v := range l {
+			for _, s := range ss {
+				if v.SchoolId != nil && *v.SchoolId == s.ID {
+					v.School = &models.SchoolResponse{}
+					v.School.FromModel(s)
+				}
 			}
-		},
-		"registration_ids": ` + string(dtsStr) + `
-	}`)
-	// log.Println(string(body))
-	r, err := http.NewRequest("POST", FcmUrl, bytes.NewBuffer(body))
-	if err != nil {
-		return err
-	}
-	r.Header.Add("Authorization", "key="+FcmApiKey)
-	r.Header.Add("Content-Type", "application/json")
-	defer r.Body.Close()
-
-	client := &http.Client{}
-	resp, err := client.Do(r)
-	if err != nil {
-		panic(err)
-	}
-	defer resp.Body.Close()
-
-	if err != nil {
-		return err
+		}
 	}
-	return nil
+	return l, nil
 }
-
-const FcmUrl = "https://fcm.googleapis.com/fcm/send"
-const FcmApiKey = "AAAA4GvElcc:APA91bHW2tjaILKzKbHJvHVT5LCvF8SmPm146HsupD90JrJo837NExX3e4SZDlDqWOrK2I2rTP1vJbsQDFPMjUprM9e3V_TeT4OTzNZTIAEIMXz5m4AYziCWICYPhl8KctXNUwsGKnJY"
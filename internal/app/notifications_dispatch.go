@@ -0,0 +1,254 @@
+package app
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/mekdep/server/internal/api/utils"
+	"github.com/mekdep/server/internal/models"
+	"github.com/mekdep/server/internal/store"
+	apputils "github.com/mekdep/server/internal/utils"
+)
+
+// Notify is one event for users, sent by channel of preference of each user
+type Notify struct {
+	Category models.NotificationCategory
+	// role of receivers, used for role default preferences
+	Role  models.Role
+	Title string
+	Body  string
+	// empty when event can not be sent by sms
+	SmsText  string
+	SmsType  models.SmsType
+	PushType PushType
+	PushId   string
+	// links push deliveries to user notifications by user id (can be nil)
+	UserNotificationIds map[string]string
+}
+
+// parents without device get sms instead of push only for events about their own child,
+// mass events (announcements, chats) are not worth sms cost
+var notifySmsFallback = []models.NotificationCategory{
+	models.NotifyDailyGrades,
+	models.NotifyNewGrade,
+	models.NotifyAbsence,
+	models.NotifyPaymentExpiry,
+	models.NotifyTransferStatus,
+}
+
+// notifyRoleDefault returns preference set by admin for role, else system default
+func notifyRoleDefault(role models.Role, category models.NotificationCategory) (models.NotificationPreference, error) {
+	def := models.NotificationPreference{
+		Category: string(category),
+		Channel:  string(models.DefaultNotificationChannels[category]),
+	}
+	if role == "" {
+		return def, nil
+	}
+	isRoleDefault := true
+	categoryStr := string(category)
+	l, err := store.Store().NotificationPreferencesFindBy(context.Background(), models.NotificationPreferenceFilterRequest{
+		Roles:         &[]string{string(role)},
+		IsRoleDefault: &isRoleDefault,
+		Category:      &categoryStr,
+	})
+	if err != nil {
+		return def, err
+	}
+	if len(l) > 0 {
+		def = *l[0]
+	}
+	return def, nil
+}
+
+// notifyPreferences returns preference of each user: own, else role default, else system default
+func notifyPreferences(userIds []string, role models.Role, category models.NotificationCategory) (map[string]models.NotificationPreference, error) {
+	res := map[string]models.NotificationPreference{}
+	def, err := notifyRoleDefault(role, category)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range userIds {
+		res[id] = def
+	}
+	if len(userIds) < 1 {
+		return res, nil
+	}
+	categoryStr := string(category)
+	l, err := store.Store().NotificationPreferencesFindBy(context.Background(), models.NotificationPreferenceFilterRequest{
+		UserIds:  &userIds,
+		Category: &categoryStr,
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range l {
+		if v.UserId != nil {
+			res[*v.UserId] = *v
+		}
+	}
+	return res, nil
+}
+
+// notifyDispatch applies preferences of users and sends push or sms, deferred to end of quiet hours
+func notifyDispatch(users []*models.User, n Notify) error {
+	if len(users) < 1 {
+		return nil
+	}
+	userIds := []string{}
+	for _, u := range users {
+		userIds = append(userIds, u.ID)
+	}
+	prefs, err := notifyPreferences(userIds, n.Role, n.Category)
+	if err != nil {
+		return err
+	}
+	hasDevice := map[string]bool{}
+	ss, _ := utils.SessionByUserIds(userIds)
+	for _, s := range ss {
+		if s.DeviceToken != nil && *s.DeviceToken != "" {
+			hasDevice[s.UserId] = true
+		}
+	}
+
+	now := time.Now()
+	// grouped by send time, zero time is now
+	pushUsers := map[time.Time][]string{}
+	smsPhones := map[time.Time][]string{}
+	for _, u := range users {
+		pref := prefs[u.ID]
+		channel := models.NotificationChannel(pref.Channel)
+		phone, _ := u.FormattedPhone()
+		canSms := n.SmsText != "" && phone != ""
+		switch channel {
+		case models.ChannelPush:
+			if !hasDevice[u.ID] {
+				if n.Role == models.RoleParent && canSms && slices.Contains(notifySmsFallback, n.Category) {
+					channel = models.ChannelSms
+				} else {
+					continue
+				}
+			}
+		case models.ChannelSms:
+			if !canSms {
+				if !hasDevice[u.ID] {
+					continue
+				}
+				channel = models.ChannelPush
+			}
+		default:
+			// in app only or off
+			continue
+		}
+		sendAt := time.Time{}
+		// quiet hours are in timezone of school of user
+		if until := pref.QuietUntil(now.In(u.Location())); until != nil {
+			sendAt = until.UTC()
+		}
+		if channel == models.ChannelPush {
+			pushUsers[sendAt] = append(pushUsers[sendAt], u.ID)
+		} else if !slices.Contains(smsPhones[sendAt], phone) {
+			smsPhones[sendAt] = append(smsPhones[sendAt], phone)
+		}
+	}
+
+	for sendAt, ids := range pushUsers {
+		var at *time.Time
+		if !sendAt.IsZero() {
+			at = &sendAt
+		}
+		err = sendNotificationPushAt(models.Notifications{
+			Title:   &n.Title,
+			Content: &n.Body,
+		}, ids, n.PushType, n.PushId, n.UserNotificationIds, at)
+		if err != nil {
+			apputils.LoggerDesc("In notifyDispatch").Error(err)
+		}
+	}
+	for sendAt, phones := range smsPhones {
+		var at *time.Time
+		if !sendAt.IsZero() {
+			at = &sendAt
+		}
+		for _, phone := range phones {
+			err = SendSMSAt([]string{phone}, LettersRemoveTurkmen(n.SmsText), n.SmsType, at)
+			if err != nil {
+				apputils.LoggerDesc("In notifyDispatch").Error(err)
+			}
+		}
+	}
+	return nil
+}
+
+// notifyJournal notifies parents about new grades or absents of lesson, each student separately
+func notifyJournal(category models.NotificationCategory, lesson models.Lesson, values map[string]string) {
+	if len(values) < 1 {
+		return
+	}
+	studentIds := []string{}
+	for id := range values {
+		studentIds = append(studentIds, id)
+	}
+	students, err := store.Store().UsersFindByIds(context.Background(), studentIds)
+	if err != nil {
+		apputils.LoggerDesc("In notifyJournal").Error(err)
+		return
+	}
+	err = store.Store().UsersLoadRelationsParents(context.Background(), &students)
+	if err != nil {
+		apputils.LoggerDesc("In notifyJournal").Error(err)
+		return
+	}
+	// new grade and absence are off by default, skip when no parent enabled them
+	parentIds := []string{}
+	for _, s := range students {
+		for _, p := range s.Parents {
+			parentIds = append(parentIds, p.ID)
+		}
+	}
+	prefs, err := notifyPreferences(parentIds, models.RoleParent, category)
+	if err != nil {
+		apputils.LoggerDesc("In notifyJournal").Error(err)
+		return
+	}
+	enabled := false
+	for _, p := range prefs {
+		if p.Channel == string(models.ChannelPush) || p.Channel == string(models.ChannelSms) {
+			enabled = true
+		}
+	}
+	if !enabled {
+		return
+	}
+	subjectName := ""
+	if subject, err := store.Store().SubjectsFindById(context.Background(), lesson.SubjectId); err == nil && subject.Name != nil {
+		subjectName = *subject.Name
+	}
+	country := models.DefaultCountry()
+	for _, s := range students {
+		body := ""
+		if category == models.NotifyNewGrade {
+			body = country.SmsText(models.SmsGrade, "subject", subjectName, "value", values[s.ID])
+		} else {
+			body = country.SmsText(models.SmsAbsent, "subject", subjectName)
+		}
+		name := ""
+		if s.FirstName != nil {
+			name = *s.FirstName
+		}
+		err = notifyDispatch(s.Parents, Notify{
+			Category: category,
+			Role:     models.RoleParent,
+			Title:    country.SmsText(models.SmsChildTitle, "child", name),
+			Body:     body,
+			SmsText:  country.SmsText(models.SmsChild, "child", name, "text", body),
+			SmsType:  models.SmsTypeDaily,
+			PushType: PushTypeDiary,
+			PushId:   lesson.ID,
+		})
+		if err != nil {
+			apputils.LoggerDesc("In notifyJournal").Error(err)
+		}
+	}
+}
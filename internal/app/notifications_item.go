@@ -39,6 +39,20 @@ func UserNotificationsList(ses *utils.Session, f models.UserNotificationFilterRe
 		userNotificationsResponse = append(userNotificationsResponse, &s)
 	}
 
+	// set opened, it was shown to user
+	openedIds := []string{}
+	for _, v := range userNotifications {
+		if v.OpenedAt == nil {
+			openedIds = append(openedIds, v.ID)
+		}
+	}
+	if len(openedIds) > 0 {
+		err = store.Store().UserNotificationsUpdateOpened(ses.Context(), openedIds)
+		if err != nil {
+			return nil, 0, 0, err
+		}
+	}
+
 	// set read
 	if f.IsRead != nil && *f.IsRead {
 		ids := []string{}
@@ -49,6 +63,8 @@ func UserNotificationsList(ses *utils.Session, f models.UserNotificationFilterRe
 		if err != nil {
 			return nil, 0, 0, err
 		}
+		// other devices of user update their badge
+		go eventsEmitUnreadCount([]string{*f.UserId}, *f.Role)
 	}
 
 	totalUnread, err := store.Store().UserNotificationsSelectTotalUnread(ses.Context(), *f.UserId, *f.Role)
@@ -0,0 +1,204 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/mekdep/server/internal/api/utils"
+	"github.com/mekdep/server/internal/models"
+	"github.com/mekdep/server/internal/store"
+	apputils "github.com/mekdep/server/internal/utils"
+	"github.com/mekdep/server/internal/utils/push"
+)
+
+// transient failures are retried with backoff 2, 4, 8, 16 minutes, then delivery is failed
+const pushMaxAttempts = 5
+
+var pushQueue = newJobQueue("PushSend", nil, pushSend)
+
+type PushType string
+
+const PushTypeNotification PushType = "NotificationPage"
+const PushTypeChat PushType = "ChatsPage"
+const PushTypeDiary PushType = "DiaryPage"
+const PushTypePayment PushType = "PaymentPage"
+
+// sendNotificationPush records delivery for each device of users and queues them,
+// userNotificationIds links deliveries to user notifications by user id (can be nil)
+func sendNotificationPush(n models.Notifications, userIds []string, t PushType, id string, userNotificationIds map[string]string) error {
+	return sendNotificationPushAt(n, userIds, t, id, userNotificationIds, nil)
+}
+
+// sendNotificationPushAt is same as sendNotificationPush, when sendAt is set deliveries wait for retry job until that time
+func sendNotificationPushAt(n models.Notifications, userIds []string, t PushType, id string, userNotificationIds map[string]string, sendAt *time.Time) error {
+	ss, err := utils.SessionByUserIds(userIds)
+	if err != nil {
+		return nil
+	}
+	desc := ""
+	if n.Content != nil {
+		desc = *n.Content
+		if len(desc) > 255 {
+			desc = desc[:255]
+		}
+	}
+	data := map[string]string{
+		"page": string(t),
+		"id":   id,
+	}
+	items := []*models.PushDelivery{}
+	sent := map[string]bool{}
+	for _, s := range ss {
+		if s.DeviceToken == nil || *s.DeviceToken == "" || sent[*s.DeviceToken] {
+			continue
+		}
+		sent[*s.DeviceToken] = true
+		item := &models.PushDelivery{
+			UserId:      s.UserId,
+			DeviceToken: *s.DeviceToken,
+			Title:       *n.Title,
+			Body:        &desc,
+			Data:        &data,
+			Status:      models.PushStatusPending,
+		}
+		if sendAt != nil {
+			item.Status = models.PushStatusRetry
+			item.NextRetryAt = sendAt
+		}
+		if unId, ok := userNotificationIds[s.UserId]; ok {
+			item.UserNotificationId = &unId
+		}
+		items = append(items, item)
+	}
+	if len(items) < 1 {
+		return nil
+	}
+	err = store.Store().PushDeliveriesCreateBatch(context.Background(), items)
+	if err != nil {
+		return err
+	}
+	if sendAt != nil {
+		return nil
+	}
+	return pushEnqueue(items)
+}
+
+// pushEnqueue queues deliveries for worker, if queue is full they are left for retry job
+func pushEnqueue(items []*models.PushDelivery) error {
+	if pushQueue.Enqueue(items) {
+		return nil
+	}
+	now := time.Now()
+	for _, item := range items {
+		item.Status = models.PushStatusRetry
+		item.NextRetryAt = &now
+	}
+	return store.Store().PushDeliveriesUpdateBatch(context.Background(), items)
+}
+
+// PushWorker sends queued deliveries one batch after another
+func PushWorker() {
+	pushQueue.Work()
+}
+
+// PushRetryPending queues deliveries failed by transient errors whose retry time came,
+// and pending ones which were lost from queue by restart
+func PushRetryPending() error {
+	now := time.Now()
+	items := []*models.PushDelivery{}
+	for _, status := range []string{models.PushStatusRetry, models.PushStatusPending} {
+		f := models.PushDeliveryFilterRequest{Status: &status}
+		if status == models.PushStatusRetry {
+			f.RetryBefore = &now
+		} else {
+			f.UpdatedBefore = new(time.Time)
+			*f.UpdatedBefore = now.Add(-time.Minute * 30)
+		}
+		f.Limit = new(int)
+		*f.Limit = 500
+		l, _, err := store.Store().PushDeliveriesFindBy(context.Background(), f)
+		if err != nil {
+			return err
+		}
+		items = append(items, l...)
+	}
+	if len(items) < 1 {
+		return nil
+	}
+	// not picked again by next run while waiting in queue
+	for _, item := range items {
+		item.Status = models.PushStatusPending
+		item.NextRetryAt = nil
+	}
+	err := store.Store().PushDeliveriesUpdateBatch(context.Background(), items)
+	if err != nil {
+		return err
+	}
+	return pushEnqueue(items)
+}
+
+// pushSend sends deliveries grouped by same message, saves result of each and prunes dead tokens
+func pushSend(ctx context.Context, items []*models.PushDelivery) error {
+	groups := map[string][]*models.PushDelivery{}
+	for _, item := range items {
+		key, _ := json.Marshal([]interface{}{item.Title, item.Body, item.Data})
+		groups[string(key)] = append(groups[string(key)], item)
+	}
+	pruneTokens := []string{}
+	for _, group := range groups {
+		msg := push.Message{Title: group[0].Title}
+		if group[0].Body != nil {
+			msg.Body = *group[0].Body
+		}
+		if group[0].Data != nil {
+			msg.Data = *group[0].Data
+		}
+		tokens := []string{}
+		for _, item := range group {
+			tokens = append(tokens, item.DeviceToken)
+		}
+		results, err := push.Provider().Send(ctx, tokens, msg)
+		if err != nil {
+			results = []push.Result{}
+			for _, t := range tokens {
+				results = append(results, push.Result{Token: t, Error: err, Retry: true})
+			}
+		}
+		now := time.Now()
+		for k, item := range group {
+			item.Attempts++
+			if k >= len(results) {
+				continue
+			}
+			r := results[k]
+			if r.Error == nil {
+				item.Status = models.PushStatusSent
+				item.SentAt = &now
+				item.Error = nil
+				if r.MessageId != "" {
+					item.MessageId = &r.MessageId
+				}
+			} else {
+				errMsg := r.Error.Error()
+				item.Error = &errMsg
+				if r.Unregistered {
+					item.Status = models.PushStatusPruned
+					pruneTokens = append(pruneTokens, item.DeviceToken)
+				} else if r.Retry && item.Attempts < pushMaxAttempts {
+					item.Status = models.PushStatusRetry
+					retryAt := now.Add(time.Minute * time.Duration(1<<item.Attempts))
+					item.NextRetryAt = &retryAt
+				} else {
+					item.Status = models.PushStatusFailed
+				}
+			}
+			apputils.MetricsPushTotal.WithLabelValues(item.Status).Inc()
+		}
+	}
+	err := store.Store().PushDeliveriesUpdateBatch(ctx, items)
+	if err != nil {
+		return err
+	}
+	return utils.SessionClearDeviceTokens(pruneTokens)
+}
@@ -52,6 +52,10 @@ func (a App) ParentAnalyticsWeekly(ses *utils.Session, student *models.User, cla
 	if err != nil {
 		return nil, err
 	}
+	res.AssignmentsMissing, err = assignmentsMissing(ses, student, classroomId, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
 
 	return &res, nil
 }
@@ -399,7 +403,7 @@ func analyticsSubjectPercent(student *models.User, classroomId string, startDate
 
 		// find subjectPercents which belongs to v(current subject area)
 		for _, sp := range subjectPercents {
-			for _, s := range models.DefaultSubjects {
+			for _, s := range models.DefaultCountry().Subjects {
 				if len(s) > 2 && s[2] == string(v) || len(s) == 2 && v == models.SubjectAreaHumanity {
 					if sp.SubjectFullName == s[1] {
 						resItem.BySubject = append(resItem.BySubject, sp)
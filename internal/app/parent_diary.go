@@ -19,7 +19,7 @@ func UserCacheClear(ses *utils.Session, parentId string) {
 func UserChildrenGet(ses *utils.Session, user *models.User) ([]*models.User, error) {
 	cKey := "user_children_" + user.ID
 	var err error
-	if val, found := Ap().cache.Get(cKey); found && val != nil {
+	if val, found := Ap().cacheGet(cKey); found && val != nil {
 		user.Children = val.([]*models.User)
 	} else {
 		user.Children = nil
@@ -337,6 +337,11 @@ func (a App) StudentDiary(ses *utils.Session, student models.User, classroom mod
 	if err != nil {
 		return nil, err
 	}
+	submissions, err := assignmentSubmissionsByLesson(ses, student.ID, lessonIds)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
 
 	// form diary
 	res := models.DiaryResponse{}
@@ -351,7 +356,7 @@ func (a App) StudentDiary(ses *utils.Session, student models.User, classroom mod
 			resDay.Holiday = &h
 		} else if is, _ := isDateVacationBySchool(ses, date, classroom.SchoolId); is {
 			resDay.Holiday = new(string)
-			*resDay.Holiday = "Dynç alyş"
+			*resDay.Holiday = models.DefaultCountry().Vacation
 		} else {
 			resDay.Holiday = nil
 		}
@@ -404,6 +409,11 @@ func (a App) StudentDiary(ses *utils.Session, student models.User, classroom mod
 							resHour.Absent = &models.AbsentResponse{}
 							resHour.Absent.FromModel(absent)
 						}
+						if submission, ok := submissions[lesson.ID]; ok {
+							resHour.Submission = &models.AssignmentSubmissionResponse{}
+							resHour.Submission.FromModel(submission)
+						}
+						resHour.IsAssignmentMissing = models.AssignmentIsMissing(lesson, submissions[lesson.ID], now)
 					}
 					resDay.Hours = append(resDay.Hours, resHour)
 				}
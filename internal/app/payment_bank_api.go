@@ -181,6 +181,7 @@ func paymentSuccess(mm models.PaymentTransaction) error {
 	if err != nil {
 		return err
 	}
+	paymentMetric(&mm)
 	// upgrade
 	for _, v := range mm.Students {
 		err = UserTariffUpgrade(&apiUtils.Session{}, &mm, v, []*models.User{mm.Payer})
@@ -188,8 +189,21 @@ func paymentSuccess(mm models.PaymentTransaction) error {
 			return err
 		}
 	}
+	eventsEmitPayment(&mm)
 	return nil
 }
+
+// eventsEmitPayment lets payer app know result without polling transaction
+func eventsEmitPayment(mm *models.PaymentTransaction) {
+	eventsEmit([]string{mm.PayerId}, EventPayment, map[string]interface{}{
+		"id":          mm.ID,
+		"status":      mm.Status,
+		"tariff_type": mm.TariffType,
+		"amount":      mm.Amount,
+		"user_ids":    mm.UserIds,
+	})
+}
+
 func paymentFailed(mm models.PaymentTransaction) error {
 	log.Println("payment failed: " + *mm.OrderNumber)
 	mm.Status = models.PaymentStatusFailed
@@ -197,6 +211,8 @@ func paymentFailed(mm models.PaymentTransaction) error {
 	if err != nil {
 		return err
 	}
+	paymentMetric(&mm)
+	eventsEmitPayment(&mm)
 
 	return nil
 }
@@ -9,6 +9,7 @@ import (
 	apiUtils "github.com/mekdep/server/internal/api/utils"
 	"github.com/mekdep/server/internal/models"
 	"github.com/mekdep/server/internal/store"
+	apputils "github.com/mekdep/server/internal/utils"
 	"go.elastic.co/apm/v2"
 )
 
@@ -461,6 +462,7 @@ func paymentHandle(data models.PaymentTransaction) (*models.PaymentTransaction,
 		if err1 != nil {
 			return nil, err1
 		}
+		paymentMetric(m)
 		return nil, err
 	}
 
@@ -471,6 +473,11 @@ func paymentHandle(data models.PaymentTransaction) (*models.PaymentTransaction,
 	return m, nil
 }
 
+// paymentMetric counts payment once by its result (completed or failed)
+func paymentMetric(m *models.PaymentTransaction) {
+	apputils.MetricsPaymentsTotal.WithLabelValues(string(m.BankType), string(m.Status)).Inc()
+}
+
 func isPaymentHandleSpecialTariff(m *models.PaymentTransaction) bool {
 	return slices.Contains([]models.PaymentTariffType{models.PaymentTrial}, m.TariffType)
 }
@@ -505,6 +512,7 @@ func paymentHandleSpecialTariff(m *models.PaymentTransaction) (*models.PaymentTr
 		if err != nil {
 			return nil, err
 		}
+		paymentMetric(m)
 		// upgrade
 		for _, v := range m.Students {
 			err = UserTariffUpgrade(&apiUtils.Session{}, m, v, []*models.User{m.Payer})
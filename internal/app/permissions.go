@@ -33,6 +33,10 @@ var rolePermissions = map[models.Role][]Permission{
 		PermToolReportsData,
 		PermToolExport,
 		PermJournal,
+		PermAdminRoles,
+		PermAdminImpersonate,
+		PermAdminUserMerges,
+		PermAdminUserErasures,
 	},
 	models.RoleOrganization: []Permission{
 		PermAdminSchools,
@@ -58,6 +62,8 @@ var rolePermissions = map[models.Role][]Permission{
 		PermAdminContactItems,
 		PermAdminTopics,
 		PermAdminBooks,
+		PermAdminImpersonate,
+		PermAdminUserMerges,
 	},
 	models.RolePrincipal: []Permission{
 		PermAdminSchools,
@@ -163,7 +169,7 @@ var rolePermissionsRead = map[models.Role][]Permission{
 	models.RoleStudent: []Permission{},
 }
 
-type Permission interface{}
+type Permission string
 
 var (
 	PermAdminSchools         Permission = "admin_schools"
@@ -182,6 +188,10 @@ var (
 	PermAdminTeacherExcuses  Permission = "admin_teacher_excuses"
 	PermAdminPayments        Permission = "admin_payments"
 	PermAdminSchoolTransfers Permission = "admin_school_transfers"
+	PermAdminRoles           Permission = "admin_roles"
+	PermAdminImpersonate     Permission = "admin_impersonate"
+	PermAdminUserMerges      Permission = "admin_user_merges"
+	PermAdminUserErasures    Permission = "admin_user_erasures"
 
 	PermToolReports     Permission = "tool_reports"
 	PermToolReportForms Permission = "tool_report_forms"
@@ -206,6 +216,19 @@ var (
 	PermUser    Permission = "user"
 )
 
+// AllPermissions are permissions which can be granted to roles
+var AllPermissions = []Permission{
+	PermAdminSchools, PermAdminClassrooms, PermAdminUsers, PermAdminSubjects, PermAdminSubjectsExams,
+	PermAdminTimetables, PermAdminShifts, PermAdminPeriods, PermAdminTopics, PermAdminContactItems,
+	PermAdminBooks, PermAdminReports, PermAdminSettings, PermAdminTeacherExcuses, PermAdminPayments,
+	PermAdminSchoolTransfers, PermAdminRoles, PermAdminImpersonate, PermAdminUserMerges,
+	PermAdminUserErasures,
+	PermToolReports, PermToolReportForms, PermToolReportsData, PermToolLogs, PermToolImport,
+	PermToolReset, PermToolNotifier, PermToolExport,
+	PermTopics, PermJournal, PermDiary, PermChildren, PermAnalytics, PermPayments, PermUnlimited, PermPlus,
+	PermTeacher,
+}
+
 func (a App) userAction(ses *utils.Session, p Permission, f func(*models.User) error) error {
 	if err := ses.LoadSession(); err != nil {
 		if _, ok := err.(AppError); ok || err == pgx.ErrNoRows {
@@ -217,37 +240,71 @@ func (a App) userAction(ses *utils.Session, p Permission, f func(*models.User) e
 	if ses.GetUser() == nil {
 		return ErrUnauthorized
 	}
+	if ses.IsImpersonated() {
+		// also own actions of user (profile, password, sessions) are checked by read permission
+		err := impersonationCheckWrite(false, ses.IsReadRequest())
+		impersonationLog(ses, p, err != nil)
+		if err != nil {
+			return err
+		}
+	}
 	return f(ses.GetUser())
 }
 
 func (a App) UserActionCheckRead(ses *utils.Session, p Permission, f func(*models.User) error) error {
-	if err := a.CheckUserRead(ses.GetRole(), p); err != nil {
+	if err := a.CheckSessionRead(ses, p); err != nil {
 		return err
 	}
 	return a.userAction(ses, p, f)
 }
 func (a App) UserActionCheckWrite(ses *utils.Session, p Permission, f func(*models.User) error) error {
-	if err := a.CheckUserWrite(ses.GetRole(), p); err != nil {
+	// impersonated session only views what user sees
+	if ses.IsImpersonated() {
+		err := impersonationCheckWrite(true, ses.IsReadRequest())
+		impersonationLog(ses, p, err != nil)
+		if err != nil {
+			return err
+		}
+	}
+	if err := a.CheckSessionWrite(ses, p); err != nil {
 		return err
 	}
 	return a.userAction(ses, p, f)
 }
 
-func (a App) CheckUserRead(r *models.Role, p Permission) error {
-	if r == nil {
+// CheckSessionRead checks permission by grants of role (or custom role) in school of session
+func (a App) CheckSessionRead(ses *utils.Session, p Permission) error {
+	if ses.GetRole() == nil {
 		return ErrUnauthorized
 	}
-	if !RoleHasPermissionRead(*r, p) {
+	if p == PermUser {
+		return nil
+	}
+	read, _, err := a.sessionPermissions(ses)
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(read, p) {
 		return ErrForbidden
 	}
 	return nil
 }
 
-func (a App) CheckUserWrite(r *models.Role, p Permission) error {
-	if r == nil {
+func (a App) CheckSessionWrite(ses *utils.Session, p Permission) error {
+	if ses.GetRole() == nil {
 		return ErrUnauthorized
 	}
-	if !RoleHasPermissionWrite(*r, p) {
+	if config.Conf.AppIsReadonly != nil && *config.Conf.AppIsReadonly {
+		return ErrForbidden
+	}
+	if p == PermUser {
+		return nil
+	}
+	_, write, err := a.sessionPermissions(ses)
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(write, p) {
 		return ErrForbidden
 	}
 	return nil
@@ -275,35 +332,6 @@ func (a App) CheckPayment(student *models.User, p Permission) error {
 	return nil
 }
 
-func RoleHasPermissionWrite(r models.Role, p Permission) bool {
-	if config.Conf.AppIsReadonly != nil && *config.Conf.AppIsReadonly {
-		return false
-	}
-	if p == PermUser {
-		return true
-	}
-	ps := PermissionsWriteByRole(r)
-	if ps != nil {
-		if i := slices.Index(ps, p); i >= 0 {
-			return true
-		}
-	}
-	return false
-}
-
-func RoleHasPermissionRead(r models.Role, p Permission) bool {
-	if p == PermUser {
-		return true
-	}
-	ps := PermissionsReadByRole(r)
-	if ps != nil {
-		if i := slices.Index(ps, p); i >= 0 {
-			return true
-		}
-	}
-	return false
-}
-
 func PermissionsWriteByRole(r models.Role) []Permission {
 	if ps, ok := rolePermissions[r]; ok {
 		return ps
@@ -323,15 +351,11 @@ func (a App) GetPermissions(ses *utils.Session) ([]Permission, []Permission, err
 	if r == nil {
 		return nil, nil, ErrUnauthorized
 	}
-	psr := PermissionsReadByRole(*r)
-	if psr == nil {
-		return nil, nil, ErrForbidden
+	psr, psw, err := a.sessionPermissions(ses)
+	if err != nil {
+		return nil, nil, err
 	}
-	psw := PermissionsWriteByRole(*r)
-	if psw == nil {
-		return nil, nil, ErrForbidden
-	}
-	if config.Conf.AppIsReadonly != nil && *config.Conf.AppIsReadonly {
+	if config.Conf.AppIsReadonly != nil && *config.Conf.AppIsReadonly || ses.IsImpersonated() {
 		psw = []Permission{}
 	}
 	return psr, psw, nil
@@ -1,9 +1,15 @@
 package app
 
 import (
+	"context"
+	"slices"
+	"sort"
+	"time"
+
 	"github.com/mekdep/server/internal/api/utils"
 	"github.com/mekdep/server/internal/models"
 	"github.com/mekdep/server/internal/store"
+	apputils "github.com/mekdep/server/internal/utils"
 	"go.elastic.co/apm/v2"
 )
 
@@ -32,10 +38,92 @@ func ReportItemsCreate(ses *utils.Session, data models.ReportItemsRequest) (*mod
 	if userRole == models.RoleOrganization && ses.GetSchoolId() != modelItem.SchoolId {
 		return &models.ReportItemsResponse{}, ErrNotfound.SetKey("id")
 	}
+	if modelItem.Status == models.ReportItemStatusApproved {
+		return nil, ErrInvalid.SetKey("status").SetComment("approved form can not be changed")
+	}
+	report, err := store.Store().ReportsFindById(ses.Context(), *modelItem.ReportId)
+	if err != nil {
+		return nil, err
+	}
+	isSubmit := data.IsDraft == nil || !*data.IsDraft
 	// update report item
 	modelItem.UpdatedBy = &ses.GetUser().ID
 	modelItem.Values = data.Values
 	modelItem.IsEditedManually = data.IsEditedManually
+	if len(modelItem.Values) > 0 || isSubmit {
+		err = reportItemFill(ses, report, modelItem)
+		if err != nil {
+			return nil, err
+		}
+	}
+	err = reportItemValidate(report, modelItem.Values, isSubmit)
+	if err != nil {
+		return nil, err
+	}
+	if isSubmit {
+		now := time.Now()
+		modelItem.Status = models.ReportItemStatusSubmitted
+		modelItem.SubmittedAt = &now
+	} else if modelItem.Status != models.ReportItemStatusReturned {
+		// returned form stays in returned until corrected one is submitted
+		modelItem.Status = models.ReportItemStatusDraft
+	}
+	modelItem, err = store.Store().ReportItemsUpdate(ses.Context(), *modelItem)
+	if err != nil {
+		return nil, err
+	}
+	err = store.Store().ReportItemsLoadRelations(ses.Context(), &[]*models.ReportItems{modelItem})
+	if err != nil {
+		return nil, err
+	}
+	res.FromModel(modelItem)
+	return res, nil
+}
+
+// ReportItemsReview approves or returns submitted form: region reviews its schools,
+// principal reviews classrooms of school, admin reviews all
+func ReportItemsReview(ses *utils.Session, data models.ReportItemsReviewRequest) (*models.ReportItemsResponse, error) {
+	sp, ctx := apm.StartSpan(ses.Context(), "ReportItemsReview", "app")
+	ses.SetContext(ctx)
+	defer sp.End()
+	modelItem, err := store.Store().ReportItemsFindById(ses.Context(), *data.ID)
+	if err != nil {
+		return nil, ErrNotfound.SetKey("id")
+	}
+	err = store.Store().ReportItemsLoadRelations(ses.Context(), &[]*models.ReportItems{modelItem})
+	if err != nil {
+		return nil, err
+	}
+	// check permission
+	userRole := *ses.GetRole()
+	isAllowed := userRole == models.RoleAdmin
+	if userRole == models.RoleOrganization && modelItem.ClassroomId == nil && modelItem.School != nil &&
+		modelItem.School.ParentUid != nil && ses.GetSchoolId() != nil && *modelItem.School.ParentUid == *ses.GetSchoolId() {
+		isAllowed = true
+	}
+	if userRole == models.RolePrincipal && modelItem.ClassroomId != nil && modelItem.SchoolId != nil &&
+		ses.GetSchoolId() != nil && *modelItem.SchoolId == *ses.GetSchoolId() {
+		isAllowed = true
+	}
+	if !isAllowed {
+		return nil, ErrNotfound.SetKey("id")
+	}
+	// approved form can be returned for correction, only submitted one can be approved
+	if modelItem.Status != models.ReportItemStatusSubmitted &&
+		!(modelItem.Status == models.ReportItemStatusApproved && data.Status == models.ReportItemStatusReturned) {
+		return nil, ErrInvalid.SetKey("status").SetComment("form is not submitted")
+	}
+	if data.Status == models.ReportItemStatusReturned && (data.Comment == nil || *data.Comment == "") {
+		return nil, ErrRequired.SetKey("comment")
+	}
+	now := time.Now()
+	modelItem.Status = data.Status
+	modelItem.ReviewedBy = &ses.GetUser().ID
+	modelItem.ReviewedAt = &now
+	modelItem.ReviewComment = data.Comment
+	if modelItem.ReviewComment == nil {
+		modelItem.ReviewComment = new(string)
+	}
 	modelItem, err = store.Store().ReportItemsUpdate(ses.Context(), *modelItem)
 	if err != nil {
 		return nil, err
@@ -44,6 +132,134 @@ func ReportItemsCreate(ses *utils.Session, data models.ReportItemsRequest) (*mod
 	if err != nil {
 		return nil, err
 	}
+	go func(item models.ReportItems) {
+		err := reportItemReviewNotify(item)
+		if err != nil {
+			apputils.LoggerDesc("In reportItemReviewNotify " + item.ID).Error(err)
+		}
+	}(*modelItem)
+	res := &models.ReportItemsResponse{}
 	res.FromModel(modelItem)
 	return res, nil
 }
+
+// reportItemReviewNotify tells principal (or teacher of classroom) that form was approved or returned
+func reportItemReviewNotify(item models.ReportItems) error {
+	f := models.UserFilterRequest{
+		Roles:    &[]string{string(models.RolePrincipal)},
+		SchoolId: item.SchoolId,
+	}
+	role := models.RolePrincipal
+	if item.ClassroomId != nil {
+		role = models.RoleTeacher
+		f.Roles = &[]string{string(models.RoleTeacher)}
+		f.ClassroomId = item.ClassroomId
+	}
+	users, _, err := store.Store().UsersFindBy(context.Background(), f)
+	if err != nil {
+		return err
+	}
+	title := ""
+	if item.Report != nil {
+		title = item.Report.Title
+	}
+	body := "Hasabat kabul edildi"
+	if item.Status == models.ReportItemStatusReturned {
+		body = "Hasabat düzediş üçin yzyna gaýtaryldy"
+		if item.ReviewComment != nil && *item.ReviewComment != "" {
+			body += ": " + *item.ReviewComment
+		}
+	}
+	return notifyDispatch(users, Notify{
+		Category: models.NotifyReportStatus,
+		Role:     role,
+		Title:    title,
+		Body:     body,
+		PushType: PushTypeNotification,
+		PushId:   stringOrEmpty(item.ReportId),
+	})
+}
+
+// ReportsInbox returns forms which school (or classroom of teacher) has to fill or correct, nearest deadline first
+func ReportsInbox(ses *utils.Session) ([]models.ReportItemsResponse, error) {
+	sp, ctx := apm.StartSpan(ses.Context(), "ReportsInbox", "app")
+	ses.SetContext(ctx)
+	defer sp.End()
+	res := []models.ReportItemsResponse{}
+	if ses.GetSchoolId() == nil {
+		return res, nil
+	}
+	f := models.ReportItemsFilterRequest{
+		SchoolId: ses.GetSchoolId(),
+		Statuses: &models.ReportItemStatusesOutstanding,
+	}
+	f.OnlyClassroom = new(bool)
+	if *ses.GetRole() == models.RoleTeacher {
+		if ses.GetUser().TeacherClassroom == nil {
+			return res, nil
+		}
+		*f.OnlyClassroom = true
+		f.ClassroomId = &ses.GetUser().TeacherClassroom.ID
+	}
+	f.Limit = new(int)
+	*f.Limit = 1000
+	items, _, err := store.Store().ReportItemsFindBy(ses.Context(), f)
+	if err != nil {
+		return nil, err
+	}
+	err = store.Store().ReportItemsLoadRelations(ses.Context(), &items)
+	if err != nil {
+		return nil, err
+	}
+	// schools removed from report keep their items
+	items = slices.DeleteFunc(items, func(item *models.ReportItems) bool {
+		return item.Report == nil || !slices.Contains(item.Report.SchoolIds, *ses.GetSchoolId())
+	})
+	sort.SliceStable(items, func(i, j int) bool {
+		di, dj := items[i].Report.DeadlineAt, items[j].Report.DeadlineAt
+		if di == nil || dj == nil {
+			return di != nil
+		}
+		return di.Before(*dj)
+	})
+	for _, item := range items {
+		resItem := models.ReportItemsResponse{}
+		resItem.FromModel(item)
+		res = append(res, resItem)
+	}
+	return res, nil
+}
+
+// ReportsCompletionByRegion returns share of submitted school forms of reports by region
+func ReportsCompletionByRegion(ses *utils.Session, reportIds []string, regionIds *[]string) ([]*models.ReportRegionCompletion, error) {
+	sp, ctx := apm.StartSpan(ses.Context(), "ReportsCompletionByRegion", "app")
+	ses.SetContext(ctx)
+	defer sp.End()
+	if len(reportIds) < 1 {
+		return []*models.ReportRegionCompletion{}, nil
+	}
+	l, err := store.Store().ReportItemsCompletionByRegion(ses.Context(), reportIds, regionIds)
+	if err != nil {
+		return nil, err
+	}
+	ids := []string{}
+	for _, v := range l {
+		ids = append(ids, v.RegionId)
+	}
+	regions, err := store.Store().SchoolsFindByIds(ses.Context(), ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range l {
+		for _, r := range regions {
+			if r.ID == v.RegionId {
+				v.Region = &models.SchoolResponse{}
+				v.Region.FromModel(r)
+			}
+		}
+	}
+	sort.SliceStable(l, func(i, j int) bool {
+		return l[i].Percent < l[j].Percent
+	})
+	return l, nil
+}
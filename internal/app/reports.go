@@ -90,10 +90,7 @@ func reportItemLoadAndIsFilled(ses *utils.Session, report *models.Reports) (bool
 
 		// Check if ReportItem was found and if it has Values
 		if report.ReportItem != nil {
-			if report.ReportItem.Values == nil || len(report.ReportItem.Values) == 0 {
-				return false, nil
-			}
-			return true, nil
+			return report.ReportItem.IsFilled(), nil
 		}
 	}
 	return false, nil
@@ -143,16 +140,23 @@ func ReportsDetail(ses *utils.Session, id string) (*models.ReportsResponse, erro
 	}
 
 	// fetch report item
-	_, err = reportItemLoadAndIsFilled(ses, m)
+	isFilled, err := reportItemLoadAndIsFilled(ses, m)
 	if err != nil {
 		return nil, err
 	}
+	// unfilled form is offered with values from journal
+	if !isFilled && m.ReportItem != nil {
+		err = reportItemFill(ses, m, m.ReportItem)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	// counts and order by
 	itemsCount := len(reportItems)
 	itemsFilledCount := 0
 	for _, reportItem := range reportItems {
-		if reportItem.Values != nil && len(reportItem.Values) > 0 {
+		if reportItem.IsFilled() {
 			itemsFilledCount++
 		}
 	}
@@ -183,7 +187,13 @@ func ReportsCreate(ses *utils.Session, data models.ReportsRequest) (*models.Repo
 	if model.ValueTypes == nil || len(model.ValueTypes) < 1 {
 		return nil, ErrRequired.SetKey("value_types")
 	}
-	var err error
+	err := reportValueTypesValidate(model.ValueTypes)
+	if err != nil {
+		return nil, err
+	}
+	if model.StartDate != nil && model.EndDate != nil && model.StartDate.After(*model.EndDate) {
+		return nil, ErrInvalid.SetKey("start_date")
+	}
 	// if selected "All"
 	if len(model.SchoolIds) == 1 && model.SchoolIds[0] == "" {
 		allSchoolIds := ses.GetSchoolIds()
@@ -280,7 +290,14 @@ func ReportsUpdate(ses *utils.Session, data models.ReportsRequest) (*models.Repo
 		return nil, err
 	}
 	data.IsClassroomsIncluded = existingReport.IsClassroomsIncluded
-	data.ValueTypes = existingReport.ValueTypes
+	data.ValueTypes = reportValueTypesMergeRules(existingReport.ValueTypes, data.ValueTypes)
+	err = reportValueTypesValidate(data.ValueTypes)
+	if err != nil {
+		return nil, err
+	}
+	if data.StartDate != nil && data.EndDate != nil && data.StartDate.After(*data.EndDate) {
+		return nil, ErrInvalid.SetKey("start_date")
+	}
 	for _, newSchoolId := range data.SchoolIds {
 		found := false
 		for _, existingsSchoolId := range existingReport.SchoolIds {
@@ -331,6 +348,10 @@ func ReportsUpdate(ses *utils.Session, data models.ReportsRequest) (*models.Repo
 	existingReport.Description = data.Description
 	existingReport.Title = data.Title
 	existingReport.IsCenterRating = data.IsCenterRating
+	existingReport.DeadlineAt = data.DeadlineAt
+	existingReport.StartDate = data.StartDate
+	existingReport.EndDate = data.EndDate
+	existingReport.ValueTypes = data.ValueTypes
 	existingReport, err = store.Store().ReportsUpdate(ses.Context(), existingReport)
 	if err != nil {
 		return nil, err
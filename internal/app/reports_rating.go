@@ -1,11 +1,8 @@
 package app
 
 import (
-	"encoding/json"
-	"errors"
 	"log"
 	"sort"
-	"strconv"
 
 	"github.com/mekdep/server/internal/api/utils"
 	"github.com/mekdep/server/internal/models"
@@ -78,72 +75,37 @@ func ReportsRatingCenter(ses *utils.Session, reportId string) (*models.ReportRat
 	return &rating, err
 }
 
+// calculateRatingValue sums points of scoring rules of report fields,
+// reports without own rules are scored by default center rating
 func calculateRatingValue(report models.Reports, item models.ReportItems) (int, error) {
-	sumPoint := 0
-	for _, v := range models.DefaultRatingReports {
-		num, err := getReportItemNumber(report, item, string(v.Key))
-		if err != nil {
+	numbers := reportItemNumbers(report, item)
+	sumPoint := 0.0
+	isScored := false
+	for _, v := range report.ValueTypes {
+		if v.Key == nil || v.Scoring == nil {
 			continue
 		}
-		if v.CalcPoint != nil {
-			sumPoint += v.CalcPoint(num)
-		}
+		isScored = true
+		sumPoint += v.Scoring.Points(numbers[*v.Key])
 	}
-
-	pointStudents, _ := getReportItemNumber(report, item, string(models.ReportKeySeasonStudents))
-	pointStudentsCompleted, _ := getReportItemNumber(report, item, string(models.ReportKeySeasonStudentsCompleted))
-	if pointStudentsCompleted > 0 && pointStudents > 0 {
-		sumPoint += int(float64(pointStudentsCompleted) / float64(pointStudents) * 10)
+	if isScored {
+		return int(sumPoint), nil
 	}
-
-	return sumPoint, nil
-}
-
-func getReportItemValue(report models.Reports, item models.ReportItems, key string) (string, models.ReportValueType, error) {
-	valueIndex := -1
-	valueType := models.ReportValueType("")
-	value := ""
-
-	for k, v := range report.ValueTypes {
-		if v.Key != nil && *v.Key == key {
-			valueIndex = k
-			valueType = v.Type
-			break
+	for _, v := range models.DefaultRatingReports {
+		if v.Scoring == nil {
+			continue
+		}
+		num, ok := numbers[string(v.Key)]
+		if v.Formula != "" {
+			var err error
+			num, err = models.ReportFormulaEval(v.Formula, numbers)
+			ok = err == nil
+		}
+		if ok {
+			sumPoint += v.Scoring.Points(num)
 		}
 	}
-	if valueIndex < 0 {
-		return value, valueType, errors.New("key not found in values: " + key)
-	}
-	if len(item.Values) <= valueIndex {
-		return value, valueType, errors.New("value types or keys not found: " + key + "  " + strconv.Itoa(valueIndex))
-	}
-	valuePtr := item.Values[valueIndex]
-	if valuePtr != nil {
-		value = *valuePtr
-	}
-	return value, valueType, nil
-}
-
-func getReportItemNumber(report models.Reports, item models.ReportItems, key string) (int, error) {
-	value, valueType, err := getReportItemValue(report, item, key)
-	if err != nil {
-		return 0, err
-	}
-	// empty
-	if value == "" {
-		return 0, nil
-	}
-	// type is number or list
-	if valueType == models.ReportValueTypeNumber {
-		return strconv.Atoi(value)
-	}
-	if valueType == models.ReportValueTypeList {
-		l := []string{}
-		json.Unmarshal([]byte(value), l)
-		return len(l), nil
-	}
-	// none type match
-	return 0, nil
+	return int(sumPoint), nil
 }
 
 func getReportItemList(item models.ReportItems, key string) (int, error) {
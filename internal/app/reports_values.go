@@ -0,0 +1,263 @@
+package app
+
+import (
+	"encoding/json"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mekdep/server/internal/api/utils"
+	"github.com/mekdep/server/internal/models"
+	"github.com/mekdep/server/internal/store"
+)
+
+// reportPeriod returns dates of report, by default from start of school year till today of school (loc)
+func reportPeriod(report *models.Reports, loc *time.Location) (time.Time, time.Time) {
+	now := time.Now().In(loc)
+	endDate := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if report.EndDate != nil {
+		endDate = *report.EndDate
+	}
+	startDate := endDate.AddDate(-1, 0, 0)
+	if report.StartDate != nil {
+		startDate = *report.StartDate
+	} else if periods := appSettingsPeriods(); len(periods.Value) > 0 && len(periods.Value[0]) > 0 {
+		if t, err := time.Parse(time.DateOnly, periods.Value[0][0]); err == nil && t.Before(endDate) {
+			startDate = t
+		}
+	}
+	return startDate, endDate
+}
+
+func reportValueIsEmpty(v *string) bool {
+	return v == nil || strings.TrimSpace(*v) == ""
+}
+
+func reportValueFormat(v float64) string {
+	return strconv.FormatFloat(math.Round(v*100)/100, 'f', -1, 64)
+}
+
+// reportValueNumber parses value of number field, list is counted by items
+func reportValueNumber(vt models.ValueTypes, v *string) (float64, error) {
+	if reportValueIsEmpty(v) {
+		return 0, nil
+	}
+	if vt.Type == models.ReportValueTypeList {
+		l := []interface{}{}
+		err := json.Unmarshal([]byte(*v), &l)
+		return float64(len(l)), err
+	}
+	return strconv.ParseFloat(strings.TrimSpace(*v), 64)
+}
+
+// reportItemNumbers returns number values of item by keys, formula fields are calculated
+func reportItemNumbers(report models.Reports, item models.ReportItems) map[string]float64 {
+	numbers := map[string]float64{}
+	for k, vt := range report.ValueTypes {
+		if vt.Key == nil || vt.Formula != nil {
+			continue
+		}
+		if vt.Type != models.ReportValueTypeNumber && vt.Type != models.ReportValueTypeList {
+			continue
+		}
+		var v *string
+		if k < len(item.Values) {
+			v = item.Values[k]
+		}
+		num, err := reportValueNumber(vt, v)
+		if err != nil {
+			continue
+		}
+		numbers[*vt.Key] = num
+	}
+	for _, vt := range report.ValueTypes {
+		if vt.Key == nil || vt.Formula == nil {
+			continue
+		}
+		num, err := models.ReportFormulaEval(*vt.Formula, numbers)
+		if err != nil {
+			continue
+		}
+		numbers[*vt.Key] = num
+	}
+	return numbers
+}
+
+func reportHasCalculated(report *models.Reports) bool {
+	for _, vt := range report.ValueTypes {
+		if vt.Source != nil || vt.Formula != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// reportItemFill sets empty values of source fields from journal and calculates formula fields,
+// item is marked as edited manually when source value differs from journal
+func reportItemFill(ses *utils.Session, report *models.Reports, item *models.ReportItems) error {
+	if !reportHasCalculated(report) {
+		return nil
+	}
+	for len(item.Values) < len(report.ValueTypes) {
+		item.Values = append(item.Values, nil)
+	}
+	// region items are summary of organizations, journal is counted only for schools
+	if item.SchoolId != nil && slices.Contains(report.SchoolIds, *item.SchoolId) {
+		var sources *models.ReportSourceValues
+		isEdited := false
+		for k, vt := range report.ValueTypes {
+			if vt.Source == nil {
+				continue
+			}
+			if sources == nil {
+				loc := ses.GetLocation()
+				if item.School != nil {
+					loc = item.School.Location()
+				}
+				startDate, endDate := reportPeriod(report, loc)
+				res, err := store.Store().ReportSourceValues(ses.Context(), *item.SchoolId, item.ClassroomId, startDate, endDate)
+				if err != nil {
+					return err
+				}
+				sources = &res
+			}
+			value := reportValueFormat(sources.Get(*vt.Source))
+			if reportValueIsEmpty(item.Values[k]) {
+				item.Values[k] = &value
+			} else if num, err := reportValueNumber(vt, item.Values[k]); err != nil || reportValueFormat(num) != value {
+				isEdited = true
+			}
+		}
+		if sources != nil {
+			item.IsEditedManually = &isEdited
+		}
+	}
+	numbers := reportItemNumbers(*report, *item)
+	for k, vt := range report.ValueTypes {
+		if vt.Key == nil || vt.Formula == nil {
+			continue
+		}
+		value := reportValueFormat(numbers[*vt.Key])
+		item.Values[k] = &value
+	}
+	return nil
+}
+
+// reportItemValidate checks filled values by rules of report fields, calculated fields are skipped,
+// required fields are checked only when form is submitted
+func reportItemValidate(report *models.Reports, values []*string, isSubmit bool) error {
+	if len(values) > len(report.ValueTypes) {
+		return ErrInvalid.SetKey("values")
+	}
+	if isSubmit && !slices.ContainsFunc(values, func(v *string) bool { return !reportValueIsEmpty(v) }) {
+		return ErrRequired.SetKey("values")
+	}
+	for k, vt := range report.ValueTypes {
+		if vt.Formula != nil {
+			continue
+		}
+		key := "values." + strconv.Itoa(k)
+		var v *string
+		if k < len(values) {
+			v = values[k]
+		}
+		if reportValueIsEmpty(v) {
+			if vt.Required && isSubmit {
+				return ErrRequired.SetKey(key).SetComment(vt.Header)
+			}
+			continue
+		}
+		if vt.Type == models.ReportValueTypeSelect && len(vt.TypeOptions) > 0 && !slices.Contains(vt.TypeOptions, *v) {
+			return ErrInvalid.SetKey(key).SetComment(vt.Header)
+		}
+		if vt.Type != models.ReportValueTypeNumber && vt.Type != models.ReportValueTypeList {
+			continue
+		}
+		num, err := reportValueNumber(vt, v)
+		if err != nil {
+			return ErrInvalid.SetKey(key).SetComment(vt.Header)
+		}
+		if vt.Min != nil && num < *vt.Min {
+			return ErrInvalid.SetKey(key).SetComment(vt.Header + ": min " + reportValueFormat(*vt.Min))
+		}
+		if vt.Max != nil && num > *vt.Max {
+			return ErrInvalid.SetKey(key).SetComment(vt.Header + ": max " + reportValueFormat(*vt.Max))
+		}
+	}
+	return nil
+}
+
+// reportValueTypesValidate checks rules of fields defined by admin,
+// formula can use keys of number fields defined before it
+func reportValueTypesValidate(valueTypes []models.ValueTypes) error {
+	keys := map[string]bool{}
+	for k, vt := range valueTypes {
+		key := "value_types." + strconv.Itoa(k)
+		isNumber := vt.Type == models.ReportValueTypeNumber
+		if vt.Source != nil && (!isNumber || !slices.Contains(models.ReportValueSources, *vt.Source)) {
+			return ErrInvalid.SetKey(key + ".source")
+		}
+		if vt.Formula != nil {
+			if !isNumber || vt.Source != nil || vt.Key == nil {
+				return ErrInvalid.SetKey(key + ".formula")
+			}
+			formulaKeys, err := models.ReportFormulaKeys(*vt.Formula)
+			if err != nil {
+				return ErrInvalid.SetKey(key + ".formula").SetComment(err.Error())
+			}
+			for _, fk := range formulaKeys {
+				if !keys[fk] {
+					return ErrInvalid.SetKey(key + ".formula").SetComment("unknown key: " + fk)
+				}
+			}
+		}
+		if vt.Min != nil && vt.Max != nil && *vt.Min > *vt.Max {
+			return ErrInvalid.SetKey(key + ".min")
+		}
+		if vt.Scoring != nil {
+			if !isNumber && vt.Type != models.ReportValueTypeList {
+				return ErrInvalid.SetKey(key + ".scoring")
+			}
+			if vt.Scoring.Per == nil && len(vt.Scoring.Ranges) < 1 {
+				return ErrRequired.SetKey(key + ".scoring")
+			}
+			for rk, r := range vt.Scoring.Ranges {
+				last := rk == len(vt.Scoring.Ranges)-1
+				if r.Below == nil && !last || rk > 0 && r.Below != nil && *r.Below <= *vt.Scoring.Ranges[rk-1].Below {
+					return ErrInvalid.SetKey(key + ".scoring.ranges." + strconv.Itoa(rk))
+				}
+			}
+		}
+		if vt.Key != nil {
+			if keys[*vt.Key] {
+				return ErrInvalid.SetKey(key + ".key").SetComment("duplicate key: " + *vt.Key)
+			}
+			if isNumber || vt.Type == models.ReportValueTypeList {
+				keys[*vt.Key] = true
+			}
+		}
+	}
+	return nil
+}
+
+// reportValueTypesMergeRules takes rules and scoring of fields from admin,
+// fields themselves are not changed because filled values are stored by index
+func reportValueTypesMergeRules(existing []models.ValueTypes, data []models.ValueTypes) []models.ValueTypes {
+	if len(existing) != len(data) {
+		return existing
+	}
+	res := slices.Clone(existing)
+	for k := range res {
+		if res[k].Type != data[k].Type || (res[k].Key == nil) != (data[k].Key == nil) ||
+			res[k].Key != nil && *res[k].Key != *data[k].Key {
+			return existing
+		}
+		res[k].Required = data[k].Required
+		res[k].Min = data[k].Min
+		res[k].Max = data[k].Max
+		res[k].Scoring = data[k].Scoring
+	}
+	return res
+}
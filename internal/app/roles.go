@@ -0,0 +1,337 @@
+package app
+
+import (
+	"context"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mekdep/server/config"
+	"github.com/mekdep/server/internal/api/utils"
+	"github.com/mekdep/server/internal/models"
+	"github.com/mekdep/server/internal/store"
+	apputils "github.com/mekdep/server/internal/utils"
+	"go.elastic.co/apm/v2"
+)
+
+const rolePermissionsCacheKey = "role_permissions_"
+const rolePermissionsCacheTime = 5 * time.Minute
+
+var roleCodeRegexp = regexp.MustCompile(`^[a-z][a-z0-9_]{2,49}$`)
+
+type rolePermissionSet struct {
+	read  []Permission
+	write []Permission
+}
+
+// sessionPermissions returns grants of session role, custom role of user in school is used instead when it is set
+func (a App) sessionPermissions(ses *utils.Session) ([]Permission, []Permission, error) {
+	role := ses.GetRole()
+	if role == nil {
+		return []Permission{}, []Permission{}, nil
+	}
+	code := string(*role)
+	if c := ses.GetCustomRole(); c != nil {
+		code = *c
+	}
+	return a.rolePermissionsResolve(code, ses.GetClaimSchoolId())
+}
+
+// rolePermissionsResolve returns read and write grants of role in school: global grants
+// with overrides of school. Compiled defaults are used if grants are not in db yet,
+// failed query is returned: compiled defaults would leave custom role without grants
+func (a App) rolePermissionsResolve(code string, schoolId string) ([]Permission, []Permission, error) {
+	key := rolePermissionsCacheKey + code + "_" + schoolId
+	if val, found := a.cacheGet(key); found && val != nil {
+		set := val.(rolePermissionSet)
+		return set.read, set.write, nil
+	}
+	var sId *string
+	if schoolId != "" {
+		sId = &schoolId
+	}
+	l, err := store.Store().RolePermissionsFindBy(context.Background(), []string{code}, sId)
+	if err != nil {
+		apputils.LoggerDesc("In rolePermissionsResolve " + code).Error(err)
+		return nil, nil, err
+	}
+	if len(l) < 1 && slices.Contains(models.DefaultRoles, models.Role(code)) {
+		return PermissionsReadByRole(models.Role(code)), PermissionsWriteByRole(models.Role(code)), nil
+	}
+	access := map[string]string{}
+	for _, v := range l {
+		if v.SchoolId == nil {
+			access[v.Permission] = v.Access
+		}
+	}
+	for _, v := range l {
+		if v.SchoolId != nil {
+			access[v.Permission] = v.Access
+		}
+	}
+	set := rolePermissionSet{read: []Permission{}, write: []Permission{}}
+	for _, p := range AllPermissions {
+		switch models.PermissionAccess(access[string(p)]) {
+		case models.PermissionAccessWrite:
+			set.write = append(set.write, p)
+			set.read = append(set.read, p)
+		case models.PermissionAccessRead:
+			set.read = append(set.read, p)
+		}
+	}
+	a.cache.Set(key, set, rolePermissionsCacheTime)
+	return set.read, set.write, nil
+}
+
+func (a App) rolePermissionsCacheClear() {
+	for k := range a.cache.Items() {
+		if strings.HasPrefix(k, rolePermissionsCacheKey) {
+			a.cache.Delete(k)
+		}
+	}
+}
+
+// RolesSeed stores system roles with compiled grants (rolePermissions, rolePermissionsRead),
+// grants changed by admin are kept
+func RolesSeed() error {
+	if config.Conf.AppIsReadonly != nil && *config.Conf.AppIsReadonly {
+		return nil
+	}
+	roles := []*models.RoleDefinition{}
+	for _, r := range models.DefaultRoles {
+		m := &models.RoleDefinition{
+			Code:        string(r),
+			Name:        string(r),
+			BaseRole:    r,
+			IsSystem:    true,
+			Permissions: []*models.RolePermission{},
+		}
+		write := PermissionsWriteByRole(r)
+		for _, p := range PermissionsReadByRole(r) {
+			access := models.PermissionAccessRead
+			if slices.Contains(write, p) {
+				access = models.PermissionAccessWrite
+			}
+			if slices.ContainsFunc(m.Permissions, func(v *models.RolePermission) bool { return v.Permission == string(p) }) {
+				continue
+			}
+			m.Permissions = append(m.Permissions, &models.RolePermission{
+				RoleCode:   m.Code,
+				Permission: string(p),
+				Access:     string(access),
+			})
+		}
+		roles = append(roles, m)
+	}
+	return store.Store().RolesSeed(context.Background(), roles)
+}
+
+func rolePermissionsFromRequest(code string, l []models.RolePermissionRequest) ([]*models.RolePermission, error) {
+	res := []*models.RolePermission{}
+	for _, v := range l {
+		if !slices.Contains(AllPermissions, Permission(v.Permission)) {
+			return nil, ErrInvalid.SetKey("permission").SetComment(v.Permission)
+		}
+		if !slices.Contains(models.PermissionAccesses, models.PermissionAccess(v.Access)) {
+			return nil, ErrInvalid.SetKey("access").SetComment(v.Access)
+		}
+		if slices.ContainsFunc(res, func(p *models.RolePermission) bool { return p.Permission == v.Permission }) {
+			return nil, ErrUnique.SetKey("permission").SetComment(v.Permission)
+		}
+		res = append(res, &models.RolePermission{
+			RoleCode:   code,
+			Permission: v.Permission,
+			Access:     v.Access,
+		})
+	}
+	return res, nil
+}
+
+// RolesList returns roles with global grants, with overrides of school when it is given
+func RolesList(ses *utils.Session, schoolId *string) ([]models.RoleResponse, error) {
+	sp, ctx := apm.StartSpan(ses.Context(), "RolesList", "app")
+	ses.SetContext(ctx)
+	defer sp.End()
+	roles, err := store.Store().RolesFindBy(ses.Context(), nil)
+	if err != nil {
+		return nil, err
+	}
+	codes := []string{}
+	for _, v := range roles {
+		codes = append(codes, v.Code)
+	}
+	grants, err := store.Store().RolePermissionsFindBy(ses.Context(), codes, schoolId)
+	if err != nil {
+		return nil, err
+	}
+	res := []models.RoleResponse{}
+	for _, v := range roles {
+		v.Permissions = []*models.RolePermission{}
+		for _, g := range grants {
+			if g.RoleCode == v.Code {
+				v.Permissions = append(v.Permissions, g)
+			}
+		}
+		item := models.RoleResponse{}
+		item.FromModel(v)
+		res = append(res, item)
+	}
+	return res, nil
+}
+
+func RolesCreate(ses *utils.Session, data models.RoleRequest) (*models.RoleResponse, error) {
+	sp, ctx := apm.StartSpan(ses.Context(), "RolesCreate", "app")
+	ses.SetContext(ctx)
+	defer sp.End()
+	if data.Code == nil || !roleCodeRegexp.MatchString(*data.Code) {
+		return nil, ErrInvalid.SetKey("code")
+	}
+	if _, err := store.Store().RolesFindByCode(ses.Context(), *data.Code); err == nil {
+		return nil, ErrUnique.SetKey("code")
+	}
+	if data.BaseRole == nil || !slices.Contains(models.DefaultRoles, models.Role(*data.BaseRole)) {
+		return nil, ErrInvalid.SetKey("base_role")
+	}
+	grants, err := rolePermissionsFromRequest(*data.Code, data.Permissions)
+	if err != nil {
+		return nil, err
+	}
+	m, err := store.Store().RoleSave(ses.Context(), &models.RoleDefinition{
+		Code:        *data.Code,
+		Name:        *data.Name,
+		BaseRole:    models.Role(*data.BaseRole),
+		Permissions: grants,
+	})
+	if err != nil {
+		return nil, err
+	}
+	Ap().rolePermissionsCacheClear()
+	return rolesDetail(ses, m.Code)
+}
+
+// RolesUpdate changes name and global grants of role, base role of system role is fixed
+func RolesUpdate(ses *utils.Session, data models.RoleRequest) (*models.RoleResponse, error) {
+	sp, ctx := apm.StartSpan(ses.Context(), "RolesUpdate", "app")
+	ses.SetContext(ctx)
+	defer sp.End()
+	m, err := store.Store().RolesFindByCode(ses.Context(), *data.Code)
+	if err != nil {
+		return nil, ErrNotfound.SetKey("code")
+	}
+	if data.BaseRole != nil && *data.BaseRole != string(m.BaseRole) {
+		if m.IsSystem || !slices.Contains(models.DefaultRoles, models.Role(*data.BaseRole)) {
+			return nil, ErrInvalid.SetKey("base_role")
+		}
+		m.BaseRole = models.Role(*data.BaseRole)
+	}
+	m.Name = *data.Name
+	m.Permissions, err = rolePermissionsFromRequest(m.Code, data.Permissions)
+	if err != nil {
+		return nil, err
+	}
+	m, err = store.Store().RoleSave(ses.Context(), m)
+	if err != nil {
+		return nil, err
+	}
+	Ap().rolePermissionsCacheClear()
+	return rolesDetail(ses, m.Code)
+}
+
+func RolesDelete(ses *utils.Session, code string) error {
+	sp, ctx := apm.StartSpan(ses.Context(), "RolesDelete", "app")
+	ses.SetContext(ctx)
+	defer sp.End()
+	m, err := store.Store().RolesFindByCode(ses.Context(), code)
+	if err != nil {
+		return ErrNotfound.SetKey("code")
+	}
+	if m.IsSystem {
+		return ErrForbidden.SetKey("code").SetComment("system role")
+	}
+	err = store.Store().RoleDelete(ses.Context(), code)
+	if err != nil {
+		return err
+	}
+	Ap().rolePermissionsCacheClear()
+	return nil
+}
+
+// RolesOverride replaces grants of role in school, empty list resets school to global grants
+func RolesOverride(ses *utils.Session, data models.RoleOverridesRequest) (*models.RoleResponse, error) {
+	sp, ctx := apm.StartSpan(ses.Context(), "RolesOverride", "app")
+	ses.SetContext(ctx)
+	defer sp.End()
+	m, err := store.Store().RolesFindByCode(ses.Context(), *data.Code)
+	if err != nil {
+		return nil, ErrNotfound.SetKey("code")
+	}
+	if _, err := store.Store().SchoolsFindById(ses.Context(), *data.SchoolId); err != nil {
+		return nil, ErrNotfound.SetKey("school_id")
+	}
+	grants, err := rolePermissionsFromRequest(m.Code, data.Permissions)
+	if err != nil {
+		return nil, err
+	}
+	err = store.Store().RolePermissionsOverride(ses.Context(), m.Code, *data.SchoolId, grants)
+	if err != nil {
+		return nil, err
+	}
+	Ap().rolePermissionsCacheClear()
+	l, err := RolesList(ses, data.SchoolId)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range l {
+		if v.Code == m.Code {
+			return &v, nil
+		}
+	}
+	return nil, ErrNotfound.SetKey("code")
+}
+
+// RolesAssign sets custom role to user in school, user must have base role of custom role there
+func RolesAssign(ses *utils.Session, data models.RoleAssignRequest) error {
+	sp, ctx := apm.StartSpan(ses.Context(), "RolesAssign", "app")
+	ses.SetContext(ctx)
+	defer sp.End()
+	m, err := store.Store().RolesFindByCode(ses.Context(), *data.Code)
+	if err != nil || m.IsSystem {
+		return ErrNotfound.SetKey("code")
+	}
+	var customRoleCode *string
+	if !data.IsRemove {
+		customRoleCode = &m.Code
+	}
+	count, err := store.Store().UserSchoolsCustomRoleSet(ses.Context(), *data.UserId, *data.SchoolId, m.BaseRole, customRoleCode)
+	if err != nil {
+		return err
+	}
+	if count < 1 {
+		return ErrNotfound.SetKey("user_id").SetComment("user has no role " + string(m.BaseRole) + " in school")
+	}
+	// logged in user gets new grants without login
+	user, err := store.Store().UsersFindById(ses.Context(), *data.UserId)
+	if err != nil {
+		return err
+	}
+	err = store.Store().UsersLoadRelations(ses.Context(), &[]*models.User{user}, false)
+	if err != nil {
+		return err
+	}
+	utils.SessionUsersUpdate(*user)
+	return nil
+}
+
+func rolesDetail(ses *utils.Session, code string) (*models.RoleResponse, error) {
+	l, err := RolesList(ses, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range l {
+		if v.Code == code {
+			return &v, nil
+		}
+	}
+	return nil, ErrNotfound.SetKey("code")
+}
@@ -3,6 +3,7 @@ package app
 import (
 	"context"
 	"errors"
+	"slices"
 	"strings"
 
 	"github.com/mekdep/server/internal/api/utils"
@@ -55,9 +56,12 @@ func SchoolTransfersCreate(ses *utils.Session, data *models.SchoolTransferCreate
 	sp, ctx := apm.StartSpan(ses.Context(), "SchoolTransfersCreate", "app")
 	ses.SetContext(ctx)
 	defer sp.End()
+	err := schoolTransferCheck(ses, data)
+	if err != nil {
+		return nil, err
+	}
 	m := &models.SchoolTransfer{}
 	data.ToModel(m)
-	var err error
 	m, err = store.Store().SchoolTransfersInsert(context.Background(), m)
 	if err != nil {
 		return nil, err
@@ -74,6 +78,41 @@ func SchoolTransfersCreate(ses *utils.Session, data *models.SchoolTransferCreate
 	return res, nil
 }
 
+// schoolTransferCheck checks student is in source classroom, has no other waiting transfer
+// and has documents: birth certificate in profile or files attached to transfer
+func schoolTransferCheck(ses *utils.Session, data *models.SchoolTransferCreateDto) error {
+	if *data.TargetSchoolId == *data.SourceSchoolId {
+		return ErrInvalid.SetKey("target_school_id")
+	}
+	classroom, err := store.Store().ClassroomsFindById(ses.Context(), *data.SourceClassroomId)
+	if err != nil || classroom.SchoolId != *data.SourceSchoolId {
+		return ErrNotfound.SetKey("source_classroom_id")
+	}
+	_, err = store.Store().UserClassroomGet(ses.Context(), *data.StudentId, *data.SourceClassroomId)
+	if err != nil {
+		return ErrNotfound.SetKey("student_id").SetComment("student is not in source classroom")
+	}
+	student, err := store.Store().UsersFindById(ses.Context(), *data.StudentId)
+	if err != nil {
+		return ErrNotfound.SetKey("student_id")
+	}
+	if (student.BirthCertNumber == nil || *student.BirthCertNumber == "") && len(data.SenderFiles) < 1 {
+		return ErrRequired.SetKey("files").SetComment("birth certificate or transfer documents")
+	}
+	waiting := models.SchoolTransferQueryDto{StudentId: data.StudentId, Status: new(string)}
+	*waiting.Status = string(models.StatusWaiting)
+	l, err := store.Store().SchoolTransfersFindBy(ses.Context(), models.ConvertSchoolTransferQueryToMap(waiting))
+	if err != nil {
+		return err
+	}
+	if l.Total > 0 {
+		return ErrUnique.SetKey("student_id").SetComment("student has waiting transfer")
+	}
+	return nil
+}
+
+// SchoolTransfersUpdate accepts or rejects waiting transfer by target school,
+// accepted student is moved with period grades
 func SchoolTransfersUpdate(ses *utils.Session, data *models.SchoolTransferCreateDto) (*models.SchoolTransferResponse, error) {
 	sp, ctx := apm.StartSpan(ses.Context(), "SchoolTransfersUpdate", "app")
 	ses.SetContext(ctx)
@@ -82,30 +121,43 @@ func SchoolTransfersUpdate(ses *utils.Session, data *models.SchoolTransferCreate
 	m := &models.SchoolTransfer{}
 	var err error
 	if m, err = store.Store().SchoolTransfersFindById(context.Background(), *data.ID); err != nil {
-		return nil, err
+		return nil, ErrNotfound.SetKey("id")
 	}
-	if m.Status != data.Status && data.Status != nil && *data.Status == string(models.StatusAccepted) {
-		data.ToModel(m)
-		if m, err = store.Store().SchoolTransfersUpdate(context.Background(), m); err != nil {
-			return nil, err
-		}
-		schoolTranfers := &models.SchoolTransfers{
-			SchoolTransfers: []*models.SchoolTransfer{m},
+	if m.Status == nil || *m.Status != string(models.StatusWaiting) {
+		return nil, ErrInvalid.SetKey("status").SetComment("transfer is not waiting")
+	}
+	if data.Status == nil || !slices.Contains([]string{string(models.StatusAccepted), string(models.StatusDeclined)}, *data.Status) {
+		return nil, ErrInvalid.SetKey("status")
+	}
+	isAccepted := *data.Status == string(models.StatusAccepted)
+	if isAccepted {
+		classroom, err := store.Store().ClassroomsFindById(ses.Context(), *data.TargetClassroomId)
+		if err != nil || m.TargetSchoolId == nil || classroom.SchoolId != *m.TargetSchoolId {
+			return nil, ErrNotfound.SetKey("target_classroom_id")
 		}
-		if err = store.Store().SchoolTransfersLoadRelations(context.Background(), schoolTranfers); err != nil {
+	} else {
+		data.TargetClassroomId = nil
+	}
+	data.ToModel(m)
+	if m, err = store.Store().SchoolTransfersUpdate(context.Background(), m); err != nil {
+		return nil, err
+	}
+	if isAccepted {
+		err = syncSchoolTransfers(ses, m)
+		if err != nil {
 			return nil, err
 		}
-		if m.Status != nil && *m.Status == string(models.StatusAccepted) {
-			err = syncSchoolTransfers(ses, m)
-			if err != nil {
-				return nil, err
-			}
-		}
-		res := &models.SchoolTransferResponse{}
-		res.FromModel(m)
-		return res, nil
 	}
-	return nil, nil
+	schoolTranfers := &models.SchoolTransfers{
+		SchoolTransfers: []*models.SchoolTransfer{m},
+	}
+	if err = store.Store().SchoolTransfersLoadRelations(context.Background(), schoolTranfers); err != nil {
+		return nil, err
+	}
+	go schoolTransferNotify(m)
+	res := &models.SchoolTransferResponse{}
+	res.FromModel(m)
+	return res, nil
 }
 
 func SchoolTransfersDelete(ses *utils.Session, ids []string) (*models.SchoolTransfers, error) {
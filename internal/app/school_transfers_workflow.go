@@ -0,0 +1,213 @@
+package app
+
+import (
+	"slices"
+
+	"github.com/mekdep/server/internal/api/utils"
+	"github.com/mekdep/server/internal/models"
+	"github.com/mekdep/server/internal/store"
+	apputils "github.com/mekdep/server/internal/utils"
+	"go.elastic.co/apm/v2"
+)
+
+// SchoolTransfersPreview shows how period grades and absents of student will be moved
+// to subjects of target classroom, same as MapOldSubjectsToNewSubjectsInPeriodGrade does it
+func SchoolTransfersPreview(ses *utils.Session, id string, targetClassroomId string) (*models.SchoolTransferPreviewResponse, error) {
+	sp, ctx := apm.StartSpan(ses.Context(), "SchoolTransfersPreview", "app")
+	ses.SetContext(ctx)
+	defer sp.End()
+	m, err := store.Store().SchoolTransfersFindById(ses.Context(), id)
+	if err != nil {
+		return nil, ErrNotfound.SetKey("id")
+	}
+	targetClassroom, err := store.Store().ClassroomsFindById(ses.Context(), targetClassroomId)
+	if err != nil || m.TargetSchoolId == nil || targetClassroom.SchoolId != *m.TargetSchoolId {
+		return nil, ErrNotfound.SetKey("target_classroom_id")
+	}
+	periodGrades, err := store.Store().PeriodGradeByStudent(ses.Context(), *m.StudentId)
+	if err != nil {
+		return nil, err
+	}
+	oldSubjects, err := store.Store().SubjectsFindByClassroomId(ses.Context(), *m.SourceClassroomId)
+	if err != nil {
+		return nil, err
+	}
+	newSubjects, err := store.Store().SubjectsFindByClassroomId(ses.Context(), targetClassroomId)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &models.SchoolTransferPreviewResponse{
+		TargetClassroom: &models.ClassroomResponse{},
+		Subjects:        []models.SchoolTransferSubjectPreview{},
+	}
+	res.TargetClassroom.FromModel(targetClassroom)
+	for _, oldSubject := range oldSubjects {
+		item := models.SchoolTransferSubjectPreview{
+			SourceSubjectId:   oldSubject.ID,
+			SourceSubjectName: oldSubject.Name,
+			PeriodKeys:        []int{},
+		}
+		for _, pg := range periodGrades {
+			if pg.SubjectId == nil || *pg.SubjectId != oldSubject.ID {
+				continue
+			}
+			if pg.GetAbsentCount() == 0 && pg.GetGradeCount() == 0 {
+				continue
+			}
+			item.GradeCount += pg.GetGradeCount()
+			item.GradeSum += pg.GetGradeSum()
+			item.AbsentCount += pg.GetAbsentCount()
+			if !slices.Contains(item.PeriodKeys, pg.PeriodKey) {
+				item.PeriodKeys = append(item.PeriodKeys, pg.PeriodKey)
+			}
+		}
+		// subject without grades has nothing to move
+		if len(item.PeriodKeys) < 1 {
+			continue
+		}
+		for _, newSubject := range newSubjects {
+			if newSubject.ParentId == nil && oldSubject.Name != nil && newSubject.Name != nil && *oldSubject.Name == *newSubject.Name {
+				item.TargetSubjectId = &newSubject.ID
+				item.TargetSubjectName = newSubject.Name
+				item.IsMatched = true
+				break
+			}
+		}
+		if !item.IsMatched {
+			res.UnmatchedCount++
+		}
+		res.Subjects = append(res.Subjects, item)
+	}
+	return res, nil
+}
+
+// schoolTransferNotify tells sending school and parents of student about answer of target school
+func schoolTransferNotify(m *models.SchoolTransfer) {
+	ses := &utils.Session{}
+	if m.Status == nil || m.StudentId == nil {
+		return
+	}
+	student, err := store.Store().UsersFindById(ses.Context(), *m.StudentId)
+	if err != nil {
+		apputils.LoggerDesc("In schoolTransferNotify " + m.ID).Error(err)
+		return
+	}
+	targetSchool := ""
+	if m.TargetSchool != nil && m.TargetSchool.Name != nil {
+		targetSchool = *m.TargetSchool.Name
+	}
+	var title string
+	switch models.StatusSchoolTransfer(*m.Status) {
+	case models.StatusAccepted:
+		title = "Okuwçy kabul edildi"
+	case models.StatusDeclined:
+		title = "Okuwçy kabul edilmedi"
+	case models.StatusExpired:
+		title = "Geçiriş möhleti geçdi"
+	default:
+		return
+	}
+	body := student.FullName() + ", " + targetSchool
+	if m.ReceiverNote != nil && *m.ReceiverNote != "" {
+		body += ": " + *m.ReceiverNote
+	}
+
+	// sending school: who sent and principals
+	staff := []*models.User{}
+	if m.SentBy != nil {
+		sender, err := store.Store().UsersFindById(ses.Context(), *m.SentBy)
+		if err == nil {
+			staff = append(staff, sender)
+		}
+	}
+	if m.SourceSchoolId != nil {
+		role := string(models.RolePrincipal)
+		principals, _, err := store.Store().UsersFindBy(ses.Context(), models.UserFilterRequest{
+			Role:     &role,
+			SchoolId: m.SourceSchoolId,
+		})
+		if err == nil {
+			for _, u := range principals {
+				if !slices.ContainsFunc(staff, func(s *models.User) bool { return s.ID == u.ID }) {
+					staff = append(staff, u)
+				}
+			}
+		}
+	}
+	err = notifyDispatch(staff, Notify{
+		Category: models.NotifyTransferStatus,
+		Role:     models.RolePrincipal,
+		Title:    title,
+		Body:     body,
+		PushType: PushTypeNotification,
+		PushId:   m.ID,
+	})
+	if err != nil {
+		apputils.LoggerDesc("In schoolTransferNotify " + m.ID).Error(err)
+	}
+	// expired transfer is matter of schools only
+	if *m.Status == string(models.StatusExpired) {
+		return
+	}
+	err = store.Store().UsersLoadRelationsParents(ses.Context(), &[]*models.User{student})
+	if err != nil {
+		apputils.LoggerDesc("In schoolTransferNotify " + m.ID).Error(err)
+		return
+	}
+	err = notifyDispatch(student.Parents, Notify{
+		Category: models.NotifyTransferStatus,
+		Role:     models.RoleParent,
+		Title:    title,
+		Body:     body,
+		SmsText:  title + ": " + LettersRemoveTurkmen(body),
+		SmsType:  models.SmsTypeOther,
+		PushType: PushTypeNotification,
+		PushId:   m.ID,
+	})
+	if err != nil {
+		apputils.LoggerDesc("In schoolTransferNotify " + m.ID).Error(err)
+	}
+}
+
+// SchoolTransfersExpire closes waiting transfers not answered in time, senders can send again
+func SchoolTransfersExpire() error {
+	ses := &utils.Session{}
+	l, err := store.Store().SchoolTransfersExpire(ses.Context())
+	if err != nil {
+		return err
+	}
+	if len(l.SchoolTransfers) < 1 {
+		return nil
+	}
+	err = store.Store().SchoolTransfersLoadRelations(ses.Context(), l)
+	if err != nil {
+		return err
+	}
+	for _, m := range l.SchoolTransfers {
+		schoolTransferNotify(m)
+	}
+	return nil
+}
+
+// schoolTransfersHistory returns transfers of student for profile
+func schoolTransfersHistory(ses *utils.Session, studentId string) (*[]models.SchoolTransferResponse, error) {
+	q := models.SchoolTransferQueryDto{StudentId: &studentId, Limit: 50}
+	l, err := store.Store().SchoolTransfersFindBy(ses.Context(), models.ConvertSchoolTransferQueryToMap(q))
+	if err != nil {
+		return nil, err
+	}
+	err = store.Store().SchoolTransfersLoadRelations(ses.Context(), l)
+	if err != nil {
+		return nil, err
+	}
+	res := []models.SchoolTransferResponse{}
+	for _, v := range l.SchoolTransfers {
+		item := models.SchoolTransferResponse{}
+		item.FromModel(v)
+		// student is profile itself
+		item.Student = nil
+		res = append(res, item)
+	}
+	return &res, nil
+}
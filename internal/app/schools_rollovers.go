@@ -0,0 +1,333 @@
+package app
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/mekdep/server/internal/api/utils"
+	"github.com/mekdep/server/internal/models"
+	"github.com/mekdep/server/internal/store"
+	"go.elastic.co/apm/v2"
+)
+
+// SchoolsRollover moves school to next year: each classroom goes to its successor (5A -> 6A)
+// with students, sub groups, class teacher and subjects, graduating classrooms are only archived.
+// Dry run returns same diff without changes.
+func SchoolsRollover(ses *utils.Session, data models.RolloverRequest) (*models.RolloverResponse, error) {
+	sp, ctx := apm.StartSpan(ses.Context(), "SchoolsRollover", "app")
+	ses.SetContext(ctx)
+	defer sp.End()
+	if data.SchoolId == nil {
+		return nil, ErrRequired.SetKey("school_id")
+	}
+	school, err := store.Store().SchoolsFindById(ses.Context(), *data.SchoolId)
+	if err != nil || school == nil {
+		return nil, ErrNotfound.SetKey("school_id")
+	}
+	// latest period of school is current year
+	limit := 1
+	periods, _, err := store.Store().PeriodsListFilters(ses.Context(), models.PeriodFilterRequest{
+		SchoolId:          &school.ID,
+		PaginationRequest: models.PaginationRequest{Limit: &limit},
+	})
+	if err != nil {
+		return nil, err
+	}
+	res := &models.RolloverResponse{
+		IsDryRun:   data.IsDryRun,
+		SchoolId:   school.ID,
+		Classrooms: []models.RolloverClassroom{},
+	}
+	var fromPeriod *models.Period
+	after := time.Now()
+	if len(periods) > 0 {
+		fromPeriod = periods[0]
+		start, end, err := fromPeriod.Dates()
+		if err != nil {
+			return nil, ErrInvalid.SetKey("period").SetComment(err.Error())
+		}
+		if start.After(time.Now()) {
+			return nil, ErrInvalid.SetKey("school_id").SetComment("next period is already created")
+		}
+		after = end
+		res.FromPeriod = &models.PeriodResponse{}
+		res.FromPeriod.FromModel(fromPeriod)
+	}
+	toPeriod, err := rolloverPeriod(school.ID, data, after)
+	if err != nil {
+		return nil, err
+	}
+	res.ToPeriod = &models.PeriodResponse{}
+	res.ToPeriod.FromModel(toPeriod)
+
+	limit = 1000
+	classrooms, _, err := store.Store().ClassroomsFindBy(ses.Context(), models.ClassroomFilterRequest{
+		SchoolId:          &school.ID,
+		PaginationRequest: models.PaginationRequest{Limit: &limit},
+	})
+	if err != nil {
+		return nil, err
+	}
+	classrooms = slices.DeleteFunc(classrooms, func(c *models.Classroom) bool {
+		return c.ArchivedAt != nil
+	})
+	err = store.Store().ClassroomsLoadRelations(ses.Context(), &classrooms, true)
+	if err != nil {
+		return nil, err
+	}
+	// parents first, their successors are created before children
+	slices.SortStableFunc(classrooms, func(a, b *models.Classroom) int {
+		if (a.ParentId == nil) == (b.ParentId == nil) {
+			return 0
+		}
+		if a.ParentId == nil {
+			return -1
+		}
+		return 1
+	})
+
+	overrides := map[string]models.RolloverClassroomRequest{}
+	for k, v := range data.Classrooms {
+		if !slices.ContainsFunc(classrooms, func(c *models.Classroom) bool { return c.ID == *v.ClassroomId }) {
+			return nil, ErrNotfound.SetKey(fmt.Sprintf("classrooms.%d.classroom_id", k))
+		}
+		overrides[*v.ClassroomId] = v
+	}
+	graduation := models.DefaultGraduationClassyear
+	if data.GraduationClassyear != nil {
+		graduation = *data.GraduationClassyear
+	}
+
+	successors := map[string]*models.Classroom{}
+	successorNames := map[string]bool{}
+	for _, c := range classrooms {
+		item := rolloverClassroom(c, graduation)
+		if v, ok := overrides[c.ID]; ok {
+			rolloverClassroomOverride(&item, v)
+		}
+		if !item.IsSkipped && !item.IsGraduating {
+			if successorNames[*item.SuccessorName] {
+				warning := "successor name is used by other classroom"
+				item.Warning = &warning
+			}
+			successorNames[*item.SuccessorName] = true
+			successors[c.ID] = rolloverSuccessor(c, item)
+		}
+		res.Classrooms = append(res.Classrooms, item)
+	}
+	res.SetCounts()
+	if data.IsDryRun {
+		return res, nil
+	}
+	for k, item := range res.Classrooms {
+		if !item.IsSkipped && !item.IsGraduating && item.Warning != nil {
+			return nil, ErrUnique.SetKey(fmt.Sprintf("classrooms.%d.successor_name", k)).SetComment(*item.SuccessorName)
+		}
+	}
+
+	m := models.Rollover{
+		SchoolId:   school.ID,
+		Classrooms: res.Classrooms,
+	}
+	if fromPeriod != nil {
+		m.FromPeriodId = &fromPeriod.ID
+	}
+	if ses.GetUser() != nil {
+		m.CreatedBy = &ses.GetUser().ID
+	}
+	err = store.Store().RolloversCommit(ses.Context(), &m, toPeriod, successors)
+	if err != nil {
+		return nil, err
+	}
+	res.FromModel(&m)
+	res.ToPeriod.FromModel(toPeriod)
+	if fromPeriod != nil {
+		res.FromPeriod.FromModel(fromPeriod)
+	}
+	return res, nil
+}
+
+func RolloversList(ses *utils.Session, f models.RolloverFilterRequest) ([]*models.RolloverResponse, int, error) {
+	sp, ctx := apm.StartSpan(ses.Context(), "RolloversList", "app")
+	ses.SetContext(ctx)
+	defer sp.End()
+	l, total, err := store.Store().RolloversFindBy(ses.Context(), f)
+	if err != nil {
+		return nil, 0, err
+	}
+	res := []*models.RolloverResponse{}
+	for _, m := range l {
+		item := models.RolloverResponse{}
+		item.FromModel(m)
+		res = append(res, &item)
+	}
+	return res, total, nil
+}
+
+// rolloverPeriod is next period of school: dates of request or default period moved to next year
+func rolloverPeriod(schoolId string, data models.RolloverRequest, after time.Time) (*models.Period, error) {
+	m := models.Period{SchoolId: &schoolId}
+	if data.PeriodValue != nil && len(*data.PeriodValue) > 0 {
+		for perK, per := range *data.PeriodValue {
+			for dateK, date := range per {
+				_, err := time.Parse(time.DateOnly, date)
+				if err != nil {
+					return nil, NewAppError("invalid", fmt.Sprintf("period_value.%d.%d", perK, dateK), err.Error())
+				}
+			}
+		}
+		m.Value = *data.PeriodValue
+	} else {
+		value, err := rolloverPeriodValue(appSettingsPeriods().Value, after)
+		if err != nil {
+			return nil, err
+		}
+		m.Value = value
+	}
+	start, _, err := m.Dates()
+	if err != nil {
+		return nil, ErrInvalid.SetKey("period_value").SetComment(err.Error())
+	}
+	if data.PeriodTitle != nil && *data.PeriodTitle != "" {
+		m.Title = *data.PeriodTitle
+	} else {
+		m.Title = fmt.Sprintf("%d/%02d", start.Year(), (start.Year()+1)%100)
+	}
+	return &m, nil
+}
+
+// rolloverPeriodValue moves dates by whole years so period starts after given time
+func rolloverPeriodValue(value [][]string, after time.Time) ([][]string, error) {
+	start, _, err := models.Period{Value: value}.Dates()
+	if err != nil {
+		return nil, err
+	}
+	years := after.Year() - start.Year()
+	if !start.AddDate(years, 0, 0).After(after) {
+		years++
+	}
+	res := [][]string{}
+	for _, per := range value {
+		item := []string{}
+		for _, date := range per {
+			t, err := time.Parse(time.DateOnly, date)
+			if err != nil {
+				return nil, err
+			}
+			item = append(item, t.AddDate(years, 0, 0).Format(time.DateOnly))
+		}
+		res = append(res, item)
+	}
+	return res, nil
+}
+
+// rolloverClassroom is default mapping of classroom by its classyear
+func rolloverClassroom(c *models.Classroom, graduation int) models.RolloverClassroom {
+	item := models.RolloverClassroom{
+		ClassroomId: c.ID,
+		Name:        c.Name,
+		TeacherId:   c.TeacherId,
+		StudentIds:  []string{},
+		SubGroups:   []string{},
+		Subjects:    []string{},
+	}
+	for _, s := range c.Students {
+		item.StudentIds = append(item.StudentIds, s.ID)
+	}
+	for _, g := range c.SubGroups {
+		if g.Type == nil || g.TypeKey == nil {
+			continue
+		}
+		item.SubGroups = append(item.SubGroups, fmt.Sprintf("%s %d (%d)", *g.Type, *g.TypeKey, len(g.StudentIds)))
+	}
+	for _, s := range c.Subjects {
+		name := ""
+		if s.Name != nil {
+			name = *s.Name
+		}
+		if s.Teacher != nil {
+			name += " - " + s.Teacher.FullName()
+		}
+		item.Subjects = append(item.Subjects, name)
+	}
+	classyear, err := strconv.Atoi(c.Classyear())
+	if err != nil {
+		warning := "classroom name has no classyear"
+		item.Warning = &warning
+		item.IsSkipped = true
+		return item
+	}
+	if classyear >= graduation {
+		item.IsGraduating = true
+		return item
+	}
+	name := c.SuccessorName()
+	item.SuccessorName = &name
+	return item
+}
+
+func rolloverClassroomOverride(item *models.RolloverClassroom, v models.RolloverClassroomRequest) {
+	if v.SuccessorName != nil && *v.SuccessorName != "" {
+		item.SuccessorName = v.SuccessorName
+		item.IsGraduating = false
+		item.IsSkipped = false
+		item.Warning = nil
+	}
+	if v.TeacherId != nil {
+		item.TeacherId = v.TeacherId
+	}
+	if v.IsGraduating != nil {
+		item.IsGraduating = *v.IsGraduating
+	}
+	if v.IsSkipped != nil {
+		item.IsSkipped = *v.IsSkipped
+	}
+	if !item.IsSkipped && !item.IsGraduating && item.SuccessorName == nil {
+		item.IsSkipped = true
+	}
+}
+
+// rolloverSuccessor is classroom of next year, subjects keep ids of old classroom to map parents
+func rolloverSuccessor(c *models.Classroom, item models.RolloverClassroom) *models.Classroom {
+	m := &models.Classroom{
+		SchoolId:      c.SchoolId,
+		ShiftId:       c.ShiftId,
+		Name:          item.SuccessorName,
+		NameCanonical: item.SuccessorName,
+		Avatar:        c.Avatar,
+		Description:   c.Description,
+		Language:      c.Language,
+		Level:         c.Level,
+		TeacherId:     item.TeacherId,
+		StudentId:     c.StudentId,
+		ParentId:      c.ParentId,
+		Subjects:      []*models.Subject{},
+	}
+	subjects := slices.Clone(c.Subjects)
+	slices.SortStableFunc(subjects, func(a, b *models.Subject) int {
+		if (a.ParentId == nil) == (b.ParentId == nil) {
+			return 0
+		}
+		if a.ParentId == nil {
+			return -1
+		}
+		return 1
+	})
+	for _, s := range subjects {
+		m.Subjects = append(m.Subjects, &models.Subject{
+			ID:               s.ID,
+			ClassroomType:    s.ClassroomType,
+			ClassroomTypeKey: s.ClassroomTypeKey,
+			BaseSubjectId:    s.BaseSubjectId,
+			ParentId:         s.ParentId,
+			TeacherId:        s.TeacherId,
+			SecondTeacherId:  s.SecondTeacherId,
+			Name:             s.Name,
+			FullName:         s.FullName,
+			WeekHours:        s.WeekHours,
+		})
+	}
+	return m
+}
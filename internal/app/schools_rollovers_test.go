@@ -0,0 +1,125 @@
+package app
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mekdep/server/internal/models"
+)
+
+func TestRolloverPeriodValue(t *testing.T) {
+	value := [][]string{{"2023-09-01", "2023-10-28"}, {"2024-03-25", "2024-05-25"}}
+	cases := []struct {
+		after time.Time
+		want  string
+	}{
+		{time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC), "2024-09-01"},
+		{time.Date(2024, 9, 1, 0, 0, 0, 0, time.UTC), "2025-09-01"},
+		{time.Date(2026, 1, 10, 0, 0, 0, 0, time.UTC), "2026-09-01"},
+	}
+	for _, c := range cases {
+		got, err := rolloverPeriodValue(value, c.after)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got[0][0] != c.want {
+			t.Errorf("rolloverPeriodValue after %s starts %s, want %s", c.after.Format(time.DateOnly), got[0][0], c.want)
+		}
+		if len(got) != len(value) || len(got[1]) != len(value[1]) {
+			t.Errorf("rolloverPeriodValue after %s = %v, want same shape as %v", c.after.Format(time.DateOnly), got, value)
+		}
+	}
+	if _, err := rolloverPeriodValue([][]string{{"2023-09-01", "not a date"}}, time.Now()); err == nil {
+		t.Error("rolloverPeriodValue with invalid date: expected error")
+	}
+}
+
+func TestRolloverClassroom(t *testing.T) {
+	str := func(s string) *string { return &s }
+	cases := []struct {
+		name       string
+		successor  *string
+		graduating bool
+		skipped    bool
+	}{
+		{"5A", str("6A"), false, false},
+		{"10 B", str("11 B"), false, false},
+		{"11A", nil, true, false},
+		{"A", nil, false, true},
+	}
+	for _, c := range cases {
+		classroom := &models.Classroom{
+			ID:       "classroom",
+			Name:     str(c.name),
+			Students: []*models.User{{ID: "student1"}, {ID: "student2"}},
+		}
+		item := rolloverClassroom(classroom, 11)
+		if !reflect.DeepEqual(item.SuccessorName, c.successor) {
+			t.Errorf("%s: successor %v, want %v", c.name, item.SuccessorName, c.successor)
+		}
+		if item.IsGraduating != c.graduating || item.IsSkipped != c.skipped {
+			t.Errorf("%s: graduating %v skipped %v, want %v %v", c.name, item.IsGraduating, item.IsSkipped, c.graduating, c.skipped)
+		}
+		if !reflect.DeepEqual(item.StudentIds, []string{"student1", "student2"}) {
+			t.Errorf("%s: students %v", c.name, item.StudentIds)
+		}
+	}
+}
+
+func TestRolloverClassroomOverride(t *testing.T) {
+	str := func(s string) *string { return &s }
+	yes := true
+
+	item := models.RolloverClassroom{IsSkipped: true, Warning: str("classroom name has no classyear")}
+	rolloverClassroomOverride(&item, models.RolloverClassroomRequest{SuccessorName: str("2A")})
+	if item.IsSkipped || item.Warning != nil || item.SuccessorName == nil || *item.SuccessorName != "2A" {
+		t.Errorf("override with successor name: got %+v", item)
+	}
+
+	item = models.RolloverClassroom{SuccessorName: str("6A")}
+	rolloverClassroomOverride(&item, models.RolloverClassroomRequest{IsGraduating: &yes})
+	if !item.IsGraduating {
+		t.Errorf("override with graduating: got %+v", item)
+	}
+
+	item = models.RolloverClassroom{}
+	rolloverClassroomOverride(&item, models.RolloverClassroomRequest{})
+	if !item.IsSkipped {
+		t.Errorf("classroom without successor and not graduating must be skipped: got %+v", item)
+	}
+}
+
+func TestRolloverSuccessor(t *testing.T) {
+	str := func(s string) *string { return &s }
+	schoolId := "school"
+	classroom := &models.Classroom{
+		ID:        "old",
+		SchoolId:  schoolId,
+		Name:      str("5A"),
+		TeacherId: str("old teacher"),
+		Subjects: []*models.Subject{
+			{ID: "child", ParentId: str("parent"), Name: str("Math")},
+			{ID: "parent", Name: str("Math")},
+			{ID: "other", Name: str("Music")},
+		},
+	}
+	item := models.RolloverClassroom{ClassroomId: "old", SuccessorName: str("6A"), TeacherId: str("new teacher")}
+	m := rolloverSuccessor(classroom, item)
+	if m.ID != "" || m.SchoolId != schoolId {
+		t.Errorf("successor must be new classroom of same school: got id %q school %q", m.ID, m.SchoolId)
+	}
+	if m.Name == nil || *m.Name != "6A" || m.TeacherId == nil || *m.TeacherId != "new teacher" {
+		t.Errorf("successor name %v teacher %v, want 6A and new teacher", m.Name, m.TeacherId)
+	}
+	ids := []string{}
+	for _, s := range m.Subjects {
+		ids = append(ids, s.ID)
+	}
+	if !reflect.DeepEqual(ids, []string{"parent", "other", "child"}) {
+		t.Errorf("successor subjects %v, want parents first", ids)
+	}
+	if classroom.Subjects[0].ID != "child" {
+		t.Error("subjects of old classroom must not be reordered")
+	}
+}
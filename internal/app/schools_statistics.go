@@ -306,7 +306,7 @@ func (a App) StatisticsPeriodFinishedByTeacher(ses *utils.Session, schoolId stri
 func (a App) StatisticsParentsCached(ses *utils.Session, schoolIds []string) (StatisticsResponse, error) {
 	schoolIdsStr, _ := json.Marshal(schoolIds)
 	k := "StatisticsParentsCached_" + string(schoolIdsStr)
-	if v, ok := a.cache.Get(k); ok {
+	if v, ok := a.cacheGet(k); ok {
 		return v.(StatisticsResponse), nil
 	} else {
 		v, err := a.StatisticsParents(ses, schoolIds)
@@ -475,7 +475,7 @@ func (a App) StatisticsParents(ses *utils.Session, schoolIds []string) (Statisti
 func (a App) StatisticsParentsBySchoolCached(ses *utils.Session, schoolId string) (StatisticsResponse, error) {
 	schoolIdsStr, _ := json.Marshal(schoolId)
 	k := "StatisticsParentsBySchoolCached_" + string(schoolIdsStr)
-	if v, ok := a.cache.Get(k); ok {
+	if v, ok := a.cacheGet(k); ok {
 		return v.(StatisticsResponse), nil
 	} else {
 		v, err := a.StatisticsParentsBySchool(ses, schoolId)
@@ -680,7 +680,7 @@ func (a App) StatisticsOnline(ses *utils.Session, schoolIds []string, startDate
 func (a App) StatisticsStudentsCached(ses *utils.Session, schoolIds []string) (StatisticsResponse, error) {
 	schoolIdsStr, _ := json.Marshal(schoolIds)
 	k := "StatisticsStudentsCached_" + string(schoolIdsStr)
-	if v, ok := a.cache.Get(k); ok {
+	if v, ok := a.cacheGet(k); ok {
 		return v.(StatisticsResponse), nil
 	} else {
 		v, err := a.StatisticsStudents(ses, schoolIds)
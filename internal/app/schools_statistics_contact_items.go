@@ -34,7 +34,8 @@ func StatisticsContactItems(ses *utils.Session, dto models.ContactItemsFilterReq
 		rowItem := StatisticsRow{}
 		resRows = append(resRows, &rowItem)
 		rowItem.FromSchool(schoolItem)
-		var totalCount, reviewCount, complaintCount, suggestionCount, dataComplaintCount int
+		var totalCount, reviewCount, complaintCount, suggestionCount, dataComplaintCount, overdueCount int
+		var responseHours, resolutionHours float64
 		for _, contactItem := range contactItemsCount {
 			if contactItem.SchoolCode == *schoolItem.Code {
 				totalCount = contactItem.TotalCount
@@ -42,6 +43,9 @@ func StatisticsContactItems(ses *utils.Session, dto models.ContactItemsFilterReq
 				complaintCount = contactItem.ComplaintCount
 				suggestionCount = contactItem.SuggestionCount
 				dataComplaintCount = contactItem.DataComplaintCount
+				responseHours = contactItem.ResponseHours
+				resolutionHours = contactItem.ResolutionHours
+				overdueCount = contactItem.OverdueCount
 			}
 		}
 		rowItem.Values = []StatisticsCell{
@@ -52,6 +56,9 @@ func StatisticsContactItems(ses *utils.Session, dto models.ContactItemsFilterReq
 			StatisticsCell(strconv.Itoa(complaintCount)),
 			StatisticsCell(strconv.Itoa(suggestionCount)),
 			StatisticsCell(strconv.Itoa(dataComplaintCount)),
+			StatisticsCell(strconv.FormatFloat(responseHours, 'f', 1, 64)),
+			StatisticsCell(strconv.FormatFloat(resolutionHours, 'f', 1, 64)),
+			StatisticsCell(strconv.Itoa(overdueCount)),
 		}
 	}
 	for _, v := range resRows {
@@ -59,7 +66,7 @@ func StatisticsContactItems(ses *utils.Session, dto models.ContactItemsFilterReq
 	}
 
 	res := StatisticsResponse{
-		Headers: []StatisticsHeader{"Kody", "Mekdep", "Jemi sany", "Teswir#review", "Arz-şikaýat#complaint", "Teklip#suggestion", "Maglumaty düzediş arza#data_complaint"},
+		Headers: []StatisticsHeader{"Kody", "Mekdep", "Jemi sany", "Teswir#review", "Arz-şikaýat#complaint", "Teklip#suggestion", "Maglumaty düzediş arza#data_complaint", "Jogap wagty (sagat)#response_hours", "Çözülme wagty (sagat)#resolution_hours", "Möhleti geçen#overdue"},
 		Rows:    resRowsNoPtr,
 	}
 	return res, nil
@@ -16,7 +16,7 @@ import (
 func (a App) StatisticsSchoolDataCached(ses *utils.Session, schoolIds []string, startDate time.Time, endDate time.Time) (StatisticsResponse, error) {
 	schoolIdsStr, _ := json.Marshal(schoolIds)
 	k := "StatisticsSchoolData_" + string(schoolIdsStr) + startDate.Format(time.DateOnly) + endDate.Format(time.DateOnly)
-	if v, ok := a.cache.Get(k); ok {
+	if v, ok := a.cacheGet(k); ok {
 		return v.(StatisticsResponse), nil
 	} else {
 		v, err := a.StatisticsSchoolData(ses, schoolIds, startDate, endDate)
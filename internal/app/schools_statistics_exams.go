@@ -7,7 +7,6 @@ import (
 	"strconv"
 	"time"
 
-	"github.com/mekdep/server/config"
 	"github.com/mekdep/server/internal/api/utils"
 	"github.com/mekdep/server/internal/models"
 	"github.com/mekdep/server/internal/store"
@@ -19,7 +18,7 @@ func (a App) StatisticsExamCached(ses *utils.Session, dto ReportsRequestDto) (*S
 	reqStr, _ := json.Marshal(dto)
 	k := "StatisticsExamCached_" + string(reqStr)
 	res := StatisticsResponse{}
-	if v, ok := a.cache.Get(k); ok {
+	if v, ok := a.cacheGet(k); ok {
 		res = v.(StatisticsResponse)
 		return &res, nil
 	} else {
@@ -70,7 +69,7 @@ func (a App) StatisticsExam(ses *utils.Session, dto ReportsRequestDto, isGraduat
 
 	// map colors by subject
 	subjectColors := map[string]string{}
-	for _, v := range models.DefaultSubjects {
+	for _, v := range models.DefaultCountry().Subjects {
 		if len(v) > 3 {
 			subjectColors[v[0]] = v[3]
 		} else {
@@ -106,7 +105,7 @@ func (a App) StatisticsExam(ses *utils.Session, dto ReportsRequestDto, isGraduat
 				if subjectExam.StartTime == nil {
 					continue
 				}
-				*subjectExam.StartTime = subjectExam.StartTime.In(config.RequestLocation)
+				*subjectExam.StartTime = subjectExam.StartTime.In(schoolItem.Location())
 				subjectExamDate := subjectExam.StartTime.Format(time.DateOnly)
 				if subjectExamsByDate[subjectExamDate] == nil {
 					subjectExamsByDate[subjectExamDate] = []models.SubjectExam{}
@@ -16,7 +16,7 @@ func (a App) StatisticsJournalCached(ses *utils.Session, dto ReportsRequestDto)
 	reqStr, _ := json.Marshal(dto)
 	k := "StatisticsJournalCached_" + string(reqStr)
 	res := StatisticsResponse{}
-	if v, ok := a.cache.Get(k); ok {
+	if v, ok := a.cacheGet(k); ok {
 		res = v.(StatisticsResponse)
 		return &res, nil
 	} else {
@@ -2,6 +2,7 @@ package app
 
 import (
 	"strings"
+	"time"
 
 	"github.com/mekdep/server/internal/models"
 	"github.com/mekdep/server/internal/store"
@@ -10,11 +11,27 @@ import (
 )
 
 func SendSMS(phones []string, text string, smsType models.SmsType) error {
+	return SendSMSAt(phones, text, smsType, nil)
+}
+
+// SendSMSAt saves sms to be sent by SmsSendScheduled when sendAt is set, otherwise sends it now
+func SendSMSAt(phones []string, text string, smsType models.SmsType, sendAt *time.Time) error {
+	if sendAt != nil {
+		_, err := store.Store().SmsSenderCreate(context.Background(), &models.SmsSender{
+			Phones:  &phones,
+			Message: text,
+			Type:    string(smsType),
+			LeftTry: 3,
+			SendAt:  sendAt,
+		})
+		return err
+	}
 	err := apputils.SendMessageToPhones(phones, text)
 	errMsg := new(string)
 	if err != nil {
 		*errMsg = err.Error()
 	}
+	apputils.MetricsSms(string(smsType), len(phones), err)
 	if err != nil && strings.Contains(err.Error(), "not set") {
 		err = nil
 	}
@@ -29,3 +46,35 @@ func SendSMS(phones []string, text string, smsType models.SmsType) error {
 
 	return nil // prevent UI "cant complete request"
 }
+
+// SmsSendScheduled sends sms deferred by quiet hours whose time came
+func SmsSendScheduled() error {
+	now := time.Now()
+	f := models.SmsSenderFilterRequest{SendBefore: &now}
+	f.Limit = new(int)
+	*f.Limit = 500
+	f.Offset = new(int)
+	l, _, err := store.Store().SmsSendersFindBy(context.Background(), f)
+	if err != nil {
+		return err
+	}
+	for _, m := range l {
+		phones := []string{}
+		if m.Phones != nil {
+			phones = *m.Phones
+		}
+		err := apputils.SendMessageToPhones(phones, m.Message)
+		apputils.MetricsSms(m.Type, len(phones), err)
+		m.IsCompleted = err == nil
+		if err != nil {
+			errMsg := err.Error()
+			m.ErrorMsg = &errMsg
+			m.LeftTry--
+		}
+		_, err = store.Store().SmsSenderUpdate(context.Background(), m)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
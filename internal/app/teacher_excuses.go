@@ -2,6 +2,7 @@ package app
 
 import (
 	"context"
+	"log"
 	"time"
 
 	"github.com/mekdep/server/internal/api/utils"
@@ -180,5 +181,13 @@ func syncTeacherExcuses(ses *utils.Session, teacherId string, excuses []*models.
 			"is_teacher_excused": true,
 		})
 	}
-	return err
+	if err != nil {
+		return err
+	}
+	// cancelled lessons shift topics of curriculum plans, sync is not failed by it
+	err = curriculumApplySubjects(ses, subjects)
+	if err != nil {
+		log.Println("Curriculum plan apply failed for teacher: "+teacherId, "err ", err)
+	}
+	return nil
 }
@@ -374,6 +374,11 @@ func lessonsSyncByTimetable(ses *utils.Session, timetable models.Timetable, tVal
 	// 	log.Println("Updated len: ", string(dt), string(dtt))
 	// }
 
+	// fill topics of curriculum plans, sync is not failed by it
+	err = curriculumApplyClassroom(ses, timetable.ClassroomId)
+	if err != nil {
+		log.Println("Curriculum plan apply failed for: "+timetable.ClassroomId, "err ", err)
+	}
 	return nil
 }
 
@@ -382,7 +387,7 @@ func isDateHoliday(date time.Time) bool {
 }
 
 func GetHolidayByDate(date time.Time) string {
-	for _, v := range models.DefaultHolidays {
+	for _, v := range models.DefaultCountry().Holidays {
 		if (date.Month() == v.StartDate.Month() && date.Day() >= v.StartDate.Day()) &&
 			(date.Month() == v.EndDate.Month() && date.Day() <= v.EndDate.Day()) {
 			return v.Name
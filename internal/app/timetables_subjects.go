@@ -118,7 +118,7 @@ func CreateSubjectsByNames(ses *utils.Session, dto *models.CreateSubjectsByNames
 
 		// db validate
 		isSubjectExists := false
-		for _, v := range models.DefaultSubjects {
+		for _, v := range models.DefaultCountry().Subjects {
 			if subject.Name == v[0] || subject.Name == v[1] {
 				isSubjectExists = true
 				subject.Name = v[0]
@@ -57,6 +57,9 @@ func UsersDetail(ses *utils.Session, f models.UserFilterRequest) (*models.UserRe
 	if err != nil {
 		return nil, err
 	}
+	if len(m) < 1 {
+		return nil, ErrNotfound
+	}
 	err = store.Store().UsersLoadRelations(ses.Context(), &m, true)
 	if err != nil {
 		return nil, err
@@ -72,11 +75,14 @@ func UsersDetail(ses *utils.Session, f models.UserFilterRequest) (*models.UserRe
 		}
 	}
 
-	if len(m) < 1 {
-		return nil, ErrNotfound
-	}
 	res := &models.UserResponse{}
 	res.FromModel(m[0])
+	if slices.ContainsFunc(mm.Schools, func(us *models.UserSchool) bool { return us.RoleCode == models.RoleStudent }) {
+		res.SchoolTransfers, err = schoolTransfersHistory(ses, mm.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
 	return res, nil
 }
 
@@ -153,6 +159,13 @@ func UsersUpdate(ses *utils.Session, data *models.UserRequest) (*models.UserResp
 	if err != nil {
 		return nil, err
 	}
+	// changed email should be verified again
+	if data.Email != nil && (existingUser.Email == nil || *existingUser.Email != *data.Email) && existingUser.EmailVerifiedAt != nil {
+		err = store.Store().UsersResetEmailVerified(ses.Context(), existingUser.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
 	model, err = store.Store().UserUpdateRelations(ses.Context(), dataModel)
 	if err != nil {
 		return nil, err
@@ -273,35 +286,7 @@ func checkExistingAndMerge(ses *utils.Session, model *models.User) (bool, error)
 	origFirstName := model.FirstName
 	origLastName := model.LastName
 
-	if model.Birthday == nil || model.FirstName == nil || model.LastName == nil {
-		return false, nil
-		// return false, ErrNotSet.SetKey("first_name").SetComment("required fields to check: first_name, last_name, birthday")
-	}
-	var classroomID string
-	// TODO: ClassroomName nirden almaly men???? SORAMALY
-	if model.Classrooms != nil && len(model.Classrooms) > 0 {
-		for _, v := range model.Classrooms {
-			if v.Classroom != nil && v.Classroom.ID != "" {
-				classroomID = v.Classroom.ID
-			}
-		}
-	}
-	var userFilter models.UserFilterRequest
-	if classroomID != "" {
-		userFilter = models.UserFilterRequest{
-			LowFirstName: model.FirstName,
-			LowLastName:  model.LastName,
-			ClassroomId:  &classroomID,
-		}
-	} else if model.Birthday != nil && !model.Birthday.IsZero() {
-		birthdayStr := model.Birthday.Format("2006-01-02")
-		userFilter = models.UserFilterRequest{
-			LowFirstName: model.FirstName,
-			LowLastName:  model.LastName,
-			Birthday:     &birthdayStr,
-		}
-	}
-	sameUsers, _, err := store.Store().UsersFindBy(ses.Context(), userFilter)
+	sameUsers, err := usersFindSame(ses, model)
 	if err != nil {
 		return false, err
 	}
@@ -366,6 +351,45 @@ func checkExistingAndMerge(ses *utils.Session, model *models.User) (bool, error)
 	return false, nil
 }
 
+// usersFindSame finds users with same first and last name in classroom or, without classroom, with same birthday
+func usersFindSame(ses *utils.Session, model *models.User) ([]*models.User, error) {
+	if model.Birthday == nil || model.FirstName == nil || model.LastName == nil {
+		return nil, nil
+		// return nil, ErrNotSet.SetKey("first_name").SetComment("required fields to check: first_name, last_name, birthday")
+	}
+	var classroomID string
+	// TODO: ClassroomName nirden almaly men???? SORAMALY
+	if model.Classrooms != nil && len(model.Classrooms) > 0 {
+		for _, v := range model.Classrooms {
+			if v.Classroom != nil && v.Classroom.ID != "" {
+				classroomID = v.Classroom.ID
+			}
+		}
+	}
+	var userFilter models.UserFilterRequest
+	if classroomID != "" {
+		userFilter = models.UserFilterRequest{
+			LowFirstName: model.FirstName,
+			LowLastName:  model.LastName,
+			ClassroomId:  &classroomID,
+		}
+	} else if model.Birthday != nil && !model.Birthday.IsZero() {
+		birthdayStr := model.Birthday.Format("2006-01-02")
+		userFilter = models.UserFilterRequest{
+			LowFirstName: model.FirstName,
+			LowLastName:  model.LastName,
+			Birthday:     &birthdayStr,
+		}
+	} else {
+		return nil, nil
+	}
+	sameUsers, _, err := store.Store().UsersFindBy(ses.Context(), userFilter)
+	if err != nil {
+		return nil, err
+	}
+	return sameUsers, nil
+}
+
 type DeleteUserRoleQuery struct {
 	UserId   string `form:"user" json:"user"`
 	SchoolId string `form:"school" json:"school"`
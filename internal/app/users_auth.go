@@ -20,11 +20,19 @@ type UserLoginRequest struct {
 	SchoolID      *string   `json:"school_id" validate:"omitempty"`
 	DeviceToken   *string   `json:"device_token" validate:"omitempty"`
 	RolesPriority *[]string `json:"roles_priority"`
+	// two factor code of authenticator or one of recovery codes
+	TotpCode     *string `json:"totp_code" validate:"omitempty"`
+	RecoveryCode *string `json:"recovery_code" validate:"omitempty"`
+	// set by api for login attempts log
+	Ip    string `json:"-"`
+	Agent string `json:"-"`
 }
 
 var ErrPassword = ErrInvalid.SetKey("password").SetComment("invalid password or user")
 
-func Login(req *UserLoginRequest, isAdmin bool) (*models.User, error) {
+// Login checks password or otp, then two factor when user has it (or role requires it).
+// User is nil when otp is sent or two factor challenge is returned
+func Login(req *UserLoginRequest, isAdmin bool) (*models.User, *models.LoginSecurityResponse, error) {
 	// TODO: user response convert , refactor
 	m, err := store.Store().UsersFindByUsername(context.Background(), *req.Username, req.SchoolID, false)
 	if err != nil {
@@ -32,16 +40,18 @@ func Login(req *UserLoginRequest, isAdmin bool) (*models.User, error) {
 	}
 	if err != nil {
 		if err == pgx.ErrNoRows {
-			return nil, ErrPassword
+			loginAttemptLog(req, nil, false, models.LoginReasonUser)
+			return nil, nil, ErrPassword
 		}
-		return nil, err
+		return nil, nil, err
 	}
 	if m.ID == "" {
-		return nil, ErrPassword
+		loginAttemptLog(req, nil, false, models.LoginReasonUser)
+		return nil, nil, ErrPassword
 	}
 	err = store.Store().UsersLoadRelations(context.Background(), &[]*models.User{&m}, false)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	// roles priority to select login role
@@ -87,7 +97,7 @@ func Login(req *UserLoginRequest, isAdmin bool) (*models.User, error) {
 	}
 	err = store.Store().SchoolsLoadRelations(context.Background(), &sl)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	// when archived disabled logins for non admins
@@ -100,7 +110,7 @@ func Login(req *UserLoginRequest, isAdmin bool) (*models.User, error) {
 			}
 		}
 		if !isOk {
-			return nil, ErrPassword.SetComment("archive server")
+			return nil, nil, ErrPassword.SetComment("archive server")
 		}
 	}
 	// in dev only devs are permitted to login
@@ -110,31 +120,79 @@ func Login(req *UserLoginRequest, isAdmin bool) (*models.User, error) {
 			isOk = true
 		}
 		if !isOk {
-			return nil, ErrPassword.SetComment("dev server")
+			return nil, nil, ErrPassword.SetComment("dev server")
 		}
 	}
 
-	// check password or otp
+	// locked after repeated failures, see loginFailed
+	sec, err := store.Store().UserSecurityFindByUserId(context.Background(), m.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if sec.IsLocked(time.Now()) {
+		loginAttemptLog(req, &m.ID, false, models.LoginReasonLocked)
+		return nil, nil, ErrExceeded.SetKey("login").SetComment("account is locked until " + sec.LockedUntil.In(m.Location()).Format(time.DateTime))
+	}
+
+	// check password or otp, otp is used after second factor as it is sent again with totp code
+	otpCodeId := ""
 	if req.Password != nil {
 		err = LoginByPassword(&m, req)
 		if err != nil {
+			loginFailed(req, &m, sec, models.LoginReasonPassword)
 			if ok := rateLimit("check"+*req.Username, 5, 300); !ok {
-				return nil, ErrExceeded.SetComment("limit exceed (login)")
+				return nil, nil, ErrExceeded.SetComment("limit exceed (login)")
 			}
-			return nil, err
+			return nil, nil, err
 		}
 	} else {
 		isOk := false
-		isOk, err = LoginByOtp(&m, req)
+		otpCodeId, isOk, err = LoginByOtp(&m, req)
+		if err != nil {
+			if req.Otp != nil {
+				loginFailed(req, &m, sec, models.LoginReasonOtp)
+			}
+			return nil, nil, err
+		}
+		if !isOk {
+			return nil, nil, nil
+		}
+	}
+
+	res := &models.LoginSecurityResponse{}
+	if req.Password != nil && twoFactorEnrollRequired(&m, sec) {
+		codeId, isOk, err := loginTwoFactorEnrollConfirm(&m, req, res)
 		if err != nil {
-			return nil, err
+			if req.Otp != nil {
+				loginFailed(req, &m, sec, models.LoginReasonOtp)
+			}
+			return nil, nil, err
 		}
 		if !isOk {
-			return nil, nil
+			return nil, res, nil
+		}
+		otpCodeId = codeId
+	}
+	isOk, err := loginTwoFactorCheck(&m, sec, req, res)
+	if err != nil {
+		loginFailed(req, &m, sec, models.LoginReasonTotp)
+		return nil, nil, err
+	}
+	if !isOk {
+		return nil, res, nil
+	}
+	if otpCodeId != "" {
+		err = store.Store().ConfirmCodeDelete(context.Background(), otpCodeId)
+		if err != nil {
+			return nil, nil, err
 		}
 	}
+	err = loginSucceeded(req, &m, sec, res)
+	if err != nil {
+		return nil, nil, err
+	}
 	// login
-	return &m, nil
+	return &m, res, nil
 }
 
 func LoginByPassword(m *models.User, req *UserLoginRequest) error {
@@ -145,41 +203,42 @@ func LoginByPassword(m *models.User, req *UserLoginRequest) error {
 	return nil
 }
 
-func LoginByOtp(m *models.User, req *UserLoginRequest) (bool, error) {
+// LoginByOtp checks otp (returns id of code to be used) or sends new one
+func LoginByOtp(m *models.User, req *UserLoginRequest) (string, bool, error) {
 	phone, err := m.FormattedPhone()
 	if err != nil {
-		return false, err
+		return "", false, err
 	}
-	// debug phone login, static otp, permit minimal roles
+	// debug phone login, static otp out of prod, permit minimal roles
 	if req.Otp != nil {
 		// rate limit sending
 		if ok := rateLimit("check"+phone, 5, 60); !ok {
-			return false, ErrExceeded.SetComment("limit exceed (check)")
+			return "", false, ErrExceeded.SetComment("limit exceed (check)")
 		}
-		if isPhoneDebug(m, true) && *req.Otp == "13321" {
+		if !config.Conf.AppEnvIsProd && isPhoneDebug(m, true) && *req.Otp == "13321" {
 			// ok, no error
-			return true, nil
-		} else if _, err := store.Store().CheckConfirmCode(context.Background(), m, *req.Otp); err != nil {
+			return "", true, nil
+		} else if codeId, err := store.Store().ConfirmCodeFind(context.Background(), m, *req.Otp); err != nil {
 			if err == pgx.ErrNoRows {
-				return false, ErrInvalid.SetKey("otp")
+				return "", false, ErrInvalid.SetKey("otp")
 			}
-			return false, ErrInvalid.SetKey("otp")
+			return "", false, ErrInvalid.SetKey("otp")
 		} else {
-			return true, nil
+			return codeId, true, nil
 		}
 	} else {
 		// rate limit sending
 		if ok := rateLimit(phone, 3, 60); !ok {
-			return false, ErrExceeded.SetComment("limit exceed")
+			return "", false, ErrExceeded.SetComment("limit exceed")
 		}
 		code, err := store.Store().ConfirmCodeGenerate(context.Background(), m)
 		if err != nil {
-			return false, err
+			return "", false, err
 		}
-		_ = SendSMS([]string{phone}, "emekdep code: "+code, models.SmsTypeOTP)
+		_ = SendSMS([]string{phone}, models.DefaultCountry().SmsText(models.SmsOtp, "code", code), models.SmsTypeOTP)
 	}
 
-	return false, nil
+	return "", false, nil
 }
 
 var UsedCount map[string]int = map[string]int{}
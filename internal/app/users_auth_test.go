@@ -1,6 +1,8 @@
 package app
 
-func TestLogin() {
+import "testing"
+
+func TestLogin(t *testing.T) {
 
 	return
 }
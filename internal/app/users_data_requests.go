@@ -0,0 +1,442 @@
+package app
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/mekdep/server/internal/api/utils"
+	"github.com/mekdep/server/internal/models"
+	"github.com/mekdep/server/internal/store"
+	apputils "github.com/mekdep/server/internal/utils"
+	"github.com/mekdep/server/internal/utils/storage"
+	"go.elastic.co/apm/v2"
+)
+
+// zip of export is downloadable this many days, then file is removed
+const userDataExportDays = 7
+
+// fields of exported rows which keep paths of uploaded files
+var userDataFileFields = []string{"avatar", "document_files", "files", "file"}
+
+var userDataQueue = newJobQueue("UserDataProcess", func(ctx context.Context, id string) (bool, error) {
+	return store.Store().UserDataRequestsClaim(ctx, id)
+}, userDataProcess)
+
+// UserDataRequestCreate is request of user for own data or data of own child,
+// erasure waits approval of admin (see UserDataRequestApprove)
+func UserDataRequestCreate(ses *utils.Session, data models.UserDataRequestRequest) (*models.UserDataRequestResponse, error) {
+	sp, ctx := apm.StartSpan(ses.Context(), "UserDataRequestCreate", "app")
+	ses.SetContext(ctx)
+	defer sp.End()
+	userId := ses.GetUser().ID
+	if data.UserId != nil && *data.UserId != "" && *data.UserId != userId {
+		children, err := UserChildrenGet(ses, ses.GetUser())
+		if err != nil {
+			return nil, err
+		}
+		isChild := false
+		for _, c := range children {
+			if c.ID == *data.UserId {
+				isChild = true
+			}
+		}
+		if !isChild {
+			return nil, ErrForbidden.SetKey("user_id")
+		}
+		userId = *data.UserId
+	}
+	return userDataRequestCreate(ses, userId, data)
+}
+
+// UserDataRequestCreateFor is request made by operator for user of its schools
+func UserDataRequestCreateFor(ses *utils.Session, userId string, data models.UserDataRequestRequest) (*models.UserDataRequestResponse, error) {
+	sp, ctx := apm.StartSpan(ses.Context(), "UserDataRequestCreateFor", "app")
+	ses.SetContext(ctx)
+	defer sp.End()
+	user, err := store.Store().UsersFindById(ses.Context(), userId)
+	if err != nil {
+		return nil, ErrNotfound.SetKey("user_id")
+	}
+	if err = userDataCheckScope(ses, user); err != nil {
+		return nil, err
+	}
+	return userDataRequestCreate(ses, user.ID, data)
+}
+
+func userDataRequestCreate(ses *utils.Session, userId string, data models.UserDataRequestRequest) (*models.UserDataRequestResponse, error) {
+	l, _, err := store.Store().UserDataRequestsFindBy(ses.Context(), models.UserDataRequestFilterRequest{
+		UserId: &userId,
+		Type:   &data.Type,
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range l {
+		if slices.Contains([]string{models.UserDataRequestWait, models.UserDataRequestPending, models.UserDataRequestProcessing}, v.Status) {
+			return nil, ErrUnique.SetKey("type").SetComment("request is already " + v.Status)
+		}
+	}
+	m := &models.UserDataRequest{
+		UserId:      userId,
+		Type:        data.Type,
+		Status:      models.UserDataRequestPending,
+		Reason:      data.Reason,
+		RequestedBy: &ses.GetUser().ID,
+	}
+	if m.Type == models.UserDataRequestErasure {
+		m.Status = models.UserDataRequestWait
+	}
+	m, err = store.Store().UserDataRequestsCreate(ses.Context(), m)
+	if err != nil {
+		return nil, err
+	}
+	if m.Status == models.UserDataRequestPending {
+		UserDataRequestEnqueue(m.ID)
+	}
+	res := &models.UserDataRequestResponse{}
+	res.FromModel(m)
+	return res, nil
+}
+
+// userDataCheckScope allows operator only users of schools of session, parents by schools of children
+func userDataCheckScope(ses *utils.Session, user *models.User) error {
+	if ses.GetRole() == nil {
+		return ErrForbidden.SetKey("user_id")
+	}
+	if *ses.GetRole() == models.RoleAdmin {
+		return nil
+	}
+	err := store.Store().UsersLoadRelations(ses.Context(), &[]*models.User{user}, false)
+	if err != nil {
+		return err
+	}
+	for _, v := range user.Schools {
+		if v.SchoolUid != nil && slices.Contains(ses.SchoolsWithCentersIds(), *v.SchoolUid) {
+			return nil
+		}
+	}
+	return ErrForbidden.SetKey("user_id")
+}
+
+// UserDataRequestApprove lets erasure run, requester can not approve own request
+func UserDataRequestApprove(ses *utils.Session, id string, data models.UserDataRequestReviewRequest) (*models.UserDataRequestResponse, error) {
+	sp, ctx := apm.StartSpan(ses.Context(), "UserDataRequestApprove", "app")
+	ses.SetContext(ctx)
+	defer sp.End()
+	return userDataRequestReview(ses, id, data, models.UserDataRequestPending)
+}
+
+func UserDataRequestReject(ses *utils.Session, id string, data models.UserDataRequestReviewRequest) (*models.UserDataRequestResponse, error) {
+	sp, ctx := apm.StartSpan(ses.Context(), "UserDataRequestReject", "app")
+	ses.SetContext(ctx)
+	defer sp.End()
+	return userDataRequestReview(ses, id, data, models.UserDataRequestRejected)
+}
+
+func userDataRequestReview(ses *utils.Session, id string, data models.UserDataRequestReviewRequest, status string) (*models.UserDataRequestResponse, error) {
+	m, err := store.Store().UserDataRequestsFindById(ses.Context(), id)
+	if err != nil {
+		return nil, ErrNotfound.SetKey("id")
+	}
+	if m.Type != models.UserDataRequestErasure || m.Status != models.UserDataRequestWait {
+		return nil, ErrInvalid.SetKey("status").SetComment("request is not waiting approval")
+	}
+	if m.RequestedBy != nil && *m.RequestedBy == ses.GetUser().ID {
+		return nil, ErrForbidden.SetKey("requested_by").SetComment("own request is approved by other admin")
+	}
+	now := time.Now()
+	m.Status = status
+	m.ReviewedBy = &ses.GetUser().ID
+	m.ReviewedAt = &now
+	if data.Reason != nil {
+		m.Reason = data.Reason
+	}
+	err = store.Store().UserDataRequestsUpdate(ses.Context(), m)
+	if err != nil {
+		return nil, err
+	}
+	if m.Status == models.UserDataRequestPending {
+		UserDataRequestEnqueue(m.ID)
+	}
+	res := &models.UserDataRequestResponse{}
+	res.FromModel(m)
+	return res, nil
+}
+
+func UserDataRequestList(ses *utils.Session, f models.UserDataRequestFilterRequest) ([]*models.UserDataRequestResponse, int, error) {
+	sp, ctx := apm.StartSpan(ses.Context(), "UserDataRequestList", "app")
+	ses.SetContext(ctx)
+	defer sp.End()
+	l, total, err := store.Store().UserDataRequestsFindBy(ses.Context(), f)
+	if err != nil {
+		return nil, 0, err
+	}
+	ids := []string{}
+	for _, m := range l {
+		ids = append(ids, m.UserId)
+	}
+	users, err := userMergeUsers(ses, ids)
+	if err != nil {
+		return nil, 0, err
+	}
+	res := []*models.UserDataRequestResponse{}
+	for _, m := range l {
+		m.User = users[m.UserId]
+		item := models.UserDataRequestResponse{}
+		item.FromModel(m)
+		res = append(res, &item)
+	}
+	return res, total, nil
+}
+
+// UserDataRequestListMe lists requests for user and its children
+func UserDataRequestListMe(ses *utils.Session, f models.UserDataRequestFilterRequest) ([]*models.UserDataRequestResponse, int, error) {
+	ids := []string{ses.GetUser().ID}
+	children, err := UserChildrenGet(ses, ses.GetUser())
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, c := range children {
+		ids = append(ids, c.ID)
+	}
+	f.UserId = nil
+	f.UserIds = &ids
+	f.SchoolIds = nil
+	return UserDataRequestList(ses, f)
+}
+
+// UserDataRequestEnqueue schedules request, if queue is full it stays pending and is picked later by UserDataRequestPending
+func UserDataRequestEnqueue(id string) {
+	userDataQueue.Enqueue(id)
+}
+
+// UserDataRequestWorker runs exports and erasures one by one
+func UserDataRequestWorker() {
+	userDataQueue.Work()
+}
+
+// UserDataRequestPending enqueues requests left pending after restart and removes expired export files
+func UserDataRequestPending() error {
+	ids, err := store.Store().UserDataRequestsPendingList(context.Background(), 20)
+	if err != nil {
+		return err
+	}
+	userDataQueue.EnqueueAll(ids)
+	l, err := store.Store().UserDataRequestsExpiredList(context.Background(), 100)
+	if err != nil {
+		return err
+	}
+	for _, m := range l {
+		err = userDataExpire(context.Background(), m)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func userDataExpire(ctx context.Context, m *models.UserDataRequest) error {
+	if m.File != nil {
+		if err := storage.Delete(ctx, *m.File, "users"); err != nil {
+			apputils.LoggerDesc("In userDataExpire " + m.ID).Error(err)
+		}
+	}
+	m.Status = models.UserDataRequestExpired
+	m.File = nil
+	return store.Store().UserDataRequestsUpdate(ctx, m)
+}
+
+// userDataProcess runs claimed request, see userDataQueue
+func userDataProcess(ctx context.Context, id string) error {
+	m, err := store.Store().UserDataRequestsFindById(ctx, id)
+	if err != nil {
+		return err
+	}
+	if m.Type == models.UserDataRequestExport {
+		err = userDataExport(ctx, m)
+	} else {
+		err = userDataErase(ctx, m)
+	}
+	now := time.Now()
+	m.Status = models.UserDataRequestDone
+	m.DoneAt = &now
+	m.Error = nil
+	if err != nil {
+		errStr := err.Error()
+		m.Status = models.UserDataRequestFailed
+		m.Error = &errStr
+	}
+	return store.Store().UserDataRequestsUpdate(ctx, m)
+}
+
+// userDataExport writes data.json and uploaded files of user into zip, children of parent are in "children/<id>/"
+func userDataExport(ctx context.Context, m *models.UserDataRequest) error {
+	f, err := os.CreateTemp("", "user-data-*.zip")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+	zw := zip.NewWriter(f)
+	dump, err := store.Store().UserDataDump(ctx, m.UserId)
+	if err != nil {
+		return err
+	}
+	if err = userDataWrite(ctx, zw, "", dump); err != nil {
+		return err
+	}
+	children := []string{}
+	links := []struct {
+		ParentUid string `json:"parent_uid"`
+		ChildUid  string `json:"child_uid"`
+	}{}
+	if err = json.Unmarshal(dump["user_parents"], &links); err != nil {
+		return err
+	}
+	for _, v := range links {
+		if v.ParentUid != m.UserId || slices.Contains(children, v.ChildUid) {
+			continue
+		}
+		children = append(children, v.ChildUid)
+		childDump, err := store.Store().UserDataDump(ctx, v.ChildUid)
+		if err != nil {
+			return err
+		}
+		if err = userDataWrite(ctx, zw, "children/"+v.ChildUid+"/", childDump); err != nil {
+			return err
+		}
+	}
+	w, err := zw.Create("manifest.json")
+	if err != nil {
+		return err
+	}
+	err = json.NewEncoder(w).Encode(map[string]interface{}{
+		"user_id":     m.UserId,
+		"children":    children,
+		"request_id":  m.ID,
+		"exported_at": time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	if err = zw.Close(); err != nil {
+		return err
+	}
+	stat, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if _, err = f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	p := "users/exports/" + storage.RandomHash(16) + "/export.zip"
+	if err = storage.Disk().Put(ctx, p, f, stat.Size(), "application/zip"); err != nil {
+		return err
+	}
+	expires := time.Now().AddDate(0, 0, userDataExportDays)
+	m.File = &p
+	m.ExpiresAt = &expires
+	return nil
+}
+
+// userDataWrite puts rows by table and files found in rows, missing files are skipped
+func userDataWrite(ctx context.Context, zw *zip.Writer, prefix string, dump map[string]json.RawMessage) error {
+	w, err := zw.Create(prefix + "data.json")
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err = enc.Encode(dump); err != nil {
+		return err
+	}
+	for _, p := range userDataFiles(dump) {
+		r, err := storage.Disk().Open(ctx, p)
+		if err != nil {
+			apputils.LoggerDesc("In userDataWrite file " + p).Error(err)
+			continue
+		}
+		w, err := zw.Create(prefix + "files/" + p)
+		if err == nil {
+			_, err = io.Copy(w, r)
+		}
+		r.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// userDataFiles returns paths of uploaded files kept in exported rows
+func userDataFiles(dump map[string]json.RawMessage) []string {
+	res := []string{}
+	add := func(v interface{}) {
+		s, ok := v.(string)
+		if !ok || s == "" {
+			return
+		}
+		p, err := storage.CleanPath(storage.PathFromUrl(s))
+		if err != nil || slices.Contains(res, p) {
+			return
+		}
+		res = append(res, p)
+	}
+	for _, rows := range dump {
+		l := []map[string]interface{}{}
+		if err := json.Unmarshal(rows, &l); err != nil {
+			continue
+		}
+		for _, row := range l {
+			for _, k := range userDataFileFields {
+				switch v := row[k].(type) {
+				case []interface{}:
+					for _, vv := range v {
+						add(vv)
+					}
+				default:
+					add(v)
+				}
+			}
+		}
+	}
+	return res
+}
+
+// userDataErase anonymises user, removes its uploaded files and zips of its exports
+func userDataErase(ctx context.Context, m *models.UserDataRequest) error {
+	files, err := store.Store().UserDataErase(ctx, m.UserId)
+	if err != nil {
+		return err
+	}
+	// loaded sessions keep tokens and user as before erase, rows are kept in DB for messages
+	if err := utils.SessionForgetByUserId(m.UserId); err != nil {
+		apputils.LoggerDesc("In userDataErase sessions").Error(err)
+	}
+	for _, p := range files {
+		if err := storage.Delete(ctx, p, "users"); err != nil {
+			apputils.LoggerDesc("In userDataErase file " + p).Error(err)
+		}
+	}
+	exportType, doneStatus, limit := models.UserDataRequestExport, models.UserDataRequestDone, 100
+	l, _, err := store.Store().UserDataRequestsFindBy(ctx, models.UserDataRequestFilterRequest{
+		UserId:            &m.UserId,
+		Type:              &exportType,
+		Status:            &doneStatus,
+		PaginationRequest: models.PaginationRequest{Limit: &limit},
+	})
+	if err != nil {
+		return err
+	}
+	for _, v := range l {
+		if err = userDataExpire(ctx, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package app
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestUserDataFiles(t *testing.T) {
+	dump := map[string]json.RawMessage{
+		"users":              json.RawMessage(`[{"uid": "1", "avatar": "https://mekdep.org/uploads/users/a.jpg?t=1", "document_files": ["users/d1.pdf", "users/d2.pdf"]}]`),
+		"contact_items":      json.RawMessage(`[{"uid": "2", "files": ["contact_files/c.png", "users/d1.pdf"]}, {"uid": "3", "files": null}]`),
+		"user_data_requests": json.RawMessage(`[{"uid": "4", "file": "../etc/passwd"}, {"uid": "5", "file": ""}]`),
+		"user_logs":          json.RawMessage(`{"not": "rows"}`),
+	}
+	got := userDataFiles(dump)
+	want := map[string]bool{"users/a.jpg": true, "users/d1.pdf": true, "users/d2.pdf": true, "contact_files/c.png": true}
+	if len(got) != len(want) {
+		t.Errorf("userDataFiles = %v, want %d files", got, len(want))
+	}
+	for _, p := range got {
+		if !want[p] {
+			t.Errorf("userDataFiles has unexpected file %s", p)
+		}
+	}
+}
+
+func TestUserDataFilesEmpty(t *testing.T) {
+	got := userDataFiles(map[string]json.RawMessage{"users": json.RawMessage(`[]`)})
+	if !reflect.DeepEqual(got, []string{}) {
+		t.Errorf("userDataFiles of empty dump = %v", got)
+	}
+}
@@ -0,0 +1,142 @@
+package app
+
+import (
+	"strings"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/mekdep/server/internal/api/utils"
+	"github.com/mekdep/server/internal/models"
+	"github.com/mekdep/server/internal/store"
+	"github.com/mekdep/server/internal/utils/mail"
+	"go.elastic.co/apm/v2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var ErrEmailToken = ErrInvalid.SetKey("token").SetComment("invalid or expired link")
+
+// UserEmailVerifySend sends verification link to current email of user
+func UserEmailVerifySend(ses *utils.Session, data models.UserEmailVerifySendRequest) error {
+	sp, ctx := apm.StartSpan(ses.Context(), "UserEmailVerifySend", "app")
+	ses.SetContext(ctx)
+	defer sp.End()
+	user, err := store.Store().UsersFindById(ses.Context(), ses.GetUser().ID)
+	if err != nil {
+		return err
+	}
+	if user.Email == nil || strings.TrimSpace(*user.Email) == "" {
+		return ErrRequired.SetKey("email")
+	}
+	if user.EmailVerifiedAt != nil {
+		return ErrInvalid.SetKey("email").SetComment("already verified")
+	}
+	if ok := rateLimit("email_verify"+user.ID, 3, 600); !ok {
+		return ErrExceeded.SetComment("limit exceed (email)")
+	}
+	token, err := mailTokenCreate(ses.Context(), user, models.EmailTokenVerify, emailVerifyExpire)
+	if err != nil {
+		return err
+	}
+	return mailSend(*user.Email, mail.TemplateVerifyEmail, stringOrEmpty(data.Lang), map[string]interface{}{
+		"Name":         user.FullName(),
+		"Link":         mailLink(mailLinkVerify + token),
+		"ExpiresHours": int(emailVerifyExpire.Hours()),
+	})
+}
+
+// UserEmailVerify sets EmailVerifiedAt by link token, link of old email does not verify new one
+func UserEmailVerify(ses *utils.Session, data models.UserEmailVerifyRequest) (*models.User, error) {
+	sp, ctx := apm.StartSpan(ses.Context(), "UserEmailVerify", "app")
+	ses.SetContext(ctx)
+	defer sp.End()
+	t, err := store.Store().EmailTokensUse(ses.Context(), mailTokenHash(data.Token), models.EmailTokenVerify)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, ErrEmailToken
+		}
+		return nil, err
+	}
+	ok, err := store.Store().UsersUpdateEmailVerified(ses.Context(), t.UserId, t.Email)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrEmailToken.SetComment("email changed")
+	}
+	return store.Store().UsersFindById(ses.Context(), t.UserId)
+}
+
+// UserPasswordResetSend sends reset link to verified email of user,
+// unknown logins are not reported to not reveal which accounts exist
+func UserPasswordResetSend(ses *utils.Session, data models.UserPasswordResetSendRequest) error {
+	sp, ctx := apm.StartSpan(ses.Context(), "UserPasswordResetSend", "app")
+	ses.SetContext(ctx)
+	defer sp.End()
+	if ok := rateLimit("password_reset"+data.Username, 3, 600); !ok {
+		return ErrExceeded.SetComment("limit exceed (email)")
+	}
+	user, err := store.Store().UsersFindByUsername(ses.Context(), data.Username, nil, false)
+	if err != nil {
+		user, err = store.Store().UsersFindByUsername(ses.Context(), data.Username, nil, true)
+	}
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil
+		}
+		return err
+	}
+	if user.ID == "" || user.Email == nil || user.EmailVerifiedAt == nil {
+		return nil
+	}
+	token, err := mailTokenCreate(ses.Context(), &user, models.EmailTokenPasswordReset, emailPasswordResetExpire)
+	if err != nil {
+		return err
+	}
+	return mailSend(*user.Email, mail.TemplatePasswordReset, stringOrEmpty(data.Lang), map[string]interface{}{
+		"Name":         user.FullName(),
+		"Link":         mailLink(mailLinkPasswordReset + token),
+		"ExpiresHours": int(emailPasswordResetExpire.Hours()),
+	})
+}
+
+// UserPasswordReset sets new password by link token and closes all sessions of user
+func UserPasswordReset(ses *utils.Session, data models.UserPasswordResetRequest) (*models.User, error) {
+	sp, ctx := apm.StartSpan(ses.Context(), "UserPasswordReset", "app")
+	ses.SetContext(ctx)
+	defer sp.End()
+	t, err := store.Store().EmailTokensUse(ses.Context(), mailTokenHash(data.Token), models.EmailTokenPasswordReset)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, ErrEmailToken
+		}
+		return nil, err
+	}
+	user, err := store.Store().UsersFindById(ses.Context(), t.UserId)
+	if err != nil {
+		return nil, err
+	}
+	if user.Email == nil || *user.Email != t.Email {
+		return nil, ErrEmailToken.SetComment("email changed")
+	}
+	tmp, err := bcrypt.GenerateFromPassword([]byte(data.NewPassword), 0)
+	if err != nil {
+		return nil, err
+	}
+	user.Password = new(string)
+	*user.Password = string(tmp)
+	user, err = store.Store().UserUpdate(ses.Context(), user)
+	if err != nil {
+		return nil, err
+	}
+	err = utils.SessionDeleteByUserId(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func stringOrEmpty(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
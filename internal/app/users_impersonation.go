@@ -0,0 +1,116 @@
+package app
+
+import (
+	"slices"
+	"time"
+
+	"github.com/mekdep/server/internal/api/utils"
+	"github.com/mekdep/server/internal/models"
+	"github.com/mekdep/server/internal/store"
+	"go.elastic.co/apm/v2"
+)
+
+const impersonationDefaultMinutes = 30
+
+// roles which can not be impersonated, their sessions can see more than impersonator
+var impersonationDeniedRoles = []models.Role{models.RoleAdmin, models.RoleOperator, models.RoleOrganization}
+
+// ImpersonationStart checks that user has role in school to be viewed as, returns user with role and session length
+func ImpersonationStart(ses *utils.Session, data models.ImpersonationRequest) (*models.User, *models.UserSchool, time.Duration, error) {
+	sp, ctx := apm.StartSpan(ses.Context(), "ImpersonationStart", "app")
+	ses.SetContext(ctx)
+	defer sp.End()
+	if ses.IsImpersonated() {
+		return nil, nil, 0, ErrForbidden.SetComment("impersonation is read only")
+	}
+	if data.UserId == nil || *data.UserId == ses.GetUser().ID {
+		return nil, nil, 0, ErrInvalid.SetKey("user_id")
+	}
+	role := models.Role(*data.RoleCode)
+	if slices.Contains(impersonationDeniedRoles, role) {
+		return nil, nil, 0, ErrForbidden.SetKey("role_code")
+	}
+	if data.SchoolId != nil && *data.SchoolId == "" {
+		data.SchoolId = nil
+	}
+	user, err := store.Store().UsersFindById(ses.Context(), *data.UserId)
+	if err != nil {
+		return nil, nil, 0, ErrNotfound.SetKey("user_id")
+	}
+	err = store.Store().UsersLoadRelations(ses.Context(), &[]*models.User{user}, false)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	var userSchool *models.UserSchool
+	for _, v := range user.Schools {
+		if v.RoleCode != role {
+			continue
+		}
+		if data.SchoolId == nil || v.SchoolUid != nil && *v.SchoolUid == *data.SchoolId {
+			userSchool = v
+			break
+		}
+	}
+	if userSchool == nil {
+		return nil, nil, 0, ErrNotfound.SetKey("role_code").SetComment("user has no role " + string(role) + " in school")
+	}
+	if err = impersonationCheckScope(ses.GetRole(), ses.SchoolsWithCentersIds(), userSchool); err != nil {
+		return nil, nil, 0, err
+	}
+	minutes := impersonationDefaultMinutes
+	if data.Minutes != nil {
+		minutes = *data.Minutes
+	}
+	return user, userSchool, time.Duration(minutes) * time.Minute, nil
+}
+
+// impersonationCheckScope lets operator view only users of schools available in session,
+// role without school (e.g. parent not linked to school) is viewed only by admin
+func impersonationCheckScope(role *models.Role, schoolIds []string, userSchool *models.UserSchool) error {
+	if role == nil {
+		return ErrForbidden.SetKey("school_id")
+	}
+	if *role == models.RoleAdmin {
+		return nil
+	}
+	if userSchool.SchoolUid == nil || !slices.Contains(schoolIds, *userSchool.SchoolUid) {
+		return ErrForbidden.SetKey("school_id")
+	}
+	return nil
+}
+
+// impersonationCheckWrite lets impersonated session only view: write permission
+// and requests other than GET and HEAD (own profile, password, sessions) are blocked
+func impersonationCheckWrite(isWrite bool, isReadRequest bool) error {
+	if isWrite || !isReadRequest {
+		return ErrForbidden.SetComment("impersonation is read only")
+	}
+	return nil
+}
+
+// impersonationLog records request of impersonated session by real actor, blocked is set for change attempts
+func impersonationLog(ses *utils.Session, p Permission, isBlocked bool) {
+	userId := ""
+	if ses.GetUser() != nil {
+		userId = ses.GetUser().ID
+	}
+	desc := ses.GetRequest()
+	var schoolId *string
+	if v := ses.GetClaimSchoolId(); v != "" {
+		schoolId = &v
+	}
+	_ = userLog(ses, models.UserLog{
+		SchoolId:           schoolId,
+		SessionId:          ses.GetSessionId(),
+		UserId:             *ses.GetImpersonatorId(),
+		SubjectId:          &userId,
+		Subject:            models.LogSubjectImpersonation,
+		SubjectAction:      models.LogActionView,
+		SubjectDescription: &desc,
+		SubjectProperties: map[string]interface{}{
+			"role_code":  ses.GetRole(),
+			"permission": p,
+			"is_blocked": isBlocked,
+		},
+	})
+}
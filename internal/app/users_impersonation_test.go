@@ -0,0 +1,53 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/mekdep/server/internal/models"
+)
+
+func TestImpersonationCheckScope(t *testing.T) {
+	schoolId := "c2d5b3a0-0000-0000-0000-000000000001"
+	otherId := "c2d5b3a0-0000-0000-0000-000000000002"
+	admin := models.RoleAdmin
+	operator := models.RoleOperator
+	cases := []struct {
+		name   string
+		role   *models.Role
+		school *string
+		ok     bool
+	}{
+		{"operator in own school", &operator, &schoolId, true},
+		{"operator in other school", &operator, &otherId, false},
+		{"operator and role without school", &operator, nil, false},
+		{"admin and role without school", &admin, nil, true},
+		{"admin in other school", &admin, &otherId, true},
+		{"no role", nil, &schoolId, false},
+	}
+	for _, c := range cases {
+		err := impersonationCheckScope(c.role, []string{schoolId}, &models.UserSchool{SchoolUid: c.school})
+		if (err == nil) != c.ok {
+			t.Errorf("%s: got %v", c.name, err)
+		}
+	}
+}
+
+func TestImpersonationCheckWrite(t *testing.T) {
+	cases := []struct {
+		name          string
+		isWrite       bool
+		isReadRequest bool
+		ok            bool
+	}{
+		{"view by read permission", false, true, true},
+		{"own action by read permission", false, false, false},
+		{"view by write permission", true, true, false},
+		{"change by write permission", true, false, false},
+	}
+	for _, c := range cases {
+		err := impersonationCheckWrite(c.isWrite, c.isReadRequest)
+		if (err == nil) != c.ok {
+			t.Errorf("%s: got %v", c.name, err)
+		}
+	}
+}
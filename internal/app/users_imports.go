@@ -0,0 +1,664 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mekdep/server/internal/api/utils"
+	"github.com/mekdep/server/internal/models"
+	"github.com/mekdep/server/internal/store"
+	"github.com/xuri/excelize/v2"
+	"go.elastic.co/apm/v2"
+)
+
+const userImportMaxRows = 5000
+
+// report of running commit is saved after this many rows, restarted job continues from there
+const userImportSaveEvery = 100
+
+var userImportQueue = newJobQueue("UserImportProcess", func(ctx context.Context, id string) (bool, error) {
+	return store.Store().UserImportsClaim(ctx, id)
+}, userImportProcess)
+
+// UserImportValidate returns errors of mapped user, api gives app_validation.ValidateUser as it imports app
+type UserImportValidate func(dto models.UserRequest) ([]models.UserImportError, error)
+
+// UserImportUpload reads xlsx or csv file into draft import of session school, columns are mapped by their headers
+func UserImportUpload(ses *utils.Session, importType string, fileName string, r io.Reader) (*models.UserImportResponse, error) {
+	sp, ctx := apm.StartSpan(ses.Context(), "UserImportUpload", "app")
+	ses.SetContext(ctx)
+	defer sp.End()
+	if _, ok := models.UserImportFields[importType]; !ok {
+		return nil, ErrInvalid.SetKey("type")
+	}
+	if ses.GetSchoolId() == nil {
+		return nil, ErrRequired.SetKey("school_id")
+	}
+	rows, err := userImportReadFile(fileName, r)
+	if err != nil {
+		return nil, ErrInvalid.SetKey("file").SetComment(err.Error())
+	}
+	if len(rows) < 2 {
+		return nil, ErrInvalid.SetKey("file").SetComment("file has no rows")
+	}
+	if len(rows)-1 > userImportMaxRows {
+		return nil, ErrInvalid.SetKey("file").SetComment(fmt.Sprintf("maximum %d rows", userImportMaxRows))
+	}
+	m := &models.UserImport{
+		SchoolId: *ses.GetSchoolId(),
+		Type:     importType,
+		FileName: &fileName,
+		Columns:  rows[0],
+		Rows:     rows[1:],
+		Mapping:  models.UserImportMappingGuess(importType, rows[0]),
+		Report:   []models.UserImportRow{},
+		Status:   models.UserImportDraft,
+	}
+	if ses.GetUser() != nil {
+		m.CreatedBy = &ses.GetUser().ID
+	}
+	m, err = store.Store().UserImportsCreate(ses.Context(), m)
+	if err != nil {
+		return nil, err
+	}
+	res := &models.UserImportResponse{}
+	res.FromModel(m)
+	return res, nil
+}
+
+// UserImportDryRun saves mapping and reports what commit does with each row, nothing is changed
+func UserImportDryRun(ses *utils.Session, id string, data models.UserImportMappingRequest, validate UserImportValidate) (*models.UserImportResponse, error) {
+	sp, ctx := apm.StartSpan(ses.Context(), "UserImportDryRun", "app")
+	ses.SetContext(ctx)
+	defer sp.End()
+	m, err := userImportFind(ses, id)
+	if err != nil {
+		return nil, err
+	}
+	if m.Status != models.UserImportDraft {
+		return nil, ErrInvalid.SetKey("status").SetComment(m.Status)
+	}
+	if data.Mapping != nil {
+		for field, column := range data.Mapping {
+			if !slices.Contains(models.UserImportFields[m.Type], field) {
+				return nil, ErrInvalid.SetKey("mapping." + field)
+			}
+			if column < 0 || column >= len(m.Columns) {
+				return nil, ErrInvalid.SetKey("mapping." + field).SetComment("file has no such column")
+			}
+		}
+		m.Mapping = data.Mapping
+	}
+	required := []string{"first_name", "last_name"}
+	if m.Type == models.UserImportTypeStudents {
+		required = append(required, "classroom_name")
+	}
+	for _, field := range required {
+		if _, ok := m.Mapping[field]; !ok {
+			return nil, ErrRequired.SetKey("mapping." + field)
+		}
+	}
+	m.Report, err = userImportPlan(ses, m, validate)
+	if err != nil {
+		return nil, err
+	}
+	err = store.Store().UserImportsUpdate(ses.Context(), m)
+	if err != nil {
+		return nil, err
+	}
+	res := &models.UserImportResponse{}
+	res.FromModel(m)
+	return res, nil
+}
+
+// UserImportCommit checks rows again and sends import to background job,
+// rows with errors and conflicts are skipped
+func UserImportCommit(ses *utils.Session, id string, validate UserImportValidate) (*models.UserImportResponse, error) {
+	sp, ctx := apm.StartSpan(ses.Context(), "UserImportCommit", "app")
+	ses.SetContext(ctx)
+	defer sp.End()
+	m, err := userImportFind(ses, id)
+	if err != nil {
+		return nil, err
+	}
+	if m.Status != models.UserImportDraft {
+		return nil, ErrInvalid.SetKey("status").SetComment(m.Status)
+	}
+	if len(m.Report) < 1 {
+		return nil, ErrRequired.SetKey("report").SetComment("dry run is required before commit")
+	}
+	// data could be changed since dry run
+	m.Report, err = userImportPlan(ses, m, validate)
+	if err != nil {
+		return nil, err
+	}
+	m.Status = models.UserImportPending
+	err = store.Store().UserImportsUpdate(ses.Context(), m)
+	if err != nil {
+		return nil, err
+	}
+	UserImportEnqueue(m.ID)
+	res := &models.UserImportResponse{}
+	res.FromModel(m)
+	return res, nil
+}
+
+// UserImportRollback removes users and relations added by commit of batch, returns count of deleted users
+func UserImportRollback(ses *utils.Session, id string) (*models.UserImportResponse, int, error) {
+	sp, ctx := apm.StartSpan(ses.Context(), "UserImportRollback", "app")
+	ses.SetContext(ctx)
+	defer sp.End()
+	m, err := userImportFind(ses, id)
+	if err != nil {
+		return nil, 0, err
+	}
+	if m.Status != models.UserImportDone && m.Status != models.UserImportFailed {
+		return nil, 0, ErrInvalid.SetKey("status").SetComment(m.Status)
+	}
+	deleted, err := store.Store().UserImportsRollback(ses.Context(), m.ID)
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, row := range m.Report {
+		userImportCacheClear(ses, row)
+	}
+	m, err = store.Store().UserImportsFindById(ses.Context(), m.ID)
+	if err != nil {
+		return nil, 0, err
+	}
+	res := &models.UserImportResponse{}
+	res.FromModel(m)
+	return res, deleted, nil
+}
+
+func UserImportDetail(ses *utils.Session, id string) (*models.UserImportResponse, error) {
+	sp, ctx := apm.StartSpan(ses.Context(), "UserImportDetail", "app")
+	ses.SetContext(ctx)
+	defer sp.End()
+	m, err := userImportFind(ses, id)
+	if err != nil {
+		return nil, err
+	}
+	res := &models.UserImportResponse{}
+	res.FromModel(m)
+	return res, nil
+}
+
+func UserImportList(ses *utils.Session, f models.UserImportFilterRequest) ([]*models.UserImportResponse, int, error) {
+	sp, ctx := apm.StartSpan(ses.Context(), "UserImportList", "app")
+	ses.SetContext(ctx)
+	defer sp.End()
+	l, total, err := store.Store().UserImportsFindBy(ses.Context(), f)
+	if err != nil {
+		return nil, 0, err
+	}
+	res := []*models.UserImportResponse{}
+	for _, m := range l {
+		item := models.UserImportResponse{}
+		item.FromModel(m)
+		// report is shown in detail
+		item.Report = nil
+		res = append(res, &item)
+	}
+	return res, total, nil
+}
+
+// UserImportEnqueue schedules commit, if queue is full import stays pending and is picked later by UserImportPending
+func UserImportEnqueue(id string) {
+	userImportQueue.Enqueue(id)
+}
+
+// UserImportWorker commits imports one by one
+func UserImportWorker() {
+	userImportQueue.Work()
+}
+
+// UserImportPending enqueues imports left pending after restart or stuck in processing
+func UserImportPending() error {
+	ids, err := store.Store().UserImportsPendingList(context.Background(), 20)
+	if err != nil {
+		return err
+	}
+	userImportQueue.EnqueueAll(ids)
+	return nil
+}
+
+// userImportProcess commits claimed import, see userImportQueue
+func userImportProcess(ctx context.Context, id string) error {
+	m, err := store.Store().UserImportsFindById(ctx, id)
+	if err != nil {
+		return err
+	}
+	ses := &utils.Session{}
+	ses.SetContext(ctx)
+	// parents created in this batch by phone, brothers and sisters get same parent
+	parents := map[string]*models.User{}
+	committed, failed := 0, 0
+	var lastErr error
+	for k := range m.Report {
+		row := &m.Report[k]
+		if row.Action != models.UserImportActionCreate && row.Action != models.UserImportActionMerge {
+			continue
+		}
+		err := userImportCommitRow(ctx, m, row, parents)
+		if err != nil {
+			row.Action = models.UserImportActionError
+			row.Errors = append(row.Errors, models.UserImportError{Code: "commit", Comment: err.Error()})
+			lastErr = err
+			failed++
+		} else {
+			committed++
+			userImportCacheClear(ses, *row)
+		}
+		if (committed+failed)%userImportSaveEvery == 0 {
+			if err := store.Store().UserImportsUpdate(ctx, m); err != nil {
+				return err
+			}
+		}
+	}
+	now := time.Now()
+	m.CommittedAt = &now
+	m.Status = models.UserImportDone
+	if lastErr != nil {
+		msg := fmt.Sprintf("%d rows are not committed, last error: %s", failed, lastErr.Error())
+		m.Error = &msg
+		if committed < 1 {
+			m.Status = models.UserImportFailed
+		}
+	}
+	return store.Store().UserImportsUpdate(ctx, m)
+}
+
+// userImportCommitRow creates or merges user of row with parents, ids are kept in row
+// so restarted job does not create them again
+func userImportCommitRow(ctx context.Context, m *models.UserImport, row *models.UserImportRow, parents map[string]*models.User) error {
+	role := models.RoleTeacher
+	if m.Type == models.UserImportTypeStudents {
+		role = models.RoleStudent
+	}
+	user, err := userImportModel(row)
+	if err != nil {
+		return err
+	}
+	rowParents := []*models.UserImportRow{}
+	l := []*models.User{}
+	phones := []string{}
+	for k := range row.Parents {
+		p := &row.Parents[k]
+		if p.Action != models.UserImportActionCreate && p.Action != models.UserImportActionMerge {
+			continue
+		}
+		var pu *models.User
+		if p.UserId == nil && p.User.Phone != nil {
+			pu = parents[*p.User.Phone]
+		}
+		if pu == nil {
+			pu, err = userImportModel(p)
+			if err != nil {
+				return err
+			}
+			if pu.ID == "" && p.User.Phone != nil {
+				parents[*p.User.Phone] = pu
+				phones = append(phones, *p.User.Phone)
+			}
+		}
+		rowParents = append(rowParents, p)
+		l = append(l, pu)
+	}
+	err = store.Store().UserImportsCommitRow(ctx, m.ID, m.SchoolId, role, user, row.ClassroomId, l)
+	if err != nil {
+		// ids of rolled back transaction are not valid
+		for _, phone := range phones {
+			delete(parents, phone)
+		}
+		return err
+	}
+	row.UserId = &user.ID
+	for k, p := range rowParents {
+		p.UserId = &l[k].ID
+	}
+	return nil
+}
+
+// userImportModel is existing user of row by id or new one with random username and password
+func userImportModel(row *models.UserImportRow) (*models.User, error) {
+	if row.UserId != nil {
+		return &models.User{ID: *row.UserId}, nil
+	}
+	dto := row.User
+	setUserDefaults(&dto)
+	model := &models.User{}
+	dto.ToModel(model)
+	err := encryptPassword(model, &dto)
+	if err != nil {
+		return nil, err
+	}
+	return model, nil
+}
+
+func userImportCacheClear(ses *utils.Session, row models.UserImportRow) {
+	if row.UserId != nil {
+		UserCacheClear(ses, *row.UserId)
+	}
+	for _, p := range row.Parents {
+		if p.UserId != nil {
+			UserCacheClear(ses, *p.UserId)
+		}
+	}
+}
+
+func userImportFind(ses *utils.Session, id string) (*models.UserImport, error) {
+	m, err := store.Store().UserImportsFindById(ses.Context(), id)
+	if err != nil {
+		return nil, ErrNotfound.SetKey("id")
+	}
+	if schoolId := ses.GetSchoolIdByFilter(&m.SchoolId); schoolId == nil || *schoolId != m.SchoolId {
+		return nil, ErrForbidden.SetKey("school_id")
+	}
+	return m, nil
+}
+
+// userImportPlan maps rows by mapping, validates them and finds same users in database and in file
+func userImportPlan(ses *utils.Session, m *models.UserImport, validate UserImportValidate) ([]models.UserImportRow, error) {
+	classrooms := map[string]*models.Classroom{}
+	if m.Type == models.UserImportTypeStudents {
+		limit := 1000
+		l, _, err := store.Store().ClassroomsFindBy(ses.Context(), models.ClassroomFilterRequest{
+			SchoolId:          &m.SchoolId,
+			PaginationRequest: models.PaginationRequest{Limit: &limit},
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range l {
+			if c.ArchivedAt == nil && c.Name != nil {
+				classrooms[userImportKey(*c.Name)] = c
+			}
+		}
+	}
+	role := models.RoleTeacher
+	if m.Type == models.UserImportTypeStudents {
+		role = models.RoleStudent
+	}
+	report := []models.UserImportRow{}
+	// same user or parent above in file
+	lines := map[string]int{}
+	parentLines := map[string]int{}
+	for k, values := range m.Rows {
+		if strings.Join(values, "") == "" {
+			continue
+		}
+		row := models.UserImportRow{
+			Line:    k + 2,
+			User:    userImportRequest(m, values, "", role),
+			Parents: []models.UserImportRow{},
+			Errors:  []models.UserImportError{},
+		}
+		if m.Type == models.UserImportTypeStudents && row.User.ClassroomName != nil {
+			c, ok := classrooms[userImportKey(*row.User.ClassroomName)]
+			if ok {
+				row.ClassroomId = &c.ID
+				row.User.ClassroomIds = &[]models.UserClassroomRequest{{ClassroomId: &c.ID}}
+			} else {
+				row.Errors = append(row.Errors, models.UserImportError{Key: "classroom_name", Code: ErrNotfound.Code(), Comment: *row.User.ClassroomName})
+			}
+		}
+		err := userImportCheck(ses, m.SchoolId, &row, role, validate)
+		if err != nil {
+			return nil, err
+		}
+		if row.User.FirstName != nil && row.User.LastName != nil {
+			key := userImportKey(*row.User.FirstName + *row.User.LastName)
+			if row.User.Birthday != nil {
+				key += *row.User.Birthday
+			}
+			if row.ClassroomId != nil {
+				key += *row.ClassroomId
+			}
+			if line, ok := lines[key]; ok && row.Action != models.UserImportActionError {
+				userImportConflict(&row, fmt.Sprintf("same user is in line %d", line))
+			}
+			lines[key] = row.Line
+		}
+
+		for i := 1; m.Type == models.UserImportTypeStudents && i <= models.UserImportParentsCount; i++ {
+			p := models.UserImportRow{
+				Line:   row.Line,
+				User:   userImportRequest(m, values, "parent"+strconv.Itoa(i)+"_", models.RoleParent),
+				Errors: []models.UserImportError{},
+			}
+			if p.User.FirstName == nil && p.User.LastName == nil && p.User.Phone == nil {
+				continue
+			}
+			err := userImportCheck(ses, m.SchoolId, &p, models.RoleParent, validate)
+			if err != nil {
+				return nil, err
+			}
+			for _, e := range p.Errors {
+				e.Key = "parents." + strconv.Itoa(i) + "." + e.Key
+				row.Errors = append(row.Errors, e)
+			}
+			if p.Action == models.UserImportActionConflict {
+				userImportConflict(&row, "parent "+strconv.Itoa(i)+": "+*p.Comment)
+			} else if p.User.Phone != nil {
+				if line, ok := parentLines[*p.User.Phone]; ok {
+					comment := fmt.Sprintf("same parent is in line %d", line)
+					p.Comment = &comment
+				} else {
+					parentLines[*p.User.Phone] = row.Line
+				}
+			}
+			row.Parents = append(row.Parents, p)
+		}
+		if len(row.Errors) > 0 {
+			row.Action = models.UserImportActionError
+		}
+		report = append(report, row)
+	}
+	return report, nil
+}
+
+// userImportCheck finds same user of row (create, merge or conflict) and validates it
+func userImportCheck(ses *utils.Session, schoolId string, row *models.UserImportRow, role models.Role, validate UserImportValidate) error {
+	model := &models.User{}
+	row.User.ToModel(model)
+	if row.User.Birthday != nil && model.Birthday == nil {
+		row.Errors = append(row.Errors, models.UserImportError{Key: "birthday", Code: ErrInvalid.Code(), Comment: *row.User.Birthday})
+	}
+	same, err := usersFindSame(ses, model)
+	if err != nil {
+		return err
+	}
+	// parents and teachers are same by phone in school
+	if len(same) < 1 && row.User.Phone != nil && role != models.RoleStudent {
+		f := models.UserFilterRequest{Phone: row.User.Phone, SchoolId: &schoolId}
+		if role == models.RoleParent {
+			f.Roles = &[]string{string(models.RoleParent)}
+		}
+		same, _, err = store.Store().UsersFindBy(ses.Context(), f)
+		if err != nil {
+			return err
+		}
+	}
+	ids := []string{}
+	for _, u := range same {
+		if !slices.Contains(ids, u.ID) {
+			ids = append(ids, u.ID)
+		}
+	}
+	row.Action = models.UserImportActionCreate
+	if len(ids) > 1 {
+		userImportConflict(row, fmt.Sprintf("%d users are same", len(ids)))
+	} else if len(ids) == 1 {
+		row.Action = models.UserImportActionMerge
+		row.UserId = &ids[0]
+		if role == models.RoleStudent && row.ClassroomId != nil {
+			isOther, err := userImportInOtherClassroom(ses, schoolId, ids[0], *row.ClassroomId)
+			if err != nil {
+				return err
+			}
+			if isOther {
+				userImportConflict(row, "student is in other classroom of school")
+			}
+		}
+	}
+
+	dto := row.User
+	dto.ID = row.UserId
+	errs, err := validate(dto)
+	if err != nil {
+		return err
+	}
+	row.Errors = append(row.Errors, errs...)
+	if len(row.Errors) > 0 {
+		row.Action = models.UserImportActionError
+	}
+	return nil
+}
+
+// userImportInOtherClassroom checks if student of school is not in classroom of row, import doesn't move students
+func userImportInOtherClassroom(ses *utils.Session, schoolId string, userId string, classroomId string) (bool, error) {
+	role := string(models.RoleStudent)
+	l, _, err := store.Store().UsersFindBy(ses.Context(), models.UserFilterRequest{ID: &userId, SchoolId: &schoolId, Role: &role})
+	if err != nil || len(l) < 1 {
+		return false, err
+	}
+	l, _, err = store.Store().UsersFindBy(ses.Context(), models.UserFilterRequest{ID: &userId, ClassroomId: &classroomId})
+	if err != nil {
+		return false, err
+	}
+	return len(l) < 1, nil
+}
+
+func userImportConflict(row *models.UserImportRow, comment string) {
+	row.Action = models.UserImportActionConflict
+	row.Comment = &comment
+}
+
+// userImportRequest maps values of row by mapping, prefix is of parent fields
+func userImportRequest(m *models.UserImport, values []string, prefix string, role models.Role) models.UserRequest {
+	value := func(field string) *string {
+		k, ok := m.Mapping[prefix+field]
+		if !ok || k >= len(values) || values[k] == "" {
+			return nil
+		}
+		v := values[k]
+		return &v
+	}
+	return models.UserRequest{
+		FirstName:       value("first_name"),
+		LastName:        value("last_name"),
+		MiddleName:      value("middle_name"),
+		Birthday:        value("birthday"),
+		Gender:          userImportGender(value("gender")),
+		Phone:           userImportPhone(value("phone")),
+		Email:           value("email"),
+		Address:         value("address"),
+		BirthCertNumber: value("birth_cert_number"),
+		PassportNumber:  value("passport_number"),
+		WorkTitle:       value("work_title"),
+		EducationTitle:  value("education_title"),
+		ClassroomName:   value("classroom_name"),
+		SchoolIds:       &[]models.UserSchoolRequest{{SchoolUid: &m.SchoolId, RoleCode: &role}},
+	}
+}
+
+var userImportDigits = regexp.MustCompile("[^0-9]")
+
+// userImportPhone formats phone as E.164: 65123456, 8 65 123456 -> +99365123456, invalid one is left as digits for validation
+func userImportPhone(v *string) *string {
+	if v == nil {
+		return nil
+	}
+	phone, err := models.PhoneE164(*v)
+	if err != nil {
+		phone = userImportDigits.ReplaceAllString(*v, "")
+	}
+	return &phone
+}
+
+func userImportGender(v *string) *int {
+	if v == nil {
+		return nil
+	}
+	var g models.Gender
+	switch strings.ToLower(*v) {
+	case "1", "m", "male", "oglan", "erkek", "м", "муж", "мужской":
+		g = models.GenderMale
+	case "2", "f", "female", "gyz", "aýal", "ж", "жен", "женский":
+		g = models.GenderFemale
+	default:
+		// guessed by last name, see UserRequest.Format
+		return nil
+	}
+	res := int(g)
+	return &res
+}
+
+// userImportKey compares names of file: "5 A", "5a" and "5-A" are same classroom
+func userImportKey(s string) string {
+	return strings.ToLower(strings.NewReplacer(" ", "", "-", "", "\"", "").Replace(s))
+}
+
+func userImportReadFile(fileName string, r io.Reader) ([][]string, error) {
+	rows := [][]string{}
+	switch strings.ToLower(path.Ext(fileName)) {
+	case ".xlsx":
+		f, err := excelize.OpenReader(r)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		rows, err = f.GetRows(f.GetSheetName(0))
+		if err != nil {
+			return nil, err
+		}
+	case ".csv":
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+		cr := csv.NewReader(bytes.NewReader(data))
+		// excel saves csv with semicolons in some locales
+		header, _, _ := bytes.Cut(data, []byte("\n"))
+		if bytes.Count(header, []byte(";")) > bytes.Count(header, []byte(",")) {
+			cr.Comma = ';'
+		}
+		cr.FieldsPerRecord = -1
+		cr.LazyQuotes = true
+		for {
+			record, err := cr.Read()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			// empty lines are skipped by reader, lines of report should be as in file
+			line, _ := cr.FieldPos(0)
+			for len(rows) < line-1 {
+				rows = append(rows, []string{})
+			}
+			rows = append(rows, record)
+		}
+	default:
+		return nil, errors.New("only xlsx and csv files are imported")
+	}
+	for k, row := range rows {
+		for kk, v := range row {
+			rows[k][kk] = strings.TrimSpace(v)
+		}
+	}
+	return rows, nil
+}
@@ -0,0 +1,393 @@
+package app
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/mekdep/server/internal/api/utils"
+	"github.com/mekdep/server/internal/models"
+	"github.com/mekdep/server/internal/store"
+	apputils "github.com/mekdep/server/internal/utils"
+	"go.elastic.co/apm/v2"
+)
+
+// names like "Amanow" and "Amanov" are similar, short names are compared only for equality
+const userDuplicateNameDistance = 2
+const userDuplicateNameMinLength = 6
+
+// users sharing too common value (first name, birthday) are not compared all with all
+const userDuplicateBucketMax = 200
+
+var userDuplicateNonLetters = regexp.MustCompile(`[^a-z]+`)
+var userDuplicateNonAlnum = regexp.MustCompile(`[^A-Z0-9]+`)
+
+// UserDuplicateScan finds pairs of students and parents of school which look like same person and puts them into review queue
+func UserDuplicateScan(ses *utils.Session, schoolId *string) (int, error) {
+	sp, ctx := apm.StartSpan(ses.Context(), "UserDuplicateScan", "app")
+	ses.SetContext(ctx)
+	defer sp.End()
+	schoolId = ses.GetSchoolIdByFilter(schoolId)
+	if schoolId == nil {
+		return 0, ErrRequired.SetKey("school_id")
+	}
+	users, err := store.Store().UserDuplicatesCandidates(ses.Context(), *schoolId)
+	if err != nil {
+		return 0, err
+	}
+	l := userDuplicatePairs(users)
+	err = store.Store().UserDuplicatesSave(ses.Context(), *schoolId, l)
+	if err != nil {
+		return 0, err
+	}
+	return len(l), nil
+}
+
+// userDuplicatePairs compares users of same role which share name, birthday, document or phone
+func userDuplicatePairs(users []*models.User) []*models.UserDuplicate {
+	buckets := map[string][]*models.User{}
+	add := func(u *models.User, kind string, v string) {
+		if v == "" || u.Role == nil {
+			return
+		}
+		k := *u.Role + ":" + kind + ":" + v
+		buckets[k] = append(buckets[k], u)
+	}
+	for _, u := range users {
+		first, last := userDuplicateName(u.FirstName), userDuplicateName(u.LastName)
+		add(u, "first", first)
+		add(u, "last", last)
+		if u.Birthday != nil {
+			add(u, "birthday", u.Birthday.Format("2006-01-02"))
+		}
+		add(u, "cert", userDuplicateDocument(u.BirthCertNumber))
+		add(u, "passport", userDuplicateDocument(u.PassportNumber))
+		if phone := userImportPhone(u.Phone); phone != nil {
+			add(u, "phone", *phone)
+		}
+	}
+	res := []*models.UserDuplicate{}
+	seen := map[string]bool{}
+	for _, l := range buckets {
+		if len(l) < 2 || len(l) > userDuplicateBucketMax {
+			continue
+		}
+		for i, u := range l {
+			for _, o := range l[i+1:] {
+				if u.ID == o.ID {
+					continue
+				}
+				// pair is kept ordered as unique of user_duplicates
+				a, b := u, o
+				if a.ID > b.ID {
+					a, b = b, a
+				}
+				if seen[a.ID+b.ID] {
+					continue
+				}
+				seen[a.ID+b.ID] = true
+				reasons := userDuplicateReasons(a, b)
+				score := 0
+				for _, r := range reasons {
+					score += models.UserDuplicateReasonScores[r]
+				}
+				if score < models.UserDuplicateMinScore {
+					continue
+				}
+				res = append(res, &models.UserDuplicate{
+					UserId:   a.ID,
+					OtherId:  b.ID,
+					RoleCode: *a.Role,
+					Score:    score,
+					Reasons:  reasons,
+					Status:   models.UserDuplicatePending,
+				})
+			}
+		}
+	}
+	return res
+}
+
+func userDuplicateReasons(a, b *models.User) []string {
+	reasons := []string{}
+	nameA := userDuplicateName(a.LastName) + userDuplicateName(a.FirstName)
+	nameB := userDuplicateName(b.LastName) + userDuplicateName(b.FirstName)
+	if nameA != "" && nameA == nameB {
+		reasons = append(reasons, models.UserDuplicateReasonName)
+	} else if len(nameA) >= userDuplicateNameMinLength && len(nameB) >= userDuplicateNameMinLength &&
+		userDuplicateDistance(nameA, nameB) <= userDuplicateNameDistance {
+		reasons = append(reasons, models.UserDuplicateReasonNameSimilar)
+	}
+	if a.Birthday != nil && b.Birthday != nil && a.Birthday.Format("2006-01-02") == b.Birthday.Format("2006-01-02") {
+		reasons = append(reasons, models.UserDuplicateReasonBirthday)
+	}
+	if v := userDuplicateDocument(a.BirthCertNumber); v != "" && v == userDuplicateDocument(b.BirthCertNumber) {
+		reasons = append(reasons, models.UserDuplicateReasonBirthCertNumber)
+	}
+	if v := userDuplicateDocument(a.PassportNumber); v != "" && v == userDuplicateDocument(b.PassportNumber) {
+		reasons = append(reasons, models.UserDuplicateReasonPassportNumber)
+	}
+	phoneA, phoneB := userImportPhone(a.Phone), userImportPhone(b.Phone)
+	if phoneA != nil && phoneB != nil && *phoneA != "" && *phoneA == *phoneB {
+		reasons = append(reasons, models.UserDuplicateReasonPhone)
+	}
+	return reasons
+}
+
+// russian transliteration of names is folded to turkmen one: "Amanov" -> "Amanow", "Shirin" -> "Sirin"
+var userDuplicateNameReplacer = strings.NewReplacer("zh", "z", "sh", "s", "ch", "c", "v", "w")
+
+// userDuplicateName is name in latin letters without Turkmen letters, spaces and signs: "Ýazmyrat" -> "yazmyrat"
+func userDuplicateName(v *string) string {
+	if v == nil {
+		return ""
+	}
+	name := userDuplicateNonLetters.ReplaceAllString(strings.ToLower(LettersRemoveTurkmen(*v)), "")
+	return userDuplicateNameReplacer.Replace(name)
+}
+
+// userDuplicateDocument is number of document without spaces and signs: "I-AŞ 123456" -> "IAS123456"
+func userDuplicateDocument(v *string) string {
+	if v == nil {
+		return ""
+	}
+	return userDuplicateNonAlnum.ReplaceAllString(strings.ToUpper(LettersRemoveTurkmen(*v)), "")
+}
+
+// userDuplicateDistance is count of letters to insert, delete or change to get b from a
+func userDuplicateDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
+
+func UserDuplicateList(ses *utils.Session, f models.UserDuplicateFilterRequest) ([]*models.UserDuplicateResponse, int, error) {
+	sp, ctx := apm.StartSpan(ses.Context(), "UserDuplicateList", "app")
+	ses.SetContext(ctx)
+	defer sp.End()
+	l, total, err := store.Store().UserDuplicatesFindBy(ses.Context(), f)
+	if err != nil {
+		return nil, 0, err
+	}
+	ids := []string{}
+	for _, m := range l {
+		ids = append(ids, m.UserId, m.OtherId)
+	}
+	users, err := userMergeUsers(ses, ids)
+	if err != nil {
+		return nil, 0, err
+	}
+	res := []*models.UserDuplicateResponse{}
+	for _, m := range l {
+		m.User, m.Other = users[m.UserId], users[m.OtherId]
+		item := models.UserDuplicateResponse{}
+		item.FromModel(m)
+		res = append(res, &item)
+	}
+	return res, total, nil
+}
+
+// UserDuplicateDismiss marks pending pair as not same person, scan does not put it into queue again
+func UserDuplicateDismiss(ses *utils.Session, id string) (*models.UserDuplicateResponse, error) {
+	sp, ctx := apm.StartSpan(ses.Context(), "UserDuplicateDismiss", "app")
+	ses.SetContext(ctx)
+	defer sp.End()
+	m, err := userDuplicateFind(ses, id)
+	if err != nil {
+		return nil, err
+	}
+	if m.Status != models.UserDuplicatePending {
+		return nil, ErrInvalid.SetKey("status").SetComment("pair is already " + m.Status)
+	}
+	err = store.Store().UserDuplicatesReview(ses.Context(), m.ID, models.UserDuplicateDismissed, ses.GetUser().ID)
+	if err != nil {
+		return nil, err
+	}
+	m, err = store.Store().UserDuplicatesFindById(ses.Context(), m.ID)
+	if err != nil {
+		return nil, err
+	}
+	res := &models.UserDuplicateResponse{}
+	res.FromModel(m)
+	return res, nil
+}
+
+func userDuplicateFind(ses *utils.Session, id string) (*models.UserDuplicate, error) {
+	m, err := store.Store().UserDuplicatesFindById(ses.Context(), id)
+	if err != nil {
+		return nil, ErrNotfound.SetKey("id")
+	}
+	if schoolId := ses.GetSchoolIdByFilter(&m.SchoolId); schoolId == nil || *schoolId != m.SchoolId {
+		return nil, ErrForbidden.SetKey("school_id")
+	}
+	return m, nil
+}
+
+// UserMerge moves everything of duplicate onto survivor and blocks duplicate, users must be students
+// or parents of same role in session school, merge can be undone by UserMergeUndo
+func UserMerge(ses *utils.Session, data models.UserMergeRequest) (*models.UserMergeResponse, error) {
+	sp, ctx := apm.StartSpan(ses.Context(), "UserMerge", "app")
+	ses.SetContext(ctx)
+	defer sp.End()
+	if data.SurvivorId == data.DuplicateId {
+		return nil, ErrInvalid.SetKey("duplicate_id").SetComment("same as survivor")
+	}
+	m := &models.UserMerge{
+		SurvivorId:  data.SurvivorId,
+		DuplicateId: data.DuplicateId,
+		CreatedBy:   &ses.GetUser().ID,
+	}
+	if data.DuplicatePairId != nil && *data.DuplicatePairId != "" {
+		pair, err := userDuplicateFind(ses, *data.DuplicatePairId)
+		if err != nil {
+			return nil, err
+		}
+		if pair.Status != models.UserDuplicatePending {
+			return nil, ErrInvalid.SetKey("duplicate_pair_id").SetComment("pair is already " + pair.Status)
+		}
+		if !slices.Contains([]string{pair.UserId, pair.OtherId}, m.SurvivorId) || !slices.Contains([]string{pair.UserId, pair.OtherId}, m.DuplicateId) {
+			return nil, ErrInvalid.SetKey("duplicate_pair_id").SetComment("users are not of pair")
+		}
+		m.SchoolId = &pair.SchoolId
+		m.DuplicatePairId = &pair.ID
+	} else {
+		m.SchoolId = ses.GetSchoolId()
+		if m.SchoolId == nil && *ses.GetRole() != models.RoleAdmin {
+			return nil, ErrRequired.SetKey("school_id")
+		}
+	}
+	// only admin merges users out of school
+	if m.SchoolId != nil {
+		users, err := store.Store().UserDuplicatesCandidates(ses.Context(), *m.SchoolId)
+		if err != nil {
+			return nil, err
+		}
+		roles := map[string]string{}
+		for _, u := range users {
+			roles[u.ID] = *u.Role
+		}
+		if _, ok := roles[m.SurvivorId]; !ok {
+			return nil, ErrNotfound.SetKey("survivor_id").SetComment("not student or parent of school")
+		}
+		if _, ok := roles[m.DuplicateId]; !ok {
+			return nil, ErrNotfound.SetKey("duplicate_id").SetComment("not student or parent of school")
+		}
+		if roles[m.SurvivorId] != roles[m.DuplicateId] {
+			return nil, ErrInvalid.SetKey("duplicate_id").SetComment("role is not same as of survivor")
+		}
+	} else {
+		users, err := store.Store().UsersFindByIds(ses.Context(), []string{m.SurvivorId, m.DuplicateId})
+		if err != nil {
+			return nil, err
+		}
+		if len(users) < 2 {
+			return nil, ErrNotfound.SetKey("duplicate_id")
+		}
+		for _, u := range users {
+			if u.ID == m.DuplicateId && u.Status != nil && *u.Status == string(models.StatusBlocked) {
+				return nil, ErrInvalid.SetKey("duplicate_id").SetComment("user is blocked or merged")
+			}
+		}
+	}
+	m, err := store.Store().UserMergesCommit(ses.Context(), m)
+	if err != nil {
+		return nil, err
+	}
+	// sessions of duplicate are moved to survivor, loaded ones would still act as duplicate
+	if err = utils.SessionForgetByUserId(m.DuplicateId); err != nil {
+		apputils.LoggerDesc("In UserMerge " + m.DuplicateId).Error(err)
+	}
+	return userMergeResponse(ses, m)
+}
+
+// UserMergeUndo moves rows back to duplicate and restores its status, pair goes back into queue
+func UserMergeUndo(ses *utils.Session, id string) (*models.UserMergeResponse, error) {
+	sp, ctx := apm.StartSpan(ses.Context(), "UserMergeUndo", "app")
+	ses.SetContext(ctx)
+	defer sp.End()
+	m, err := store.Store().UserMergesFindById(ses.Context(), id)
+	if err != nil {
+		return nil, ErrNotfound.SetKey("id")
+	}
+	if m.SchoolId == nil && *ses.GetRole() != models.RoleAdmin {
+		return nil, ErrForbidden.SetKey("school_id")
+	}
+	if schoolId := ses.GetSchoolIdByFilter(m.SchoolId); m.SchoolId != nil && (schoolId == nil || *schoolId != *m.SchoolId) {
+		return nil, ErrForbidden.SetKey("school_id")
+	}
+	if m.UndoneAt != nil {
+		return nil, ErrInvalid.SetKey("id").SetComment("merge is already undone")
+	}
+	m, err = store.Store().UserMergesUndo(ses.Context(), m, ses.GetUser().ID)
+	if err != nil {
+		return nil, err
+	}
+	return userMergeResponse(ses, m)
+}
+
+func UserMergeList(ses *utils.Session, f models.UserMergeFilterRequest) ([]*models.UserMergeResponse, int, error) {
+	sp, ctx := apm.StartSpan(ses.Context(), "UserMergeList", "app")
+	ses.SetContext(ctx)
+	defer sp.End()
+	l, total, err := store.Store().UserMergesFindBy(ses.Context(), f)
+	if err != nil {
+		return nil, 0, err
+	}
+	ids := []string{}
+	for _, m := range l {
+		ids = append(ids, m.SurvivorId, m.DuplicateId)
+	}
+	users, err := userMergeUsers(ses, ids)
+	if err != nil {
+		return nil, 0, err
+	}
+	res := []*models.UserMergeResponse{}
+	for _, m := range l {
+		m.Survivor, m.Duplicate = users[m.SurvivorId], users[m.DuplicateId]
+		item := models.UserMergeResponse{}
+		item.FromModel(m)
+		res = append(res, &item)
+	}
+	return res, total, nil
+}
+
+func userMergeResponse(ses *utils.Session, m *models.UserMerge) (*models.UserMergeResponse, error) {
+	users, err := userMergeUsers(ses, []string{m.SurvivorId, m.DuplicateId})
+	if err != nil {
+		return nil, err
+	}
+	m.Survivor, m.Duplicate = users[m.SurvivorId], users[m.DuplicateId]
+	res := &models.UserMergeResponse{}
+	res.FromModel(m)
+	return res, nil
+}
+
+func userMergeUsers(ses *utils.Session, ids []string) (map[string]*models.User, error) {
+	res := map[string]*models.User{}
+	if len(ids) < 1 {
+		return res, nil
+	}
+	l, err := store.Store().UsersFindByIds(ses.Context(), ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, u := range l {
+		res[u.ID] = u
+	}
+	return res, nil
+}
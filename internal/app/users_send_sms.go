@@ -2,7 +2,6 @@ package app
 
 import (
 	"context"
-	"fmt"
 	"strconv"
 	"strings"
 	"time"
@@ -14,8 +13,6 @@ import (
 	"go.elastic.co/apm/v2"
 )
 
-const AppOpenLink = `mekdep.edu.tm/redirect/app`
-
 // Caganyz Meret eMekdep programmada "Goreldeli" nyrhnama birikdi. Gutarmagyna 30 gun galdy.
 // Elektron gundelik, Jemleyji bahalar, SMS habarlar, Gyzyklanma analitika we basgalar.
 // Peydalanmak:
@@ -30,9 +27,7 @@ func SendWelcomeSms(child *models.User, parents []*models.User, expiresAt time.T
 			tariffName = v.Name
 		}
 	}
-	msg := `Caganyz ` + *child.FirstName + ` eMekdep programmada "` + tariffName + `" nyrhnama birikdi. Gutarmagyna ` + strconv.Itoa(int(leftDays)) + ` gun galdy.
-Elektron gundelik, Jemleyji bahalar, SMS habarlar, Gyzyklanma analitika we basgalar.
-Peydalanmak: ` + AppOpenLink
+	msg := models.DefaultCountry().SmsText(models.SmsWelcome, "child", *child.FirstName, "tariff", tariffName, "days", strconv.Itoa(int(leftDays)))
 
 	var err error
 	phones := []string{}
@@ -56,8 +51,9 @@ func SendTariffEndsSmsAll(ses *apiutils.Session, isLate bool, today time.Time, s
 		return ErrNotSet.SetKey("classroom_id").SetComment("ID: " + student.ID)
 	}
 	childName := *student.FirstName
+	country := models.DefaultCountry()
 
-	smsText := "Çagaňyz " + childName + " eMekdep programmada nyrhnamasynyň gutarmagyna " + daysLeft + " gün galdy! Programmada töleg edip uzaltmagy unutmaň!"
+	smsText := country.SmsText(models.SmsTariffEnds, "child", childName, "days", daysLeft)
 	phones := []string{}
 
 	for _, parent := range parents {
@@ -67,26 +63,38 @@ func SendTariffEndsSmsAll(ses *apiutils.Session, isLate bool, today time.Time, s
 	}
 
 	utils.LoggerDesc("SendExpirationReminderSms").Info(phones, smsText)
-	for _, phone := range phones {
-		err := SendSMS([]string{phone}, LettersRemoveTurkmen(smsText), models.SmsTypeReminder)
-		if err != nil {
-			utils.LoggerDesc("SendExpirationReminderSms").Error(err)
-		}
+	err := notifyDispatch(parents, Notify{
+		Category: models.NotifyPaymentExpiry,
+		Role:     models.RoleParent,
+		Title:    country.SmsText(models.SmsChildTitle, "child", childName),
+		Body:     country.SmsText(models.SmsTariffEndsBody, "days", daysLeft),
+		SmsText:  smsText,
+		SmsType:  models.SmsTypeReminder,
+		PushType: PushTypePayment,
+		PushId:   student.ID,
+	})
+	if err != nil {
+		utils.LoggerDesc("SendExpirationReminderSms").Error(err)
 	}
 
 	return nil
 }
 
 func SendMessageUnreadReminder(ses *apiutils.Session, count int, user *models.User) (err error) {
-	title := "Size " + strconv.Itoa(count) + " sany täze hat geldi"
-	body := ""
-	// add payload type of push, and id
-	sendNotificationPush(models.Notifications{
-		Title:   &title,
-		Content: &body,
-		UserIds: []string{user.ID},
-	}, []string{user.ID}, PushTypeChat, "")
-	return nil
+	country := models.DefaultCountry()
+	title := country.SmsText(models.SmsUnreadMessages, "count", strconv.Itoa(count))
+	role := models.Role("")
+	if user.Role != nil {
+		role = models.Role(*user.Role)
+	}
+	return notifyDispatch([]*models.User{user}, Notify{
+		Category: models.NotifyChatMessage,
+		Role:     role,
+		Title:    title,
+		SmsText:  title + ". " + country.AppLink,
+		SmsType:  models.SmsTypeReminder,
+		PushType: PushTypeChat,
+	})
 }
 
 // Caganyz Meret
@@ -133,8 +141,7 @@ func SendDailySms(ses *apiutils.Session, isLate bool, today time.Time, student *
 		return nil
 	}
 
-	smsTextSubGrade := "Elektron gündeliginde %s baha aldy. "
-	smsTextSubAbsent := "%s sapagyna gatnaşmady. "
+	country := models.DefaultCountry()
 	smsTextSub := ""
 	// message grades
 	tmp := ""
@@ -142,7 +149,7 @@ func SendDailySms(ses *apiutils.Session, isLate bool, today time.Time, student *
 		tmp += subjectNames[v.Lesson.SubjectId] + " " + v.ValueString() + ", "
 	}
 	if tmp != "" {
-		smsTextSub += fmt.Sprintf(smsTextSubGrade, strings.Trim(tmp, ", "))
+		smsTextSub += country.SmsText(models.SmsDailyGrades, "grades", strings.Trim(tmp, ", ")) + " "
 	}
 	// message absents
 	tmp = ""
@@ -150,10 +157,11 @@ func SendDailySms(ses *apiutils.Session, isLate bool, today time.Time, student *
 		tmp += subjectNames[v.Lesson.SubjectId] + ", "
 	}
 	if tmp != "" {
-		smsTextSub += fmt.Sprintf(smsTextSubAbsent, strings.Trim(tmp, ", "))
+		smsTextSub += country.SmsText(models.SmsDailyAbsents, "subjects", strings.Trim(tmp, ", ")) + " "
 	}
+	smsTextSub = strings.TrimSpace(smsTextSub)
 	// message all
-	smsText := "Çagaňyz " + childName + " " + smsTextSub + "Giňişleýin " + AppOpenLink
+	smsText := country.SmsText(models.SmsChild, "child", childName, "text", smsTextSub)
 	phones := []string{}
 	for _, p := range parents {
 		if ph, err := p.FormattedPhone(); err == nil && ph != "" {
@@ -161,10 +169,16 @@ func SendDailySms(ses *apiutils.Session, isLate bool, today time.Time, student *
 		}
 	}
 	utils.LoggerDesc("SendSmsItem").Info(phones, smsText)
-	for _, v := range phones {
-		err = SendSMS([]string{v}, LettersRemoveTurkmen(smsText), models.SmsTypeDaily)
-	}
-	return err
+	return notifyDispatch(parents, Notify{
+		Category: models.NotifyDailyGrades,
+		Role:     models.RoleParent,
+		Title:    country.SmsText(models.SmsChildTitle, "child", childName),
+		Body:     smsTextSub,
+		SmsText:  smsText,
+		SmsType:  models.SmsTypeDaily,
+		PushType: PushTypeDiary,
+		PushId:   student.ID,
+	})
 }
 
 func SendDailyGetLessons(ses *apiutils.Session, today time.Time, classroomId string, studentId string) ([]*models.Lesson, error) {
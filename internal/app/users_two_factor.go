@@ -0,0 +1,505 @@
+package app
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mekdep/server/config"
+	"github.com/mekdep/server/internal/api/utils"
+	"github.com/mekdep/server/internal/models"
+	"github.com/mekdep/server/internal/store"
+	apputils "github.com/mekdep/server/internal/utils"
+	"go.elastic.co/apm/v2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const totpIssuer = "eMekdep"
+const totpPeriod = 30
+const totpDigits = 6
+const recoveryCodesCount = 10
+
+// totpCode returns code of secret (RFC 6238, sha1) for time step counter
+func totpCode(secret string, counter int64) (string, error) {
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return "", err
+	}
+	msg := make([]byte, 8)
+	binary.BigEndian.PutUint64(msg, uint64(counter))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg)
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%06d", value%1000000), nil
+}
+
+// totpValidate checks code in previous, current and next time step, returns matched step.
+// Steps not after lastCounter are rejected so code can not be used twice
+func totpValidate(secret string, code string, now time.Time, lastCounter int64) (int64, bool) {
+	code = strings.ReplaceAll(code, " ", "")
+	if len(code) != totpDigits {
+		return 0, false
+	}
+	current := now.Unix() / totpPeriod
+	for _, counter := range []int64{current - 1, current, current + 1} {
+		if counter <= lastCounter {
+			continue
+		}
+		expected, err := totpCode(secret, counter)
+		if err != nil {
+			return 0, false
+		}
+		if hmac.Equal([]byte(expected), []byte(code)) {
+			return counter, true
+		}
+	}
+	return 0, false
+}
+
+func totpSecretGenerate() (string, error) {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(b), nil
+}
+
+func totpUrl(m *models.User, secret string) string {
+	account := m.ID
+	if m.Username != nil {
+		account = *m.Username
+	}
+	q := url.Values{}
+	q.Set("secret", secret)
+	q.Set("issuer", totpIssuer)
+	q.Set("digits", fmt.Sprint(totpDigits))
+	q.Set("period", fmt.Sprint(totpPeriod))
+	return "otpauth://totp/" + url.PathEscape(totpIssuer+":"+account) + "?" + q.Encode()
+}
+
+// recoveryCodesGenerate returns plain codes to show once and their hashes to store
+func recoveryCodesGenerate() ([]string, []string, error) {
+	codes := []string{}
+	hashes := []string{}
+	for i := 0; i < recoveryCodesCount; i++ {
+		b := make([]byte, 5)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		code := hex.EncodeToString(b)
+		code = code[:5] + "-" + code[5:]
+		hash, err := bcrypt.GenerateFromPassword([]byte(code), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, nil, err
+		}
+		codes = append(codes, code)
+		hashes = append(hashes, string(hash))
+	}
+	return codes, hashes, nil
+}
+
+// recoveryCodeUse removes matched code from stored hashes
+func recoveryCodeUse(sec *models.UserSecurity, code string) bool {
+	if sec.RecoveryCodes == nil {
+		return false
+	}
+	code = strings.ToLower(strings.TrimSpace(code))
+	for k, hash := range *sec.RecoveryCodes {
+		if bcrypt.CompareHashAndPassword([]byte(hash), []byte(code)) == nil {
+			l := slices.Delete(slices.Clone(*sec.RecoveryCodes), k, k+1)
+			sec.RecoveryCodes = &l
+			return true
+		}
+	}
+	return false
+}
+
+// twoFactorEnforced is true when user has role which must login with two factor (config two_factor_roles)
+func twoFactorEnforced(m *models.User) bool {
+	for _, v := range m.Schools {
+		if slices.Contains(config.Conf.TwoFactorRoles, string(v.RoleCode)) {
+			return true
+		}
+	}
+	return false
+}
+
+// twoFactorEnrollRequired is true when user of enforced role has no two factor yet
+func twoFactorEnrollRequired(m *models.User, sec *models.UserSecurity) bool {
+	return !sec.IsTotpEnabled() && twoFactorEnforced(m)
+}
+
+// loginTwoFactorEnrollConfirm sends otp by sms (or checks it) before enrollment secret is given on password login,
+// so leaked password alone does not bind authenticator of attacker. Returns id of checked code
+func loginTwoFactorEnrollConfirm(m *models.User, req *UserLoginRequest, res *models.LoginSecurityResponse) (string, bool, error) {
+	if _, err := m.FormattedPhone(); err != nil {
+		return "", false, ErrForbidden.SetKey("phone").SetComment("two factor enrollment needs phone, ask admin to set it")
+	}
+	codeId, isOk, err := LoginByOtp(m, req)
+	if err != nil || isOk {
+		return codeId, isOk, err
+	}
+	res.TwoFactor = &models.TwoFactorChallenge{
+		IsRequired:      true,
+		IsSetupRequired: true,
+		IsOtpRequired:   true,
+	}
+	return "", false, nil
+}
+
+// loginTwoFactorCheck returns false with challenge when code is not given yet.
+// User of enforced role without two factor gets secret to enroll (after sms code, see loginTwoFactorEnrollConfirm), first valid code enables it
+func loginTwoFactorCheck(m *models.User, sec *models.UserSecurity, req *UserLoginRequest, res *models.LoginSecurityResponse) (bool, error) {
+	if !sec.IsTotpEnabled() && !twoFactorEnforced(m) {
+		return true, nil
+	}
+	now := time.Now()
+	if !sec.IsTotpEnabled() {
+		if sec.TotpSecret == nil {
+			secret, err := totpSecretGenerate()
+			if err != nil {
+				return false, err
+			}
+			sec.TotpSecret = &secret
+			err = store.Store().UserSecuritySave(context.Background(), sec)
+			if err != nil {
+				return false, err
+			}
+		}
+		if req.TotpCode == nil || *req.TotpCode == "" {
+			otpauthUrl := totpUrl(m, *sec.TotpSecret)
+			res.TwoFactor = &models.TwoFactorChallenge{
+				IsRequired:      true,
+				IsSetupRequired: true,
+				Secret:          sec.TotpSecret,
+				OtpauthUrl:      &otpauthUrl,
+			}
+			return false, nil
+		}
+		counter, ok := totpValidate(*sec.TotpSecret, *req.TotpCode, now, sec.TotpLastCounter)
+		if !ok {
+			return false, ErrInvalid.SetKey("totp_code")
+		}
+		codes, hashes, err := recoveryCodesGenerate()
+		if err != nil {
+			return false, err
+		}
+		sec.TotpEnabledAt = &now
+		sec.TotpLastCounter = counter
+		sec.RecoveryCodes = &hashes
+		res.RecoveryCodes = codes
+		return true, nil
+	}
+	if req.TotpCode != nil && *req.TotpCode != "" {
+		counter, ok := totpValidate(*sec.TotpSecret, *req.TotpCode, now, sec.TotpLastCounter)
+		if !ok {
+			return false, ErrInvalid.SetKey("totp_code")
+		}
+		sec.TotpLastCounter = counter
+		return true, nil
+	}
+	if req.RecoveryCode != nil && *req.RecoveryCode != "" {
+		if !recoveryCodeUse(sec, *req.RecoveryCode) {
+			return false, ErrInvalid.SetKey("recovery_code")
+		}
+		left := sec.RecoveryCodesLeft()
+		res.RecoveryCodesLeft = &left
+		return true, nil
+	}
+	res.TwoFactor = &models.TwoFactorChallenge{IsRequired: true}
+	return false, nil
+}
+
+// loginAttemptLog stores attempt with ip and device of request, errors are only logged
+func loginAttemptLog(req *UserLoginRequest, userId *string, isSuccess bool, reason string) {
+	m := &models.LoginAttempt{
+		UserId:    userId,
+		Login:     *req.Username,
+		IsSuccess: isSuccess,
+	}
+	if req.Ip != "" {
+		m.Ip = &req.Ip
+	}
+	if req.Agent != "" {
+		device := models.LoginDevice(req.Agent)
+		m.Agent = &req.Agent
+		m.Device = &device
+	}
+	if reason != "" {
+		m.Reason = &reason
+	}
+	err := store.Store().LoginAttemptsCreate(context.Background(), m)
+	if err != nil {
+		apputils.LoggerDesc("In loginAttemptLog").Error(err)
+	}
+}
+
+// loginFailed counts failure of user, account is locked after config login_max_attempts in a row
+func loginFailed(req *UserLoginRequest, m *models.User, sec *models.UserSecurity, reason string) {
+	loginAttemptLog(req, &m.ID, false, reason)
+	sec.FailedCount++
+	if sec.FailedCount >= config.Conf.LoginMaxAttempts {
+		lockedUntil := time.Now().Add(time.Duration(config.Conf.LoginLockoutMinutes) * time.Minute)
+		sec.LockedUntil = &lockedUntil
+		sec.FailedCount = 0
+	}
+	err := store.Store().UserSecuritySave(context.Background(), sec)
+	if err != nil {
+		apputils.LoggerDesc("In loginFailed " + m.ID).Error(err)
+	}
+}
+
+// loginSucceeded resets failures, alerts user when device or network is not seen in previous logins
+func loginSucceeded(req *UserLoginRequest, m *models.User, sec *models.UserSecurity, res *models.LoginSecurityResponse) error {
+	sec.FailedCount = 0
+	sec.LockedUntil = nil
+	err := store.Store().UserSecuritySave(context.Background(), sec)
+	if err != nil {
+		return err
+	}
+	isSuccess := true
+	limit := 100
+	l, _, err := store.Store().LoginAttemptsFindBy(context.Background(), models.LoginAttemptFilterRequest{
+		UserId:            &m.ID,
+		IsSuccess:         &isSuccess,
+		PaginationRequest: models.PaginationRequest{Limit: &limit},
+	})
+	if err != nil {
+		return err
+	}
+	loginAttemptLog(req, &m.ID, true, "")
+	// first login of user is not alerted
+	if len(l) < 1 || req.Agent == "" && req.Ip == "" {
+		return nil
+	}
+	device := models.LoginDevice(req.Agent)
+	location := models.LoginLocation(req.Ip)
+	isNewDevice := true
+	isNewLocation := true
+	for _, v := range l {
+		if v.Device != nil && *v.Device == device {
+			isNewDevice = false
+		}
+		if v.Ip != nil && models.LoginLocation(*v.Ip) == location {
+			isNewLocation = false
+		}
+	}
+	if !isNewDevice && !isNewLocation {
+		return nil
+	}
+	country := models.DefaultCountry()
+	alert := country.SmsText(models.SmsLoginNewDevice, "device", device, "ip", req.Ip)
+	if !isNewDevice {
+		alert = country.SmsText(models.SmsLoginNewLocation, "ip", req.Ip)
+	}
+	if config.Conf.SettingLoginAlert != nil {
+		alert += ". " + *config.Conf.SettingLoginAlert
+	}
+	res.Alert = &alert
+	role := models.Role("")
+	if len(m.Schools) > 0 {
+		role = m.Schools[0].RoleCode
+	}
+	go func() {
+		err := notifyDispatch([]*models.User{m}, Notify{
+			Category: models.NotifyLoginAlert,
+			Role:     role,
+			Title:    country.SmsText(models.SmsLoginTitle),
+			Body:     alert,
+			SmsText:  alert,
+			SmsType:  models.SmsTypeOther,
+			PushType: PushTypeNotification,
+		})
+		if err != nil {
+			apputils.LoggerDesc("In loginSucceeded alert " + m.ID).Error(err)
+		}
+	}()
+	return nil
+}
+
+func TwoFactorStatus(ses *utils.Session) (*models.TwoFactorStatusResponse, error) {
+	sp, ctx := apm.StartSpan(ses.Context(), "TwoFactorStatus", "app")
+	ses.SetContext(ctx)
+	defer sp.End()
+	sec, err := store.Store().UserSecurityFindByUserId(ses.Context(), ses.GetUser().ID)
+	if err != nil {
+		return nil, err
+	}
+	return &models.TwoFactorStatusResponse{
+		IsEnabled:         sec.IsTotpEnabled(),
+		IsEnforced:        twoFactorEnforced(ses.GetUser()),
+		EnabledAt:         sec.TotpEnabledAt,
+		RecoveryCodesLeft: sec.RecoveryCodesLeft(),
+		LockedUntil:       sec.LockedUntil,
+	}, nil
+}
+
+// TwoFactorSetup creates new secret for user without two factor, it is enabled by TwoFactorEnable
+func TwoFactorSetup(ses *utils.Session) (*models.TwoFactorChallenge, error) {
+	sp, ctx := apm.StartSpan(ses.Context(), "TwoFactorSetup", "app")
+	ses.SetContext(ctx)
+	defer sp.End()
+	sec, err := store.Store().UserSecurityFindByUserId(ses.Context(), ses.GetUser().ID)
+	if err != nil {
+		return nil, err
+	}
+	if sec.IsTotpEnabled() {
+		return nil, ErrUnique.SetKey("totp").SetComment("two factor is already enabled")
+	}
+	secret, err := totpSecretGenerate()
+	if err != nil {
+		return nil, err
+	}
+	sec.TotpSecret = &secret
+	err = store.Store().UserSecuritySave(ses.Context(), sec)
+	if err != nil {
+		return nil, err
+	}
+	otpauthUrl := totpUrl(ses.GetUser(), secret)
+	return &models.TwoFactorChallenge{
+		IsSetupRequired: true,
+		Secret:          &secret,
+		OtpauthUrl:      &otpauthUrl,
+	}, nil
+}
+
+// TwoFactorEnable checks code of secret from setup, returns recovery codes to show once
+func TwoFactorEnable(ses *utils.Session, data models.TwoFactorCodeRequest) ([]string, error) {
+	sp, ctx := apm.StartSpan(ses.Context(), "TwoFactorEnable", "app")
+	ses.SetContext(ctx)
+	defer sp.End()
+	sec, err := store.Store().UserSecurityFindByUserId(ses.Context(), ses.GetUser().ID)
+	if err != nil {
+		return nil, err
+	}
+	if sec.IsTotpEnabled() {
+		return nil, ErrUnique.SetKey("totp").SetComment("two factor is already enabled")
+	}
+	if sec.TotpSecret == nil {
+		return nil, ErrNotExists.SetKey("totp").SetComment("setup is not started")
+	}
+	counter, ok := totpValidate(*sec.TotpSecret, *data.Code, time.Now(), sec.TotpLastCounter)
+	if !ok {
+		return nil, ErrInvalid.SetKey("code")
+	}
+	codes, hashes, err := recoveryCodesGenerate()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	sec.TotpEnabledAt = &now
+	sec.TotpLastCounter = counter
+	sec.RecoveryCodes = &hashes
+	err = store.Store().UserSecuritySave(ses.Context(), sec)
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// TwoFactorRecoveryCodes replaces recovery codes, code of authenticator is required
+func TwoFactorRecoveryCodes(ses *utils.Session, data models.TwoFactorCodeRequest) ([]string, error) {
+	sp, ctx := apm.StartSpan(ses.Context(), "TwoFactorRecoveryCodes", "app")
+	ses.SetContext(ctx)
+	defer sp.End()
+	sec, err := store.Store().UserSecurityFindByUserId(ses.Context(), ses.GetUser().ID)
+	if err != nil {
+		return nil, err
+	}
+	if !sec.IsTotpEnabled() {
+		return nil, ErrNotExists.SetKey("totp")
+	}
+	counter, ok := totpValidate(*sec.TotpSecret, *data.Code, time.Now(), sec.TotpLastCounter)
+	if !ok {
+		return nil, ErrInvalid.SetKey("code")
+	}
+	codes, hashes, err := recoveryCodesGenerate()
+	if err != nil {
+		return nil, err
+	}
+	sec.TotpLastCounter = counter
+	sec.RecoveryCodes = &hashes
+	err = store.Store().UserSecuritySave(ses.Context(), sec)
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// TwoFactorDisable removes two factor of user, not permitted for enforced roles
+func TwoFactorDisable(ses *utils.Session, data models.TwoFactorDisableRequest) error {
+	sp, ctx := apm.StartSpan(ses.Context(), "TwoFactorDisable", "app")
+	ses.SetContext(ctx)
+	defer sp.End()
+	user, err := store.Store().UsersFindById(ses.Context(), ses.GetUser().ID)
+	if err != nil {
+		return err
+	}
+	if user.Password == nil || bcrypt.CompareHashAndPassword([]byte(*user.Password), []byte(*data.Password)) != nil {
+		return ErrPassword
+	}
+	if twoFactorEnforced(ses.GetUser()) {
+		return ErrForbidden.SetKey("totp").SetComment("two factor is required for role")
+	}
+	sec, err := store.Store().UserSecurityFindByUserId(ses.Context(), user.ID)
+	if err != nil {
+		return err
+	}
+	if !sec.IsTotpEnabled() {
+		return ErrNotExists.SetKey("totp")
+	}
+	if _, ok := totpValidate(*sec.TotpSecret, *data.Code, time.Now(), sec.TotpLastCounter); !ok {
+		return ErrInvalid.SetKey("code")
+	}
+	sec.TotpSecret = nil
+	sec.TotpEnabledAt = nil
+	sec.TotpLastCounter = 0
+	sec.RecoveryCodes = nil
+	return store.Store().UserSecuritySave(ses.Context(), sec)
+}
+
+// TwoFactorReset is done by admin for user who lost authenticator: two factor is removed and account unlocked
+func TwoFactorReset(ses *utils.Session, userId string) error {
+	sp, ctx := apm.StartSpan(ses.Context(), "TwoFactorReset", "app")
+	ses.SetContext(ctx)
+	defer sp.End()
+	sec, err := store.Store().UserSecurityFindByUserId(ses.Context(), userId)
+	if err != nil {
+		return err
+	}
+	sec.TotpSecret = nil
+	sec.TotpEnabledAt = nil
+	sec.TotpLastCounter = 0
+	sec.RecoveryCodes = nil
+	sec.FailedCount = 0
+	sec.LockedUntil = nil
+	return store.Store().UserSecuritySave(ses.Context(), sec)
+}
+
+func LoginAttemptsList(ses *utils.Session, f models.LoginAttemptFilterRequest) ([]models.LoginAttemptResponse, int, error) {
+	sp, ctx := apm.StartSpan(ses.Context(), "LoginAttemptsList", "app")
+	ses.SetContext(ctx)
+	defer sp.End()
+	l, total, err := store.Store().LoginAttemptsFindBy(ses.Context(), f)
+	if err != nil {
+		return nil, 0, err
+	}
+	res := []models.LoginAttemptResponse{}
+	for _, v := range l {
+		item := models.LoginAttemptResponse{}
+		item.FromModel(v)
+		res = append(res, item)
+	}
+	return res, total, nil
+}
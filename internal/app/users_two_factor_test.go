@@ -0,0 +1,108 @@
+package app
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mekdep/server/internal/models"
+)
+
+// secret of RFC 6238 test vectors (sha1), "12345678901234567890"
+var totpTestSecret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+func TestTotpCode(t *testing.T) {
+	// last 6 digits of RFC 6238 codes
+	cases := map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1111111111: "050471",
+		1234567890: "005924",
+		2000000000: "279037",
+	}
+	for ts, want := range cases {
+		got, err := totpCode(totpTestSecret, ts/totpPeriod)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("totpCode at %d = %s, want %s", ts, got, want)
+		}
+	}
+	if _, err := totpCode("not base32!", 1); err == nil {
+		t.Error("totpCode with invalid secret: expected error")
+	}
+}
+
+func TestTotpValidate(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	current := now.Unix() / totpPeriod
+	code, _ := totpCode(totpTestSecret, current)
+
+	counter, ok := totpValidate(totpTestSecret, code, now, 0)
+	if !ok || counter != current {
+		t.Fatalf("current code: got %d %v", counter, ok)
+	}
+	// code of same step is not used twice
+	if _, ok := totpValidate(totpTestSecret, code, now, counter); ok {
+		t.Error("used code is accepted again")
+	}
+	// previous and next steps are accepted for clock drift, older ones are not
+	prev, _ := totpCode(totpTestSecret, current-1)
+	if _, ok := totpValidate(totpTestSecret, prev, now, 0); !ok {
+		t.Error("code of previous step is rejected")
+	}
+	old, _ := totpCode(totpTestSecret, current-2)
+	if _, ok := totpValidate(totpTestSecret, old, now, 0); ok {
+		t.Error("code of two steps ago is accepted")
+	}
+	// spaces are ignored, other length is rejected
+	if _, ok := totpValidate(totpTestSecret, code[:3]+" "+code[3:], now, 0); !ok {
+		t.Error("code with space is rejected")
+	}
+	if _, ok := totpValidate(totpTestSecret, code[:5], now, 0); ok {
+		t.Error("short code is accepted")
+	}
+}
+
+func TestTotpSecretGenerate(t *testing.T) {
+	a, err := totpSecretGenerate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := totpSecretGenerate()
+	if a == b {
+		t.Error("secrets are same")
+	}
+	if _, err := totpCode(a, 1); err != nil {
+		t.Errorf("generated secret is not usable: %v", err)
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, hashes, err := recoveryCodesGenerate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(codes) != recoveryCodesCount || len(hashes) != recoveryCodesCount {
+		t.Fatalf("got %d codes, %d hashes", len(codes), len(hashes))
+	}
+	sec := &models.UserSecurity{RecoveryCodes: &hashes}
+	if recoveryCodeUse(sec, "00000-00000") {
+		t.Error("unknown code is accepted")
+	}
+	// codes are case and space insensitive, used only once
+	if !recoveryCodeUse(sec, " "+strings.ToUpper(codes[3])+" ") {
+		t.Fatal("code is rejected")
+	}
+	if sec.RecoveryCodesLeft() != recoveryCodesCount-1 {
+		t.Errorf("codes left = %d", sec.RecoveryCodesLeft())
+	}
+	if recoveryCodeUse(sec, codes[3]) {
+		t.Error("used code is accepted again")
+	}
+	if (models.UserSecurity{}).RecoveryCodesLeft() != 0 {
+		t.Error("codes left without codes")
+	}
+}
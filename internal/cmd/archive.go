@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"log"
+	"os"
+	"strings"
+
+	"github.com/mekdep/server/internal/api/utils"
+	"github.com/mekdep/server/internal/app"
+	"github.com/spf13/cobra"
+)
+
+// ArchiveExportCmd writes academic year into snapshot, all schools when codes are "*"
+func ArchiveExportCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:     "archive-export [period title] [school codes] [file]",
+		Short:   "export academic year of schools into zip snapshot",
+		Example: "archive-export 2024/25 '*' archive2024.zip",
+		Args:    cobra.ExactArgs(3),
+		Run: func(cmd *cobra.Command, args []string) {
+			codes := []string{}
+			if args[1] != "*" && args[1] != "" {
+				codes = strings.Split(args[1], ",")
+			}
+			m, err := app.ArchiveExport(&utils.Session{}, args[0], codes, args[2])
+			if err != nil {
+				log.Fatalln(err)
+			}
+			log.Println("exported:", args[2], m.Title)
+			os.Exit(0)
+		},
+	}
+}
+
+// ArchiveLoadCmd loads snapshot into database of archive server
+func ArchiveLoadCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "archive-load [file]",
+		Short: "load zip snapshot of academic year into database",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			m, err := app.ArchiveLoad(&utils.Session{}, args[0])
+			if err != nil {
+				log.Fatalln(err)
+			}
+			log.Println("loaded:", m.Title, m.ID)
+			os.Exit(0)
+		},
+	}
+}
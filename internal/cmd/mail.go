@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"context"
+	"log"
+	"os"
+
+	"github.com/mekdep/server/internal/app"
+	"github.com/mekdep/server/internal/utils/mail"
+	"github.com/spf13/cobra"
+)
+
+// MailTestCmd sends every template to given address, used to check smtp with local sink (mailpit, mailhog)
+func MailTestCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "mail-test [email]",
+		Short: "send all email templates in all languages to address",
+		Args:  cobra.ExactArgs(1),
+		Run:   mailTestRun,
+	}
+}
+
+func MailDigestCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "mail-digest",
+		Short: "send weekly journal digest to principals and organizations now",
+		Run: func(cmd *cobra.Command, args []string) {
+			err := app.MailWeeklyDigest()
+			if err != nil {
+				log.Fatalln(err)
+			}
+			os.Exit(0)
+		},
+	}
+}
+
+func MailReportRemindersCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "mail-report-reminders",
+		Short: "send report deadline reminders now",
+		Run: func(cmd *cobra.Command, args []string) {
+			err := app.MailReportDeadlineReminders()
+			if err != nil {
+				log.Fatalln(err)
+			}
+			os.Exit(0)
+		},
+	}
+}
+
+func mailTestRun(cmd *cobra.Command, args []string) {
+	data := map[string]interface{}{
+		"Name":           "Test Ulanyjy",
+		"Link":           "http://localhost/test",
+		"ExpiresHours":   24,
+		"StartDate":      "2024-01-01",
+		"EndDate":        "2024-01-07",
+		"SchoolsCount":   2,
+		"FullCount":      1,
+		"AveragePercent": 45,
+		"Rows": []map[string]interface{}{
+			{"School": "1-nji mekdep", "Students": "500", "Lessons": "120 / 100", "Percent": 80, "IsLow": false},
+			{"School": "2-nji mekdep", "Students": "420", "Lessons": "110 / 12", "Percent": 10, "IsLow": true},
+		},
+		"ReportTitle": "Test hasabat",
+		"SchoolName":  "1-nji mekdep",
+		"Deadline":    "2024-01-10 18:00",
+		"DaysLeft":    1,
+	}
+	templates := []string{mail.TemplateVerifyEmail, mail.TemplatePasswordReset, mail.TemplateWeeklyDigest, mail.TemplateReportDeadline}
+	for _, t := range templates {
+		for _, lang := range mail.Langs {
+			msg, err := mail.Render(t, lang, data)
+			if err != nil {
+				log.Fatalln(t, lang, err)
+			}
+			msg.To = []string{args[0]}
+			err = mail.Provider().Send(context.Background(), msg)
+			if err != nil {
+				log.Fatalln(t, lang, err)
+			}
+			log.Println("sent:", t, lang, msg.Subject)
+		}
+	}
+	os.Exit(0)
+}
@@ -26,6 +26,12 @@ func Init() {
 	rootCmd.AddCommand(UpdatePeriodGradesCmd())
 	rootCmd.AddCommand(UpdatePaymentStatusCmd())
 	rootCmd.AddCommand(MakeAdminCmd())
+	rootCmd.AddCommand(ProcessImagesCmd())
+	rootCmd.AddCommand(MailTestCmd())
+	rootCmd.AddCommand(MailDigestCmd())
+	rootCmd.AddCommand(MailReportRemindersCmd())
+	rootCmd.AddCommand(ArchiveExportCmd())
+	rootCmd.AddCommand(ArchiveLoadCmd())
 	err := rootCmd.Execute()
 	if err != nil {
 		log.Fatal(err)
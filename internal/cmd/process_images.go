@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"log"
+	"os"
+
+	"github.com/mekdep/server/internal/api/utils"
+	"github.com/mekdep/server/internal/models"
+	"github.com/mekdep/server/internal/store"
+	"github.com/mekdep/server/internal/utils/storage"
+	"github.com/spf13/cobra"
+)
+
+func ProcessImagesCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "process-images",
+		Short: "generate thumbnails for avatars and galleries uploaded before image processing",
+		Run:   processImagesRun,
+	}
+}
+
+func processImagesRun(cmd *cobra.Command, args []string) {
+	err := processImages(&utils.Session{})
+	if err != nil {
+		log.Fatalln(err)
+	}
+	os.Exit(0)
+}
+
+func processImages(ses *utils.Session) error {
+	limit := 500
+	processed := 0
+	process := func(p *string) {
+		if p == nil || *p == "" || !storage.IsImage(*p) {
+			return
+		}
+		err := storage.ProcessImage(ses.Context(), *p)
+		if err != nil {
+			log.Println("# Error: ", *p, err)
+			return
+		}
+		processed++
+	}
+
+	for offset := 0; ; offset += limit {
+		f := models.SchoolFilterRequest{}
+		f.Limit = &limit
+		f.Offset = new(int)
+		*f.Offset = offset
+		schools, _, err := store.Store().SchoolsFindBy(ses.Context(), f)
+		if err != nil {
+			return err
+		}
+		for _, s := range schools {
+			process(s.Avatar)
+			if s.Galleries != nil {
+				for _, g := range *s.Galleries {
+					process(&g)
+				}
+			}
+		}
+		if len(schools) < limit {
+			break
+		}
+	}
+	log.Println("# Schools done, images: ", processed)
+
+	for offset := 0; ; offset += limit {
+		f := models.UserFilterRequest{}
+		f.Limit = &limit
+		f.Offset = new(int)
+		*f.Offset = offset
+		users, _, err := store.Store().UsersFindBy(ses.Context(), f)
+		if err != nil {
+			return err
+		}
+		for _, u := range users {
+			process(u.Avatar)
+		}
+		if len(users) < limit {
+			break
+		}
+	}
+	log.Println("# Users done, images: ", processed)
+	return nil
+}
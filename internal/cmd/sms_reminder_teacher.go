@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/mekdep/server/internal/app"
@@ -85,37 +86,31 @@ func SendReminderTeacherBySchool(school models.School, isPrincipal bool) error {
 }
 
 func SendReminderTeacher(date time.Time, teacher *models.User, subjectPercents []models.DashboardSubjectsPercent) error {
-	msg := date.Format(time.DateOnly) + " senesinde " + strconv.Itoa(len(subjectPercents)) + ` sapak hasaba alyndy, olar:
-	
-	`
+	country := models.DefaultCountry()
+	lines := []string{country.SmsText(models.SmsTeacherJournal, "date", date.Format(time.DateOnly), "count", strconv.Itoa(len(subjectPercents))), ""}
 	noFullCount := 0
 	for _, sp := range subjectPercents {
 		if !sp.IsGradeFull {
 			noFullCount++
-			msg += sp.ClassroomName + ` ` + sp.SubjectName + ` - žurnal doly däl
-`
+			lines = append(lines, country.SmsText(models.SmsTeacherJournalNotFull, "classroom", sp.ClassroomName, "subject", sp.SubjectName))
 		} else {
-			msg += sp.ClassroomName + ` ` + sp.SubjectName + ` - žurnal doly 
-`
+			lines = append(lines, country.SmsText(models.SmsTeacherJournalFull, "classroom", sp.ClassroomName, "subject", sp.SubjectName))
 		}
 	}
 	if noFullCount > 0 {
-		msg += `
-` +
-			strconv.Itoa(noFullCount) + ` sany doly däl žurnallary girizmek: ` + app.AppOpenLink
+		lines = append(lines, "", country.SmsText(models.SmsTeacherJournalFooter, "count", strconv.Itoa(noFullCount)))
 		phone, err := teacher.FormattedPhone()
 		if err != nil {
 			return nil
 		}
-		app.SendSMS([]string{phone}, msg, models.SmsTypeReminder)
+		app.SendSMS([]string{phone}, strings.Join(lines, "\n"), models.SmsTypeReminder)
 	}
 	return nil
 }
 
 func SendReminderPrincipal(principal *models.User, teachers []*models.User, subjects []*models.Subject, subjectPercents []models.DashboardSubjectsPercent) error {
-	msg := "Hormatly Mekdep müdiri! Jemi " + strconv.Itoa(len(teachers)) + ` mugallym hasaba alynyp, olaryň žurnal dolduryş hasabaty:
-
-`
+	country := models.DefaultCountry()
+	lines := []string{country.SmsText(models.SmsPrincipalJournal, "count", strconv.Itoa(len(teachers))), ""}
 	noFullTeacherCount := 0
 	for _, teacher := range teachers {
 		sp := []models.DashboardSubjectsPercent{}
@@ -134,18 +129,16 @@ func SendReminderPrincipal(principal *models.User, teachers []*models.User, subj
 		}
 		if noFullCount > 0 {
 			noFullTeacherCount++
-			msg += teacher.FullName() + ` jemi: ` + strconv.Itoa(len(sp)) + ` - doly däl: ` + strconv.Itoa(noFullCount) + `
-`
+			lines = append(lines, country.SmsText(models.SmsPrincipalJournalItem, "teacher", teacher.FullName(), "total", strconv.Itoa(len(sp)), "count", strconv.Itoa(noFullCount)))
 		}
 	}
 	if noFullTeacherCount > 0 {
-		msg += `
-Giňişleýin ` + strconv.Itoa(noFullTeacherCount) + ` doldurmadyklary görmek: ` + app.AppOpenLink
+		lines = append(lines, "", country.SmsText(models.SmsPrincipalJournalEnd, "count", strconv.Itoa(noFullTeacherCount)))
 		phone, err := principal.FormattedPhone()
 		if err != nil {
 			return nil
 		}
-		app.SendSMS([]string{phone}, msg, models.SmsTypeReminder)
+		app.SendSMS([]string{phone}, strings.Join(lines, "\n"), models.SmsTypeReminder)
 	}
 	return nil
 }
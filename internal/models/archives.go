@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// ArchiveTables are data of academic year in snapshot, loader inserts them in this order
+var ArchiveTables = []string{
+	"schools",
+	"users",
+	"user_schools",
+	"user_parents",
+	"base_subjects",
+	"periods",
+	"shifts",
+	"classrooms",
+	"user_classrooms",
+	"subjects",
+	"subject_exams",
+	"timetables",
+	"lessons",
+	"grades",
+	"absents",
+	"period_grades",
+}
+
+const ArchiveManifestFile = "manifest.json"
+
+// ArchiveSnapshot is manifest of exported academic year, stored in archive server when loaded
+type ArchiveSnapshot struct {
+	ID         string         `json:"id"`
+	Title      string         `json:"title"`
+	PeriodIds  []string       `json:"period_ids"`
+	SchoolIds  []string       `json:"school_ids"`
+	Tables     map[string]int `json:"tables"`
+	ApiVersion *string        `json:"api_version"`
+	ExportedAt *time.Time     `json:"exported_at"`
+	LoadedAt   *time.Time     `json:"loaded_at"`
+}
+
+func (ArchiveSnapshot) RelationFields() []string {
+	return []string{}
+}
@@ -14,6 +14,7 @@ type Book struct {
 	FileSize       *int       `json:"file_size"`
 	FilePreview    *string    `json:"file_preview"`
 	IsDownloadable bool       `json:"is_downloadable"`
+	ProcessStatus  *string    `json:"process_status"`
 	CreatedAt      *time.Time `json:"created_at"`
 	UpdatedAt      *time.Time `json:"updated_at"`
 }
@@ -48,6 +49,7 @@ type BookResponse struct {
 	FileSize       *int       `json:"file_size"`
 	FilePreview    *string    `json:"file_preview"`
 	IsDownloadable bool       `json:"is_downloadable"`
+	ProcessStatus  *string    `json:"process_status"`
 	CreatedAt      *time.Time `json:"created_at"`
 	UpdatedAt      *time.Time `json:"updated_at"`
 }
@@ -84,6 +86,7 @@ func (r *BookResponse) FromModel(m *Book) error {
 		r.FilePreview = &f
 	}
 	r.IsDownloadable = m.IsDownloadable
+	r.ProcessStatus = m.ProcessStatus
 	r.CreatedAt = m.CreatedAt
 	r.UpdatedAt = m.UpdatedAt
 	return nil
@@ -116,6 +119,61 @@ type BookFilterRequest struct {
 	PaginationRequest
 }
 
+// pdf processing status of book (page count, cover, page thumbnails and text for search)
+const (
+	BookProcessPending    = "pending"
+	BookProcessProcessing = "processing"
+	BookProcessDone       = "done"
+	BookProcessFailed     = "failed"
+)
+
+type BookPage struct {
+	ID        string     `json:"id"`
+	BookId    string     `json:"book_id"`
+	Page      int        `json:"page"`
+	Thumbnail *string    `json:"thumbnail"`
+	Content   *string    `json:"content"`
+	CreatedAt *time.Time `json:"created_at"`
+}
+
+func (BookPage) RelationFields() []string {
+	return []string{}
+}
+
+type BookPageResponse struct {
+	Page      int     `json:"page"`
+	Thumbnail *string `json:"thumbnail"`
+}
+
+func (r *BookPageResponse) FromModel(m *BookPage) {
+	r.Page = m.Page
+	if m.Thumbnail != nil {
+		t := fileUrl(m.Thumbnail)
+		r.Thumbnail = &t
+	}
+}
+
+// BookPageSearch is a found page, headline is a fragment of text with <b> marked matches
+type BookPageSearch struct {
+	BookId   string
+	Page     int
+	Headline string
+}
+
+type BookPageSearchResponse struct {
+	Book     *BookResponse `json:"book"`
+	BookId   string        `json:"book_id"`
+	BookPage int           `json:"book_page"`
+	Headline string        `json:"headline"`
+}
+
+type BookPageSearchRequest struct {
+	Search     string    `form:"search" validate:"required"`
+	BookIds    *[]string `form:"book_ids"`
+	Categories *[]string `form:"categories"`
+	PaginationRequest
+}
+
 var BookCategories = []string{
 	"1-nji synp",
 	"2-nji synp",
@@ -0,0 +1,39 @@
+package models
+
+// ContactDataPayload is structured data_complaint request, kept to be applied to users by operator
+type ContactDataPayload struct {
+	ParentFirstName      *string          `json:"parent_first_name"`
+	ParentLastName       *string          `json:"parent_last_name"`
+	ParentPhone          *string          `json:"parent_phone"`
+	ParentPassportNumber *string          `json:"parent_passport_number"`
+	ParentBirthday       *string          `json:"parent_birthday"`
+	Children             []ChildrenObject `json:"children"`
+}
+
+type ContactDataAction string
+
+const ContactDataActionCreate ContactDataAction = "create"
+const ContactDataActionUpdate ContactDataAction = "update"
+const ContactDataActionLink ContactDataAction = "link"
+const ContactDataActionUnlink ContactDataAction = "unlink"
+
+// ContactDataActionNone is not applied: already done, not found or ambiguous (see comment)
+const ContactDataActionNone ContactDataAction = "none"
+
+// ContactDataChange is one proposed change of parent (Child is nil) or child
+type ContactDataChange struct {
+	Action          ContactDataAction  `json:"action"`
+	Child           *ChildrenObject    `json:"child"`
+	User            *UserResponse      `json:"user"`
+	Classroom       *ClassroomResponse `json:"classroom"`
+	CandidatesCount int                `json:"candidates_count"`
+	Comment         string             `json:"comment"`
+}
+
+// ContactDataProposal is change set of data_complaint, shown to operator before apply
+type ContactDataProposal struct {
+	ContactItemId string              `json:"contact_item_id"`
+	Parent        *UserResponse       `json:"parent"`
+	Changes       []ContactDataChange `json:"changes"`
+	IsApplicable  bool                `json:"is_applicable"`
+}
@@ -22,31 +22,44 @@ const ContactStatusBacklog ContactStatus = "backlog"
 const ContactStatusRejected ContactStatus = "rejected"
 
 type ContactItems struct {
-	ID                   string           `json:"id"`
-	UserId               *string          `json:"user_id"`
-	SchoolId             *string          `json:"school_id"`
-	Message              *string          `json:"message"`
-	Type                 string           `json:"type"`
-	Status               string           `json:"status"`
-	Files                *[]string        `json:"files"`
-	Note                 *string          `json:"note"`
-	ClassroomName        *string          `json:"classroom_name"`
-	ParentPhone          *string          `json:"parent_phone"`
-	BirthCertNumber      *string          `json:"birth_cert_number"`
-	RelatedChildrenCount int              `json:"related_children_count"`
-	RelatedId            *string          `json:"related_id"`
-	CreatedAt            *time.Time       `json:"created_at"`
-	UpdatedAt            *time.Time       `json:"updated_at"`
-	UpdatedBy            *string          `json:"updated_by_id"`
-	User                 *User            `json:"user"`
-	School               *School          `json:"school"`
-	Related              *ContactItems    `json:"related"`
-	RelatedChildren      *[]*ContactItems `json:"related_children"`
-	UpdatedByUser        *User            `json:"updated_by"`
+	ID                   string              `json:"id"`
+	UserId               *string             `json:"user_id"`
+	SchoolId             *string             `json:"school_id"`
+	Message              *string             `json:"message"`
+	Type                 string              `json:"type"`
+	Status               string              `json:"status"`
+	Files                *[]string           `json:"files"`
+	Note                 *string             `json:"note"`
+	ClassroomName        *string             `json:"classroom_name"`
+	ParentPhone          *string             `json:"parent_phone"`
+	BirthCertNumber      *string             `json:"birth_cert_number"`
+	RelatedChildrenCount int                 `json:"related_children_count"`
+	RelatedId            *string             `json:"related_id"`
+	CreatedAt            *time.Time          `json:"created_at"`
+	UpdatedAt            *time.Time          `json:"updated_at"`
+	UpdatedBy            *string             `json:"updated_by_id"`
+	AssigneeId           *string             `json:"assignee_id"`
+	AssignedAt           *time.Time          `json:"assigned_at"`
+	FirstResponseAt      *time.Time          `json:"first_response_at"`
+	ResolvedAt           *time.Time          `json:"resolved_at"`
+	DueAt                *time.Time          `json:"due_at"`
+	EscalatedAt          *time.Time          `json:"escalated_at"`
+	Payload              *ContactDataPayload `json:"payload"`
+	User                 *User               `json:"user"`
+	School               *School             `json:"school"`
+	Related              *ContactItems       `json:"related"`
+	RelatedChildren      *[]*ContactItems    `json:"related_children"`
+	UpdatedByUser        *User               `json:"updated_by"`
+	Assignee             *User               `json:"assignee"`
+	Replies              []*ContactReply     `json:"replies"`
 }
 
 func (ContactItems) RelationFields() []string {
-	return []string{"User", "School", "Related", "RelatedChildren", "UpdatedByUser"}
+	return []string{"User", "School", "Related", "RelatedChildren", "UpdatedByUser", "Assignee", "Replies"}
+}
+
+func (m ContactItems) IsOverdue() bool {
+	return m.ResolvedAt == nil && m.DueAt != nil && m.DueAt.Before(time.Now())
 }
 
 type ContactItemsRequest struct {
@@ -95,6 +108,15 @@ type ContactItemsResponse struct {
 	Related              *ContactItemsResponse   `json:"related"`
 	RelatedChildren      *[]ContactItemsResponse `json:"related_children"`
 	UpdatedByUser        *UserResponse           `json:"updated_by"`
+	Assignee             *UserResponse           `json:"assignee"`
+	AssignedAt           *time.Time              `json:"assigned_at"`
+	FirstResponseAt      *time.Time              `json:"first_response_at"`
+	ResolvedAt           *time.Time              `json:"resolved_at"`
+	DueAt                *time.Time              `json:"due_at"`
+	EscalatedAt          *time.Time              `json:"escalated_at"`
+	IsOverdue            bool                    `json:"is_overdue"`
+	Replies              *[]ContactReplyResponse `json:"replies"`
+	Payload              *ContactDataPayload     `json:"payload"`
 }
 
 func (r *ContactItemsRequest) ToModel(m *ContactItems) error {
@@ -155,14 +177,24 @@ func (r *ContactItemsRequest) ToModel(m *ContactItems) error {
 			*m.Message += fmt.Sprintf("\n Okuwçynyň %v familýasy: %v", k+1, *v.LastName)
 			*m.Message += fmt.Sprintf("\n Okuwçynyň %v synpy: %v", k+1, *v.ClassroomName)
 			*m.Message += fmt.Sprintf("\n Okuwçynyň %v dog. şah.: %v", k+1, *v.BirthCertNumber)
-			if m.BirthCertNumber != nil && *m.BirthCertNumber != "" {
+			if m.BirthCertNumber == nil || *m.BirthCertNumber == "" {
 				m.BirthCertNumber = v.BirthCertNumber
 			}
-			if m.ClassroomName != nil && *m.ClassroomName != "" {
+			if m.ClassroomName == nil || *m.ClassroomName == "" {
 				m.ClassroomName = v.ClassroomName
 			}
 		}
 	}
+	if r.Type == ContactTypeDataComplaint && (r.ParentFirstName != nil && *r.ParentFirstName != "" || len(r.Children) > 0) {
+		m.Payload = &ContactDataPayload{
+			ParentFirstName:      r.ParentFirstName,
+			ParentLastName:       r.ParentLastName,
+			ParentPhone:          r.ParentPhone,
+			ParentPassportNumber: r.ParentPassportNumber,
+			ParentBirthday:       r.ParentBirthday,
+			Children:             r.Children,
+		}
+	}
 	m.Type = r.Type
 	m.Status = r.Status
 	m.Files = r.Files
@@ -194,6 +226,7 @@ func (r *ContactItemsResponse) FromModel(m *ContactItems) error {
 	r.ClassroomName = m.ClassroomName
 	r.BirthCertNumber = m.BirthCertNumber
 	r.RelatedChildrenCount = m.RelatedChildrenCount
+	r.Payload = m.Payload
 	if m.Related != nil {
 		r.Related = &ContactItemsResponse{}
 		r.Related.FromModel(m.Related)
@@ -212,6 +245,24 @@ func (r *ContactItemsResponse) FromModel(m *ContactItems) error {
 			*r.Files = append(*r.Files, fileUrl(&f))
 		}
 	}
+	if m.Assignee != nil {
+		r.Assignee = &UserResponse{}
+		r.Assignee.FromModel(m.Assignee)
+	}
+	r.AssignedAt = m.AssignedAt
+	r.FirstResponseAt = m.FirstResponseAt
+	r.ResolvedAt = m.ResolvedAt
+	r.DueAt = m.DueAt
+	r.EscalatedAt = m.EscalatedAt
+	r.IsOverdue = m.IsOverdue()
+	if m.Replies != nil {
+		r.Replies = &[]ContactReplyResponse{}
+		for _, v := range m.Replies {
+			item := ContactReplyResponse{}
+			item.FromModel(v)
+			*r.Replies = append(*r.Replies, item)
+		}
+	}
 	r.CreatedAt = m.CreatedAt
 	r.UpdatedAt = m.UpdatedAt
 	return nil
@@ -232,5 +283,7 @@ type ContactItemsFilterRequest struct {
 	OnlyNotRelated *bool     `json:"only_not_related" form:"only_not_related"` // TODO @sohbet
 	StartDate      *string   `json:"start_date" form:"start_date"`
 	EndDate        *string   `json:"end_date" form:"end_date"`
+	AssigneeId     *string   `json:"assignee_id" form:"assignee_id"`
+	IsOverdue      *bool     `json:"is_overdue" form:"is_overdue"`
 	PaginationRequest
 }
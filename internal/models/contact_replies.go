@@ -0,0 +1,96 @@
+package models
+
+import (
+	"slices"
+	"time"
+)
+
+// ContactSla is time given to answer and to resolve contact item of type
+type ContactSla struct {
+	Response   time.Duration
+	Resolution time.Duration
+}
+
+var DefaultContactSla = map[string]ContactSla{
+	ContactTypeComplaint:     {Response: time.Hour * 24, Resolution: time.Hour * 24 * 3},
+	ContactTypeDataComplaint: {Response: time.Hour * 24, Resolution: time.Hour * 24 * 5},
+	ContactTypeSuggestion:    {Response: time.Hour * 24 * 3, Resolution: time.Hour * 24 * 14},
+	ContactTypeReview:        {Response: time.Hour * 24 * 3, Resolution: time.Hour * 24 * 14},
+}
+
+// ContactStatusTransitions are statuses allowed after status, done and rejected are reopened to processing
+var ContactStatusTransitions = map[ContactStatus][]ContactStatus{
+	ContactStatusWaiting:    {ContactStatusTodo, ContactStatusProcessing, ContactStatusBacklog, ContactStatusDone, ContactStatusRejected},
+	ContactStatusTodo:       {ContactStatusProcessing, ContactStatusBacklog, ContactStatusDone, ContactStatusRejected},
+	ContactStatusProcessing: {ContactStatusTodo, ContactStatusBacklog, ContactStatusDone, ContactStatusRejected},
+	ContactStatusBacklog:    {ContactStatusTodo, ContactStatusProcessing, ContactStatusDone, ContactStatusRejected},
+	ContactStatusDone:       {ContactStatusProcessing},
+	ContactStatusRejected:   {ContactStatusProcessing},
+}
+
+func ContactStatusIsResolved(status string) bool {
+	return status == string(ContactStatusDone) || status == string(ContactStatusRejected)
+}
+
+func ContactStatusCanChange(from string, to string) bool {
+	return slices.Contains(ContactStatusTransitions[ContactStatus(from)], ContactStatus(to))
+}
+
+// ContactReply is message in thread of contact item, or status change when Status is set.
+// Internal replies are notes of operators, not shown to submitter
+type ContactReply struct {
+	ID            string     `json:"id"`
+	ContactItemId string     `json:"contact_item_id"`
+	UserId        *string    `json:"user_id"`
+	Message       *string    `json:"message"`
+	Status        *string    `json:"status"`
+	Files         *[]string  `json:"files"`
+	IsInternal    bool       `json:"is_internal"`
+	CreatedAt     *time.Time `json:"created_at"`
+	User          *User      `json:"user"`
+}
+
+func (ContactReply) RelationFields() []string {
+	return []string{"User"}
+}
+
+type ContactReplyRequest struct {
+	ContactItemId *string `json:"contact_item_id"`
+	Message       *string `json:"message" validate:"required"`
+	IsInternal    *bool   `json:"is_internal"`
+}
+
+type ContactReplyResponse struct {
+	ID         string        `json:"id"`
+	User       *UserResponse `json:"user"`
+	Message    *string       `json:"message"`
+	Status     *string       `json:"status"`
+	Files      *[]string     `json:"files"`
+	IsInternal bool          `json:"is_internal"`
+	CreatedAt  *time.Time    `json:"created_at"`
+}
+
+func (r *ContactReplyResponse) FromModel(m *ContactReply) error {
+	r.ID = m.ID
+	if m.User != nil {
+		r.User = &UserResponse{}
+		r.User.FromModel(m.User)
+	}
+	r.Message = m.Message
+	r.Status = m.Status
+	if m.Files != nil {
+		r.Files = &[]string{}
+		for _, f := range *m.Files {
+			*r.Files = append(*r.Files, fileUrl(&f))
+		}
+	}
+	r.IsInternal = m.IsInternal
+	r.CreatedAt = m.CreatedAt
+	return nil
+}
+
+type ContactItemsAssignRequest struct {
+	ID *string `json:"id"`
+	// empty to unassign
+	AssigneeId *string `json:"assignee_id"`
+}
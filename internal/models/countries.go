@@ -0,0 +1,177 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mekdep/server/config"
+)
+
+// Country is pack of deployment: phone numbering, holidays, default subjects and sms templates
+type Country struct {
+	Code        string `json:"code"`
+	CallingCode string `json:"calling_code"`
+	// digits of national number, without calling code and trunk prefix
+	PhoneLength int    `json:"phone_length"`
+	TrunkPrefix string `json:"trunk_prefix"`
+	// link to app which is put in sms
+	AppLink  string           `json:"app_link"`
+	Vacation string           `json:"vacation"`
+	Holidays []HolidaySetting `json:"holidays"`
+	// short name, full name, area, color
+	Subjects [][]string             `json:"subjects"`
+	Sms      map[SmsTemplate]string `json:"sms"`
+}
+
+type CountrySetting struct {
+	Code        string `json:"code"`
+	CallingCode string `json:"calling_code"`
+	PhoneLength int    `json:"phone_length"`
+	Timezone    string `json:"timezone"`
+}
+
+// SmsTemplate has placeholders in braces, {link} is app link of country
+type SmsTemplate string
+
+const (
+	SmsOtp                   SmsTemplate = "otp"              // {code}
+	SmsWelcome               SmsTemplate = "welcome"          // {child} {tariff} {days}
+	SmsChildTitle            SmsTemplate = "child_title"      // {child}
+	SmsChild                 SmsTemplate = "child"            // {child} {text}
+	SmsDailyGrades           SmsTemplate = "daily_grades"     // {grades}
+	SmsDailyAbsents          SmsTemplate = "daily_absents"    // {subjects}
+	SmsGrade                 SmsTemplate = "grade"            // {subject} {value}
+	SmsAbsent                SmsTemplate = "absent"           // {subject}
+	SmsTariffEnds            SmsTemplate = "tariff_ends"      // {child} {days}
+	SmsTariffEndsBody        SmsTemplate = "tariff_ends_body" // {days}
+	SmsUnreadMessages        SmsTemplate = "unread_messages"  // {count}
+	SmsLoginTitle            SmsTemplate = "login_title"
+	SmsLoginNewDevice        SmsTemplate = "login_new_device"         // {device} {ip}
+	SmsLoginNewLocation      SmsTemplate = "login_new_location"       // {ip}
+	SmsTeacherJournal        SmsTemplate = "teacher_journal"          // {date} {count}
+	SmsTeacherJournalFull    SmsTemplate = "teacher_journal_full"     // {classroom} {subject}
+	SmsTeacherJournalNotFull SmsTemplate = "teacher_journal_not_full" // {classroom} {subject}
+	SmsTeacherJournalFooter  SmsTemplate = "teacher_journal_footer"   // {count}
+	SmsPrincipalJournal      SmsTemplate = "principal_journal"        // {count}
+	SmsPrincipalJournalItem  SmsTemplate = "principal_journal_item"   // {teacher} {total} {count}
+	SmsPrincipalJournalEnd   SmsTemplate = "principal_journal_end"    // {count}
+)
+
+var Countries = map[string]*Country{
+	CountryTM.Code: &CountryTM,
+}
+
+// DefaultCountry is country of deployment (see config DEFAULT_COUNTRY)
+func DefaultCountry() *Country {
+	if c, ok := Countries[config.Conf.DefaultCountry]; ok {
+		return c
+	}
+	return &CountryTM
+}
+
+func (c *Country) Setting() CountrySetting {
+	return CountrySetting{
+		Code:        c.Code,
+		CallingCode: c.CallingCode,
+		PhoneLength: c.PhoneLength,
+		Timezone:    config.Conf.Timezone,
+	}
+}
+
+// SmsText fills template, args are pairs of placeholder and value: SmsText(SmsOtp, "code", "1234")
+func (c *Country) SmsText(t SmsTemplate, args ...string) string {
+	pairs := []string{"{link}", c.AppLink}
+	for i := 0; i+1 < len(args); i += 2 {
+		pairs = append(pairs, "{"+args[i]+"}", args[i+1])
+	}
+	return strings.NewReplacer(pairs...).Replace(c.Sms[t])
+}
+
+// CountryPackLoad adds country of json file to built in ones, sms templates and app link which are missed are of Turkmenistan
+func CountryPackLoad(path string) error {
+	if path == "" {
+		return nil
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	c := Country{}
+	err = json.Unmarshal(b, &c)
+	if err != nil {
+		return err
+	}
+	c.Code = strings.ToUpper(c.Code)
+	if c.Code == "" || c.CallingCode == "" || c.PhoneLength < 1 {
+		return errors.New("country pack: code, calling_code and phone_length are required")
+	}
+	if c.AppLink == "" {
+		c.AppLink = CountryTM.AppLink
+	}
+	if c.Vacation == "" {
+		c.Vacation = CountryTM.Vacation
+	}
+	if c.Sms == nil {
+		c.Sms = map[SmsTemplate]string{}
+	}
+	for k, v := range CountryTM.Sms {
+		if _, ok := c.Sms[k]; !ok {
+			c.Sms[k] = v
+		}
+	}
+	Countries[c.Code] = &c
+	return nil
+}
+
+var phoneNonDigits = regexp.MustCompile("[^0-9]")
+
+// PhoneE164 formats number as +<calling code><national number>, numbers without calling code are of default country:
+// 65123456, 8 65 123456, 993 65 123456, +993 65 123456 -> +99365123456
+func PhoneE164(phone string) (string, error) {
+	phone = strings.TrimSpace(phone)
+	isIntl := strings.HasPrefix(phone, "+") || strings.HasPrefix(phone, "00")
+	digits := phoneNonDigits.ReplaceAllString(phone, "")
+	if strings.HasPrefix(phone, "00") {
+		digits = digits[2:]
+	}
+	c := DefaultCountry()
+	if !isIntl {
+		if len(digits) == c.PhoneLength {
+			digits = c.CallingCode + digits
+		} else if c.TrunkPrefix != "" && len(digits) == len(c.TrunkPrefix)+c.PhoneLength && strings.HasPrefix(digits, c.TrunkPrefix) {
+			digits = c.CallingCode + digits[len(c.TrunkPrefix):]
+		} else if len(digits) != len(c.CallingCode)+c.PhoneLength || !strings.HasPrefix(digits, c.CallingCode) {
+			return "", errors.New("phone is invalid")
+		}
+	}
+	// numbers of known countries have fixed length, others are checked by length of E.164 only
+	for _, v := range Countries {
+		if strings.HasPrefix(digits, v.CallingCode) && len(digits) != len(v.CallingCode)+v.PhoneLength {
+			return "", errors.New("phone is invalid")
+		}
+	}
+	if len(digits) < 8 || len(digits) > 15 {
+		return "", errors.New("phone is invalid")
+	}
+	return "+" + digits, nil
+}
+
+var locations sync.Map
+
+// LoadLocation caches timezones, nil when name is unknown
+func LoadLocation(name string) *time.Location {
+	if v, ok := locations.Load(name); ok {
+		return v.(*time.Location)
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil
+	}
+	locations.Store(name, loc)
+	return loc
+}
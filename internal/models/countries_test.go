@@ -0,0 +1,92 @@
+package models
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mekdep/server/config"
+)
+
+func TestPhoneE164(t *testing.T) {
+	config.Conf.DefaultCountry = "TM"
+	cases := map[string]string{
+		"65123456":          "+99365123456",
+		"8 65 123456":       "+99365123456",
+		"8(65)12-34-56":     "+99365123456",
+		"99365123456":       "+99365123456",
+		"+993 65 123456":    "+99365123456",
+		"0099365123456":     "+99365123456",
+		"+7 912 345 67 89":  "+79123456789",
+		" +44 20 7946 0958": "+442079460958",
+	}
+	for phone, want := range cases {
+		got, err := PhoneE164(phone)
+		if err != nil || got != want {
+			t.Errorf("PhoneE164(%q) = %q, %v, want %q", phone, got, err, want)
+		}
+	}
+	invalid := []string{
+		"",
+		"6512345",
+		"651234567",
+		"7 65 123456",
+		"+993 65 12345",
+		"+993 65 1234567",
+		"+1 234",
+		"+1234567890123456",
+	}
+	for _, phone := range invalid {
+		if got, err := PhoneE164(phone); err == nil {
+			t.Errorf("PhoneE164(%q) = %q, expected error", phone, got)
+		}
+	}
+}
+
+func TestPhoneE164DefaultCountry(t *testing.T) {
+	defer func() {
+		config.Conf.DefaultCountry = "TM"
+		delete(Countries, "UZ")
+	}()
+	p := filepath.Join(t.TempDir(), "uz.json")
+	err := os.WriteFile(p, []byte(`{"code": "uz", "calling_code": "998", "phone_length": 9, "sms": {"otp": "Kod: {code}"}}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = CountryPackLoad(p); err != nil {
+		t.Fatal(err)
+	}
+	config.Conf.DefaultCountry = "UZ"
+	c := DefaultCountry()
+	if c.Code != "UZ" {
+		t.Fatalf("default country = %s", c.Code)
+	}
+	// national numbers are of default country, other countries keep their lengths
+	if got, err := PhoneE164("90 123 45 67"); err != nil || got != "+998901234567" {
+		t.Errorf("national number = %q, %v", got, err)
+	}
+	if got, err := PhoneE164("+993 65 123456"); err != nil || got != "+99365123456" {
+		t.Errorf("number of TM = %q, %v", got, err)
+	}
+	if _, err := PhoneE164("65123456"); err == nil {
+		t.Error("TM national number is accepted in UZ")
+	}
+	// missed templates are of TM
+	if got := c.SmsText(SmsOtp, "code", "12345"); got != "Kod: 12345" {
+		t.Errorf("sms otp = %q", got)
+	}
+	if c.Sms[SmsWelcome] != CountryTM.Sms[SmsWelcome] || c.AppLink != CountryTM.AppLink {
+		t.Error("missed sms templates or app link are not of TM")
+	}
+}
+
+func TestCountryPackLoadInvalid(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "xx.json")
+	_ = os.WriteFile(p, []byte(`{"code": "xx"}`), 0644)
+	if err := CountryPackLoad(p); err == nil {
+		t.Error("pack without calling code is loaded")
+	}
+	if err := CountryPackLoad(""); err != nil {
+		t.Errorf("empty path: %v", err)
+	}
+}
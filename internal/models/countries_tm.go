@@ -0,0 +1,37 @@
+package models
+
+var CountryTM = Country{
+	Code:        "TM",
+	CallingCode: "993",
+	PhoneLength: 8,
+	TrunkPrefix: "8",
+	AppLink:     "mekdep.edu.tm/redirect/app",
+	Vacation:    "Dynç alyş",
+	Holidays:    DefaultHolidays,
+	Subjects:    DefaultSubjects,
+	Sms: map[SmsTemplate]string{
+		SmsOtp: "emekdep code: {code}",
+		SmsWelcome: `Caganyz {child} eMekdep programmada "{tariff}" nyrhnama birikdi. Gutarmagyna {days} gun galdy.
+Elektron gundelik, Jemleyji bahalar, SMS habarlar, Gyzyklanma analitika we basgalar.
+Peydalanmak: {link}`,
+		SmsChildTitle:            "Çagaňyz {child}",
+		SmsChild:                 "Çagaňyz {child} {text} Giňişleýin {link}",
+		SmsDailyGrades:           "Elektron gündeliginde {grades} baha aldy.",
+		SmsDailyAbsents:          "{subjects} sapagyna gatnaşmady.",
+		SmsGrade:                 "{subject} {value} baha aldy.",
+		SmsAbsent:                "{subject} sapagyna gatnaşmady.",
+		SmsTariffEnds:            "Çagaňyz {child} eMekdep programmada nyrhnamasynyň gutarmagyna {days} gün galdy! Programmada töleg edip uzaltmagy unutmaň!",
+		SmsTariffEndsBody:        "Nyrhnamanyň gutarmagyna {days} gün galdy!",
+		SmsUnreadMessages:        "Size {count} sany täze hat geldi",
+		SmsLoginTitle:            "Howpsuzlyk",
+		SmsLoginNewDevice:        "Hasabyňyza täze enjamdan girildi: {device} ({ip})",
+		SmsLoginNewLocation:      "Hasabyňyza täze ýerden girildi: {ip}",
+		SmsTeacherJournal:        "{date} senesinde {count} sapak hasaba alyndy, olar:",
+		SmsTeacherJournalFull:    "{classroom} {subject} - žurnal doly",
+		SmsTeacherJournalNotFull: "{classroom} {subject} - žurnal doly däl",
+		SmsTeacherJournalFooter:  "{count} sany doly däl žurnallary girizmek: {link}",
+		SmsPrincipalJournal:      "Hormatly Mekdep müdiri! Jemi {count} mugallym hasaba alynyp, olaryň žurnal dolduryş hasabaty:",
+		SmsPrincipalJournalItem:  "{teacher} jemi: {total} - doly däl: {count}",
+		SmsPrincipalJournalEnd:   "Giňişleýin {count} doldurmadyklary görmek: {link}",
+	},
+}
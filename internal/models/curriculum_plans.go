@@ -0,0 +1,128 @@
+package models
+
+import (
+	"time"
+	"unicode"
+)
+
+// CurriculumPlan is ordered list of topics of base subject for class year,
+// topics are mapped one by one onto lessons of subjects (cancelled lessons and holidays are skipped)
+type CurriculumPlan struct {
+	ID            string        `json:"id"`
+	BaseSubjectId string        `json:"base_subject_id"`
+	Classyear     string        `json:"classyear"`
+	TopicIds      []string      `json:"topic_ids"`
+	UpdatedAt     *time.Time    `json:"updated_at"`
+	CreatedAt     *time.Time    `json:"created_at"`
+	BaseSubject   *BaseSubjects `json:"base_subject"`
+	Topics        []*Topics     `json:"topics"`
+}
+
+func (CurriculumPlan) RelationFields() []string {
+	return []string{"BaseSubject", "Topics"}
+}
+
+type CurriculumPlanRequest struct {
+	ID            *string   `json:"id"`
+	BaseSubjectId *string   `json:"base_subject_id" validate:"required"`
+	Classyear     *string   `json:"classyear" validate:"required"`
+	TopicIds      *[]string `json:"topic_ids" validate:"required"`
+}
+
+type CurriculumPlanResponse struct {
+	ID            string                `json:"id"`
+	BaseSubjectId string                `json:"base_subject_id"`
+	Classyear     string                `json:"classyear"`
+	TopicIds      []string              `json:"topic_ids"`
+	UpdatedAt     *time.Time            `json:"updated_at"`
+	CreatedAt     *time.Time            `json:"created_at"`
+	BaseSubject   *BaseSubjectsResponse `json:"base_subject"`
+	Topics        []*TopicsResponse     `json:"topics"`
+}
+
+func (r *CurriculumPlanRequest) ToModel(m *CurriculumPlan) {
+	if r.ID != nil {
+		m.ID = *r.ID
+	}
+	if r.BaseSubjectId != nil {
+		m.BaseSubjectId = *r.BaseSubjectId
+	}
+	if r.Classyear != nil {
+		m.Classyear = *r.Classyear
+	}
+	if r.TopicIds != nil {
+		m.TopicIds = *r.TopicIds
+	}
+}
+
+func (r *CurriculumPlanResponse) FromModel(m *CurriculumPlan) {
+	r.ID = m.ID
+	r.BaseSubjectId = m.BaseSubjectId
+	r.Classyear = m.Classyear
+	r.TopicIds = m.TopicIds
+	r.UpdatedAt = m.UpdatedAt
+	r.CreatedAt = m.CreatedAt
+	if m.BaseSubject != nil {
+		r.BaseSubject = &BaseSubjectsResponse{}
+		r.BaseSubject.FromModel(m.BaseSubject)
+	}
+	if m.Topics != nil {
+		r.Topics = []*TopicsResponse{}
+		for _, t := range m.Topics {
+			tr := &TopicsResponse{}
+			tr.FromModel(t)
+			r.Topics = append(r.Topics, tr)
+		}
+	}
+}
+
+type CurriculumPlanFilterRequest struct {
+	ID             *string   `form:"id"`
+	IDs            *[]string `form:"ids"`
+	BaseSubjectId  *string   `form:"base_subject_id"`
+	BaseSubjectIds *[]string `form:"base_subject_ids"`
+	SchoolId       *string   `form:"school_id"`
+	Classyear      *string   `form:"classyear"`
+	PaginationRequest
+}
+
+// LessonTopic is topic filled into lesson by plan
+type LessonTopic struct {
+	LessonId string
+	TopicId  string
+	Title    *string
+}
+
+// CurriculumProgress shows how subject follows its plan until today
+type CurriculumProgress struct {
+	Subject      *SubjectResponse `json:"subject"`
+	PlanId       string           `json:"plan_id"`
+	TopicsCount  int              `json:"topics_count"`
+	LessonsDone  int              `json:"lessons_done"`
+	LessonsLeft  int              `json:"lessons_left"`
+	CurrentTopic *TopicsResponse  `json:"current_topic"`
+	// count of topics which will not fit into left lessons of period
+	TopicsBehind int `json:"topics_behind"`
+	Percent      int `json:"percent"`
+}
+
+type CurriculumProgressRequest struct {
+	SchoolId    *string `form:"school_id"`
+	ClassroomId *string `form:"classroom_id"`
+	SubjectId   *string `form:"subject_id"`
+}
+
+// Classyear is leading number of classroom name: "11A" -> "11"
+func (c Classroom) Classyear() string {
+	if c.Name == nil {
+		return ""
+	}
+	res := ""
+	for _, r := range *c.Name {
+		if !unicode.IsDigit(r) {
+			break
+		}
+		res += string(r)
+	}
+	return res
+}
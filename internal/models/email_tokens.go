@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+const (
+	EmailTokenVerify        = "verify"
+	EmailTokenPasswordReset = "password_reset"
+)
+
+// EmailToken is one-time link sent to email, only hash of token is stored
+type EmailToken struct {
+	ID        string     `json:"id"`
+	UserId    string     `json:"user_id"`
+	Type      string     `json:"type"`
+	Email     string     `json:"email"`
+	TokenHash string     `json:"-"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt *time.Time `json:"created_at"`
+}
+
+func (EmailToken) RelationFields() []string {
+	return []string{}
+}
+
+type UserEmailVerifySendRequest struct {
+	Lang *string `json:"lang" validate:"omitempty,oneof=tm ru"`
+}
+
+type UserEmailVerifyRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+type UserPasswordResetSendRequest struct {
+	Username string  `json:"login" validate:"required"`
+	Lang     *string `json:"lang" validate:"omitempty,oneof=tm ru"`
+}
+
+type UserPasswordResetRequest struct {
+	Token       string `json:"token" validate:"required"`
+	NewPassword string `json:"new_password" validate:"required,min=8"`
+}
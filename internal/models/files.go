@@ -0,0 +1,8 @@
+package models
+
+// FileOwner is user and school of row which keeps private file, Shared file is seen by whole school (avatar)
+type FileOwner struct {
+	UserId   *string `json:"user_id"`
+	SchoolId *string `json:"school_id"`
+	Shared   bool    `json:"shared"`
+}
@@ -23,6 +23,7 @@ type Lesson struct {
 	AssignmentTitle   *string                 `json:"assignment_title"`
 	AssignmentContent *string                 `json:"assignment_content"`
 	AssignmentFiles   *[]string               `json:"assignment_files"`
+	AssignmentDueAt   *time.Time              `json:"assignment_due_at"`
 	LessonAttributes  *map[string]interface{} `json:"lesson_attributes"`
 	IsTeacherExcused  *bool                   `json:"is_teacher_excused"`
 	UpdatedAt         *time.Time              `json:"updated_at"`
@@ -50,6 +51,13 @@ func (lesson Lesson) Label() string {
 	return label
 }
 
+// HasAssignment is true when teacher gave homework on lesson
+func (lesson Lesson) HasAssignment() bool {
+	return lesson.AssignmentTitle != nil && *lesson.AssignmentTitle != "" ||
+		lesson.AssignmentContent != nil && *lesson.AssignmentContent != "" ||
+		lesson.AssignmentFiles != nil && len(*lesson.AssignmentFiles) > 0
+}
+
 type LessonResponse struct {
 	ID               string                  `json:"id"`
 	SchoolId         string                  `json:"school_id"`
@@ -152,6 +160,7 @@ func (r *LessonResponse) FromModel(m *Lesson) {
 		if m.AssignmentContent != nil {
 			r.Assignment.Content = m.AssignmentContent
 		}
+		r.Assignment.DueAt = m.AssignmentDueAt
 	}
 	if m.ProTitle != nil || m.ProFiles != nil && len(*m.ProFiles) > 0 {
 		r.LessonPro = &LessonProResponse{}
@@ -237,6 +246,7 @@ func (m *Lesson) FromRequest(r *LessonRequest) error {
 	if r.Assignment.Content != nil {
 		m.AssignmentContent = r.Assignment.Content
 	}
+	m.AssignmentDueAt = r.Assignment.DueAtTime()
 	if r.LessonPro.Title != nil {
 		m.ProTitle = r.LessonPro.Title
 	}
@@ -4,8 +4,6 @@ import (
 	"errors"
 	"log"
 	"time"
-
-	"github.com/mekdep/server/config"
 )
 
 type Absent struct {
@@ -51,7 +49,7 @@ func (m *Absent) IsCreateExpired() bool {
 	}
 	now := time.Now()
 	expirationDate := -14
-	if now.Before(time.Date(2024, 10, 31, 23, 59, 0, 0, config.RequestLocation)) {
+	if now.Before(time.Date(2024, 10, 31, 23, 59, 0, 0, m.Lesson.School.Location())) {
 		expirationDate = -365
 	}
 	// for _, devPhone := range config.Conf.DevPhones {
@@ -8,6 +8,8 @@ import (
 
 type ParentAnalyticsWeekly struct {
 	SubjectRating *SubjectRatingByPeriodGrade `json:"subject_rating"`
+	// assignments of week which are due and not submitted
+	AssignmentsMissing []DiaryLessonResponse `json:"assignments_missing"`
 	// TODO: deprecate below
 	SubjectPercentByAreas []SubjectPercentByArea `json:"subject_percents"`
 	GradeStreak           int                    `json:"grade_strike"`
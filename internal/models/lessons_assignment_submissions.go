@@ -0,0 +1,134 @@
+package models
+
+import "time"
+
+type AssignmentSubmissionStatus string
+
+const (
+	SubmissionStatusSubmitted AssignmentSubmissionStatus = "submitted"
+	SubmissionStatusGraded    AssignmentSubmissionStatus = "graded"
+	// returned to student to do again, student can resubmit
+	SubmissionStatusReturned AssignmentSubmissionStatus = "returned"
+)
+
+// AssignmentSubmission is homework handed in by student (or by parent on behalf of child)
+// for assignment of lesson, resubmission updates it and increments Attempt
+type AssignmentSubmission struct {
+	ID              string     `json:"id"`
+	LessonId        string     `json:"lesson_id"`
+	StudentId       string     `json:"student_id"`
+	SubmittedBy     *string    `json:"submitted_by"`
+	Content         *string    `json:"content"`
+	Files           *[]string  `json:"files"`
+	Status          string     `json:"status"`
+	IsLate          bool       `json:"is_late"`
+	Attempt         int        `json:"attempt"`
+	GradeValue      *int       `json:"grade_value"`
+	Feedback        *string    `json:"feedback"`
+	GradedBy        *string    `json:"graded_by"`
+	GradedAt        *time.Time `json:"graded_at"`
+	SubmittedAt     *time.Time `json:"submitted_at"`
+	UpdatedAt       *time.Time `json:"updated_at"`
+	CreatedAt       *time.Time `json:"created_at"`
+	Student         *User      `json:"student"`
+	SubmittedByUser *User      `json:"submitted_by_user"`
+	GradedByUser    *User      `json:"graded_by_user"`
+}
+
+func (AssignmentSubmission) RelationFields() []string {
+	return []string{"Student", "SubmittedByUser", "GradedByUser"}
+}
+
+// CanResubmit is false after teacher graded submission
+func (m AssignmentSubmission) CanResubmit() bool {
+	return m.Status != string(SubmissionStatusGraded)
+}
+
+// AssignmentIsMissing is true when assignment of lesson is due and student did not submit it
+func AssignmentIsMissing(lesson *Lesson, submission *AssignmentSubmission, now time.Time) bool {
+	if !lesson.HasAssignment() || lesson.AssignmentDueAt == nil || lesson.AssignmentDueAt.After(now) {
+		return false
+	}
+	return submission == nil || submission.Status == string(SubmissionStatusReturned)
+}
+
+type AssignmentSubmissionRequest struct {
+	LessonId  *string   `json:"lesson_id" form:"lesson_id"`
+	StudentId *string   `json:"student_id" form:"student_id"`
+	Content   *string   `json:"content" form:"content"`
+	Files     *[]string `json:"files"`
+}
+
+type AssignmentSubmissionGradeRequest struct {
+	ID *string `json:"id"`
+	// graded with value or returned to student for resubmission
+	Status   *string `json:"status" validate:"required,oneof=graded returned"`
+	Value    *int    `json:"value"`
+	Feedback *string `json:"feedback"`
+}
+
+type AssignmentSubmissionFilterRequest struct {
+	ID         *string   `json:"id"`
+	LessonIds  *[]string `json:"lesson_ids"`
+	StudentId  *string   `json:"student_id"`
+	StudentIds *[]string `json:"student_ids"`
+}
+
+type AssignmentSubmissionResponse struct {
+	ID              string        `json:"id"`
+	LessonId        string        `json:"lesson_id"`
+	StudentId       string        `json:"student_id"`
+	Content         *string       `json:"content"`
+	Files           *[]string     `json:"files"`
+	Status          string        `json:"status"`
+	IsLate          bool          `json:"is_late"`
+	Attempt         int           `json:"attempt"`
+	GradeValue      *int          `json:"grade_value"`
+	Feedback        *string       `json:"feedback"`
+	GradedAt        *time.Time    `json:"graded_at"`
+	SubmittedAt     *time.Time    `json:"submitted_at"`
+	Student         *UserResponse `json:"student,omitempty"`
+	SubmittedByUser *UserResponse `json:"submitted_by_user,omitempty"`
+	GradedByUser    *UserResponse `json:"graded_by_user,omitempty"`
+}
+
+func (r *AssignmentSubmissionResponse) FromModel(m *AssignmentSubmission) {
+	r.ID = m.ID
+	r.LessonId = m.LessonId
+	r.StudentId = m.StudentId
+	r.Content = m.Content
+	if m.Files != nil {
+		r.Files = &[]string{}
+		for _, f := range *m.Files {
+			*r.Files = append(*r.Files, fileUrl(&f))
+		}
+	}
+	r.Status = m.Status
+	r.IsLate = m.IsLate
+	r.Attempt = m.Attempt
+	r.GradeValue = m.GradeValue
+	r.Feedback = m.Feedback
+	r.GradedAt = m.GradedAt
+	r.SubmittedAt = m.SubmittedAt
+	if m.Student != nil {
+		r.Student = &UserResponse{}
+		r.Student.FromModel(m.Student)
+	}
+	if m.SubmittedByUser != nil {
+		r.SubmittedByUser = &UserResponse{}
+		r.SubmittedByUser.FromModel(m.SubmittedByUser)
+	}
+	if m.GradedByUser != nil {
+		r.GradedByUser = &UserResponse{}
+		r.GradedByUser.FromModel(m.GradedByUser)
+	}
+}
+
+// AssignmentSubmissionMatrixResponse is submissions of classroom students by lessons with assignment
+type AssignmentSubmissionMatrixResponse struct {
+	Students    []UserResponse                 `json:"students"`
+	Lessons     []LessonResponse               `json:"lessons"`
+	Submissions []AssignmentSubmissionResponse `json:"submissions"`
+	// lesson id => count of students not submitted after due date
+	MissingCounts map[string]int `json:"missing_counts"`
+}
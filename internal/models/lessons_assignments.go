@@ -6,6 +6,7 @@ type Assignment struct {
 	ID            string     `json:"id"`
 	LessonId      string     `json:"lesson_id"`
 	Title         string     `json:"title"`
+	Content       *string    `json:"content"`
 	Files         *[]string  `json:"files"`
 	UpdatedAt     *time.Time `json:"updated_at"`
 	CreatedAt     *time.Time `json:"created_at"`
@@ -24,6 +25,7 @@ type AssignmentResponse struct {
 	Title         string        `json:"title"`
 	Files         *[]string     `json:"files"`
 	Content       *string       `json:"content"`
+	DueAt         *time.Time    `json:"due_at"`
 	UpdatedByUser *UserResponse `json:"updated_by_user"`
 	CreatedByUser *UserResponse `json:"created_by_user"`
 }
@@ -32,6 +34,7 @@ type AssignmentRequest struct {
 	LessonID    *string   `json:"lesson_id" form:"lesson_id"`
 	Title       *string   `json:"title" form:"title"`
 	Content     *string   `json:"content" form:"content"`
+	DueAt       *string   `json:"due_at" form:"due_at"`
 	FilesDelete *[]string `json:"files_delete" validate:"omitempty"`
 	Files       *[]string `json:"files" validate:"omitempty"`
 	UpdatedBy   string
@@ -46,6 +49,21 @@ type AssignmentFilterRequest struct {
 	PaginationRequest
 }
 
+// DueAtTime parses due date of assignment, date without time is due till end of day
+func (r AssignmentRequest) DueAtTime() *time.Time {
+	if r.DueAt == nil || *r.DueAt == "" {
+		return nil
+	}
+	if t, err := time.ParseInLocation(time.DateTime, *r.DueAt, time.Local); err == nil {
+		return &t
+	}
+	if t, err := time.ParseInLocation(time.DateOnly, *r.DueAt, time.Local); err == nil {
+		t = t.Add(24*time.Hour - time.Second)
+		return &t
+	}
+	return nil
+}
+
 func (m *Assignment) FromRequest(r *AssignmentRequest) {
 	if r.LessonID != nil {
 		m.LessonId = *r.LessonID
@@ -53,7 +71,7 @@ func (m *Assignment) FromRequest(r *AssignmentRequest) {
 	if r.Title != nil {
 		m.Title = *r.Title
 	}
-	// m.Content = r.Content
+	m.Content = r.Content
 	m.Files = r.Files
 	m.UpdatedBy = &r.UpdatedBy
 	m.CreatedBy = &r.UpdatedBy
@@ -68,6 +86,7 @@ func (r *AssignmentResponse) FromModel(m *Assignment) {
 		}
 	}
 	r.Title = m.Title
+	r.Content = m.Content
 	if m.UpdatedByUser != nil {
 		t := UserResponse{}
 		t.FromModel(m.UpdatedByUser)
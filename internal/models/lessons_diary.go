@@ -43,12 +43,15 @@ type DiarySubjectResponse struct {
 }
 
 type DiaryLessonResponse struct {
-	ShiftTimes []string            `json:"shift_times"`
-	Subject    *SubjectResponse    `json:"subject"`
-	Lesson     *LessonResponse     `json:"lesson"`
-	Absent     *AbsentResponse     `json:"absent"`
-	Grade      *GradeResponse      `json:"grade"`
-	Assignment *AssignmentResponse `json:"assignment"`
+	ShiftTimes []string                      `json:"shift_times"`
+	Subject    *SubjectResponse              `json:"subject"`
+	Lesson     *LessonResponse               `json:"lesson"`
+	Absent     *AbsentResponse               `json:"absent"`
+	Grade      *GradeResponse                `json:"grade"`
+	Assignment *AssignmentResponse           `json:"assignment"`
+	Submission *AssignmentSubmissionResponse `json:"submission"`
+	// assignment is due and not submitted
+	IsAssignmentMissing bool `json:"is_assignment_missing"`
 }
 
 type ParentChildrenResponse struct {
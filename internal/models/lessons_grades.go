@@ -69,7 +69,7 @@ func (m *GradeRequest) IsValueDelete() bool {
 func (m *Grade) IsCreateExpired(phone string) bool {
 	now := time.Now()
 	expirationDate := -14
-	if now.Before(time.Date(2024, 10, 31, 23, 59, 0, 0, config.RequestLocation)) {
+	if now.Before(time.Date(2024, 10, 31, 23, 59, 0, 0, m.Lesson.School.Location())) {
 		expirationDate = -365
 	}
 	for _, devPhone := range config.Conf.DevPhones {
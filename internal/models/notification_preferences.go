@@ -0,0 +1,140 @@
+package models
+
+import "time"
+
+type NotificationCategory string
+type NotificationChannel string
+
+const (
+	NotifyDailyGrades    NotificationCategory = "daily_grades"
+	NotifyNewGrade       NotificationCategory = "new_grade"
+	NotifyAbsence        NotificationCategory = "absence"
+	NotifyChatMessage    NotificationCategory = "chat_message"
+	NotifyAnnouncement   NotificationCategory = "announcement"
+	NotifyPaymentExpiry  NotificationCategory = "payment_expiry"
+	NotifyTransferStatus NotificationCategory = "transfer_status"
+	NotifyReportStatus   NotificationCategory = "report_status"
+	NotifyContactItem    NotificationCategory = "contact_item"
+	NotifyLoginAlert     NotificationCategory = "login_alert"
+)
+
+const (
+	ChannelPush  NotificationChannel = "push"
+	ChannelSms   NotificationChannel = "sms"
+	ChannelInApp NotificationChannel = "in_app"
+	ChannelOff   NotificationChannel = "off"
+)
+
+var NotificationCategories = []NotificationCategory{
+	NotifyDailyGrades, NotifyNewGrade, NotifyAbsence, NotifyChatMessage,
+	NotifyAnnouncement, NotifyPaymentExpiry, NotifyTransferStatus, NotifyReportStatus, NotifyContactItem,
+	NotifyLoginAlert,
+}
+
+// DefaultNotificationChannels is used when neither user nor role has preference
+var DefaultNotificationChannels = map[NotificationCategory]NotificationChannel{
+	NotifyDailyGrades:    ChannelSms,
+	NotifyNewGrade:       ChannelOff,
+	NotifyAbsence:        ChannelOff,
+	NotifyChatMessage:    ChannelPush,
+	NotifyAnnouncement:   ChannelPush,
+	NotifyPaymentExpiry:  ChannelSms,
+	NotifyTransferStatus: ChannelPush,
+	NotifyReportStatus:   ChannelPush,
+	NotifyContactItem:    ChannelPush,
+	NotifyLoginAlert:     ChannelPush,
+}
+
+// NotificationPreference is set by user for himself (UserId) or by admin as default of role (Role)
+type NotificationPreference struct {
+	ID         string     `json:"id"`
+	UserId     *string    `json:"user_id"`
+	Role       *string    `json:"role"`
+	Category   string     `json:"category"`
+	Channel    string     `json:"channel"`
+	QuietStart *string    `json:"quiet_start"`
+	QuietEnd   *string    `json:"quiet_end"`
+	UpdatedAt  *time.Time `json:"updated_at"`
+	CreatedAt  *time.Time `json:"created_at"`
+}
+
+func (NotificationPreference) RelationFields() []string {
+	return []string{}
+}
+
+// QuietUntil returns end of quiet hours if t is inside them, hours are "HH:MM" in location of t
+func (p NotificationPreference) QuietUntil(t time.Time) *time.Time {
+	if p.QuietStart == nil || p.QuietEnd == nil || *p.QuietStart == *p.QuietEnd {
+		return nil
+	}
+	start, err := time.ParseInLocation("15:04", *p.QuietStart, t.Location())
+	if err != nil {
+		return nil
+	}
+	end, err := time.ParseInLocation("15:04", *p.QuietEnd, t.Location())
+	if err != nil {
+		return nil
+	}
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	start = day.Add(time.Duration(start.Hour())*time.Hour + time.Duration(start.Minute())*time.Minute)
+	end = day.Add(time.Duration(end.Hour())*time.Hour + time.Duration(end.Minute())*time.Minute)
+	if start.Before(end) {
+		// eg: 13:00 - 15:00
+		if !t.Before(start) && t.Before(end) {
+			return &end
+		}
+		return nil
+	}
+	// over midnight, eg: 22:00 - 07:00
+	if !t.Before(start) {
+		end = end.AddDate(0, 0, 1)
+		return &end
+	}
+	if t.Before(end) {
+		return &end
+	}
+	return nil
+}
+
+type NotificationPreferenceFilterRequest struct {
+	UserIds *[]string
+	Roles   *[]string
+	// only role defaults (user_uid is null)
+	IsRoleDefault *bool
+	Category      *string
+}
+
+type NotificationPreferenceRequest struct {
+	Category   string  `json:"category" validate:"required,oneof=daily_grades new_grade absence chat_message announcement payment_expiry transfer_status"`
+	Channel    string  `json:"channel" validate:"required,oneof=push sms in_app off"`
+	QuietStart *string `json:"quiet_start" validate:"omitempty,datetime=15:04"`
+	QuietEnd   *string `json:"quiet_end" validate:"omitempty,datetime=15:04"`
+}
+
+type NotificationPreferencesRequest struct {
+	Role  *string                         `json:"role"`
+	Items []NotificationPreferenceRequest `json:"items" validate:"dive"`
+}
+
+func (r *NotificationPreferenceRequest) ToModel(m *NotificationPreference) {
+	m.Category = r.Category
+	m.Channel = r.Channel
+	m.QuietStart = r.QuietStart
+	m.QuietEnd = r.QuietEnd
+}
+
+type NotificationPreferenceResponse struct {
+	Category   string  `json:"category"`
+	Channel    string  `json:"channel"`
+	QuietStart *string `json:"quiet_start"`
+	QuietEnd   *string `json:"quiet_end"`
+	// true when value is not set by user, comes from role or default
+	IsDefault bool `json:"is_default"`
+}
+
+func (r *NotificationPreferenceResponse) FromModel(m *NotificationPreference) {
+	r.Category = m.Category
+	r.Channel = m.Channel
+	r.QuietStart = m.QuietStart
+	r.QuietEnd = m.QuietEnd
+}
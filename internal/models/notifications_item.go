@@ -13,8 +13,22 @@ type Notifications struct {
 	Files     *[]string  `json:"files"`
 	UpdatedAt *time.Time `json:"updated_at"`
 	CreatedAt *time.Time `json:"created_at"`
-	Schools   *[]School  `json:"school"`
-	Author    *User      `json:"author"`
+	// not sent until send_at, hidden from users after expires_at
+	SendAt    *time.Time `json:"send_at"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	SentAt    *time.Time `json:"sent_at"`
+	// targeting inside schools: classrooms, classroom levels (eg: "5"), regions and tariffs
+	ClassroomIds []string  `json:"classroom_ids"`
+	Classyears   []string  `json:"classyears"`
+	Regions      []string  `json:"regions"`
+	TariffTypes  []string  `json:"tariff_types"`
+	Schools      *[]School `json:"school"`
+	Author       *User     `json:"author"`
+}
+
+// HasClassroomTarget is true when only users of some classrooms receive it
+func (m Notifications) HasClassroomTarget() bool {
+	return len(m.ClassroomIds) > 0 || len(m.Classyears) > 0
 }
 
 func (Notifications) RelationFields() []string {
@@ -31,30 +45,43 @@ type NotificationsFilterRequest struct {
 }
 
 type NotificationsRequest struct {
-	ID          *string   `json:"id" form:"id"`
-	SchoolIds   []string  `json:"school_ids" form:"school_ids[]"`
-	Roles       []string  `json:"roles" form:"roles[]"`
-	UserIds     []string  `json:"user_ids" form:"user_ids[]"`
-	AuthorId    string    `json:"author_id" form:"author_id"`
-	Title       *string   `json:"title" form:"title"`
-	Content     *string   `json:"content" form:"content"`
-	Files       *[]string ``
-	FilesDelete *[]string `json:"files_delete" form:"files_delete"`
+	ID           *string    `json:"id" form:"id"`
+	SchoolIds    []string   `json:"school_ids" form:"school_ids[]"`
+	Roles        []string   `json:"roles" form:"roles[]"`
+	UserIds      []string   `json:"user_ids" form:"user_ids[]"`
+	AuthorId     string     `json:"author_id" form:"author_id"`
+	Title        *string    `json:"title" form:"title"`
+	Content      *string    `json:"content" form:"content"`
+	Files        *[]string  ``
+	FilesDelete  *[]string  `json:"files_delete" form:"files_delete"`
+	SendAt       *time.Time `json:"send_at" form:"send_at" time_format:"2006-01-02 15:04"`
+	ExpiresAt    *time.Time `json:"expires_at" form:"expires_at" time_format:"2006-01-02 15:04"`
+	ClassroomIds []string   `json:"classroom_ids" form:"classroom_ids[]"`
+	Classyears   []string   `json:"classyears" form:"classyears[]"`
+	Regions      []string   `json:"regions" form:"regions[]"`
+	TariffTypes  []string   `json:"tariff_types" form:"tariff_types[]"`
 }
 
 type NotificationsResponse struct {
-	ID        string                      `json:"id"`
-	SchoolIds []string                    `json:"school_ids"`
-	Roles     []string                    `json:"roles"`
-	UserIds   []string                    `json:"user_ids"`
-	AuthorId  *string                     `json:"author_id"`
-	Title     *string                     `json:"title"`
-	Content   *string                     `json:"content"`
-	Files     *[]string                   `json:"files"`
-	Items     *[]UserNotificationResponse `json:"items"`
-	UpdatedAt *time.Time                  `json:"updated_at"`
-	CreatedAt *time.Time                  `json:"created_at"`
-	Author    *UserResponse               `json:"author"`
+	ID           string                      `json:"id"`
+	SchoolIds    []string                    `json:"school_ids"`
+	Roles        []string                    `json:"roles"`
+	UserIds      []string                    `json:"user_ids"`
+	AuthorId     *string                     `json:"author_id"`
+	Title        *string                     `json:"title"`
+	Content      *string                     `json:"content"`
+	Files        *[]string                   `json:"files"`
+	Items        *[]UserNotificationResponse `json:"items"`
+	UpdatedAt    *time.Time                  `json:"updated_at"`
+	CreatedAt    *time.Time                  `json:"created_at"`
+	SendAt       *time.Time                  `json:"send_at"`
+	ExpiresAt    *time.Time                  `json:"expires_at"`
+	SentAt       *time.Time                  `json:"sent_at"`
+	ClassroomIds []string                    `json:"classroom_ids"`
+	Classyears   []string                    `json:"classyears"`
+	Regions      []string                    `json:"regions"`
+	TariffTypes  []string                    `json:"tariff_types"`
+	Author       *UserResponse               `json:"author"`
 }
 
 func (r *NotificationsResponse) FromModel(m *Notifications) error {
@@ -72,6 +99,13 @@ func (r *NotificationsResponse) FromModel(m *Notifications) error {
 	r.Title = m.Title
 	r.Content = m.Content
 	r.UpdatedAt = m.UpdatedAt
+	r.SendAt = m.SendAt
+	r.ExpiresAt = m.ExpiresAt
+	r.SentAt = m.SentAt
+	r.ClassroomIds = m.ClassroomIds
+	r.Classyears = m.Classyears
+	r.Regions = m.Regions
+	r.TariffTypes = m.TariffTypes
 	if m.CreatedAt != nil {
 		localTime := m.CreatedAt.Local()
 		r.CreatedAt = &localTime
@@ -101,25 +135,35 @@ func (r *NotificationsRequest) ToModel(m *Notifications) error {
 	m.Title = r.Title
 	m.Content = r.Content
 	m.Files = r.Files
+	m.SendAt = r.SendAt
+	m.ExpiresAt = r.ExpiresAt
+	m.ClassroomIds = r.ClassroomIds
+	m.Classyears = r.Classyears
+	m.Regions = r.Regions
+	m.TariffTypes = r.TariffTypes
 	return nil
 }
 
 // ++++++++++++++++ USER NOTIFICATIONS +++++++++++++++++++++
 
 type UserNotification struct {
-	ID             string         `json:"id"`
-	NotificationId string         `json:"notification_id"`
-	UserId         string         `json:"user_id"`
-	ReadAt         *time.Time     `json:"read_at"`
-	Role           *string        `json:"role"`
-	Comment        *string        `json:"comment"`
-	CommentFiles   *[]string      `json:"comment_files"`
-	Notifications  *Notifications `json:"notifications"`
-	User           *User          `json:"user"`
+	ID             string     `json:"id"`
+	NotificationId string     `json:"notification_id"`
+	UserId         string     `json:"user_id"`
+	ReadAt         *time.Time `json:"read_at"`
+	Role           *string    `json:"role"`
+	Comment        *string    `json:"comment"`
+	CommentFiles   *[]string  `json:"comment_files"`
+	SchoolId       *string    `json:"school_id"`
+	// first time user got it in list
+	OpenedAt       *time.Time      `json:"opened_at"`
+	Notifications  *Notifications  `json:"notifications"`
+	User           *User           `json:"user"`
+	PushDeliveries []*PushDelivery `json:"push_deliveries"`
 }
 
 func (UserNotification) RelationFields() []string {
-	return []string{"Notifications", "User"}
+	return []string{"Notifications", "User", "PushDeliveries"}
 }
 
 type UserNotificationFilterRequest struct {
@@ -128,7 +172,10 @@ type UserNotificationFilterRequest struct {
 	UserId         *string   `form:"user_id"`
 	Role           *string   `form:"role"`
 	IsRead         *bool     `form:"read"`
-	Sort           *string   `form:"sort"`
+	UserIds        *[]string ``
+	// hide expired notifications
+	NotExpired *bool   ``
+	Sort       *string `form:"sort"`
 	PaginationRequest
 }
 
@@ -143,14 +190,17 @@ type UserNotificationRequest struct {
 }
 
 type UserNotificationResponse struct {
-	ID           string                 `json:"id"`
-	Notification *NotificationsResponse `json:"notification"`
-	UserId       string                 `json:"user_id"`
-	User         *UserResponse          `json:"user"`
-	Role         *string                `json:"role"`
-	ReadAt       *time.Time             `json:"read_at"`
-	Comment      *string                `json:"comment"`
-	CommentFiles *[]string              `json:"comment_files"`
+	ID             string                  `json:"id"`
+	Notification   *NotificationsResponse  `json:"notification"`
+	UserId         string                  `json:"user_id"`
+	User           *UserResponse           `json:"user"`
+	Role           *string                 `json:"role"`
+	SchoolId       *string                 `json:"school_id"`
+	OpenedAt       *time.Time              `json:"opened_at"`
+	ReadAt         *time.Time              `json:"read_at"`
+	Comment        *string                 `json:"comment"`
+	CommentFiles   *[]string               `json:"comment_files"`
+	PushDeliveries []*PushDeliveryResponse `json:"push_deliveries"`
 }
 
 func (r *UserNotificationResponse) FromModel(m *UserNotification) error {
@@ -165,6 +215,8 @@ func (r *UserNotificationResponse) FromModel(m *UserNotification) error {
 	}
 	r.UserId = m.UserId
 	r.Role = m.Role
+	r.SchoolId = m.SchoolId
+	r.OpenedAt = m.OpenedAt
 	r.ReadAt = m.ReadAt
 	r.Comment = m.Comment
 	if m.CommentFiles != nil {
@@ -173,6 +225,14 @@ func (r *UserNotificationResponse) FromModel(m *UserNotification) error {
 			*r.CommentFiles = append(*r.CommentFiles, fileUrl(&f))
 		}
 	}
+	if m.PushDeliveries != nil {
+		r.PushDeliveries = []*PushDeliveryResponse{}
+		for _, d := range m.PushDeliveries {
+			dr := &PushDeliveryResponse{}
+			dr.FromModel(d)
+			r.PushDeliveries = append(r.PushDeliveries, dr)
+		}
+	}
 	return nil
 }
 
@@ -187,3 +247,16 @@ func (r *UserNotificationRequest) ToModel(m *UserNotification) error {
 	m.CommentFiles = r.CommentFiles
 	return nil
 }
+
+// NotificationAnalytics is reach of notification in one school for one role
+type NotificationAnalytics struct {
+	SchoolId *string `json:"school_id"`
+	Role     *string `json:"role"`
+	// user notifications created
+	Total int `json:"total"`
+	// push sent or seen in app
+	Delivered int             `json:"delivered"`
+	Opened    int             `json:"opened"`
+	Read      int             `json:"read"`
+	School    *SchoolResponse `json:"school"`
+}
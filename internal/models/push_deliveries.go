@@ -0,0 +1,63 @@
+package models
+
+import "time"
+
+const (
+	PushStatusPending = "pending"
+	PushStatusSent    = "sent"
+	PushStatusRetry   = "retry"
+	PushStatusFailed  = "failed"
+	// token is unregistered, it was removed from sessions
+	PushStatusPruned = "pruned"
+)
+
+// PushDelivery is one push to one device of user, linked to user notification if sent for it
+type PushDelivery struct {
+	ID                 string             `json:"id"`
+	UserNotificationId *string            `json:"user_notification_id"`
+	UserId             string             `json:"user_id"`
+	DeviceToken        string             `json:"device_token"`
+	Title              string             `json:"title"`
+	Body               *string            `json:"body"`
+	Data               *map[string]string `json:"data"`
+	Status             string             `json:"status"`
+	Attempts           int                `json:"attempts"`
+	Error              *string            `json:"error"`
+	MessageId          *string            `json:"message_id"`
+	NextRetryAt        *time.Time         `json:"next_retry_at"`
+	SentAt             *time.Time         `json:"sent_at"`
+	UpdatedAt          *time.Time         `json:"updated_at"`
+	CreatedAt          *time.Time         `json:"created_at"`
+}
+
+func (PushDelivery) RelationFields() []string {
+	return []string{}
+}
+
+type PushDeliveryFilterRequest struct {
+	UserNotificationIds *[]string
+	UserId              *string
+	Status              *string
+	// due for retry
+	RetryBefore   *time.Time
+	UpdatedBefore *time.Time
+	PaginationRequest
+}
+
+type PushDeliveryResponse struct {
+	ID        string     `json:"id"`
+	Status    string     `json:"status"`
+	Attempts  int        `json:"attempts"`
+	Error     *string    `json:"error"`
+	SentAt    *time.Time `json:"sent_at"`
+	CreatedAt *time.Time `json:"created_at"`
+}
+
+func (r *PushDeliveryResponse) FromModel(m *PushDelivery) {
+	r.ID = m.ID
+	r.Status = m.Status
+	r.Attempts = m.Attempts
+	r.Error = m.Error
+	r.SentAt = m.SentAt
+	r.CreatedAt = m.CreatedAt
+}
@@ -10,6 +10,14 @@ type ValueTypes struct {
 	Type        ReportValueType `json:"type"`
 	TypeOptions []string        `json:"type_options"`
 	Group       *string         `json:"group"`
+	// value is pre-filled from journal data of report period
+	Source *ReportValueSource `json:"source"`
+	// value is calculated from values of previous keys, ex: "{completed} / {students} * 100"
+	Formula  *string        `json:"formula"`
+	Required bool           `json:"required"`
+	Min      *float64       `json:"min"`
+	Max      *float64       `json:"max"`
+	Scoring  *ReportScoring `json:"scoring"`
 }
 
 type Reports struct {
@@ -22,6 +30,9 @@ type Reports struct {
 	IsPinned             *bool          `json:"is_pinned"`
 	IsCenterRating       *bool          `json:"is_center_rating"`
 	IsClassroomsIncluded *bool          `json:"is_classrooms_included"`
+	DeadlineAt           *time.Time     `json:"deadline_at"`
+	StartDate            *time.Time     `json:"start_date"`
+	EndDate              *time.Time     `json:"end_date"`
 	CreatedAt            *time.Time     `json:"created_at"`
 	UpdatedAt            *time.Time     `json:"updated_at"`
 	ItemsCount           *int           `json:"items_count"`
@@ -30,6 +41,17 @@ type Reports struct {
 	ReportItems          []*ReportItems `json:"report_items"`
 }
 
+// ReportRegionCompletion is count of school forms of region by status
+type ReportRegionCompletion struct {
+	RegionId       string          `json:"region_id"`
+	Region         *SchoolResponse `json:"region"`
+	ItemsCount     int             `json:"items_count"`
+	SubmittedCount int             `json:"submitted_count"`
+	ApprovedCount  int             `json:"approved_count"`
+	ReturnedCount  int             `json:"returned_count"`
+	Percent        int             `json:"percent"`
+}
+
 type ReportRating struct {
 	Report           ReportsResponse    `json:"report"`
 	ReportRatingList []ReportRatingItem `json:"rating"`
@@ -53,6 +75,9 @@ type ReportsRequest struct {
 	IsPinned             *bool        `json:"is_pinned"`
 	IsClassroomsIncluded *bool        `json:"is_classrooms_included"`
 	IsCenterRating       *bool        `json:"is_center_rating"`
+	DeadlineAt           *time.Time   `json:"deadline_at"`
+	StartDate            *time.Time   `json:"start_date"`
+	EndDate              *time.Time   `json:"end_date"`
 }
 
 type ReportsResponse struct {
@@ -69,6 +94,9 @@ type ReportsResponse struct {
 	ItemsCount           *int                  `json:"items_count"`
 	ItemsCountFilled     *int                  `json:"items_filled_count"`
 	IsClassroomsIncluded *bool                 `json:"is_classrooms_included"`
+	DeadlineAt           *time.Time            `json:"deadline_at"`
+	StartDate            *time.Time            `json:"start_date"`
+	EndDate              *time.Time            `json:"end_date"`
 	ReportItem           *ReportItemsResponse  `json:"report_item"`
 	ReportItems          []ReportItemsResponse `json:"report_items"`
 }
@@ -82,6 +110,9 @@ type ReportsFilterRequest struct {
 	IsPinned       *bool     `form:"is_pinned"`
 	IsCenterRating *bool     `form:"is_center_rating"`
 	Search         *string   `form:"search"`
+	// reports with deadline in range, used by reminders
+	DeadlineFrom *time.Time ``
+	DeadlineTo   *time.Time ``
 	PaginationRequest
 }
 
@@ -113,6 +144,9 @@ func (r *ReportsResponse) FromModel(m *Reports) error {
 	r.ItemsCount = m.ItemsCount
 	r.ItemsCountFilled = m.ItemsCountFilled
 	r.IsClassroomsIncluded = m.IsClassroomsIncluded
+	r.DeadlineAt = m.DeadlineAt
+	r.StartDate = m.StartDate
+	r.EndDate = m.EndDate
 	return nil
 }
 
@@ -134,10 +168,21 @@ func (r *ReportsRequest) ToModel(m *Reports) error {
 	if r.IsClassroomsIncluded != nil {
 		m.IsClassroomsIncluded = r.IsClassroomsIncluded
 	}
+	m.DeadlineAt = r.DeadlineAt
+	m.StartDate = r.StartDate
+	m.EndDate = r.EndDate
 	return nil
 }
 
 // ------------> report_items <------------- //
+const ReportItemStatusDraft = "draft"
+const ReportItemStatusSubmitted = "submitted"
+const ReportItemStatusReturned = "returned"
+const ReportItemStatusApproved = "approved"
+
+// ReportItemStatusesOutstanding are forms which school still has to fill or correct
+var ReportItemStatusesOutstanding = []string{ReportItemStatusDraft, ReportItemStatusReturned}
+
 type ReportItems struct {
 	ID               string     `json:"id"`
 	ReportId         *string    `json:"report_id"`
@@ -147,6 +192,11 @@ type ReportItems struct {
 	UpdatedBy        *string    `json:"updated_by"`
 	Values           []*string  `json:"values"`
 	IsEditedManually *bool      `json:"is_edited_manually"`
+	Status           string     `json:"status"`
+	SubmittedAt      *time.Time `json:"submitted_at"`
+	ReviewedBy       *string    `json:"reviewed_by"`
+	ReviewedAt       *time.Time `json:"reviewed_at"`
+	ReviewComment    *string    `json:"review_comment"`
 	CreatedAt        *time.Time `json:"created_at"`
 	UpdatedAt        *time.Time `json:"updated_at"`
 	Report           *Reports   `json:"report"`
@@ -157,6 +207,10 @@ type ReportItems struct {
 	UpdatedByUser    *User      `json:"updated_by_user"`
 }
 
+func (m ReportItems) IsFilled() bool {
+	return m.Status == ReportItemStatusSubmitted || m.Status == ReportItemStatusApproved
+}
+
 func (ReportItems) RelationFields() []string {
 	return []string{"Report", "School", "Region", "Period", "Classroom", "UpdatedByUser"}
 }
@@ -170,6 +224,15 @@ type ReportItemsRequest struct {
 	UpdatedBy        *string   `json:"updated_by"`
 	IsEditedManually *bool     `json:"is_edited_manually"`
 	Values           []*string `json:"values"`
+	// saved without sending to review, by default saving submits form
+	IsDraft *bool `json:"is_draft"`
+}
+
+// ReportItemsReviewRequest is answer of region (or principal for classrooms) to submitted form
+type ReportItemsReviewRequest struct {
+	ID      *string `json:"id"`
+	Status  string  `json:"status" validate:"required,oneof=approved returned"`
+	Comment *string `json:"comment"`
 }
 
 type ReportItemsResponse struct {
@@ -181,6 +244,10 @@ type ReportItemsResponse struct {
 	UpdatedByUser    *UserResponse      `json:"updated_by_user"`
 	IsEditedManually *bool              `json:"is_edited_manually"`
 	Values           []*string          `json:"values"`
+	Status           string             `json:"status"`
+	SubmittedAt      *time.Time         `json:"submitted_at"`
+	ReviewedAt       *time.Time         `json:"reviewed_at"`
+	ReviewComment    *string            `json:"review_comment"`
 	CreatedAt        *time.Time         `json:"created_at"`
 	UpdatedAt        *time.Time         `json:"updated_at"`
 }
@@ -195,6 +262,7 @@ type ReportItemsFilterRequest struct {
 	PeriodId      *string   `json:"period_id"`
 	Sort          *string   `json:"sort"`
 	OnlyClassroom *bool     `json:"only_clasroom"`
+	Statuses      *[]string `json:"statuses"`
 	PaginationRequest
 }
 
@@ -222,6 +290,10 @@ func (r *ReportItemsResponse) FromModel(m *ReportItems) error {
 	}
 	r.IsEditedManually = m.IsEditedManually
 	r.Values = m.Values
+	r.Status = m.Status
+	r.SubmittedAt = m.SubmittedAt
+	r.ReviewedAt = m.ReviewedAt
+	r.ReviewComment = m.ReviewComment
 	if m.CreatedAt != nil {
 		r.CreatedAt = m.CreatedAt
 	}
@@ -253,10 +325,76 @@ const ReportValueTypeText ReportValueType = "text"
 const ReportValueTypeList ReportValueType = "list"
 const ReportValueTypeSelect ReportValueType = "select"
 
+// ReportValueSource is journal data of school (or classroom) for report period
+type ReportValueSource string
+
+const ReportSourceStudentsCount ReportValueSource = "students_count"
+const ReportSourceClassroomsCount ReportValueSource = "classrooms_count"
+const ReportSourceAbsenceRate ReportValueSource = "absence_rate"
+const ReportSourceGradeAverage ReportValueSource = "grade_average"
+
+var ReportValueSources = []ReportValueSource{
+	ReportSourceStudentsCount,
+	ReportSourceClassroomsCount,
+	ReportSourceAbsenceRate,
+	ReportSourceGradeAverage,
+}
+
+type ReportSourceValues struct {
+	StudentsCount   int
+	ClassroomsCount int
+	// percent of absent students in lessons
+	AbsenceRate  float64
+	GradeAverage float64
+}
+
+func (v ReportSourceValues) Get(source ReportValueSource) float64 {
+	switch source {
+	case ReportSourceStudentsCount:
+		return float64(v.StudentsCount)
+	case ReportSourceClassroomsCount:
+		return float64(v.ClassroomsCount)
+	case ReportSourceAbsenceRate:
+		return v.AbsenceRate
+	case ReportSourceGradeAverage:
+		return v.GradeAverage
+	}
+	return 0
+}
+
+// ReportScoring gives rating points for number value:
+// value multiplied by Per, or points of first range which value is below, range without Below is for rest values
+type ReportScoring struct {
+	Per    *float64           `json:"per"`
+	Ranges []ReportScoreRange `json:"ranges"`
+}
+
+type ReportScoreRange struct {
+	Below  *float64 `json:"below"`
+	Points float64  `json:"points"`
+}
+
+func (s ReportScoring) Points(value float64) float64 {
+	if s.Per != nil {
+		return value * *s.Per
+	}
+	for _, r := range s.Ranges {
+		if r.Below == nil || value < *r.Below {
+			return r.Points
+		}
+	}
+	return 0
+}
+
+func reportPoints(v float64) *float64 {
+	return &v
+}
+
 type ReportKey string
 
 const ReportKeySeasonStudents ReportKey = "season_students"
 const ReportKeySeasonStudentsCompleted ReportKey = "season_students_completed"
+const ReportKeySeasonStudentsRatio ReportKey = "season_students_ratio"
 const ReportKeyCourses ReportKey = "courses"
 const ReportKeyGroups ReportKey = "groups"
 
@@ -266,8 +404,9 @@ type reportTypeKey struct {
 	Description string          `json:"description"`
 	Key         ReportKey       `json:"key"`
 	Type        ReportValueType `json:"type"`
-	CalcPoint   (func(value interface{}) int)
-	Value       string `json:"value"`
+	Formula     string          `json:"formula"`
+	Scoring     *ReportScoring  `json:"scoring"`
+	Value       string          `json:"value"`
 }
 
 var DefaultRatingReports = []reportTypeKey{
@@ -275,50 +414,39 @@ var DefaultRatingReports = []reportTypeKey{
 		Title: "Hasabat döwri üçin ähli ugurlar boýunça okuwa kabul edilen diňleýjileriň sany (okuwy doly tamamlanan toparlar boýunça)",
 		Key:   ReportKeySeasonStudents,
 		Type:  ReportValueTypeNumber,
-		// CalcPoint: func(val interface{}) int {
-		// 	num, _ := strconv.Atoi(val.(string))
-		// 	return num * 5
-		// },
 	},
 	{
 		Title: "Hasabat döwri üçin ähli ugurlar boýunça okuwa kabul edilen we üstünlikli tamamlanan diňleýjileriň sany (okuwy doly tamamlanan toparlar boýunça)",
 		Key:   ReportKeySeasonStudentsCompleted,
 		Type:  ReportValueTypeNumber,
-		// CalcPoint: func(val interface{}) int {
-		// 	num, _ := strconv.Atoi(val.(string))
-		// 	return num * 5
-		// },
+	},
+	{
+		Title:   "Hasabat döwri üçin üstünlikli tamamlanan diňleýjileriň sanynyň okuwa kabul edilen diňleýjileriň sanyna gatnaşygy",
+		Key:     ReportKeySeasonStudentsRatio,
+		Type:    ReportValueTypeNumber,
+		Formula: "{" + string(ReportKeySeasonStudentsCompleted) + "} / {" + string(ReportKeySeasonStudents) + "}",
+		Scoring: &ReportScoring{Per: reportPoints(10)},
 	},
 
 	{
-		Title: "Hasabat döwri üçin pedagogik işgärleri tarapyndan okatmagyň usullary işlenip taýýarlanylmagy we tejribä ornaşdyrylmagy",
-		Key:   "pedagogy",
-		Type:  ReportValueTypeList,
-		CalcPoint: func(val interface{}) int {
-			return val.(int) * 5
-		},
+		Title:   "Hasabat döwri üçin pedagogik işgärleri tarapyndan okatmagyň usullary işlenip taýýarlanylmagy we tejribä ornaşdyrylmagy",
+		Key:     "pedagogy",
+		Type:    ReportValueTypeList,
+		Scoring: &ReportScoring{Per: reportPoints(5)},
 	},
 
 	{ // TODO: ask duplicate?
 		Title: "Hasabat döwri üçin okuwa kabul edilen diňleýjileriň sany (ähli şahamçalar boýunça)",
 		Key:   "students2",
 		Type:  ReportValueTypeNumber,
-		CalcPoint: func(val interface{}) int {
-			num := val.(int)
-			if num < 100 {
-				return 20
-			} else if num < 280 {
-				return 25
-			} else if num < 400 {
-				return 30
-			} else if num < 880 {
-				return 35
-			} else if num < 1600 {
-				return 40
-			} else {
-				return 50
-			}
-		},
+		Scoring: &ReportScoring{Ranges: []ReportScoreRange{
+			{Below: reportPoints(100), Points: 20},
+			{Below: reportPoints(280), Points: 25},
+			{Below: reportPoints(400), Points: 30},
+			{Below: reportPoints(880), Points: 35},
+			{Below: reportPoints(1600), Points: 40},
+			{Points: 50},
+		}},
 	},
 
 	{
@@ -330,20 +458,13 @@ var DefaultRatingReports = []reportTypeKey{
 		Title: "Halkara synaglaryna taýýarlyk toparlaryna kabul edilen diňleýjileriň sany (ähli şahamçalar boýunça)",
 		Key:   "exams_international_count",
 		Type:  ReportValueTypeNumber,
-		CalcPoint: func(val interface{}) int {
-			num := val.(int)
-			if num < 10 {
-				return 25
-			} else if num < 20 {
-				return 30
-			} else if num < 50 {
-				return 35
-			} else if num < 100 {
-				return 40
-			} else {
-				return 50
-			}
-		},
+		Scoring: &ReportScoring{Ranges: []ReportScoreRange{
+			{Below: reportPoints(10), Points: 25},
+			{Below: reportPoints(20), Points: 30},
+			{Below: reportPoints(50), Points: 35},
+			{Below: reportPoints(100), Points: 40},
+			{Points: 50},
+		}},
 	},
 
 	{
@@ -352,162 +473,111 @@ var DefaultRatingReports = []reportTypeKey{
 		Type:  ReportValueTypeList,
 	},
 	{
-		Title: "Hasabat döwri üçin Okuw merkezleriniň arasynda geçirilen döwlet bäsleşiklerinde Okuw merkeziniň diňleýjileriniň gazanan I orun sany",
-		Key:   "competitions1_count",
-		Type:  ReportValueTypeNumber,
-		CalcPoint: func(val interface{}) int {
-			num := val.(int)
-			return num * 20
-		},
+		Title:   "Hasabat döwri üçin Okuw merkezleriniň arasynda geçirilen döwlet bäsleşiklerinde Okuw merkeziniň diňleýjileriniň gazanan I orun sany",
+		Key:     "competitions1_count",
+		Type:    ReportValueTypeNumber,
+		Scoring: &ReportScoring{Per: reportPoints(20)},
 	},
 	{
-		Title: "II orun sany",
-		Key:   "competitions2_count",
-		Type:  ReportValueTypeNumber,
-		CalcPoint: func(val interface{}) int {
-			num := val.(int)
-			return num * 15
-		},
+		Title:   "II orun sany",
+		Key:     "competitions2_count",
+		Type:    ReportValueTypeNumber,
+		Scoring: &ReportScoring{Per: reportPoints(15)},
 	},
 	{
-		Title: "III orun sany",
-		Key:   "competitions3_count",
-		Type:  ReportValueTypeNumber,
-		CalcPoint: func(val interface{}) int {
-			num := val.(int)
-			return num * 10
-		},
+		Title:   "III orun sany",
+		Key:     "competitions3_count",
+		Type:    ReportValueTypeNumber,
+		Scoring: &ReportScoring{Per: reportPoints(10)},
 	},
 
 	{
-		Title: "Hasabat döwri üçin Türkmenistanyň ýaşlarynyň arasynda geçirilýän bilim-taslama bäsleşikleriň orun gazanylanlary",
-		Key:   "exhibitions",
-		Type:  ReportValueTypeNumber,
-		CalcPoint: func(val interface{}) int {
-			num := val.(int)
-			return num * 5
-		},
+		Title:   "Hasabat döwri üçin Türkmenistanyň ýaşlarynyň arasynda geçirilýän bilim-taslama bäsleşikleriň orun gazanylanlary",
+		Key:     "exhibitions",
+		Type:    ReportValueTypeNumber,
+		Scoring: &ReportScoring{Per: reportPoints(5)},
 	},
 	{
-		Title: "Hasabat döwri üçin ýaşlaryň arasynda geçirilýän döwlet we halkara bilim-taslama bäsleşiklerinde Okuw merkeziniň diňleýjileriniň gazanan I orun sany",
-		Key:   "exhibitions1_count",
-		Type:  ReportValueTypeNumber,
-		CalcPoint: func(val interface{}) int {
-			num := val.(int)
-			return num * 20
-		},
+		Title:   "Hasabat döwri üçin ýaşlaryň arasynda geçirilýän döwlet we halkara bilim-taslama bäsleşiklerinde Okuw merkeziniň diňleýjileriniň gazanan I orun sany",
+		Key:     "exhibitions1_count",
+		Type:    ReportValueTypeNumber,
+		Scoring: &ReportScoring{Per: reportPoints(20)},
 	},
 	{
-		Title: "II orun sany",
-		Key:   "exhibitions2_count",
-		Type:  ReportValueTypeNumber,
-		CalcPoint: func(val interface{}) int {
-			num := val.(int)
-			return num * 15
-		},
+		Title:   "II orun sany",
+		Key:     "exhibitions2_count",
+		Type:    ReportValueTypeNumber,
+		Scoring: &ReportScoring{Per: reportPoints(15)},
 	},
 	{
-		Title: "II orun sany",
-		Key:   "exhibitions3_count",
-		Type:  ReportValueTypeNumber,
-		CalcPoint: func(val interface{}) int {
-			num := val.(int)
-			return num * 10
-		},
+		Title:   "II orun sany",
+		Key:     "exhibitions3_count",
+		Type:    ReportValueTypeNumber,
+		Scoring: &ReportScoring{Per: reportPoints(10)},
 	},
 
 	{
-		Title: "Okuw merkezlerinde bilim alýan mümkinçiligi çäkli adamlaryň sany (A)",
-		Key:   "disabilities_count",
-		Type:  ReportValueTypeNumber,
-		CalcPoint: func(val interface{}) int {
-			num := val.(int)
-			return num * 5
-		},
+		Title:   "Okuw merkezlerinde bilim alýan mümkinçiligi çäkli adamlaryň sany (A)",
+		Key:     "disabilities_count",
+		Type:    ReportValueTypeNumber,
+		Scoring: &ReportScoring{Per: reportPoints(5)},
 	},
 
 	{
-		Title: "Hasabat döwri üçin okadylýan ugurlar boýunça I hünär derejeli pedagogik işgärleriň sanynyň (A) okadylýan ugurlar boýunça jemi sanyna (B) gatnaşygy",
-		Key:   "teachers1_count",
-		Type:  ReportValueTypeNumber,
-		CalcPoint: func(val interface{}) int {
-			num := val.(int)
-			return num * 50
-		},
+		Title:   "Hasabat döwri üçin okadylýan ugurlar boýunça I hünär derejeli pedagogik işgärleriň sanynyň (A) okadylýan ugurlar boýunça jemi sanyna (B) gatnaşygy",
+		Key:     "teachers1_count",
+		Type:    ReportValueTypeNumber,
+		Scoring: &ReportScoring{Per: reportPoints(50)},
 	},
 	{
-		Title: "Hasabat döwri üçin okadylýan ugurlar boýunça II hünär derejeli pedagogik işgärleriň sanynyň (A) okadylýan ugurlar boýunça jemi sanyna (B) gatnaşygy",
-		Key:   "teachers2_count",
-		Type:  ReportValueTypeNumber,
-		CalcPoint: func(val interface{}) int {
-			num := val.(int)
-			return num * 70
-		},
+		Title:   "Hasabat döwri üçin okadylýan ugurlar boýunça II hünär derejeli pedagogik işgärleriň sanynyň (A) okadylýan ugurlar boýunça jemi sanyna (B) gatnaşygy",
+		Key:     "teachers2_count",
+		Type:    ReportValueTypeNumber,
+		Scoring: &ReportScoring{Per: reportPoints(70)},
 	},
 	{
-		Title: "Hasabat döwri üçin okadylýan ugurlar boýunça III hünär derejeli pedagogik işgärleriň sanynyň (A) okadylýan ugurlar boýunça jemi sanyna (B) gatnaşygy",
-		Key:   "teachers3_count",
-		Type:  ReportValueTypeNumber,
-		CalcPoint: func(val interface{}) int {
-			num := val.(int)
-			return num * 70
-		},
+		Title:   "Hasabat döwri üçin okadylýan ugurlar boýunça III hünär derejeli pedagogik işgärleriň sanynyň (A) okadylýan ugurlar boýunça jemi sanyna (B) gatnaşygy",
+		Key:     "teachers3_count",
+		Type:    ReportValueTypeNumber,
+		Scoring: &ReportScoring{Per: reportPoints(70)},
 	},
 	{
-		Title: "Hasabat döwri üçin okadylýan ugurlar boýunça Alymlyk hünär derejeli pedagogik işgärleriň sanynyň (A) okadylýan ugurlar boýunça jemi sanyna (B) gatnaşygy",
-		Key:   "teachers4_count",
-		Type:  ReportValueTypeNumber,
-		CalcPoint: func(val interface{}) int {
-			num := val.(int)
-			return num * 100
-		},
+		Title:   "Hasabat döwri üçin okadylýan ugurlar boýunça Alymlyk hünär derejeli pedagogik işgärleriň sanynyň (A) okadylýan ugurlar boýunça jemi sanyna (B) gatnaşygy",
+		Key:     "teachers4_count",
+		Type:    ReportValueTypeNumber,
+		Scoring: &ReportScoring{Per: reportPoints(100)},
 	},
 
 	{
-		Title: "Hasabat döwri üçin Okuw merkeziniň pedagogik işgärleriň halkara maslahatlara, seminarlara gatnaşanlarynyň sany (A)",
-		Key:   "teachers_international",
-		Type:  ReportValueTypeNumber,
-		CalcPoint: func(val interface{}) int {
-			num := val.(int)
-			return num * 10
-		},
+		Title:   "Hasabat döwri üçin Okuw merkeziniň pedagogik işgärleriň halkara maslahatlara, seminarlara gatnaşanlarynyň sany (A)",
+		Key:     "teachers_international",
+		Type:    ReportValueTypeNumber,
+		Scoring: &ReportScoring{Per: reportPoints(10)},
 	},
 
 	{
-		Title: "Hasabat döwri üçin Okuw merkeziniň pedagogik işgärleriň tejribelerini artdyrmak (tejribe alyşmak) maksady bilen, okuw merkezi tarapyndan daşary ýurtlara iş saparyna iberilen pedagogiki işgärleriň sany (A)",
-		Key:   "teachers_international_trained",
-		Type:  ReportValueTypeNumber,
-		CalcPoint: func(val interface{}) int {
-			num := val.(int)
-			return num * 20
-		},
+		Title:   "Hasabat döwri üçin Okuw merkeziniň pedagogik işgärleriň tejribelerini artdyrmak (tejribe alyşmak) maksady bilen, okuw merkezi tarapyndan daşary ýurtlara iş saparyna iberilen pedagogiki işgärleriň sany (A)",
+		Key:     "teachers_international_trained",
+		Type:    ReportValueTypeNumber,
+		Scoring: &ReportScoring{Per: reportPoints(20)},
 	},
 
 	{
-		Title: "Halkara we döwlet derejesinde geçirilýän jemgyýetçilik we bilim çärelerine işjeň gatnaşandygy üçin sylaglandyrylan (Hormat haty, Minnetdarlyk haty we beýlekiler) işgärleriň hasabat döwrine degişli sylaglarynyň sany",
-		Key:   "teachers_social",
-		Type:  ReportValueTypeNumber,
-		CalcPoint: func(val interface{}) int {
-			num := val.(int)
-			return num * 10
-		},
+		Title:   "Halkara we döwlet derejesinde geçirilýän jemgyýetçilik we bilim çärelerine işjeň gatnaşandygy üçin sylaglandyrylan (Hormat haty, Minnetdarlyk haty we beýlekiler) işgärleriň hasabat döwrine degişli sylaglarynyň sany",
+		Key:     "teachers_social",
+		Type:    ReportValueTypeNumber,
+		Scoring: &ReportScoring{Per: reportPoints(10)},
 	},
 	{
-		Title: "Arkadag we Aşgabat şäherleri hem-de welaýat derejesinde geçirilýän jemgyýetçilik we bilim çärelerine işjeň gatnaşandygy üçin sylaglandyrylan (Hormat haty, Minnetdarlyk haty we beýlekiler) işgärleriň hasabat döwrüne degişli sylaglarynyň sany",
-		Key:   "teachers_social_capital",
-		Type:  ReportValueTypeNumber,
-		CalcPoint: func(val interface{}) int {
-			num := val.(int)
-			return num * 5
-		},
+		Title:   "Arkadag we Aşgabat şäherleri hem-de welaýat derejesinde geçirilýän jemgyýetçilik we bilim çärelerine işjeň gatnaşandygy üçin sylaglandyrylan (Hormat haty, Minnetdarlyk haty we beýlekiler) işgärleriň hasabat döwrüne degişli sylaglarynyň sany",
+		Key:     "teachers_social_capital",
+		Type:    ReportValueTypeNumber,
+		Scoring: &ReportScoring{Per: reportPoints(5)},
 	},
 	{
-		Title: "Hasabat döwri üçin Okuw merkeziniň Sanly bilim portalyna gündelik girýän ulanyjylaryň sanynyň (A) okuw merkeziniň mugallymlarynyň, diňleýjileriniň sanyna (B) bolan gatnaşygy (okuw ýyly boýunça ortaça bir güne düşýän sanyndan ugur alynýar)",
-		Key:   "online_count",
-		Type:  ReportValueTypeNumber,
-		CalcPoint: func(val interface{}) int {
-			num := val.(int)
-			return num
-		},
+		Title:   "Hasabat döwri üçin Okuw merkeziniň Sanly bilim portalyna gündelik girýän ulanyjylaryň sanynyň (A) okuw merkeziniň mugallymlarynyň, diňleýjileriniň sanyna (B) bolan gatnaşygy (okuw ýyly boýunça ortaça bir güne düşýän sanyndan ugur alynýar)",
+		Key:     "online_count",
+		Type:    ReportValueTypeNumber,
+		Scoring: &ReportScoring{Per: reportPoints(1)},
 	},
 }
@@ -0,0 +1,161 @@
+package models
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Report formula is arithmetic of numbers and values by keys: "({a} + {b}) / {c} * 100".
+// Supported: + - * / and brackets, division by zero gives zero (empty values in report).
+
+var ErrReportFormula = errors.New("invalid formula")
+
+type reportFormulaParser struct {
+	src    string
+	pos    int
+	values map[string]float64
+	keys   []string
+}
+
+// ReportFormulaKeys validates formula and returns keys used in it
+func ReportFormulaKeys(formula string) ([]string, error) {
+	p := reportFormulaParser{src: formula}
+	_, err := p.parse()
+	if err != nil {
+		return nil, err
+	}
+	return p.keys, nil
+}
+
+// ReportFormulaEval calculates formula, missing keys are zero
+func ReportFormulaEval(formula string, values map[string]float64) (float64, error) {
+	p := reportFormulaParser{src: formula, values: values}
+	return p.parse()
+}
+
+func (p *reportFormulaParser) parse() (float64, error) {
+	v, err := p.expr()
+	if err != nil {
+		return 0, err
+	}
+	p.skipSpaces()
+	if p.pos < len(p.src) {
+		return 0, p.errorAt()
+	}
+	return v, nil
+}
+
+func (p *reportFormulaParser) errorAt() error {
+	return errors.New(ErrReportFormula.Error() + " at " + strconv.Itoa(p.pos+1) + ": " + p.src)
+}
+
+func (p *reportFormulaParser) skipSpaces() {
+	for p.pos < len(p.src) && p.src[p.pos] == ' ' {
+		p.pos++
+	}
+}
+
+func (p *reportFormulaParser) next() byte {
+	p.skipSpaces()
+	if p.pos < len(p.src) {
+		return p.src[p.pos]
+	}
+	return 0
+}
+
+// expr = term {("+"|"-") term}
+func (p *reportFormulaParser) expr() (float64, error) {
+	v, err := p.term()
+	if err != nil {
+		return 0, err
+	}
+	for {
+		op := p.next()
+		if op != '+' && op != '-' {
+			return v, nil
+		}
+		p.pos++
+		r, err := p.term()
+		if err != nil {
+			return 0, err
+		}
+		if op == '+' {
+			v += r
+		} else {
+			v -= r
+		}
+	}
+}
+
+// term = factor {("*"|"/") factor}
+func (p *reportFormulaParser) term() (float64, error) {
+	v, err := p.factor()
+	if err != nil {
+		return 0, err
+	}
+	for {
+		op := p.next()
+		if op != '*' && op != '/' {
+			return v, nil
+		}
+		p.pos++
+		r, err := p.factor()
+		if err != nil {
+			return 0, err
+		}
+		if op == '*' {
+			v *= r
+		} else if r == 0 {
+			v = 0
+		} else {
+			v /= r
+		}
+	}
+}
+
+// factor = "-" factor | "(" expr ")" | "{" key "}" | number
+func (p *reportFormulaParser) factor() (float64, error) {
+	switch c := p.next(); {
+	case c == '-':
+		p.pos++
+		v, err := p.factor()
+		return -v, err
+	case c == '(':
+		p.pos++
+		v, err := p.expr()
+		if err != nil {
+			return 0, err
+		}
+		if p.next() != ')' {
+			return 0, p.errorAt()
+		}
+		p.pos++
+		return v, nil
+	case c == '{':
+		end := strings.IndexByte(p.src[p.pos:], '}')
+		if end < 0 {
+			return 0, p.errorAt()
+		}
+		key := strings.TrimSpace(p.src[p.pos+1 : p.pos+end])
+		if key == "" {
+			return 0, p.errorAt()
+		}
+		p.pos += end + 1
+		p.keys = append(p.keys, key)
+		return p.values[key], nil
+	case c == '.' || unicode.IsDigit(rune(c)):
+		start := p.pos
+		for p.pos < len(p.src) && (p.src[p.pos] == '.' || unicode.IsDigit(rune(p.src[p.pos]))) {
+			p.pos++
+		}
+		v, err := strconv.ParseFloat(p.src[start:p.pos], 64)
+		if err != nil {
+			p.pos = start
+			return 0, p.errorAt()
+		}
+		return v, nil
+	}
+	return 0, p.errorAt()
+}
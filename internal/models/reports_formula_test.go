@@ -0,0 +1,90 @@
+package models
+
+import (
+	"math"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestReportFormulaEval(t *testing.T) {
+	values := map[string]float64{"a": 6, "b": 4, "c": 5, "zero": 0}
+	cases := map[string]float64{
+		"1":                   1,
+		"2.5":                 2.5,
+		".5 + 1":              1.5,
+		"{a} + {b}":           10,
+		"{a} - {b} - 1":       1,
+		"{a} + {b} * {c}":     26,
+		"({a} + {b}) * {c}":   50,
+		"({a} + {b}) / {c}":   2,
+		"{a} / {b} / 3":       0.5,
+		"-{a} + 10":           4,
+		"--{a}":               6,
+		"-({a} - {b}) * 2":    -4,
+		"{a} / {zero}":        0,
+		"{a} / ({b} - 4) + 1": 1,
+		"{missing} + 1":       1,
+		"{ a }*2":             12,
+		"  ( ( {c} ) )  ":     5,
+		"{b} / {c} * 100":     80,
+	}
+	for formula, want := range cases {
+		got, err := ReportFormulaEval(formula, values)
+		if err != nil {
+			t.Errorf("ReportFormulaEval(%q): %v", formula, err)
+			continue
+		}
+		if math.Abs(got-want) > 1e-9 {
+			t.Errorf("ReportFormulaEval(%q) = %v, want %v", formula, got, want)
+		}
+	}
+}
+
+func TestReportFormulaInvalid(t *testing.T) {
+	invalid := []string{
+		"",
+		"1 +",
+		"* 2",
+		"(1 + 2",
+		"1 + 2)",
+		"{a",
+		"{} + 1",
+		"{a} {b}",
+		"1..2",
+		"2 ^ 3",
+		"abc",
+	}
+	for _, formula := range invalid {
+		_, err := ReportFormulaEval(formula, nil)
+		if err == nil {
+			t.Errorf("ReportFormulaEval(%q): expected error", formula)
+			continue
+		}
+		if !strings.HasPrefix(err.Error(), ErrReportFormula.Error()) {
+			t.Errorf("ReportFormulaEval(%q): unexpected error %v", formula, err)
+		}
+		if _, err := ReportFormulaKeys(formula); err == nil {
+			t.Errorf("ReportFormulaKeys(%q): expected error", formula)
+		}
+	}
+	// position of error is given
+	_, err := ReportFormulaEval("1 + (2 * 3", nil)
+	if err == nil || !strings.Contains(err.Error(), " at 11:") {
+		t.Errorf("error position: %v", err)
+	}
+}
+
+func TestReportFormulaKeys(t *testing.T) {
+	keys, err := ReportFormulaKeys("({students} - {absents}) / {students} * 100")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(keys, []string{"students", "absents", "students"}) {
+		t.Errorf("keys = %v", keys)
+	}
+	keys, err = ReportFormulaKeys("2 * 3")
+	if err != nil || len(keys) != 0 {
+		t.Errorf("keys of formula without keys = %v, %v", keys, err)
+	}
+}
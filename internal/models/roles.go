@@ -0,0 +1,104 @@
+package models
+
+import (
+	"time"
+)
+
+type PermissionAccess string
+
+const (
+	PermissionAccessRead  PermissionAccess = "read"
+	PermissionAccessWrite PermissionAccess = "write"
+	// removes grant, used by school override or to keep removed grant of system role
+	PermissionAccessNone PermissionAccess = "none"
+)
+
+var PermissionAccesses = []PermissionAccess{PermissionAccessRead, PermissionAccessWrite, PermissionAccessNone}
+
+// RoleDefinition is role stored in db: system roles are DefaultRoles,
+// custom roles (deputy principal, librarian...) act as BaseRole with own grants
+type RoleDefinition struct {
+	Code        string            `json:"code"`
+	Name        string            `json:"name"`
+	BaseRole    Role              `json:"base_role"`
+	IsSystem    bool              `json:"is_system"`
+	UpdatedAt   *time.Time        `json:"updated_at"`
+	CreatedAt   *time.Time        `json:"created_at"`
+	Permissions []*RolePermission `json:"permissions"`
+}
+
+func (RoleDefinition) RelationFields() []string {
+	return []string{"Permissions"}
+}
+
+// RolePermission is grant of role, global when SchoolId is nil, otherwise override for school
+type RolePermission struct {
+	ID         string     `json:"id"`
+	RoleCode   string     `json:"role_code"`
+	SchoolId   *string    `json:"school_id"`
+	Permission string     `json:"permission"`
+	Access     string     `json:"access"`
+	UpdatedAt  *time.Time `json:"updated_at"`
+}
+
+func (RolePermission) RelationFields() []string {
+	return []string{}
+}
+
+type RolePermissionRequest struct {
+	Permission string `json:"permission" validate:"required"`
+	Access     string `json:"access" validate:"required,oneof=read write none"`
+}
+
+type RoleRequest struct {
+	Code        *string                 `json:"code"`
+	Name        *string                 `json:"name" validate:"required"`
+	BaseRole    *string                 `json:"base_role"`
+	Permissions []RolePermissionRequest `json:"permissions" validate:"dive"`
+}
+
+type RoleOverridesRequest struct {
+	Code     *string `json:"code"`
+	SchoolId *string `json:"school_id" validate:"required"`
+	// empty list resets school to global grants
+	Permissions []RolePermissionRequest `json:"permissions" validate:"dive"`
+}
+
+type RoleAssignRequest struct {
+	Code     *string `json:"code"`
+	UserId   *string `json:"user_id" validate:"required"`
+	SchoolId *string `json:"school_id" validate:"required"`
+	// unassign custom role, user stays in base role
+	IsRemove bool `json:"is_remove"`
+}
+
+type RolePermissionResponse struct {
+	Permission string  `json:"permission"`
+	Access     string  `json:"access"`
+	SchoolId   *string `json:"school_id"`
+}
+
+type RoleResponse struct {
+	Code        string                   `json:"code"`
+	Name        string                   `json:"name"`
+	BaseRole    Role                     `json:"base_role"`
+	IsSystem    bool                     `json:"is_system"`
+	UpdatedAt   *time.Time               `json:"updated_at"`
+	Permissions []RolePermissionResponse `json:"permissions"`
+}
+
+func (r *RoleResponse) FromModel(m *RoleDefinition) {
+	r.Code = m.Code
+	r.Name = m.Name
+	r.BaseRole = m.BaseRole
+	r.IsSystem = m.IsSystem
+	r.UpdatedAt = m.UpdatedAt
+	r.Permissions = []RolePermissionResponse{}
+	for _, v := range m.Permissions {
+		r.Permissions = append(r.Permissions, RolePermissionResponse{
+			Permission: v.Permission,
+			Access:     v.Access,
+			SchoolId:   v.SchoolId,
+		})
+	}
+}
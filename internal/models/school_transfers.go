@@ -8,6 +8,8 @@ const (
 	StatusAccepted StatusSchoolTransfer = "accepted"
 	StatusDeclined StatusSchoolTransfer = "rejected"
 	StatusWaiting  StatusSchoolTransfer = "waiting"
+	// waiting transfer which was not answered till expires_at
+	StatusExpired StatusSchoolTransfer = "expired"
 )
 
 type SchoolTransfer struct {
@@ -25,6 +27,7 @@ type SchoolTransfer struct {
 	Status            *string    `json:"status"`
 	UpdatedAt         *time.Time `json:"updated_at"`
 	CreatedAt         *time.Time `json:"created_at"`
+	ExpiresAt         *time.Time `json:"expires_at"`
 	Student           *User      `json:"student"`
 	TargetSchool      *School    `json:"target_school"`
 	SourceSchool      *School    `json:"source_school"`
@@ -73,6 +76,7 @@ type SchoolTransferResponse struct {
 	Status            *string            `json:"status"`
 	UpdatedAt         *time.Time         `json:"updated_at"`
 	CreatedAt         *time.Time         `json:"created_at"`
+	ExpiresAt         *time.Time         `json:"expires_at"`
 	Student           *UserResponse      `json:"student"`
 	TargetSchool      *SchoolResponse    `json:"target_school"`
 	SourceSchool      *SchoolResponse    `json:"source_school"`
@@ -82,6 +86,26 @@ type SchoolTransferResponse struct {
 	RecievedByUser    *UserResponse      `json:"received_by"`
 }
 
+// SchoolTransferSubjectPreview shows where period grades of source subject are moved,
+// TargetSubject is nil when subject of target classroom is not found and grades are lost
+type SchoolTransferSubjectPreview struct {
+	SourceSubjectId   string  `json:"source_subject_id"`
+	SourceSubjectName *string `json:"source_subject_name"`
+	TargetSubjectId   *string `json:"target_subject_id"`
+	TargetSubjectName *string `json:"target_subject_name"`
+	PeriodKeys        []int   `json:"period_keys"`
+	GradeCount        int     `json:"grade_count"`
+	GradeSum          int     `json:"grade_sum"`
+	AbsentCount       int     `json:"absent_count"`
+	IsMatched         bool    `json:"is_matched"`
+}
+
+type SchoolTransferPreviewResponse struct {
+	TargetClassroom *ClassroomResponse             `json:"target_classroom"`
+	Subjects        []SchoolTransferSubjectPreview `json:"subjects"`
+	UnmatchedCount  int                            `json:"unmatched_count"`
+}
+
 type SchoolTransfersResponse struct {
 	SchoolTransfersResponse []SchoolTransferResponse `json:"school_transfer_response"`
 	Total                   int                      `json:"total"`
@@ -116,6 +140,7 @@ func (response *SchoolTransferResponse) FromModel(model *SchoolTransfer) {
 	response.Status = model.Status
 	response.CreatedAt = model.CreatedAt
 	response.UpdatedAt = model.UpdatedAt
+	response.ExpiresAt = model.ExpiresAt
 	if model.Student != nil {
 		t := UserResponse{}
 		t.FromModel(model.Student)
@@ -195,6 +220,9 @@ func ConvertSchoolTransferQueryToMap(query SchoolTransferQueryDto) (m map[string
 	if query.SourceSchoolId != nil && *query.SourceSchoolId != "" {
 		m["source_school_id"] = *query.SourceSchoolId
 	}
+	if query.Status != nil && *query.Status != "" {
+		m["status"] = *query.Status
+	}
 	if v := query.Limit; v > 0 {
 		m["limit"] = v
 	} else {
@@ -3,6 +3,9 @@ package models
 import (
 	"strings"
 	"time"
+
+	"github.com/mekdep/server/config"
+	"github.com/mekdep/server/internal/utils/storage"
 )
 
 type School struct {
@@ -28,6 +31,7 @@ type School struct {
 	UpdatedAt         *time.Time `json:"updated_at"`
 	CreatedAt         *time.Time `json:"created_at"`
 	ArchivedAt        *time.Time `json:"archived_at"`
+	Timezone          *string    `json:"timezone"`
 	TimetablesCount   *int       `json:"timetables_count"`
 	ClassroomsCount   *int       `json:"classrooms_count"`
 	Parent            *School    `json:"parent"`
@@ -39,12 +43,35 @@ func (School) RelationFields() []string {
 	return []string{"Parent", "Admin", "Specialist"}
 }
 
+// DefaultLocation is timezone of deployment, schools without own or region timezone are in it
+func DefaultLocation() *time.Location {
+	return config.RequestLocation
+}
+
+// Location is timezone of school, of its region (when loaded) when not set, of deployment by default
+func (s *School) Location() *time.Location {
+	if s == nil {
+		return DefaultLocation()
+	}
+	if s.Timezone != nil && *s.Timezone != "" {
+		if loc := LoadLocation(*s.Timezone); loc != nil {
+			return loc
+		}
+	}
+	if s.Parent != nil {
+		return s.Parent.Location()
+	}
+	return DefaultLocation()
+}
+
 type UserSchool struct {
 	SchoolUid *string `json:"school_id"`
 	UserId    string  `json:"user_id"`
 	RoleCode  Role    `json:"role_code"`
-	School    *School `json:"school"`
-	User      *User   `json:"user"`
+	// custom role acting as RoleCode in school (see RoleDefinition)
+	CustomRoleCode *string `json:"custom_role_code"`
+	School         *School `json:"school"`
+	User           *User   `json:"user"`
 }
 
 type SchoolValueResponse struct {
@@ -134,11 +161,13 @@ type SchoolResponse struct {
 	Description       *string         `json:"description"`
 	Address           *string         `json:"address"`
 	Avatar            *string         `json:"avatar"`
+	AvatarThumb       *string         `json:"avatar_thumb"`
 	Background        *string         `json:"background"`
 	Phone             *string         `json:"phone"`
 	Email             *string         `json:"email"`
 	Level             *string         `json:"level"`
 	Galleries         *[]string       `json:"galleries"`
+	GalleriesThumbs   *[]string       `json:"galleries_thumbs"`
 	Latitude          *string         `json:"latitude"`
 	Longitude         *string         `json:"longitude"`
 	IsDigitalized     *bool           `json:"is_digitalized"`
@@ -146,6 +175,7 @@ type SchoolResponse struct {
 	UpdatedAt         *time.Time      `json:"updated_at"`
 	CreatedAt         *time.Time      `json:"created_at"`
 	ArchivedAt        *time.Time      `json:"archived_at"`
+	Timezone          *string         `json:"timezone"`
 	TimetablesCount   *int            `json:"timetables_count"`
 	ClassroomsCount   *int            `json:"classrooms_count"`
 	Parent            *SchoolResponse `json:"parent"`
@@ -192,6 +222,7 @@ type SchoolRequest struct {
 	IsDigitalized     *bool     `json:"is_digitalized" form:"is_digitalized"`
 	IsSecondarySchool *bool     `json:"is_secondary_school" form:"is_secondary_school"`
 	IsArchive         *bool     `json:"is_archive" form:"is_archive"`
+	Timezone          *string   `json:"timezone" form:"timezone"`
 	ParentUid         *string   `json:"parent_id" form:"parent_id"`
 	AdminUid          *string   `json:"admin_id" form:"admin_id"`
 	SpecialistUId     *string   `json:"specialist_id" form:"specialist_id"`
@@ -220,6 +251,8 @@ func (r *SchoolResponse) FromModel(m *School) {
 	if m.Avatar != nil {
 		a := fileUrl(m.Avatar)
 		r.Avatar = &a
+		t := storage.FileUrl(storage.ThumbPath(*m.Avatar))
+		r.AvatarThumb = &t
 	}
 	r.Background = m.Background
 	r.Phone = m.Phone
@@ -227,8 +260,10 @@ func (r *SchoolResponse) FromModel(m *School) {
 	r.Level = m.Level
 	if m.Galleries != nil {
 		r.Galleries = &[]string{}
+		r.GalleriesThumbs = &[]string{}
 		for _, f := range *m.Galleries {
 			*r.Galleries = append(*r.Galleries, fileUrl(&f))
+			*r.GalleriesThumbs = append(*r.GalleriesThumbs, storage.FileUrl(storage.ThumbPath(f)))
 		}
 	}
 	r.Latitude = m.Latitude
@@ -238,6 +273,7 @@ func (r *SchoolResponse) FromModel(m *School) {
 	r.UpdatedAt = m.UpdatedAt
 	r.CreatedAt = m.CreatedAt
 	r.ArchivedAt = m.ArchivedAt
+	r.Timezone = m.Timezone
 	r.TimetablesCount = m.TimetablesCount
 	r.ClassroomsCount = m.ClassroomsCount
 	if m.Admin != nil {
@@ -280,6 +316,7 @@ func (r *SchoolRequest) ToModel(m *School) {
 	m.ParentUid = r.ParentUid
 	m.AdminUid = r.AdminUid
 	m.SpecialistUid = r.SpecialistUId
+	m.Timezone = r.Timezone
 	if m.ArchivedAt == nil && r.IsArchive != nil && *r.IsArchive {
 		m.ArchivedAt = new(time.Time)
 		*m.ArchivedAt = time.Now()
@@ -0,0 +1,120 @@
+package models
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultGraduationClassyear is last classyear of school, its classrooms graduate on rollover
+const DefaultGraduationClassyear = 12
+
+// Rollover is year-end move of school to next period
+type Rollover struct {
+	ID           string              `json:"id"`
+	SchoolId     string              `json:"school_id"`
+	FromPeriodId *string             `json:"from_period_id"`
+	ToPeriodId   *string             `json:"to_period_id"`
+	Classrooms   []RolloverClassroom `json:"classrooms"`
+	CreatedBy    *string             `json:"created_by"`
+	CreatedAt    *time.Time          `json:"created_at"`
+}
+
+func (Rollover) RelationFields() []string {
+	return []string{}
+}
+
+// RolloverClassroom is diff line of classroom: what is carried to successor or why it is not
+type RolloverClassroom struct {
+	ClassroomId   string   `json:"classroom_id"`
+	Name          *string  `json:"name"`
+	SuccessorId   *string  `json:"successor_id"`
+	SuccessorName *string  `json:"successor_name"`
+	IsGraduating  bool     `json:"is_graduating"`
+	IsSkipped     bool     `json:"is_skipped"`
+	TeacherId     *string  `json:"teacher_id"`
+	StudentIds    []string `json:"student_ids"`
+	SubGroups     []string `json:"sub_groups"`
+	Subjects      []string `json:"subjects"`
+	// successor name is already used in next period or classroom has no classyear
+	Warning *string `json:"warning"`
+}
+
+type RolloverClassroomRequest struct {
+	ClassroomId   *string `json:"classroom_id" validate:"required"`
+	SuccessorName *string `json:"successor_name"`
+	TeacherId     *string `json:"teacher_id"`
+	IsGraduating  *bool   `json:"is_graduating"`
+	IsSkipped     *bool   `json:"is_skipped"`
+}
+
+type RolloverRequest struct {
+	SchoolId    *string     `json:"school_id"`
+	PeriodTitle *string     `json:"period_title"`
+	PeriodValue *[][]string `json:"period_value"`
+	// classrooms of this classyear and above graduate
+	GraduationClassyear *int `json:"graduation_classyear" validate:"omitempty,min=1,max=12"`
+	// overrides of default mapping by classyear
+	Classrooms []RolloverClassroomRequest `json:"classrooms" validate:"dive"`
+	IsDryRun   bool                       `json:"is_dry_run"`
+}
+
+type RolloverFilterRequest struct {
+	SchoolId  *string   `form:"school_id"`
+	SchoolIds *[]string `form:"school_ids[]"`
+	PaginationRequest
+}
+
+type RolloverResponse struct {
+	ID             *string             `json:"id"`
+	IsDryRun       bool                `json:"is_dry_run"`
+	SchoolId       string              `json:"school_id"`
+	FromPeriod     *PeriodResponse     `json:"from_period"`
+	ToPeriod       *PeriodResponse     `json:"to_period"`
+	Classrooms     []RolloverClassroom `json:"classrooms"`
+	StudentsCount  int                 `json:"students_count"`
+	GraduatesCount int                 `json:"graduates_count"`
+	SubjectsCount  int                 `json:"subjects_count"`
+	CreatedBy      *string             `json:"created_by"`
+	CreatedAt      *time.Time          `json:"created_at"`
+}
+
+func (r *RolloverResponse) FromModel(m *Rollover) {
+	r.ID = &m.ID
+	r.SchoolId = m.SchoolId
+	r.Classrooms = m.Classrooms
+	r.CreatedBy = m.CreatedBy
+	r.CreatedAt = m.CreatedAt
+	if m.FromPeriodId != nil {
+		r.FromPeriod = &PeriodResponse{ID: *m.FromPeriodId}
+	}
+	if m.ToPeriodId != nil {
+		r.ToPeriod = &PeriodResponse{ID: *m.ToPeriodId}
+	}
+	r.SetCounts()
+}
+
+func (r *RolloverResponse) SetCounts() {
+	r.StudentsCount, r.GraduatesCount, r.SubjectsCount = 0, 0, 0
+	for _, c := range r.Classrooms {
+		if c.IsSkipped {
+			continue
+		}
+		if c.IsGraduating {
+			r.GraduatesCount += len(c.StudentIds)
+			continue
+		}
+		r.StudentsCount += len(c.StudentIds)
+		r.SubjectsCount += len(c.Subjects)
+	}
+}
+
+// SuccessorName is name of classroom in next year: "5A" -> "6A", empty when name has no classyear
+func (c Classroom) SuccessorName() string {
+	classyear := c.Classyear()
+	n, err := strconv.Atoi(classyear)
+	if err != nil || c.Name == nil {
+		return ""
+	}
+	return strconv.Itoa(n+1) + strings.TrimPrefix(*c.Name, classyear)
+}
@@ -331,6 +331,7 @@ type GeneralSetting struct {
 	TimetableUpdateCurrentWeek bool                           `json:"timetable_update_week_available"`
 	ContactPhones              []ContactPhones                `json:"contact_phones"`
 	IsForeignCountry           bool                           `json:"is_foreign_country"`
+	Country                    CountrySetting                 `json:"country"`
 	UserDocumentKeys           []string                       `json:"user_document_keys"`
 }
 
@@ -565,6 +566,10 @@ type ContactItemsCount struct {
 	ComplaintCount     int    `json:"complaint_count"`
 	SuggestionCount    int    `json:"suggestion_count"`
 	DataComplaintCount int    `json:"data_complaint_count"`
+	// average hours till first response and till done or rejected
+	ResponseHours   float64 `json:"response_hours"`
+	ResolutionHours float64 `json:"resolution_hours"`
+	OverdueCount    int     `json:"overdue_count"`
 }
 
 type PaymentTransactionsCount struct {
@@ -604,6 +609,7 @@ type SchoolStudentsCount struct {
 	Counts     [][]int `json:"counts"`
 }
 
+// holidays of Turkmenistan, use DefaultCountry().Holidays
 var DefaultHolidays = []HolidaySetting{
 	{
 		StartDate: time.Date(2000, 1, 1, 0, 0, 0, 0, time.Local),
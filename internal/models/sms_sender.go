@@ -19,6 +19,8 @@ type SmsSender struct {
 	LeftTry     uint       `json:"left_try"`
 	TriedAt     *time.Time `json:"tried_at"`
 	CreatedAt   *time.Time `json:"created_at"`
+	// deferred by quiet hours, sent by scheduled job
+	SendAt *time.Time `json:"send_at"`
 }
 
 func (SmsSender) RelationFields() []string {
@@ -27,5 +29,7 @@ func (SmsSender) RelationFields() []string {
 
 type SmsSenderFilterRequest struct {
 	ID *string `json:"id"`
+	// not completed and deferred until this time
+	SendBefore *time.Time `json:"-"`
 	PaginationRequest
 }
@@ -19,6 +19,7 @@ var DefaultSubjectAreas = []SubjectArea{
 }
 
 // TODO: fill subject areas
+// subjects of Turkmenistan, use DefaultCountry().Subjects
 var DefaultSubjects = [][]string{
 	{"Algebra", "Algebra", string(SubjectAreaExact), "#3E5F8A"},
 	{"Astronomiýa", "Astronomiýa", string(SubjectAreaNatural), "#C7B446"},
@@ -8,7 +8,7 @@ import (
 	"strings"
 	"time"
 
-	"github.com/mekdep/server/config"
+	"github.com/mekdep/server/internal/utils/storage"
 )
 
 type Role string
@@ -114,17 +114,26 @@ func (User) RelationFields() []string {
 	return []string{"TeacherClassroom", "Children", "Parents", "Schools", "Classrooms"}
 }
 
+// FormattedPhone is E.164 number without plus as sms gateways expect: 99365123456
 func (u *User) FormattedPhone() (string, error) {
-	p := ""
-	if u.Phone != nil {
-		p = *u.Phone
-		if len(p) < 8 {
-			return "", errors.New("phone is invalid")
-		}
-	} else {
+	if u.Phone == nil {
 		return "", errors.New("phone is not set")
 	}
-	return "993" + p, nil
+	p, err := PhoneE164(*u.Phone)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimPrefix(p, "+"), nil
+}
+
+// Location is timezone of first loaded school of user
+func (u *User) Location() *time.Location {
+	for _, v := range u.Schools {
+		if v.School != nil {
+			return v.School.Location()
+		}
+	}
+	return DefaultLocation()
 }
 
 func (u *User) FullName() string {
@@ -210,6 +219,7 @@ type UserResponse struct {
 	Gender          *int       `json:"gender"`
 	Address         *string    `json:"address"`
 	Avatar          *string    `json:"avatar"`
+	AvatarThumb     *string    `json:"avatar_thumb"`
 	LastOnlineAt    *time.Time `json:"last_online_at"`
 	IsActive        bool       `json:"is_active"`
 	Role            *string    `json:"role"`
@@ -243,6 +253,8 @@ type UserResponse struct {
 	Schools          []*UserSchoolResource `json:"schools"`
 	Classrooms       []*ClassroomResponse  `json:"classrooms"`
 	TeacherClassroom *ClassroomResponse    `json:"teacher_classroom"`
+	// history of student, only in detail
+	SchoolTransfers *[]SchoolTransferResponse `json:"school_transfers,omitempty"`
 }
 
 type UserRequest struct {
@@ -359,6 +371,12 @@ type UserFilterRequest struct {
 	FirstName              *string    ``
 	LastName               *string    ``
 	NoParent               *bool      `form:"no_parent"`
+	BirthCertNumber        *string    ``
+	// parents are matched by classrooms and tariffs of children, teachers by their classrooms
+	ClassroomIds *[]string ``
+	TariffTypes  *[]string ``
+	// only users with verified email, for email digests
+	EmailVerified *bool ``
 	PaginationRequest
 }
 
@@ -398,9 +416,10 @@ func (data UserFilterRequest) ToMap() map[string]interface{} {
 }
 
 // TODO move
+// private files (documents) get signed url, see storage.PrivateFolders
 func fileUrl(path *string) string {
 	if path != nil {
-		return config.Conf.AppUrl + "/uploads/" + *path
+		return storage.FileUrl(*path)
 	}
 	return ""
 }
@@ -437,6 +456,8 @@ func (u *UserResponse) FromModel(m *User) {
 	if m.Avatar != nil {
 		a := fileUrl(m.Avatar)
 		u.Avatar = &a
+		t := storage.FileUrl(storage.ThumbPath(*m.Avatar))
+		u.AvatarThumb = &t
 	}
 	u.Children = []*UserResponse{}
 	for _, i := range m.Children {
@@ -539,6 +560,12 @@ func (u *UserRequest) Format() {
 	if u.MiddleName != nil {
 		*u.MiddleName = strings.Trim(*u.MiddleName, " ")
 	}
+	// invalid phone is kept for validation
+	if u.Phone != nil && *u.Phone != "" {
+		if p, err := PhoneE164(*u.Phone); err == nil {
+			*u.Phone = p
+		}
+	}
 
 	// if gender is not specified
 	if u.Gender == nil && u.LastName != nil {
@@ -80,3 +80,12 @@ type SessionFilter struct {
 	Lat         *time.Time
 	PaginationRequest
 }
+
+type ImpersonationRequest struct {
+	UserId   *string `json:"user_id"`
+	RoleCode *string `json:"role_code" validate:"required"`
+	SchoolId *string `json:"school_id"`
+	PeriodId *string `json:"period_id"`
+	// session length, 30 minutes by default
+	Minutes *int `json:"minutes" validate:"omitempty,min=1,max=120"`
+}
@@ -0,0 +1,108 @@
+package models
+
+import (
+	"time"
+
+	"github.com/mekdep/server/internal/utils/storage"
+)
+
+const (
+	UserDataRequestExport  = "export"
+	UserDataRequestErasure = "erasure"
+)
+
+// erasure waits approval of admin, export is pending at once
+const (
+	UserDataRequestWait       = "wait"
+	UserDataRequestPending    = "pending"
+	UserDataRequestProcessing = "processing"
+	UserDataRequestDone       = "done"
+	UserDataRequestFailed     = "failed"
+	UserDataRequestRejected   = "rejected"
+	// file of export is removed after expires_at
+	UserDataRequestExpired = "expired"
+)
+
+// UserDataRequest is export of personal data of user (with children for parent) or its erasure
+type UserDataRequest struct {
+	ID          string     `json:"id"`
+	UserId      string     `json:"user_id"`
+	Type        string     `json:"type"`
+	Status      string     `json:"status"`
+	File        *string    `json:"file"`
+	Reason      *string    `json:"reason"`
+	Error       *string    `json:"error"`
+	RequestedBy *string    `json:"requested_by"`
+	ReviewedBy  *string    `json:"reviewed_by"`
+	ReviewedAt  *time.Time `json:"reviewed_at"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+	CreatedAt   *time.Time `json:"created_at"`
+	UpdatedAt   *time.Time `json:"updated_at"`
+	DoneAt      *time.Time `json:"done_at"`
+	User        *User      `json:"user"`
+}
+
+func (UserDataRequest) RelationFields() []string {
+	return []string{"User"}
+}
+
+type UserDataRequestRequest struct {
+	Type string `json:"type" validate:"required,oneof=export erasure"`
+	// own child of parent, self when empty
+	UserId *string `json:"user_id"`
+	Reason *string `json:"reason"`
+}
+
+type UserDataRequestReviewRequest struct {
+	Reason *string `json:"reason"`
+}
+
+type UserDataRequestFilterRequest struct {
+	UserId    *string   `form:"user_id"`
+	UserIds   *[]string `form:"user_ids[]"`
+	SchoolIds *[]string `form:"school_ids[]"`
+	Type      *string   `form:"type"`
+	Status    *string   `form:"status"`
+	PaginationRequest
+}
+
+type UserDataRequestResponse struct {
+	ID          string        `json:"id"`
+	UserId      string        `json:"user_id"`
+	Type        string        `json:"type"`
+	Status      string        `json:"status"`
+	FileUrl     *string       `json:"file_url"`
+	Reason      *string       `json:"reason"`
+	Error       *string       `json:"error"`
+	RequestedBy *string       `json:"requested_by"`
+	ReviewedBy  *string       `json:"reviewed_by"`
+	ReviewedAt  *time.Time    `json:"reviewed_at"`
+	ExpiresAt   *time.Time    `json:"expires_at"`
+	CreatedAt   *time.Time    `json:"created_at"`
+	DoneAt      *time.Time    `json:"done_at"`
+	User        *UserResponse `json:"user"`
+}
+
+func (r *UserDataRequestResponse) FromModel(m *UserDataRequest) {
+	r.ID = m.ID
+	r.UserId = m.UserId
+	r.Type = m.Type
+	r.Status = m.Status
+	r.FileUrl = nil
+	if m.File != nil && m.Status == UserDataRequestDone {
+		u := storage.FileUrl(*m.File)
+		r.FileUrl = &u
+	}
+	r.Reason = m.Reason
+	r.Error = m.Error
+	r.RequestedBy = m.RequestedBy
+	r.ReviewedBy = m.ReviewedBy
+	r.ReviewedAt = m.ReviewedAt
+	r.ExpiresAt = m.ExpiresAt
+	r.CreatedAt = m.CreatedAt
+	r.DoneAt = m.DoneAt
+	if m.User != nil {
+		r.User = &UserResponse{}
+		r.User.FromModel(m.User)
+	}
+}
@@ -0,0 +1,218 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+const (
+	UserImportTypeStudents = "students"
+	UserImportTypeTeachers = "teachers"
+)
+
+const (
+	UserImportDraft      = "draft"
+	UserImportPending    = "pending"
+	UserImportProcessing = "processing"
+	UserImportDone       = "done"
+	UserImportFailed     = "failed"
+	UserImportRolledBack = "rolled_back"
+)
+
+// actions of row in dry run report, only create and merge rows are committed
+const (
+	UserImportActionCreate   = "create"
+	UserImportActionMerge    = "merge"
+	UserImportActionConflict = "conflict"
+	UserImportActionError    = "error"
+)
+
+// kinds of rows added by commit, see user_import_items
+const (
+	UserImportItemUser      = "user"
+	UserImportItemSchool    = "school"
+	UserImportItemClassroom = "classroom"
+	UserImportItemParent    = "parent"
+)
+
+// UserImportParentsCount is how many parents a student row can have: parent1_first_name, parent2_first_name
+const UserImportParentsCount = 2
+
+// UserImportFields are fields which columns of file are mapped to
+var UserImportFields = map[string][]string{
+	UserImportTypeStudents: {
+		"first_name", "last_name", "middle_name", "birthday", "gender", "classroom_name", "address", "birth_cert_number",
+		"parent1_first_name", "parent1_last_name", "parent1_middle_name", "parent1_phone",
+		"parent2_first_name", "parent2_last_name", "parent2_middle_name", "parent2_phone",
+	},
+	UserImportTypeTeachers: {
+		"first_name", "last_name", "middle_name", "birthday", "gender", "phone", "email", "address",
+		"passport_number", "work_title", "education_title",
+	},
+}
+
+// userImportHeaders are usual headers of school lists (lowercase), for default mapping
+var userImportHeaders = map[string][]string{
+	"first_name":        {"ady", "имя", "name"},
+	"last_name":         {"familiýasy", "familiyasy", "фамилия", "surname"},
+	"middle_name":       {"atasynyň ady", "atasynyn ady", "отчество"},
+	"birthday":          {"doglan senesi", "doglan güni", "дата рождения"},
+	"gender":            {"jynsy", "пол"},
+	"classroom_name":    {"synpy", "synp", "класс", "classroom"},
+	"address":           {"salgysy", "adres", "адрес"},
+	"birth_cert_number": {"şahadatnama", "dogluş şahadatnamasy", "свидетельство о рождении"},
+	"phone":             {"telefon", "telefon belgisi", "телефон"},
+	"email":             {"e-mail", "email", "почта"},
+	"passport_number":   {"pasport", "паспорт"},
+	"work_title":        {"wezipesi", "должность"},
+	"education_title":   {"bilimi", "образование"},
+}
+
+// UserImport is uploaded file of users with mapping of its columns and report of last dry run
+type UserImport struct {
+	ID           string          `json:"id"`
+	SchoolId     string          `json:"school_id"`
+	Type         string          `json:"type"`
+	FileName     *string         `json:"file_name"`
+	Columns      []string        `json:"columns"`
+	Rows         [][]string      `json:"rows"`
+	Mapping      map[string]int  `json:"mapping"`
+	Report       []UserImportRow `json:"report"`
+	Status       string          `json:"status"`
+	Error        *string         `json:"error"`
+	CreatedBy    *string         `json:"created_by"`
+	CreatedAt    *time.Time      `json:"created_at"`
+	UpdatedAt    *time.Time      `json:"updated_at"`
+	CommittedAt  *time.Time      `json:"committed_at"`
+	RolledBackAt *time.Time      `json:"rolled_back_at"`
+}
+
+func (UserImport) RelationFields() []string {
+	return []string{}
+}
+
+// UserImportRow is report line of file row: mapped user, what commit does with it and why not
+type UserImportRow struct {
+	// line in file, header is line 1
+	Line        int               `json:"line"`
+	Action      string            `json:"action"`
+	UserId      *string           `json:"user_id"`
+	User        UserRequest       `json:"user"`
+	ClassroomId *string           `json:"classroom_id"`
+	Parents     []UserImportRow   `json:"parents"`
+	Errors      []UserImportError `json:"errors"`
+	// why row is conflict or which line has same parent
+	Comment *string `json:"comment"`
+}
+
+type UserImportError struct {
+	Key     string `json:"key"`
+	Code    string `json:"code"`
+	Comment string `json:"comment"`
+}
+
+type UserImportUploadRequest struct {
+	Type string `form:"type" validate:"required,oneof=students teachers"`
+}
+
+type UserImportMappingRequest struct {
+	// field -> index of column, see UserImportFields
+	Mapping map[string]int `json:"mapping"`
+}
+
+type UserImportFilterRequest struct {
+	SchoolId  *string   `form:"school_id"`
+	SchoolIds *[]string `form:"school_ids[]"`
+	Status    *string   `form:"status"`
+	PaginationRequest
+}
+
+type UserImportResponse struct {
+	ID            string          `json:"id"`
+	SchoolId      string          `json:"school_id"`
+	Type          string          `json:"type"`
+	FileName      *string         `json:"file_name"`
+	Columns       []string        `json:"columns"`
+	Fields        []string        `json:"fields"`
+	Mapping       map[string]int  `json:"mapping"`
+	Preview       [][]string      `json:"preview"`
+	RowsCount     int             `json:"rows_count"`
+	Report        []UserImportRow `json:"report,omitempty"`
+	CreateCount   int             `json:"create_count"`
+	MergeCount    int             `json:"merge_count"`
+	ConflictCount int             `json:"conflict_count"`
+	ErrorCount    int             `json:"error_count"`
+	Status        string          `json:"status"`
+	Error         *string         `json:"error"`
+	CreatedBy     *string         `json:"created_by"`
+	CreatedAt     *time.Time      `json:"created_at"`
+	CommittedAt   *time.Time      `json:"committed_at"`
+	RolledBackAt  *time.Time      `json:"rolled_back_at"`
+}
+
+// UserImportPreviewRows are first rows of file shown for mapping
+const UserImportPreviewRows = 5
+
+func (r *UserImportResponse) FromModel(m *UserImport) {
+	r.ID = m.ID
+	r.SchoolId = m.SchoolId
+	r.Type = m.Type
+	r.FileName = m.FileName
+	r.Columns = m.Columns
+	r.Fields = UserImportFields[m.Type]
+	r.Mapping = m.Mapping
+	r.RowsCount = len(m.Rows)
+	r.Preview = m.Rows[:min(len(m.Rows), UserImportPreviewRows)]
+	r.Report = m.Report
+	r.Status = m.Status
+	r.Error = m.Error
+	r.CreatedBy = m.CreatedBy
+	r.CreatedAt = m.CreatedAt
+	r.CommittedAt = m.CommittedAt
+	r.RolledBackAt = m.RolledBackAt
+	r.CreateCount, r.MergeCount, r.ConflictCount, r.ErrorCount = 0, 0, 0, 0
+	for _, row := range m.Report {
+		switch row.Action {
+		case UserImportActionCreate:
+			r.CreateCount++
+		case UserImportActionMerge:
+			r.MergeCount++
+		case UserImportActionConflict:
+			r.ConflictCount++
+		case UserImportActionError:
+			r.ErrorCount++
+		}
+	}
+}
+
+// UserImportMappingGuess maps columns by their headers, fields of other type are skipped
+func UserImportMappingGuess(importType string, columns []string) map[string]int {
+	res := map[string]int{}
+	for k, column := range columns {
+		column = strings.ToLower(strings.TrimSpace(column))
+		for _, field := range UserImportFields[importType] {
+			if _, ok := res[field]; ok {
+				continue
+			}
+			if column == field || column == strings.ReplaceAll(field, "_", " ") {
+				res[field] = k
+				break
+			}
+			// parents columns are mapped by hand
+			if strings.HasPrefix(field, "parent") {
+				continue
+			}
+			isFound := false
+			for _, h := range userImportHeaders[field] {
+				if column == h {
+					isFound = true
+				}
+			}
+			if isFound {
+				res[field] = k
+				break
+			}
+		}
+	}
+	return res
+}
@@ -25,6 +25,15 @@ const LogSubjectBaseSubjects LogSubject = "base_subjects"
 const LogSubjectReports LogSubject = "reports"
 const LogSubjectReportItems LogSubject = "report_items"
 const LogSubjectSchoolTransfers LogSubject = "school_transfers"
+const LogSubjectCurriculumPlans LogSubject = "curriculum_plans"
+const LogSubjectNotificationPreferences LogSubject = "notification_preferences"
+const LogSubjectContactItems LogSubject = "contact_items"
+const LogSubjectAssignmentSubmissions LogSubject = "assignment_submissions"
+const LogSubjectRoles LogSubject = "roles"
+const LogSubjectImpersonation LogSubject = "impersonation"
+const LogSubjectUserImports LogSubject = "user_imports"
+const LogSubjectUserMerges LogSubject = "user_merges"
+const LogSubjectUserDataRequests LogSubject = "user_data_requests"
 
 const LogActionCreate LogAction = "create"
 const LogActionUpdate LogAction = "update"
@@ -33,6 +42,20 @@ const LogActionLogout LogAction = "logout"
 const LogActionLogin LogAction = "login"
 const LogActionUpdatePassword LogAction = "update_password"
 const LogActionUpdateProfile LogAction = "update_profile"
+const LogActionVerifyEmail LogAction = "verify_email"
+const LogActionResetPassword LogAction = "reset_password"
+const LogActionImpersonate LogAction = "impersonate"
+const LogActionView LogAction = "view"
+const LogActionTwoFactorEnable LogAction = "two_factor_enable"
+const LogActionTwoFactorDisable LogAction = "two_factor_disable"
+const LogActionTwoFactorReset LogAction = "two_factor_reset"
+const LogActionRollover LogAction = "rollover"
+const LogActionImport LogAction = "import"
+const LogActionRollback LogAction = "rollback"
+const LogActionMerge LogAction = "merge"
+const LogActionUndo LogAction = "undo"
+const LogActionApprove LogAction = "approve"
+const LogActionReject LogAction = "reject"
 
 type UserLog struct {
 	ID                 string      `json:"id"`
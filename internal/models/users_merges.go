@@ -0,0 +1,184 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	UserDuplicatePending   = "pending"
+	UserDuplicateMerged    = "merged"
+	UserDuplicateDismissed = "dismissed"
+)
+
+// reasons of duplicate pair, score is sum of their weights
+const (
+	UserDuplicateReasonName            = "name"
+	UserDuplicateReasonNameSimilar     = "name_similar"
+	UserDuplicateReasonBirthday        = "birthday"
+	UserDuplicateReasonBirthCertNumber = "birth_cert_number"
+	UserDuplicateReasonPassportNumber  = "passport_number"
+	UserDuplicateReasonPhone           = "phone"
+)
+
+var UserDuplicateReasonScores = map[string]int{
+	UserDuplicateReasonName:            40,
+	UserDuplicateReasonNameSimilar:     25,
+	UserDuplicateReasonBirthday:        20,
+	UserDuplicateReasonBirthCertNumber: 50,
+	UserDuplicateReasonPassportNumber:  50,
+	UserDuplicateReasonPhone:           15,
+}
+
+// UserDuplicateMinScore is least score of pair to be put into review queue
+const UserDuplicateMinScore = 55
+
+// UserDuplicate is pair of users of same role which look like same person
+type UserDuplicate struct {
+	ID         string     `json:"id"`
+	SchoolId   string     `json:"school_id"`
+	UserId     string     `json:"user_id"`
+	OtherId    string     `json:"other_id"`
+	RoleCode   string     `json:"role_code"`
+	Score      int        `json:"score"`
+	Reasons    []string   `json:"reasons"`
+	Status     string     `json:"status"`
+	ReviewedBy *string    `json:"reviewed_by"`
+	ReviewedAt *time.Time `json:"reviewed_at"`
+	CreatedAt  *time.Time `json:"created_at"`
+	UpdatedAt  *time.Time `json:"updated_at"`
+	User       *User      `json:"user"`
+	Other      *User      `json:"other"`
+}
+
+func (UserDuplicate) RelationFields() []string {
+	return []string{"User", "Other"}
+}
+
+// UserMerge is merge of duplicate into survivor, Moved are keys of rows per "table.column" which undo moves back
+type UserMerge struct {
+	ID              string              `json:"id"`
+	SchoolId        *string             `json:"school_id"`
+	DuplicatePairId *string             `json:"duplicate_pair_id"`
+	SurvivorId      string              `json:"survivor_id"`
+	DuplicateId     string              `json:"duplicate_id"`
+	DuplicateStatus string              `json:"duplicate_status"`
+	Moved           map[string][]string `json:"moved"`
+	Skipped         map[string][]string `json:"skipped"`
+	CreatedBy       *string             `json:"created_by"`
+	CreatedAt       *time.Time          `json:"created_at"`
+	UndoneBy        *string             `json:"undone_by"`
+	UndoneAt        *time.Time          `json:"undone_at"`
+	Survivor        *User               `json:"survivor"`
+	Duplicate       *User               `json:"duplicate"`
+}
+
+func (UserMerge) RelationFields() []string {
+	return []string{"Survivor", "Duplicate"}
+}
+
+type UserDuplicateFilterRequest struct {
+	ID        *string   `form:"id"`
+	SchoolId  *string   `form:"school_id"`
+	SchoolIds *[]string `form:"school_ids[]"`
+	UserId    *string   `form:"user_id"`
+	RoleCode  *string   `form:"role_code"`
+	Status    *string   `form:"status"`
+	PaginationRequest
+}
+
+type UserMergeFilterRequest struct {
+	SchoolId  *string   `form:"school_id"`
+	SchoolIds *[]string `form:"school_ids[]"`
+	UserId    *string   `form:"user_id"`
+	PaginationRequest
+}
+
+type UserDuplicateScanRequest struct {
+	SchoolId *string `json:"school_id"`
+}
+
+type UserMergeRequest struct {
+	SurvivorId      string  `json:"survivor_id" validate:"required"`
+	DuplicateId     string  `json:"duplicate_id" validate:"required"`
+	DuplicatePairId *string `json:"duplicate_pair_id"`
+}
+
+type UserDuplicateResponse struct {
+	ID         string        `json:"id"`
+	SchoolId   string        `json:"school_id"`
+	RoleCode   string        `json:"role_code"`
+	Score      int           `json:"score"`
+	Reasons    []string      `json:"reasons"`
+	Status     string        `json:"status"`
+	ReviewedBy *string       `json:"reviewed_by"`
+	ReviewedAt *time.Time    `json:"reviewed_at"`
+	CreatedAt  *time.Time    `json:"created_at"`
+	User       *UserResponse `json:"user"`
+	Other      *UserResponse `json:"other"`
+}
+
+func (r *UserDuplicateResponse) FromModel(m *UserDuplicate) {
+	r.ID = m.ID
+	r.SchoolId = m.SchoolId
+	r.RoleCode = m.RoleCode
+	r.Score = m.Score
+	r.Reasons = m.Reasons
+	r.Status = m.Status
+	r.ReviewedBy = m.ReviewedBy
+	r.ReviewedAt = m.ReviewedAt
+	r.CreatedAt = m.CreatedAt
+	if m.User != nil {
+		r.User = &UserResponse{}
+		r.User.FromModel(m.User)
+	}
+	if m.Other != nil {
+		r.Other = &UserResponse{}
+		r.Other.FromModel(m.Other)
+	}
+}
+
+type UserMergeResponse struct {
+	ID              string         `json:"id"`
+	SchoolId        *string        `json:"school_id"`
+	DuplicatePairId *string        `json:"duplicate_pair_id"`
+	SurvivorId      string         `json:"survivor_id"`
+	DuplicateId     string         `json:"duplicate_id"`
+	DuplicateStatus string         `json:"duplicate_status"`
+	MovedCounts     map[string]int `json:"moved_counts"`
+	SkippedCounts   map[string]int `json:"skipped_counts"`
+	CreatedBy       *string        `json:"created_by"`
+	CreatedAt       *time.Time     `json:"created_at"`
+	UndoneBy        *string        `json:"undone_by"`
+	UndoneAt        *time.Time     `json:"undone_at"`
+	Survivor        *UserResponse  `json:"survivor"`
+	Duplicate       *UserResponse  `json:"duplicate"`
+}
+
+func (r *UserMergeResponse) FromModel(m *UserMerge) {
+	r.ID = m.ID
+	r.SchoolId = m.SchoolId
+	r.DuplicatePairId = m.DuplicatePairId
+	r.SurvivorId = m.SurvivorId
+	r.DuplicateId = m.DuplicateId
+	r.DuplicateStatus = m.DuplicateStatus
+	r.MovedCounts = map[string]int{}
+	for k, v := range m.Moved {
+		r.MovedCounts[k] = len(v)
+	}
+	r.SkippedCounts = map[string]int{}
+	for k, v := range m.Skipped {
+		r.SkippedCounts[k] = len(v)
+	}
+	r.CreatedBy = m.CreatedBy
+	r.CreatedAt = m.CreatedAt
+	r.UndoneBy = m.UndoneBy
+	r.UndoneAt = m.UndoneAt
+	if m.Survivor != nil {
+		r.Survivor = &UserResponse{}
+		r.Survivor.FromModel(m.Survivor)
+	}
+	if m.Duplicate != nil {
+		r.Duplicate = &UserResponse{}
+		r.Duplicate.FromModel(m.Duplicate)
+	}
+}
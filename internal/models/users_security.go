@@ -0,0 +1,168 @@
+package models
+
+import (
+	"net"
+	"strings"
+	"time"
+
+	"github.com/mileusna/useragent"
+)
+
+// reasons of failed login attempts
+const (
+	LoginReasonUser     = "user"
+	LoginReasonPassword = "password"
+	LoginReasonOtp      = "otp"
+	LoginReasonTotp     = "totp"
+	LoginReasonLocked   = "locked"
+)
+
+// UserSecurity keeps two factor (totp) and lockout state of user
+type UserSecurity struct {
+	UserId          string     `json:"user_id"`
+	TotpSecret      *string    `json:"-"`
+	TotpEnabledAt   *time.Time `json:"totp_enabled_at"`
+	TotpLastCounter int64      `json:"-"`
+	// bcrypt hashes, used code is removed
+	RecoveryCodes *[]string  `json:"-"`
+	FailedCount   int        `json:"failed_count"`
+	LockedUntil   *time.Time `json:"locked_until"`
+	UpdatedAt     *time.Time `json:"updated_at"`
+}
+
+func (UserSecurity) RelationFields() []string {
+	return []string{}
+}
+
+func (m UserSecurity) IsTotpEnabled() bool {
+	return m.TotpEnabledAt != nil && m.TotpSecret != nil
+}
+
+func (m UserSecurity) IsLocked(now time.Time) bool {
+	return m.LockedUntil != nil && now.Before(*m.LockedUntil)
+}
+
+func (m UserSecurity) RecoveryCodesLeft() int {
+	if m.RecoveryCodes == nil {
+		return 0
+	}
+	return len(*m.RecoveryCodes)
+}
+
+type LoginAttempt struct {
+	ID        string     `json:"id"`
+	UserId    *string    `json:"user_id"`
+	Login     string     `json:"login"`
+	Ip        *string    `json:"ip"`
+	Agent     *string    `json:"agent"`
+	Device    *string    `json:"device"`
+	IsSuccess bool       `json:"is_success"`
+	Reason    *string    `json:"reason"`
+	CreatedAt *time.Time `json:"created_at"`
+}
+
+func (LoginAttempt) RelationFields() []string {
+	return []string{}
+}
+
+type LoginAttemptFilterRequest struct {
+	UserId    *string    `form:"user_id"`
+	Login     *string    `form:"login"`
+	Ip        *string    `form:"ip"`
+	IsSuccess *bool      `form:"is_success"`
+	StartDate *time.Time `form:"start_date"`
+	PaginationRequest
+}
+
+type LoginAttemptResponse struct {
+	ID        string     `json:"id"`
+	UserId    *string    `json:"user_id"`
+	Login     string     `json:"login"`
+	Ip        *string    `json:"ip"`
+	Device    *string    `json:"device"`
+	Os        string     `json:"os"`
+	Browser   string     `json:"browser"`
+	IsSuccess bool       `json:"is_success"`
+	Reason    *string    `json:"reason"`
+	CreatedAt *time.Time `json:"created_at"`
+}
+
+func (r *LoginAttemptResponse) FromModel(m *LoginAttempt) {
+	r.ID = m.ID
+	r.UserId = m.UserId
+	r.Login = m.Login
+	r.Ip = m.Ip
+	r.Device = m.Device
+	r.IsSuccess = m.IsSuccess
+	r.Reason = m.Reason
+	r.CreatedAt = m.CreatedAt
+	if m.Agent != nil {
+		agent := useragent.Parse(*m.Agent)
+		r.Os = agent.OS
+		r.Browser = agent.Name
+	}
+}
+
+// LoginDevice returns device of user agent to compare logins: os, browser and type of device
+func LoginDevice(agent string) string {
+	a := useragent.Parse(agent)
+	device := "Desktop"
+	if a.Mobile {
+		device = "Mobile"
+	} else if a.Tablet {
+		device = "Tablet"
+	} else if a.Bot {
+		device = "Bot"
+	}
+	return strings.TrimSpace(a.OS + " " + a.Name + " " + device)
+}
+
+// LoginLocation returns network of ip (/24 for ipv4, /48 for ipv6) to compare logins
+func LoginLocation(ip string) string {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return ip
+	}
+	if v4 := parsed.To4(); v4 != nil {
+		return v4.Mask(net.CIDRMask(24, 32)).String()
+	}
+	return parsed.Mask(net.CIDRMask(48, 128)).String()
+}
+
+// TwoFactorChallenge is returned by login instead of token when code is needed
+type TwoFactorChallenge struct {
+	IsRequired bool `json:"is_required"`
+	// role of user requires two factor, user enrolls by secret and logs in with code
+	IsSetupRequired bool `json:"is_setup_required"`
+	// secret is given after code sent by sms, login is repeated with otp
+	IsOtpRequired bool    `json:"is_otp_required"`
+	Secret        *string `json:"secret"`
+	OtpauthUrl    *string `json:"otpauth_url"`
+}
+
+// LoginSecurityResponse is result of login checks
+type LoginSecurityResponse struct {
+	TwoFactor *TwoFactorChallenge `json:"two_factor"`
+	// set once when two factor is enabled or codes are regenerated
+	RecoveryCodes     []string `json:"recovery_codes"`
+	RecoveryCodesLeft *int     `json:"recovery_codes_left"`
+	// new device or location of login
+	Alert *string `json:"login_alert"`
+}
+
+type TwoFactorStatusResponse struct {
+	IsEnabled         bool       `json:"is_enabled"`
+	IsEnforced        bool       `json:"is_enforced"`
+	EnabledAt         *time.Time `json:"enabled_at"`
+	RecoveryCodesLeft int        `json:"recovery_codes_left"`
+	LockedUntil       *time.Time `json:"locked_until"`
+}
+
+type TwoFactorCodeRequest struct {
+	Code *string `json:"code" validate:"required"`
+}
+
+type TwoFactorDisableRequest struct {
+	Password *string `json:"password" validate:"required"`
+	Code     *string `json:"code" validate:"required"`
+}
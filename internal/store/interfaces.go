@@ -2,6 +2,7 @@ package store
 
 import (
 	"context"
+	"encoding/json"
 	"time"
 
 	"github.com/mekdep/server/internal/models"
@@ -12,6 +13,12 @@ type IStore interface {
 	ConfirmCodeClear(ctx context.Context, m *models.User) error
 	ConfirmCodeDelete(ctx context.Context, id string) error
 	CheckConfirmCode(ctx context.Context, m *models.User, code string) (string, error)
+	ConfirmCodeFind(ctx context.Context, m *models.User, code string) (string, error)
+
+	EmailTokensCreate(ctx context.Context, m *models.EmailToken) error
+	EmailTokensUse(ctx context.Context, tokenHash string, tokenType string) (*models.EmailToken, error)
+	UsersUpdateEmailVerified(ctx context.Context, userId string, email string) (bool, error)
+	UsersResetEmailVerified(ctx context.Context, userId string) error
 
 	UsersFindByIds(ctx context.Context, ids []string) ([]*models.User, error)
 	UsersFindById(ctx context.Context, id string) (*models.User, error)
@@ -43,6 +50,7 @@ type IStore interface {
 	UpdateUserPayment(ctx context.Context, uid string, expireAt time.Time, classroomId string) (*models.User, error)
 	GetDateUserPayment(ctx context.Context, userUid string, classroomId string) (time.Time, error)
 	UpdateUserPaymentClassroom(ctx context.Context, userUid string, classroomId string) (*models.User, error)
+	UserParentsChange(ctx context.Context, parentId string, schoolId *string, addIds []string, removeIds []string) error
 	UserChangeSchoolAndClassroom(ctx context.Context, studentId, schoolId, classroomId *string) error
 
 	// TODO: replace l *[]*models.Model -> []*models.Model (without pointer) on all list models
@@ -182,6 +190,64 @@ type IStore interface {
 	AssignmentsFindBy(ctx context.Context, f models.AssignmentFilterRequest) ([]models.Assignment, int, error)
 	AssignmentsFindByIds(ctx context.Context, ids []string) ([]models.Assignment, error)
 
+	AssignmentSubmissionsFindBy(ctx context.Context, f models.AssignmentSubmissionFilterRequest) ([]*models.AssignmentSubmission, error)
+	AssignmentSubmissionsFindById(ctx context.Context, id string) (*models.AssignmentSubmission, error)
+	AssignmentSubmissionCreate(ctx context.Context, m *models.AssignmentSubmission) (*models.AssignmentSubmission, error)
+	AssignmentSubmissionUpdate(ctx context.Context, m *models.AssignmentSubmission) (*models.AssignmentSubmission, error)
+	AssignmentSubmissionsLoadRelations(ctx context.Context, l []*models.AssignmentSubmission) error
+
+	RolesFindBy(ctx context.Context, codes []string) ([]*models.RoleDefinition, error)
+	RolesFindByCode(ctx context.Context, code string) (*models.RoleDefinition, error)
+	RoleSave(ctx context.Context, m *models.RoleDefinition) (*models.RoleDefinition, error)
+	RoleDelete(ctx context.Context, code string) error
+	RolesSeed(ctx context.Context, roles []*models.RoleDefinition) error
+	RolePermissionsFindBy(ctx context.Context, roleCodes []string, schoolId *string) ([]*models.RolePermission, error)
+	RolePermissionsOverride(ctx context.Context, roleCode string, schoolId string, l []*models.RolePermission) error
+	UserSchoolsCustomRoleSet(ctx context.Context, userId string, schoolId string, baseRole models.Role, customRoleCode *string) (int64, error)
+
+	UserSecurityFindByUserId(ctx context.Context, userId string) (*models.UserSecurity, error)
+	UserSecuritySave(ctx context.Context, m *models.UserSecurity) error
+	LoginAttemptsCreate(ctx context.Context, m *models.LoginAttempt) error
+	LoginAttemptsFindBy(ctx context.Context, f models.LoginAttemptFilterRequest) ([]*models.LoginAttempt, int, error)
+
+	RolloversCommit(ctx context.Context, m *models.Rollover, period *models.Period, successors map[string]*models.Classroom) error
+	RolloversFindBy(ctx context.Context, f models.RolloverFilterRequest) ([]*models.Rollover, int, error)
+
+	ArchiveExportRows(ctx context.Context, table string, schoolIds []string, periodIds []string, f func(row []byte) error) (int, error)
+	ArchiveImport(ctx context.Context, m *models.ArchiveSnapshot, read func(table string, insert func(row []byte) error) error) error
+	ArchiveSnapshotsFindAll(ctx context.Context) ([]*models.ArchiveSnapshot, error)
+
+	FileOwnersFind(ctx context.Context, path string) ([]*models.FileOwner, error)
+
+	UserImportsCreate(ctx context.Context, m *models.UserImport) (*models.UserImport, error)
+	UserImportsUpdate(ctx context.Context, m *models.UserImport) error
+	UserImportsFindById(ctx context.Context, id string) (*models.UserImport, error)
+	UserImportsFindBy(ctx context.Context, f models.UserImportFilterRequest) ([]*models.UserImport, int, error)
+	UserImportsClaim(ctx context.Context, id string) (bool, error)
+	UserImportsPendingList(ctx context.Context, limit int) ([]string, error)
+	UserImportsCommitRow(ctx context.Context, importId string, schoolId string, role models.Role, user *models.User, classroomId *string, parents []*models.User) error
+	UserImportsRollback(ctx context.Context, importId string) (int, error)
+
+	UserDuplicatesCandidates(ctx context.Context, schoolId string) ([]*models.User, error)
+	UserDuplicatesSave(ctx context.Context, schoolId string, items []*models.UserDuplicate) error
+	UserDuplicatesFindById(ctx context.Context, id string) (*models.UserDuplicate, error)
+	UserDuplicatesFindBy(ctx context.Context, f models.UserDuplicateFilterRequest) ([]*models.UserDuplicate, int, error)
+	UserDuplicatesReview(ctx context.Context, id string, status string, reviewedBy string) error
+	UserMergesFindById(ctx context.Context, id string) (*models.UserMerge, error)
+	UserMergesFindBy(ctx context.Context, f models.UserMergeFilterRequest) ([]*models.UserMerge, int, error)
+	UserMergesCommit(ctx context.Context, m *models.UserMerge) (*models.UserMerge, error)
+	UserMergesUndo(ctx context.Context, m *models.UserMerge, undoneBy string) (*models.UserMerge, error)
+
+	UserDataRequestsCreate(ctx context.Context, m *models.UserDataRequest) (*models.UserDataRequest, error)
+	UserDataRequestsUpdate(ctx context.Context, m *models.UserDataRequest) error
+	UserDataRequestsFindById(ctx context.Context, id string) (*models.UserDataRequest, error)
+	UserDataRequestsFindBy(ctx context.Context, f models.UserDataRequestFilterRequest) ([]*models.UserDataRequest, int, error)
+	UserDataRequestsClaim(ctx context.Context, id string) (bool, error)
+	UserDataRequestsPendingList(ctx context.Context, limit int) ([]string, error)
+	UserDataRequestsExpiredList(ctx context.Context, limit int) ([]*models.UserDataRequest, error)
+	UserDataDump(ctx context.Context, userId string) (map[string]json.RawMessage, error)
+	UserDataErase(ctx context.Context, userId string) ([]string, error)
+
 	GradesFindByIds(ctx context.Context, ids []string) ([]models.Grade, error)
 	GradesFindById(ctx context.Context, id string) (models.Grade, error)
 	GradesFindBy(ctx context.Context, f models.GradeFilterRequest) ([]*models.Grade, int, error)
@@ -211,6 +277,7 @@ type IStore interface {
 
 	SessionsSelect(ctx context.Context, f models.SessionFilter) ([]models.Session, error)
 	SessionsClear(ctx context.Context, now time.Time) error
+	SessionsClearDeviceTokens(ctx context.Context, tokens []string) error
 	SessionsCreate(ctx context.Context, m models.Session) (models.Session, error)
 	SessionsDelete(ctx context.Context, f models.SessionFilter) error
 
@@ -222,12 +289,23 @@ type IStore interface {
 	UserNotificationsSelectTotalUnread(ctx context.Context, userId string, role string) (int, error)
 	UserNotificationsLoadRelations(ctx context.Context, l *[]*models.UserNotification) error
 	UserNotificationsCreateBatch(ctx context.Context, l []models.UserNotification) error
+	UserNotificationsUpdateOpened(ctx context.Context, ids []string) error
 	UserNotificationsLoadRelationUser(l *[]*models.UserNotification) error
+	UserNotificationsLoadPushDeliveries(ctx context.Context, l *[]*models.UserNotification) error
+
+	PushDeliveriesFindBy(ctx context.Context, f models.PushDeliveryFilterRequest) (items []*models.PushDelivery, total int, err error)
+	PushDeliveriesCreateBatch(ctx context.Context, l []*models.PushDelivery) error
+	PushDeliveriesUpdateBatch(ctx context.Context, l []*models.PushDelivery) error
+
+	NotificationPreferencesFindBy(ctx context.Context, f models.NotificationPreferenceFilterRequest) (items []*models.NotificationPreference, err error)
+	NotificationPreferencesReplace(ctx context.Context, userId *string, role *string, l []*models.NotificationPreference) error
 
 	NotificationsFindBy(ctx context.Context, f models.NotificationsFilterRequest) (notifications []*models.Notifications, total int, err error)
 	NotificationFindById(ctx context.Context, ID string) (*models.Notifications, error)
 	NotificationFindByIds(ctx context.Context, Ids []string) ([]*models.Notifications, error)
 	NotificationCreate(ctx context.Context, model *models.Notifications) (*models.Notifications, error)
+	NotificationsClaimScheduled(ctx context.Context, now time.Time) ([]*models.Notifications, error)
+	NotificationsAnalytics(ctx context.Context, ID string) ([]*models.NotificationAnalytics, error)
 	NotificationsLoadRelations(ctx context.Context, l *[]*models.Notifications) error
 	NotificationUpdate(ctx context.Context, model *models.Notifications) (*models.Notifications, error)
 	NotificationDelete(ctx context.Context, ID string) error
@@ -249,6 +327,15 @@ type IStore interface {
 	TopicsDelete(ctx context.Context, items []*models.Topics) ([]*models.Topics, error)
 	TopicsLoadRelations(ctx context.Context, l *[]*models.Topics) error
 
+	CurriculumPlansFindBy(ctx context.Context, f models.CurriculumPlanFilterRequest) (plans []*models.CurriculumPlan, total int, err error)
+	CurriculumPlansFindById(ctx context.Context, id string) (*models.CurriculumPlan, error)
+	CurriculumPlansCreate(ctx context.Context, model *models.CurriculumPlan) (*models.CurriculumPlan, error)
+	CurriculumPlansUpdate(ctx context.Context, model *models.CurriculumPlan) (*models.CurriculumPlan, error)
+	CurriculumPlansDelete(ctx context.Context, items []*models.CurriculumPlan) ([]*models.CurriculumPlan, error)
+	CurriculumPlansLoadRelations(ctx context.Context, l *[]*models.CurriculumPlan) error
+	LessonTopicsFindByLessons(ctx context.Context, lessonIds []string) ([]models.LessonTopic, error)
+	LessonTopicsReplace(ctx context.Context, lessonIds []string, items []models.LessonTopic) error
+
 	BookFindBy(ctx context.Context, f models.BookFilterRequest) (books []*models.Book, total int, err error)
 	BookFindById(ctx context.Context, ID string) (*models.Book, error)
 	BookFindByIds(ctx context.Context, Ids []string) ([]*models.Book, error)
@@ -256,6 +343,11 @@ type IStore interface {
 	BookCreate(ctx context.Context, model *models.Book) (*models.Book, error)
 	BookUpdate(ctx context.Context, model *models.Book) (*models.Book, error)
 	BookDelete(ctx context.Context, items []*models.Book) ([]*models.Book, error)
+	BookPagesFindByBook(ctx context.Context, bookId string) ([]*models.BookPage, error)
+	BookPagesReplace(ctx context.Context, bookId string, pages []models.BookPage) error
+	BookPagesSearch(ctx context.Context, f models.BookPageSearchRequest) ([]models.BookPageSearch, int, error)
+	BookProcessClaim(ctx context.Context, bookId string) (bool, error)
+	BookProcessList(ctx context.Context, limit int) ([]string, error)
 
 	BaseSubjectsFindBy(ctx context.Context, f models.BaseSubjectsFilterRequest) (baseSubjects []*models.BaseSubjects, total int, err error)
 	BaseSubjectsFindById(ctx context.Context, ID string) (*models.BaseSubjects, error)
@@ -269,6 +361,7 @@ type IStore interface {
 	SmsSendersFindById(ctx context.Context, ID string) (*models.SmsSender, error)
 	SmsSendersFindByIds(ctx context.Context, IDs []string) ([]*models.SmsSender, error)
 	SmsSenderCreate(ctx context.Context, model *models.SmsSender) (*models.SmsSender, error)
+	SmsSenderUpdate(ctx context.Context, model *models.SmsSender) (*models.SmsSender, error)
 
 	ContactItemsFindBy(ctx context.Context, f models.ContactItemsFilterRequest) (contactItems []*models.ContactItems, total int, err error)
 	ContactItemsFindById(ctx context.Context, Id string) (*models.ContactItems, error)
@@ -278,6 +371,9 @@ type IStore interface {
 	ContactItemsDelete(ctx context.Context, items []*models.ContactItems) ([]*models.ContactItems, error)
 	ContactItemLoadRelations(ctx context.Context, l *[]*models.ContactItems, isDetail bool) error
 	ContactItemsCountByType(ctx context.Context, f models.ContactItemsFilterRequest) ([]models.ContactItemsCount, error)
+	ContactItemsEscalateDue(ctx context.Context, now time.Time) ([]*models.ContactItems, error)
+	ContactRepliesFindBy(ctx context.Context, contactItemIds []string, withInternal bool) ([]*models.ContactReply, error)
+	ContactReplyCreate(ctx context.Context, m *models.ContactReply) (*models.ContactReply, error)
 
 	MessageGroupsFindById(ctx context.Context, id string) (models.MessageGroup, error)
 	MessageGroupsFindBy(ctx context.Context, f models.GetMessageGroupsRequest) ([]*models.MessageGroup, int, error)
@@ -295,6 +391,7 @@ type IStore interface {
 	ReportsCreate(ctx context.Context, model *models.Reports) (*models.Reports, error)
 	ReportsDelete(ctx context.Context, items []*models.Reports) ([]*models.Reports, error)
 	ReportsUpdate(ctx context.Context, model *models.Reports) (*models.Reports, error)
+	ReportSourceValues(ctx context.Context, schoolId string, classroomId *string, startDate time.Time, endDate time.Time) (models.ReportSourceValues, error)
 
 	ReportItemsFindBy(ctx context.Context, f models.ReportItemsFilterRequest) (reportItems []*models.ReportItems, total int, err error)
 	ReportItemsFindById(ctx context.Context, ID string) (*models.ReportItems, error)
@@ -303,6 +400,7 @@ type IStore interface {
 	ReportItemsCreate(ctx context.Context, model models.ReportItems) (*models.ReportItems, error)
 	ReportItemsLoadRelations(ctx context.Context, l *[]*models.ReportItems) error
 	ReportItemsUpdate(ctx context.Context, model models.ReportItems) (*models.ReportItems, error)
+	ReportItemsCompletionByRegion(ctx context.Context, reportIds []string, regionIds *[]string) ([]*models.ReportRegionCompletion, error)
 
 	SettingsFindById(ctx context.Context, id string) (model *models.Settings, err error)
 	SettingsUpsert(ctx context.Context, data *models.Settings) (model *models.Settings, err error)
@@ -317,6 +415,7 @@ type IStore interface {
 	SchoolTransfersFindById(ctx context.Context, id string) (model *models.SchoolTransfer, err error)
 	SchoolTransfersFindBy(ctx context.Context, opts map[string]interface{}) (list *models.SchoolTransfers, err error)
 	SchoolTransfersUpdate(ctx context.Context, data *models.SchoolTransfer) (model *models.SchoolTransfer, err error)
+	SchoolTransfersExpire(ctx context.Context) (*models.SchoolTransfers, error)
 	SchoolTransfersInsert(ctx context.Context, data *models.SchoolTransfer) (model *models.SchoolTransfer, err error)
 	SchoolTransfersDelete(ctx context.Context, ids []string) (list *models.SchoolTransfers, err error)
 	SchoolTransfersLoadRelations(ctx context.Context, list *models.SchoolTransfers) error
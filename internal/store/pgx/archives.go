@@ -0,0 +1,161 @@
+package pgx
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/mekdep/server/internal/models"
+	"github.com/mekdep/server/internal/utils"
+)
+
+// $1 school uids, $2 period uids of snapshot
+const sqlArchiveClassrooms = `select c.uid from classrooms c where c.school_uid=ANY($1::uuid[]) and (c.period_uid=ANY($2::uuid[]) or c.period_uid is null)`
+const sqlArchiveSubjects = `select sb.uid from subjects sb where sb.classroom_uid in (` + sqlArchiveClassrooms + `)`
+const sqlArchiveLessons = `select l.uid from lessons l where l.subject_uid in (` + sqlArchiveSubjects + `)`
+const sqlArchiveUsers = `select us.user_uid from user_schools us where us.school_uid=ANY($1::uuid[])`
+
+// rows of table as json
+var sqlArchiveExport = map[string]string{
+	"schools": `select to_jsonb(t)::text from schools t where t.uid=ANY($1::uuid[])
+		or t.uid in (select s.parent_uid from schools s where s.uid=ANY($1::uuid[]))`,
+	"users": `select to_jsonb(t)::text from users t where t.uid in (` + sqlArchiveUsers + `)
+		or t.uid in (select up.parent_uid from user_parents up where up.child_uid in (` + sqlArchiveUsers + `))`,
+	"user_schools":    `select to_jsonb(t)::text from user_schools t where t.school_uid=ANY($1::uuid[])`,
+	"user_parents":    `select to_jsonb(t)::text from user_parents t where t.child_uid in (` + sqlArchiveUsers + `)`,
+	"base_subjects":   `select to_jsonb(t)::text from base_subjects t where t.school_uid is null or t.school_uid=ANY($1::uuid[])`,
+	"periods":         `select to_jsonb(t)::text from periods t where t.uid=ANY($2::uuid[]) and t.school_uid=ANY($1::uuid[])`,
+	"shifts":          `select to_jsonb(t)::text from shifts t where t.school_uid=ANY($1::uuid[])`,
+	"classrooms":      `select to_jsonb(t)::text from classrooms t where t.uid in (` + sqlArchiveClassrooms + `)`,
+	"user_classrooms": `select to_jsonb(t)::text from user_classrooms t where t.classroom_uid in (` + sqlArchiveClassrooms + `)`,
+	"subjects":        `select to_jsonb(t)::text from subjects t where t.uid in (` + sqlArchiveSubjects + `)`,
+	"subject_exams":   `select to_jsonb(t)::text from subject_exams t where t.subject_uid in (` + sqlArchiveSubjects + `)`,
+	"timetables":      `select to_jsonb(t)::text from timetables t where t.classroom_uid in (` + sqlArchiveClassrooms + `)`,
+	"lessons":         `select to_jsonb(t)::text from lessons t where t.uid in (` + sqlArchiveLessons + `)`,
+	"grades":          `select to_jsonb(t)::text from grades t where t.lesson_uid in (` + sqlArchiveLessons + `)`,
+	"absents":         `select to_jsonb(t)::text from absents t where t.lesson_uid in (` + sqlArchiveLessons + `)`,
+	"period_grades":   `select to_jsonb(t)::text from period_grades t where t.period_uid=ANY($2::uuid[]) and t.subject_uid in (` + sqlArchiveSubjects + `)`,
+}
+
+const sqlArchiveSnapshotFields = `asn.uid, asn.title, asn.period_uids, asn.school_uids, asn.tables, asn.api_version, asn.exported_at, asn.loaded_at`
+const sqlArchiveSnapshotSelect = `select ` + sqlArchiveSnapshotFields + ` from archive_snapshots asn order by asn.loaded_at desc`
+const sqlArchiveSnapshotInsert = `insert into archive_snapshots (title, period_uids, school_uids, tables, api_version, exported_at)
+	values ($1, $2, $3, $4, $5, $6) returning uid, loaded_at`
+
+func scanArchiveSnapshot(rows pgx.Row, m *models.ArchiveSnapshot, addColumns ...interface{}) (err error) {
+	err = rows.Scan(parseColumnsForScan(m, addColumns...)...)
+	return
+}
+
+// ArchiveExportRows streams rows of table in snapshot of schools and periods, returns count of rows
+func (d *PgxStore) ArchiveExportRows(ctx context.Context, table string, schoolIds []string, periodIds []string, f func(row []byte) error) (int, error) {
+	qs, ok := sqlArchiveExport[table]
+	if !ok {
+		return 0, errors.New("table is not archived: " + table)
+	}
+	count := 0
+	err := d.runQuery(ctx, func(tx *pgxpool.Conn) (err error) {
+		rows, err := tx.Query(ctx, qs, schoolIds, periodIds)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var row []byte
+			err = rows.Scan(&row)
+			if err != nil {
+				return err
+			}
+			err = f(row)
+			if err != nil {
+				return err
+			}
+			count++
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		utils.LoggerDesc("Query error").Error(err)
+		return 0, err
+	}
+	return count, nil
+}
+
+// ArchiveImport inserts rows of snapshot tables in one transaction, rows which exist are kept.
+// Triggers (and so foreign keys) are off while loading as snapshot may miss referenced rows of other years,
+// it needs owner rights on database.
+func (d *PgxStore) ArchiveImport(ctx context.Context, m *models.ArchiveSnapshot, read func(table string, insert func(row []byte) error) error) error {
+	err := d.runInTx(ctx, func(tx pgx.Tx) (rollback bool, err error) {
+		_, err = tx.Exec(ctx, "SET LOCAL session_replication_role = replica")
+		if err != nil {
+			return true, err
+		}
+		for _, table := range models.ArchiveTables {
+			// query by columns of row, missing columns get their defaults
+			queries := map[string]string{}
+			err = read(table, func(row []byte) error {
+				values := map[string]json.RawMessage{}
+				if err := json.Unmarshal(row, &values); err != nil {
+					return err
+				}
+				cols := []string{}
+				for k := range values {
+					cols = append(cols, pgx.Identifier{k}.Sanitize())
+				}
+				sort.Strings(cols)
+				key := strings.Join(cols, ", ")
+				qs, ok := queries[key]
+				if !ok {
+					qs = "insert into " + pgx.Identifier{table}.Sanitize() + " (" + key + ") select " + key +
+						" from jsonb_populate_record(null::" + pgx.Identifier{table}.Sanitize() + ", $1::jsonb) on conflict do nothing"
+					queries[key] = qs
+				}
+				_, err := tx.Exec(ctx, qs, string(row))
+				return err
+			})
+			if err != nil {
+				return true, errors.New(table + ": " + err.Error())
+			}
+		}
+		err = tx.QueryRow(ctx, sqlArchiveSnapshotInsert, m.Title, m.PeriodIds, m.SchoolIds, m.Tables, m.ApiVersion, m.ExportedAt).Scan(&m.ID, &m.LoadedAt)
+		if err != nil {
+			return true, err
+		}
+		return false, nil
+	})
+	if err != nil {
+		utils.LoggerDesc("Query error").Error(err)
+		return err
+	}
+	return nil
+}
+
+func (d *PgxStore) ArchiveSnapshotsFindAll(ctx context.Context) ([]*models.ArchiveSnapshot, error) {
+	l := []*models.ArchiveSnapshot{}
+	err := d.runQuery(ctx, func(tx *pgxpool.Conn) (err error) {
+		rows, err := tx.Query(ctx, sqlArchiveSnapshotSelect)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			m := models.ArchiveSnapshot{}
+			err = scanArchiveSnapshot(rows, &m)
+			if err != nil {
+				utils.LoggerDesc("Scan error").Error(err)
+				return err
+			}
+			l = append(l, &m)
+		}
+		return
+	})
+	if err != nil {
+		utils.LoggerDesc("Query error").Error(err)
+		return nil, err
+	}
+	return l, nil
+}
@@ -0,0 +1,167 @@
+package pgx
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/mekdep/server/internal/models"
+	"github.com/mekdep/server/internal/utils"
+)
+
+// content is not needed in page list, it is only used by search index
+const sqlBookPageSelect = `select bp.uid, bp.book_uid, bp.page, bp.thumbnail, null::text, bp.created_at from book_pages bp where bp.book_uid=$1 order by bp.page`
+
+const sqlBookPageDelete = `delete from book_pages where book_uid=$1`
+
+const sqlBookPageInsert = `insert into book_pages (book_uid, page, thumbnail, content) values ($1, $2, $3, $4)`
+
+const sqlBookPageSearch = `select bp.book_uid, bp.page,
+	ts_headline('simple', bp.content, q, 'MaxFragments=1, MaxWords=25, MinWords=10'),
+	count(*) over() as total
+	from book_pages bp, websearch_to_tsquery('simple', $3) q
+	where bp.content_tsv @@ q`
+
+// books are claimed by update so several api instances don't process same book,
+// processing older than hour means worker died (restart)
+const sqlBookProcessClaim = `update books set process_status='processing', updated_at=now() where uid=$1 and
+	(process_status='pending' or (process_status='processing' and updated_at < now() - interval '1 hour'))`
+
+const sqlBookProcessList = `select uid from books where process_status='pending' or
+	(process_status='processing' and updated_at < now() - interval '1 hour') order by updated_at limit $1`
+
+func scanBookPage(rows pgx.Rows, m *models.BookPage, addColumns ...interface{}) (err error) {
+	err = rows.Scan(parseColumnsForScan(m, addColumns...)...)
+	return
+}
+
+func (d *PgxStore) BookPagesFindByBook(ctx context.Context, bookId string) ([]*models.BookPage, error) {
+	pages := []*models.BookPage{}
+	err := d.runQuery(ctx, func(tx *pgxpool.Conn) (err error) {
+		rows, err := tx.Query(ctx, sqlBookPageSelect, bookId)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			t := models.BookPage{}
+			err = scanBookPage(rows, &t)
+			if err != nil {
+				utils.LoggerDesc("Scan error").Error(err)
+				return err
+			}
+			pages = append(pages, &t)
+		}
+		return
+	})
+	if err != nil {
+		utils.LoggerDesc("Query error").Error(err)
+		return nil, err
+	}
+	return pages, nil
+}
+
+// BookPagesReplace removes old pages of book and inserts new in one transaction
+func (d *PgxStore) BookPagesReplace(ctx context.Context, bookId string, pages []models.BookPage) error {
+	err := d.runInTx(ctx, func(tx pgx.Tx) (rollback bool, err error) {
+		_, err = tx.Exec(ctx, sqlBookPageDelete, bookId)
+		if err != nil {
+			return true, err
+		}
+		sqls := pgx.Batch{}
+		for _, p := range pages {
+			sqls.Queue(sqlBookPageInsert, bookId, p.Page, p.Thumbnail, p.Content)
+		}
+		br := tx.SendBatch(ctx, &sqls)
+		for range pages {
+			_, err = br.Exec()
+			if err != nil {
+				br.Close()
+				return true, err
+			}
+		}
+		return false, br.Close()
+	})
+	if err != nil {
+		utils.LoggerDesc("Query error").Error(err)
+	}
+	return err
+}
+
+func (d *PgxStore) BookPagesSearch(ctx context.Context, f models.BookPageSearchRequest) ([]models.BookPageSearch, int, error) {
+	res := []models.BookPageSearch{}
+	total := 0
+	args := []interface{}{f.Limit, f.Offset, f.Search}
+	qs := sqlBookPageSearch
+	if f.BookIds != nil {
+		args = append(args, *f.BookIds)
+		qs += " and bp.book_uid = ANY($" + strconv.Itoa(len(args)) + "::uuid[])"
+	}
+	if f.Categories != nil {
+		args = append(args, *f.Categories)
+		qs += " and bp.book_uid in (select b.uid from books b where b.categories::varchar[] && $" + strconv.Itoa(len(args)) + "::varchar[])"
+	}
+	qs += " order by ts_rank(bp.content_tsv, q) desc, bp.book_uid, bp.page limit $1 offset $2"
+
+	err := d.runQuery(ctx, func(tx *pgxpool.Conn) (err error) {
+		rows, err := tx.Query(ctx, qs, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			t := models.BookPageSearch{}
+			err = rows.Scan(&t.BookId, &t.Page, &t.Headline, &total)
+			if err != nil {
+				utils.LoggerDesc("Scan error").Error(err)
+				return err
+			}
+			res = append(res, t)
+		}
+		return
+	})
+	if err != nil {
+		utils.LoggerDesc("Query error").Error(err)
+		return nil, 0, err
+	}
+	return res, total, nil
+}
+
+func (d *PgxStore) BookProcessClaim(ctx context.Context, bookId string) (bool, error) {
+	claimed := false
+	err := d.runQuery(ctx, func(tx *pgxpool.Conn) (err error) {
+		tag, err := tx.Exec(ctx, sqlBookProcessClaim, bookId)
+		claimed = tag.RowsAffected() > 0
+		return
+	})
+	if err != nil {
+		utils.LoggerDesc("Query error").Error(err)
+		return false, err
+	}
+	return claimed, nil
+}
+
+func (d *PgxStore) BookProcessList(ctx context.Context, limit int) ([]string, error) {
+	ids := []string{}
+	err := d.runQuery(ctx, func(tx *pgxpool.Conn) (err error) {
+		rows, err := tx.Query(ctx, sqlBookProcessList, limit)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var id string
+			if err = rows.Scan(&id); err != nil {
+				return err
+			}
+			ids = append(ids, id)
+		}
+		return
+	})
+	if err != nil {
+		utils.LoggerDesc("Query error").Error(err)
+		return nil, err
+	}
+	return ids, nil
+}
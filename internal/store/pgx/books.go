@@ -13,7 +13,7 @@ import (
 	"github.com/mekdep/server/internal/utils"
 )
 
-const sqlBookFields = `b.uid, b.title, b.categories, b.description, b.year, b.pages, b.authors, b.file, b.file_size, b.file_preview, b.is_downloadable, b.created_at, b.updated_at`
+const sqlBookFields = `b.uid, b.title, b.categories, b.description, b.year, b.pages, b.authors, b.file, b.file_size, b.file_preview, b.is_downloadable, b.process_status, b.created_at, b.updated_at`
 
 const sqlBookInsert = `insert into books`
 
@@ -218,6 +218,9 @@ func BookAtomicQuery(m *models.Book, isCreate bool) map[string]interface{} {
 		q["file_preview"] = *m.FilePreview
 	}
 	q["is_downloadable"] = m.IsDownloadable
+	if m.ProcessStatus != nil {
+		q["process_status"] = *m.ProcessStatus
+	}
 	if isCreate {
 		q["created_at"] = time.Now()
 	}
@@ -14,8 +14,8 @@ import (
 )
 
 // FIELDS
-const sqlContactItemFieldsMany = `ct.uid, ct.user_uid, ct.school_uid, ct.message, ct.type, ct.status, ct.files, ct.note, ct.classroom_name, ct.parent_phone, ct.birth_cert_number, count(distinct ctt.uid), ct.related_uid, ct.created_at, ct.updated_at, ct.updated_by`
-const sqlContactItemFields = `ct.uid, ct.user_uid, ct.school_uid, ct.message, ct.type, ct.status, ct.files, ct.note, ct.classroom_name, ct.parent_phone, ct.birth_cert_number, 0, ct.related_uid, ct.created_at, ct.updated_at, ct.updated_by`
+const sqlContactItemFieldsMany = `ct.uid, ct.user_uid, ct.school_uid, ct.message, ct.type, ct.status, ct.files, ct.note, ct.classroom_name, ct.parent_phone, ct.birth_cert_number, count(distinct ctt.uid), ct.related_uid, ct.created_at, ct.updated_at, ct.updated_by, ct.assignee_uid, ct.assigned_at, ct.first_response_at, ct.resolved_at, ct.due_at, ct.escalated_at, ct.payload`
+const sqlContactItemFields = `ct.uid, ct.user_uid, ct.school_uid, ct.message, ct.type, ct.status, ct.files, ct.note, ct.classroom_name, ct.parent_phone, ct.birth_cert_number, 0, ct.related_uid, ct.created_at, ct.updated_at, ct.updated_by, ct.assignee_uid, ct.assigned_at, ct.first_response_at, ct.resolved_at, ct.due_at, ct.escalated_at, ct.payload`
 
 // CRUD
 const sqlContactItemSelect = `SELECT ` + sqlContactItemFieldsMany + ` FROM contact_items ct 
@@ -35,6 +35,9 @@ right join users u on (u.uid=ct.user_uid) where ct.uid = ANY($1::uuid[])`
 const sqlContactItemUpdatedBy = `select ` + sqlUserFields + `, ct.uid from contact_items ct
 	right join users u on (u.uid=ct.updated_by) where ct.uid = ANY($1::uuid[])`
 
+const sqlContactItemAssignee = `select ` + sqlUserFields + `, ct.uid from contact_items ct
+	right join users u on (u.uid=ct.assignee_uid) where ct.uid = ANY($1::uuid[])`
+
 const sqlContactItemSchool = `select ` + sqlSchoolFields + `, ct.uid from contact_items ct
 	right join schools s on (s.uid=ct.school_uid) where ct.uid = ANY($1::uuid[])`
 
@@ -51,7 +54,10 @@ const sqlContactItemsCountByType = `SELECT
 	SUM(CASE WHEN ct.type = 'review' THEN 1 ELSE 0 END) AS review_count,
 	SUM(CASE WHEN ct.type = 'suggestion' THEN 1 ELSE 0 END) AS suggestion_count,
 	SUM(CASE WHEN ct.type = 'complaint' THEN 1 ELSE 0 END) AS complaint_count,
-	SUM(CASE WHEN ct.type = 'data_complaint' THEN 1 ELSE 0 END) AS data_complaint_count
+	SUM(CASE WHEN ct.type = 'data_complaint' THEN 1 ELSE 0 END) AS data_complaint_count,
+	COALESCE(AVG(EXTRACT(EPOCH FROM ct.first_response_at - ct.created_at)) / 3600, 0) AS response_hours,
+	COALESCE(AVG(EXTRACT(EPOCH FROM ct.resolved_at - ct.created_at)) / 3600, 0) AS resolution_hours,
+	SUM(CASE WHEN ct.resolved_at IS NULL AND ct.due_at < NOW() THEN 1 ELSE 0 END) AS overdue_count
 FROM contact_items ct JOIN schools s ON ct.school_uid = s.uid GROUP BY s.code`
 
 func scanContactItem(rows pgx.Row, m *models.ContactItems, addColumns ...interface{}) (err error) {
@@ -67,7 +73,8 @@ func (d *PgxStore) ContactItemsCountByType(ctx context.Context, f models.Contact
 		rows, err := tx.Query(ctx, qs, args...)
 		for rows.Next() {
 			item := models.ContactItemsCount{}
-			err = rows.Scan(&item.SchoolCode, &item.TotalCount, &item.ReviewCount, &item.ComplaintCount, &item.SuggestionCount, &item.DataComplaintCount)
+			err = rows.Scan(&item.SchoolCode, &item.TotalCount, &item.ReviewCount, &item.ComplaintCount, &item.SuggestionCount, &item.DataComplaintCount,
+				&item.ResponseHours, &item.ResolutionHours, &item.OverdueCount)
 			if err != nil {
 				return err
 			}
@@ -263,6 +270,16 @@ func ContactItemAtomicQuery(m *models.ContactItems, isCreate bool) map[string]in
 	if m.UpdatedBy != nil {
 		q["updated_by"] = m.UpdatedBy
 	}
+	if m.Payload != nil {
+		q["payload"] = m.Payload
+	}
+	// helpdesk fields are cleared on reopen and unassign
+	q["assignee_uid"] = m.AssigneeId
+	q["assigned_at"] = m.AssignedAt
+	q["first_response_at"] = m.FirstResponseAt
+	q["resolved_at"] = m.ResolvedAt
+	q["due_at"] = m.DueAt
+	q["escalated_at"] = m.EscalatedAt
 	if isCreate {
 		q["created_at"] = time.Now()
 	}
@@ -315,6 +332,17 @@ func ContactItemsListBuildQuery(f models.ContactItemsFilterRequest, args []inter
 		args = append(args, *f.Type)
 		wheres += " and ct.type=$" + strconv.Itoa(len(args))
 	}
+	if f.AssigneeId != nil && *f.AssigneeId != "" {
+		args = append(args, *f.AssigneeId)
+		wheres += " and ct.assignee_uid=$" + strconv.Itoa(len(args))
+	}
+	if f.IsOverdue != nil {
+		if *f.IsOverdue {
+			wheres += " and ct.resolved_at is null and ct.due_at < now()"
+		} else {
+			wheres += " and not (ct.resolved_at is null and ct.due_at < now())"
+		}
+	}
 	if f.Search != nil && *f.Search != "" {
 		*f.Search = strings.ToLower(*f.Search)
 		args = append(args, *f.Search)
@@ -375,6 +403,10 @@ func (d *PgxStore) ContactItemLoadRelations(ctx context.Context, l *[]*models.Co
 	if err != nil {
 		return err
 	}
+	err = d.ContactItemLoadAssignee(ctx, l)
+	if err != nil {
+		return err
+	}
 	if isDetail {
 		if err := d.ContactItemLoadRelated(ctx, l); err != nil {
 			return err
@@ -594,3 +626,73 @@ func (d *PgxStore) ContactItemLoadUpdatedBy(ctx context.Context, l *[]*models.Co
 	}
 	return nil
 }
+
+func (d *PgxStore) ContactItemLoadAssignee(ctx context.Context, l *[]*models.ContactItems) error {
+	ids := []string{}
+	for _, m := range *l {
+		if m.AssigneeId != nil {
+			ids = append(ids, m.ID)
+		}
+	}
+	if len(ids) < 1 {
+		return nil
+	}
+	err := d.runQuery(ctx, func(tx *pgxpool.Conn) (err error) {
+		rows, err := tx.Query(ctx, sqlContactItemAssignee, ids)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			sub := models.User{}
+			pid := ""
+			err = scanUser(rows, &sub, &pid)
+			if err != nil {
+				utils.LoggerDesc("Scan error").Error(err)
+				return err
+			}
+			for _, m := range *l {
+				if m.ID == pid {
+					m.Assignee = &sub
+				}
+			}
+		}
+		return
+	})
+	if err != nil {
+		utils.LoggerDesc("Query error").Error(err)
+		return err
+	}
+	return nil
+}
+
+// ContactItemsEscalateDue marks unresolved items which passed due time as escalated and returns them,
+// every item is escalated once
+func (d *PgxStore) ContactItemsEscalateDue(ctx context.Context, now time.Time) ([]*models.ContactItems, error) {
+	ids := []string{}
+	err := d.runQuery(ctx, func(tx *pgxpool.Conn) (err error) {
+		rows, err := tx.Query(ctx, `update contact_items set escalated_at=$1
+			where due_at < $1 and resolved_at is null and escalated_at is null returning uid`, now)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			id := ""
+			err = rows.Scan(&id)
+			if err != nil {
+				return err
+			}
+			ids = append(ids, id)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		utils.LoggerDesc("Query error").Error(err)
+		return nil, err
+	}
+	if len(ids) < 1 {
+		return []*models.ContactItems{}, nil
+	}
+	return d.ContactItemsFindByIds(ctx, ids)
+}
@@ -0,0 +1,91 @@
+package pgx
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/mekdep/server/internal/models"
+	"github.com/mekdep/server/internal/utils"
+)
+
+const sqlContactReplyFields = `cr.uid, cr.contact_item_uid, cr.user_uid, cr.message, cr.status, cr.files, cr.is_internal, cr.created_at`
+
+const sqlContactReplyInsert = `insert into contact_replies`
+
+const sqlContactReplySelectMany = `select ` + sqlContactReplyFields + ` from contact_replies cr
+	where cr.contact_item_uid = ANY($1::uuid[]) and ($2 or not cr.is_internal) order by cr.created_at asc`
+
+func scanContactReply(rows pgx.Rows, m *models.ContactReply, addColumns ...interface{}) (err error) {
+	err = rows.Scan(parseColumnsForScan(m, addColumns...)...)
+	return
+}
+
+// ContactRepliesFindBy returns threads of contact items, internal notes only when withInternal
+func (d *PgxStore) ContactRepliesFindBy(ctx context.Context, contactItemIds []string, withInternal bool) ([]*models.ContactReply, error) {
+	items := []*models.ContactReply{}
+	err := d.runQuery(ctx, func(tx *pgxpool.Conn) (err error) {
+		rows, err := tx.Query(ctx, sqlContactReplySelectMany, contactItemIds, withInternal)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			item := models.ContactReply{}
+			err = scanContactReply(rows, &item)
+			if err != nil {
+				utils.LoggerDesc("Scan error").Error(err)
+				return err
+			}
+			items = append(items, &item)
+		}
+		return
+	})
+	if err != nil {
+		utils.LoggerDesc("Query error").Error(err)
+		return nil, err
+	}
+	return items, nil
+}
+
+func (d *PgxStore) ContactReplyCreate(ctx context.Context, m *models.ContactReply) (*models.ContactReply, error) {
+	qs, args := ContactReplyCreateQuery(m)
+	err := d.runQuery(ctx, func(tx *pgxpool.Conn) (err error) {
+		err = tx.QueryRow(ctx, qs+" RETURNING uid, created_at", args...).Scan(&m.ID, &m.CreatedAt)
+		return
+	})
+	if err != nil {
+		utils.LoggerDesc("Query error").Error(err)
+		return nil, err
+	}
+	return m, nil
+}
+
+func ContactReplyCreateQuery(m *models.ContactReply) (string, []interface{}) {
+	args := []interface{}{}
+	cols := ""
+	vals := ""
+	q := ContactReplyAtomicQuery(m)
+	for k, v := range q {
+		args = append(args, v)
+		cols += ", " + k
+		vals += ", $" + strconv.Itoa(len(args))
+	}
+	qs := sqlContactReplyInsert + " (" + strings.Trim(cols, ", ") + ") VALUES (" + strings.Trim(vals, ", ") + ")"
+	return qs, args
+}
+
+func ContactReplyAtomicQuery(m *models.ContactReply) map[string]interface{} {
+	q := map[string]interface{}{}
+	q["contact_item_uid"] = m.ContactItemId
+	q["user_uid"] = m.UserId
+	q["message"] = m.Message
+	q["status"] = m.Status
+	q["files"] = m.Files
+	q["is_internal"] = m.IsInternal
+	q["created_at"] = time.Now()
+	return q
+}
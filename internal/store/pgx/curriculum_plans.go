@@ -0,0 +1,307 @@
+package pgx
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/mekdep/server/internal/models"
+	"github.com/mekdep/server/internal/utils"
+)
+
+const sqlCurriculumPlanFields = `cp.uid, cp.base_subject_uid, cp.classyear, cp.topic_uids::text[], cp.updated_at, cp.created_at`
+
+const sqlCurriculumPlanInsert = `insert into curriculum_plans`
+
+const sqlCurriculumPlanUpdate = `update curriculum_plans set uid=uid`
+
+const sqlCurriculumPlanDelete = `delete from curriculum_plans where uid = ANY($1::uuid[])`
+
+const sqlCurriculumPlanSelect = `select ` + sqlCurriculumPlanFields + ` from curriculum_plans cp where cp.uid = ANY($1::uuid[])`
+
+const sqlCurriculumPlanSelectMany = `select ` + sqlCurriculumPlanFields + `, count(*) over() as total from curriculum_plans cp
+	left join base_subjects bs on (bs.uid=cp.base_subject_uid) where cp.uid=cp.uid limit $1 offset $2 `
+
+const sqlLessonTopicSelect = `select lt.lesson_uid, lt.topic_uid, lt.title from lesson_topics lt where lt.lesson_uid = ANY($1::uuid[])`
+
+const sqlLessonTopicDelete = `delete from lesson_topics where lesson_uid = ANY($1::uuid[])`
+
+const sqlLessonTopicInsert = `insert into lesson_topics (lesson_uid, topic_uid, title) values ($1, $2, $3)`
+
+func scanCurriculumPlan(rows pgx.Rows, m *models.CurriculumPlan, addColumns ...interface{}) (err error) {
+	err = rows.Scan(parseColumnsForScan(m, addColumns...)...)
+	return
+}
+
+func (d *PgxStore) CurriculumPlansFindBy(ctx context.Context, f models.CurriculumPlanFilterRequest) (plans []*models.CurriculumPlan, total int, err error) {
+	args := []interface{}{f.Limit, f.Offset}
+	qs, args := CurriculumPlansListBuildQuery(f, args)
+	err = d.runQuery(ctx, func(tx *pgxpool.Conn) (err error) {
+		rows, err := tx.Query(ctx, qs, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			t := models.CurriculumPlan{}
+			err = scanCurriculumPlan(rows, &t, &total)
+			if err != nil {
+				utils.LoggerDesc("Scan error").Error(err)
+				return err
+			}
+			plans = append(plans, &t)
+		}
+		return
+	})
+	if err != nil {
+		utils.LoggerDesc("Query error").Error(err)
+		return nil, 0, err
+	}
+	return plans, total, nil
+}
+
+func (d *PgxStore) CurriculumPlansFindById(ctx context.Context, id string) (*models.CurriculumPlan, error) {
+	plans := []*models.CurriculumPlan{}
+	err := d.runQuery(ctx, func(tx *pgxpool.Conn) (err error) {
+		rows, err := tx.Query(ctx, sqlCurriculumPlanSelect, []string{id})
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			t := models.CurriculumPlan{}
+			err = scanCurriculumPlan(rows, &t)
+			if err != nil {
+				utils.LoggerDesc("Scan error").Error(err)
+				return err
+			}
+			plans = append(plans, &t)
+		}
+		return
+	})
+	if err != nil {
+		utils.LoggerDesc("Query error").Error(err)
+		return nil, err
+	}
+	if len(plans) < 1 {
+		return nil, errors.New("curriculum plan not found by id: " + id)
+	}
+	return plans[0], nil
+}
+
+func (d *PgxStore) CurriculumPlansCreate(ctx context.Context, model *models.CurriculumPlan) (*models.CurriculumPlan, error) {
+	qs, args := CurriculumPlanCreateQuery(model)
+	qs += " RETURNING uid"
+	err := d.runQuery(ctx, func(tx *pgxpool.Conn) (err error) {
+		err = tx.QueryRow(ctx, qs, args...).Scan(&model.ID)
+		return
+	})
+	if err != nil {
+		utils.LoggerDesc("Query error").Error(err)
+		return nil, err
+	}
+	return d.CurriculumPlansFindById(ctx, model.ID)
+}
+
+func (d *PgxStore) CurriculumPlansUpdate(ctx context.Context, model *models.CurriculumPlan) (*models.CurriculumPlan, error) {
+	qs, args := CurriculumPlanUpdateQuery(model)
+	err := d.runQuery(ctx, func(tx *pgxpool.Conn) (err error) {
+		_, err = tx.Exec(ctx, qs, args...)
+		return
+	})
+	if err != nil {
+		utils.LoggerDesc("Query error").Error(err)
+		return nil, err
+	}
+	return d.CurriculumPlansFindById(ctx, model.ID)
+}
+
+func (d *PgxStore) CurriculumPlansDelete(ctx context.Context, items []*models.CurriculumPlan) ([]*models.CurriculumPlan, error) {
+	ids := []string{}
+	for _, i := range items {
+		ids = append(ids, i.ID)
+	}
+	err := d.runQuery(ctx, func(tx *pgxpool.Conn) (err error) {
+		_, err = tx.Exec(ctx, sqlCurriculumPlanDelete, ids)
+		return
+	})
+	if err != nil {
+		utils.LoggerDesc("Query error").Error(err)
+		return nil, err
+	}
+	return items, nil
+}
+
+func CurriculumPlanCreateQuery(m *models.CurriculumPlan) (string, []interface{}) {
+	args := []interface{}{}
+	cols := ""
+	vals := ""
+	q := CurriculumPlanAtomicQuery(m, true)
+	for k, v := range q {
+		args = append(args, v)
+		cols += ", " + k
+		vals += ", $" + strconv.Itoa(len(args))
+	}
+	qs := sqlCurriculumPlanInsert + " (" + strings.Trim(cols, ", ") + ") VALUES (" + strings.Trim(vals, ", ") + ")"
+	return qs, args
+}
+
+func CurriculumPlanUpdateQuery(m *models.CurriculumPlan) (string, []interface{}) {
+	args := []interface{}{}
+	sets := ""
+	q := CurriculumPlanAtomicQuery(m, false)
+	for k, v := range q {
+		args = append(args, v)
+		sets += ", " + k + "=$" + strconv.Itoa(len(args))
+	}
+	args = append(args, m.ID)
+	qs := strings.ReplaceAll(sqlCurriculumPlanUpdate, "set uid=uid", "set uid=uid"+sets+" ") + " where uid=$" + strconv.Itoa(len(args))
+	return qs, args
+}
+
+func CurriculumPlanAtomicQuery(m *models.CurriculumPlan, isCreate bool) map[string]interface{} {
+	q := map[string]interface{}{}
+	if m.BaseSubjectId != "" {
+		q["base_subject_uid"] = m.BaseSubjectId
+	}
+	if m.Classyear != "" {
+		q["classyear"] = m.Classyear
+	}
+	if m.TopicIds != nil {
+		q["topic_uids"] = m.TopicIds
+	}
+	if isCreate {
+		q["created_at"] = time.Now()
+	}
+	q["updated_at"] = time.Now()
+	return q
+}
+
+func CurriculumPlansListBuildQuery(f models.CurriculumPlanFilterRequest, args []interface{}) (string, []interface{}) {
+	wheres := ""
+	if f.ID != nil && *f.ID != "" {
+		args = append(args, *f.ID)
+		wheres += " and cp.uid=$" + strconv.Itoa(len(args))
+	}
+	if f.IDs != nil {
+		args = append(args, *f.IDs)
+		wheres += " and cp.uid = ANY($" + strconv.Itoa(len(args)) + "::uuid[])"
+	}
+	if f.BaseSubjectId != nil && *f.BaseSubjectId != "" {
+		args = append(args, *f.BaseSubjectId)
+		wheres += " and cp.base_subject_uid=$" + strconv.Itoa(len(args))
+	}
+	if f.BaseSubjectIds != nil {
+		args = append(args, *f.BaseSubjectIds)
+		wheres += " and cp.base_subject_uid = ANY($" + strconv.Itoa(len(args)) + "::uuid[])"
+	}
+	if f.SchoolId != nil && *f.SchoolId != "" {
+		args = append(args, *f.SchoolId)
+		wheres += " and (bs.school_uid=$" + strconv.Itoa(len(args)) + " or bs.school_uid is null)"
+	}
+	if f.Classyear != nil && *f.Classyear != "" {
+		args = append(args, *f.Classyear)
+		wheres += " and cp.classyear=$" + strconv.Itoa(len(args))
+	}
+	wheres += " order by bs.name, cp.classyear"
+	qs := strings.ReplaceAll(sqlCurriculumPlanSelectMany, "cp.uid=cp.uid", "cp.uid=cp.uid "+wheres+" ")
+	return qs, args
+}
+
+func (d *PgxStore) CurriculumPlansLoadRelations(ctx context.Context, l *[]*models.CurriculumPlan) error {
+	baseSubjectIds := []string{}
+	topicIds := []string{}
+	for _, m := range *l {
+		baseSubjectIds = append(baseSubjectIds, m.BaseSubjectId)
+		topicIds = append(topicIds, m.TopicIds...)
+	}
+	if len(baseSubjectIds) < 1 {
+		return nil
+	}
+	baseSubjects, err := d.BaseSubjectsFindByIds(ctx, baseSubjectIds)
+	if err != nil {
+		return err
+	}
+	topics, err := d.TopicsFindByIds(ctx, topicIds)
+	if err != nil {
+		return err
+	}
+	err = d.TopicsLoadRelations(ctx, &topics)
+	if err != nil {
+		return err
+	}
+	topicsById := map[string]*models.Topics{}
+	for _, t := range topics {
+		topicsById[t.ID] = t
+	}
+	for _, m := range *l {
+		for _, bs := range baseSubjects {
+			if bs.ID == m.BaseSubjectId {
+				m.BaseSubject = bs
+			}
+		}
+		// keep order of plan, deleted topics are skipped
+		m.Topics = []*models.Topics{}
+		for _, id := range m.TopicIds {
+			if t, ok := topicsById[id]; ok {
+				m.Topics = append(m.Topics, t)
+			}
+		}
+	}
+	return nil
+}
+
+func (d *PgxStore) LessonTopicsFindByLessons(ctx context.Context, lessonIds []string) ([]models.LessonTopic, error) {
+	res := []models.LessonTopic{}
+	err := d.runQuery(ctx, func(tx *pgxpool.Conn) (err error) {
+		rows, err := tx.Query(ctx, sqlLessonTopicSelect, lessonIds)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			t := models.LessonTopic{}
+			if err = rows.Scan(&t.LessonId, &t.TopicId, &t.Title); err != nil {
+				return err
+			}
+			res = append(res, t)
+		}
+		return
+	})
+	if err != nil {
+		utils.LoggerDesc("Query error").Error(err)
+		return nil, err
+	}
+	return res, nil
+}
+
+// LessonTopicsReplace sets topics filled by plan for given lessons
+func (d *PgxStore) LessonTopicsReplace(ctx context.Context, lessonIds []string, items []models.LessonTopic) error {
+	err := d.runInTx(ctx, func(tx pgx.Tx) (rollback bool, err error) {
+		_, err = tx.Exec(ctx, sqlLessonTopicDelete, lessonIds)
+		if err != nil {
+			return true, err
+		}
+		sqls := pgx.Batch{}
+		for _, v := range items {
+			sqls.Queue(sqlLessonTopicInsert, v.LessonId, v.TopicId, v.Title)
+		}
+		br := tx.SendBatch(ctx, &sqls)
+		for range items {
+			_, err = br.Exec()
+			if err != nil {
+				br.Close()
+				return true, err
+			}
+		}
+		return false, br.Close()
+	})
+	if err != nil {
+		utils.LoggerDesc("Query error").Error(err)
+	}
+	return err
+}
@@ -0,0 +1,88 @@
+package pgx
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/mekdep/server/internal/models"
+	"github.com/mekdep/server/internal/utils"
+)
+
+const sqlEmailTokenFields = `uid, user_uid, type, email, token_hash, expires_at, used_at, created_at`
+
+const sqlEmailTokenInsert = `insert into email_tokens (user_uid, type, email, token_hash, expires_at) values ($1, $2, $3, $4, $5) returning uid`
+
+// older links of same type stop working when new one is sent
+const sqlEmailTokenExpireOld = `update email_tokens set used_at=$3 where user_uid=$1 and type=$2 and used_at is null`
+
+const sqlEmailTokenUse = `update email_tokens set used_at=$3 where token_hash=$1 and type=$2 and used_at is null and expires_at>$3
+	returning ` + sqlEmailTokenFields
+
+const sqlUserEmailVerified = `update users set email_verified_at=$3 where uid=$1 and email=$2`
+
+const sqlUserEmailVerifiedReset = `update users set email_verified_at=null where uid=$1`
+
+// EmailTokensCreate saves token and expires previous unused tokens of user with same type
+func (d *PgxStore) EmailTokensCreate(ctx context.Context, m *models.EmailToken) error {
+	err := d.runInTx(ctx, func(tx pgx.Tx) (rollback bool, err error) {
+		_, err = tx.Exec(ctx, sqlEmailTokenExpireOld, m.UserId, m.Type, time.Now())
+		if err != nil {
+			return true, err
+		}
+		err = tx.QueryRow(ctx, sqlEmailTokenInsert, m.UserId, m.Type, m.Email, m.TokenHash, m.ExpiresAt).Scan(&m.ID)
+		if err != nil {
+			return true, err
+		}
+		return false, nil
+	})
+	if err != nil {
+		utils.LoggerDesc("Query error").Error(err)
+		return err
+	}
+	return nil
+}
+
+// EmailTokensUse marks token as used, pgx.ErrNoRows is returned for unknown, used or expired token
+func (d *PgxStore) EmailTokensUse(ctx context.Context, tokenHash string, tokenType string) (*models.EmailToken, error) {
+	m := models.EmailToken{}
+	err := d.runQuery(ctx, func(tx *pgxpool.Conn) (err error) {
+		row := tx.QueryRow(ctx, sqlEmailTokenUse, tokenHash, tokenType, time.Now())
+		return row.Scan(parseColumnsForScan(&m)...)
+	})
+	if err != nil {
+		if err != pgx.ErrNoRows {
+			utils.LoggerDesc("Query error").Error(err)
+		}
+		return nil, err
+	}
+	return &m, nil
+}
+
+// UsersUpdateEmailVerified sets verified time only when user still has same email
+func (d *PgxStore) UsersUpdateEmailVerified(ctx context.Context, userId string, email string) (bool, error) {
+	var affected int64
+	err := d.runQuery(ctx, func(tx *pgxpool.Conn) (err error) {
+		ct, err := tx.Exec(ctx, sqlUserEmailVerified, userId, email, time.Now())
+		affected = ct.RowsAffected()
+		return
+	})
+	if err != nil {
+		utils.LoggerDesc("Query error").Error(err)
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+func (d *PgxStore) UsersResetEmailVerified(ctx context.Context, userId string) error {
+	err := d.runQuery(ctx, func(tx *pgxpool.Conn) (err error) {
+		_, err = tx.Exec(ctx, sqlUserEmailVerifiedReset, userId)
+		return
+	})
+	if err != nil {
+		utils.LoggerDesc("Query error").Error(err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package pgx
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/mekdep/server/internal/models"
+	"github.com/mekdep/server/internal/utils"
+)
+
+// rows keeping file ($1 path) by private folder: user, school, shared
+var sqlFileOwners = map[string]string{
+	"users": `select u.uid, us.school_uid, u.avatar=$1 from users u left join user_schools us on us.user_uid=u.uid
+		where u.avatar=$1 or $1=ANY(u.document_files)
+		union all select dr.user_uid, null, false from user_data_requests dr where dr.file=$1`,
+	"teacher_excuses": `select te.teacher_uid, te.school_uid, false from teacher_excuses te where te.document_files::jsonb ? $1`,
+	"school_transfers": `select st.student_uid, s.uid, false from school_transfers st
+		join schools s on s.uid=st.source_school_uid or s.uid=st.target_school_uid where st.sender_files::jsonb ? $1`,
+	"contact_files": `select ci.user_uid, ci.school_uid, false from contact_items ci where ci.files::jsonb ? $1
+		or exists (select 1 from contact_replies cr where cr.contact_item_uid=ci.uid and $1=ANY(cr.files))`,
+	"submissions": `select s.student_uid, l.school_uid, false from assignment_submissions s
+		join lessons l on l.uid=s.lesson_uid where $1=ANY(s.files)`,
+}
+
+// FileOwnersFind returns owners of private file by rows keeping its path, empty when file is not kept anywhere
+func (d *PgxStore) FileOwnersFind(ctx context.Context, path string) ([]*models.FileOwner, error) {
+	l := []*models.FileOwner{}
+	qs, ok := sqlFileOwners[strings.SplitN(path, "/", 2)[0]]
+	if !ok {
+		return l, nil
+	}
+	err := d.runQuery(ctx, func(tx *pgxpool.Conn) (err error) {
+		rows, err := tx.Query(ctx, qs, path)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			m := models.FileOwner{}
+			if err = rows.Scan(&m.UserId, &m.SchoolId, &m.Shared); err != nil {
+				return err
+			}
+			l = append(l, &m)
+		}
+		return
+	})
+	if err != nil {
+		utils.LoggerDesc("Query error").Error(err)
+		return nil, err
+	}
+	return l, nil
+}
@@ -15,7 +15,7 @@ import (
 )
 
 // base
-const sqlLessonFields = `l.uid, l.school_uid, l.subject_uid, l.book_uid, l.book_page, l.period_uid, l.period_key, l.date, l.hour_number, l.type_title, l.title, l.content, l.pro_title, l.pro_files, l.assignment_title, l.assignment_content, l.assignment_files, l.lesson_attributes, l.is_teacher_excused, l.updated_at, l.created_at`
+const sqlLessonFields = `l.uid, l.school_uid, l.subject_uid, l.book_uid, l.book_page, l.period_uid, l.period_key, l.date, l.hour_number, l.type_title, l.title, l.content, l.pro_title, l.pro_files, l.assignment_title, l.assignment_content, l.assignment_files, l.assignment_due_at, l.lesson_attributes, l.is_teacher_excused, l.updated_at, l.created_at`
 const sqlLessonSelect = `select ` + sqlLessonFields + `  from lessons l where uid = ANY($1::uuid[])`
 const sqlLessonSelectBySubject = `select ` + sqlLessonFields + `  from lessons l where subject_uid = ANY($1::uuid[])`
 const sqlLessonSelectMany = `select ` + sqlLessonFields + `, 1 from lessons l 
@@ -379,6 +379,9 @@ func LessonAtomicQuery(m models.Lesson, isCreate bool) map[string]interface{} {
 	if m.AssignmentFiles != nil {
 		q["assignment_files"] = m.AssignmentFiles
 	}
+	if m.AssignmentDueAt != nil {
+		q["assignment_due_at"] = m.AssignmentDueAt
+	}
 	if m.IsTeacherExcused != nil {
 		q["is_teacher_excused"] = *m.IsTeacherExcused
 	}
@@ -13,7 +13,7 @@ import (
 )
 
 const (
-	sqlAssignmentFields     = "a.uid, a.lesson_uid, a.title, a.files, a.updated_at, a.created_at, a.updated_by_uid, a.created_by_uid"
+	sqlAssignmentFields     = "a.uid, a.lesson_uid, a.title, a.content, a.files, a.updated_at, a.created_at, a.updated_by_uid, a.created_by_uid"
 	sqlAssignmentSelect     = `select ` + sqlAssignmentFields + `  from assignments a where a.uid = ANY($1::uuid[])`
 	sqlAssignmentSelectMany = `select ` + sqlAssignmentFields + `, count(*) over() as total  from assignments a where uid=uid limit $1 offset $2`
 	sqlAssignmentUpdate     = "update assignments a set uid=uid"
@@ -207,6 +207,9 @@ func AssignmentAtomicQuery(m models.Assignment, isCreate bool) map[string]interf
 		q["lesson_uid"] = m.LessonId
 	}
 	q["title"] = m.Title
+	if m.Content != nil {
+		q["content"] = *m.Content
+	}
 	if m.Files != nil {
 		q["files"] = *m.Files
 	}
@@ -0,0 +1,181 @@
+package pgx
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/mekdep/server/internal/models"
+	"github.com/mekdep/server/internal/utils"
+)
+
+const sqlAssignmentSubmissionFields = `asb.uid, asb.lesson_uid, asb.student_uid, asb.submitted_by_uid, asb.content, asb.files, asb.status, asb.is_late, asb.attempt,
+	asb.grade_value, asb.feedback, asb.graded_by_uid, asb.graded_at, asb.submitted_at, asb.updated_at, asb.created_at`
+const sqlAssignmentSubmissionSelectMany = `select ` + sqlAssignmentSubmissionFields + ` from assignment_submissions asb where asb.uid=asb.uid`
+const sqlAssignmentSubmissionInsert = `insert into assignment_submissions`
+const sqlAssignmentSubmissionUpdate = `update assignment_submissions asb set uid=uid`
+
+func scanAssignmentSubmission(rows pgx.Row, m *models.AssignmentSubmission, addColumns ...interface{}) (err error) {
+	err = rows.Scan(parseColumnsForScan(m, addColumns...)...)
+	return
+}
+
+func (d *PgxStore) AssignmentSubmissionsFindBy(ctx context.Context, f models.AssignmentSubmissionFilterRequest) ([]*models.AssignmentSubmission, error) {
+	args := []interface{}{}
+	wheres := ""
+	if f.ID != nil {
+		args = append(args, *f.ID)
+		wheres += " and asb.uid=$" + strconv.Itoa(len(args))
+	}
+	if f.LessonIds != nil {
+		args = append(args, *f.LessonIds)
+		wheres += " and asb.lesson_uid = ANY($" + strconv.Itoa(len(args)) + "::uuid[])"
+	}
+	if f.StudentId != nil {
+		args = append(args, *f.StudentId)
+		wheres += " and asb.student_uid=$" + strconv.Itoa(len(args))
+	}
+	if f.StudentIds != nil {
+		args = append(args, *f.StudentIds)
+		wheres += " and asb.student_uid = ANY($" + strconv.Itoa(len(args)) + "::uuid[])"
+	}
+	qs := sqlAssignmentSubmissionSelectMany + wheres + " order by asb.submitted_at asc"
+	items := []*models.AssignmentSubmission{}
+	err := d.runQuery(ctx, func(tx *pgxpool.Conn) (err error) {
+		rows, err := tx.Query(ctx, qs, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			item := models.AssignmentSubmission{}
+			err = scanAssignmentSubmission(rows, &item)
+			if err != nil {
+				utils.LoggerDesc("Scan error").Error(err)
+				return err
+			}
+			items = append(items, &item)
+		}
+		return
+	})
+	if err != nil {
+		utils.LoggerDesc("Query error").Error(err)
+		return nil, err
+	}
+	return items, nil
+}
+
+func (d *PgxStore) AssignmentSubmissionsFindById(ctx context.Context, id string) (*models.AssignmentSubmission, error) {
+	l, err := d.AssignmentSubmissionsFindBy(ctx, models.AssignmentSubmissionFilterRequest{ID: &id})
+	if err != nil {
+		return nil, err
+	}
+	if len(l) < 1 {
+		return nil, errors.New("assignment submission not found by id: " + id)
+	}
+	return l[0], nil
+}
+
+func (d *PgxStore) AssignmentSubmissionCreate(ctx context.Context, m *models.AssignmentSubmission) (*models.AssignmentSubmission, error) {
+	args := []interface{}{}
+	cols := ""
+	vals := ""
+	q := AssignmentSubmissionAtomicQuery(m, true)
+	for k, v := range q {
+		args = append(args, v)
+		cols += ", " + k
+		vals += ", $" + strconv.Itoa(len(args))
+	}
+	qs := sqlAssignmentSubmissionInsert + " (" + strings.Trim(cols, ", ") + ") VALUES (" + strings.Trim(vals, ", ") + ") RETURNING uid"
+	err := d.runQuery(ctx, func(tx *pgxpool.Conn) (err error) {
+		err = tx.QueryRow(ctx, qs, args...).Scan(&m.ID)
+		return
+	})
+	if err != nil {
+		utils.LoggerDesc("Query error").Error(err)
+		return nil, err
+	}
+	return d.AssignmentSubmissionsFindById(ctx, m.ID)
+}
+
+func (d *PgxStore) AssignmentSubmissionUpdate(ctx context.Context, m *models.AssignmentSubmission) (*models.AssignmentSubmission, error) {
+	args := []interface{}{}
+	sets := ""
+	q := AssignmentSubmissionAtomicQuery(m, false)
+	for k, v := range q {
+		args = append(args, v)
+		sets += ", " + k + "=$" + strconv.Itoa(len(args))
+	}
+	args = append(args, m.ID)
+	qs := strings.ReplaceAll(sqlAssignmentSubmissionUpdate, "set uid=uid", "set uid=uid "+sets) + " where uid=$" + strconv.Itoa(len(args))
+	err := d.runQuery(ctx, func(tx *pgxpool.Conn) (err error) {
+		_, err = tx.Exec(ctx, qs, args...)
+		return
+	})
+	if err != nil {
+		utils.LoggerDesc("Query error").Error(err)
+		return nil, err
+	}
+	return d.AssignmentSubmissionsFindById(ctx, m.ID)
+}
+
+func AssignmentSubmissionAtomicQuery(m *models.AssignmentSubmission, isCreate bool) map[string]interface{} {
+	q := map[string]interface{}{}
+	q["submitted_by_uid"] = m.SubmittedBy
+	q["content"] = m.Content
+	q["files"] = m.Files
+	q["status"] = m.Status
+	q["is_late"] = m.IsLate
+	q["attempt"] = m.Attempt
+	q["grade_value"] = m.GradeValue
+	q["feedback"] = m.Feedback
+	q["graded_by_uid"] = m.GradedBy
+	q["graded_at"] = m.GradedAt
+	q["submitted_at"] = m.SubmittedAt
+	q["updated_at"] = time.Now()
+	if isCreate {
+		q["lesson_uid"] = m.LessonId
+		q["student_uid"] = m.StudentId
+		q["created_at"] = time.Now()
+	}
+	return q
+}
+
+// AssignmentSubmissionsLoadRelations loads students and users who submitted and graded
+func (d *PgxStore) AssignmentSubmissionsLoadRelations(ctx context.Context, l []*models.AssignmentSubmission) error {
+	ids := []string{}
+	for _, m := range l {
+		ids = append(ids, m.StudentId)
+		if m.SubmittedBy != nil {
+			ids = append(ids, *m.SubmittedBy)
+		}
+		if m.GradedBy != nil {
+			ids = append(ids, *m.GradedBy)
+		}
+	}
+	if len(ids) < 1 {
+		return nil
+	}
+	users, err := d.UsersFindByIds(ctx, ids)
+	if err != nil {
+		return err
+	}
+	for _, m := range l {
+		for _, u := range users {
+			if u.ID == m.StudentId {
+				m.Student = u
+			}
+			if m.SubmittedBy != nil && u.ID == *m.SubmittedBy {
+				m.SubmittedByUser = u
+			}
+			if m.GradedBy != nil && u.ID == *m.GradedBy {
+				m.GradedByUser = u
+			}
+		}
+	}
+	return nil
+}
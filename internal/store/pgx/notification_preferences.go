@@ -0,0 +1,128 @@
+package pgx
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/mekdep/server/internal/models"
+	"github.com/mekdep/server/internal/utils"
+)
+
+const sqlNotificationPreferenceFields = `np.uid, np.user_uid, np.role, np.category, np.channel, np.quiet_start, np.quiet_end, np.updated_at, np.created_at`
+
+const sqlNotificationPreferenceInsert = `insert into notification_preferences`
+
+const sqlNotificationPreferenceSelectMany = `select ` + sqlNotificationPreferenceFields + ` from notification_preferences np
+	where np.uid=np.uid`
+
+func scanNotificationPreference(rows pgx.Rows, m *models.NotificationPreference, addColumns ...interface{}) (err error) {
+	err = rows.Scan(parseColumnsForScan(m, addColumns...)...)
+	return
+}
+
+func (d *PgxStore) NotificationPreferencesFindBy(ctx context.Context, f models.NotificationPreferenceFilterRequest) (items []*models.NotificationPreference, err error) {
+	qs, args := NotificationPreferencesListBuildQuery(f, []interface{}{})
+	err = d.runQuery(ctx, func(tx *pgxpool.Conn) (err error) {
+		rows, err := tx.Query(ctx, qs, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			item := models.NotificationPreference{}
+			err = scanNotificationPreference(rows, &item)
+			if err != nil {
+				utils.LoggerDesc("Scan error").Error(err)
+				return err
+			}
+			items = append(items, &item)
+		}
+		return
+	})
+	if err != nil {
+		utils.LoggerDesc("Query error").Error(err)
+		return nil, err
+	}
+	return items, nil
+}
+
+// NotificationPreferencesReplace replaces all preferences of user, or of role defaults when userId is nil
+func (d *PgxStore) NotificationPreferencesReplace(ctx context.Context, userId *string, role *string, l []*models.NotificationPreference) error {
+	err := d.runInTx(ctx, func(tx pgx.Tx) (rollback bool, err error) {
+		if userId != nil {
+			_, err = tx.Exec(ctx, `delete from notification_preferences where user_uid=$1`, *userId)
+		} else {
+			_, err = tx.Exec(ctx, `delete from notification_preferences where user_uid is null and role=$1`, role)
+		}
+		if err != nil {
+			return true, err
+		}
+		for _, m := range l {
+			m.UserId = userId
+			m.Role = role
+			qs, args := NotificationPreferenceCreateQuery(m)
+			err = tx.QueryRow(ctx, qs+" RETURNING uid", args...).Scan(&m.ID)
+			if err != nil {
+				return true, err
+			}
+		}
+		return false, nil
+	})
+	if err != nil {
+		utils.LoggerDesc("Query error").Error(err)
+	}
+	return err
+}
+
+func NotificationPreferenceCreateQuery(m *models.NotificationPreference) (string, []interface{}) {
+	args := []interface{}{}
+	cols := ""
+	vals := ""
+	q := map[string]interface{}{
+		"user_uid":    m.UserId,
+		"role":        m.Role,
+		"category":    m.Category,
+		"channel":     m.Channel,
+		"quiet_start": m.QuietStart,
+		"quiet_end":   m.QuietEnd,
+		"updated_at":  time.Now(),
+		"created_at":  time.Now(),
+	}
+	for k, v := range q {
+		args = append(args, v)
+		cols += ", " + k
+		vals += ", $" + strconv.Itoa(len(args))
+	}
+	qs := sqlNotificationPreferenceInsert + " (" + strings.Trim(cols, ", ") + ") VALUES (" + strings.Trim(vals, ", ") + ")"
+	return qs, args
+}
+
+func NotificationPreferencesListBuildQuery(f models.NotificationPreferenceFilterRequest, args []interface{}) (string, []interface{}) {
+	wheres := ""
+	if f.UserIds != nil {
+		args = append(args, *f.UserIds)
+		wheres += " and np.user_uid = ANY($" + strconv.Itoa(len(args)) + "::uuid[])"
+	}
+	if f.Roles != nil {
+		args = append(args, *f.Roles)
+		wheres += " and np.role = ANY($" + strconv.Itoa(len(args)) + "::text[])"
+	}
+	if f.IsRoleDefault != nil {
+		if *f.IsRoleDefault {
+			wheres += " and np.user_uid is null"
+		} else {
+			wheres += " and np.user_uid is not null"
+		}
+	}
+	if f.Category != nil && *f.Category != "" {
+		args = append(args, *f.Category)
+		wheres += " and np.category=$" + strconv.Itoa(len(args))
+	}
+	wheres += " order by np.created_at"
+	qs := strings.ReplaceAll(sqlNotificationPreferenceSelectMany, "np.uid=np.uid", "np.uid=np.uid "+wheres+" ")
+	return qs, args
+}
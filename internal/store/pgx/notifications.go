@@ -13,7 +13,8 @@ import (
 	"github.com/mekdep/server/internal/utils"
 )
 
-const sqlNotificationFields = `nn.uid, nn.school_uids, nn.roles, nn.user_uids, nn.author_uid, nn.title, nn.content, nn.files, nn.updated_at, nn.created_at`
+const sqlNotificationFields = `nn.uid, nn.school_uids, nn.roles, nn.user_uids, nn.author_uid, nn.title, nn.content, nn.files, nn.updated_at, nn.created_at,
+	nn.send_at, nn.expires_at, nn.sent_at, nn.classroom_uids, nn.classyears, nn.regions, nn.tariff_types`
 
 const sqlNotificationsInsert = `insert into notifications`
 
@@ -27,6 +28,16 @@ const sqlNotificationSelect = `select ` + sqlNotificationFields + ` from notific
 
 const sqlNotificationSelectMany = `select ` + sqlNotificationFields + `, count(*) over() as total from notifications nn where nn.uid=nn.uid limit $1 offset $2`
 
+// claims scheduled notifications, so each is sent once even when jobs overlap
+const sqlNotificationClaimScheduled = `update notifications nn set sent_at=$1
+	where nn.sent_at is null and nn.send_at <= $1 returning ` + sqlNotificationFields
+
+const sqlNotificationAnalytics = `select un.school_uid, un.role, count(un.uid),
+	count(un.uid) filter (where un.opened_at is not null or exists (select 1 from push_deliveries pd where pd.user_notification_uid=un.uid and pd.status='sent')),
+	count(un.opened_at), count(un.read_at)
+	from user_notifications un where un.notification_uid=$1
+	group by un.school_uid, un.role order by un.school_uid, un.role`
+
 const sqlNotificationSchool = `select ` + sqlSchoolFields + `, nn.uid from notifications nn
 	right join schools s on (s.uid = ANY(nn.school_uids::uuid[])) where nn.uid = ANY($1::uuid[])`
 
@@ -159,6 +170,60 @@ func (d *PgxStore) NotificationDelete(ctx context.Context, ID string) error {
 
 	return nil
 }
+
+// NotificationsClaimScheduled marks scheduled notifications whose time came as sent and returns them
+func (d *PgxStore) NotificationsClaimScheduled(ctx context.Context, now time.Time) ([]*models.Notifications, error) {
+	notifications := []*models.Notifications{}
+	err := d.runQuery(ctx, func(tx *pgxpool.Conn) (err error) {
+		rows, err := tx.Query(ctx, sqlNotificationClaimScheduled, now)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			notification := models.Notifications{}
+			err = scanNotifications(rows, &notification)
+			if err != nil {
+				utils.LoggerDesc("Scan error").Error(err)
+				return err
+			}
+			notifications = append(notifications, &notification)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		utils.LoggerDesc("Query error").Error(err)
+		return nil, err
+	}
+	return notifications, nil
+}
+
+func (d *PgxStore) NotificationsAnalytics(ctx context.Context, ID string) ([]*models.NotificationAnalytics, error) {
+	res := []*models.NotificationAnalytics{}
+	err := d.runQuery(ctx, func(tx *pgxpool.Conn) (err error) {
+		rows, err := tx.Query(ctx, sqlNotificationAnalytics, ID)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			item := models.NotificationAnalytics{}
+			err = rows.Scan(&item.SchoolId, &item.Role, &item.Total, &item.Delivered, &item.Opened, &item.Read)
+			if err != nil {
+				utils.LoggerDesc("Scan error").Error(err)
+				return err
+			}
+			res = append(res, &item)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		utils.LoggerDesc("Query error").Error(err)
+		return nil, err
+	}
+	return res, nil
+}
+
 func NotificationCreateQuery(m *models.Notifications) (string, []interface{}) {
 	args := []interface{}{}
 	cols := ""
@@ -209,6 +274,27 @@ func NotificationAtomicQuery(m *models.Notifications, isCreate bool) map[string]
 	if m.Files != nil {
 		q["files"] = *m.Files
 	}
+	if m.SendAt != nil {
+		q["send_at"] = m.SendAt
+	}
+	if m.ExpiresAt != nil {
+		q["expires_at"] = m.ExpiresAt
+	}
+	if m.SentAt != nil {
+		q["sent_at"] = m.SentAt
+	}
+	if m.ClassroomIds != nil {
+		q["classroom_uids"] = m.ClassroomIds
+	}
+	if m.Classyears != nil {
+		q["classyears"] = m.Classyears
+	}
+	if m.Regions != nil {
+		q["regions"] = m.Regions
+	}
+	if m.TariffTypes != nil {
+		q["tariff_types"] = m.TariffTypes
+	}
 	if isCreate {
 		q["created_at"] = time.Now()
 	}
@@ -14,13 +14,16 @@ import (
 	"github.com/mekdep/server/internal/utils"
 )
 
-const sqlUserNotificationFields = `un.uid, un.notification_uid, un.user_uid, un.read_at, un.role, un.comment, un.comment_files`
+const sqlUserNotificationFields = `un.uid, un.notification_uid, un.user_uid, un.read_at, un.role, un.comment, un.comment_files, un.school_uid, un.opened_at`
 
 const sqlUserNotificationsInsert = `insert into user_notifications`
 
 const sqlUserNotificationUpdate = `update user_notifications set uid=uid`
 const sqlUserNotificationUpdateRead = `update user_notifications set read_at=$2 where read_at is null and uid=ANY($1::uuid[])`
-const sqlUserNotificationSelectTotalUnread = `select count(uid) from user_notifications where user_uid=$1 and role=$2 and read_at is null`
+const sqlUserNotificationUpdateOpened = `update user_notifications set opened_at=$2 where opened_at is null and uid=ANY($1::uuid[])`
+const sqlUserNotificationSelectTotalUnread = `select count(un.uid) from user_notifications un
+	join notifications nn on un.notification_uid = nn.uid
+	where un.user_uid=$1 and un.role=$2 and un.read_at is null and (nn.expires_at is null or nn.expires_at > now())`
 
 const sqlUserNotificationSelect = `select ` + sqlUserNotificationFields + ` from user_notifications un where un.uid = ANY($1::uuid[])`
 
@@ -124,6 +127,15 @@ func (d *PgxStore) UserNotificationsUpdateRead(ctx context.Context, ids []string
 	return err
 }
 
+func (d *PgxStore) UserNotificationsUpdateOpened(ctx context.Context, ids []string) error {
+	now := time.Now()
+	err := d.runQuery(ctx, func(tx *pgxpool.Conn) (err error) {
+		_, err = tx.Exec(ctx, sqlUserNotificationUpdateOpened, ids, now)
+		return
+	})
+	return err
+}
+
 func (d *PgxStore) UserNotificationsSelectTotalUnread(ctx context.Context, userId string, role string) (int, error) {
 	total := 0
 	err := d.runQuery(ctx, func(tx *pgxpool.Conn) (err error) {
@@ -205,6 +217,9 @@ func UserNotificationAtomicQuery(m models.UserNotification, isCreate bool) map[s
 	if m.Role != nil {
 		q["role"] = m.Role
 	}
+	if m.SchoolId != nil {
+		q["school_uid"] = m.SchoolId
+	}
 	if m.Comment != nil {
 		q["comment"] = m.Comment
 	}
@@ -239,6 +254,13 @@ func UserNotificationsListBuildQuery(f models.UserNotificationFilterRequest, arg
 		args = append(args, *f.Role)
 		wheres += " and un.role=$" + strconv.Itoa(len(args))
 	}
+	if f.UserIds != nil {
+		args = append(args, *f.UserIds)
+		wheres += " and un.user_uid = ANY($" + strconv.Itoa(len(args)) + "::uuid[])"
+	}
+	if f.NotExpired != nil && *f.NotExpired {
+		wheres += " and (nn.expires_at is null or nn.expires_at > now())"
+	}
 	wheres += " group by un.uid, nn.created_at "
 	if f.Sort != nil && *f.Sort != "" {
 		dir := "desc"
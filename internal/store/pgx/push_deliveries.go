@@ -0,0 +1,227 @@
+package pgx
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/mekdep/server/internal/models"
+	"github.com/mekdep/server/internal/utils"
+)
+
+const sqlPushDeliveryFields = `pd.uid, pd.user_notification_uid, pd.user_uid, pd.device_token, pd.title, pd.body, pd.data, pd.status,
+	pd.attempts, pd.error, pd.message_id, pd.next_retry_at, pd.sent_at, pd.updated_at, pd.created_at`
+
+const sqlPushDeliveryInsert = `insert into push_deliveries`
+
+const sqlPushDeliveryUpdate = `update push_deliveries set uid=uid`
+
+const sqlPushDeliverySelectMany = `select ` + sqlPushDeliveryFields + `, count(*) over() as total from push_deliveries pd
+	where pd.uid=pd.uid limit $1 offset $2 `
+
+const sqlSessionClearDeviceTokens = `update sessions set device_token=null where device_token = ANY($1::text[])`
+
+func scanPushDelivery(rows pgx.Rows, m *models.PushDelivery, addColumns ...interface{}) (err error) {
+	err = rows.Scan(parseColumnsForScan(m, addColumns...)...)
+	return
+}
+
+func (d *PgxStore) PushDeliveriesFindBy(ctx context.Context, f models.PushDeliveryFilterRequest) (items []*models.PushDelivery, total int, err error) {
+	args := []interface{}{f.Limit, f.Offset}
+	qs, args := PushDeliveriesListBuildQuery(f, args)
+	err = d.runQuery(ctx, func(tx *pgxpool.Conn) (err error) {
+		rows, err := tx.Query(ctx, qs, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			item := models.PushDelivery{}
+			err = scanPushDelivery(rows, &item, &total)
+			if err != nil {
+				utils.LoggerDesc("Scan error").Error(err)
+				return err
+			}
+			items = append(items, &item)
+		}
+		return
+	})
+	if err != nil {
+		utils.LoggerDesc("Query error").Error(err)
+		return nil, 0, err
+	}
+	return items, total, nil
+}
+
+// PushDeliveriesCreateBatch inserts deliveries and sets their ids
+func (d *PgxStore) PushDeliveriesCreateBatch(ctx context.Context, l []*models.PushDelivery) error {
+	if len(l) < 1 {
+		return nil
+	}
+	err := d.runQuery(ctx, func(tx *pgxpool.Conn) (err error) {
+		sqls := pgx.Batch{}
+		for _, m := range l {
+			qs, args := PushDeliveryCreateQuery(m)
+			sqls.Queue(qs+" RETURNING uid", args...)
+		}
+		br := tx.SendBatch(ctx, &sqls)
+		defer br.Close()
+		for _, m := range l {
+			err = br.QueryRow().Scan(&m.ID)
+			if err != nil {
+				return err
+			}
+		}
+		return
+	})
+	if err != nil {
+		utils.LoggerDesc("Query error").Error(err)
+	}
+	return err
+}
+
+func (d *PgxStore) PushDeliveriesUpdateBatch(ctx context.Context, l []*models.PushDelivery) error {
+	if len(l) < 1 {
+		return nil
+	}
+	err := d.runQuery(ctx, func(tx *pgxpool.Conn) (err error) {
+		sqls := pgx.Batch{}
+		for _, m := range l {
+			qs, args := PushDeliveryUpdateQuery(m)
+			sqls.Queue(qs, args...)
+		}
+		br := tx.SendBatch(ctx, &sqls)
+		defer br.Close()
+		for range l {
+			_, err = br.Exec()
+			if err != nil {
+				return err
+			}
+		}
+		return
+	})
+	if err != nil {
+		utils.LoggerDesc("Query error").Error(err)
+	}
+	return err
+}
+
+// SessionsClearDeviceTokens removes unregistered device tokens, sessions stay logged in
+func (d *PgxStore) SessionsClearDeviceTokens(ctx context.Context, tokens []string) error {
+	if len(tokens) < 1 {
+		return nil
+	}
+	err := d.runQuery(ctx, func(tx *pgxpool.Conn) (err error) {
+		_, err = tx.Exec(ctx, sqlSessionClearDeviceTokens, tokens)
+		return
+	})
+	if err != nil {
+		utils.LoggerDesc("Query error").Error(err)
+	}
+	return err
+}
+
+func PushDeliveryCreateQuery(m *models.PushDelivery) (string, []interface{}) {
+	args := []interface{}{}
+	cols := ""
+	vals := ""
+	q := PushDeliveryAtomicQuery(m, true)
+	for k, v := range q {
+		args = append(args, v)
+		cols += ", " + k
+		vals += ", $" + strconv.Itoa(len(args))
+	}
+	qs := sqlPushDeliveryInsert + " (" + strings.Trim(cols, ", ") + ") VALUES (" + strings.Trim(vals, ", ") + ")"
+	return qs, args
+}
+
+func PushDeliveryUpdateQuery(m *models.PushDelivery) (string, []interface{}) {
+	args := []interface{}{}
+	sets := ""
+	q := PushDeliveryAtomicQuery(m, false)
+	for k, v := range q {
+		args = append(args, v)
+		sets += ", " + k + "=$" + strconv.Itoa(len(args))
+	}
+	args = append(args, m.ID)
+	qs := strings.ReplaceAll(sqlPushDeliveryUpdate, "set uid=uid", "set uid=uid"+sets+" ") + " where uid=$" + strconv.Itoa(len(args))
+	return qs, args
+}
+
+func PushDeliveryAtomicQuery(m *models.PushDelivery, isCreate bool) map[string]interface{} {
+	q := map[string]interface{}{}
+	if isCreate {
+		q["user_notification_uid"] = m.UserNotificationId
+		q["user_uid"] = m.UserId
+		q["device_token"] = m.DeviceToken
+		q["title"] = m.Title
+		q["body"] = m.Body
+		q["data"] = m.Data
+		q["created_at"] = time.Now()
+	}
+	q["status"] = m.Status
+	q["attempts"] = m.Attempts
+	q["error"] = m.Error
+	q["message_id"] = m.MessageId
+	q["next_retry_at"] = m.NextRetryAt
+	q["sent_at"] = m.SentAt
+	q["updated_at"] = time.Now()
+	return q
+}
+
+func PushDeliveriesListBuildQuery(f models.PushDeliveryFilterRequest, args []interface{}) (string, []interface{}) {
+	wheres := ""
+	if f.UserNotificationIds != nil {
+		args = append(args, *f.UserNotificationIds)
+		wheres += " and pd.user_notification_uid = ANY($" + strconv.Itoa(len(args)) + "::uuid[])"
+	}
+	if f.UserId != nil && *f.UserId != "" {
+		args = append(args, *f.UserId)
+		wheres += " and pd.user_uid=$" + strconv.Itoa(len(args))
+	}
+	if f.Status != nil && *f.Status != "" {
+		args = append(args, *f.Status)
+		wheres += " and pd.status=$" + strconv.Itoa(len(args))
+	}
+	if f.RetryBefore != nil {
+		args = append(args, *f.RetryBefore)
+		wheres += " and pd.next_retry_at <= $" + strconv.Itoa(len(args))
+	}
+	if f.UpdatedBefore != nil {
+		args = append(args, *f.UpdatedBefore)
+		wheres += " and pd.updated_at <= $" + strconv.Itoa(len(args))
+	}
+	wheres += " order by pd.created_at desc"
+	qs := strings.ReplaceAll(sqlPushDeliverySelectMany, "pd.uid=pd.uid", "pd.uid=pd.uid "+wheres+" ")
+	return qs, args
+}
+
+func (d *PgxStore) UserNotificationsLoadPushDeliveries(ctx context.Context, l *[]*models.UserNotification) error {
+	ids := []string{}
+	for _, m := range *l {
+		ids = append(ids, m.ID)
+	}
+	if len(ids) < 1 {
+		return nil
+	}
+	f := models.PushDeliveryFilterRequest{UserNotificationIds: &ids}
+	f.Limit = new(int)
+	*f.Limit = len(ids) * 10
+	f.Offset = new(int)
+	items, _, err := d.PushDeliveriesFindBy(ctx, f)
+	if err != nil {
+		return err
+	}
+	for _, m := range *l {
+		m.PushDeliveries = []*models.PushDelivery{}
+		for _, item := range items {
+			if item.UserNotificationId != nil && *item.UserNotificationId == m.ID {
+				m.PushDeliveries = append(m.PushDeliveries, item)
+			}
+		}
+	}
+	return nil
+}
@@ -14,7 +14,7 @@ import (
 	"github.com/mekdep/server/internal/utils"
 )
 
-const sqlReportItemsFields = `ri.uid, ri.report_uid, ri.school_uid, ri.period_uid, ri.classroom_uid, ri.updated_by, ri.values, ri.is_edited_manually, ri.created_at, ri.updated_at`
+const sqlReportItemsFields = `ri.uid, ri.report_uid, ri.school_uid, ri.period_uid, ri.classroom_uid, ri.updated_by, ri.values, ri.is_edited_manually, ri.status, ri.submitted_at, ri.reviewed_by, ri.reviewed_at, ri.review_comment, ri.created_at, ri.updated_at`
 
 const sqlReportItemsInsert = `insert into report_items`
 
@@ -227,6 +227,21 @@ func ReportItemsAtomicQuery(m models.ReportItems, isCreate bool) map[string]inte
 	if m.Values != nil {
 		q["values"] = m.Values
 	}
+	if m.Status != "" {
+		q["status"] = m.Status
+	}
+	if m.SubmittedAt != nil {
+		q["submitted_at"] = m.SubmittedAt
+	}
+	if m.ReviewedBy != nil {
+		q["reviewed_by"] = m.ReviewedBy
+	}
+	if m.ReviewedAt != nil {
+		q["reviewed_at"] = m.ReviewedAt
+	}
+	if m.ReviewComment != nil {
+		q["review_comment"] = m.ReviewComment
+	}
 	if isCreate {
 		q["created_at"] = time.Now()
 		q["updated_at"] = time.Now()
@@ -266,6 +281,10 @@ func ReportItemsListBuildQuery(f models.ReportItemsFilterRequest, args []interfa
 		args = append(args, *f.PeriodId)
 		wheres += " and ri.period_uid=$" + strconv.Itoa(len(args))
 	}
+	if f.Statuses != nil {
+		args = append(args, *f.Statuses)
+		wheres += " and ri.status=ANY($" + strconv.Itoa(len(args)) + ")"
+	}
 	if f.OnlyClassroom != nil {
 		if *f.OnlyClassroom {
 			wheres += " and ri.classroom_uid IS NOT NULL"
@@ -493,3 +512,42 @@ func (d *PgxStore) ReportItemsLoadUpdatedBy(ctx context.Context, ids []string) (
 	}
 	return res, nil
 }
+
+const sqlReportItemsCompletionByRegion = `select s.parent_uid, count(ri.uid),
+	count(ri.uid) filter (where ri.status in ('submitted', 'approved')),
+	count(ri.uid) filter (where ri.status='approved'),
+	count(ri.uid) filter (where ri.status='returned')
+	from report_items ri
+	join schools s on (s.uid=ri.school_uid)
+	where ri.report_uid = ANY($1::uuid[]) and ri.classroom_uid is null and s.parent_uid is not null
+	and ($2::uuid[] is null or s.parent_uid = ANY($2::uuid[]))
+	group by s.parent_uid`
+
+// ReportItemsCompletionByRegion counts school forms (not classrooms) of reports by region and status
+func (d *PgxStore) ReportItemsCompletionByRegion(ctx context.Context, reportIds []string, regionIds *[]string) ([]*models.ReportRegionCompletion, error) {
+	res := []*models.ReportRegionCompletion{}
+	err := d.runQuery(ctx, func(tx *pgxpool.Conn) error {
+		rows, err := tx.Query(ctx, sqlReportItemsCompletionByRegion, reportIds, regionIds)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			item := models.ReportRegionCompletion{}
+			err = rows.Scan(&item.RegionId, &item.ItemsCount, &item.SubmittedCount, &item.ApprovedCount, &item.ReturnedCount)
+			if err != nil {
+				return err
+			}
+			if item.ItemsCount > 0 {
+				item.Percent = item.SubmittedCount * 100 / item.ItemsCount
+			}
+			res = append(res, &item)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		utils.LoggerDesc("Query error").Error(err)
+		return nil, err
+	}
+	return res, nil
+}
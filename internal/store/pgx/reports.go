@@ -13,7 +13,7 @@ import (
 	"github.com/mekdep/server/internal/utils"
 )
 
-const sqlReportsFields = `rp.uid, rp.title, rp.description, rp.value_types, rp.school_uids, rp.region_uids, rp.is_pinned, rp.is_center_rating, rp.is_classrooms_included, rp.created_at, rp.updated_at`
+const sqlReportsFields = `rp.uid, rp.title, rp.description, rp.value_types, rp.school_uids, rp.region_uids, rp.is_pinned, rp.is_center_rating, rp.is_classrooms_included, rp.deadline_at, rp.start_date, rp.end_date, rp.created_at, rp.updated_at`
 const sqlReportsSelect = `SELECT ` + sqlReportsFields + `, null as items_count, null as items_filled_count FROM reports rp 
 	left join report_items ri on (ri.report_uid=rp.uid) WHERE rp.uid = ANY($1::uuid[]) GROUP BY rp.uid`
 const sqlReportsSelectMany = `SELECT ` + sqlReportsFields + `, null as items_count, null as items_filled_count, count(1) over() as total FROM reports rp 
@@ -179,6 +179,9 @@ func ReportsAtomicQuery(m *models.Reports, isCreate bool) map[string]interface{}
 	if m.IsClassroomsIncluded != nil {
 		q["is_classrooms_included"] = m.IsClassroomsIncluded
 	}
+	q["deadline_at"] = m.DeadlineAt
+	q["start_date"] = m.StartDate
+	q["end_date"] = m.EndDate
 	if isCreate {
 		q["created_at"] = time.Now()
 	}
@@ -210,6 +213,14 @@ func ReportsListBuildQuery(f models.ReportsFilterRequest, args []interface{}) (s
 		args = append(args, *f.Search)
 		wheres += " and (lower(rp.title) like '%' || $" + strconv.Itoa(len(args)) + "|| '%')"
 	}
+	if f.DeadlineFrom != nil {
+		args = append(args, *f.DeadlineFrom)
+		wheres += " and rp.deadline_at>=$" + strconv.Itoa(len(args))
+	}
+	if f.DeadlineTo != nil {
+		args = append(args, *f.DeadlineTo)
+		wheres += " and rp.deadline_at<$" + strconv.Itoa(len(args))
+	}
 	wheres += " group by rp.uid "
 	wheres += " order by rp.is_pinned desc, rp.created_at desc"
 
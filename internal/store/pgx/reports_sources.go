@@ -0,0 +1,53 @@
+package pgx
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/mekdep/server/internal/models"
+	"github.com/mekdep/server/internal/utils"
+)
+
+// $1 school, $2 classroom or null for all classrooms of school, $3 and $4 lesson dates
+const sqlReportSourceValues = `select
+(select count(distinct uc.user_uid) from classrooms c
+	join user_classrooms uc on (uc.classroom_uid=c.uid and uc.type is null and uc.type_key is null)
+	where c.school_uid=$1 and c.archived_at is null and ($2::uuid is null or c.uid=$2)) as students_count,
+(select count(*) from classrooms c
+	where c.school_uid=$1 and c.archived_at is null and ($2::uuid is null or c.uid=$2)) as classrooms_count,
+(select count(*) from absents a
+	join lessons l on (l.uid=a.lesson_uid)
+	join subjects sb on (sb.uid=l.subject_uid)
+	where sb.school_uid=$1 and ($2::uuid is null or sb.classroom_uid=$2) and l.date>=$3 and l.date<=$4 and a.deleted_at is null) as absents_count,
+(select count(*) from lessons l
+	join subjects sb on (sb.uid=l.subject_uid)
+	join user_classrooms uc on (uc.classroom_uid=sb.classroom_uid and COALESCE(uc.type_key, 0) = COALESCE(sb.classroom_type_key, 0))
+	where sb.school_uid=$1 and ($2::uuid is null or sb.classroom_uid=$2) and l.date>=$3 and l.date<=$4 and l.is_teacher_excused = false) as student_lessons_count,
+(select COALESCE(avg(COALESCE(case when g.value ~ '^[0-9]+$' then g.value::int end, (g.values[1]+g.values[2])/2.0)), 0) from grades g
+	join lessons l on (l.uid=g.lesson_uid)
+	join subjects sb on (sb.uid=l.subject_uid)
+	where sb.school_uid=$1 and ($2::uuid is null or sb.classroom_uid=$2) and l.date>=$3 and l.date<=$4 and g.deleted_at is null) as grade_average`
+
+// ReportSourceValues counts journal data of school or classroom for report fields with source
+func (d *PgxStore) ReportSourceValues(ctx context.Context, schoolId string, classroomId *string, startDate time.Time, endDate time.Time) (models.ReportSourceValues, error) {
+	res := models.ReportSourceValues{}
+	err := d.runQuery(ctx, func(tx *pgxpool.Conn) error {
+		absentsCount := 0
+		studentLessonsCount := 0
+		err := tx.QueryRow(ctx, sqlReportSourceValues, schoolId, classroomId, startDate, endDate).Scan(
+			&res.StudentsCount, &res.ClassroomsCount, &absentsCount, &studentLessonsCount, &res.GradeAverage)
+		if err != nil {
+			return err
+		}
+		if studentLessonsCount > 0 {
+			res.AbsenceRate = float64(absentsCount) * 100 / float64(studentLessonsCount)
+		}
+		return nil
+	})
+	if err != nil {
+		utils.LoggerDesc("Query error").Error(err)
+		return res, err
+	}
+	return res, nil
+}
@@ -0,0 +1,219 @@
+package pgx
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/mekdep/server/internal/models"
+	"github.com/mekdep/server/internal/utils"
+)
+
+const sqlRoleFields = `r.code, r.name, r.base_role, r.is_system, r.updated_at, r.created_at`
+const sqlRoleSelectMany = `select ` + sqlRoleFields + ` from roles r order by r.is_system desc, r.code asc`
+const sqlRoleSelect = `select ` + sqlRoleFields + ` from roles r where r.code = ANY($1::text[])`
+const sqlRoleUpsert = `insert into roles (code, name, base_role, is_system) values ($1, $2, $3, $4)
+	on conflict (code) do update set name=excluded.name, base_role=excluded.base_role, updated_at=CURRENT_TIMESTAMP`
+const sqlRoleInsertIgnore = `insert into roles (code, name, base_role, is_system) values ($1, $2, $3, $4) on conflict (code) do nothing`
+const sqlRoleDelete = `delete from roles where code=$1 and not is_system`
+
+const sqlRolePermissionFields = `rp.uid, rp.role_code, rp.school_uid, rp.permission, rp.access, rp.updated_at`
+
+// global grants with overrides of school when school is given
+const sqlRolePermissionSelect = `select ` + sqlRolePermissionFields + ` from role_permissions rp
+	where rp.role_code = ANY($1::text[]) and (rp.school_uid is null or rp.school_uid = $2::uuid) order by rp.permission asc`
+const sqlRolePermissionUpsert = `insert into role_permissions (role_code, school_uid, permission, access) values ($1, $2, $3, $4)
+	on conflict (role_code, (COALESCE(school_uid, '00000000-0000-0000-0000-000000000000'::uuid)), permission)
+	do update set access=excluded.access, updated_at=CURRENT_TIMESTAMP`
+const sqlRolePermissionInsertIgnore = `insert into role_permissions (role_code, school_uid, permission, access) values ($1, $2, $3, $4)
+	on conflict (role_code, (COALESCE(school_uid, '00000000-0000-0000-0000-000000000000'::uuid)), permission) do nothing`
+const sqlRolePermissionDeleteGlobal = `delete from role_permissions where role_code=$1 and school_uid is null`
+const sqlRolePermissionDeleteSchool = `delete from role_permissions where role_code=$1 and school_uid=$2`
+
+const sqlUserSchoolsCustomRoleSet = `update user_schools set custom_role_code=$4 where user_uid=$1 and school_uid=$2 and role_code=$3`
+
+func scanRole(rows pgx.Row, m *models.RoleDefinition, addColumns ...interface{}) (err error) {
+	err = rows.Scan(parseColumnsForScan(m, addColumns...)...)
+	return
+}
+
+func scanRolePermission(rows pgx.Row, m *models.RolePermission, addColumns ...interface{}) (err error) {
+	err = rows.Scan(parseColumnsForScan(m, addColumns...)...)
+	return
+}
+
+func (d *PgxStore) RolesFindBy(ctx context.Context, codes []string) ([]*models.RoleDefinition, error) {
+	qs := sqlRoleSelectMany
+	args := []interface{}{}
+	if codes != nil {
+		qs = sqlRoleSelect
+		args = append(args, codes)
+	}
+	l := []*models.RoleDefinition{}
+	err := d.runQuery(ctx, func(tx *pgxpool.Conn) (err error) {
+		rows, err := tx.Query(ctx, qs, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			m := models.RoleDefinition{}
+			err = scanRole(rows, &m)
+			if err != nil {
+				utils.LoggerDesc("Scan error").Error(err)
+				return err
+			}
+			l = append(l, &m)
+		}
+		return
+	})
+	if err != nil {
+		utils.LoggerDesc("Query error").Error(err)
+		return nil, err
+	}
+	return l, nil
+}
+
+func (d *PgxStore) RolesFindByCode(ctx context.Context, code string) (*models.RoleDefinition, error) {
+	l, err := d.RolesFindBy(ctx, []string{code})
+	if err != nil {
+		return nil, err
+	}
+	if len(l) < 1 {
+		return nil, errors.New("role not found by code: " + code)
+	}
+	return l[0], nil
+}
+
+// RoleSave creates or updates role with its global grants, grants are replaced
+func (d *PgxStore) RoleSave(ctx context.Context, m *models.RoleDefinition) (*models.RoleDefinition, error) {
+	err := d.runInTx(ctx, func(tx pgx.Tx) (rollback bool, err error) {
+		_, err = tx.Exec(ctx, sqlRoleUpsert, m.Code, m.Name, m.BaseRole, m.IsSystem)
+		if err != nil {
+			return true, err
+		}
+		if m.Permissions == nil {
+			return false, nil
+		}
+		_, err = tx.Exec(ctx, sqlRolePermissionDeleteGlobal, m.Code)
+		if err != nil {
+			return true, err
+		}
+		for _, p := range m.Permissions {
+			_, err = tx.Exec(ctx, sqlRolePermissionUpsert, m.Code, nil, p.Permission, p.Access)
+			if err != nil {
+				return true, err
+			}
+		}
+		return false, nil
+	})
+	if err != nil {
+		utils.LoggerDesc("Query error").Error(err)
+		return nil, err
+	}
+	return d.RolesFindByCode(ctx, m.Code)
+}
+
+func (d *PgxStore) RoleDelete(ctx context.Context, code string) error {
+	err := d.runQuery(ctx, func(tx *pgxpool.Conn) (err error) {
+		_, err = tx.Exec(ctx, sqlRoleDelete, code)
+		return
+	})
+	if err != nil {
+		utils.LoggerDesc("Query error").Error(err)
+		return err
+	}
+	return nil
+}
+
+// RolesSeed inserts roles and grants which are not stored yet, stored ones (also removed as none) are kept
+func (d *PgxStore) RolesSeed(ctx context.Context, roles []*models.RoleDefinition) error {
+	err := d.runInTx(ctx, func(tx pgx.Tx) (rollback bool, err error) {
+		for _, m := range roles {
+			_, err = tx.Exec(ctx, sqlRoleInsertIgnore, m.Code, m.Name, m.BaseRole, m.IsSystem)
+			if err != nil {
+				return true, err
+			}
+			for _, p := range m.Permissions {
+				_, err = tx.Exec(ctx, sqlRolePermissionInsertIgnore, m.Code, nil, p.Permission, p.Access)
+				if err != nil {
+					return true, err
+				}
+			}
+		}
+		return false, nil
+	})
+	if err != nil {
+		utils.LoggerDesc("Query error").Error(err)
+		return err
+	}
+	return nil
+}
+
+// RolePermissionsFindBy returns global grants of roles and overrides of school
+func (d *PgxStore) RolePermissionsFindBy(ctx context.Context, roleCodes []string, schoolId *string) ([]*models.RolePermission, error) {
+	l := []*models.RolePermission{}
+	err := d.runQuery(ctx, func(tx *pgxpool.Conn) (err error) {
+		rows, err := tx.Query(ctx, sqlRolePermissionSelect, roleCodes, schoolId)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			m := models.RolePermission{}
+			err = scanRolePermission(rows, &m)
+			if err != nil {
+				utils.LoggerDesc("Scan error").Error(err)
+				return err
+			}
+			l = append(l, &m)
+		}
+		return
+	})
+	if err != nil {
+		utils.LoggerDesc("Query error").Error(err)
+		return nil, err
+	}
+	return l, nil
+}
+
+// RolePermissionsOverride replaces grants of role in school
+func (d *PgxStore) RolePermissionsOverride(ctx context.Context, roleCode string, schoolId string, l []*models.RolePermission) error {
+	err := d.runInTx(ctx, func(tx pgx.Tx) (rollback bool, err error) {
+		_, err = tx.Exec(ctx, sqlRolePermissionDeleteSchool, roleCode, schoolId)
+		if err != nil {
+			return true, err
+		}
+		for _, p := range l {
+			_, err = tx.Exec(ctx, sqlRolePermissionUpsert, roleCode, schoolId, p.Permission, p.Access)
+			if err != nil {
+				return true, err
+			}
+		}
+		return false, nil
+	})
+	if err != nil {
+		utils.LoggerDesc("Query error").Error(err)
+		return err
+	}
+	return nil
+}
+
+// UserSchoolsCustomRoleSet sets custom role of user in school for base role, returns count of updated
+func (d *PgxStore) UserSchoolsCustomRoleSet(ctx context.Context, userId string, schoolId string, baseRole models.Role, customRoleCode *string) (int64, error) {
+	var count int64
+	err := d.runQuery(ctx, func(tx *pgxpool.Conn) (err error) {
+		cmd, err := tx.Exec(ctx, sqlUserSchoolsCustomRoleSet, userId, schoolId, baseRole, customRoleCode)
+		if err != nil {
+			return err
+		}
+		count = cmd.RowsAffected()
+		return
+	})
+	if err != nil {
+		utils.LoggerDesc("Query error").Error(err)
+		return 0, err
+	}
+	return count, nil
+}
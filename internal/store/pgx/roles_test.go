@@ -0,0 +1,48 @@
+package pgx
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/mekdep/server/internal/models"
+)
+
+type fakeRow struct {
+	values []interface{}
+}
+
+func (r fakeRow) Scan(dest ...interface{}) error {
+	if len(dest) != len(r.values) {
+		return fmt.Errorf("scan: %d columns, %d destinations", len(r.values), len(dest))
+	}
+	for i, v := range r.values {
+		switch d := dest[i].(type) {
+		case *string:
+			*d = v.(string)
+		case **string:
+			*d = v.(*string)
+		case **time.Time:
+			*d = v.(*time.Time)
+		}
+	}
+	return nil
+}
+
+func TestScanRolePermission(t *testing.T) {
+	schoolId := "c2d5b3a0-0000-0000-0000-000000000001"
+	now := time.Now()
+	row := fakeRow{values: []interface{}{"1", "librarian", &schoolId, "books", "write", &now}}
+
+	m := models.RolePermission{}
+	err := scanRolePermission(row, &m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.ID != "1" || m.RoleCode != "librarian" || m.Permission != "books" || m.Access != "write" {
+		t.Errorf("unexpected grant: %+v", m)
+	}
+	if m.SchoolId == nil || *m.SchoolId != schoolId || m.UpdatedAt == nil {
+		t.Errorf("unexpected grant school or time: %+v", m)
+	}
+}
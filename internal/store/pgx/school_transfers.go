@@ -14,12 +14,12 @@ import (
 )
 
 const sqlSchoolTransfersTable = `school_transfers st`
-const sqlSchoolTransferFields = `st.uid, st.student_uid, st.target_school_uid, st.source_school_uid, st.target_classroom_uid, st.source_classroom_uid, st.sender_note, st.sender_files, st.receiver_note, st.sent_by, st.received_by, st.status, st.created_at, st.updated_at`
+const sqlSchoolTransferFields = `st.uid, st.student_uid, st.target_school_uid, st.source_school_uid, st.target_classroom_uid, st.source_classroom_uid, st.sender_note, st.sender_files, st.receiver_note, st.sent_by, st.received_by, st.status, st.created_at, st.updated_at, st.expires_at`
 
 func scanSchoolTransfer(rows pgx.Row, model *models.SchoolTransfer, addColumns ...interface{}) error {
 	scanColumns := append([]interface{}{
 		&model.ID, &model.StudentId, &model.TargetSchoolId, &model.SourceSchoolId, &model.TargetClassroomId, &model.SourceClassroomId, &model.SenderNote,
-		&model.SenderFiles, &model.ReceiverNote, &model.SentBy, &model.ReceivedBy, &model.Status, &model.CreatedAt, &model.UpdatedAt,
+		&model.SenderFiles, &model.ReceiverNote, &model.SentBy, &model.ReceivedBy, &model.Status, &model.CreatedAt, &model.UpdatedAt, &model.ExpiresAt,
 	}, addColumns...)
 	return rows.Scan(scanColumns...)
 }
@@ -79,7 +79,7 @@ func schoolTransfersFindBySql(opts map[string]interface{}) (sql string, sqlArgs
 	}
 	if v, ok := opts["ids"]; ok {
 		sqlArgs = append(sqlArgs, v)
-		sqlWheres += fmt.Sprintf(` AND te.uid = ANY($%v::uuid[])`, len(sqlArgs))
+		sqlWheres += fmt.Sprintf(` AND st.uid = ANY($%v::uuid[])`, len(sqlArgs))
 	}
 	if v, ok := opts["student_id"]; ok {
 		sqlArgs = append(sqlArgs, v.(string))
@@ -97,7 +97,7 @@ func schoolTransfersFindBySql(opts map[string]interface{}) (sql string, sqlArgs
 		sqlArgs = append(sqlArgs, v.(string))
 		sqlWheres += fmt.Sprintf(` AND st.status = $%v`, len(sqlArgs))
 	}
-	sqlWheres += " GROUP BY st.uid"
+	sqlWheres += " GROUP BY st.uid ORDER BY st.created_at DESC"
 	if v, ok := opts["limit"].(int); ok {
 		sqlLimit = v
 	}
@@ -148,7 +148,7 @@ func schoolTransferUpdateSql(data *models.SchoolTransfer) (sql string, sqlArgs [
 
 	sqlSets := ""
 	for key, value := range dataMap {
-		if value == nil || slices.Contains([]string{"id"}, key) {
+		if value == nil || slices.Contains([]string{"id", "created_at", "updated_at", "expires_at"}, key) {
 			continue
 		}
 		if key == "target_classroom_id" {
@@ -157,7 +157,7 @@ func schoolTransferUpdateSql(data *models.SchoolTransfer) (sql string, sqlArgs [
 		sqlArgs = append(sqlArgs, value)
 		sqlSets += fmt.Sprintf("%v=$%v, ", key, len(sqlArgs))
 	}
-	sqlSets = strings.TrimRight(sqlSets, ", ")
+	sqlSets += "updated_at=CURRENT_TIMESTAMP"
 
 	sqlTableParts := strings.Split(sqlSchoolTransfersTable, " ")
 	sqlTable := sqlTableParts[0]
@@ -166,6 +166,37 @@ func schoolTransferUpdateSql(data *models.SchoolTransfer) (sql string, sqlArgs [
 	return sql, sqlArgs
 }
 
+// SchoolTransfersExpire sets expired status of waiting transfers after expires_at
+func (d *PgxStore) SchoolTransfersExpire(ctx context.Context) (list *models.SchoolTransfers, err error) {
+	list = &models.SchoolTransfers{
+		SchoolTransfers: []*models.SchoolTransfer{},
+	}
+	err = d.runQuery(ctx, func(tx *pgxpool.Conn) (err error) {
+		sql := fmt.Sprintf(`UPDATE %v SET status=$1, updated_at=CURRENT_TIMESTAMP
+			WHERE st.status=$2 AND st.expires_at < CURRENT_TIMESTAMP RETURNING %v`, sqlSchoolTransfersTable, sqlSchoolTransferFields)
+		rows, err := tx.Query(ctx, sql, string(models.StatusExpired), string(models.StatusWaiting))
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			model := &models.SchoolTransfer{}
+			err = scanSchoolTransfer(rows, model)
+			if err != nil {
+				return err
+			}
+			list.SchoolTransfers = append(list.SchoolTransfers, model)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		utils.LoggerDesc("Update Query error").Error(err)
+		return nil, err
+	}
+	list.Total = len(list.SchoolTransfers)
+	return list, nil
+}
+
 func (d *PgxStore) SchoolTransfersInsert(ctx context.Context, data *models.SchoolTransfer) (model *models.SchoolTransfer, err error) {
 	err = d.runQuery(ctx, func(tx *pgxpool.Conn) (err error) {
 		sql, sqlArgs := schoolTransferInsertSql(data)
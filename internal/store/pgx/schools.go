@@ -14,7 +14,7 @@ import (
 )
 
 const sqlSchoolFields = sqlSchoolFieldsNoRelation + `, null, null`
-const sqlSchoolFieldsNoRelation = `s.uid, s.code, s.name, s.full_name, s.description, s.address, s.avatar, s.background, s.phone, s.email, s.level, s.galleries, s.latitude, s.longitude, s.is_digitalized, s.is_secondary_school, s.parent_uid, s.admin_uid, s.specialist_uid, s.updated_at, s.created_at, s.archived_at`
+const sqlSchoolFieldsNoRelation = `s.uid, s.code, s.name, s.full_name, s.description, s.address, s.avatar, s.background, s.phone, s.email, s.level, s.galleries, s.latitude, s.longitude, s.is_digitalized, s.is_secondary_school, s.parent_uid, s.admin_uid, s.specialist_uid, s.updated_at, s.created_at, s.archived_at, s.timezone`
 const sqlSchoolSelect = `select ` + sqlSchoolFields + ` from schools s where s.uid = ANY($1::uuid[])`
 const sqlSchoolSelectByCode = `select ` + sqlSchoolFields + ` from schools s where code = ANY($1::text[])`
 const sqlSchoolSelectMany = `select ` + sqlSchoolFieldsNoRelation + `, count(distinct tt.uid) as timetables_count, count(c.uid) as classrooms_count, count(1) over() as total from schools s 
@@ -295,6 +295,13 @@ func SchoolAtomicQuery(m *models.School, isCreate bool) map[string]interface{} {
 			q["archived_at"] = m.ArchivedAt
 		}
 	}
+	if m.Timezone != nil {
+		if *m.Timezone == "" {
+			q["timezone"] = nil
+		} else {
+			q["timezone"] = m.Timezone
+		}
+	}
 	if m.ParentUid != nil {
 		q["parent_uid"] = m.ParentUid
 	}
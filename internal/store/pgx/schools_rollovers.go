@@ -0,0 +1,158 @@
+package pgx
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/mekdep/server/internal/models"
+	"github.com/mekdep/server/internal/utils"
+)
+
+const sqlRolloverFields = `sr.uid, sr.school_uid, sr.from_period_uid, sr.to_period_uid, sr.classrooms, sr.created_by, sr.created_at`
+const sqlRolloverSelectMany = `select ` + sqlRolloverFields + `, count(*) over() as total from school_rollovers sr where sr.uid=sr.uid`
+const sqlRolloverInsert = `insert into school_rollovers (school_uid, from_period_uid, to_period_uid, classrooms, created_by)
+	values ($1, $2, $3, $4, $5) returning uid, created_at`
+const sqlRolloverClassroomArchive = `update classrooms set archived_at=$2, updated_at=$2 where uid=$1`
+
+// students with sub groups and their tariffs are copied to successor classroom,
+// rows of old classroom are kept for journal and grades of closed year
+const sqlRolloverStudentsCopy = `insert into user_classrooms (user_uid, classroom_uid, type, type_key, tariff_type, tariff_end_at)
+	select user_uid, $2, type, type_key, tariff_type, tariff_end_at from user_classrooms where classroom_uid=$1`
+const sqlRolloverPaymentsCopy = `insert into user_payments (user_uid, classroom_uid, expires_at)
+	select user_uid, $2, expires_at from user_payments where classroom_uid=$1 on conflict do nothing`
+
+func scanRollover(rows pgx.Row, m *models.Rollover, addColumns ...interface{}) (err error) {
+	err = rows.Scan(parseColumnsForScan(m, addColumns...)...)
+	return
+}
+
+// RolloversCommit creates next period and successor classrooms with subjects, copies students
+// and archives old classrooms in one transaction, classrooms of m are ordered parents first
+func (d *PgxStore) RolloversCommit(ctx context.Context, m *models.Rollover, period *models.Period, successors map[string]*models.Classroom) error {
+	now := time.Now()
+	err := d.runInTx(ctx, func(tx pgx.Tx) (rollback bool, err error) {
+		qs, args := PeriodsCreateQuery(period)
+		err = tx.QueryRow(ctx, qs+" RETURNING uid", args...).Scan(&period.ID)
+		if err != nil {
+			return true, err
+		}
+		m.ToPeriodId = &period.ID
+		classroomIds := map[string]string{}
+		for k, item := range m.Classrooms {
+			if item.IsSkipped {
+				continue
+			}
+			_, err = tx.Exec(ctx, sqlRolloverClassroomArchive, item.ClassroomId, now)
+			if err != nil {
+				return true, err
+			}
+			successor, ok := successors[item.ClassroomId]
+			if item.IsGraduating || !ok {
+				continue
+			}
+			successor.PeriodId = &period.ID
+			if successor.ParentId != nil {
+				if id, ok := classroomIds[*successor.ParentId]; ok {
+					successor.ParentId = &id
+				} else {
+					successor.ParentId = nil
+				}
+			}
+			qs, args = ClassroomsCreateQuery(successor)
+			err = tx.QueryRow(ctx, qs+" RETURNING uid", args...).Scan(&successor.ID)
+			if err != nil {
+				return true, err
+			}
+			classroomIds[item.ClassroomId] = successor.ID
+			m.Classrooms[k].SuccessorId = &successor.ID
+
+			_, err = tx.Exec(ctx, sqlRolloverStudentsCopy, item.ClassroomId, successor.ID)
+			if err != nil {
+				return true, err
+			}
+			_, err = tx.Exec(ctx, sqlRolloverPaymentsCopy, item.ClassroomId, successor.ID)
+			if err != nil {
+				return true, err
+			}
+			// subjects are ordered parents first, ids are of old classroom
+			subjectIds := map[string]string{}
+			for _, subject := range successor.Subjects {
+				oldId := subject.ID
+				subject.ClassroomId = successor.ID
+				subject.SchoolId = successor.SchoolId
+				if subject.ParentId != nil {
+					if id, ok := subjectIds[*subject.ParentId]; ok {
+						subject.ParentId = &id
+					} else {
+						subject.ParentId = nil
+					}
+				}
+				qs, args = SubjectsCreateQuery(subject)
+				err = tx.QueryRow(ctx, qs+" RETURNING uid", args...).Scan(&subject.ID)
+				if err != nil {
+					return true, err
+				}
+				subjectIds[oldId] = subject.ID
+			}
+		}
+		err = tx.QueryRow(ctx, sqlRolloverInsert, m.SchoolId, m.FromPeriodId, m.ToPeriodId, m.Classrooms, m.CreatedBy).Scan(&m.ID, &m.CreatedAt)
+		if err != nil {
+			return true, err
+		}
+		return false, nil
+	})
+	if err != nil {
+		utils.LoggerDesc("Query error").Error(err)
+		return err
+	}
+	return nil
+}
+
+func (d *PgxStore) RolloversFindBy(ctx context.Context, f models.RolloverFilterRequest) ([]*models.Rollover, int, error) {
+	args := []interface{}{}
+	wheres := ""
+	if f.SchoolId != nil {
+		args = append(args, *f.SchoolId)
+		wheres += " and sr.school_uid=$" + strconv.Itoa(len(args))
+	}
+	if f.SchoolIds != nil {
+		args = append(args, *f.SchoolIds)
+		wheres += " and sr.school_uid=ANY($" + strconv.Itoa(len(args)) + "::uuid[])"
+	}
+	if f.Limit == nil {
+		f.Limit = new(int)
+		*f.Limit = 12
+	}
+	if f.Offset == nil {
+		f.Offset = new(int)
+	}
+	args = append(args, *f.Limit, *f.Offset)
+	qs := sqlRolloverSelectMany + wheres + " order by sr.created_at desc limit $" + strconv.Itoa(len(args)-1) + " offset $" + strconv.Itoa(len(args))
+	items := []*models.Rollover{}
+	total := 0
+	err := d.runQuery(ctx, func(tx *pgxpool.Conn) (err error) {
+		rows, err := tx.Query(ctx, qs, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			item := models.Rollover{}
+			err = scanRollover(rows, &item, &total)
+			if err != nil {
+				utils.LoggerDesc("Scan error").Error(err)
+				return err
+			}
+			items = append(items, &item)
+		}
+		return
+	})
+	if err != nil {
+		utils.LoggerDesc("Query error").Error(err)
+		return nil, 0, err
+	}
+	return items, total, nil
+}
@@ -13,10 +13,11 @@ import (
 	"github.com/mekdep/server/internal/utils"
 )
 
-const sqlSmsSenderFields = `ss.uid, ss.phones, ss.message, ss.type, ss.error_msg, ss.is_completed, ss.left_try, ss.tried_at, ss.created_at`
+const sqlSmsSenderFields = `ss.uid, ss.phones, ss.message, ss.type, ss.error_msg, ss.is_completed, ss.left_try, ss.tried_at, ss.created_at, ss.send_at`
 const sqlSmsSenderSelect = `SELECT ` + sqlSmsSenderFields + ` FROM sms_sender ss WHERE ss.uid = ANY($1::uuid[])`
 const sqlSmsSenderSelectMany = `SELECT ` + sqlSmsSenderFields + `, count(*) over() as total FROM sms_sender ss where ss.uid=ss.uid limit $1 offset $2 `
 const sqlSmsSenderInsert = `INSERT INTO sms_sender`
+const sqlSmsSenderUpdate = `UPDATE sms_sender ss set uid=uid`
 
 func scanSmsSender(rows pgx.Row, m *models.SmsSender, addColumns ...interface{}) (err error) {
 	err = rows.Scan(parseColumnsForScan(m, addColumns...)...)
@@ -97,6 +98,36 @@ func (d *PgxStore) SmsSenderCreate(ctx context.Context, model *models.SmsSender)
 	return editModel, nil
 }
 
+func (d *PgxStore) SmsSenderUpdate(ctx context.Context, model *models.SmsSender) (*models.SmsSender, error) {
+	qs, args := SmsSenderUpdateQuery(model)
+	err := d.runQuery(ctx, func(tx *pgxpool.Conn) (err error) {
+		_, err = tx.Exec(ctx, qs, args...)
+		return
+	})
+	if err != nil {
+		utils.LoggerDesc("Query error").Error(err)
+		return nil, err
+	}
+	editModel, err := d.SmsSendersFindById(ctx, model.ID)
+	if err != nil {
+		return nil, err
+	}
+	return editModel, nil
+}
+
+func SmsSenderUpdateQuery(m *models.SmsSender) (string, []interface{}) {
+	args := []interface{}{}
+	sets := ""
+	q := SmsSenderAtomicQuery(m, false)
+	for k, v := range q {
+		args = append(args, v)
+		sets += ", " + k + "=$" + strconv.Itoa(len(args))
+	}
+	args = append(args, m.ID)
+	qs := strings.ReplaceAll(sqlSmsSenderUpdate, "set uid=uid", "set uid=uid"+sets+" ") + " where uid=$" + strconv.Itoa(len(args))
+	return qs, args
+}
+
 func SmsSenderCreateQuery(m *models.SmsSender) (string, []interface{}) {
 	args := []interface{}{}
 	cols := ""
@@ -123,14 +154,17 @@ func SmsSenderAtomicQuery(m *models.SmsSender, isCreate bool) map[string]interfa
 		q["type"] = m.Type
 	}
 	if m.ErrorMsg != nil {
-		q["error_msg"] = m.Type
+		q["error_msg"] = m.ErrorMsg
 	}
 	q["is_completed"] = m.IsCompleted
 	q["left_try"] = m.LeftTry
 	if isCreate {
 		q["created_at"] = time.Now()
+		q["send_at"] = m.SendAt
+	}
+	if m.SendAt == nil || !isCreate {
+		q["tried_at"] = time.Now()
 	}
-	q["tried_at"] = time.Now()
 	return q
 }
 
@@ -141,6 +175,10 @@ func SmsSendersListBuildQuery(f models.SmsSenderFilterRequest, args []interface{
 		args = append(args, *f.ID)
 		wheres += " and ss.uid=$" + strconv.Itoa(len(args))
 	}
+	if f.SendBefore != nil {
+		args = append(args, *f.SendBefore)
+		wheres += " and ss.is_completed=false and ss.left_try > 0 and ss.send_at <= $" + strconv.Itoa(len(args))
+	}
 	wheres += " group by ss.uid "
 	wheres += " order by ss.created_at desc"
 
@@ -10,6 +10,7 @@ import (
 	"github.com/jackc/pgx/v4/pgxpool"
 	"github.com/mekdep/server/config"
 	"github.com/mekdep/server/internal/models"
+	"github.com/prometheus/client_golang/prometheus"
 	"go.elastic.co/apm/module/apmpgx/v2"
 )
 
@@ -38,6 +39,7 @@ func Init() *PgxStore {
 	if err != nil {
 		log.Fatal(err)
 	}
+	prometheus.MustRegister(newPoolCollector(pool))
 	return &PgxStore{pool: pool}
 }
 
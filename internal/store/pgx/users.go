@@ -36,7 +36,7 @@ const sqlUserSelectMany = `select ` + sqlUserFieldsClassroom + `, count(u.uid) o
 	left join schools s on us.school_uid=s.uid 
 	left join schools sp on s.uid=sp.parent_uid
 	left join user_classrooms uc on uc.user_uid=u.uid
-	left join classrooms cc on cc.uid=uc.classroom_uid
+	left join classrooms cc on cc.uid=uc.classroom_uid and (cc.archived_at is null or ` + sqlUserClassroomNoCurrent + `)
 	left join classrooms c on c.teacher_uid=u.uid
 	left join user_payments p on p.user_uid=u.uid and p.classroom_uid=uc.classroom_uid
 	where u.uid=u.uid limit $1 offset $2`
@@ -96,7 +96,7 @@ const sqlUserChildren = `select ` + sqlUserFieldsClassroom + `, array_agg(distin
 	left join schools sp on s.uid=sp.parent_uid
 	right join users u on up.child_uid=u.uid 
 	left join user_classrooms uc on uc.user_uid=u.uid
-	left join classrooms cc on cc.uid=uc.classroom_uid
+	left join classrooms cc on cc.uid=uc.classroom_uid and (cc.archived_at is null or ` + sqlUserClassroomNoCurrent + `)
 	where up.parent_uid = ANY($1::uuid[]) 
 	group by u.uid`
 const sqlUserParentsDelete = `delete from user_parents up using user_schools us where up.child_uid=us.user_uid and us.school_uid = $2 and up.parent_uid=$1`
@@ -110,17 +110,22 @@ const sqlUserTeacherClassroom = `select ` + sqlClassroomFields + `, u.uid from u
 
 const sqlUserChildrenDelete = `delete from user_parents where child_uid=$1`
 const sqlUserChildrenInsert = `insert into user_parents (child_uid, parent_uid, school_uid) values ($1, $2, $3)`
-const sqlUserSchools = `select us.role_code, us.user_uid, ` + sqlSchoolFields + `   from user_schools us 
+const sqlUserSchools = `select us.role_code, us.custom_role_code, us.user_uid, ` + sqlSchoolFields + `   from user_schools us 
 	left join schools s on us.school_uid=s.uid  where us.user_uid = ANY($1::uuid[])`
 const sqlUserSchoolsDelete = `delete from user_schools where user_uid=$1`
 const sqlUserSchoolsDeleteBySchool = `delete from user_schools where user_uid=ANY($1::uuid[]) and COALESCE(school_uid,'00000000-0000-0000-0000-000000000000'::uuid)=ANY($2::uuid[])`
 const sqlUserSchoolsDeleteByRole = `delete from user_schools where user_uid=ANY($1::uuid[]) and school_uid=ANY($2::uuid[]) and role_code=ANY($3::text[])`
 const sqlUserParentsDeleteByRole = `delete from user_parents where parent_uid=ANY($1::uuid[]) and school_uid=ANY($2::uuid[])`
-const sqlUserSchoolsInsert = `insert into user_schools (user_uid, school_uid, role_code) values ($1, $2, $3)`
+const sqlUserSchoolsInsert = `insert into user_schools (user_uid, school_uid, role_code, custom_role_code) values ($1, $2, $3, $4)`
+
+// classroom of closed year is of student only when there is no classroom in current year (see RolloversCommit),
+// it has no where as UsersListBuildQuery adds joins before every where
+const sqlUserClassroomNoCurrent = `not exists (select 1 from user_classrooms ucl join classrooms cl on cl.uid=ucl.classroom_uid
+	and ucl.user_uid=uc.user_uid and ucl.type is null and ucl.type_key is null and cl.archived_at is null)`
 const sqlUserClassrooms = `select ` + sqlClassroomFields + `, uc.user_uid, uc.type, p.expires_at, 'plus' from user_classrooms uc 
 	left join user_payments p on p.user_uid=uc.user_uid and p.classroom_uid=uc.classroom_uid
 	right join classrooms c on uc.classroom_uid=c.uid  
-	where uc.user_uid = ANY($1::uuid[]) and uc.type is null and uc.type_key is null`
+	where uc.user_uid = ANY($1::uuid[]) and uc.type is null and uc.type_key is null and (c.archived_at is null or ` + sqlUserClassroomNoCurrent + `)`
 const sqlUserClassroomsAll = `select ` + sqlClassroomFields + `, uc.user_uid, uc.type, p.expires_at, 'plus' from user_classrooms uc 
 	left join user_payments p on p.user_uid=uc.user_uid and p.classroom_uid=uc.classroom_uid
 	right join classrooms c on uc.classroom_uid=c.uid  
@@ -216,10 +221,15 @@ func (d *PgxStore) GetDateUserPayment(ctx context.Context, userUid string, class
 func (d *PgxStore) UsersFindByUsername(ctx context.Context, username string, schoolId *string, onlyAdmin bool) (models.User, error) {
 	var user models.User
 	var role string
+	// phone is entered as local number
+	phone := username
+	if p, err := models.PhoneE164(username); err == nil {
+		phone = p
+	}
 	// first try to get default search by username
 	err := d.runQuery(ctx, func(tx *pgxpool.Conn) (err error) {
 		wheres := ""
-		args := []interface{}{1, 0, username}
+		args := []interface{}{1, 0, username, phone}
 		if schoolId != nil {
 			if onlyAdmin {
 				wheres += " and (us.role_code='" + string(models.RoleAdmin) + "' or us.role_code='" + string(models.RoleOrganization) + "' or us.role_code='" + string(models.RoleOperator) + "' )"
@@ -230,7 +240,7 @@ func (d *PgxStore) UsersFindByUsername(ctx context.Context, username string, sch
 		}
 		qs := strings.ReplaceAll(`select `+sqlUserFields+`, max(us.role_code) from users u 
 			RIGHT JOIN user_schools us on us.user_uid=u.uid 
-			WHERE (username=REPLACE(REPLACE(REPLACE(REPLACE(REPLACE(REPLACE(LOWER($3), 'ý', 'y'), 'ö', 'o'), 'ä', 'a'), 'ň', 'n'), 'ü', 'u'), 'ç', 'c') or phone=$3) 
+			WHERE (username=REPLACE(REPLACE(REPLACE(REPLACE(REPLACE(REPLACE(LOWER($3), 'ý', 'y'), 'ö', 'o'), 'ä', 'a'), 'ň', 'n'), 'ü', 'u'), 'ç', 'c') or phone=$4) 
 				AND 1=1 
 			GROUP BY u.uid	
 			LIMIT $1 OFFSET $2`, "1=1", "1=1"+wheres)
@@ -263,7 +273,7 @@ func (d *PgxStore) UsersFindByUsername(ctx context.Context, username string, sch
 			RIGHT JOIN user_parents up ON up.parent_uid = u.uid
 			WHERE phone=$1 and up.school_uid=$2
 			GROUP BY u.uid`
-		args := []interface{}{username, schoolId}
+		args := []interface{}{phone, schoolId}
 
 		err = scanUser(tx.QueryRow(ctx, qs, args...), &parent)
 		if err != nil {
@@ -397,7 +407,7 @@ func (d *PgxStore) UserUpdateRelations(ctx context.Context, model *models.User)
 				continue
 			}
 			err = d.runQuery(ctx, func(tx *pgxpool.Conn) (err error) {
-				_, err = tx.Query(ctx, sqlUserSchoolsInsert, model.ID, c.SchoolUid, c.RoleCode)
+				_, err = tx.Query(ctx, sqlUserSchoolsInsert, model.ID, c.SchoolUid, c.RoleCode, c.CustomRoleCode)
 				return
 			})
 			if err != nil {
@@ -639,7 +649,7 @@ func (d *PgxStore) MapOldSubjectsToNewSubjectsInPeriodGrade(
 
 func (d *PgxStore) UserChangeSchoolAndClassroom(ctx context.Context, studentId, schoolId, classroomId *string) error {
 	err := d.runQuery(ctx, func(tx *pgxpool.Conn) (err error) {
-		_, err = tx.Query(ctx, sqlUserSchoolsInsert, studentId, schoolId, models.RoleStudent)
+		_, err = tx.Query(ctx, sqlUserSchoolsInsert, studentId, schoolId, models.RoleStudent, nil)
 		return
 	})
 	if err != nil {
@@ -658,6 +668,33 @@ func (d *PgxStore) UserChangeSchoolAndClassroom(ctx context.Context, studentId,
 	return nil
 }
 
+const sqlUserParentsRemove = `delete from user_parents where parent_uid=$1 and child_uid=ANY($2::uuid[])`
+const sqlUserParentsAdd = `insert into user_parents (parent_uid, child_uid, school_uid) select $1, $2, $3
+	where not exists (select 1 from user_parents where parent_uid=$1 and child_uid=$2)`
+
+// UserParentsChange links and unlinks children of parent, other children are kept
+func (d *PgxStore) UserParentsChange(ctx context.Context, parentId string, schoolId *string, addIds []string, removeIds []string) error {
+	err := d.runInTx(ctx, func(tx pgx.Tx) (rollback bool, err error) {
+		if len(removeIds) > 0 {
+			_, err = tx.Exec(ctx, sqlUserParentsRemove, parentId, removeIds)
+			if err != nil {
+				return true, err
+			}
+		}
+		for _, childId := range addIds {
+			_, err = tx.Exec(ctx, sqlUserParentsAdd, parentId, childId, schoolId)
+			if err != nil {
+				return true, err
+			}
+		}
+		return false, nil
+	})
+	if err != nil {
+		utils.LoggerDesc("Query error").Error(err)
+	}
+	return err
+}
+
 func (d PgxStore) UserCreate(ctx context.Context, model *models.User) (*models.User, error) {
 	qs, args := UserCreateQuery(model)
 	qs += " RETURNING uid"
@@ -809,8 +846,8 @@ func (d *PgxStore) UsersLoadRelations(ctx context.Context, users *[]*models.User
 			userSchool := models.UserSchool{}
 			mid := ""
 			// scan 2 or more if there is also school cols
-			cols := []interface{}{&userSchool.RoleCode, &mid}
-			if vl, _ := rows.Values(); vl[2] != nil {
+			cols := []interface{}{&userSchool.RoleCode, &userSchool.CustomRoleCode, &mid}
+			if vl, _ := rows.Values(); vl[3] != nil {
 				cols = append(cols, parseColumnsForScan(school)...)
 			} else {
 				var t interface{}
@@ -836,10 +873,11 @@ func (d *PgxStore) UsersLoadRelations(ctx context.Context, users *[]*models.User
 						schoolUid = &school.ID
 					}
 					user.Schools = append(user.Schools, &models.UserSchool{
-						SchoolUid: schoolUid,
-						UserId:    user.ID,
-						School:    school,
-						RoleCode:  userSchool.RoleCode,
+						SchoolUid:      schoolUid,
+						UserId:         user.ID,
+						School:         school,
+						RoleCode:       userSchool.RoleCode,
+						CustomRoleCode: userSchool.CustomRoleCode,
 					})
 				}
 			}
@@ -1397,9 +1435,17 @@ func UsersListBuildQuery(f models.UserFilterRequest, args []interface{}) (string
 		wheres += " and uc.classroom_uid=$" + strconv.Itoa(len(args))
 	}
 	if f.Phone != nil && *f.Phone != "" {
-		args = append(args, *f.Phone)
+		phone := *f.Phone
+		if p, err := models.PhoneE164(phone); err == nil {
+			phone = p
+		}
+		args = append(args, phone)
 		wheres += " and u.phone=$" + strconv.Itoa(len(args))
 	}
+	if f.BirthCertNumber != nil && *f.BirthCertNumber != "" {
+		args = append(args, strings.ToLower(strings.ReplaceAll(*f.BirthCertNumber, " ", "")))
+		wheres += " and lower(replace(u.birth_cert_number, ' ', ''))=$" + strconv.Itoa(len(args))
+	}
 	if f.FirstName != nil && *f.FirstName != "" {
 		args = append(args, *f.FirstName)
 		wheres += " and first_name=$" + strconv.Itoa(len(args))
@@ -1437,6 +1483,27 @@ func UsersListBuildQuery(f models.UserFilterRequest, args []interface{}) (string
 			}
 		}
 	}
+	if f.ClassroomIds != nil || f.TariffTypes != nil {
+		isParent := (f.Role != nil && *f.Role == string(models.RoleParent)) || (f.Roles != nil && slices.Contains(*f.Roles, string(models.RoleParent)))
+		isTeacher := (f.Role != nil && *f.Role == string(models.RoleTeacher)) || (f.Roles != nil && slices.Contains(*f.Roles, string(models.RoleTeacher)))
+		ucAlias := "uc"
+		if isParent {
+			isParentClassroomJoin = true
+			ucAlias = "uc_child"
+		}
+		if f.ClassroomIds != nil {
+			args = append(args, *f.ClassroomIds)
+			if isTeacher {
+				wheres += " and c.uid = ANY($" + strconv.Itoa(len(args)) + "::uuid[])"
+			} else {
+				wheres += " and " + ucAlias + ".classroom_uid = ANY($" + strconv.Itoa(len(args)) + "::uuid[])"
+			}
+		}
+		if f.TariffTypes != nil {
+			args = append(args, *f.TariffTypes)
+			wheres += " and " + ucAlias + ".tariff_type = ANY($" + strconv.Itoa(len(args)) + "::text[])"
+		}
+	}
 	if f.ClassroomType != nil {
 		args = append(args, *f.ClassroomType)
 		wheres += " and uc.type=$" + strconv.Itoa(len(args))
@@ -1459,6 +1526,13 @@ func UsersListBuildQuery(f models.UserFilterRequest, args []interface{}) (string
 		args = append(args, *f.ClassroomTypeKey)
 		wheres += " and uc.type_key=$" + strconv.Itoa(len(args))
 	}
+	if f.EmailVerified != nil {
+		if *f.EmailVerified {
+			wheres += " and u.email is not null and u.email_verified_at is not null"
+		} else {
+			wheres += " and u.email_verified_at is null"
+		}
+	}
 	if f.Search != nil && *f.Search != "" {
 		*f.Search = strings.ToLower(*f.Search)
 		args = append(args, *f.Search)
@@ -96,22 +96,38 @@ func (d *PgxStore) ConfirmCodeDelete(ctx context.Context, id string) error {
 }
 
 func (d *PgxStore) CheckConfirmCode(ctx context.Context, m *models.User, code string) (string, error) {
-	err := d.ConfirmCodeClear(ctx, m)
+	codeId, userId, err := d.confirmCodeFind(ctx, m, code)
+	if err != nil || codeId == "" {
+		return userId, err
+	}
+	err = d.ConfirmCodeDelete(ctx, codeId)
 	if err != nil {
 		return "", err
 	}
+	return userId, nil
+}
+
+// ConfirmCodeFind checks code without using it, id is empty when validation is off
+func (d *PgxStore) ConfirmCodeFind(ctx context.Context, m *models.User, code string) (string, error) {
+	codeId, _, err := d.confirmCodeFind(ctx, m, code)
+	return codeId, err
+}
+
+func (d *PgxStore) confirmCodeFind(ctx context.Context, m *models.User, code string) (codeId string, userId string, err error) {
+	err = d.ConfirmCodeClear(ctx, m)
+	if err != nil {
+		return "", "", err
+	}
 
 	phone, err := m.FormattedPhone()
 	if err != nil {
-		return "", nil
+		return "", "", nil
 	}
 
 	if !*config.Conf.OtpValidationEnabled {
-		return "", nil
+		return "", "", nil
 	}
 
-	var userId, codeId string
-
 	err = d.runQuery(ctx, func(tx *pgxpool.Conn) (err error) {
 		err = tx.QueryRow(ctx, SQL_CONFIRM_CODE_LIST, phone, code).Scan(&codeId, &userId)
 		return
@@ -120,14 +136,9 @@ func (d *PgxStore) CheckConfirmCode(ctx context.Context, m *models.User, code st
 		if err != pgx.ErrNoRows {
 			utils.LoggerDesc("Query error").Error(err)
 		}
-		return "", err
+		return "", "", err
 	}
-
-	err = d.ConfirmCodeDelete(ctx, codeId)
-	if err != nil {
-		return "", err
-	}
-	return userId, nil
+	return codeId, userId, nil
 }
 
 func (d *PgxStore) SessionsSelect(ctx context.Context, f models.SessionFilter) ([]models.Session, error) {
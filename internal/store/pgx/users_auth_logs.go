@@ -401,7 +401,7 @@ func (d *PgxStore) UserLogLoadSession(ctx context.Context, ids []string) ([]User
 		rows, err := tx.Query(ctx, sqlUserLogSession, (ids))
 		for rows.Next() {
 			sub := models.Session{}
-			pid := string(0)
+			pid := ""
 			err = scanSession(rows, &sub, &pid)
 			if err != nil {
 				utils.LoggerDesc("Scan error").Error(err)
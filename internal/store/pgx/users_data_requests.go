@@ -0,0 +1,338 @@
+package pgx
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/mekdep/server/internal/models"
+	"github.com/mekdep/server/internal/utils"
+)
+
+const sqlUserDataRequestFields = `dr.uid, dr.user_uid, dr.type, dr.status, dr.file, dr.reason, dr.error, dr.requested_by, dr.reviewed_by, dr.reviewed_at,
+	dr.expires_at, dr.created_at, dr.updated_at, dr.done_at`
+const sqlUserDataRequestSelect = `select ` + sqlUserDataRequestFields + ` from user_data_requests dr where dr.uid=$1`
+const sqlUserDataRequestSelectMany = `select ` + sqlUserDataRequestFields + `, count(*) over() as total from user_data_requests dr where dr.uid=dr.uid`
+const sqlUserDataRequestInsert = `insert into user_data_requests (user_uid, type, status, reason, requested_by) values ($1, $2, $3, $4, $5)
+	returning uid, created_at, updated_at`
+const sqlUserDataRequestUpdate = `update user_data_requests set status=$2, file=$3, reason=$4, error=$5, reviewed_by=$6, reviewed_at=$7, expires_at=$8,
+	done_at=$9, updated_at=now() where uid=$1 returning updated_at`
+
+// stuck in processing after restart is taken again
+const sqlUserDataRequestClaim = `update user_data_requests set status='processing', updated_at=now() where uid=$1 and
+	(status='pending' or (status='processing' and updated_at < now() - interval '1 hour'))`
+const sqlUserDataRequestPendingList = `select uid from user_data_requests where status='pending' or
+	(status='processing' and updated_at < now() - interval '1 hour') order by updated_at limit $1`
+const sqlUserDataRequestExpiredList = `select ` + sqlUserDataRequestFields + ` from user_data_requests dr
+	where dr.type='export' and dr.status='done' and dr.expires_at < now() limit $1`
+
+// sqlUserDataImportLine is report line of import about user (t is line), student or its parent
+const sqlUserDataImportLine = `(t->>'user_id'=$1::text or t->'parents' @> jsonb_build_array(jsonb_build_object('user_id', $1::text)))`
+
+// userDataTable is table with rows of user, Where has user as $1, secret columns are not exported,
+// Row replaces whole row when only part of it is about user
+type userDataTable struct {
+	Table   string
+	Where   string
+	Exclude []string
+	Row     string
+}
+
+var userDataTables = []userDataTable{
+	{Table: "users", Where: "uid=$1", Exclude: []string{"password"}},
+	{Table: "user_schools", Where: "user_uid=$1"},
+	{Table: "user_classrooms", Where: "user_uid=$1"},
+	{Table: "user_parents", Where: "parent_uid=$1 or child_uid=$1"},
+	{Table: "user_settings", Where: "user_uid=$1"},
+	{Table: "notification_preferences", Where: "user_uid=$1"},
+	{Table: "grades", Where: "student_uid=$1"},
+	{Table: "absents", Where: "student_uid=$1"},
+	{Table: "period_grades", Where: "student_uid=$1"},
+	{Table: "student_notes", Where: "student_uid=$1"},
+	{Table: "assignment_submissions", Where: "student_uid=$1"},
+	{Table: "teacher_excuses", Where: "teacher_uid=$1"},
+	{Table: "messages", Where: "user_uid=$1"},
+	{Table: "messages_reads", Where: "user_uid=$1"},
+	{Table: "user_payments", Where: "user_uid=$1"},
+	{Table: "payment_transactions", Where: "payer_uid=$1 or $1=ANY(user_uids)"},
+	{Table: "contact_items", Where: "user_uid=$1"},
+	{Table: "contact_replies", Where: "user_uid=$1"},
+	{Table: "sessions", Where: "user_uid=$1", Exclude: []string{"token", "device_token"}},
+	{Table: "login_attempts", Where: "user_uid=$1"},
+	{Table: "user_logs", Where: "user_uid=$1 or subject_uid=$1"},
+	{Table: "user_merges", Where: "survivor_uid=$1 or duplicate_uid=$1"},
+	{Table: "user_imports", Where: "exists (select 1 from jsonb_array_elements(t.report) t where " + sqlUserDataImportLine + ")",
+		Row: "jsonb_build_object('uid', t.uid, 'school_uid', t.school_uid, 'type', t.type, 'file_name', t.file_name, 'created_at', t.created_at, " +
+			"'report', (select jsonb_agg(t) from jsonb_array_elements(t.report) t where " + sqlUserDataImportLine + "))"},
+}
+
+func (t userDataTable) sql() string {
+	row := "to_jsonb(t)"
+	if t.Row != "" {
+		row = t.Row
+	}
+	for _, c := range t.Exclude {
+		row += " - '" + c + "'"
+	}
+	return "select coalesce(jsonb_agg(" + row + "), '[]'::jsonb) from " + t.Table + " t where " + t.Where
+}
+
+// erasure keeps gender, year of birth, district and titles for statistics, relations, grades and payments are kept
+const sqlUserDataEraseFiles = `select avatar, document_files from users where uid=$1 for update`
+const sqlUserDataErase = `update users set first_name='Deleted', last_name='User', middle_name=null,
+	username='deleted_' || replace(uid::text, '-', ''), password=md5(random()::text || uid::text), status='blocked',
+	phone=null, phone_verified_at=null, email=null, email_verified_at=null, birthday=date_trunc('year', birthday)::date,
+	address=null, avatar=null, passport_number=null, birth_cert_number=null, apply_number=null, work_place=null,
+	reference=null, nickname=null, education_place=null, education_group=null, documents=null, document_files=null,
+	archived_at=coalesce(archived_at, now()), updated_at=now() where uid=$1`
+
+// sessions are not deleted: messages are linked to them
+const sqlUserDataEraseSessions = `update sessions set token=md5(random()::text || uid::text), device_token=null, agent='', ip='',
+	exp=least(exp, now()) where user_uid=$1`
+
+var sqlUserDataEraseDeletes = []string{
+	`delete from user_security where user_uid=$1`,
+	`delete from login_attempts where user_uid=$1`,
+	`delete from confirm_codes where user_uid=$1`,
+	`delete from email_tokens where user_uid=$1`,
+	`delete from push_deliveries where user_uid=$1`,
+	`delete from notification_preferences where user_uid=$1`,
+	`delete from user_settings where user_uid=$1`,
+	`delete from user_duplicates where (user_uid=$1 or other_uid=$1) and status='pending'`,
+	// merge can not be undone onto erased user, its moved keys are not needed
+	`delete from user_merges where survivor_uid=$1 or duplicate_uid=$1`,
+	// logs are kept as actions, properties hold fields of user
+	`update user_logs set properties=null, subject_description=null where user_uid=$1 or subject_uid=$1`,
+	// file lines and report lines of imports about user (student or its parent) are emptied
+	`update user_imports ui set
+	rows=coalesce((select jsonb_agg(case when exists (select 1 from jsonb_array_elements(ui.report) t
+		where (t->>'line')::int=r.i+1 and ` + sqlUserDataImportLine + `) then '[]'::jsonb else r.v end order by r.i)
+		from jsonb_array_elements(ui.rows) with ordinality r(v, i)), '[]'),
+	report=coalesce((select jsonb_agg(case when ` + sqlUserDataImportLine + ` then jsonb_build_object('line', t->'line', 'action', t->'action')
+		else t end order by r.i) from jsonb_array_elements(ui.report) with ordinality r(t, i)), '[]'),
+	updated_at=now()
+	where exists (select 1 from jsonb_array_elements(ui.report) t where ` + sqlUserDataImportLine + `)`,
+}
+
+func scanUserDataRequest(rows pgx.Row, m *models.UserDataRequest, addColumns ...interface{}) (err error) {
+	err = rows.Scan(parseColumnsForScan(m, addColumns...)...)
+	return
+}
+
+func (d *PgxStore) UserDataRequestsCreate(ctx context.Context, m *models.UserDataRequest) (*models.UserDataRequest, error) {
+	err := d.runQuery(ctx, func(tx *pgxpool.Conn) (err error) {
+		err = tx.QueryRow(ctx, sqlUserDataRequestInsert, m.UserId, m.Type, m.Status, m.Reason, m.RequestedBy).
+			Scan(&m.ID, &m.CreatedAt, &m.UpdatedAt)
+		return
+	})
+	if err != nil {
+		utils.LoggerDesc("Query error").Error(err)
+		return nil, err
+	}
+	return m, nil
+}
+
+func (d *PgxStore) UserDataRequestsUpdate(ctx context.Context, m *models.UserDataRequest) error {
+	err := d.runQuery(ctx, func(tx *pgxpool.Conn) (err error) {
+		err = tx.QueryRow(ctx, sqlUserDataRequestUpdate, m.ID, m.Status, m.File, m.Reason, m.Error, m.ReviewedBy, m.ReviewedAt, m.ExpiresAt, m.DoneAt).
+			Scan(&m.UpdatedAt)
+		return
+	})
+	if err != nil {
+		utils.LoggerDesc("Query error").Error(err)
+		return err
+	}
+	return nil
+}
+
+func (d *PgxStore) UserDataRequestsFindById(ctx context.Context, id string) (*models.UserDataRequest, error) {
+	m := models.UserDataRequest{}
+	err := d.runQuery(ctx, func(tx *pgxpool.Conn) (err error) {
+		err = scanUserDataRequest(tx.QueryRow(ctx, sqlUserDataRequestSelect, id), &m)
+		return
+	})
+	if err != nil {
+		utils.LoggerDesc("Query error").Error(err)
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (d *PgxStore) UserDataRequestsFindBy(ctx context.Context, f models.UserDataRequestFilterRequest) ([]*models.UserDataRequest, int, error) {
+	args := []interface{}{}
+	wheres := ""
+	if f.UserId != nil && *f.UserId != "" {
+		args = append(args, *f.UserId)
+		wheres += " and dr.user_uid=$" + strconv.Itoa(len(args))
+	}
+	if f.UserIds != nil {
+		args = append(args, *f.UserIds)
+		wheres += " and dr.user_uid=ANY($" + strconv.Itoa(len(args)) + "::uuid[])"
+	}
+	// user or parent of its child is in school
+	if f.SchoolIds != nil {
+		args = append(args, *f.SchoolIds)
+		n := len(args)
+		wheres += " and (exists (select 1 from user_schools us where us.user_uid=dr.user_uid and us.school_uid=ANY($" + strconv.Itoa(n) + "::uuid[]))" +
+			" or exists (select 1 from user_parents up join user_schools us on us.user_uid=up.child_uid where up.parent_uid=dr.user_uid and us.school_uid=ANY($" + strconv.Itoa(n) + "::uuid[])))"
+	}
+	if f.Type != nil && *f.Type != "" {
+		args = append(args, *f.Type)
+		wheres += " and dr.type=$" + strconv.Itoa(len(args))
+	}
+	if f.Status != nil && *f.Status != "" {
+		args = append(args, *f.Status)
+		wheres += " and dr.status=$" + strconv.Itoa(len(args))
+	}
+	if f.Limit == nil {
+		f.Limit = new(int)
+		*f.Limit = 12
+	}
+	if f.Offset == nil {
+		f.Offset = new(int)
+	}
+	args = append(args, *f.Limit, *f.Offset)
+	qs := sqlUserDataRequestSelectMany + wheres + " order by dr.created_at desc limit $" + strconv.Itoa(len(args)-1) + " offset $" + strconv.Itoa(len(args))
+	items := []*models.UserDataRequest{}
+	total := 0
+	err := d.runQuery(ctx, func(tx *pgxpool.Conn) (err error) {
+		rows, err := tx.Query(ctx, qs, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			item := models.UserDataRequest{}
+			err = scanUserDataRequest(rows, &item, &total)
+			if err != nil {
+				utils.LoggerDesc("Scan error").Error(err)
+				return err
+			}
+			items = append(items, &item)
+		}
+		return
+	})
+	if err != nil {
+		utils.LoggerDesc("Query error").Error(err)
+		return nil, 0, err
+	}
+	return items, total, nil
+}
+
+func (d *PgxStore) UserDataRequestsClaim(ctx context.Context, id string) (bool, error) {
+	claimed := false
+	err := d.runQuery(ctx, func(tx *pgxpool.Conn) (err error) {
+		tag, err := tx.Exec(ctx, sqlUserDataRequestClaim, id)
+		claimed = tag.RowsAffected() > 0
+		return
+	})
+	if err != nil {
+		utils.LoggerDesc("Query error").Error(err)
+		return false, err
+	}
+	return claimed, nil
+}
+
+func (d *PgxStore) UserDataRequestsPendingList(ctx context.Context, limit int) ([]string, error) {
+	ids := []string{}
+	err := d.runQuery(ctx, func(tx *pgxpool.Conn) (err error) {
+		rows, err := tx.Query(ctx, sqlUserDataRequestPendingList, limit)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var id string
+			if err = rows.Scan(&id); err != nil {
+				return err
+			}
+			ids = append(ids, id)
+		}
+		return
+	})
+	if err != nil {
+		utils.LoggerDesc("Query error").Error(err)
+		return nil, err
+	}
+	return ids, nil
+}
+
+// UserDataRequestsExpiredList returns done exports which file should be removed
+func (d *PgxStore) UserDataRequestsExpiredList(ctx context.Context, limit int) ([]*models.UserDataRequest, error) {
+	items := []*models.UserDataRequest{}
+	err := d.runQuery(ctx, func(tx *pgxpool.Conn) (err error) {
+		rows, err := tx.Query(ctx, sqlUserDataRequestExpiredList, limit)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			item := models.UserDataRequest{}
+			if err = scanUserDataRequest(rows, &item); err != nil {
+				return err
+			}
+			items = append(items, &item)
+		}
+		return
+	})
+	if err != nil {
+		utils.LoggerDesc("Query error").Error(err)
+		return nil, err
+	}
+	return items, nil
+}
+
+// UserDataDump returns rows of every table holding data of user as json arrays by table name
+func (d *PgxStore) UserDataDump(ctx context.Context, userId string) (map[string]json.RawMessage, error) {
+	res := map[string]json.RawMessage{}
+	err := d.runQuery(ctx, func(tx *pgxpool.Conn) (err error) {
+		for _, t := range userDataTables {
+			var rows []byte
+			if err = tx.QueryRow(ctx, t.sql(), userId).Scan(&rows); err != nil {
+				return err
+			}
+			res[t.Table] = rows
+		}
+		return
+	})
+	if err != nil {
+		utils.LoggerDesc("Query error").Error(err)
+		return nil, err
+	}
+	return res, nil
+}
+
+// UserDataErase anonymises personal fields of user in one transaction, returns its uploaded files to remove
+func (d *PgxStore) UserDataErase(ctx context.Context, userId string) ([]string, error) {
+	files := []string{}
+	err := d.runInTx(ctx, func(tx pgx.Tx) (rollback bool, err error) {
+		var avatar *string
+		var documentFiles []string
+		if err = tx.QueryRow(ctx, sqlUserDataEraseFiles, userId).Scan(&avatar, &documentFiles); err != nil {
+			return true, err
+		}
+		if avatar != nil && *avatar != "" {
+			files = append(files, *avatar)
+		}
+		files = append(files, documentFiles...)
+		if _, err = tx.Exec(ctx, sqlUserDataErase, userId); err != nil {
+			return true, err
+		}
+		if _, err = tx.Exec(ctx, sqlUserDataEraseSessions, userId); err != nil {
+			return true, err
+		}
+		for _, qs := range sqlUserDataEraseDeletes {
+			if _, err = tx.Exec(ctx, qs, userId); err != nil {
+				return true, err
+			}
+		}
+		return false, nil
+	})
+	if err != nil {
+		utils.LoggerDesc("Query error").Error(err)
+		return nil, err
+	}
+	return files, nil
+}
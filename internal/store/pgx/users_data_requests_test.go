@@ -0,0 +1,69 @@
+package pgx
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestUserDataTableSql(t *testing.T) {
+	m := userDataTable{Table: "sessions", Where: "user_uid=$1", Exclude: []string{"token", "device_token"}}
+	want := "select coalesce(jsonb_agg(to_jsonb(t) - 'token' - 'device_token'), '[]'::jsonb) from sessions t where user_uid=$1"
+	if got := m.sql(); got != want {
+		t.Errorf("sql() = %s", got)
+	}
+	m = userDataTable{Table: "user_imports", Where: "true", Row: "jsonb_build_object('uid', t.uid)"}
+	want = "select coalesce(jsonb_agg(jsonb_build_object('uid', t.uid)), '[]'::jsonb) from user_imports t where true"
+	if got := m.sql(); got != want {
+		t.Errorf("sql() with row = %s", got)
+	}
+}
+
+func TestUserDataTables(t *testing.T) {
+	tables := map[string]userDataTable{}
+	for _, m := range userDataTables {
+		if _, ok := tables[m.Table]; ok {
+			t.Errorf("%s is exported twice", m.Table)
+		}
+		if !strings.Contains(m.Where, "$1") {
+			t.Errorf("%s is not filtered by user", m.Table)
+		}
+		tables[m.Table] = m
+	}
+	for _, table := range []string{"users", "user_logs", "user_merges", "user_imports", "sessions"} {
+		if _, ok := tables[table]; !ok {
+			t.Errorf("%s is not exported", table)
+		}
+	}
+	if !strings.Contains(tables["user_logs"].Where, "subject_uid=$1") {
+		t.Error("logs about user are not exported")
+	}
+	if !strings.Contains(tables["user_imports"].Row, "jsonb_array_elements(t.report)") {
+		t.Error("imports must export only report lines of user")
+	}
+	if !slices.Contains(tables["users"].Exclude, "password") {
+		t.Error("users.password is exported")
+	}
+	if !slices.Contains(tables["sessions"].Exclude, "token") {
+		t.Error("sessions.token is exported")
+	}
+}
+
+func TestUserDataEraseDeletes(t *testing.T) {
+	for _, prefix := range []string{
+		"delete from user_security ", "delete from user_merges ", "update user_logs set properties=null", "update user_imports ",
+	} {
+		found := false
+		for _, qs := range sqlUserDataEraseDeletes {
+			found = found || strings.HasPrefix(qs, prefix)
+		}
+		if !found {
+			t.Errorf("erasure has no %q", prefix)
+		}
+	}
+	for _, qs := range sqlUserDataEraseDeletes {
+		if !strings.Contains(qs, "$1") {
+			t.Errorf("erasure is not filtered by user: %s", qs)
+		}
+	}
+}
@@ -0,0 +1,274 @@
+package pgx
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/mekdep/server/internal/models"
+	"github.com/mekdep/server/internal/utils"
+)
+
+const sqlUserImportFields = `ui.uid, ui.school_uid, ui.type, ui.file_name, ui.columns, ui.rows, ui.mapping, ui.report, ui.status, ui.error,
+	ui.created_by, ui.created_at, ui.updated_at, ui.committed_at, ui.rolled_back_at`
+const sqlUserImportSelect = `select ` + sqlUserImportFields + ` from user_imports ui where ui.uid=$1`
+const sqlUserImportSelectMany = `select ` + sqlUserImportFields + `, count(*) over() as total from user_imports ui where ui.uid=ui.uid`
+const sqlUserImportInsert = `insert into user_imports (school_uid, type, file_name, columns, rows, mapping, report, status, created_by)
+	values ($1, $2, $3, $4, $5, $6, $7, $8, $9) returning uid, created_at, updated_at`
+const sqlUserImportUpdate = `update user_imports set mapping=$2, report=$3, status=$4, error=$5, committed_at=$6, rolled_back_at=$7,
+	updated_at=now() where uid=$1 returning updated_at`
+
+// stuck in processing after restart is taken again, rows of done commit are skipped by not exists
+const sqlUserImportClaim = `update user_imports set status='processing', updated_at=now() where uid=$1 and
+	(status='pending' or (status='processing' and updated_at < now() - interval '1 hour'))`
+const sqlUserImportPendingList = `select uid from user_imports where status='pending' or
+	(status='processing' and updated_at < now() - interval '1 hour') order by updated_at limit $1`
+
+const sqlUserImportItemInsert = `insert into user_import_items (import_uid, kind, user_uid, school_uid, classroom_uid, parent_uid, role_code)
+	values ($1, $2, $3, $4, $5, $6, $7)`
+const sqlUserImportSchoolAdd = `insert into user_schools (user_uid, school_uid, role_code) select $1, $2, $3
+	where not exists (select 1 from user_schools where user_uid=$1 and school_uid=$2 and role_code=$3)`
+const sqlUserImportClassroomAdd = `insert into user_classrooms (user_uid, classroom_uid) select $1, $2
+	where not exists (select 1 from user_classrooms where user_uid=$1 and classroom_uid=$2 and type is null)`
+
+// rollback removes relations added by batch, created users only when nothing else holds them
+const sqlUserImportRollbackParents = `delete from user_parents up using user_import_items i where i.import_uid=$1 and i.kind='parent'
+	and up.parent_uid=i.parent_uid and up.child_uid=i.user_uid and up.school_uid=i.school_uid`
+const sqlUserImportRollbackClassrooms = `delete from user_classrooms uc using user_import_items i where i.import_uid=$1 and i.kind='classroom'
+	and uc.user_uid=i.user_uid and uc.classroom_uid=i.classroom_uid and uc.type is null`
+const sqlUserImportRollbackSchools = `delete from user_schools us using user_import_items i where i.import_uid=$1 and i.kind='school'
+	and us.user_uid=i.user_uid and us.school_uid=i.school_uid and us.role_code=i.role_code`
+const sqlUserImportRollbackUsers = `delete from users u using user_import_items i where i.import_uid=$1 and i.kind='user' and u.uid=i.user_uid
+	and not exists (select 1 from user_schools where user_uid=u.uid)
+	and not exists (select 1 from user_parents where parent_uid=u.uid or child_uid=u.uid)
+	and not exists (select 1 from user_classrooms where user_uid=u.uid)`
+const sqlUserImportRollbackStatus = `update user_imports set status='rolled_back', rolled_back_at=now(), updated_at=now() where uid=$1`
+
+func scanUserImport(rows pgx.Row, m *models.UserImport, addColumns ...interface{}) (err error) {
+	err = rows.Scan(parseColumnsForScan(m, addColumns...)...)
+	return
+}
+
+func (d *PgxStore) UserImportsCreate(ctx context.Context, m *models.UserImport) (*models.UserImport, error) {
+	err := d.runQuery(ctx, func(tx *pgxpool.Conn) (err error) {
+		err = tx.QueryRow(ctx, sqlUserImportInsert, m.SchoolId, m.Type, m.FileName, m.Columns, m.Rows, m.Mapping, m.Report, m.Status, m.CreatedBy).
+			Scan(&m.ID, &m.CreatedAt, &m.UpdatedAt)
+		return
+	})
+	if err != nil {
+		utils.LoggerDesc("Query error").Error(err)
+		return nil, err
+	}
+	return m, nil
+}
+
+func (d *PgxStore) UserImportsUpdate(ctx context.Context, m *models.UserImport) error {
+	err := d.runQuery(ctx, func(tx *pgxpool.Conn) (err error) {
+		err = tx.QueryRow(ctx, sqlUserImportUpdate, m.ID, m.Mapping, m.Report, m.Status, m.Error, m.CommittedAt, m.RolledBackAt).
+			Scan(&m.UpdatedAt)
+		return
+	})
+	if err != nil {
+		utils.LoggerDesc("Query error").Error(err)
+		return err
+	}
+	return nil
+}
+
+func (d *PgxStore) UserImportsFindById(ctx context.Context, id string) (*models.UserImport, error) {
+	m := models.UserImport{}
+	err := d.runQuery(ctx, func(tx *pgxpool.Conn) (err error) {
+		err = scanUserImport(tx.QueryRow(ctx, sqlUserImportSelect, id), &m)
+		return
+	})
+	if err != nil {
+		utils.LoggerDesc("Query error").Error(err)
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (d *PgxStore) UserImportsFindBy(ctx context.Context, f models.UserImportFilterRequest) ([]*models.UserImport, int, error) {
+	args := []interface{}{}
+	wheres := ""
+	if f.SchoolId != nil {
+		args = append(args, *f.SchoolId)
+		wheres += " and ui.school_uid=$" + strconv.Itoa(len(args))
+	}
+	if f.SchoolIds != nil {
+		args = append(args, *f.SchoolIds)
+		wheres += " and ui.school_uid=ANY($" + strconv.Itoa(len(args)) + "::uuid[])"
+	}
+	if f.Status != nil && *f.Status != "" {
+		args = append(args, *f.Status)
+		wheres += " and ui.status=$" + strconv.Itoa(len(args))
+	}
+	if f.Limit == nil {
+		f.Limit = new(int)
+		*f.Limit = 12
+	}
+	if f.Offset == nil {
+		f.Offset = new(int)
+	}
+	args = append(args, *f.Limit, *f.Offset)
+	qs := sqlUserImportSelectMany + wheres + " order by ui.created_at desc limit $" + strconv.Itoa(len(args)-1) + " offset $" + strconv.Itoa(len(args))
+	items := []*models.UserImport{}
+	total := 0
+	err := d.runQuery(ctx, func(tx *pgxpool.Conn) (err error) {
+		rows, err := tx.Query(ctx, qs, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			item := models.UserImport{}
+			err = scanUserImport(rows, &item, &total)
+			if err != nil {
+				utils.LoggerDesc("Scan error").Error(err)
+				return err
+			}
+			items = append(items, &item)
+		}
+		return
+	})
+	if err != nil {
+		utils.LoggerDesc("Query error").Error(err)
+		return nil, 0, err
+	}
+	return items, total, nil
+}
+
+func (d *PgxStore) UserImportsClaim(ctx context.Context, id string) (bool, error) {
+	claimed := false
+	err := d.runQuery(ctx, func(tx *pgxpool.Conn) (err error) {
+		tag, err := tx.Exec(ctx, sqlUserImportClaim, id)
+		claimed = tag.RowsAffected() > 0
+		return
+	})
+	if err != nil {
+		utils.LoggerDesc("Query error").Error(err)
+		return false, err
+	}
+	return claimed, nil
+}
+
+func (d *PgxStore) UserImportsPendingList(ctx context.Context, limit int) ([]string, error) {
+	ids := []string{}
+	err := d.runQuery(ctx, func(tx *pgxpool.Conn) (err error) {
+		rows, err := tx.Query(ctx, sqlUserImportPendingList, limit)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var id string
+			if err = rows.Scan(&id); err != nil {
+				return err
+			}
+			ids = append(ids, id)
+		}
+		return
+	})
+	if err != nil {
+		utils.LoggerDesc("Query error").Error(err)
+		return nil, err
+	}
+	return ids, nil
+}
+
+// UserImportsCommitRow creates user and parents without id, adds school role, classroom and parents
+// in one transaction, every added row is kept in items of batch for rollback
+func (d *PgxStore) UserImportsCommitRow(ctx context.Context, importId string, schoolId string, role models.Role, user *models.User, classroomId *string, parents []*models.User) error {
+	err := d.runInTx(ctx, func(tx pgx.Tx) (rollback bool, err error) {
+		item := func(kind string, userId string, classroomId *string, parentId *string, role *models.Role) error {
+			_, err := tx.Exec(ctx, sqlUserImportItemInsert, importId, kind, userId, schoolId, classroomId, parentId, role)
+			return err
+		}
+		create := func(m *models.User) error {
+			if m.ID != "" {
+				return nil
+			}
+			qs, args := UserCreateQuery(m)
+			err := tx.QueryRow(ctx, qs+" RETURNING uid", args...).Scan(&m.ID)
+			if err != nil {
+				return err
+			}
+			return item(models.UserImportItemUser, m.ID, nil, nil, nil)
+		}
+		if err = create(user); err != nil {
+			return true, err
+		}
+		if role != models.RoleParent {
+			tag, err := tx.Exec(ctx, sqlUserImportSchoolAdd, user.ID, schoolId, role)
+			if err != nil {
+				return true, err
+			}
+			if tag.RowsAffected() > 0 {
+				if err = item(models.UserImportItemSchool, user.ID, nil, nil, &role); err != nil {
+					return true, err
+				}
+			}
+		}
+		if classroomId != nil {
+			tag, err := tx.Exec(ctx, sqlUserImportClassroomAdd, user.ID, *classroomId)
+			if err != nil {
+				return true, err
+			}
+			if tag.RowsAffected() > 0 {
+				if err = item(models.UserImportItemClassroom, user.ID, classroomId, nil, nil); err != nil {
+					return true, err
+				}
+			}
+		}
+		for _, p := range parents {
+			if err = create(p); err != nil {
+				return true, err
+			}
+			tag, err := tx.Exec(ctx, sqlUserParentsAdd, p.ID, user.ID, schoolId)
+			if err != nil {
+				return true, err
+			}
+			if tag.RowsAffected() > 0 {
+				if err = item(models.UserImportItemParent, user.ID, nil, &p.ID, nil); err != nil {
+					return true, err
+				}
+			}
+		}
+		return false, nil
+	})
+	if err != nil {
+		utils.LoggerDesc("Query error").Error(err)
+		return err
+	}
+	return nil
+}
+
+// UserImportsRollback removes what batch added, returns count of deleted users,
+// users merged or linked by others after import are kept
+func (d *PgxStore) UserImportsRollback(ctx context.Context, importId string) (int, error) {
+	deleted := 0
+	err := d.runInTx(ctx, func(tx pgx.Tx) (rollback bool, err error) {
+		for _, qs := range []string{sqlUserImportRollbackParents, sqlUserImportRollbackClassrooms, sqlUserImportRollbackSchools} {
+			_, err = tx.Exec(ctx, qs, importId)
+			if err != nil {
+				return true, err
+			}
+		}
+		tag, err := tx.Exec(ctx, sqlUserImportRollbackUsers, importId)
+		if err != nil {
+			return true, err
+		}
+		deleted = int(tag.RowsAffected())
+		_, err = tx.Exec(ctx, sqlUserImportRollbackStatus, importId)
+		if err != nil {
+			return true, err
+		}
+		return false, nil
+	})
+	if err != nil {
+		utils.LoggerDesc("Query error").Error(err)
+		return 0, err
+	}
+	return deleted, nil
+}
@@ -0,0 +1,443 @@
+package pgx
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/mekdep/server/internal/models"
+	"github.com/mekdep/server/internal/utils"
+)
+
+// students of school and parents of its students, blocked users are already merged or not in use
+const sqlUserDuplicateCandidates = `select u.uid, u.first_name, u.last_name, u.middle_name, u.birthday, u.birth_cert_number, u.passport_number, u.phone, us.role_code
+	from users u join user_schools us on us.user_uid=u.uid where us.school_uid=$1 and us.role_code='student' and u.status<>'blocked'
+	union
+	select u.uid, u.first_name, u.last_name, u.middle_name, u.birthday, u.birth_cert_number, u.passport_number, u.phone, 'parent'
+	from users u join user_parents up on up.parent_uid=u.uid join user_schools us on us.user_uid=up.child_uid
+	where us.school_uid=$1 and us.role_code='student' and u.status<>'blocked'`
+
+const sqlUserDuplicateFields = `ud.uid, ud.school_uid, ud.user_uid, ud.other_uid, ud.role_code, ud.score, ud.reasons, ud.status,
+	ud.reviewed_by, ud.reviewed_at, ud.created_at, ud.updated_at`
+const sqlUserDuplicateSelect = `select ` + sqlUserDuplicateFields + ` from user_duplicates ud where ud.uid=$1`
+const sqlUserDuplicateSelectMany = `select ` + sqlUserDuplicateFields + `, count(*) over() as total from user_duplicates ud where ud.uid=ud.uid`
+
+// reviewed pairs are kept as they are, pending pairs not found again are removed
+const sqlUserDuplicateUpsert = `insert into user_duplicates (school_uid, user_uid, other_uid, role_code, score, reasons) values ($1, $2, $3, $4, $5, $6)
+	on conflict (user_uid, other_uid) do update set score=excluded.score, reasons=excluded.reasons, updated_at=now()
+	where user_duplicates.status='pending'`
+const sqlUserDuplicateDeleteStale = `delete from user_duplicates where school_uid=$1 and status='pending' and updated_at < now()`
+const sqlUserDuplicateReview = `update user_duplicates set status=$2, reviewed_by=$3, reviewed_at=now(), updated_at=now() where uid=$1`
+const sqlUserDuplicateReopen = `update user_duplicates set status='pending', reviewed_by=null, reviewed_at=null, updated_at=now() where uid=$1`
+
+const sqlUserMergeFields = `um.uid, um.school_uid, um.duplicate_pair_uid, um.survivor_uid, um.duplicate_uid, um.duplicate_status, um.moved,
+	um.skipped, um.created_by, um.created_at, um.undone_by, um.undone_at`
+const sqlUserMergeSelect = `select ` + sqlUserMergeFields + ` from user_merges um where um.uid=$1`
+const sqlUserMergeSelectMany = `select ` + sqlUserMergeFields + `, count(*) over() as total from user_merges um where um.uid=um.uid`
+const sqlUserMergeInsert = `insert into user_merges (school_uid, duplicate_pair_uid, survivor_uid, duplicate_uid, duplicate_status, moved, skipped, created_by)
+	values ($1, $2, $3, $4, $5, $6, $7, $8) returning uid, created_at`
+const sqlUserMergeUndone = `update user_merges set undone_by=$2, undone_at=now() where uid=$1 and undone_at is null returning undone_at`
+const sqlUserMergeLockUser = `select status from users where uid=$1 for update`
+const sqlUserMergeBlock = `update users set status='blocked', updated_at=now() where uid=$1`
+const sqlUserMergeUnblock = `update users set status=$2, updated_at=now() where uid=$1 and status='blocked'`
+
+// userMergeMove is column of table which holds user, Key identifies moved rows for undo,
+// row is not moved when target user already has row with same Unique columns
+type userMergeMove struct {
+	Table  string
+	Column string
+	Key    string
+	Unique []string
+	// column is uuid[] of users, user is replaced in array
+	IsArray bool
+}
+
+var userMergeMoves = []userMergeMove{
+	{Table: "grades", Column: "student_uid", Key: "uid", Unique: []string{"lesson_uid"}},
+	{Table: "absents", Column: "student_uid", Key: "uid", Unique: []string{"lesson_uid"}},
+	{Table: "period_grades", Column: "student_uid", Key: "uid", Unique: []string{"subject_uid", "period_key"}},
+	{Table: "user_parents", Column: "parent_uid", Key: "uid", Unique: []string{"child_uid"}},
+	{Table: "user_parents", Column: "child_uid", Key: "uid", Unique: []string{"parent_uid"}},
+	{Table: "user_classrooms", Column: "user_uid", Key: "uid", Unique: []string{"classroom_uid", "type", "type_key"}},
+	{Table: "user_schools", Column: "user_uid", Key: "uid", Unique: []string{"school_uid", "role_code"}},
+	{Table: "user_payments", Column: "user_uid", Key: "classroom_uid", Unique: []string{"classroom_uid"}},
+	{Table: "payment_transactions", Column: "payer_uid", Key: "uid"},
+	{Table: "payment_transactions", Column: "user_uids", Key: "uid", IsArray: true},
+	{Table: "sessions", Column: "user_uid", Key: "uid"},
+	{Table: "messages", Column: "user_uid", Key: "uid"},
+	{Table: "messages_reads", Column: "user_uid", Key: "uid", Unique: []string{"message_uid"}},
+	{Table: "message_groups", Column: "admin_uid", Key: "uid"},
+	{Table: "assignment_submissions", Column: "student_uid", Key: "uid", Unique: []string{"lesson_uid"}},
+	{Table: "assignment_submissions", Column: "submitted_by_uid", Key: "uid"},
+	{Table: "user_notifications", Column: "user_uid", Key: "uid", Unique: []string{"notification_uid"}},
+	{Table: "contact_items", Column: "user_uid", Key: "uid"},
+	{Table: "school_transfers", Column: "student_uid", Key: "uid"},
+	{Table: "classrooms", Column: "teacher_uid", Key: "uid"},
+}
+
+func (m userMergeMove) Name() string {
+	return m.Table + "." + m.Column
+}
+
+// sqlSkipped returns keys of rows left on user $1 as user $2 has same Unique ones, after rows are moved
+func (m userMergeMove) sqlSkipped() string {
+	return "select t." + m.Key + " from " + m.Table + " t where t." + m.Column + "=$1 and exists (select 1 from " + m.Table + " s where s." +
+		m.Column + "=$2" + m.sqlUnique() + ")"
+}
+
+func (m userMergeMove) sqlUnique() string {
+	qs := ""
+	for _, u := range m.Unique {
+		qs += " and s." + u + " is not distinct from t." + u
+	}
+	return qs
+}
+
+// sql moves rows from user $1 to user $2, only rows with keys $3 when isUndo
+func (m userMergeMove) sql(isUndo bool) string {
+	qs := ""
+	if m.IsArray {
+		qs = "update " + m.Table + " t set " + m.Column + "=array_replace(t." + m.Column + ", $1::uuid, $2::uuid) where $1::uuid=ANY(t." + m.Column + ")"
+	} else {
+		qs = "update " + m.Table + " t set " + m.Column + "=$2 where t." + m.Column + "=$1"
+		if len(m.Unique) > 0 {
+			qs += " and not exists (select 1 from " + m.Table + " s where s." + m.Column + "=$2" + m.sqlUnique() + ")"
+		}
+	}
+	if isUndo {
+		qs += " and t." + m.Key + "=ANY($3::uuid[])"
+	}
+	return qs + " returning t." + m.Key
+}
+
+func scanUserDuplicate(rows pgx.Row, m *models.UserDuplicate, addColumns ...interface{}) (err error) {
+	err = rows.Scan(parseColumnsForScan(m, addColumns...)...)
+	return
+}
+
+func scanUserMerge(rows pgx.Row, m *models.UserMerge, addColumns ...interface{}) (err error) {
+	err = rows.Scan(parseColumnsForScan(m, addColumns...)...)
+	return
+}
+
+// UserDuplicatesCandidates returns students and parents of school with fields compared by finder, Role is set
+func (d *PgxStore) UserDuplicatesCandidates(ctx context.Context, schoolId string) ([]*models.User, error) {
+	items := []*models.User{}
+	err := d.runQuery(ctx, func(tx *pgxpool.Conn) (err error) {
+		rows, err := tx.Query(ctx, sqlUserDuplicateCandidates, schoolId)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			item := models.User{}
+			err = rows.Scan(&item.ID, &item.FirstName, &item.LastName, &item.MiddleName, &item.Birthday,
+				&item.BirthCertNumber, &item.PassportNumber, &item.Phone, &item.Role)
+			if err != nil {
+				utils.LoggerDesc("Scan error").Error(err)
+				return err
+			}
+			items = append(items, &item)
+		}
+		return
+	})
+	if err != nil {
+		utils.LoggerDesc("Query error").Error(err)
+		return nil, err
+	}
+	return items, nil
+}
+
+// UserDuplicatesSave puts found pairs of school into queue, pending pairs which are not found anymore are removed
+func (d *PgxStore) UserDuplicatesSave(ctx context.Context, schoolId string, items []*models.UserDuplicate) error {
+	err := d.runInTx(ctx, func(tx pgx.Tx) (rollback bool, err error) {
+		for _, m := range items {
+			_, err = tx.Exec(ctx, sqlUserDuplicateUpsert, schoolId, m.UserId, m.OtherId, m.RoleCode, m.Score, m.Reasons)
+			if err != nil {
+				return true, err
+			}
+		}
+		_, err = tx.Exec(ctx, sqlUserDuplicateDeleteStale, schoolId)
+		if err != nil {
+			return true, err
+		}
+		return false, nil
+	})
+	if err != nil {
+		utils.LoggerDesc("Query error").Error(err)
+		return err
+	}
+	return nil
+}
+
+func (d *PgxStore) UserDuplicatesFindById(ctx context.Context, id string) (*models.UserDuplicate, error) {
+	m := models.UserDuplicate{}
+	err := d.runQuery(ctx, func(tx *pgxpool.Conn) (err error) {
+		err = scanUserDuplicate(tx.QueryRow(ctx, sqlUserDuplicateSelect, id), &m)
+		return
+	})
+	if err != nil {
+		utils.LoggerDesc("Query error").Error(err)
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (d *PgxStore) UserDuplicatesFindBy(ctx context.Context, f models.UserDuplicateFilterRequest) ([]*models.UserDuplicate, int, error) {
+	args := []interface{}{}
+	wheres := ""
+	if f.ID != nil && *f.ID != "" {
+		args = append(args, *f.ID)
+		wheres += " and ud.uid=$" + strconv.Itoa(len(args))
+	}
+	if f.SchoolId != nil && *f.SchoolId != "" {
+		args = append(args, *f.SchoolId)
+		wheres += " and ud.school_uid=$" + strconv.Itoa(len(args))
+	}
+	if f.SchoolIds != nil {
+		args = append(args, *f.SchoolIds)
+		wheres += " and ud.school_uid=ANY($" + strconv.Itoa(len(args)) + "::uuid[])"
+	}
+	if f.UserId != nil && *f.UserId != "" {
+		args = append(args, *f.UserId)
+		wheres += " and (ud.user_uid=$" + strconv.Itoa(len(args)) + " or ud.other_uid=$" + strconv.Itoa(len(args)) + ")"
+	}
+	if f.RoleCode != nil && *f.RoleCode != "" {
+		args = append(args, *f.RoleCode)
+		wheres += " and ud.role_code=$" + strconv.Itoa(len(args))
+	}
+	if f.Status != nil && *f.Status != "" {
+		args = append(args, *f.Status)
+		wheres += " and ud.status=$" + strconv.Itoa(len(args))
+	}
+	if f.Limit == nil {
+		f.Limit = new(int)
+		*f.Limit = 12
+	}
+	if f.Offset == nil {
+		f.Offset = new(int)
+	}
+	args = append(args, *f.Limit, *f.Offset)
+	qs := sqlUserDuplicateSelectMany + wheres + " order by ud.score desc, ud.created_at limit $" + strconv.Itoa(len(args)-1) + " offset $" + strconv.Itoa(len(args))
+	items := []*models.UserDuplicate{}
+	total := 0
+	err := d.runQuery(ctx, func(tx *pgxpool.Conn) (err error) {
+		rows, err := tx.Query(ctx, qs, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			item := models.UserDuplicate{}
+			err = scanUserDuplicate(rows, &item, &total)
+			if err != nil {
+				utils.LoggerDesc("Scan error").Error(err)
+				return err
+			}
+			items = append(items, &item)
+		}
+		return
+	})
+	if err != nil {
+		utils.LoggerDesc("Query error").Error(err)
+		return nil, 0, err
+	}
+	return items, total, nil
+}
+
+func (d *PgxStore) UserDuplicatesReview(ctx context.Context, id string, status string, reviewedBy string) error {
+	err := d.runQuery(ctx, func(tx *pgxpool.Conn) (err error) {
+		_, err = tx.Exec(ctx, sqlUserDuplicateReview, id, status, reviewedBy)
+		return
+	})
+	if err != nil {
+		utils.LoggerDesc("Query error").Error(err)
+		return err
+	}
+	return nil
+}
+
+func (d *PgxStore) UserMergesFindById(ctx context.Context, id string) (*models.UserMerge, error) {
+	m := models.UserMerge{}
+	err := d.runQuery(ctx, func(tx *pgxpool.Conn) (err error) {
+		err = scanUserMerge(tx.QueryRow(ctx, sqlUserMergeSelect, id), &m)
+		return
+	})
+	if err != nil {
+		utils.LoggerDesc("Query error").Error(err)
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (d *PgxStore) UserMergesFindBy(ctx context.Context, f models.UserMergeFilterRequest) ([]*models.UserMerge, int, error) {
+	args := []interface{}{}
+	wheres := ""
+	if f.SchoolId != nil && *f.SchoolId != "" {
+		args = append(args, *f.SchoolId)
+		wheres += " and um.school_uid=$" + strconv.Itoa(len(args))
+	}
+	if f.SchoolIds != nil {
+		args = append(args, *f.SchoolIds)
+		wheres += " and um.school_uid=ANY($" + strconv.Itoa(len(args)) + "::uuid[])"
+	}
+	if f.UserId != nil && *f.UserId != "" {
+		args = append(args, *f.UserId)
+		wheres += " and (um.survivor_uid=$" + strconv.Itoa(len(args)) + " or um.duplicate_uid=$" + strconv.Itoa(len(args)) + ")"
+	}
+	if f.Limit == nil {
+		f.Limit = new(int)
+		*f.Limit = 12
+	}
+	if f.Offset == nil {
+		f.Offset = new(int)
+	}
+	args = append(args, *f.Limit, *f.Offset)
+	qs := sqlUserMergeSelectMany + wheres + " order by um.created_at desc limit $" + strconv.Itoa(len(args)-1) + " offset $" + strconv.Itoa(len(args))
+	items := []*models.UserMerge{}
+	total := 0
+	err := d.runQuery(ctx, func(tx *pgxpool.Conn) (err error) {
+		rows, err := tx.Query(ctx, qs, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			item := models.UserMerge{}
+			err = scanUserMerge(rows, &item, &total)
+			if err != nil {
+				utils.LoggerDesc("Scan error").Error(err)
+				return err
+			}
+			items = append(items, &item)
+		}
+		return
+	})
+	if err != nil {
+		utils.LoggerDesc("Query error").Error(err)
+		return nil, 0, err
+	}
+	return items, total, nil
+}
+
+// userMergeRun moves rows of every table from user to user, returns moved keys by table.column
+// and keys of rows left on user as target has same unique ones (not counted on undo, keys are set)
+func userMergeRun(ctx context.Context, tx pgx.Tx, fromId string, toId string, keys map[string][]string) (map[string][]string, map[string][]string, error) {
+	moved := map[string][]string{}
+	skipped := map[string][]string{}
+	for _, m := range userMergeMoves {
+		args := []interface{}{fromId, toId}
+		if keys != nil {
+			if len(keys[m.Name()]) < 1 {
+				continue
+			}
+			args = append(args, keys[m.Name()])
+		}
+		l, err := userMergeKeys(ctx, tx, m.sql(keys != nil), args...)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(l) > 0 {
+			moved[m.Name()] = l
+		}
+		if keys != nil || len(m.Unique) < 1 {
+			continue
+		}
+		l, err = userMergeKeys(ctx, tx, m.sqlSkipped(), fromId, toId)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(l) > 0 {
+			skipped[m.Name()] = l
+		}
+	}
+	return moved, skipped, nil
+}
+
+func userMergeKeys(ctx context.Context, tx pgx.Tx, qs string, args ...interface{}) ([]string, error) {
+	rows, err := tx.Query(ctx, qs, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	l := []string{}
+	for rows.Next() {
+		var key string
+		if err = rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		l = append(l, key)
+	}
+	return l, rows.Err()
+}
+
+// UserMergesCommit moves grades, absents, period grades, parents, classrooms, schools, payments, sessions, messages,
+// submissions, notifications, contact items, transfers and classes of teacher of duplicate onto survivor in one transaction, duplicate is blocked, merge keeps moved rows for undo
+func (d *PgxStore) UserMergesCommit(ctx context.Context, m *models.UserMerge) (*models.UserMerge, error) {
+	err := d.runInTx(ctx, func(tx pgx.Tx) (rollback bool, err error) {
+		var survivorStatus string
+		if err = tx.QueryRow(ctx, sqlUserMergeLockUser, m.SurvivorId).Scan(&survivorStatus); err != nil {
+			return true, err
+		}
+		if err = tx.QueryRow(ctx, sqlUserMergeLockUser, m.DuplicateId).Scan(&m.DuplicateStatus); err != nil {
+			return true, err
+		}
+		m.Moved, m.Skipped, err = userMergeRun(ctx, tx, m.DuplicateId, m.SurvivorId, nil)
+		if err != nil {
+			return true, err
+		}
+		if _, err = tx.Exec(ctx, sqlUserMergeBlock, m.DuplicateId); err != nil {
+			return true, err
+		}
+		if m.DuplicatePairId != nil {
+			if _, err = tx.Exec(ctx, sqlUserDuplicateReview, *m.DuplicatePairId, models.UserDuplicateMerged, m.CreatedBy); err != nil {
+				return true, err
+			}
+		}
+		err = tx.QueryRow(ctx, sqlUserMergeInsert, m.SchoolId, m.DuplicatePairId, m.SurvivorId, m.DuplicateId, m.DuplicateStatus, m.Moved, m.Skipped, m.CreatedBy).
+			Scan(&m.ID, &m.CreatedAt)
+		if err != nil {
+			return true, err
+		}
+		return false, nil
+	})
+	if err != nil {
+		utils.LoggerDesc("Query error").Error(err)
+		return nil, err
+	}
+	return m, nil
+}
+
+// UserMergesUndo moves recorded rows back to duplicate and restores its status, pair is reviewed again,
+// rows deleted since merge or taken by duplicate again are skipped
+func (d *PgxStore) UserMergesUndo(ctx context.Context, m *models.UserMerge, undoneBy string) (*models.UserMerge, error) {
+	err := d.runInTx(ctx, func(tx pgx.Tx) (rollback bool, err error) {
+		err = tx.QueryRow(ctx, sqlUserMergeUndone, m.ID, undoneBy).Scan(&m.UndoneAt)
+		if err != nil {
+			return true, err
+		}
+		m.UndoneBy = &undoneBy
+		var status string
+		if err = tx.QueryRow(ctx, sqlUserMergeLockUser, m.DuplicateId).Scan(&status); err != nil {
+			return true, err
+		}
+		if _, _, err = userMergeRun(ctx, tx, m.SurvivorId, m.DuplicateId, m.Moved); err != nil {
+			return true, err
+		}
+		if _, err = tx.Exec(ctx, sqlUserMergeUnblock, m.DuplicateId, m.DuplicateStatus); err != nil {
+			return true, err
+		}
+		if m.DuplicatePairId != nil {
+			if _, err = tx.Exec(ctx, sqlUserDuplicateReopen, *m.DuplicatePairId); err != nil {
+				return true, err
+			}
+		}
+		return false, nil
+	})
+	if err != nil {
+		utils.LoggerDesc("Query error").Error(err)
+		return nil, err
+	}
+	return m, nil
+}
@@ -0,0 +1,120 @@
+package pgx
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v4"
+)
+
+// fakeTx answers queries of merge with keys given by query, only Query is used by userMergeRun
+type fakeTx struct {
+	pgx.Tx
+	keys    func(qs string, args []interface{}) []string
+	queries []string
+}
+
+func (tx *fakeTx) Query(ctx context.Context, qs string, args ...interface{}) (pgx.Rows, error) {
+	tx.queries = append(tx.queries, qs)
+	return &fakeRows{keys: tx.keys(qs, args)}, nil
+}
+
+type fakeRows struct {
+	pgx.Rows
+	keys []string
+	k    int
+}
+
+func (r *fakeRows) Next() bool {
+	r.k++
+	return r.k <= len(r.keys)
+}
+
+func (r *fakeRows) Scan(dest ...interface{}) error {
+	*dest[0].(*string) = r.keys[r.k-1]
+	return nil
+}
+
+func (r *fakeRows) Close() {}
+
+func (r *fakeRows) Err() error {
+	return nil
+}
+
+func TestUserMergeMovesTables(t *testing.T) {
+	names := map[string]bool{}
+	for _, m := range userMergeMoves {
+		if names[m.Name()] {
+			t.Errorf("%s is moved twice", m.Name())
+		}
+		names[m.Name()] = true
+	}
+	for _, name := range []string{
+		"grades.student_uid", "user_parents.child_uid", "assignment_submissions.student_uid", "assignment_submissions.submitted_by_uid",
+		"user_notifications.user_uid", "contact_items.user_uid", "school_transfers.student_uid", "classrooms.teacher_uid",
+	} {
+		if !names[name] {
+			t.Errorf("%s is not moved", name)
+		}
+	}
+}
+
+func TestUserMergeMoveSql(t *testing.T) {
+	m := userMergeMove{Table: "grades", Column: "student_uid", Key: "uid", Unique: []string{"lesson_uid"}}
+	want := "update grades t set student_uid=$2 where t.student_uid=$1" +
+		" and not exists (select 1 from grades s where s.student_uid=$2 and s.lesson_uid is not distinct from t.lesson_uid)"
+	if got := m.sql(false); got != want+" returning t.uid" {
+		t.Errorf("sql() = %s", got)
+	}
+	if got := m.sql(true); got != want+" and t.uid=ANY($3::uuid[]) returning t.uid" {
+		t.Errorf("sql(undo) = %s", got)
+	}
+	want = "select t.uid from grades t where t.student_uid=$1 and exists" +
+		" (select 1 from grades s where s.student_uid=$2 and s.lesson_uid is not distinct from t.lesson_uid)"
+	if got := m.sqlSkipped(); got != want {
+		t.Errorf("sqlSkipped() = %s", got)
+	}
+
+	m = userMergeMove{Table: "payment_transactions", Column: "user_uids", Key: "uid", IsArray: true}
+	want = "update payment_transactions t set user_uids=array_replace(t.user_uids, $1::uuid, $2::uuid) where $1::uuid=ANY(t.user_uids) returning t.uid"
+	if got := m.sql(false); got != want {
+		t.Errorf("sql() of array = %s", got)
+	}
+}
+
+func TestUserMergeRun(t *testing.T) {
+	tx := &fakeTx{keys: func(qs string, args []interface{}) []string {
+		switch {
+		case strings.HasPrefix(qs, "update grades "):
+			return []string{"grade1", "grade2"}
+		case strings.HasPrefix(qs, "select t.uid from grades "):
+			return []string{"grade3"}
+		case strings.HasPrefix(qs, "update classrooms "):
+			return []string{"classroom1"}
+		}
+		return nil
+	}}
+	moved, skipped, err := userMergeRun(context.Background(), tx, "duplicate", "survivor", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantMoved := map[string][]string{"grades.student_uid": {"grade1", "grade2"}, "classrooms.teacher_uid": {"classroom1"}}
+	if !reflect.DeepEqual(moved, wantMoved) {
+		t.Errorf("moved = %v, want %v", moved, wantMoved)
+	}
+	if !reflect.DeepEqual(skipped, map[string][]string{"grades.student_uid": {"grade3"}}) {
+		t.Errorf("skipped = %v", skipped)
+	}
+
+	// undo moves back only tables with kept keys and does not look for skipped rows
+	tx.queries = nil
+	_, skipped, err = userMergeRun(context.Background(), tx, "survivor", "duplicate", moved)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tx.queries) != 2 || len(skipped) != 0 {
+		t.Errorf("undo queries %v skipped %v", tx.queries, skipped)
+	}
+}
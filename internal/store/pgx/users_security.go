@@ -0,0 +1,136 @@
+package pgx
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/mekdep/server/internal/models"
+	"github.com/mekdep/server/internal/utils"
+)
+
+const sqlUserSecurityFields = `usc.user_uid, usc.totp_secret, usc.totp_enabled_at, usc.totp_last_counter, usc.recovery_codes,
+	usc.failed_count, usc.locked_until, usc.updated_at`
+const sqlUserSecuritySelect = `select ` + sqlUserSecurityFields + ` from user_security usc where usc.user_uid=$1`
+const sqlUserSecurityUpsert = `insert into user_security
+	(user_uid, totp_secret, totp_enabled_at, totp_last_counter, recovery_codes, failed_count, locked_until)
+	values ($1, $2, $3, $4, $5, $6, $7)
+	on conflict (user_uid) do update set totp_secret=excluded.totp_secret, totp_enabled_at=excluded.totp_enabled_at,
+	totp_last_counter=excluded.totp_last_counter, recovery_codes=excluded.recovery_codes, failed_count=excluded.failed_count,
+	locked_until=excluded.locked_until, updated_at=CURRENT_TIMESTAMP`
+
+const sqlLoginAttemptFields = `la.uid, la.user_uid, la.login, la.ip, la.agent, la.device, la.is_success, la.reason, la.created_at`
+const sqlLoginAttemptSelectMany = `select ` + sqlLoginAttemptFields + `, count(*) over() as total from login_attempts la where la.uid=la.uid`
+const sqlLoginAttemptInsert = `insert into login_attempts (user_uid, login, ip, agent, device, is_success, reason)
+	values ($1, $2, $3, $4, $5, $6, $7) returning uid`
+
+func scanUserSecurity(rows pgx.Row, m *models.UserSecurity, addColumns ...interface{}) (err error) {
+	err = rows.Scan(parseColumnsForScan(m, addColumns...)...)
+	return
+}
+
+func scanLoginAttempt(rows pgx.Row, m *models.LoginAttempt, addColumns ...interface{}) (err error) {
+	err = rows.Scan(parseColumnsForScan(m, addColumns...)...)
+	return
+}
+
+// UserSecurityFindByUserId returns security state of user, empty state when it is not stored yet
+func (d *PgxStore) UserSecurityFindByUserId(ctx context.Context, userId string) (*models.UserSecurity, error) {
+	m := models.UserSecurity{}
+	err := d.runQuery(ctx, func(tx *pgxpool.Conn) (err error) {
+		err = scanUserSecurity(tx.QueryRow(ctx, sqlUserSecuritySelect, userId), &m)
+		return
+	})
+	if err == pgx.ErrNoRows {
+		return &models.UserSecurity{UserId: userId}, nil
+	}
+	if err != nil {
+		utils.LoggerDesc("Query error").Error(err)
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (d *PgxStore) UserSecuritySave(ctx context.Context, m *models.UserSecurity) error {
+	err := d.runQuery(ctx, func(tx *pgxpool.Conn) (err error) {
+		_, err = tx.Exec(ctx, sqlUserSecurityUpsert, m.UserId, m.TotpSecret, m.TotpEnabledAt, m.TotpLastCounter,
+			m.RecoveryCodes, m.FailedCount, m.LockedUntil)
+		return
+	})
+	if err != nil {
+		utils.LoggerDesc("Query error").Error(err)
+		return err
+	}
+	return nil
+}
+
+func (d *PgxStore) LoginAttemptsCreate(ctx context.Context, m *models.LoginAttempt) error {
+	err := d.runQuery(ctx, func(tx *pgxpool.Conn) (err error) {
+		err = tx.QueryRow(ctx, sqlLoginAttemptInsert, m.UserId, m.Login, m.Ip, m.Agent, m.Device, m.IsSuccess, m.Reason).Scan(&m.ID)
+		return
+	})
+	if err != nil {
+		utils.LoggerDesc("Query error").Error(err)
+		return err
+	}
+	return nil
+}
+
+func (d *PgxStore) LoginAttemptsFindBy(ctx context.Context, f models.LoginAttemptFilterRequest) ([]*models.LoginAttempt, int, error) {
+	args := []interface{}{}
+	wheres := ""
+	if f.UserId != nil {
+		args = append(args, *f.UserId)
+		wheres += " and la.user_uid=$" + strconv.Itoa(len(args))
+	}
+	if f.Login != nil {
+		args = append(args, *f.Login)
+		wheres += " and la.login=$" + strconv.Itoa(len(args))
+	}
+	if f.Ip != nil {
+		args = append(args, *f.Ip)
+		wheres += " and la.ip=$" + strconv.Itoa(len(args))
+	}
+	if f.IsSuccess != nil {
+		args = append(args, *f.IsSuccess)
+		wheres += " and la.is_success=$" + strconv.Itoa(len(args))
+	}
+	if f.StartDate != nil {
+		args = append(args, *f.StartDate)
+		wheres += " and la.created_at>=$" + strconv.Itoa(len(args))
+	}
+	if f.Limit == nil {
+		f.Limit = new(int)
+		*f.Limit = 50
+	}
+	if f.Offset == nil {
+		f.Offset = new(int)
+	}
+	args = append(args, *f.Limit, *f.Offset)
+	qs := sqlLoginAttemptSelectMany + wheres + " order by la.created_at desc limit $" + strconv.Itoa(len(args)-1) + " offset $" + strconv.Itoa(len(args))
+	items := []*models.LoginAttempt{}
+	total := 0
+	err := d.runQuery(ctx, func(tx *pgxpool.Conn) (err error) {
+		rows, err := tx.Query(ctx, qs, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			item := models.LoginAttempt{}
+			err = scanLoginAttempt(rows, &item, &total)
+			if err != nil {
+				utils.LoggerDesc("Scan error").Error(err)
+				return err
+			}
+			items = append(items, &item)
+		}
+		return
+	})
+	if err != nil {
+		utils.LoggerDesc("Query error").Error(err)
+		return nil, 0, err
+	}
+	return items, total, nil
+}
@@ -0,0 +1,52 @@
+package mail
+
+import (
+	"context"
+	"log"
+
+	"github.com/mekdep/server/config"
+)
+
+// Message is sent as multipart/alternative, clients without html show Text
+type Message struct {
+	To      []string
+	Subject string
+	Html    string
+	Text    string
+}
+
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+var provider Mailer
+
+func Provider() Mailer {
+	return provider
+}
+
+// SetProvider replaces provider, used by tests and local development
+func SetProvider(p Mailer) {
+	provider = p
+}
+
+func Init() Mailer {
+	if config.Conf.MailDriver == config.MAIL_DRIVER_FAKE {
+		provider = NewFakeMailer()
+	} else if config.Conf.MailHost == "" || config.Conf.MailFromAddress == "" {
+		// email is optional, app works without smtp server
+		log.Println("mail provider: host or from address is not set")
+		provider = NewFakeMailer()
+	} else {
+		provider = NewSmtpMailer(SmtpConfig{
+			Host:       config.Conf.MailHost,
+			Port:       config.Conf.MailPort,
+			Username:   config.Conf.MailUsername,
+			Password:   config.Conf.MailPassword,
+			Encryption: config.Conf.MailEncryption,
+			From:       config.Conf.MailFromAddress,
+			FromName:   config.Conf.MailFromName,
+		})
+	}
+	return provider
+}
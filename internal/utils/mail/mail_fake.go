@@ -0,0 +1,36 @@
+package mail
+
+import (
+	"context"
+	"sync"
+)
+
+// only last sends are kept, fake is also used when smtp is not configured
+const fakeSentLimit = 1000
+
+// FakeMailer records messages instead of sending
+type FakeMailer struct {
+	mu   sync.Mutex
+	Sent []Message
+}
+
+func NewFakeMailer() *FakeMailer {
+	return &FakeMailer{}
+}
+
+func (p *FakeMailer) Send(ctx context.Context, msg Message) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.Sent = append(p.Sent, msg)
+	if len(p.Sent) > fakeSentLimit {
+		p.Sent = p.Sent[len(p.Sent)-fakeSentLimit:]
+	}
+	return nil
+}
+
+// Messages returns copy of recorded sends
+func (p *FakeMailer) Messages() []Message {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]Message{}, p.Sent...)
+}
@@ -0,0 +1,177 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"github.com/mekdep/server/config"
+)
+
+const smtpTimeout = time.Second * 30
+
+type SmtpConfig struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	// tls is STARTTLS after connect, ssl is implicit tls, empty is plain (local sink)
+	Encryption string
+	From       string
+	FromName   string
+}
+
+// SmtpMailer opens one connection per message, digests are sent rarely
+type SmtpMailer struct {
+	conf SmtpConfig
+}
+
+func NewSmtpMailer(conf SmtpConfig) *SmtpMailer {
+	return &SmtpMailer{conf: conf}
+}
+
+func (p *SmtpMailer) Send(ctx context.Context, msg Message) error {
+	if len(msg.To) < 1 {
+		return errors.New("mail: no recipients")
+	}
+	body, err := p.build(msg)
+	if err != nil {
+		return err
+	}
+	c, err := p.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	if p.conf.Username != "" {
+		err = c.Auth(smtp.PlainAuth("", p.conf.Username, p.conf.Password, p.conf.Host))
+		if err != nil {
+			return err
+		}
+	}
+	if err = c.Mail(p.conf.From); err != nil {
+		return err
+	}
+	for _, to := range msg.To {
+		if err = c.Rcpt(to); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err = w.Write(body); err != nil {
+		return err
+	}
+	if err = w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+func (p *SmtpMailer) dial(ctx context.Context) (*smtp.Client, error) {
+	addr := net.JoinHostPort(p.conf.Host, p.conf.Port)
+	dialer := &net.Dialer{Timeout: smtpTimeout}
+	tlsConf := &tls.Config{ServerName: p.conf.Host}
+
+	var conn net.Conn
+	var err error
+	if p.conf.Encryption == config.MAIL_ENCRYPTION_SSL {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: tlsConf}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(smtpTimeout)
+	}
+	conn.SetDeadline(deadline)
+
+	c, err := smtp.NewClient(conn, p.conf.Host)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if p.conf.Encryption == config.MAIL_ENCRYPTION_TLS {
+		if err = c.StartTLS(tlsConf); err != nil {
+			c.Close()
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+func (p *SmtpMailer) build(msg Message) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	from := mail.Address{Name: p.conf.FromName, Address: p.conf.From}
+	headers := []string{
+		"From: " + from.String(),
+		"To: " + strings.Join(msg.To, ", "),
+		"Subject: " + mime.BEncoding.Encode("utf-8", msg.Subject),
+		"Date: " + time.Now().Format(time.RFC1123Z),
+		"Message-ID: " + messageId(p.conf.From),
+		"MIME-Version: 1.0",
+	}
+	mw := multipart.NewWriter(buf)
+	headers = append(headers, "Content-Type: multipart/alternative; boundary="+mw.Boundary())
+	head := strings.Join(headers, "\r\n") + "\r\n\r\n"
+
+	parts := []struct {
+		contentType string
+		body        string
+	}{
+		{"text/plain; charset=utf-8", msg.Text},
+		{"text/html; charset=utf-8", msg.Html},
+	}
+	for _, part := range parts {
+		if part.body == "" {
+			continue
+		}
+		w, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qw := quotedprintable.NewWriter(w)
+		if _, err = qw.Write([]byte(part.body)); err != nil {
+			return nil, err
+		}
+		if err = qw.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	return append([]byte(head), buf.Bytes()...), nil
+}
+
+func messageId(from string) string {
+	domain := "localhost"
+	if i := strings.LastIndex(from, "@"); i >= 0 {
+		domain = from[i+1:]
+	}
+	b := make([]byte, 12)
+	rand.Read(b)
+	return fmt.Sprintf("<%s.%d@%s>", hex.EncodeToString(b), time.Now().UnixNano(), domain)
+}
@@ -0,0 +1,103 @@
+package mail
+
+import (
+	"bytes"
+	"embed"
+	htmltemplate "html/template"
+	"io/fs"
+	"sync"
+	texttemplate "text/template"
+
+	"github.com/mekdep/server/config"
+)
+
+const LangTm = "tm"
+const LangRu = "ru"
+
+var Langs = []string{LangTm, LangRu}
+
+const (
+	TemplateVerifyEmail    = "verify_email"
+	TemplatePasswordReset  = "password_reset"
+	TemplateWeeklyDigest   = "weekly_digest"
+	TemplateReportDeadline = "report_deadline"
+)
+
+// every template has <name>.<lang>.txt with "subject" block and text body,
+// and <name>.<lang>.html with "content" and "footer" blocks placed into layout.html
+//
+//go:embed templates
+var templatesFS embed.FS
+
+var templatesCache sync.Map
+
+type parsedTemplate struct {
+	html *htmltemplate.Template
+	text *texttemplate.Template
+}
+
+// Lang returns supported language, default from config otherwise
+func Lang(lang string) string {
+	for _, v := range Langs {
+		if v == lang {
+			return lang
+		}
+	}
+	if config.Conf.MailLang != "" && config.Conf.MailLang != lang {
+		return Lang(config.Conf.MailLang)
+	}
+	return LangTm
+}
+
+// Render returns message of template without recipients
+func Render(name string, lang string, data map[string]interface{}) (Message, error) {
+	msg := Message{}
+	t, err := parse(name, Lang(lang))
+	if err != nil {
+		return msg, err
+	}
+	if data == nil {
+		data = map[string]interface{}{}
+	}
+	if _, ok := data["SupportEmail"]; !ok {
+		data["SupportEmail"] = config.Conf.SupportEmail
+	}
+	buf := &bytes.Buffer{}
+	if err = t.text.ExecuteTemplate(buf, "subject", data); err != nil {
+		return msg, err
+	}
+	msg.Subject = buf.String()
+	buf.Reset()
+	if err = t.text.Execute(buf, data); err != nil {
+		return msg, err
+	}
+	msg.Text = buf.String()
+	buf.Reset()
+	if err = t.html.ExecuteTemplate(buf, "layout.html", data); err != nil {
+		return msg, err
+	}
+	msg.Html = buf.String()
+	return msg, nil
+}
+
+func parse(name string, lang string) (*parsedTemplate, error) {
+	key := name + "." + lang
+	if v, ok := templatesCache.Load(key); ok {
+		return v.(*parsedTemplate), nil
+	}
+	sub, err := fs.Sub(templatesFS, "templates")
+	if err != nil {
+		return nil, err
+	}
+	text, err := texttemplate.ParseFS(sub, key+".txt")
+	if err != nil {
+		return nil, err
+	}
+	html, err := htmltemplate.ParseFS(sub, "layout.html", key+".html")
+	if err != nil {
+		return nil, err
+	}
+	t := &parsedTemplate{html: html, text: text}
+	templatesCache.Store(key, t)
+	return t, nil
+}
@@ -0,0 +1,104 @@
+package utils
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const metricsNamespace = "mekdep"
+
+var (
+	MetricsHttpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Latency of api requests by gin route and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	MetricsSmsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "sms",
+		Name:      "messages_total",
+		Help:      "Sms messages by type and result (sent, failed), counted per phone.",
+	}, []string{"type", "result"})
+
+	MetricsPushTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "push",
+		Name:      "deliveries_total",
+		Help:      "Push deliveries by result (sent, retry, failed, pruned), counted per device token.",
+	}, []string{"result"})
+
+	MetricsMailTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "mail",
+		Name:      "messages_total",
+		Help:      "Emails by template and result (sent, failed).",
+	}, []string{"template", "result"})
+
+	MetricsPaymentsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "payments",
+		Name:      "transactions_total",
+		Help:      "Payment transactions by bank and result status.",
+	}, []string{"bank", "status"})
+
+	MetricsChatConnections = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "chat",
+		Name:      "connections",
+		Help:      "Open chat websocket connections by message group.",
+	}, []string{"group_id"})
+
+	MetricsEventConnections = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "events",
+		Name:      "connections",
+		Help:      "Open event stream connections.",
+	})
+
+	MetricsCacheRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "app_cache",
+		Name:      "requests_total",
+		Help:      "App cache lookups by result (hit, miss).",
+	}, []string{"result"})
+
+	MetricsJobDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "jobs",
+		Name:      "duration_seconds",
+		Help:      "Duration of scheduled jobs.",
+		Buckets:   []float64{1, 5, 15, 30, 60, 120, 300, 600, 1800, 3600},
+	}, []string{"job"})
+)
+
+// usage: defer utils.MetricsJobTimer("SendDailySms").ObserveDuration()
+func MetricsJobTimer(job string) *prometheus.Timer {
+	return prometheus.NewTimer(MetricsJobDuration.WithLabelValues(job))
+}
+
+func MetricsSms(smsType string, count int, err error) {
+	result := "sent"
+	if err != nil {
+		result = "failed"
+	}
+	MetricsSmsTotal.WithLabelValues(smsType, result).Add(float64(count))
+}
+
+func MetricsMail(template string, err error) {
+	result := "sent"
+	if err != nil {
+		result = "failed"
+	}
+	MetricsMailTotal.WithLabelValues(template, result).Inc()
+}
+
+func MetricsCache(found bool) {
+	result := "miss"
+	if found {
+		result = "hit"
+	}
+	MetricsCacheRequestsTotal.WithLabelValues(result).Inc()
+}
@@ -0,0 +1,56 @@
+package push
+
+import (
+	"context"
+	"log"
+
+	"github.com/mekdep/server/config"
+)
+
+// Message is same for all tokens of one send
+type Message struct {
+	Title string
+	Body  string
+	Data  map[string]string
+}
+
+// Result is outcome of sending to one token, in order of given tokens
+type Result struct {
+	Token     string
+	MessageId string
+	Error     error
+	// Unregistered token will never be delivered, it should be removed from sessions
+	Unregistered bool
+	// Retry is set for transient failures (unavailable, quota, internal)
+	Retry bool
+}
+
+type PushProvider interface {
+	Send(ctx context.Context, tokens []string, msg Message) ([]Result, error)
+}
+
+var provider PushProvider
+
+func Provider() PushProvider {
+	return provider
+}
+
+// SetProvider replaces provider, used by tests and local development
+func SetProvider(p PushProvider) {
+	provider = p
+}
+
+func Init() PushProvider {
+	var err error
+	if config.Conf.PushDriver == config.PUSH_DRIVER_FAKE {
+		provider = NewFakeProvider()
+	} else {
+		provider, err = NewFcmProvider(context.Background(), config.Conf.FcmCredentialsFile)
+	}
+	if err != nil {
+		// push is optional, app works without credentials
+		log.Println("push provider: ", err)
+		provider = NewFakeProvider()
+	}
+	return provider
+}
@@ -0,0 +1,51 @@
+package push
+
+import (
+	"context"
+	"sync"
+)
+
+// only last sends are kept, fake is also used when fcm credentials are missing
+const fakeSentLimit = 1000
+
+type FakeSent struct {
+	Tokens  []string
+	Message Message
+}
+
+// FakeProvider records messages instead of sending, tokens in Fail are answered with given result
+type FakeProvider struct {
+	mu   sync.Mutex
+	Sent []FakeSent
+	Fail map[string]Result
+}
+
+func NewFakeProvider() *FakeProvider {
+	return &FakeProvider{Fail: map[string]Result{}}
+}
+
+func (p *FakeProvider) Send(ctx context.Context, tokens []string, msg Message) ([]Result, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.Sent = append(p.Sent, FakeSent{Tokens: tokens, Message: msg})
+	if len(p.Sent) > fakeSentLimit {
+		p.Sent = p.Sent[len(p.Sent)-fakeSentLimit:]
+	}
+	res := []Result{}
+	for _, t := range tokens {
+		if r, ok := p.Fail[t]; ok {
+			r.Token = t
+			res = append(res, r)
+			continue
+		}
+		res = append(res, Result{Token: t, MessageId: "fake"})
+	}
+	return res, nil
+}
+
+// Messages returns copy of recorded sends
+func (p *FakeProvider) Messages() []FakeSent {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]FakeSent{}, p.Sent...)
+}
@@ -0,0 +1,67 @@
+package push
+
+import (
+	"context"
+
+	"firebase.google.com/go/v4/messaging"
+	"github.com/appleboy/go-fcm"
+)
+
+// fcm accepts up to 500 tokens in one multicast
+const fcmMulticastLimit = 500
+
+type FcmProvider struct {
+	client *fcm.Client
+}
+
+// NewFcmProvider creates client once, it keeps oauth token between sends
+func NewFcmProvider(ctx context.Context, credentialsFile string) (*FcmProvider, error) {
+	client, err := fcm.NewClient(ctx, fcm.WithCredentialsFile(credentialsFile))
+	if err != nil {
+		return nil, err
+	}
+	return &FcmProvider{client: client}, nil
+}
+
+func (p *FcmProvider) Send(ctx context.Context, tokens []string, msg Message) ([]Result, error) {
+	res := []Result{}
+	for start := 0; start < len(tokens); start += fcmMulticastLimit {
+		end := min(start+fcmMulticastLimit, len(tokens))
+		part := tokens[start:end]
+		br, err := p.client.SendMulticast(ctx, &messaging.MulticastMessage{
+			Notification: &messaging.Notification{
+				Title: msg.Title,
+				Body:  msg.Body,
+			},
+			Data: msg.Data,
+			APNS: &messaging.APNSConfig{
+				Headers: map[string]string{
+					"apns-priority": "10",
+				},
+			},
+			Tokens: part,
+		})
+		if err != nil {
+			// whole request failed, all tokens of part can be retried
+			for _, t := range part {
+				res = append(res, Result{Token: t, Error: err, Retry: isFcmTransient(err)})
+			}
+			continue
+		}
+		for i, r := range br.Responses {
+			item := Result{Token: part[i], MessageId: r.MessageID}
+			if !r.Success {
+				item.Error = r.Error
+				item.Unregistered = messaging.IsUnregistered(r.Error) || messaging.IsSenderIDMismatch(r.Error) ||
+					messaging.IsInvalidArgument(r.Error)
+				item.Retry = isFcmTransient(r.Error)
+			}
+			res = append(res, item)
+		}
+	}
+	return res, nil
+}
+
+func isFcmTransient(err error) bool {
+	return messaging.IsUnavailable(err) || messaging.IsInternal(err) || messaging.IsQuotaExceeded(err)
+}
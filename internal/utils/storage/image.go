@@ -0,0 +1,282 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"mime/multipart"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/HugoSmits86/nativewebp"
+	xdraw "golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+var ImageExts = map[string]bool{
+	".png": true, ".jpg": true, ".jpeg": true, ".webp": true,
+}
+
+// ImageMaxSize is the longest side of stored original, ThumbSizes are generated next to it
+const ImageMaxSize = 1920
+
+var ThumbSizes = []int{160, 480}
+
+// decoding allocates 4 bytes per pixel, small file can be a huge image
+const imageMaxPixels = 50_000_000
+
+func IsImage(p string) bool {
+	return ImageExts[Ext(p)]
+}
+
+// VariantPath gives path of processed variant: "a/b/photo.jpg" -> "a/b/photo_160.webp"
+func VariantPath(p string, size int, ext string) string {
+	stem := strings.TrimSuffix(p, path.Ext(p))
+	if size > 0 {
+		stem += "_" + strconv.Itoa(size)
+	}
+	return stem + ext
+}
+
+// ThumbPath is the smallest thumbnail in the same format as original
+func ThumbPath(p string) string {
+	return VariantPath(p, ThumbSizes[0], path.Ext(p))
+}
+
+func variantPaths(p string) []string {
+	res := []string{}
+	for _, size := range ThumbSizes {
+		res = append(res, VariantPath(p, size, path.Ext(p)), VariantPath(p, size, ".webp"))
+	}
+	return res
+}
+
+// UploadImage validates image, strips metadata (exif, gps) by re-encoding,
+// fixes orientation and saves original with thumbnails in both original and webp format.
+// webp encoder is lossless, so full size is kept only in original format
+func UploadImage(ctx context.Context, handler *multipart.FileHeader, folder string) (string, int64, error) {
+	f, _, err := openChecked(ctx, handler, folder, ImageExts)
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return "", 0, err
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return "", 0, errors.New(ErrInvalidContent.Error() + ": " + err.Error())
+	}
+	if cfg.Width*cfg.Height > imageMaxPixels {
+		return "", 0, ErrFileTooLarge
+	}
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return "", 0, err
+	}
+	if format == "jpeg" {
+		img = orient(img, jpegOrientation(data))
+	}
+
+	// png keeps transparency, others are stored as jpeg
+	ext := ".jpg"
+	if format == "png" {
+		ext = ".png"
+	}
+	name := SanitizeFilename(handler.Filename)
+	filePath := folder + "/" + RandomHash(16) + "/" + strings.TrimSuffix(name, path.Ext(name)) + ext
+
+	img = resize(img, ImageMaxSize)
+	size, err := putImage(ctx, filePath, img)
+	if err != nil {
+		return "", 0, err
+	}
+	if err := putThumbs(ctx, filePath, img); err != nil {
+		return "", 0, err
+	}
+	return filePath, size / 1024, nil
+}
+
+// ProcessImage generates thumbnails of already stored image (uploaded before processing existed)
+func ProcessImage(ctx context.Context, p string) error {
+	r, err := Disk().Open(ctx, p)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	if format == "jpeg" {
+		img = orient(img, jpegOrientation(data))
+	}
+	return putThumbs(ctx, p, img)
+}
+
+func putThumbs(ctx context.Context, p string, img image.Image) error {
+	for _, s := range ThumbSizes {
+		thumb := resize(img, s)
+		if _, err := putImage(ctx, VariantPath(p, s, path.Ext(p)), thumb); err != nil {
+			return err
+		}
+		if _, err := putImage(ctx, VariantPath(p, s, ".webp"), thumb); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PutImage saves generated image (eg: rendered pdf page) fitted into box, format is by extension of path
+func PutImage(ctx context.Context, p string, img image.Image, box int) error {
+	_, err := putImage(ctx, p, resize(img, box))
+	return err
+}
+
+func putImage(ctx context.Context, p string, img image.Image) (int64, error) {
+	buf := bytes.Buffer{}
+	var err error
+	contentType := "image/jpeg"
+	switch path.Ext(p) {
+	case ".png":
+		contentType = "image/png"
+		err = png.Encode(&buf, img)
+	case ".webp":
+		contentType = "image/webp"
+		err = nativewebp.Encode(&buf, img, nil)
+	default:
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: 85})
+	}
+	if err != nil {
+		return 0, err
+	}
+	size := int64(buf.Len())
+	return size, Disk().Put(ctx, p, &buf, size, contentType)
+}
+
+// resize fits image into box x box square, smaller images are not enlarged
+func resize(img image.Image, box int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= box && h <= box {
+		return img
+	}
+	if w > h {
+		h, w = h*box/w, box
+	} else {
+		w, h = w*box/h, box
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, max1(w), max1(h)))
+	xdraw.CatmullRom.Scale(dst, dst.Rect, img, b, draw.Over, nil)
+	return dst
+}
+
+func max1(v int) int {
+	if v < 1 {
+		return 1
+	}
+	return v
+}
+
+// orient applies exif orientation, values are from exif spec (1 is normal)
+func orient(img image.Image, o int) image.Image {
+	if o < 2 || o > 8 {
+		return img
+	}
+	b := img.Bounds()
+	src := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(src, src.Rect, img, b.Min, draw.Src)
+	w, h := b.Dx(), b.Dy()
+	dw, dh := w, h
+	if o >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			var sx, sy int
+			switch o {
+			case 2:
+				sx, sy = w-1-x, y
+			case 3:
+				sx, sy = w-1-x, h-1-y
+			case 4:
+				sx, sy = x, h-1-y
+			case 5:
+				sx, sy = y, x
+			case 6:
+				sx, sy = y, h-1-x
+			case 7:
+				sx, sy = w-1-y, h-1-x
+			case 8:
+				sx, sy = w-1-y, x
+			}
+			copy(dst.Pix[dst.PixOffset(x, y):dst.PixOffset(x, y)+4], src.Pix[src.PixOffset(sx, sy):src.PixOffset(sx, sy)+4])
+		}
+	}
+	return dst
+}
+
+// jpegOrientation reads orientation tag from exif (APP1) segment, 1 if not found
+func jpegOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+	i := 2
+	for i+4 <= len(data) {
+		if data[i] != 0xFF {
+			return 1
+		}
+		marker := data[i+1]
+		segLen := int(binary.BigEndian.Uint16(data[i+2:]))
+		// start of scan, no more metadata
+		if marker == 0xDA || segLen < 2 || i+2+segLen > len(data) {
+			return 1
+		}
+		seg := data[i+4 : i+2+segLen]
+		if marker == 0xE1 && len(seg) > 14 && string(seg[:6]) == "Exif\x00\x00" {
+			return exifOrientation(seg[6:])
+		}
+		i += 2 + segLen
+	}
+	return 1
+}
+
+func exifOrientation(tiff []byte) int {
+	var bo binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		bo = binary.LittleEndian
+	case "MM":
+		bo = binary.BigEndian
+	default:
+		return 1
+	}
+	ifd := int(bo.Uint32(tiff[4:]))
+	if ifd+2 > len(tiff) {
+		return 1
+	}
+	count := int(bo.Uint16(tiff[ifd:]))
+	for k := 0; k < count; k++ {
+		e := ifd + 2 + k*12
+		if e+12 > len(tiff) {
+			return 1
+		}
+		if bo.Uint16(tiff[e:]) == 0x0112 {
+			return int(bo.Uint16(tiff[e+8:]))
+		}
+	}
+	return 1
+}
@@ -0,0 +1,104 @@
+package storage
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/mekdep/server/config"
+)
+
+var ErrInfected = errors.New("file is infected")
+
+// Scanner checks uploaded content for viruses, nil scanner skips the check
+type Scanner interface {
+	Scan(ctx context.Context, r io.Reader) error
+}
+
+var scanner Scanner
+
+func SetScanner(s Scanner) {
+	scanner = s
+}
+
+func initScanner() {
+	if config.Conf.ClamdAddress != "" {
+		scanner = NewClamdScanner(config.Conf.ClamdAddress)
+	}
+}
+
+// ClamdScanner sends file by INSTREAM command of clamd,
+// address is "tcp://host:port" or "unix:///path/to/clamd.ctl"
+type ClamdScanner struct {
+	network string
+	address string
+	timeout time.Duration
+}
+
+func NewClamdScanner(address string) *ClamdScanner {
+	s := &ClamdScanner{network: "tcp", address: address, timeout: 60 * time.Second}
+	if a, ok := strings.CutPrefix(address, "unix://"); ok {
+		s.network, s.address = "unix", a
+	} else if a, ok := strings.CutPrefix(address, "tcp://"); ok {
+		s.address = a
+	}
+	return s
+}
+
+const clamdChunkSize = 64 << 10
+
+func (s *ClamdScanner) Scan(ctx context.Context, r io.Reader) error {
+	d := net.Dialer{Timeout: 5 * time.Second}
+	conn, err := d.DialContext(ctx, s.network, s.address)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(s.timeout))
+
+	if _, err := conn.Write([]byte("zINSTREAM\x00")); err != nil {
+		return err
+	}
+	buf := make([]byte, clamdChunkSize)
+	size := make([]byte, 4)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			binary.BigEndian.PutUint32(size, uint32(n))
+			if _, err := conn.Write(size); err != nil {
+				return err
+			}
+			if _, err := conn.Write(buf[:n]); err != nil {
+				return err
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+	// zero length chunk ends the stream
+	if _, err := conn.Write([]byte{0, 0, 0, 0}); err != nil {
+		return err
+	}
+
+	res, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil && err != io.EOF {
+		return err
+	}
+	res = strings.TrimRight(res, "\x00\n")
+	if strings.HasSuffix(res, "FOUND") {
+		return errors.New(ErrInfected.Error() + ": " + strings.TrimSpace(strings.TrimPrefix(strings.TrimSuffix(res, "FOUND"), "stream:")))
+	}
+	if !strings.HasSuffix(res, "OK") {
+		return errors.New("clamd: " + res)
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"testing"
+)
+
+// fakeClamd reads one INSTREAM of connection and answers with reply, chunks are sent to received
+func fakeClamd(t *testing.T, reply string, received chan<- [][]byte) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		cmd := make([]byte, len("zINSTREAM\x00"))
+		if _, err := io.ReadFull(conn, cmd); err != nil || string(cmd) != "zINSTREAM\x00" {
+			conn.Write([]byte("UNKNOWN COMMAND\x00"))
+			received <- nil
+			return
+		}
+		chunks := [][]byte{}
+		size := make([]byte, 4)
+		for {
+			if _, err := io.ReadFull(conn, size); err != nil {
+				received <- nil
+				return
+			}
+			n := binary.BigEndian.Uint32(size)
+			if n == 0 {
+				break
+			}
+			chunk := make([]byte, n)
+			if _, err := io.ReadFull(conn, chunk); err != nil {
+				received <- nil
+				return
+			}
+			chunks = append(chunks, chunk)
+		}
+		conn.Write([]byte(reply + "\x00"))
+		received <- chunks
+	}()
+	return "tcp://" + l.Addr().String()
+}
+
+func TestClamdScannerInstream(t *testing.T) {
+	received := make(chan [][]byte, 1)
+	s := NewClamdScanner(fakeClamd(t, "stream: OK", received))
+	data := bytes.Repeat([]byte("0123456789"), clamdChunkSize/5)
+	err := s.Scan(context.Background(), bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	chunks := <-received
+	if len(chunks) < 2 {
+		t.Fatalf("stream is sent in %d chunks", len(chunks))
+	}
+	for _, c := range chunks {
+		if len(c) > clamdChunkSize {
+			t.Errorf("chunk of %d bytes is over limit", len(c))
+		}
+	}
+	if !bytes.Equal(bytes.Join(chunks, nil), data) {
+		t.Error("received stream differs from file")
+	}
+}
+
+func TestClamdScannerFound(t *testing.T) {
+	received := make(chan [][]byte, 1)
+	s := NewClamdScanner(fakeClamd(t, "stream: Eicar-Test-Signature FOUND", received))
+	err := s.Scan(context.Background(), strings.NewReader("X5O!P%@AP"))
+	<-received
+	if err == nil || err.Error() != ErrInfected.Error()+": Eicar-Test-Signature" {
+		t.Errorf("infected file: %v", err)
+	}
+}
+
+func TestClamdScannerError(t *testing.T) {
+	received := make(chan [][]byte, 1)
+	s := NewClamdScanner(fakeClamd(t, "INSTREAM size limit exceeded. ERROR", received))
+	err := s.Scan(context.Background(), strings.NewReader("data"))
+	<-received
+	if err == nil || err.Error() != "clamd: INSTREAM size limit exceeded. ERROR" {
+		t.Errorf("clamd error: %v", err)
+	}
+	// clamd is down
+	s = NewClamdScanner("tcp://127.0.0.1:1")
+	if err := s.Scan(context.Background(), strings.NewReader("data")); err == nil {
+		t.Error("scan without clamd: expected error")
+	}
+}
+
+func TestNewClamdScanner(t *testing.T) {
+	cases := map[string][2]string{
+		"tcp://127.0.0.1:3310":             {"tcp", "127.0.0.1:3310"},
+		"127.0.0.1:3310":                   {"tcp", "127.0.0.1:3310"},
+		"unix:///var/run/clamav/clamd.ctl": {"unix", "/var/run/clamav/clamd.ctl"},
+	}
+	for address, want := range cases {
+		s := NewClamdScanner(address)
+		if s.network != want[0] || s.address != want[1] {
+			t.Errorf("NewClamdScanner(%q) = %s %s", address, s.network, s.address)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package storage
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/mekdep/server/config"
+)
+
+// signed url is valid from ttl up to 2*ttl, see FileUrl
+const SignedUrlTtl = time.Minute * 15
+
+var signKey []byte
+
+func initSignKey() {
+	if config.Conf.StorageSignKey != "" {
+		signKey = []byte(config.Conf.StorageSignKey)
+		return
+	}
+	// signed urls will not survive restart, set storage_sign_key on prod
+	signKey = make([]byte, 32)
+	_, _ = rand.Read(signKey)
+	log.Println("storage_sign_key not set, using random key")
+}
+
+func Sign(p string, expires int64) string {
+	mac := hmac.New(sha256.New, signKey)
+	mac.Write([]byte(p + "\n" + strconv.FormatInt(expires, 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func VerifySignature(p string, expires string, signature string) bool {
+	exp, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > exp {
+		return false
+	}
+	return hmac.Equal([]byte(Sign(p, exp)), []byte(signature))
+}
+
+// FileUrl builds download url of uploaded file, private files get signed url which is served only
+// with token of user (see api.FileServe). Expire time is rounded to ttl window so url doesn't change
+// on every response (client cache)
+func FileUrl(p string) string {
+	u := config.Conf.AppUrl + "/uploads/" + p
+	if !IsPrivate(p) {
+		return u
+	}
+	exp := time.Now().Truncate(SignedUrlTtl).Add(2 * SignedUrlTtl).Unix()
+	return u + "?expires=" + strconv.FormatInt(exp, 10) + "&signature=" + Sign(p, exp)
+}
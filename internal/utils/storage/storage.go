@@ -0,0 +1,171 @@
+package storage
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"io"
+	"log"
+	"mime/multipart"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/mekdep/server/config"
+)
+
+// FileStorage keeps uploaded files by relative path, eg: "users/AbCd.../passport.pdf"
+type FileStorage interface {
+	Put(ctx context.Context, path string, r io.Reader, size int64, contentType string) error
+	Open(ctx context.Context, path string) (io.ReadCloser, error)
+	Delete(ctx context.Context, path string) error
+	// PresignedUrl returns direct download url, empty if files are served by api itself
+	PresignedUrl(ctx context.Context, path string, expires time.Duration) (string, error)
+}
+
+var ErrInvalidPath = errors.New("invalid file path")
+var ErrInvalidExtension = errors.New("invalid extension")
+
+var disk FileStorage
+
+func Disk() FileStorage {
+	return disk
+}
+
+func Init() FileStorage {
+	var err error
+	if config.Conf.StorageDriver == config.STORAGE_DRIVER_S3 {
+		disk, err = NewS3Storage()
+	} else {
+		disk, err = NewLocalStorage(config.Conf.StorageLocalPath)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	initSignKey()
+	initScanner()
+	return disk
+}
+
+// folders which need signed url to download (personal documents of users)
+var PrivateFolders = []string{"users", "teacher_excuses", "school_transfers", "contact_files", "submissions"}
+
+var DefaultExts = map[string]bool{
+	".png": true, ".jpg": true, ".jpeg": true, ".zip": true, ".rar": true,
+	".mp3": true, ".mp4": true, ".pdf": true, ".docx": true, ".doc": true, ".xlsx": true,
+}
+
+func IsPrivate(p string) bool {
+	folder := strings.SplitN(p, "/", 2)[0]
+	for _, v := range PrivateFolders {
+		if v == folder {
+			return true
+		}
+	}
+	return false
+}
+
+// CleanPath validates relative path, prevents traversal out of uploads root
+func CleanPath(p string) (string, error) {
+	p = strings.TrimPrefix(p, "/")
+	if p == "" || strings.Contains(p, "..") || strings.Contains(p, "\\") {
+		return "", ErrInvalidPath
+	}
+	cleaned := path.Clean(p)
+	if cleaned != p || strings.HasPrefix(cleaned, "/") {
+		return "", ErrInvalidPath
+	}
+	return cleaned, nil
+}
+
+// PathFromUrl converts url given by FileUrl back to relative path
+func PathFromUrl(url string) string {
+	if i := strings.Index(url, "?"); i >= 0 {
+		url = url[:i]
+	}
+	if i := strings.Index(url, "/uploads/"); i >= 0 {
+		url = url[i+len("/uploads/"):]
+	}
+	return url
+}
+
+func Ext(fileName string) string {
+	fParts := strings.Split(fileName, ".")
+	return "." + strings.ToLower(fParts[len(fParts)-1])
+}
+
+// openChecked opens multipart file after checking extension, size, sniffed content and antivirus
+func openChecked(ctx context.Context, handler *multipart.FileHeader, folder string, exts map[string]bool) (multipart.File, string, error) {
+	ext := Ext(handler.Filename)
+	if !exts[ext] {
+		return nil, "", errors.New(ErrInvalidExtension.Error() + ": " + ext)
+	}
+	if err := CheckSize(folder, handler.Size); err != nil {
+		return nil, "", err
+	}
+	f, err := handler.Open()
+	if err != nil {
+		return nil, "", err
+	}
+	mimeType, err := DetectMime(f)
+	if err == nil {
+		err = CheckMime(ext, mimeType)
+	}
+	if err == nil && scanner != nil {
+		err = scanner.Scan(ctx, f)
+		if err == nil {
+			_, err = f.Seek(0, io.SeekStart)
+		}
+	}
+	if err != nil {
+		f.Close()
+		return nil, "", err
+	}
+	return f, mimeType, nil
+}
+
+// Upload saves multipart file into "folder/hash/filename"
+func Upload(ctx context.Context, handler *multipart.FileHeader, folder string, exts map[string]bool) (string, int64, error) {
+	f, mimeType, err := openChecked(ctx, handler, folder, exts)
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+	filePath := folder + "/" + RandomHash(16) + "/" + SanitizeFilename(handler.Filename)
+	if _, err := CleanPath(filePath); err != nil {
+		return "", 0, err
+	}
+	err = Disk().Put(ctx, filePath, f, handler.Size, mimeType)
+	if err != nil {
+		return "", 0, err
+	}
+	return filePath, handler.Size / 1024, nil
+}
+
+// Delete removes file only if it is inside of given folder, with its image variants
+func Delete(ctx context.Context, p string, folder string) error {
+	p, err := CleanPath(PathFromUrl(p))
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(p, folder+"/") {
+		return ErrInvalidPath
+	}
+	if IsImage(p) {
+		for _, v := range variantPaths(p) {
+			_ = Disk().Delete(ctx, v)
+		}
+	}
+	return Disk().Delete(ctx, p)
+}
+
+const letterBytes = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+
+func RandomHash(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	for i := range b {
+		b[i] = letterBytes[int(b[i])%len(letterBytes)]
+	}
+	return string(b)
+}
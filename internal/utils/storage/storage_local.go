@@ -0,0 +1,60 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+type LocalStorage struct {
+	root string
+}
+
+func NewLocalStorage(root string) (*LocalStorage, error) {
+	err := os.MkdirAll(root, 0755)
+	if err != nil {
+		return nil, err
+	}
+	return &LocalStorage{root: root}, nil
+}
+
+// FullPath is used to serve files from disk
+func (s *LocalStorage) FullPath(p string) string {
+	return filepath.Join(s.root, filepath.FromSlash(p))
+}
+
+func (s *LocalStorage) Put(ctx context.Context, p string, r io.Reader, size int64, contentType string) error {
+	fp := s.FullPath(p)
+	err := os.MkdirAll(filepath.Dir(fp), 0755)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(fp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(f, r)
+	return err
+}
+
+func (s *LocalStorage) Open(ctx context.Context, p string) (io.ReadCloser, error) {
+	return os.Open(s.FullPath(p))
+}
+
+func (s *LocalStorage) Delete(ctx context.Context, p string) error {
+	fp := s.FullPath(p)
+	err := os.Remove(fp)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	// remove hash folder if it became empty, error means it's not empty
+	_ = os.Remove(filepath.Dir(fp))
+	return nil
+}
+
+func (s *LocalStorage) PresignedUrl(ctx context.Context, p string, expires time.Duration) (string, error) {
+	return "", nil
+}
@@ -0,0 +1,56 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/mekdep/server/config"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// S3Storage works with any S3 compatible server (minio, ceph etc.)
+type S3Storage struct {
+	client *minio.Client
+	bucket string
+}
+
+func NewS3Storage() (*S3Storage, error) {
+	if config.Conf.StorageS3Endpoint == "" || config.Conf.StorageS3Bucket == "" {
+		return nil, errors.New("storage s3 endpoint or bucket not set")
+	}
+	client, err := minio.New(config.Conf.StorageS3Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(config.Conf.StorageS3AccessKey, config.Conf.StorageS3SecretKey, ""),
+		Secure: config.Conf.StorageS3UseSSL,
+		Region: config.Conf.StorageS3Region,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &S3Storage{client: client, bucket: config.Conf.StorageS3Bucket}, nil
+}
+
+func (s *S3Storage) Put(ctx context.Context, p string, r io.Reader, size int64, contentType string) error {
+	_, err := s.client.PutObject(ctx, s.bucket, p, r, size, minio.PutObjectOptions{
+		ContentType: contentType,
+	})
+	return err
+}
+
+func (s *S3Storage) Open(ctx context.Context, p string) (io.ReadCloser, error) {
+	return s.client.GetObject(ctx, s.bucket, p, minio.GetObjectOptions{})
+}
+
+func (s *S3Storage) Delete(ctx context.Context, p string) error {
+	return s.client.RemoveObject(ctx, s.bucket, p, minio.RemoveObjectOptions{})
+}
+
+func (s *S3Storage) PresignedUrl(ctx context.Context, p string, expires time.Duration) (string, error) {
+	u, err := s.client.PresignedGetObject(ctx, s.bucket, p, expires, nil)
+	if err != nil {
+		return "", err
+	}
+	return u.String(), nil
+}
@@ -0,0 +1,34 @@
+package storage
+
+import "testing"
+
+func TestCleanPath(t *testing.T) {
+	valid := map[string]string{
+		"users/AbCd/passport.pdf":  "users/AbCd/passport.pdf",
+		"/users/AbCd/passport.pdf": "users/AbCd/passport.pdf",
+		"books/cover.png":          "books/cover.png",
+	}
+	for p, want := range valid {
+		got, err := CleanPath(p)
+		if err != nil || got != want {
+			t.Errorf("CleanPath(%q) = %q, %v, want %q", p, got, err, want)
+		}
+	}
+	invalid := []string{
+		"",
+		"/",
+		"../etc/passwd",
+		"users/../../etc/passwd",
+		"users/..",
+		"//etc/passwd",
+		"users//passport.pdf",
+		"users/./passport.pdf",
+		"users/AbCd/",
+		"users\\AbCd\\passport.pdf",
+	}
+	for _, p := range invalid {
+		if got, err := CleanPath(p); err != ErrInvalidPath {
+			t.Errorf("CleanPath(%q) = %q, %v, expected ErrInvalidPath", p, got, err)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package storage
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"unicode"
+
+	"github.com/mekdep/server/config"
+)
+
+var ErrInvalidContent = errors.New("file content does not match extension")
+var ErrFileTooLarge = errors.New("file too large")
+
+// sniffed mime types allowed for extension, octet-stream is for formats
+// http.DetectContentType doesn't know (old office files, mp3 without id3)
+var extMimeTypes = map[string][]string{
+	".png":  {"image/png"},
+	".jpg":  {"image/jpeg"},
+	".jpeg": {"image/jpeg"},
+	".webp": {"image/webp"},
+	".pdf":  {"application/pdf"},
+	".zip":  {"application/zip"},
+	".docx": {"application/zip"},
+	".xlsx": {"application/zip"},
+	".rar":  {"application/x-rar-compressed"},
+	".mp3":  {"audio/mpeg", "application/octet-stream"},
+	".mp4":  {"video/mp4"},
+	".doc":  {"application/octet-stream"},
+}
+
+// FolderMaxSizeMb limits upload size per folder, others use config upload_max_size_mb
+var FolderMaxSizeMb = map[string]int64{
+	"users":            10,
+	"schools":          10,
+	"teacher_excuses":  10,
+	"school_transfers": 10,
+	"contact_files":    10,
+	"books":            200,
+	"lesson_pro":       100,
+	"assignments":      50,
+	"topics":           100,
+	"submissions":      20,
+	"notifications":    20,
+}
+
+func FolderMaxSize(folder string) int64 {
+	if mb, ok := FolderMaxSizeMb[folder]; ok {
+		return mb << 20
+	}
+	return config.Conf.UploadMaxSizeMb << 20
+}
+
+// formMaxSize is room for fields and part headers of multipart request besides files
+const formMaxSize = 1 << 20
+
+// RequestMaxSize limits whole multipart request by largest limit of folders it uploads to, files of request share it
+func RequestMaxSize(folders ...string) int64 {
+	var size int64
+	for _, v := range folders {
+		size = max(size, FolderMaxSize(v))
+	}
+	return size + formMaxSize
+}
+
+func CheckSize(folder string, size int64) error {
+	if size > FolderMaxSize(folder) {
+		return ErrFileTooLarge
+	}
+	return nil
+}
+
+// DetectMime sniffs first 512 bytes, reader must be seekable to rewind it
+func DetectMime(r io.ReadSeeker) (string, error) {
+	buf := make([]byte, 512)
+	n, err := io.ReadFull(r, buf)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", err
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	mt, _, _ := mime.ParseMediaType(http.DetectContentType(buf[:n]))
+	return mt, nil
+}
+
+func CheckMime(ext string, mimeType string) error {
+	for _, v := range extMimeTypes[ext] {
+		if v == mimeType {
+			return nil
+		}
+	}
+	return errors.New(ErrInvalidContent.Error() + ": " + mimeType)
+}
+
+// SanitizeFilename keeps letters, digits, "-" and "_" of base name,
+// spaces and dots become "_", name is shortened to 100 runes keeping extension
+func SanitizeFilename(name string) string {
+	name = path.Base(strings.ReplaceAll(name, "\\", "/"))
+	ext := path.Ext(name)
+	stem := strings.TrimSuffix(name, ext)
+	ext = strings.ToLower(ext)
+	// trailing dot (also base of empty name) is not extension
+	if ext == "." {
+		ext = ""
+	}
+	stem = strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_' {
+			return r
+		}
+		if unicode.IsSpace(r) || r == '.' {
+			return '_'
+		}
+		return -1
+	}, stem)
+	stem = strings.Trim(stem, "_")
+	if rs := []rune(stem); len(rs) > 100 {
+		stem = string(rs[:100])
+	}
+	if stem == "" {
+		stem = "file"
+	}
+	return stem + ext
+}
@@ -0,0 +1,36 @@
+package storage
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSanitizeFilename(t *testing.T) {
+	cases := map[string]string{
+		"report.pdf":                      "report.pdf",
+		"My Report v1.2.PDF":              "My_Report_v1_2.pdf",
+		"C:\\Users\\me\\passport.jpg":     "passport.jpg",
+		"../../etc/passwd":                "passwd",
+		"Sapak meýilnamasy ç.docx":        "Sapak_meýilnamasy_ç.docx",
+		"<script>alert(1)</script>.png":   "script.png",
+		"report.":                         "report",
+		"___.png":                         "file.png",
+		"":                                "file",
+		strings.Repeat("a", 150) + ".pdf": strings.Repeat("a", 100) + ".pdf",
+	}
+	for name, want := range cases {
+		if got := SanitizeFilename(name); got != want {
+			t.Errorf("SanitizeFilename(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestRequestMaxSize(t *testing.T) {
+	if got, want := RequestMaxSize("submissions"), int64(20<<20+formMaxSize); got != want {
+		t.Errorf("RequestMaxSize(submissions) = %d, want %d", got, want)
+	}
+	// request uploading to several folders gets largest limit
+	if got, want := RequestMaxSize("assignments", "lesson_pro"), int64(100<<20+formMaxSize); got != want {
+		t.Errorf("RequestMaxSize(assignments, lesson_pro) = %d, want %d", got, want)
+	}
+}
@@ -15,18 +15,28 @@ import (
 	apiutils "github.com/mekdep/server/internal/api/utils"
 	"github.com/mekdep/server/internal/app"
 	"github.com/mekdep/server/internal/cmd"
+	"github.com/mekdep/server/internal/models"
 	"github.com/mekdep/server/internal/store"
 	"github.com/mekdep/server/internal/store/pgx"
 	"github.com/mekdep/server/internal/utils"
+	"github.com/mekdep/server/internal/utils/mail"
+	"github.com/mekdep/server/internal/utils/push"
+	"github.com/mekdep/server/internal/utils/storage"
 	"go.elastic.co/apm/module/apmgin/v2"
 )
 
 func main() {
 	defer utils.InitLogs().Close()
 	config.LoadConfig()
+	storage.Init()
+	push.Init()
+	mail.Init()
 	defer store.Init().(*pgx.PgxStore).Close()
 	cmd.Init()
 	app.Init()
+	if err := app.RolesSeed(); err != nil {
+		utils.LoggerDesc("In RolesSeed").Error(err)
+	}
 
 	if config.Conf.AppEnvIsProd {
 		gin.SetMode(gin.ReleaseMode)
@@ -43,11 +53,19 @@ func main() {
 		// MaxAge:           12 * time.Hour,
 	}))
 	DebugInit(routes)
+	if config.Conf.MetricsEnabled {
+		routes.Use(middleware.SetMetricsRequest)
+	}
 	routes.Use(middleware.SetLoggerRequest)
 	routes.Use(middleware.StartSession)
 	api.Routes(routes)
 
 	go runSchedules()
+	go app.BookProcessWorker()
+	go app.UserImportWorker()
+	go app.UserDataRequestWorker()
+	go app.PushWorker()
+	go app.CurriculumPlanApplyWorker()
 	port := "8000"
 	if config.Conf.HttpPort != "" {
 		port = config.Conf.HttpPort
@@ -104,15 +122,63 @@ func runSchedules() {
 }
 
 func runEveryMinute() {
-	now := time.Now().In(config.RequestLocation)
+	// jobs are scheduled by clock of deployment timezone
+	now := time.Now().In(models.DefaultLocation())
 	// utils.LoggerDesc("Running runEveryMinute").Info()
 	// SendDailySms
 	isEvening := now.Hour() == 18 && now.Minute() == 30
 	isAfternoon := now.Hour() == 13 && now.Minute() == 50
 	isMidnight := now.Hour() == 00 && now.Minute() == 00
+	isMorning := now.Hour() == 9 && now.Minute() == 0
+	go func() {
+		err := app.PushRetryPending()
+		if err != nil {
+			utils.LoggerDesc("In PushRetryPending").Error(err)
+		}
+	}()
+	go func() {
+		err := app.NotificationsSendScheduled()
+		if err != nil {
+			utils.LoggerDesc("In NotificationsSendScheduled").Error(err)
+		}
+	}()
+	go func() {
+		err := app.SmsSendScheduled()
+		if err != nil {
+			utils.LoggerDesc("In SmsSendScheduled").Error(err)
+		}
+	}()
+	if now.Minute()%5 == 0 {
+		go func() {
+			err := app.BookProcessPending()
+			if err != nil {
+				utils.LoggerDesc("In BookProcessPending").Error(err)
+			}
+		}()
+		go func() {
+			err := app.UserImportPending()
+			if err != nil {
+				utils.LoggerDesc("In UserImportPending").Error(err)
+			}
+		}()
+		go func() {
+			err := app.UserDataRequestPending()
+			if err != nil {
+				utils.LoggerDesc("In UserDataRequestPending").Error(err)
+			}
+		}()
+		go func() {
+			defer utils.MetricsJobTimer("ContactItemsEscalate").ObserveDuration()
+			err := app.ContactItemsEscalate()
+			if err != nil {
+				utils.LoggerDesc("In ContactItemsEscalate").Error(err)
+			}
+		}()
+	}
 	if isEvening || isAfternoon {
 		utils.LoggerDesc("Running SendDailySms... Last ran: " + cmd.SendDailySmsLastRun.Format(time.DateTime)).Info()
 		go func() {
+			defer utils.MetricsJobTimer("SendDailySms").ObserveDuration()
 			err := cmd.SendDailySms(isEvening)
 			if err != nil {
 				utils.LoggerDesc("In SendDailySms").Error(err)
@@ -132,21 +198,51 @@ func runEveryMinute() {
 	if isEvening {
 		utils.LoggerDesc("Running SendTariffEndsSmsAll... Last ran: " + cmd.SendDailySmsLastRun.Format(time.DateTime)).Info()
 		go func() {
+			defer utils.MetricsJobTimer("SendTariffEndsSmsAll").ObserveDuration()
 			err := cmd.SendTariffEndsSmsAll(isEvening)
 			if err != nil {
 				utils.LoggerDesc("In SendTariffEndsSmsAll").Error(err)
 			}
 		}()
 	}
+	if isMorning {
+		utils.LoggerDesc("Running MailReportDeadlineReminders...").Info()
+		go func() {
+			defer utils.MetricsJobTimer("MailReportDeadlineReminders").ObserveDuration()
+			err := app.MailReportDeadlineReminders()
+			if err != nil {
+				utils.LoggerDesc("In MailReportDeadlineReminders").Error(err)
+			}
+		}()
+		go func() {
+			defer utils.MetricsJobTimer("SchoolTransfersExpire").ObserveDuration()
+			err := app.SchoolTransfersExpire()
+			if err != nil {
+				utils.LoggerDesc("In SchoolTransfersExpire").Error(err)
+			}
+		}()
+	}
+	if isMorning && now.Weekday() == time.Monday {
+		utils.LoggerDesc("Running MailWeeklyDigest...").Info()
+		go func() {
+			defer utils.MetricsJobTimer("MailWeeklyDigest").ObserveDuration()
+			err := app.MailWeeklyDigest()
+			if err != nil {
+				utils.LoggerDesc("In MailWeeklyDigest").Error(err)
+			}
+		}()
+	}
 	if isMidnight {
 		utils.LoggerDesc("Running UpdatePeriodGrades...").Info()
 		go func() {
+			defer utils.MetricsJobTimer("UpdatePeriodGrades").ObserveDuration()
 			err := cmd.UpdatePeriodGrades(&apiutils.Session{}, 0, "", "", false)
 			if err != nil {
 				utils.LoggerDesc("In SendTariffEndsSmsAll").Error(err)
 			}
 		}()
 		go func() {
+			defer utils.MetricsJobTimer("UpdatePaymentStatus").ObserveDuration()
 			err := cmd.UpdatePaymentStatus(&apiutils.Session{})
 			if err != nil {
 				utils.LoggerDesc("In SendTariffEndsSmsAll").Error(err)